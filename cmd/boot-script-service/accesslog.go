@@ -0,0 +1,248 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// HTTP access log.
+//
+// --access-log sends one line per request to "stdout" or to a file, apart
+// from the application log on stderr.  Lines are in Common Log Format or,
+// with --access-log-format=json, JSON.  Boot scripts and cloud-init data are
+// requested by every node on every boot, so those routes only log one
+// request in --access-log-sample; errors are always logged.  A log file is
+// rotated when it reaches --access-log-max-size megabytes, keeping
+// --access-log-keep old files as <file>.1, <file>.2 and so on.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	accessLogCommon = "common"
+	accessLogJSON   = "json"
+)
+
+var (
+	accessLog        = ""
+	accessLogFormat  = accessLogCommon
+	accessLogSample  = uint(1)
+	accessLogMaxSize = uint(100) // megabytes
+	accessLogKeep    = uint(5)
+
+	accessLogSampledRoutes = []string{baseEndpoint + "/bootscript",
+		metaDataRoute, userDataRoute, phoneHomeRoute}
+)
+
+type accessEntry struct {
+	Time     string `json:"time"`
+	Remote   string `json:"remote"`
+	User     string `json:"user"`
+	Method   string `json:"method"`
+	URI      string `json:"uri"`
+	Proto    string `json:"proto"`
+	Status   int    `json:"status"`
+	Bytes    int64  `json:"bytes"`
+	Duration int64  `json:"duration-us"`
+	Agent    string `json:"agent,omitempty"`
+}
+
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *accessRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *accessRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *accessRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// A log file that is renamed aside once it grows past max bytes.
+type rotatingFile struct {
+	mutex sync.Mutex
+	path  string
+	max   int64
+	keep  int
+	file  *os.File
+	size  int64
+}
+
+func openRotatingFile(path string, max int64, keep int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, max: max, keep: keep}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.file, rf.size = f, st.Size()
+	return nil
+}
+
+func (rf *rotatingFile) rotate() error {
+	rf.file.Close()
+	for i := rf.keep - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	if rf.keep > 0 {
+		os.Rename(rf.path, rf.path+".1")
+	} else {
+		os.Remove(rf.path)
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) Write(b []byte) (int, error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.max > 0 && rf.size > 0 && rf.size+int64(len(b)) > rf.max {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(b)
+	rf.size += int64(n)
+	return n, err
+}
+
+type accessLogger struct {
+	inner   http.Handler
+	out     io.Writer
+	format  string
+	sample  uint64
+	counter atomic.Uint64
+	mutex   sync.Mutex
+}
+
+func accessLogSampled(path string) bool {
+	for _, r := range accessLogSampledRoutes {
+		if path == r || strings.HasPrefix(path, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (al *accessLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &accessRecorder{ResponseWriter: w}
+	al.inner.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if al.sample > 1 && rec.status < http.StatusBadRequest && accessLogSampled(r.URL.Path) {
+		if al.counter.Add(1)%al.sample != 1 {
+			return
+		}
+	}
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	e := accessEntry{
+		Time:     start.UTC().Format(time.RFC3339Nano),
+		Remote:   remote,
+		User:     requestSubject(r),
+		Method:   r.Method,
+		URI:      r.RequestURI,
+		Proto:    r.Proto,
+		Status:   rec.status,
+		Bytes:    rec.bytes,
+		Duration: time.Since(start).Microseconds(),
+		Agent:    r.UserAgent(),
+	}
+	var line []byte
+	if al.format == accessLogJSON {
+		line, _ = json.Marshal(e)
+		line = append(line, '\n')
+	} else {
+		user := e.User
+		if user == unknownSubject {
+			user = "-"
+		}
+		line = []byte(fmt.Sprintf("%s - %s [%s] %q %d %d\n", e.Remote, user,
+			start.Format("02/Jan/2006:15:04:05 -0700"),
+			e.Method+" "+e.URI+" "+e.Proto, e.Status, e.Bytes))
+	}
+	al.mutex.Lock()
+	al.out.Write(line)
+	al.mutex.Unlock()
+}
+
+// Function accessLogInit() wraps inner with the access log, if one is
+// configured.
+func accessLogInit(inner http.Handler) (http.Handler, error) {
+	if accessLog == "" {
+		return inner, nil
+	}
+	if accessLogFormat != accessLogCommon && accessLogFormat != accessLogJSON {
+		return nil, fmt.Errorf("Unknown access log format '%s'", accessLogFormat)
+	}
+	al := &accessLogger{inner: inner, format: accessLogFormat, sample: uint64(accessLogSample)}
+	if accessLog == "stdout" {
+		al.out = os.Stdout
+	} else {
+		rf, err := openRotatingFile(accessLog, int64(accessLogMaxSize)<<20, int(accessLogKeep))
+		if err != nil {
+			return nil, err
+		}
+		al.out = rf
+	}
+	return al, nil
+}
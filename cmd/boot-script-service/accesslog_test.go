@@ -0,0 +1,97 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "no", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("hello"))
+	})
+	al := &accessLogger{inner: inner, out: &buf, format: accessLogJSON, sample: 10}
+
+	for i := 0; i < 20; i++ {
+		al.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boot/v1/bootscript?mac=1", nil))
+	}
+	al.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boot/v1/bootscript?fail=1", nil))
+	al.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boot/v1/bootparameters", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 2 sampled, 1 error and 1 other lines, got %d:\n%s", len(lines), buf.String())
+	}
+	var e accessEntry
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatalf("Bad JSON line %s: %s", lines[0], err)
+	}
+	if e.Status != http.StatusOK || e.Bytes != 5 || e.URI != "/boot/v1/bootscript?mac=1" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &e); err != nil || e.Status != http.StatusNotFound {
+		t.Errorf("Error request was not logged: %s", lines[2])
+	}
+
+	buf.Reset()
+	al.format = accessLogCommon
+	al.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boot/v1/hosts", nil))
+	if !strings.HasSuffix(buf.String(), ` "GET /boot/v1/hosts HTTP/1.1" 200 5`+"\n") ||
+		!strings.HasPrefix(buf.String(), "192.0.2.1 - - [") {
+		t.Errorf("Unexpected common log line %q", buf.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	rf, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile failed: %s", err)
+	}
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err = rf.Write([]byte(s)); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+	for suffix, want := range map[string]string{"": "dddddd\n", ".1": "cccccc\n", ".2": "bbbbbb\n"} {
+		data, err := os.ReadFile(path + suffix)
+		if err != nil || string(data) != want {
+			t.Errorf("%s%s: expected %q, got %q (%v)", path, suffix, want, data, err)
+		}
+	}
+	if _, err = os.Stat(path + ".3"); err == nil {
+		t.Errorf("Kept more rotated files than asked")
+	}
+}
@@ -0,0 +1,216 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Endpoint access roll-ups.
+//
+// The endpoint access records only keep the last access of a node to each
+// endpoint.  For trends, every access is also counted per UTC day, node and
+// endpoint.  The counts are collected in memory and added to the datastore
+// every --analytics-interval seconds, under
+// /analytics/access/<day>/<node>/<endpoint>, with a test-and-set so that
+// several BSS instances add up.  Days older than --analytics-retention are
+// deleted, so the storage is bounded by the number of nodes and endpoints.
+// GET /boot/v1/analytics/access returns the counts.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	analyticsAccessPfx = "/analytics/access/"
+	analyticsDay       = "2006-01-02"
+)
+
+var (
+	analyticsInterval  = uint(300) // seconds, 0 disables the counts
+	analyticsRetention = uint(400) // days
+)
+
+type accessCountKey struct {
+	day, name string
+	endpoint  bssTypes.EndpointType
+}
+
+var accessCounts = struct {
+	sync.Mutex
+	pending map[accessCountKey]int64
+}{pending: make(map[accessCountKey]int64)}
+
+func (k accessCountKey) key() string {
+	return fmt.Sprintf("%s%s/%s/%s", analyticsAccessPfx, k.day, k.name, k.endpoint)
+}
+
+func countAccess(name string, endpoint bssTypes.EndpointType, when time.Time) {
+	if analyticsInterval == 0 {
+		return
+	}
+	k := accessCountKey{when.UTC().Format(analyticsDay), name, endpoint}
+	accessCounts.Lock()
+	accessCounts.pending[k]++
+	accessCounts.Unlock()
+}
+
+// Function addCounter() atomically adds n to the counter stored at key.
+func addCounter(key string, n int64) error {
+	for i := 0; i < 100; i++ {
+		val, exists, err := kvstore.Get(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// Stored as is unless another instance created it first.
+			stored, err := storeIfMissing(key, strconv.FormatInt(n, 10))
+			if err != nil || stored {
+				return err
+			}
+			continue
+		}
+		cur, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		ok, err := kvstore.TAS(key, val, strconv.FormatInt(cur+n, 10))
+		if err != nil || ok {
+			return err
+		}
+	}
+	return fmt.Errorf("Too much contention on %s", key)
+}
+
+// Function flushAccessCounts() adds the counts collected since the last
+// flush to the datastore.  Counts that cannot be stored are kept for the
+// next flush.
+func flushAccessCounts() {
+	accessCounts.Lock()
+	pending := accessCounts.pending
+	accessCounts.pending = make(map[accessCountKey]int64)
+	accessCounts.Unlock()
+	failed := 0
+	for k, n := range pending {
+		if err := addCounter(k.key(), n); err != nil {
+			debugf("Cannot store access count %s: %s\n", k.key(), err)
+			accessCounts.Lock()
+			accessCounts.pending[k] += n
+			accessCounts.Unlock()
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Printf("WARNING: %d access counts could not be stored, retrying later", failed)
+	}
+}
+
+// Function pruneAccessCounts() deletes the counts of the days before the
+// retention period.
+func pruneAccessCounts(now time.Time) (pruned int) {
+	cutoff := now.UTC().AddDate(0, 0, -int(analyticsRetention)).Format(analyticsDay)
+	kvl, err := kvstore.GetRange(analyticsAccessPfx+keyMin, analyticsAccessPfx+cutoff)
+	if err != nil {
+		log.Printf("Cannot read access counts to prune: %s", err)
+		return 0
+	}
+	for _, kv := range kvl {
+		if kv.Key >= analyticsAccessPfx+cutoff {
+			continue
+		}
+		if err = kvstore.Delete(kv.Key); err != nil {
+			log.Printf("Cannot delete access count %s: %s", kv.Key, err)
+			continue
+		}
+		pruned++
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d access counts from before %s", pruned, cutoff)
+	}
+	return pruned
+}
+
+func analyticsLoop() {
+	for {
+		time.Sleep(time.Duration(analyticsInterval) * time.Second)
+		flushAccessCounts()
+		pruneAccessCounts(time.Now())
+	}
+}
+
+func AnalyticsAccessGet(w http.ResponseWriter, r *http.Request) {
+	debugf("AnalyticsAccessGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	name := r.Form.Get("name")
+	endpoint := bssTypes.EndpointType(r.Form.Get("endpoint"))
+	from, to := r.Form.Get("from"), r.Form.Get("to")
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse(analyticsDay, d); d != "" && err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad day '%s', expected YYYY-MM-DD", d))
+			return
+		}
+	}
+	start, end := analyticsAccessPfx+keyMin, analyticsAccessPfx+keyMax
+	if from != "" {
+		start = analyticsAccessPfx + from
+	}
+	if to != "" {
+		end = analyticsAccessPfx + to + "/" + keyMax
+	}
+	kvl, err := kvstore.GetRange(start, end)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve access counts: %s", err))
+		return
+	}
+	results := []bssTypes.AccessCount{}
+	for _, kv := range kvl {
+		parts := strings.Split(strings.TrimPrefix(kv.Key, analyticsAccessPfx), "/")
+		if len(parts) != 3 {
+			continue
+		}
+		c := bssTypes.AccessCount{Day: parts[0], Name: parts[1], Endpoint: bssTypes.EndpointType(parts[2])}
+		if (name != "" && c.Name != name) || (endpoint != "" && c.Endpoint != endpoint) {
+			continue
+		}
+		if c.Count, err = strconv.ParseInt(kv.Value, 10, 64); err != nil {
+			log.Printf("Skipping bad access count %s: %s", kv.Key, err)
+			continue
+		}
+		results = append(results, c)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(results)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,89 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestAccessCounts(t *testing.T) {
+	defer func() {
+		if kvl, err := kvstore.GetRange(analyticsAccessPfx+keyMin, analyticsAccessPfx+keyMax); err == nil {
+			for _, kv := range kvl {
+				kvstore.Delete(kv.Key)
+			}
+		}
+	}()
+	// Drop what other tests counted.
+	accessCounts.Lock()
+	accessCounts.pending = make(map[accessCountKey]int64)
+	accessCounts.Unlock()
+	day := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	countAccess("x9c0s0b0n0", bssTypes.EndpointTypeBootscript, day)
+	countAccess("x9c0s0b0n0", bssTypes.EndpointTypeBootscript, day)
+	countAccess("x9c0s0b0n0", bssTypes.EndpointTypeUserData, day)
+	countAccess("x9c0s1b0n0", bssTypes.EndpointTypeBootscript, day.Add(time.Hour))
+	flushAccessCounts()
+	// A later flush adds to the stored count.
+	countAccess("x9c0s0b0n0", bssTypes.EndpointTypeBootscript, day)
+	flushAccessCounts()
+
+	get := func(query string) (int, []bssTypes.AccessCount) {
+		w := httptest.NewRecorder()
+		analyticsAccess(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/analytics/access"+query, nil))
+		var counts []bssTypes.AccessCount
+		json.Unmarshal(w.Body.Bytes(), &counts)
+		return w.Code, counts
+	}
+	code, counts := get("?name=x9c0s0b0n0&endpoint=bootscript")
+	if code != http.StatusOK || len(counts) != 1 || counts[0] !=
+		(bssTypes.AccessCount{Day: "2026-03-01", Name: "x9c0s0b0n0", Endpoint: "bootscript", Count: 3}) {
+		t.Errorf("Bootscript count of x9c0s0b0n0: %d %v", code, counts)
+	}
+	// Days are UTC, the hour later is the next day.
+	if _, counts = get("?from=2026-03-02&to=2026-03-02"); len(counts) != 1 || counts[0].Name != "x9c0s1b0n0" {
+		t.Errorf("Counts of 2026-03-02: %v", counts)
+	}
+	if _, counts = get("?to=2026-03-01"); len(counts) != 2 {
+		t.Errorf("Counts up to 2026-03-01: %v", counts)
+	}
+	if code, _ = get("?from=March"); code != http.StatusBadRequest {
+		t.Errorf("Bad from= returned %d", code)
+	}
+
+	savedRetention := analyticsRetention
+	defer func() { analyticsRetention = savedRetention }()
+	analyticsRetention = 1
+	if n := pruneAccessCounts(day.AddDate(0, 0, 2)); n != 2 {
+		t.Errorf("Pruned %d counts, want the 2 of 2026-03-01", n)
+	}
+	if _, counts = get("?to=2026-03-31"); len(counts) != 1 || counts[0].Day != "2026-03-02" {
+		t.Errorf("Counts after pruning: %v", counts)
+	}
+}
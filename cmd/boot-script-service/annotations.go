@@ -0,0 +1,168 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Maintenance notes attached to hosts.
+//
+// Annotations are stored per host name in the KV store as a JSON list.  They
+// have no effect on the generated boot script, they are purely informational.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const annotationsPfx = "/annotations/"
+
+func getAnnotations(name string) ([]bssTypes.Annotation, error) {
+	var notes []bssTypes.Annotation
+	val, exists, err := kvstore.Get(annotationsPfx + name)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &notes)
+	}
+	return notes, err
+}
+
+func addAnnotation(note bssTypes.Annotation) error {
+	kvMutex.Lock()
+	defer kvMutex.Unlock()
+	notes, err := getAnnotations(note.Name)
+	if err != nil {
+		return err
+	}
+	notes = append(notes, note)
+	return storeData(annotationsPfx+note.Name, notes)
+}
+
+func removeAnnotations(name string) error {
+	return kvstore.Delete(annotationsPfx + name)
+}
+
+// Function annotateResults() adds any annotations to the boot parameter items
+// returned in a verbose GET response.
+func annotateResults(results []bssTypes.BootParams) {
+	for i := range results {
+		for _, h := range results[i].Hosts {
+			notes, err := getAnnotations(h)
+			if err != nil {
+				log.Printf("Failed to retrieve annotations for %s: %s", h, err)
+				continue
+			}
+			results[i].Annotations = append(results[i].Annotations, notes...)
+		}
+	}
+}
+
+func isVerbose(r *http.Request) bool {
+	v := strings.ToLower(strings.Join(r.Form["verbose"], ""))
+	return v == "true" || v == "1" || v == "yes"
+}
+
+func AnnotationsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("AnnotationsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	results := []bssTypes.Annotation{}
+	names := r.Form["name"]
+	if len(names) == 0 {
+		kvl, err := kvstore.GetRange(annotationsPfx+keyMin, annotationsPfx+keyMax)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to retrieve annotations: %s", err))
+			return
+		}
+		for _, kv := range kvl {
+			var notes []bssTypes.Annotation
+			if err = json.Unmarshal([]byte(kv.Value), &notes); err == nil {
+				results = append(results, notes...)
+			}
+		}
+	} else {
+		for _, n := range strings.Split(strings.Join(names, ","), ",") {
+			notes, err := getAnnotations(n)
+			if err != nil {
+				base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+					fmt.Sprintf("Failed to retrieve annotations for %s: %s", n, err))
+				return
+			}
+			results = append(results, notes...)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(results)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func AnnotationsPost(w http.ResponseWriter, r *http.Request) {
+	debugf("AnnotationsPost(): Received request %v\n", r.URL)
+	var note bssTypes.Annotation
+	err := json.NewDecoder(r.Body).Decode(&note)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if note.Name == "" || note.Note == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: both name and note are required")
+		return
+	}
+	note.Who = requestSubject(r)
+	note.When = time.Now().Unix()
+	if err = addAnnotation(note); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store annotation: %s", err))
+		return
+	}
+	log.Printf("/annotations POST: %s by %s: %s", note.Name, note.Who, note.Note)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(note)
+}
+
+func AnnotationsDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("AnnotationsDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	if name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	if err := removeAnnotations(name); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove annotations for %s: %s", name, err))
+		return
+	}
+	log.Printf("/annotations DELETE: %s", name)
+	w.WriteHeader(http.StatusOK)
+}
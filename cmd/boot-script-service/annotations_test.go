@@ -0,0 +1,75 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestAnnotations(t *testing.T) {
+	const host = "x0c0s9b0n0"
+	// Subject "jdoe" in an unsigned token
+	const token = "Bearer eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOiJqZG9lIn0.sig"
+
+	body := bytes.NewBufferString(`{"name":"` + host + `","note":"pinned to old kernel","who":"mallory"}`)
+	req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/annotations", body)
+	req.Header.Set("Authorization", token)
+	rr := httptest.NewRecorder()
+	annotations(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST annotation returned %d, expected %d: %s", rr.Code, http.StatusCreated, rr.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, baseEndpoint+"/annotations?name="+host, nil)
+	rr = httptest.NewRecorder()
+	annotations(rr, req)
+	var notes []bssTypes.Annotation
+	if err := json.Unmarshal(rr.Body.Bytes(), &notes); err != nil {
+		t.Fatalf("GET annotations decode failed: %s", err)
+	}
+	if len(notes) != 1 || notes[0].Who != "jdoe" || notes[0].Note != "pinned to old kernel" || notes[0].When == 0 {
+		t.Errorf("GET annotations returned unexpected data: %v", notes)
+	}
+
+	results := []bssTypes.BootParams{{Hosts: []string{host}}}
+	annotateResults(results)
+	if len(results[0].Annotations) != 1 {
+		t.Errorf("annotateResults expected 1 annotation, got %v", results[0].Annotations)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, baseEndpoint+"/annotations?name="+host, nil)
+	rr = httptest.NewRecorder()
+	annotations(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("DELETE annotations returned %d, expected %d", rr.Code, http.StatusOK)
+	}
+	if notes, _ = getAnnotations(host); len(notes) != 0 {
+		t.Errorf("Annotations still present after DELETE: %v", notes)
+	}
+}
@@ -0,0 +1,324 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Staged changes with approval.
+//
+// A change to the Default or Global tag reaches every node using it at its
+// next boot.  Tags listed in BSS_APPROVAL_TAGS are changed in two steps:
+// a bootparameters request naming one of them is not applied but kept as a
+// proposal, answered with 202 and the proposal ID, and a second identity
+// applies it with POST /boot/v1/proposals?id=<id>&action=approve.  Anyone
+// can drop it with action=reject.  The whole request is staged, including
+// any other hosts it names.  PUT and DELETE of /entries/<tag> are staged
+// the same way; imports, node merges and namespace copies that would
+// change such a tag are refused.
+//
+// A proposal records digests of the entries it changes.  If one of them
+// changed in the meantime the approval fails and the change has to be
+// proposed again against the current configuration.  An approval or
+// rejection first claims the proposal with a test-and-set, so that of two
+// concurrent ones only one is carried out.
+//
+// The identities are the subjects of the bearer tokens.  BSS only checks
+// token signatures if it is configured to, see identity.go; otherwise the
+// API gateway must verify the tokens, or anyone can approve their own
+// change by presenting a token with another subject.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-bss/pkg/idgen"
+)
+
+const (
+	proposalsEndpoint = baseEndpoint + "/proposals"
+	proposalsPfx      = "/proposals/"
+)
+
+var approvalTags []string
+
+type proposalRecord struct {
+	bssTypes.Proposal
+	Base map[string]string `json:"base"`
+
+	// Who is approving or rejecting the proposal right now, and since when.
+	ClaimedBy string `json:"claimed-by,omitempty"`
+	ClaimedAt int64  `json:"claimed-at,omitempty"`
+}
+
+// Seconds after which the claim of an instance that failed while deciding a
+// proposal is ignored.
+const proposalClaimTTL = 60
+
+func needsApproval(name string) bool {
+	for _, t := range approvalTags {
+		if t != "" && strings.EqualFold(name, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function approvalTargets() returns the tags of bp that need approval.
+func approvalTargets(bp bssTypes.BootParams) []string {
+	var ret []string
+	for _, h := range bp.Hosts {
+		if needsApproval(h) {
+			ret = append(ret, h)
+		}
+	}
+	return ret
+}
+
+// Function refuseUnstaged() refuses a request that would change tags that
+// need approval but cannot be kept as a proposal, such as a node merge or a
+// namespace copy.  It returns true if it did, having sent the response.
+func refuseUnstaged(w http.ResponseWriter, names []string) bool {
+	var tags []string
+	for _, n := range names {
+		if needsApproval(n) {
+			tags = append(tags, n)
+		}
+	}
+	if len(tags) == 0 {
+		return false
+	}
+	base.SendProblemDetailsGeneric(w, http.StatusConflict,
+		fmt.Sprintf("Conflict: changes to %s need approval, propose them through %s/bootparameters",
+			strings.Join(tags, ", "), baseEndpoint))
+	return true
+}
+
+func entryDigest(name string) string {
+	val, exists, err := kvstore.Get(paramsPfx + name)
+	if err != nil {
+		return "(error)"
+	}
+	return valueDigest(val, exists)
+}
+
+// Function stageChange() keeps a bootparameters request changing a tag that
+// needs approval as a proposal.  It returns true if it did, having sent the
+// response.
+func stageChange(w http.ResponseWriter, r *http.Request, bp bssTypes.BootParams, cascade string) bool {
+	tags := approvalTargets(bp)
+	if len(tags) == 0 {
+		return false
+	}
+	p := proposalRecord{
+		Proposal: bssTypes.Proposal{ID: idgen.NewULID(), Method: r.Method, Params: bp, Cascade: cascade,
+			ProposedBy: requestSubject(r), ProposedAt: time.Now().Unix()},
+		Base: make(map[string]string),
+	}
+	for _, t := range tags {
+		p.Base[t] = entryDigest(t)
+	}
+	if err := storeData(proposalsPfx+p.ID, p); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store proposal: %s", err))
+		return true
+	}
+	LogBootParameters(fmt.Sprintf("/bootparameters %s PROPOSED as %s by %s", r.Method, p.ID, p.ProposedBy), bp)
+	sendProposals(w, http.StatusAccepted, p.Proposal)
+	return true
+}
+
+func getProposal(id string) (proposalRecord, bool, error) {
+	var p proposalRecord
+	val, exists, err := kvstore.Get(proposalsPfx + id)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &p)
+	}
+	return p, exists, err
+}
+
+// Function claimProposal() marks the proposal id as being decided by who,
+// with a test-and-set so that only one of several concurrent approvals or
+// rejections gets it.  The returned function gives it up again.
+func claimProposal(id, who string) (proposalRecord, func(), int, error) {
+	key := proposalsPfx + id
+	var p proposalRecord
+	val, exists, err := kvstore.Get(key)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &p)
+	}
+	if err != nil || !exists {
+		return p, nil, http.StatusNotFound, fmt.Errorf("No proposal '%s'", id)
+	}
+	if p.ClaimedBy != "" && time.Now().Unix()-p.ClaimedAt < proposalClaimTTL {
+		return p, nil, http.StatusConflict, fmt.Errorf("proposal %s is being decided by %s", id, p.ClaimedBy)
+	}
+	claimed := p
+	claimed.ClaimedBy, claimed.ClaimedAt = who, time.Now().Unix()
+	data, err := json.Marshal(claimed)
+	ok := false
+	if err == nil {
+		ok, err = kvstore.TAS(key, val, string(data))
+	}
+	if err != nil {
+		return p, nil, http.StatusInternalServerError, err
+	}
+	if !ok {
+		return p, nil, http.StatusConflict, fmt.Errorf("proposal %s is being decided by someone else", id)
+	}
+	release := func() {
+		if _, err := kvstore.TAS(key, string(data), val); err != nil {
+			log.Printf("Failed to release proposal %s: %s", id, err)
+		}
+	}
+	return p, release, http.StatusOK, nil
+}
+
+func getProposals() ([]bssTypes.Proposal, error) {
+	ret := []bssTypes.Proposal{}
+	kvl, err := kvstore.GetRange(proposalsPfx+keyMin, proposalsPfx+keyMax)
+	if err != nil {
+		return ret, err
+	}
+	for _, kv := range kvl {
+		var p proposalRecord
+		if err = json.Unmarshal([]byte(kv.Value), &p); err != nil {
+			log.Printf("Bad proposal at %s: %s", kv.Key, err)
+			continue
+		}
+		ret = append(ret, p.Proposal)
+	}
+	return ret, nil
+}
+
+// Function applyProposal() makes the staged request, returning the HTTP
+// status for a failure.
+func applyProposal(p proposalRecord, approver string) (int, error) {
+	for name, digest := range p.Base {
+		if entryDigest(name) != digest {
+			return http.StatusConflict, fmt.Errorf("%s changed since the proposal was made", name)
+		}
+	}
+	who := p.ProposedBy + ", approved by " + approver
+	var err error
+	switch p.Method {
+	case http.MethodPut:
+		err, _ = Store(p.Params, who)
+	case http.MethodPost:
+		err, _ = StoreNew(p.Params, who)
+	case http.MethodPatch:
+		err = Update(p.Params, who)
+	case http.MethodDelete:
+		err = Remove(p.Params, p.Cascade)
+	default:
+		err = fmt.Errorf("unknown method %s", p.Method)
+	}
+	var conflict DeleteConflict
+	if errors.As(err, &conflict) {
+		return http.StatusConflict, err
+	} else if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+func sendProposals(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func ProposalsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ProposalsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	if id := strings.Join(r.Form["id"], ""); id != "" {
+		p, exists, err := getProposal(id)
+		if err != nil || !exists {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No proposal '%s'", id))
+			return
+		}
+		sendProposals(w, http.StatusOK, p.Proposal)
+		return
+	}
+	proposals, err := getProposals()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve proposals: %s", err))
+		return
+	}
+	sendProposals(w, http.StatusOK, proposals)
+}
+
+// POST with action=approve applies a proposal, action=reject drops it.
+func ProposalsPost(w http.ResponseWriter, r *http.Request) {
+	debugf("ProposalsPost(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	id := strings.Join(r.Form["id"], "")
+	action := strings.Join(r.Form["action"], "")
+	if id == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need an id= parameter")
+		return
+	}
+	if action != "approve" && action != "reject" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: unknown action '%s'", action))
+		return
+	}
+	who := requestSubject(r)
+	p, release, status, err := claimProposal(id, who)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, status, fmt.Sprintf("Cannot %s proposal %s: %s", action, id, err))
+		return
+	}
+	if action == "approve" {
+		if who == unknownSubject || strings.EqualFold(who, p.ProposedBy) {
+			release()
+			base.SendProblemDetailsGeneric(w, http.StatusForbidden,
+				fmt.Sprintf("Forbidden: proposal %s must be approved by an identity other than '%s'", id, p.ProposedBy))
+			return
+		}
+		if status, err := applyProposal(p, who); err != nil {
+			release()
+			base.SendProblemDetailsGeneric(w, status,
+				fmt.Sprintf("Cannot apply proposal %s: %s", id, err))
+			return
+		}
+	}
+	if err = kvstore.Delete(proposalsPfx + id); err != nil {
+		log.Printf("Failed to remove proposal %s: %s", id, err)
+	}
+	outcome := "APPROVED"
+	if action == "reject" {
+		outcome = "REJECTED"
+	}
+	LogBootParameters(fmt.Sprintf("/bootparameters %s %s %s by %s", p.Method, id, outcome, who), p.Params)
+	sendProposals(w, http.StatusOK, p.Proposal)
+}
@@ -0,0 +1,151 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestProposals(t *testing.T) {
+	defer func(tags []string) {
+		approvalTags = tags
+		kvstore.Delete(paramsPfx + GlobalTag)
+		for _, p := range mustProposals(t) {
+			kvstore.Delete(proposalsPfx + p.ID)
+		}
+		recs, _ := getAuditLog(0, -1)
+		for _, rec := range recs {
+			kvstore.Delete(auditLogPfx + rec.ID)
+		}
+	}(approvalTags)
+	approvalTags = []string{GlobalTag}
+	alice := testToken(`{"sub":"alice"}`)
+	bob := testToken(`{"sub":"bob"}`)
+	send := func(method, target, body, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		req.Header.Set(protectOverrideHeader, "true")
+		w := httptest.NewRecorder()
+		if strings.HasPrefix(target, baseEndpoint+"/proposals") {
+			auditLogged(proposals)(w, req)
+		} else if strings.HasPrefix(target, entriesEndpoint) {
+			entries(w, req)
+		} else {
+			bootParameters(w, req)
+		}
+		return w
+	}
+
+	w := send(http.MethodPut, baseEndpoint+"/bootparameters", `{"hosts":["Global"],"params":"quiet"}`, alice)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("PUT of Global returned %d: %s", w.Code, w.Body.String())
+	}
+	var p bssTypes.Proposal
+	json.Unmarshal(w.Body.Bytes(), &p)
+	if p.ID == "" || p.Method != http.MethodPut || p.ProposedBy != "alice" {
+		t.Errorf("Unexpected proposal %+v", p)
+	}
+	if _, err := lookupHost(GlobalTag); err == nil {
+		t.Errorf("Global was changed before approval")
+	}
+	if l := mustProposals(t); len(l) != 1 || l[0].ID != p.ID {
+		t.Errorf("Unexpected pending proposals %+v", l)
+	}
+	if w = send(http.MethodPost, baseEndpoint+"/proposals?action=approve&id="+p.ID, "", alice); w.Code != http.StatusForbidden {
+		t.Errorf("Self approval returned %d", w.Code)
+	}
+	_, release, _, err := claimProposal(p.ID, "carol")
+	if err != nil {
+		t.Fatalf("Claim failed: %s", err)
+	}
+	if w = send(http.MethodPost, baseEndpoint+"/proposals?action=approve&id="+p.ID, "", bob); w.Code != http.StatusConflict {
+		t.Errorf("Approval of a claimed proposal returned %d", w.Code)
+	}
+	release()
+	if w = send(http.MethodPost, baseEndpoint+"/proposals?action=approve&id="+p.ID, "", bob); w.Code != http.StatusOK {
+		t.Fatalf("Approval returned %d: %s", w.Code, w.Body.String())
+	}
+	bds, err := lookupHost(GlobalTag)
+	if err != nil || bds.Params != "quiet" || bds.Provenance == nil ||
+		bds.Provenance.UpdatedBy != "alice, approved by bob" {
+		t.Errorf("Global not changed by approval: %+v, %v", bds, err)
+	}
+	if l := mustProposals(t); len(l) != 0 {
+		t.Errorf("Approved proposal still pending: %+v", l)
+	}
+	if recs, _ := getAuditLog(0, -1); len(recs) == 0 || recs[len(recs)-1].Subject != "bob" ||
+		strings.Join(recs[len(recs)-1].Names, " ") != GlobalTag || len(recs[len(recs)-1].Changes) != 1 {
+		t.Errorf("Approval not audited: %+v", recs)
+	}
+
+	// A proposal made stale by another change cannot be approved.
+	w = send(http.MethodPatch, baseEndpoint+"/bootparameters", `{"hosts":["Global"],"params":"debug"}`, alice)
+	json.Unmarshal(w.Body.Bytes(), &p)
+	w = send(http.MethodDelete, baseEndpoint+"/bootparameters", `{"hosts":["Global"]}`, bob)
+	var del bssTypes.Proposal
+	json.Unmarshal(w.Body.Bytes(), &del)
+	if w = send(http.MethodPost, baseEndpoint+"/proposals?action=approve&id="+del.ID, "", alice); w.Code != http.StatusOK {
+		t.Fatalf("Approval of delete returned %d: %s", w.Code, w.Body.String())
+	}
+	if w = send(http.MethodPost, baseEndpoint+"/proposals?action=approve&id="+p.ID, "", bob); w.Code != http.StatusConflict {
+		t.Errorf("Approval of a stale proposal returned %d", w.Code)
+	}
+	if w = send(http.MethodPost, baseEndpoint+"/proposals?action=reject&id="+p.ID, "", alice); w.Code != http.StatusOK {
+		t.Errorf("Reject returned %d", w.Code)
+	}
+	if w = send(http.MethodGet, baseEndpoint+"/proposals?id="+p.ID, "", alice); w.Code != http.StatusNotFound {
+		t.Errorf("Rejected proposal still found: %d", w.Code)
+	}
+
+	// /entries is staged as well, imports are refused.
+	if w = send(http.MethodPut, entriesEndpoint+"/Global", `{"params":"quiet"}`, alice); w.Code != http.StatusAccepted {
+		t.Errorf("PUT of /entries/Global returned %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := lookupHost(GlobalTag); err == nil {
+		t.Errorf("Global was changed through /entries before approval")
+	}
+	if bps, errs := validateImport([]importRow{{Xname: GlobalTag, Kernel: "http://s3/k", line: 2}}, true); len(bps) != 0 ||
+		len(errs) != 1 {
+		t.Errorf("Import of Global was not refused: %v %v", bps, errs)
+	}
+
+	// Other hosts are not staged.
+	if w = send(http.MethodPut, baseEndpoint+"/bootparameters", `{"hosts":["x0c0s8b0n0"],"params":"quiet"}`, alice); w.Code != http.StatusOK {
+		t.Errorf("PUT of a node returned %d", w.Code)
+	}
+	kvstore.Delete(paramsPfx + "x0c0s8b0n0")
+}
+
+func mustProposals(t *testing.T) []bssTypes.Proposal {
+	l, err := getProposals()
+	if err != nil {
+		t.Fatalf("getProposals failed: %s", err)
+	}
+	return l
+}
@@ -0,0 +1,240 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Approved images.
+//
+// Sites with change control can keep a registry of approved kernel and
+// initrd images under /boot/v1/approved-images, by URI or by digest (see
+// image_digest.go).  Boot parameters naming other images are still stored,
+// but the images are logged and listed with ?unapproved=true.  With
+// --approved-images-strict, boot scripts for them are refused instead, and
+// so are boot scripts that load any other file not approved by URI: the
+// wimboot and mboot files, the device tree and ACPI tables, and the initrd
+// overlays.  Those have no image entries, so they are not listed with
+// ?unapproved=true.  An empty registry approves nothing, so fill it before
+// turning strict mode on.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	approvedImagesEndpoint = baseEndpoint + "/approved-images"
+	approvedImagesPfx      = "/approved-images/"
+)
+
+var approvedImagesStrict = false
+
+// Function approvedImageKey() returns the key of an approval of a URI or a
+// digest.
+func approvedImageKey(s string) string {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return fmt.Sprintf("%s%x", approvedImagesPfx, h.Sum(nil))
+}
+
+func imageApproved(im ImageData) bool {
+	for _, s := range []string{im.Path, im.Digest} {
+		if s == "" {
+			continue
+		}
+		if _, exists, err := kvstore.Get(approvedImageKey(s)); err == nil && exists {
+			return true
+		}
+	}
+	return false
+}
+
+// Function flagUnapprovedImages() logs the images of new boot parameters that
+// are not approved.
+func flagUnapprovedImages(bp bssTypes.BootParams, kernelKey, initrdKey string) {
+	for imtype, key := range map[string]string{kernelImageType: kernelKey, initrdImageType: initrdKey} {
+		if key == "" {
+			continue
+		}
+		if im, ok := readImage(key); ok && !imageApproved(im) {
+			log.Printf("WARNING: %s %s for %v is not an approved image", imtype, im.Path, bp.Hosts)
+		}
+	}
+}
+
+// Function checkApprovedImages() returns an error for a boot script with an
+// image that is not approved in strict mode.
+func checkApprovedImages(bd BootData) error {
+	if !approvedImagesStrict {
+		return nil
+	}
+	type artifact struct {
+		what string
+		im   ImageData
+	}
+	all := []artifact{{kernelImageType, bd.Kernel}, {initrdImageType, bd.Initrd}, {"dtb", ImageData{Path: bd.DTB}}}
+	for _, f := range bd.Files {
+		all = append(all, artifact{"file", ImageData{Path: f.Path}})
+	}
+	for _, p := range bd.ACPI {
+		all = append(all, artifact{"acpi table", ImageData{Path: p}})
+	}
+	for _, p := range bd.Overlays {
+		all = append(all, artifact{"initrd overlay", ImageData{Path: p}})
+	}
+	for _, a := range all {
+		if a.im.Path != "" && !imageApproved(a.im) {
+			return fmt.Errorf("%s %s is not an approved image", a.what, a.im.Path)
+		}
+	}
+	return nil
+}
+
+func getApprovedImages() ([]bssTypes.ApprovedImage, error) {
+	kvl, err := kvstore.GetRange(approvedImagesPfx+keyMin, approvedImagesPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	ret := []bssTypes.ApprovedImage{}
+	for _, kv := range kvl {
+		var a bssTypes.ApprovedImage
+		if json.Unmarshal([]byte(kv.Value), &a) == nil {
+			ret = append(ret, a)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].URI+ret[i].Digest < ret[j].URI+ret[j].Digest })
+	return ret, nil
+}
+
+// Function unapprovedImages() returns the stored images that are not
+// approved.
+func unapprovedImages() []bssTypes.UnapprovedImage {
+	ret := []bssTypes.UnapprovedImage{}
+	for _, imtype := range []string{kernelImageType, initrdImageType} {
+		for _, im := range getImageInfo(imtype) {
+			if !imageApproved(im) {
+				ret = append(ret, bssTypes.UnapprovedImage{Path: im.Path, Type: imtype, Digest: im.Digest})
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Path < ret[j].Path })
+	return ret
+}
+
+func sendApprovedImages(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Function approvalSubject() returns the URI or digest an approval is about,
+// or sends an error response.
+func approvalSubject(w http.ResponseWriter, uri, digest string) (string, bool) {
+	if (uri == "") == (digest == "") {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request - Need either a URI or a digest")
+		return "", false
+	}
+	if digest != "" && !validDigest(digest) {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - Bad digest '%s', expected sha256:<hex> or etag:<etag>", digest))
+		return "", false
+	}
+	return uri + digest, true
+}
+
+func ApprovedImagesGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ApprovedImagesGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	if strings.Join(r.Form["unapproved"], "") == "true" {
+		sendApprovedImages(w, http.StatusOK, unapprovedImages())
+		return
+	}
+	approved, err := getApprovedImages()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read approved images: %s", err))
+		return
+	}
+	sendApprovedImages(w, http.StatusOK, approved)
+}
+
+func ApprovedImagesPut(w http.ResponseWriter, r *http.Request) {
+	debugf("ApprovedImagesPut(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var a bssTypes.ApprovedImage
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	subject, ok := approvalSubject(w, a.URI, a.Digest)
+	if !ok {
+		return
+	}
+	a.ApprovedBy, a.ApprovedAt = requestSubject(r), time.Now().Unix()
+	if err := storeData(approvedImageKey(subject), a); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot store the approval: %s", err))
+		return
+	}
+	log.Printf("Image %s approved by %s", subject, a.ApprovedBy)
+	sendApprovedImages(w, http.StatusOK, a)
+}
+
+func ApprovedImagesDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("ApprovedImagesDelete(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	subject, ok := approvalSubject(w, strings.Join(r.Form["uri"], ""), strings.Join(r.Form["digest"], ""))
+	if !ok {
+		return
+	}
+	key := approvedImageKey(subject)
+	if _, exists, _ := kvstore.Get(key); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s is not approved", subject))
+		return
+	}
+	if err := kvstore.Delete(key); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot delete the approval: %s", err))
+		return
+	}
+	log.Printf("Approval of image %s withdrawn by %s", subject, requestSubject(r))
+	w.WriteHeader(http.StatusNoContent)
+}
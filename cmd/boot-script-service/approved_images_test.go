@@ -0,0 +1,111 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestApprovedImages(t *testing.T) {
+	const node = "x0c0s3b0n0"
+	const kernel = "s3://boot-images/approved/kernel"
+	savedMode, savedStrict := s3SignerMode, approvedImagesStrict
+	s3SignerMode = s3SignerMock
+	defer func() {
+		s3SignerMode, approvedImagesStrict = savedMode, savedStrict
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(approvedImageKey(kernel))
+		kvstore.Delete(makeImageKey(kernelImageType, kernel))
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "console=ttyS0",
+		Kernel: kernel}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	call := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		approvedImages(w, r)
+		return w
+	}
+	boot := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+		return w
+	}
+
+	w := call(httptest.NewRequest(http.MethodGet, approvedImagesEndpoint+"?unapproved=true", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), kernel) {
+		t.Errorf("Expected %s to be unapproved: %d %s", kernel, w.Code, w.Body.String())
+	}
+	if w = boot(); !strings.Contains(w.Body.String(), "console=ttyS0") {
+		t.Errorf("Unapproved images should be served without strict mode:\n%s", w.Body.String())
+	}
+	approvedImagesStrict = true
+	if w = boot(); strings.Contains(w.Body.String(), "console=ttyS0") {
+		t.Errorf("Unapproved images should not be served in strict mode:\n%s", w.Body.String())
+	}
+
+	if w = call(httptest.NewRequest(http.MethodPut, approvedImagesEndpoint,
+		strings.NewReader(`{"uri":"`+kernel+`"}`))); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an approval without a token to be refused, got %d", w.Code)
+	}
+	bad := adminRequest(http.MethodPut, `{"uri":"`+kernel+`","digest":"sha256:00"}`)
+	if w = call(bad); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a URI and a bad digest to be refused, got %d", w.Code)
+	}
+	if w = call(adminRequest(http.MethodPut, `{"uri":"`+kernel+`","comment":"CHG0042"}`)); w.Code != http.StatusOK {
+		t.Fatalf("PUT returned %d: %s", w.Code, w.Body.String())
+	}
+	if w = boot(); !strings.Contains(w.Body.String(), "console=ttyS0") {
+		t.Errorf("Approved image not served in strict mode:\n%s", w.Body.String())
+	}
+	bd, _ := LookupByName(node)
+	bd.Overlays = []string{"s3://boot-images/overlays/site.cpio"}
+	if err := checkApprovedImages(bd); err == nil || !strings.Contains(err.Error(), "initrd overlay") {
+		t.Errorf("Expected an unapproved overlay to be refused, got %v", err)
+	}
+	bd.Overlays, bd.Files = nil, []bssTypes.BootFile{{Name: "boot.wim", Path: "s3://boot-images/boot.wim"}}
+	if err := checkApprovedImages(bd); err == nil {
+		t.Errorf("Expected an unapproved wimboot file to be refused")
+	}
+	w = call(httptest.NewRequest(http.MethodGet, approvedImagesEndpoint, nil))
+	var approved []bssTypes.ApprovedImage
+	json.Unmarshal(w.Body.Bytes(), &approved)
+	if len(approved) != 1 || approved[0].URI != kernel || approved[0].ApprovedBy != "jdoe" {
+		t.Errorf("Unexpected approvals %+v", approved)
+	}
+
+	del := adminRequest(http.MethodDelete, "")
+	del.URL.RawQuery = "uri=" + kernel
+	if w = call(del); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE returned %d: %s", w.Code, w.Body.String())
+	}
+	if w = call(del); w.Code != http.StatusNotFound {
+		t.Errorf("Second DELETE returned %d", w.Code)
+	}
+}
@@ -0,0 +1,273 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Artifact mirrors.
+//
+// During a mass boot every node fetching its images from the same object
+// store fills the uplinks.  With rack-local caches registered under
+// /boot/v1/artifact-mirrors as subnet and base URL, the boot script of a
+// node booting from an address in the subnet names the cache instead:
+//
+//	kernel --name kernel https://s3.example/boot-images/k?X-Amz-...
+//	kernel --name kernel http://rack3-cache:8080/boot-images/k?X-Amz-...
+//
+// Only the scheme and host of the kernel, initrd and other image URLs, and
+// of the rootfs parameters (metal.server=, root=live:) are replaced, so the
+// path and any signature in the query stay; the cache has to fetch misses
+// from the original host.  URLs of BSS itself are left alone.  The most
+// specific subnet wins.  A script rewritten for a mirror is not cacheable,
+// see cachehdr.go, as a proxy would serve it to other subnets too.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	artifactMirrorsEndpoint = baseEndpoint + "/artifact-mirrors"
+	artifactMirrorsPfx      = "/artifact-mirrors/"
+)
+
+var mirrorRootfsParams = []string{"metal.server=", "root=live:"}
+
+// Function artifactMirrorKey() returns the key of the mirror of a subnet,
+// which must be in canonical form.
+func artifactMirrorKey(subnet string) string {
+	return artifactMirrorsPfx + strings.Replace(subnet, "/", "_", 1)
+}
+
+// Function canonicalSubnet() returns the canonical form of a CIDR.
+func canonicalSubnet(s string) (string, error) {
+	_, n, err := net.ParseCIDR(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("Bad subnet '%s', expected a CIDR", s)
+	}
+	return n.String(), nil
+}
+
+func checkArtifactMirror(m *bssTypes.ArtifactMirror) error {
+	subnet, err := canonicalSubnet(m.Subnet)
+	if err != nil {
+		return err
+	}
+	m.Subnet = subnet
+	u, err := url.Parse(m.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" {
+		return fmt.Errorf("Bad base URL '%s', expected http(s)://host[:port][/path]", m.BaseURL)
+	}
+	return nil
+}
+
+func getArtifactMirrors() ([]bssTypes.ArtifactMirror, error) {
+	kvl, err := kvstore.GetRange(artifactMirrorsPfx+keyMin, artifactMirrorsPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	ret := []bssTypes.ArtifactMirror{}
+	for _, kv := range kvl {
+		var m bssTypes.ArtifactMirror
+		if json.Unmarshal([]byte(kv.Value), &m) == nil {
+			ret = append(ret, m)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Subnet < ret[j].Subnet })
+	return ret, nil
+}
+
+// Function artifactMirrorFor() returns the mirror of the most specific
+// subnet containing the address.
+func artifactMirrorFor(addr string) (bssTypes.ArtifactMirror, bool) {
+	ip := net.ParseIP(canonicalIP(addr))
+	if ip == nil {
+		return bssTypes.ArtifactMirror{}, false
+	}
+	mirrors, err := getArtifactMirrors()
+	if err != nil {
+		log.Printf("Cannot read artifact mirrors: %s", err)
+		return bssTypes.ArtifactMirror{}, false
+	}
+	var best bssTypes.ArtifactMirror
+	bestOnes := -1
+	for _, m := range mirrors {
+		_, n, err := net.ParseCIDR(m.Subnet)
+		if err != nil || !n.Contains(ip) {
+			continue
+		}
+		if ones, _ := n.Mask.Size(); ones > bestOnes {
+			best, bestOnes = m, ones
+		}
+	}
+	return best, bestOnes >= 0
+}
+
+// Function mirrorURL() returns u with the scheme and host of the mirror,
+// and the mirror's path before its own.
+func mirrorURL(u string, mirror *url.URL) string {
+	p, err := url.Parse(u)
+	if err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" ||
+		strings.EqualFold(p.Host, urlHost(ipxeServer)) ||
+		strings.EqualFold(p.Hostname(), strings.Trim(ipxeServer, "[]")) {
+		return u
+	}
+	p.Scheme, p.Host = mirror.Scheme, mirror.Host
+	p.Path = strings.TrimRight(mirror.Path, "/") + p.Path
+	if p.RawPath != "" {
+		p.RawPath = strings.TrimRight(mirror.EscapedPath(), "/") + p.RawPath
+	}
+	return p.String()
+}
+
+// Function mirrorScript() rewrites the artifact URLs of a boot script for
+// a node booting from addr.
+func mirrorScript(script, addr string) string {
+	m, ok := artifactMirrorFor(addr)
+	if !ok {
+		return script
+	}
+	mirror, err := url.Parse(m.BaseURL)
+	if err != nil {
+		return script
+	}
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		fields := strings.Split(line, " ")
+		switch fields[0] {
+		case "kernel", "initrd", "imgfetch", "module":
+		default:
+			continue
+		}
+		image := 1
+		if len(fields) > 2 && fields[1] == "--name" {
+			image = 3
+		}
+		for j := image; j < len(fields) && fields[j] != "||"; j++ {
+			if j == image {
+				fields[j] = mirrorURL(fields[j], mirror)
+				continue
+			}
+			for _, pfx := range mirrorRootfsParams {
+				if strings.HasPrefix(fields[j], pfx) {
+					fields[j] = pfx + mirrorURL(fields[j][len(pfx):], mirror)
+				}
+			}
+		}
+		lines[i] = strings.Join(fields, " ")
+	}
+	debugf("Boot script of %s uses the artifact mirror %s", addr, m.BaseURL)
+	return strings.Join(lines, "\n")
+}
+
+func sendArtifactMirrors(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func ArtifactMirrorsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ArtifactMirrorsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	if addr := strings.Join(r.Form["addr"], ""); addr != "" {
+		m, ok := artifactMirrorFor(addr)
+		if !ok {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No artifact mirror for %s", addr))
+			return
+		}
+		sendArtifactMirrors(w, http.StatusOK, m)
+		return
+	}
+	mirrors, err := getArtifactMirrors()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read artifact mirrors: %s", err))
+		return
+	}
+	sendArtifactMirrors(w, http.StatusOK, mirrors)
+}
+
+func ArtifactMirrorsPut(w http.ResponseWriter, r *http.Request) {
+	debugf("ArtifactMirrorsPut(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var m bssTypes.ArtifactMirror
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if err := checkArtifactMirror(&m); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - %s", err))
+		return
+	}
+	m.UpdatedBy, m.UpdatedAt = requestSubject(r), time.Now().Unix()
+	if err := storeData(artifactMirrorKey(m.Subnet), m); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot store the artifact mirror: %s", err))
+		return
+	}
+	log.Printf("Artifact mirror of %s set to %s by %s", m.Subnet, m.BaseURL, m.UpdatedBy)
+	sendArtifactMirrors(w, http.StatusOK, m)
+}
+
+func ArtifactMirrorsDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("ArtifactMirrorsDelete(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	subnet, err := canonicalSubnet(strings.Join(r.Form["subnet"], ""))
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - %s", err))
+		return
+	}
+	key := artifactMirrorKey(subnet)
+	if _, exists, _ := kvstore.Get(key); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No artifact mirror for %s", subnet))
+		return
+	}
+	if err := kvstore.Delete(key); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot delete the artifact mirror: %s", err))
+		return
+	}
+	log.Printf("Artifact mirror of %s removed by %s", subnet, requestSubject(r))
+	w.WriteHeader(http.StatusNoContent)
+}
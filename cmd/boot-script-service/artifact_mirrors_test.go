@@ -0,0 +1,132 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestMirrorScript(t *testing.T) {
+	defer kvstore.Delete(artifactMirrorKey("10.1.0.0/16"))
+	defer kvstore.Delete(artifactMirrorKey("10.1.3.0/24"))
+	for _, m := range []bssTypes.ArtifactMirror{
+		{Subnet: "10.1.0.0/16", BaseURL: "http://site-cache"},
+		{Subnet: "10.1.3.0/24", BaseURL: "http://rack3-cache:8080/s3/"},
+	} {
+		if err := storeData(artifactMirrorKey(m.Subnet), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := "#!ipxe\n" +
+		"kernel --name kernel https://s3.example/boot-images/k?X-Amz-Signature=ab metal.server=https://s3.example/boot-images/rootfs ds=nocloud-net;s=" +
+		chainProto + "://" + ipxeServer + "/ || goto boot_retry\n" +
+		"initrd --name initrd " + chainProto + "://" + ipxeServer + "/apis/bss/boot/v1/artifacts/x/initrd || goto boot_retry\n" +
+		"boot || goto boot_retry\n"
+
+	got := mirrorScript(script, "10.1.3.7:4242")
+	for _, want := range []string{
+		"kernel --name kernel http://rack3-cache:8080/s3/boot-images/k?X-Amz-Signature=ab ",
+		" metal.server=http://rack3-cache:8080/s3/boot-images/rootfs ",
+		"ds=nocloud-net;s=" + chainProto + "://" + ipxeServer + "/ ",
+		"initrd --name initrd " + chainProto + "://" + ipxeServer + "/apis/bss/",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if got = mirrorScript(script, "10.1.9.7"); !strings.Contains(got, " http://site-cache/boot-images/k?") {
+		t.Errorf("Expected the /16 mirror:\n%s", got)
+	}
+	if got = mirrorScript(script, "10.2.0.1"); got != script {
+		t.Errorf("Script of an address without a mirror changed:\n%s", got)
+	}
+
+	const node, kernel = "x0c0s8b0n0", "https://s3.example/boot-images/k"
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "quiet", Kernel: kernel}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+	for addr, cacheable := range map[string]bool{"10.1.3.7:4242": false, "10.2.0.1:4242": true} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil)
+		r.RemoteAddr = addr
+		bootScript(w, r)
+		if got := w.Header().Get("ETag") != ""; got != cacheable {
+			t.Errorf("Script for %s: cacheable %v, expected %v (%v)", addr, got, cacheable, w.Header())
+		}
+	}
+}
+
+func TestArtifactMirrorsAPI(t *testing.T) {
+	defer kvstore.Delete(artifactMirrorKey("10.4.0.0/16"))
+	call := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		artifactMirrors(w, r)
+		return w
+	}
+	put := func(body string) *http.Request {
+		r := adminRequest(http.MethodPut, body)
+		r.URL.Path = artifactMirrorsEndpoint
+		return r
+	}
+
+	if w := call(httptest.NewRequest(http.MethodPut, artifactMirrorsEndpoint,
+		strings.NewReader(`{"subnet":"10.4.0.0/16","base-url":"http://c"}`))); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a PUT without a token to be refused, got %d", w.Code)
+	}
+	for _, bad := range []string{
+		`{"subnet":"10.4.0.0","base-url":"http://c"}`,
+		`{"subnet":"10.4.0.0/16","base-url":"ftp://c"}`,
+		`{"subnet":"10.4.0.0/16","base-url":"http://c/?a=b"}`,
+	} {
+		if w := call(put(bad)); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be refused, got %d", bad, w.Code)
+		}
+	}
+	if w := call(put(`{"subnet":"10.4.7.1/16","base-url":"http://c"}`)); w.Code != http.StatusOK ||
+		!strings.Contains(w.Body.String(), `"subnet":"10.4.0.0/16"`) {
+		t.Fatalf("PUT returned %d: %s", w.Code, w.Body.String())
+	}
+	w := call(httptest.NewRequest(http.MethodGet, artifactMirrorsEndpoint+"?addr=10.4.1.1", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"updated-by":"jdoe"`) {
+		t.Errorf("GET ?addr returned %d: %s", w.Code, w.Body.String())
+	}
+
+	del := adminRequest(http.MethodDelete, "")
+	del.URL.RawQuery = "subnet=" + url.QueryEscape("10.4.0.0/16")
+	if w = call(del); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE returned %d: %s", w.Code, w.Body.String())
+	}
+	if w = call(del); w.Code != http.StatusNotFound {
+		t.Errorf("Second DELETE returned %d", w.Code)
+	}
+}
@@ -0,0 +1,312 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Optional kernel and initrd proxy.
+//
+// Small systems may not have an object store that booting nodes can reach.
+// When the artifact proxy is enabled, the boot scripts reference BSS itself
+// for the kernel and initrd images.  BSS then serves the bytes either from a
+// local directory (for plain path images) or by fetching them from the image
+// URL (http, https or s3) into an on-disk LRU cache.  Range requests are
+// supported for both.
+//
+// Artifacts are addressed by their image storage key, so the proxy will only
+// serve images that are referenced by stored boot parameters.
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const artifactsEndpoint = baseEndpoint + "/artifacts/"
+
+var (
+	artifactProxy       = false
+	artifactDir         = ""
+	artifactCacheDir    = filepath.Join(os.TempDir(), "bss-artifacts")
+	artifactCacheSizeMB = uint(10240)
+	artifactClient      *http.Client
+	artifacts           *artifactCache
+)
+
+type artifactCacheEntry struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// The artifact cache maintains the set of files in the cache directory and
+// evicts the least recently used ones once the size limit is reached.
+type artifactCache struct {
+	mutex    sync.Mutex
+	dir      string
+	limit    int64
+	size     int64
+	entries  map[string]*artifactCacheEntry
+	inflight map[string]*sync.Mutex
+}
+
+func newArtifactCache(dir string, limit int64) (*artifactCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	c := &artifactCache{
+		dir:      dir,
+		limit:    limit,
+		entries:  make(map[string]*artifactCacheEntry),
+		inflight: make(map[string]*sync.Mutex),
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), ".tmp") {
+			// Left over from an interrupted download
+			os.Remove(filepath.Join(dir, f.Name()))
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		c.entries[f.Name()] = &artifactCacheEntry{info.Size(), info.ModTime()}
+		c.size += info.Size()
+	}
+	c.mutex.Lock()
+	c.evict(0)
+	c.mutex.Unlock()
+	return c, nil
+}
+
+// Function evict() removes least recently used entries until there is room
+// for an additional need bytes.  The cache mutex must be held.
+func (c *artifactCache) evict(need int64) {
+	for c.size+need > c.limit && len(c.entries) > 0 {
+		oldest := ""
+		var oldestTime time.Time
+		for name, e := range c.entries {
+			if oldest == "" || e.lastUsed.Before(oldestTime) {
+				oldest = name
+				oldestTime = e.lastUsed
+			}
+		}
+		debugf("Evicting %s from the artifact cache", oldest)
+		if err := os.Remove(filepath.Join(c.dir, oldest)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to evict %s from the artifact cache: %s", oldest, err)
+		}
+		c.size -= c.entries[oldest].size
+		delete(c.entries, oldest)
+	}
+}
+
+func (c *artifactCache) lookup(name string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[name]
+	if ok {
+		e.lastUsed = time.Now()
+	}
+	return filepath.Join(c.dir, name), ok
+}
+
+// Function fetch() returns the path of the cached copy of the named artifact,
+// downloading it from src if necessary.  Concurrent requests for the same
+// artifact wait for a single download.
+func (c *artifactCache) fetch(name, src string) (string, error) {
+	if path, ok := c.lookup(name); ok {
+		return path, nil
+	}
+	c.mutex.Lock()
+	m, ok := c.inflight[name]
+	if !ok {
+		m = new(sync.Mutex)
+		c.inflight[name] = m
+	}
+	c.mutex.Unlock()
+	m.Lock()
+	defer m.Unlock()
+	if path, ok := c.lookup(name); ok {
+		return path, nil
+	}
+
+	path := filepath.Join(c.dir, name)
+	size, err := downloadArtifact(src, path+".tmp")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.inflight, name)
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return "", err
+	}
+	c.evict(size)
+	if err = os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return "", err
+	}
+	c.entries[name] = &artifactCacheEntry{size, time.Now()}
+	c.size += size
+	return path, nil
+}
+
+func downloadArtifact(src, dest string) (int64, error) {
+	debugf("Downloading artifact %s to %s", src, dest)
+	req, err := http.NewRequest(http.MethodGet, src, nil)
+	if err != nil {
+		return 0, err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	rsp, err := artifactClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Artifact download from %s failed: %s", src, rsp.Status)
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(f, rsp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return size, err
+}
+
+func artifactProxyInit(opts string) error {
+	if !artifactProxy {
+		return nil
+	}
+	insecure := false
+	for _, opt := range strings.Split(opts, ",") {
+		if strings.EqualFold(opt, "insecure") {
+			insecure = true
+			break
+		}
+	}
+	artifactClient = new(http.Client)
+	if insecure {
+		artifactClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	var err error
+	artifacts, err = newArtifactCache(artifactCacheDir, int64(artifactCacheSizeMB)<<20)
+	if err != nil {
+		return fmt.Errorf("Artifact cache %s initialization failed: %s", artifactCacheDir, err)
+	}
+	log.Printf("Artifact proxy enabled, cache %s (%d MiB), local images from '%s'",
+		artifactCacheDir, artifactCacheSizeMB, artifactDir)
+	return nil
+}
+
+// Function artifactProxyURL() returns the URL a booting node should use to
+// fetch the image through the artifact proxy.
+func artifactProxyURL(imtype, path string) string {
+	return chainProto + "://" + urlHost(ipxeServer) + gwURI + artifactsEndpoint[:len(artifactsEndpoint)-1] +
+		makeImageKey(imtype, path)
+}
+
+// Function artifactSource() maps the image path to either a local file or a
+// URL that can be downloaded.
+func artifactSource(path string) (local string, remote string, err error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", "", err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		if artifactDir == "" {
+			return "", "", fmt.Errorf("No local artifact directory configured for %s", path)
+		}
+		return filepath.Join(artifactDir, filepath.Clean("/"+u.Path)), "", nil
+	case "http", "https":
+		return "", path, nil
+	case "s3":
+		remote, err = checkURL(path)
+		return "", remote, err
+	}
+	return "", "", fmt.Errorf("Unsupported artifact URL scheme '%s'", u.Scheme)
+}
+
+func artifactsGetAPI(w http.ResponseWriter, r *http.Request) {
+	debugf("artifactsGetAPI(): Received request %v\n", r.URL)
+	if !artifactProxy {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound, "Artifact proxy is not enabled")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, artifactsEndpoint), "/")
+	if len(parts) != 2 || (parts[0] != kernelImageType && parts[0] != initrdImageType) || parts[1] == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - Invalid artifact '%s'", r.URL.Path))
+		return
+	}
+	imdata, err := getImage(parts[0], parts[1])
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - Unknown %s artifact '%s'", parts[0], parts[1]))
+		return
+	}
+	local, remote, err := artifactSource(imdata.Path)
+	if err == nil && local == "" {
+		local, err = artifacts.fetch(parts[0]+"-"+parts[1], remote)
+	}
+	if err != nil {
+		log.Printf("Artifact proxy failed for %s: %s", imdata.Path, err)
+		base.SendProblemDetailsGeneric(w, http.StatusBadGateway,
+			fmt.Sprintf("Cannot retrieve %s", imdata.Path))
+		return
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		log.Printf("Artifact proxy failed for %s: %s", imdata.Path, err)
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Cannot retrieve %s", imdata.Path))
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, filepath.Base(local), info.ModTime(), f)
+}
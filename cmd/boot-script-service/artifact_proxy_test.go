@@ -0,0 +1,100 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArtifactCacheEviction(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("123456"))
+	}))
+	defer upstream.Close()
+	artifactClient = upstream.Client()
+
+	c, err := newArtifactCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("newArtifactCache failed: %s", err)
+	}
+	if _, err = c.fetch("a", upstream.URL+"/a"); err != nil {
+		t.Fatalf("fetch(a) failed: %s", err)
+	}
+	if _, err = c.fetch("b", upstream.URL+"/b"); err != nil {
+		t.Fatalf("fetch(b) failed: %s", err)
+	}
+	if _, ok := c.lookup("a"); ok {
+		t.Errorf("Least recently used artifact 'a' was not evicted")
+	}
+	if path, ok := c.lookup("b"); !ok {
+		t.Errorf("Artifact 'b' is not cached")
+	} else if data, _ := os.ReadFile(path); string(data) != "123456" {
+		t.Errorf("Artifact 'b' cached content miscompare: %s", data)
+	}
+	if c.size != 6 {
+		t.Errorf("Artifact cache size expected 6, got %d", c.size)
+	}
+}
+
+func TestArtifactsGetRange(t *testing.T) {
+	savedProxy, savedDir := artifactProxy, artifactDir
+	artifactProxy = true
+	artifactDir = t.TempDir()
+	defer func() { artifactProxy, artifactDir = savedProxy, savedDir }()
+
+	err := os.WriteFile(filepath.Join(artifactDir, "vmlinuz"), []byte("0123456789"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := imageStore("/vmlinuz", kernelImageType)
+	if key == "" {
+		t.Fatal("imageStore failed")
+	}
+	defer kvstore.Delete(key)
+
+	req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/artifacts"+key, nil)
+	req.Header.Set("Range", "bytes=2-4")
+	rr := httptest.NewRecorder()
+	artifactsGet(rr, req)
+	if rr.Code != http.StatusPartialContent {
+		t.Fatalf("Range request returned %d, expected %d: %s", rr.Code, http.StatusPartialContent, rr.Body)
+	}
+	if rr.Body.String() != "234" {
+		t.Errorf("Range request returned '%s', expected '234'", rr.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, baseEndpoint+"/artifacts/kernel/0000", nil)
+	rr = httptest.NewRecorder()
+	artifactsGet(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Unknown artifact returned %d, expected %d", rr.Code, http.StatusNotFound)
+	}
+
+	if u := artifactProxyURL(kernelImageType, "/vmlinuz"); u != chainProto+"://"+ipxeServer+gwURI+baseEndpoint+"/artifacts"+key {
+		t.Errorf("artifactProxyURL returned unexpected URL %s", u)
+	}
+}
@@ -0,0 +1,299 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Audit export.
+//
+// With --audit-export set, security relevant events are shipped to the site
+// security monitoring as well: refused admin and node tokens, identity
+// mismatches (see security.go) and boot configuration changes.  The
+// destination is a syslog receiver, udp://host:port, tcp://host:port or
+// tls://host:port, which gets RFC 5424 messages in CEF, or a Splunk HTTP
+// Event Collector, https://host:port/services/collector/event, which gets
+// JSON events authorized with --audit-export-token.  --audit-export-ca
+// names a PEM file with the CA certificates for tls:// and https://.
+//
+// With --audit-export-secret every event is signed with an HMAC-SHA256 under
+// that secret, so the receiver can tell events from BSS from forged ones.
+// A CEF message ends with the signature of the text before it as
+// cs1=<hex>; a HEC event carries the signature of the JSON of the other
+// event fields as "signature".
+//
+// Events are queued and sent in the background, so a slow or unreachable
+// receiver never holds up a request.  When the queue is full events are
+// dropped and counted.
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	auditAuthFailure    = "auth-failure"
+	auditIdentity       = "identity-mismatch"
+	auditConfigChange   = "config-change"
+	auditQueueSize      = 1000
+	auditSendAttempts   = 3
+	auditConnectTimeout = 10 * time.Second
+)
+
+var (
+	auditExportURL    = ""
+	auditExportToken  = ""
+	auditExportCA     = ""
+	auditExportSecret = ""
+)
+
+// CEF severity of each event type, 0 to 10.
+var auditSeverity = map[string]int{
+	auditAuthFailure:  5,
+	auditIdentity:     8,
+	auditConfigChange: 3,
+}
+
+type auditEvent struct {
+	Time      int64  `json:"time"`
+	Type      string `json:"type"`
+	Subject   string `json:"subject,omitempty"`
+	Remote    string `json:"remote,omitempty"`
+	Target    string `json:"target,omitempty"`
+	Message   string `json:"message"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type auditExporter struct {
+	dest    *url.URL
+	token   string
+	secret  []byte
+	tls     *tls.Config
+	client  *http.Client
+	conn    net.Conn
+	host    string
+	queue   chan auditEvent
+	dropped atomic.Uint64
+}
+
+var auditExport *auditExporter
+
+func newAuditExporter(dest, token, caFile, secret string) (*auditExporter, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+	e := &auditExporter{dest: u, token: token, secret: []byte(secret),
+		queue: make(chan auditEvent, auditQueueSize)}
+	e.host, _ = os.Hostname()
+	if e.host == "" {
+		e.host = "-"
+	}
+	e.tls = &tls.Config{ServerName: u.Hostname()}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		e.tls.RootCAs = x509.NewCertPool()
+		if !e.tls.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+	}
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+		if u.Port() == "" {
+			return nil, fmt.Errorf("%s: a port is required", dest)
+		}
+	case "http", "https":
+		if token == "" {
+			return nil, fmt.Errorf("%s: a HEC token is required", dest)
+		}
+		e.client = &http.Client{Timeout: auditConnectTimeout,
+			Transport: &http.Transport{TLSClientConfig: e.tls}}
+	default:
+		return nil, fmt.Errorf("%s: expected udp, tcp, tls or https", dest)
+	}
+	return e, nil
+}
+
+// Function auditInit() starts the exporter if one is configured.
+func auditInit() error {
+	if auditExportURL == "" {
+		return nil
+	}
+	e, err := newAuditExporter(auditExportURL, auditExportToken, auditExportCA, auditExportSecret)
+	if err != nil {
+		return err
+	}
+	auditExport = e
+	go e.run()
+	log.Printf("Exporting audit events to %s", e.dest.Redacted())
+	return nil
+}
+
+// Function auditEmit() queues an event for export.
+func auditEmit(typ, subject, remote, target, format string, args ...interface{}) {
+	e := auditExport
+	if e == nil {
+		return
+	}
+	evt := auditEvent{Time: time.Now().Unix(), Type: typ, Subject: subject, Remote: remote,
+		Target: target, Message: fmt.Sprintf(format, args...)}
+	select {
+	case e.queue <- evt:
+	default:
+		if n := e.dropped.Add(1); n%100 == 1 {
+			log.Printf("WARNING: audit export queue full, %d events dropped so far", n)
+		}
+	}
+}
+
+func (e *auditExporter) run() {
+	for evt := range e.queue {
+		var err error
+		for i := 0; i < auditSendAttempts; i++ {
+			if err = e.send(evt); err == nil {
+				break
+			}
+			time.Sleep(time.Duration(i+1) * time.Second)
+		}
+		if err != nil {
+			e.dropped.Add(1)
+			log.Printf("WARNING: cannot export audit event: %s", err)
+		}
+	}
+}
+
+func (e *auditExporter) sign(data []byte) string {
+	if len(e.secret) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, e.secret)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *auditExporter) send(evt auditEvent) error {
+	if e.client != nil {
+		return e.sendHEC(evt)
+	}
+	if e.conn == nil {
+		network := e.dest.Scheme
+		var err error
+		if network == "tls" {
+			d := &net.Dialer{Timeout: auditConnectTimeout}
+			e.conn, err = tls.DialWithDialer(d, "tcp", e.dest.Host, e.tls)
+		} else {
+			e.conn, err = net.DialTimeout(network, e.dest.Host, auditConnectTimeout)
+		}
+		if err != nil {
+			e.conn = nil
+			return err
+		}
+	}
+	msg := e.syslogMessage(evt)
+	if e.dest.Scheme != "udp" {
+		msg += "\n"
+	}
+	e.conn.SetWriteDeadline(time.Now().Add(auditConnectTimeout))
+	if _, err := e.conn.Write([]byte(msg)); err != nil {
+		e.conn.Close()
+		e.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Function syslogMessage() formats evt as an RFC 5424 message with a CEF
+// body, facility authpriv.
+func (e *auditExporter) syslogMessage(evt auditEvent) string {
+	sev := auditSeverity[evt.Type]
+	pri := 10*8 + 5 // authpriv.notice
+	if sev >= 5 {
+		pri = 10*8 + 4 // authpriv.warning
+	}
+	ext := []string{"rt=" + cefValue(fmt.Sprint(evt.Time*1000)), "msg=" + cefValue(evt.Message)}
+	if evt.Subject != "" {
+		ext = append(ext, "suser="+cefValue(evt.Subject))
+	}
+	if evt.Remote != "" {
+		ext = append(ext, "src="+cefValue(evt.Remote))
+	}
+	if evt.Target != "" {
+		ext = append(ext, "request="+cefValue(evt.Target))
+	}
+	cef := fmt.Sprintf("CEF:0|HPE|%s|1|%s|%s|%d|%s", cefHeader(serviceName), cefHeader(evt.Type),
+		cefHeader(evt.Message), sev, strings.Join(ext, " "))
+	if sig := e.sign([]byte(cef)); sig != "" {
+		cef += " cs1Label=signature cs1=" + sig
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s", pri,
+		time.Unix(evt.Time, 0).UTC().Format(time.RFC3339), e.host, serviceName, evt.Type, cef)
+}
+
+func cefHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ").Replace(s)
+}
+
+func cefValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+func (e *auditExporter) sendHEC(evt auditEvent) error {
+	if data, err := json.Marshal(evt); err == nil {
+		evt.Signature = e.sign(data)
+	}
+	body, err := json.Marshal(map[string]interface{}{"time": evt.Time, "host": e.host,
+		"source": serviceName, "sourcetype": "bss:audit", "event": evt})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.dest.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Splunk "+e.token)
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", e.dest.Redacted(), rsp.Status)
+	}
+	return nil
+}
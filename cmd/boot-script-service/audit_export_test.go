@@ -0,0 +1,133 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSignature(secret, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuditExportSyslog(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+	e, err := newAuditExporter("tcp://"+ln.Addr().String(), "", "", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditExport = e
+	defer func() { auditExport = nil }()
+	go e.run()
+	defer close(e.queue)
+
+	r := httptest.NewRequest(http.MethodDelete, baseEndpoint+"/warmup", nil)
+	if requestAdmin(httptest.NewRecorder(), r) {
+		t.Fatal("Request without a token was admitted")
+	}
+	var line string
+	select {
+	case line = <-lines:
+	case <-time.After(5 * time.Second):
+		t.Fatal("No audit event received")
+	}
+	line = strings.TrimSuffix(line, "\n")
+	if !strings.HasPrefix(line, "<84>1 ") || !strings.Contains(line, " auth-failure - CEF:0|HPE|") ||
+		!strings.Contains(line, "|auth-failure|DELETE without a bearer token|5|") ||
+		!strings.Contains(line, "request="+baseEndpoint+"/warmup") {
+		t.Errorf("Unexpected syslog message %s", line)
+	}
+	cef, sig, ok := strings.Cut(line[strings.Index(line, "CEF:0"):], " cs1Label=signature cs1=")
+	if !ok || sig != testSignature("s3cret", cef) {
+		t.Errorf("Bad signature in %s", line)
+	}
+}
+
+func TestAuditExportHEC(t *testing.T) {
+	events := make(chan map[string]json.RawMessage, 1)
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var body map[string]json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		events <- body
+	}))
+	defer srv.Close()
+	e, err := newAuditExporter(srv.URL+"/services/collector/event", "hec-token", "", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.send(auditEvent{Time: 1700000000, Type: auditConfigChange, Target: "x0c0s1b0n0",
+		Message: "Boot parameters update, revision 7"}); err != nil {
+		t.Fatal(err)
+	}
+	body := <-events
+	var evt auditEvent
+	json.Unmarshal(body["event"], &evt)
+	sig := evt.Signature
+	evt.Signature = ""
+	data, _ := json.Marshal(evt)
+	if auth != "Splunk hec-token" || evt.Target != "x0c0s1b0n0" || sig != testSignature("s3cret", string(data)) {
+		t.Errorf("Unexpected HEC event %s (%s)", body["event"], auth)
+	}
+}
+
+func TestAuditExportConfig(t *testing.T) {
+	for _, dest := range []string{"ftp://siem:21", "tcp://siem", "https://siem:8088/services/collector/event"} {
+		if _, err := newAuditExporter(dest, "", "", ""); err == nil {
+			t.Errorf("Audit export to %s accepted", dest)
+		}
+	}
+	if v := cefValue("a=b\\c\nd"); v != `a\=b\\c\nd` {
+		t.Errorf("CEF value escaped as %s", v)
+	}
+	if v := cefHeader("a|b"); v != `a\|b` {
+		t.Errorf("CEF header escaped as %s", v)
+	}
+}
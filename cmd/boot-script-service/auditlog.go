@@ -0,0 +1,286 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Audit log.
+//
+// Every POST, PUT, PATCH and DELETE of /boot/v1/bootparameters and
+// /boot/v1/entries, and every POST of /boot/v1/proposals, is recorded in the
+// datastore with who made it (the token subject), when, the names it
+// concerns and, when it succeeded, what it changed.  Request bodies are not
+// kept, they can hold cloud-init secrets; with a datastore key the records
+// are sealed like the boot parameters (datastore_crypt.go).  Unlike the
+// change feed (changes.go), which only says which entries changed for
+// downstream caches, the audit log is meant for people and is kept for
+// --audit-log-retention days.  Records are keyed by ULID, so they sort by
+// time and a time range is read without the rest of the log.  GET
+// /boot/v1/auditlog returns them, oldest first, filtered by since=, until=
+// (RFC 3339 or Unix times), name= and subject=.  Reading the log needs an
+// admin role.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-bss/pkg/idgen"
+)
+
+const (
+	auditLogEndpoint = baseEndpoint + "/auditlog"
+	auditLogPfx      = "/auditlog/"
+	auditLogBodyMax  = 64 * 1024
+)
+
+var auditLogRetention = uint(90) // days, 0 keeps records forever
+
+var auditLogPruned = struct {
+	sync.Mutex
+	last time.Time
+}{}
+
+// Function auditLogged() wraps a handler to record the changes made
+// through it.
+func auditLogged(inner http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			inner(w, r)
+			return
+		}
+		body := &captureBuffer{max: auditLogBodyMax}
+		if r.Body != nil {
+			r.Body = captureBody{r.Body, body}
+		}
+		// Entries and proposals answer with no diff, it is worked out here.
+		targets := auditTargets(r)
+		before := effectiveOf(targets)
+		cw := &captureWriter{ResponseWriter: w, buf: captureBuffer{max: auditLogBodyMax}}
+		start := time.Now()
+		inner(cw, r)
+		rec := bssTypes.AuditRecord{
+			ID:      idgen.NewULID(),
+			Time:    start.Unix(),
+			Subject: requestSubject(r),
+			Remote:  findRemoteAddr(r),
+			Method:  r.Method,
+			URI:     r.RequestURI,
+			Names:   targets,
+			Status:  cw.status,
+		}
+		if targets == nil {
+			rec.Names = auditNames(body.Bytes())
+		}
+		switch {
+		case cw.status >= 300:
+		case targets != nil:
+			for i, after := range effectiveOf(targets) {
+				if diff := bootParamsDiff(before[i], after); !reflect.DeepEqual(diff, bssTypes.BootParamsDiff{}) {
+					rec.Changes = append(rec.Changes, bssTypes.AuditChange{Name: targets[i], Diff: diff})
+				}
+			}
+		default:
+			var applied []bssTypes.AppliedBootParams
+			if json.Unmarshal(cw.buf.Bytes(), &applied) == nil {
+				for _, a := range applied {
+					rec.Changes = append(rec.Changes, bssTypes.AuditChange{Name: a.Name, Diff: a.Diff})
+				}
+			}
+		}
+		recordAudit(rec)
+	}
+}
+
+// Function auditTargets() returns the names an entries or proposals request
+// changes, nil for other requests.
+func auditTargets(r *http.Request) []string {
+	if strings.HasPrefix(r.URL.Path, entriesEndpoint+"/") {
+		if id, ok := entryID(r.URL.Path); ok && id != "" {
+			return []string{id}
+		}
+		return nil
+	}
+	if r.URL.Path != proposalsEndpoint || r.Method != http.MethodPost {
+		return nil
+	}
+	r.ParseForm() // the body is captured on the way
+	p, exists, err := getProposal(strings.Join(r.Form["id"], ""))
+	if err != nil || !exists {
+		return nil
+	}
+	return bootParamsTargets(p.Params)
+}
+
+// Function auditNames() returns the hosts, MACs and NIDs a request body
+// names.
+func auditNames(body []byte) []string {
+	var bp bssTypes.BootParams
+	if json.Unmarshal(body, &bp) != nil {
+		return nil
+	}
+	names := append(append([]string{}, bp.Hosts...), bp.Macs...)
+	for _, n := range bp.Nids {
+		names = append(names, strconv.Itoa(int(n)))
+	}
+	return names
+}
+
+// Function recordAudit() stores an audit record.  Failures are logged, the
+// request has already been answered.
+func recordAudit(rec bssTypes.AuditRecord) {
+	value, err := sealValue(rec)
+	if err == nil {
+		err = kvstore.Store(auditLogPfx+rec.ID, value)
+	}
+	if err != nil {
+		log.Printf("Failed to record the audit record of %s %s by %s: %s", rec.Method, rec.URI, rec.Subject, err)
+	}
+	auditLogPruned.Lock()
+	prune := auditLogRetention > 0 && time.Since(auditLogPruned.last) > time.Hour
+	if prune {
+		auditLogPruned.last = time.Now()
+	}
+	auditLogPruned.Unlock()
+	if prune {
+		go pruneAuditLog(time.Now().AddDate(0, 0, -int(auditLogRetention)))
+	}
+}
+
+// Function getAuditLog() returns the records made from since up to until,
+// both Unix times; a negative until has no end.
+func getAuditLog(since, until int64) ([]bssTypes.AuditRecord, error) {
+	end := auditLogPfx + keyMax
+	if until >= 0 {
+		end = auditLogPfx + idgen.ULIDAt(time.Unix(until+1, 0))
+	}
+	kvl, err := kvstore.GetRange(auditLogPfx+idgen.ULIDAt(time.Unix(since, 0)), end)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(kvl, func(i, j int) bool { return kvl[i].Key < kvl[j].Key })
+	var recs []bssTypes.AuditRecord
+	for _, kv := range kvl {
+		var rec bssTypes.AuditRecord
+		if err = unsealValue(kv.Value, &rec); err != nil {
+			log.Printf("Cannot read audit record %s: %s", kv.Key, err)
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func pruneAuditLog(before time.Time) {
+	// The keys say when the records were made, so only the old ones are read.
+	kvl, err := kvstore.GetRange(auditLogPfx+keyMin, auditLogPfx+idgen.ULIDAt(before))
+	if err != nil {
+		log.Printf("Failed to prune the audit log: %s", err)
+		return
+	}
+	for _, kv := range kvl {
+		kvstore.Delete(kv.Key)
+	}
+}
+
+// Function auditTime() parses an RFC 3339 or Unix time.
+func auditTime(s string) (int64, error) {
+	if t, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not an RFC 3339 or Unix time", s)
+	}
+	return t.Unix(), nil
+}
+
+func AuditLogGet(w http.ResponseWriter, r *http.Request) {
+	debugf("AuditLogGet(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	since, until := int64(0), int64(-1)
+	var err error
+	if v := r.Form.Get("since"); v != "" {
+		if since, err = auditTime(v); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request - since %s", err))
+			return
+		}
+	}
+	if v := r.Form.Get("until"); v != "" {
+		if until, err = auditTime(v); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request - until %s", err))
+			return
+		}
+	}
+	name, subject := r.Form.Get("name"), r.Form.Get("subject")
+	recs, err := getAuditLog(since, until)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve the audit log: %s", err))
+		return
+	}
+	ret := []bssTypes.AuditRecord{}
+	for _, rec := range recs {
+		if rec.Time < since || until >= 0 && rec.Time > until {
+			continue
+		}
+		if subject != "" && rec.Subject != subject || name != "" && !auditNamed(rec, name) {
+			continue
+		}
+		ret = append(ret, rec)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(ret); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func auditNamed(rec bssTypes.AuditRecord, name string) bool {
+	for _, n := range rec.Names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	for _, c := range rec.Changes {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestAuditLog(t *testing.T) {
+	const host = "x9c5s1b0n0"
+	defer func() {
+		kvstore.Delete(paramsPfx + host)
+		kvl, _ := kvstore.GetRange(auditLogPfx+keyMin, auditLogPfx+keyMax)
+		for _, kv := range kvl {
+			kvstore.Delete(kv.Key)
+		}
+	}()
+	handler := auditLogged(bootParameters)
+	send := func(method, body string) int {
+		req := httptest.NewRequest(method, baseEndpoint+"/bootparameters", strings.NewReader(body))
+		req.Header.Set("Authorization", testToken(`{"sub":"alice"}`))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+	get := func(query string) (int, []bssTypes.AuditRecord) {
+		req := adminRequest(http.MethodGet, "")
+		req.URL.Path, req.URL.RawQuery = auditLogEndpoint, query
+		w := httptest.NewRecorder()
+		auditLog(w, req)
+		var recs []bssTypes.AuditRecord
+		json.Unmarshal(w.Body.Bytes(), &recs)
+		return w.Code, recs
+	}
+
+	if code := send(http.MethodPut, `{"hosts":["`+host+`"],"params":"quiet"}`); code != http.StatusOK {
+		t.Fatalf("PUT returned %d", code)
+	}
+	send(http.MethodPatch, `{"hosts":["`+host+`"],"params":"quiet debug"}`)
+	send(http.MethodGet, "")
+
+	code, recs := get("name=" + host)
+	if code != http.StatusOK || len(recs) != 2 {
+		t.Fatalf("GET returned %d with %d records: %+v", code, len(recs), recs)
+	}
+	patch := recs[1]
+	if patch.Method != http.MethodPatch || patch.Subject != "alice" || patch.Status != http.StatusOK ||
+		len(patch.Changes) != 1 || strings.Join(patch.Changes[0].Diff.Added, " ") != "debug" {
+		t.Errorf("Unexpected PATCH record %+v", patch)
+	}
+	if recs[0].Method != http.MethodPut || len(recs[0].Changes) != 1 || strings.Join(recs[0].Names, " ") != host {
+		t.Errorf("Unexpected PUT record %+v", recs[0])
+	}
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	if _, recs = get("since=" + future); len(recs) != 0 {
+		t.Errorf("Expected no records since %s, got %+v", future, recs)
+	}
+	if _, recs = get("subject=bob"); len(recs) != 0 {
+		t.Errorf("Expected no records of bob, got %+v", recs)
+	}
+	if code, _ = get("until=yesterday"); code != http.StatusBadRequest {
+		t.Errorf("Bad until returned %d", code)
+	}
+
+	t.Setenv("BSS_DATASTORE_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	datastoreCryptInit()
+	defer func() { sealKey, openKeys = nil, make(map[string]*datastoreKey) }()
+	req := httptest.NewRequest(http.MethodPut, entriesEndpoint+"/"+host, strings.NewReader(`{"params":"quiet password=hunter2"}`))
+	req.Header.Set("Authorization", testToken(`{"sub":"bob"}`))
+	w := httptest.NewRecorder()
+	auditLogged(entries)(w, req)
+	if _, recs = get("subject=bob"); len(recs) != 1 || strings.Join(recs[0].Names, " ") != host ||
+		len(recs[0].Changes) != 1 || strings.Join(recs[0].Changes[0].Diff.Removed, " ") != "debug" {
+		t.Errorf("Unexpected entries record %+v", recs)
+	}
+	kvl, _ := kvstore.GetRange(auditLogPfx+keyMin, auditLogPfx+keyMax)
+	for _, kv := range kvl {
+		if strings.Contains(kv.Value, "hunter2") {
+			t.Errorf("Audit record %s is not sealed: %s", kv.Key, kv.Value)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	auditLog(w, httptest.NewRequest(http.MethodGet, auditLogEndpoint, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("GET without a token returned %d", w.Code)
+	}
+}
@@ -0,0 +1,255 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Authorization webhook.
+//
+// Sites with an external policy engine such as OPA can have it decide on API
+// changes: with --authz-webhook set, BSS POSTs the context of every request
+// under /boot/v1 that is not a GET, HEAD or OPTIONS to the webhook as
+// {"input": {...}} and refuses the request unless the answer is
+// {"result": true} or {"result": {"allow": true}}, the OPA data API format.
+// HSM notifications and node token requests, which have no user identity,
+// are not sent.  Request bodies, boot parameters for instance, are passed
+// along as input.body so that policies can check their content; bodies over
+// 1 MiB or that are not JSON are refused, and decisions on requests without
+// a body are cached for --authz-webhook-cache seconds.
+// When the webhook is unreachable or answers something else, requests are
+// refused, or allowed with --authz-webhook-fail-open.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+)
+
+var (
+	authzWebhookURL      = ""
+	authzWebhookFailOpen = false
+	authzWebhookCache    = uint(60) // seconds, 0 disables caching
+	authzWebhookTimeout  = uint(5)  // seconds
+)
+
+// Larger request bodies are refused, the webhook could not check them.
+const authzMaxBody = 1 << 20
+
+// The request context sent to the webhook.
+type authzInput struct {
+	Route   string          `json:"route"`
+	Method  string          `json:"method"`
+	Subject string          `json:"subject"`
+	Roles   []string        `json:"roles"`
+	Xname   string          `json:"xname,omitempty"`
+	Remote  string          `json:"remote,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+type authzDecision struct {
+	allow   bool
+	reason  string
+	expires time.Time
+}
+
+var authzCache = struct {
+	sync.Mutex
+	decisions map[string]authzDecision
+}{decisions: make(map[string]authzDecision)}
+
+var authzClient = &http.Client{}
+
+// Function authzXname() returns the xname a request is about: that of a
+// name, host or xname parameter, or failing that a path element that is one.
+func authzXname(r *http.Request) string {
+	q := r.URL.Query()
+	for _, p := range []string{"name", "host", "xname"} {
+		if v := q.Get(p); v != "" && xnametypes.IsHMSCompIDValid(v) {
+			return v
+		}
+	}
+	for _, elem := range strings.Split(r.URL.Path, "/") {
+		if strings.HasPrefix(elem, "x") && xnametypes.IsHMSCompIDValid(elem) {
+			return elem
+		}
+	}
+	return ""
+}
+
+// Function authzBody() returns the request body for the webhook, leaving it
+// in place for the handler.  The CSV and JSON lines bulk imports are passed
+// as a string.  A body the webhook cannot be shown, because it is too large
+// or not JSON, is refused with the returned status rather than decided on
+// without it.
+func authzBody(r *http.Request) (json.RawMessage, int, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, 0, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, authzMaxBody+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	switch {
+	case err != nil:
+		return nil, http.StatusBadRequest, err
+	case len(body) > authzMaxBody:
+		return nil, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("request bodies over %d bytes cannot be authorized", authzMaxBody)
+	case len(body) == 0:
+		return nil, 0, nil
+	case json.Valid(body):
+		return body, 0, nil
+	}
+	ctype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ctype {
+	case "text/csv", "application/jsonl", "application/x-ndjson":
+		if utf8.Valid(body) {
+			quoted, _ := json.Marshal(string(body))
+			return quoted, 0, nil
+		}
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("request body is not JSON")
+}
+
+func authzNeeded(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if !strings.HasPrefix(path, baseEndpoint+"/") {
+		return false
+	}
+	return path != notifierEndpoint && path != baseEndpoint+"/node-token"
+}
+
+// Function askAuthzWebhook() sends the input to the webhook and returns its
+// decision.
+func askAuthzWebhook(in authzInput) (bool, string, error) {
+	body, _ := json.Marshal(map[string]authzInput{"input": in})
+	rsp, err := authzClient.Post(authzWebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("authorization webhook answered %s", rsp.Status)
+	}
+	var out struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err = json.NewDecoder(rsp.Body).Decode(&out); err != nil {
+		return false, "", fmt.Errorf("authorization webhook: %s", err)
+	}
+	var allow bool
+	if json.Unmarshal(out.Result, &allow) == nil {
+		return allow, "", nil
+	}
+	var result struct {
+		Allow  *bool  `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(out.Result, &result) != nil || result.Allow == nil {
+		return false, "", fmt.Errorf("authorization webhook answered no decision")
+	}
+	return *result.Allow, result.Reason, nil
+}
+
+// Function authorize() returns whether the webhook allows the request, and
+// its reason if it does not.
+func authorize(r *http.Request, body json.RawMessage) (bool, string) {
+	claims, _ := requestClaims(r)
+	in := authzInput{Route: r.URL.Path, Method: r.Method, Subject: requestSubject(r),
+		Roles: claims.RealmAccess.Roles, Xname: authzXname(r), Remote: findRemoteAddr(r),
+		Body: body}
+	if in.Roles == nil {
+		in.Roles = []string{}
+	}
+	key := strings.Join([]string{in.Method, in.Route, in.Subject, strings.Join(in.Roles, ","), in.Xname}, "|")
+	now := time.Now()
+	authzCache.Lock()
+	d, ok := authzCache.decisions[key]
+	authzCache.Unlock()
+	if ok && now.Before(d.expires) && in.Body == nil {
+		return d.allow, d.reason
+	}
+
+	allow, reason, err := askAuthzWebhook(in)
+	if err != nil {
+		action := "refusing it"
+		if authzWebhookFailOpen {
+			action = "allowing it"
+		}
+		log.Printf("WARNING: %s %s by %s: %s, %s", in.Method, in.Route, in.Subject, err, action)
+		return authzWebhookFailOpen, "authorization webhook unavailable"
+	}
+	if authzWebhookCache > 0 && in.Body == nil {
+		authzCache.Lock()
+		for k, d := range authzCache.decisions {
+			if now.After(d.expires) {
+				delete(authzCache.decisions, k)
+			}
+		}
+		authzCache.decisions[key] = authzDecision{allow, reason,
+			now.Add(time.Duration(authzWebhookCache) * time.Second)}
+		authzCache.Unlock()
+	}
+	return allow, reason
+}
+
+// Function authzHandler() refuses the requests the webhook does not allow.
+func authzHandler(inner http.Handler) http.Handler {
+	if authzWebhookURL == "" {
+		return inner
+	}
+	log.Printf("API changes are authorized by %s", authzWebhookURL)
+	authzClient.Timeout = time.Duration(authzWebhookTimeout) * time.Second
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authzNeeded(r) {
+			body, status, err := authzBody(r)
+			if err != nil {
+				base.SendProblemDetailsGeneric(w, status, fmt.Sprintf("%s: %s", http.StatusText(status), err))
+				return
+			}
+			if allow, reason := authorize(r, body); !allow {
+				auditEmit(auditAuthFailure, requestSubject(r), findRemoteAddr(r), r.URL.Path,
+					"%s refused by the authorization webhook", r.Method)
+				msg := "Forbidden by the authorization policy"
+				if reason != "" {
+					msg += ": " + reason
+				}
+				base.SendProblemDetailsGeneric(w, http.StatusForbidden, msg)
+				return
+			}
+		}
+		inner.ServeHTTP(w, r)
+	})
+}
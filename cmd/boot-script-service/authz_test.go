@@ -0,0 +1,154 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAuthzWebhook(t *testing.T) {
+	var calls atomic.Int32
+	var last authzInput
+	policy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body struct {
+			Input authzInput `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		last = body.Input
+		switch {
+		case strings.Contains(string(body.Input.Body), "init=/bin/sh"):
+			w.Write([]byte(`{"result":{"allow":false,"reason":"no shells"}}`))
+		case body.Input.Method == http.MethodDelete:
+			w.Write([]byte(`{"result":{"allow":false,"reason":"no deletions on Fridays"}}`))
+		case body.Input.Subject == "jdoe":
+			w.Write([]byte(`{"result":true}`))
+		default:
+			w.Write([]byte(`{"result":false}`))
+		}
+	}))
+	defer policy.Close()
+	savedURL, savedOpen, savedCache := authzWebhookURL, authzWebhookFailOpen, authzWebhookCache
+	defer func() {
+		authzWebhookURL, authzWebhookFailOpen, authzWebhookCache = savedURL, savedOpen, savedCache
+		authzCache.decisions = make(map[string]authzDecision)
+	}()
+	authzWebhookURL, authzWebhookCache = policy.URL, 60
+	handler := authzHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.Copy(w, r.Body)
+	}))
+	callBody := func(method, path, sub, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if sub != "" {
+			r.Header.Set("Authorization", testToken(`{"sub":"`+sub+`","realm_access":{"roles":["admin"]}}`))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	call := func(method, path, sub string) *httptest.ResponseRecorder {
+		return callBody(method, path, sub, "")
+	}
+
+	if w := call(http.MethodGet, baseEndpoint+"/bootparameters", ""); w.Code != http.StatusTeapot || calls.Load() != 0 {
+		t.Errorf("GET should not be sent to the webhook: %d, %d calls", w.Code, calls.Load())
+	}
+	if w := call(http.MethodPost, baseEndpoint+"/scn", ""); w.Code != http.StatusTeapot || calls.Load() != 0 {
+		t.Errorf("HSM notifications should not be sent to the webhook: %d, %d calls", w.Code, calls.Load())
+	}
+	if w := call(http.MethodPut, baseEndpoint+"/bootparameters?name=x0c0s1b0n0", "jdoe"); w.Code != http.StatusTeapot {
+		t.Errorf("Allowed PUT returned %d", w.Code)
+	}
+	if last.Subject != "jdoe" || last.Xname != "x0c0s1b0n0" || last.Route != baseEndpoint+"/bootparameters" ||
+		len(last.Roles) != 1 || last.Roles[0] != "admin" {
+		t.Errorf("Unexpected webhook input %+v", last)
+	}
+	call(http.MethodPut, baseEndpoint+"/bootparameters?name=x0c0s1b0n0", "jdoe")
+	if calls.Load() != 1 {
+		t.Errorf("Expected the decision to be cached, %d calls", calls.Load())
+	}
+	if w := call(http.MethodPut, baseEndpoint+"/bootparameters", "mallory"); w.Code != http.StatusForbidden {
+		t.Errorf("Denied PUT returned %d", w.Code)
+	}
+	w := call(http.MethodDelete, baseEndpoint+"/override/x0c0s1b0n0", "jdoe")
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "no deletions on Fridays") {
+		t.Errorf("Denied DELETE returned %d: %s", w.Code, w.Body.String())
+	}
+	if last.Xname != "x0c0s1b0n0" {
+		t.Errorf("Expected the xname from the path, got %+v", last)
+	}
+
+	params := `{"hosts":["x0c0s1b0n0"],"params":"console=ttyS0"}`
+	if w = callBody(http.MethodPut, baseEndpoint+"/bootparameters", "jdoe", params); w.Code != http.StatusTeapot ||
+		w.Body.String() != params {
+		t.Errorf("Allowed PUT with a body returned %d: %s", w.Code, w.Body.String())
+	}
+	if string(last.Body) != params {
+		t.Errorf("Expected the body in the webhook input, got %s", last.Body)
+	}
+	n := calls.Load()
+	w = callBody(http.MethodPut, baseEndpoint+"/bootparameters", "jdoe", `{"hosts":["x0c0s1b0n0"],"params":"init=/bin/sh"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "no shells") {
+		t.Errorf("Denied PUT with a body returned %d: %s", w.Code, w.Body.String())
+	}
+	if calls.Load() != n+1 {
+		t.Errorf("Decisions on requests with a body should not be cached")
+	}
+	n = calls.Load()
+	padded := `{"hosts":["x0c0s1b0n0"],"params":"init=/bin/sh"}` + strings.Repeat(" ", authzMaxBody)
+	if w = callBody(http.MethodPut, baseEndpoint+"/bootparameters", "jdoe", padded); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("PUT with a padded body returned %d", w.Code)
+	}
+	if w = callBody(http.MethodPut, baseEndpoint+"/bootparameters", "jdoe", "hosts=x0c0s1b0n0"); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT with a body that is not JSON returned %d", w.Code)
+	}
+	if calls.Load() != n {
+		t.Errorf("Refused bodies should not be sent to the webhook")
+	}
+	csvBody := "xname,kernel\nx0c0s1b0n0,s3://boot-images/k\n"
+	r := httptest.NewRequest(http.MethodPost, baseEndpoint+"/import", strings.NewReader(csvBody))
+	r.Header.Set("Content-Type", "text/csv")
+	r.Header.Set("Authorization", testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	var passed string
+	if w.Code != http.StatusTeapot || json.Unmarshal(last.Body, &passed) != nil || passed != csvBody {
+		t.Errorf("CSV import returned %d, webhook body %s", w.Code, last.Body)
+	}
+
+	authzWebhookURL = "http://127.0.0.1:1/v1/data/bss/allow"
+	if w = call(http.MethodPost, baseEndpoint+"/bootparameters", "bob"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected fail-closed, got %d", w.Code)
+	}
+	authzWebhookFailOpen = true
+	if w = call(http.MethodPatch, baseEndpoint+"/bootparameters", "bob"); w.Code != http.StatusTeapot {
+		t.Errorf("Expected fail-open, got %d", w.Code)
+	}
+}
@@ -0,0 +1,127 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Backfilling host names from HSM.
+//
+// Boot parameters given by MAC address or NID are stored under the node's
+// xname, but only if HSM knows the node at the time; otherwise they are kept
+// under the MAC address or nid<N> name, where xname lookups do not find
+// them.  On write, an unknown MAC or NID now forces an HSM refresh (rate
+// limited like unknown IP addresses) before falling back.  A background job
+// also moves entries still stored under a MAC or NID to the xname once HSM
+// knows it, unless the xname already has boot parameters of its own.
+
+package main
+
+import (
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var backfillInterval = uint(300) // seconds, 0 disables
+
+// Function findCompByMACFresh() looks the MAC address up in HSM, refreshing
+// the HSM data once if it is not found.
+func findCompByMACFresh(mac string) (SMComponent, bool) {
+	comp, ok := FindSMCompByMAC(mac)
+	if !ok && allowForcedRefresh("mac:"+mac) {
+		refreshState(time.Now().Unix())
+		if comp, ok = FindSMCompByMAC(mac); !ok {
+			markUnknownIP("mac:" + mac)
+		}
+	}
+	return comp, ok
+}
+
+func findCompByNidFresh(nid int) (SMComponent, bool) {
+	comp, ok := FindSMCompByNid(nid)
+	if !ok && allowForcedRefresh(nidName(nid)) {
+		refreshState(time.Now().Unix())
+		if comp, ok = FindSMCompByNid(nid); !ok {
+			markUnknownIP(nidName(nid))
+		}
+	}
+	return comp, ok
+}
+
+// Function backfillName() returns the xname HSM has for a host stored under
+// a MAC address or nid<N> name.
+func backfillName(name string) (string, bool) {
+	if _, err := net.ParseMAC(name); err == nil {
+		comp, ok := FindSMCompByMAC(name)
+		return comp.ID, ok && comp.ID != ""
+	}
+	if strings.HasPrefix(name, "nid") {
+		if nid, err := strconv.Atoi(strings.TrimPrefix(name, "nid")); err == nil {
+			comp, ok := FindSMCompByNid(nid)
+			return comp.ID, ok && comp.ID != ""
+		}
+	}
+	return "", false
+}
+
+// Function backfillHosts() moves boot parameters stored under MAC addresses
+// and NIDs to the xnames HSM now has for them.
+func backfillHosts() (moved int) {
+	kvl, err := getTags()
+	if err != nil {
+		log.Printf("Backfill: cannot read boot parameters: %s", err)
+		return 0
+	}
+	for _, kv := range kvl {
+		name := extractParamName(kv)
+		xname, ok := backfillName(name)
+		if !ok {
+			continue
+		}
+		if _, err := lookupHost(xname); err == nil {
+			log.Printf("Backfill: %s is %s, which has its own boot parameters, leaving %s alone",
+				name, xname, name)
+			continue
+		}
+		bds, err := lookupHost(name)
+		if err != nil {
+			continue
+		}
+		if err = storeData(paramsPfx+xname, bds); err != nil {
+			log.Printf("Backfill: cannot store %s: %s", xname, err)
+			continue
+		}
+		if err = removeHost(name); err != nil {
+			log.Printf("Backfill: cannot remove %s after copying it to %s: %s", name, xname, err)
+		}
+		log.Printf("Backfill: moved boot parameters of %s to %s", name, xname)
+		moved++
+	}
+	return moved
+}
+
+func backfillLoop() {
+	for {
+		time.Sleep(time.Duration(backfillInterval) * time.Second)
+		backfillHosts()
+	}
+}
@@ -0,0 +1,61 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestBackfillHosts(t *testing.T) {
+	names := []string{"00:1e:67:d6:24:ce", "nid412", "nid24", "nid99999",
+		"x0c1s21b0n0", "x0c3s5b0n0", "x0c0s5b0n0"}
+	defer func() {
+		for _, n := range names {
+			kvstore.Delete(paramsPfx + n)
+		}
+	}()
+	for _, n := range []string{"00:1e:67:d6:24:ce", "nid412", "nid24", "nid99999"} {
+		storeData(paramsPfx+n, BootDataStore{Params: "from=" + n})
+	}
+	storeData(paramsPfx+"x0c0s5b0n0", BootDataStore{Params: "own"})
+
+	if moved := backfillHosts(); moved != 2 {
+		t.Errorf("Expected 2 hosts moved, got %d", moved)
+	}
+	for xname, params := range map[string]string{
+		"x0c1s21b0n0": "from=00:1e:67:d6:24:ce",
+		"x0c3s5b0n0":  "from=nid412",
+		"x0c0s5b0n0":  "own",
+	} {
+		bds, err := lookupHost(xname)
+		if err != nil || bds.Params != params {
+			t.Errorf("%s: expected params %q, got %q (%v)", xname, params, bds.Params, err)
+		}
+	}
+	for n, exists := range map[string]bool{"00:1e:67:d6:24:ce": false, "nid412": false,
+		"nid24": true, "nid99999": true} {
+		if _, err := lookupHost(n); (err == nil) != exists {
+			t.Errorf("%s: expected exists %t", n, exists)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Console capture for failed boots.
+//
+// When boot verification gives up on a node and a console URL is
+// configured, BSS asks the console logging service for the tail of the
+// node's console and keeps it with the failed verification, so
+// GET /boot/v1/bootverify shows what the node printed before it stopped.
+// The URL is a template: {xname} is replaced by the node and {lines} by the
+// number of lines wanted, e.g.
+//
+//	http://cray-console-node/console/v1/log/{xname}?tail={lines}
+//
+// The service may answer with plain text or a JSON array of lines.  BSS
+// keeps the last BSS_CONSOLE_LINES lines either way.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const consoleMaxBytes = 1 << 20
+
+var (
+	consoleBase       = ""
+	consoleLines      = uint(100)
+	consoleClient     *http.Client
+	bootVerifyConsole = getConsoleTail
+)
+
+func consoleURL(name string) string {
+	return strings.NewReplacer("{xname}", url.PathEscape(name),
+		"{lines}", strconv.FormatUint(uint64(consoleLines), 10)).Replace(consoleBase)
+}
+
+func consoleInit(urlBase, opts string) error {
+	if urlBase == "" {
+		return nil
+	}
+	u, err := url.Parse(consoleURL("x0"))
+	if err != nil {
+		return fmt.Errorf("URL parse error %s, URL: %s", err, urlBase)
+	}
+	https := u.Scheme == "https"
+	insecure := false
+	for _, opt := range strings.Split(opts, ",") {
+		if strings.ToLower(opt) == "insecure" {
+			insecure = true
+			break
+		}
+	}
+	consoleClient = &http.Client{Timeout: 30 * time.Second}
+	if https && insecure {
+		tcfg := new(tls.Config)
+		tcfg.InsecureSkipVerify = true
+		trans := new(http.Transport)
+		trans.TLSClientConfig = tcfg
+		consoleClient.Transport = trans
+		log.Printf("WARNING: insecure https connection to console service\n")
+	}
+	log.Printf("Capturing console output of failed boots from %s", urlBase)
+	return nil
+}
+
+// Function getConsoleTail() returns the last lines of a node's console.
+func getConsoleTail(name string) ([]string, error) {
+	u := consoleURL(name)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	rsp, err := consoleClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s failed: %s", u, rsp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, consoleMaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	if strings.Contains(rsp.Header.Get("Content-Type"), "json") {
+		if err = json.Unmarshal(body, &lines); err != nil {
+			return nil, fmt.Errorf("console service response: %s", err)
+		}
+	} else {
+		lines = strings.Split(strings.TrimRight(string(body), "\r\n"), "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight(l, "\r")
+		}
+	}
+	if uint(len(lines)) > consoleLines {
+		lines = lines[uint(len(lines))-consoleLines:]
+	}
+	return lines, nil
+}
+
+// Function captureConsole() attaches the console tail to a failed boot
+// verification.
+func captureConsole(v *bootVerification) {
+	if consoleClient == nil {
+		return
+	}
+	lines, err := bootVerifyConsole(v.Name)
+	if err != nil {
+		log.Printf("Console capture for %s failed: %s", v.Name, err)
+		v.ConsoleError = err.Error()
+		return
+	}
+	v.Console = lines
+}
@@ -0,0 +1,72 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConsoleTail(t *testing.T) {
+	console := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/log/x0c0s1b0n0":
+			fmt.Fprintf(w, "line 1\r\nline 2\nline 3\nKernel panic - tail=%s\n", r.URL.Query().Get("tail"))
+		case "/log/x0c0s2b0n0":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]string{"a", "b"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer console.Close()
+	savedBase, savedLines, savedClient := consoleBase, consoleLines, consoleClient
+	consoleBase, consoleLines = console.URL+"/log/{xname}?tail={lines}", 3
+	defer func() { consoleBase, consoleLines, consoleClient = savedBase, savedLines, savedClient }()
+	if err := consoleInit(consoleBase, ""); err != nil {
+		t.Fatalf("consoleInit failed: %s", err)
+	}
+
+	lines, err := getConsoleTail("x0c0s1b0n0")
+	if err != nil || strings.Join(lines, "|") != "line 2|line 3|Kernel panic - tail=3" {
+		t.Errorf("getConsoleTail returned %q, %v", lines, err)
+	}
+	if lines, err = getConsoleTail("x0c0s2b0n0"); err != nil || strings.Join(lines, "|") != "a|b" {
+		t.Errorf("getConsoleTail returned %q, %v for JSON", lines, err)
+	}
+
+	v := bootVerification{Name: "x0c0s1b0n0", Result: bootVerifyFailed}
+	captureConsole(&v)
+	if len(v.Console) != 3 || v.ConsoleError != "" {
+		t.Errorf("Console not captured: %+v", v)
+	}
+	v = bootVerification{Name: "x0c0s3b0n0", Result: bootVerifyFailed}
+	captureConsole(&v)
+	if v.Console != nil || !strings.Contains(v.ConsoleError, "404") {
+		t.Errorf("Expected a console error: %+v", v)
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"log"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -43,9 +44,9 @@ import (
 
 	base "github.com/Cray-HPE/hms-base/v2"
 	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-bss/pkg/idgen"
 	hmetcd "github.com/Cray-HPE/hms-hmetcd"
 	jsonpatch "github.com/evanphx/json-patch"
-	"github.com/google/uuid"
 )
 
 const (
@@ -58,16 +59,27 @@ const (
 )
 
 type BootDataStore struct {
-	Params        string             `json:"params,omitempty"`
-	Kernel        string             `json:"kernel,omitempty"`        // Image storage key
-	Initrd        string             `json:"initrd,omitempty"`        // Image storage key
-	CloudInit     bssTypes.CloudInit `json:"cloud-init,omitempty"`    // Image storage key
-	ReferralToken string             `json:"referral-token,omitempty` // UUID
+	Params        string               `json:"params,omitempty"`
+	Kernel        string               `json:"kernel,omitempty"`        // Image storage key
+	Initrd        string               `json:"initrd,omitempty"`        // Image storage key
+	CloudInit     bssTypes.CloudInit   `json:"cloud-init,omitempty"`    // Image storage key
+	ReferralToken string               `json:"ReferralToken,omitempty"` // UUID
+	FirstBoot     *bssTypes.FirstBoot  `json:"first-boot,omitempty"`    // Image paths, not keys
+	Messages      map[string]string    `json:"messages,omitempty"`
+	Labels        map[string]string    `json:"labels,omitempty"`
+	Reasons       map[string]string    `json:"reasons,omitempty"`
+	Payload       string               `json:"payload,omitempty"`
+	Files         []bssTypes.BootFile  `json:"files,omitempty"`           // Image paths, not keys
+	DTB           string               `json:"dtb,omitempty"`             // Image path, not key
+	ACPI          []string             `json:"acpi,omitempty"`            // Image paths, not keys
+	Overlays      []string             `json:"initrd-overlays,omitempty"` // Image paths, not keys
+	Provenance    *bssTypes.Provenance `json:"provenance,omitempty"`
 }
 
 type ImageData struct {
 	Path   string `json:"path"`             // URL or path to the image
 	Params string `json:"params,omitempty"` // boot parameters associated with this image
+	Digest string `json:"digest,omitempty"` // sha256:<hex> or etag:<etag>
 }
 
 type BootData struct {
@@ -76,6 +88,16 @@ type BootData struct {
 	Initrd        ImageData
 	CloudInit     bssTypes.CloudInit
 	ReferralToken string
+	FirstBoot     *bssTypes.FirstBoot
+	Messages      map[string]string
+	Labels        map[string]string
+	Reasons       map[string]string
+	Payload       string
+	Files         []bssTypes.BootFile
+	DTB           string
+	ACPI          []string
+	Overlays      []string
+	Provenance    *bssTypes.Provenance
 }
 
 const DefaultTag = "Default"
@@ -154,6 +176,8 @@ func getImageInfo(imtype string) []ImageData {
 			}
 		}
 	}
+	// Image keys are derived from the path, list them by the path itself.
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Path < ret[j].Path })
 	return ret
 }
 
@@ -168,6 +192,44 @@ func GetInitrdInfo() []ImageData {
 // Convert a data structure to json and store it at the given key
 func storeData(key string, v interface{}) error {
 	debugf("storeData(%s, %v)\n", key, v)
+	err := storeValue(key, v)
+	if err == nil && strings.HasPrefix(key, paramsPfx) {
+		recordChange(strings.TrimPrefix(key, paramsPfx), changeUpdate)
+	}
+	return err
+}
+
+// Function storeHostData() stores the boot parameters of host like
+// storeData(), but if expect has a value for host only while that is what is
+// stored, returning PreconditionFailed otherwise.
+func storeHostData(host string, bd BootDataStore, expect map[string]string) error {
+	old, ok := expect[host]
+	if !ok {
+		return storeData(paramsPfx+host, bd)
+	}
+	key := paramsPfx + host
+	data, err := json.Marshal(bd)
+	swapped := false
+	if err == nil {
+		swapped, err = kvstore.TAS(key, old, string(data))
+		debugf("kvstore.TAS(%s, %s, %s) -> %v, %v\n", key, old, data, swapped, err)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Key %s storage of '%v' failed: %s\n", key, bd, err.Error())
+		herr := base.NewHMSError("Storage", msg)
+		herr.AddProblem(base.NewProblemDetailsStatus(msg, http.StatusInternalServerError))
+		return herr
+	}
+	if !swapped {
+		return PreconditionFailed{host}
+	}
+	recordChange(host, changeUpdate)
+	return nil
+}
+
+// Function storeValue() is storeData() without recording a change, for
+// callers recording the changes themselves.
+func storeValue(key string, v interface{}) error {
 	data, err := json.Marshal(v)
 	if err == nil {
 		value := string(data)
@@ -193,87 +255,359 @@ func getImages(imtype string) ([]hmetcd.Kvi_KV, error) {
 	return kvstore.GetRange(makeKey(imtype, keyMin), makeKey(imtype, keyMax))
 }
 
+// Image keys are derived from the path, so the common case is a single read
+// of that key.  Scanning all images is only needed for paths whose key
+// collides with another path.
 func imageFind(path string, imtype string) string {
-	kvMutex.Lock()
-	defer kvMutex.Unlock()
+	key := makeImageKey(imtype, path)
+	if imdata, exists := readImage(key); exists && imdata.Path == path {
+		return key
+	}
 	kvl, _ := getImages(imtype)
 	ret, _ := imageLookup(path, imtype, kvl)
 	return ret
 }
 
+func readImage(key string) (ImageData, bool) {
+	var imdata ImageData
+	val, exists, err := kvstore.Get(key)
+	if err != nil || !exists || json.Unmarshal([]byte(val), &imdata) != nil {
+		return imdata, false
+	}
+	return imdata, true
+}
+
+// Raw datastore values saved so that a multi-key change can be undone.
+type kvUndo struct {
+	saved []struct {
+		key, value string
+		exists     bool
+	}
+	seen map[string]bool
+}
+
+func (u *kvUndo) save(key string) error {
+	if u.seen[key] {
+		return nil
+	}
+	value, exists, err := kvstore.Get(key)
+	if err != nil {
+		return err
+	}
+	u.record(key, value, exists)
+	return nil
+}
+
+// Function record() saves a value the caller has already read.
+func (u *kvUndo) record(key, value string, exists bool) {
+	if u.seen == nil {
+		u.seen = make(map[string]bool)
+	}
+	if u.seen[key] {
+		return
+	}
+	u.seen[key] = true
+	u.saved = append(u.saved, struct {
+		key, value string
+		exists     bool
+	}{key, value, exists})
+}
+
+func (u *kvUndo) existed(key string) bool {
+	for _, s := range u.saved {
+		if s.key == key {
+			return s.exists
+		}
+	}
+	return false
+}
+
+func (u *kvUndo) rollback() {
+	for i := len(u.saved) - 1; i >= 0; i-- {
+		s := u.saved[i]
+		var err error
+		if s.exists {
+			err = kvstore.Store(s.key, s.value)
+		} else {
+			err = kvstore.Delete(s.key)
+		}
+		if err != nil {
+			log.Printf("WARNING: rollback of %s failed: %s", s.key, err)
+		}
+		if name := strings.TrimPrefix(s.key, paramsPfx); name != s.key {
+			if s.exists {
+				recordChange(name, changeUpdate)
+			} else {
+				recordChange(name, changeDelete)
+			}
+		}
+	}
+}
+
 var kvMutex sync.Mutex
 
-func imageStore(path string, imtype string) string {
-	debugf("ImageStore(%s, %s)\n", path, imtype)
-	kvMutex.Lock()
-	defer kvMutex.Unlock()
-	kvstore.DistTimedLock(5)
+// The distributed lock is held per process, not per goroutine, so the
+// goroutines of this instance take turns with distLockMutex.
+var distLockMutex sync.Mutex
+
+// Function distLocked() runs fn holding the distributed lock, so that no
+// other goroutine or instance runs one at the same time.  If the lock cannot
+// be taken fn is not run.
+func distLocked(fn func() error) error {
+	distLockMutex.Lock()
+	defer distLockMutex.Unlock()
+	if err := kvstore.DistTimedLock(5); err != nil {
+		return fmt.Errorf("Cannot take the datastore lock: %s", err)
+	}
 	defer kvstore.DistUnlock()
+	return fn()
+}
 
-	kvl, err := getImages(imtype)
-	var k string
-	var imdata ImageData
-	if err == nil {
-		k, imdata = imageLookup(path, imtype, kvl)
-	}
-	debugf("imageLookup() -> (%s, %v)\n", k, imdata)
-	if k != "" {
-		// This path is already stored, return the key for it
-		return k
+// Function storeIfMissing() stores value at key unless the key exists, and
+// tells whether it did.  Test-and-set does not work on a missing key in etcd,
+// so it takes the distributed lock.
+func storeIfMissing(key, value string) (bool, error) {
+	stored := false
+	err := distLocked(func() error {
+		_, exists, err := kvstore.Get(key)
+		if err == nil && !exists {
+			err = kvstore.Store(key, value)
+			stored = err == nil
+		}
+		return err
+	})
+	return stored, err
+}
+
+// Function imageStore() returns the key of an image path, creating it if
+// needed.  Only creating a new image takes the lock; looking up an existing
+// one is a plain read.
+func imageStore(path string, imtype string) string {
+	debugf("ImageStore(%s, %s)\n", path, imtype)
+	if key := imageFind(path, imtype); key != "" {
+		return key
 	}
-	key := makeImageKey(imtype, path)
-	imdata = ImageData{path, ""}
-	err = storeData(key, imdata)
+	var key string
+	err := distLocked(func() error {
+		// Someone may have created it while we were waiting for the lock.
+		if key = imageFind(path, imtype); key != "" {
+			return nil
+		}
+		key = makeImageKey(imtype, path)
+		if imdata, exists := readImage(key); exists {
+			return fmt.Errorf("key %s is in use by %s", key, imdata.Path)
+		}
+		if err := storeData(key, ImageData{Path: path}); err != nil {
+			return err
+		}
+		recordDigest(key, path)
+		return nil
+	})
 	if err != nil {
-		debugf("Cannot store %s path %s: %v\n", imtype, path, err)
-		key = ""
+		log.Printf("Cannot store %s path %s: %s", imtype, path, err)
+		return ""
 	}
 	return key
 }
 
+// Function updateImage() replaces the data of an existing image with
+// compare-and-swap, so an update racing with another one or with removal of
+// the image cannot store data under a key that no longer holds that path.
+// An empty digest keeps the recorded one.
+func updateImage(key string, imdata ImageData) error {
+	var err error
+	for i := 0; err == nil && i < 10; i++ {
+		var val string
+		var data []byte
+		var exists, ok bool
+		if val, exists, err = kvstore.Get(key); err != nil {
+			break
+		}
+		var cur ImageData
+		if !exists || json.Unmarshal([]byte(val), &cur) != nil || cur.Path != imdata.Path {
+			err = fmt.Errorf("image changed while updating")
+			break
+		}
+		upd := imdata
+		if upd.Digest == "" {
+			upd.Digest = cur.Digest
+		}
+		if data, err = json.Marshal(upd); err != nil {
+			break
+		}
+		if val == string(data) {
+			return nil
+		}
+		if ok, err = kvstore.TAS(key, val, string(data)); err == nil && ok {
+			return nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("too much contention")
+	}
+	msg := fmt.Sprintf("Key %s update of '%v' failed: %s", key, imdata, err)
+	herr := base.NewHMSError("Storage", msg)
+	herr.AddProblem(base.NewProblemDetailsStatus(msg, http.StatusInternalServerError))
+	return herr
+}
+
 func nidName(nid int) string {
 	return fmt.Sprintf("nid%d", nid)
 }
 
-func Remove(bp bssTypes.BootParams) error {
-	debugf("Remove(): Ready to remove %v\n", bp)
-	var err error
-	for _, h := range bp.Hosts {
-		e := removeHost(h)
-		if err == nil {
-			err = e
-		}
-	}
+// Delete policies for images shared by several hosts.  With orphan, removing
+// an image leaves the hosts using it without one and removing a host leaves
+// its images in place.  Cascade also removes the hosts using a removed image
+// and the images of removed hosts that are no longer used.  Restrict refuses
+// to remove an image that is still in use by hosts not being removed.
+const (
+	deleteOrphan   = "orphan"
+	deleteCascade  = "cascade"
+	deleteRestrict = "restrict"
+)
+
+func validDeletePolicy(policy string) bool {
+	return policy == deleteOrphan || policy == deleteCascade || policy == deleteRestrict
+}
+
+// A DeleteConflict is returned when the restrict policy prevents a removal.
+type DeleteConflict struct {
+	Image string
+	Users []string
+}
+
+func (e DeleteConflict) Error() string {
+	return fmt.Sprintf("Image %s is in use by %s", e.Image, strings.Join(e.Users, ","))
+}
+
+// Function bpHostNames() returns the host entries named by the hosts, MACs
+// and NIDs of the boot parameters.
+func bpHostNames(bp bssTypes.BootParams) []string {
+	hosts := append([]string{}, bp.Hosts...)
 	for _, m := range bp.Macs {
 		comp, ok := FindSMCompByMAC(m)
 		if ok {
-			e := removeHost(comp.ID)
-			if err == nil {
-				err = e
-			}
+			hosts = append(hosts, comp.ID)
 		}
 	}
 	for _, n := range bp.Nids {
 		comp, ok := FindSMCompByNid(int(n))
 		if ok {
-			e := removeHost(comp.ID)
-			if err == nil {
-				err = e
-			}
+			hosts = append(hosts, comp.ID)
 		} else {
-			e := removeHost(nidName(int(n)))
-			if err == nil {
-				err = e
+			hosts = append(hosts, nidName(int(n)))
+		}
+	}
+	return hosts
+}
+
+// Function imageUsers() returns the host entries using an image key.
+func imageUsers(key string) []string {
+	var users []string
+	kvl, err := getTags()
+	if err != nil || key == "" {
+		return users
+	}
+	for _, x := range kvl {
+		var bds BootDataStore
+		if json.Unmarshal([]byte(x.Value), &bds) == nil && (bds.Kernel == key || bds.Initrd == key) {
+			users = append(users, extractParamName(x))
+		}
+	}
+	return users
+}
+
+func Remove(bp bssTypes.BootParams, policy string) error {
+	debugf("Remove(): Ready to remove %v (%s)\n", bp, policy)
+	var err error
+	hosts := bpHostNames(bp)
+	removing := make(map[string]bool)
+	for _, h := range hosts {
+		removing[h] = true
+	}
+	images := []struct{ path, imtype string }{{bp.Kernel, kernelImageType}, {bp.Initrd, initrdImageType}}
+	if policy == deleteRestrict {
+		for _, im := range images {
+			if im.path == "" {
+				continue
+			}
+			var users []string
+			for _, u := range imageUsers(imageFind(im.path, im.imtype)) {
+				if !removing[u] {
+					users = append(users, u)
+				}
+			}
+			if len(users) > 0 {
+				return DeleteConflict{im.path, users}
 			}
 		}
 	}
-	e := removeImage(bp.Kernel, kernelImageType)
-	if err == nil {
-		err = e
+	// Images of the removed hosts that may become unused.
+	unused := make(map[string]string)
+	if policy == deleteCascade {
+		for _, h := range hosts {
+			if bds, e := lookupHost(h); e == nil {
+				if bds.Kernel != "" {
+					unused[bds.Kernel] = kernelImageType
+				}
+				if bds.Initrd != "" {
+					unused[bds.Initrd] = initrdImageType
+				}
+			}
+		}
 	}
-	e = removeImage(bp.Initrd, initrdImageType)
-	if err == nil {
-		err = e
+	// A host that does not exist is reported, but a datastore failure undoes
+	// the removal of all the hosts.
+	var undo kvUndo
+	for _, h := range hosts {
+		e := undo.save(paramsPfx + h)
+		if e == nil {
+			e = removeHost(h)
+		}
+		if e != nil && undo.existed(paramsPfx+h) {
+			undo.rollback()
+			return e
+		}
+		if err == nil {
+			err = e
+		}
+	}
+	// The cascade runs before any image is removed so that a failure can
+	// still be undone.
+	for _, im := range images {
+		if policy != deleteCascade || im.path == "" {
+			continue
+		}
+		for _, u := range imageUsers(imageFind(im.path, im.imtype)) {
+			if removing[u] {
+				continue
+			}
+			removing[u] = true
+			log.Printf("Remove(): cascading delete of %s using %s", u, im.path)
+			e := undo.save(paramsPfx + u)
+			if e == nil {
+				e = removeHost(u)
+			}
+			if e != nil {
+				undo.rollback()
+				return e
+			}
+		}
+	}
+	for _, im := range images {
+		e := removeImage(im.path, im.imtype)
+		if err == nil {
+			err = e
+		}
+	}
+	for key, imtype := range unused {
+		if len(imageUsers(key)) > 0 {
+			continue
+		}
+		if imdata, e := getImage(key, ""); e == nil {
+			log.Printf("Remove(): removing unused %s %s", imtype, imdata.Path)
+			removeImage(imdata.Path, imtype)
+		}
 	}
 	return err
 }
@@ -286,6 +620,9 @@ func removeHost(h string) error {
 	} else if err == nil {
 		err = kvstore.Delete(key)
 	}
+	if err == nil {
+		recordChange(h, changeDelete)
+	}
 	if err != nil {
 		msg := fmt.Sprintf("Key %s deletion: %s", h, err.Error())
 		herr := base.NewHMSError("Storage", msg)
@@ -339,11 +676,22 @@ func extractParamName(x hmetcd.Kvi_KV) (ret string) {
 	return ret
 }
 
-func StoreNew(bp bssTypes.BootParams) (error, string) {
+func StoreNew(bp bssTypes.BootParams, who string) (error, string) {
 	item := ""
 	// Go through the entire struct.  We must be storing to new hosts or this
 	// request must fail.
 	switch {
+	case len(bp.Hosts) >= bulkStoreMin:
+		existing, err := existingHosts()
+		if err != nil {
+			return err, ""
+		}
+		for _, h := range bp.Hosts {
+			if _, ok := existing[h]; ok {
+				item = h
+				break
+			}
+		}
 	case len(bp.Hosts) > 0:
 		for _, h := range bp.Hosts {
 			_, err := lookupHost(h)
@@ -355,7 +703,7 @@ func StoreNew(bp bssTypes.BootParams) (error, string) {
 	case len(bp.Macs) > 0:
 		// Deal with MAC addresses
 		for _, m := range bp.Macs {
-			comp, ok := FindSMCompByMAC(m)
+			comp, ok := findCompByMACFresh(m)
 			if ok {
 				if _, err := lookupHost(comp.ID); err == nil {
 					item = m
@@ -366,7 +714,7 @@ func StoreNew(bp bssTypes.BootParams) (error, string) {
 	case len(bp.Nids) > 0:
 		// Deal with Nids addresses
 		for _, n := range bp.Nids {
-			comp, ok := FindSMCompByNid(int(n))
+			comp, ok := findCompByNidFresh(int(n))
 			if ok {
 				if _, err := lookupHost(comp.ID); err == nil {
 					item = fmt.Sprintf("%d", n)
@@ -386,11 +734,28 @@ func StoreNew(bp bssTypes.BootParams) (error, string) {
 	if item != "" {
 		return fmt.Errorf("Already exists: %s", item), ""
 	} else {
-		return Store(bp)
+		return Store(bp, who)
+	}
+}
+
+// Function provenance() returns the provenance for a change by who, keeping
+// the creation time of existing data.
+func provenance(existing *bssTypes.Provenance, who string) *bssTypes.Provenance {
+	now := time.Now().Unix()
+	p := &bssTypes.Provenance{CreatedAt: now, UpdatedAt: now, UpdatedBy: who}
+	if existing != nil && existing.CreatedAt != 0 {
+		p.CreatedAt = existing.CreatedAt
 	}
+	return p
 }
 
-func Store(bp bssTypes.BootParams) (error, string) {
+func Store(bp bssTypes.BootParams, who string) (error, string) {
+	return storeIf(bp, who, nil)
+}
+
+// Function storeIf() is Store() replacing the boot parameters of the hosts
+// in expect only if they still have the stored value given there.
+func storeIf(bp bssTypes.BootParams, who string, expect map[string]string) (error, string) {
 	debugf("Store(%v)\n", bp)
 
 	var kernel_id, initrd_id string
@@ -406,14 +771,55 @@ func Store(bp bssTypes.BootParams) (error, string) {
 			return fmt.Errorf("Cannot store image path %s", bp.Initrd), ""
 		}
 	}
+	if err := setImageDigests(bp, kernel_id, initrd_id); err != nil {
+		return err, ""
+	}
+	flagUnapprovedImages(bp, kernel_id, initrd_id)
+
+	if err := storeFirstBootImages(bp.FirstBoot); err != nil {
+		return err, ""
+	}
+	if err := checkMessages(bp.Messages); err != nil {
+		return err, ""
+	}
+	if err := checkReasons(bp.Reasons); err != nil {
+		return err, ""
+	}
+	if err := checkConditionals(bp.Params); err != nil {
+		return err, ""
+	}
+	if err := checkPayload(bp.Payload, bp.Files); err != nil {
+		return err, ""
+	}
+	if err := checkDeviceFiles(bpHostNames(bp), bp.Payload, bp.DTB, bp.ACPI); err != nil {
+		return err, ""
+	}
+	if err := checkOverlays(bp.Payload, bp.Initrd, bp.InitrdOverlays); err != nil {
+		return err, ""
+	}
 
-	referralToken := uuid.New().String()
-	bd := BootDataStore{bp.Params, kernel_id, initrd_id, bp.CloudInit, referralToken}
+	referralToken := idgen.New()
+	bd := BootDataStore{bp.Params, kernel_id, initrd_id, bp.CloudInit, referralToken, bp.FirstBoot, bp.Messages, bp.Labels, bp.Reasons, bp.Payload, bp.Files, bp.DTB, bp.ACPI, bp.InitrdOverlays, nil}
+	var undo kvUndo
+	storeHost := func(name string) error {
+		if err := undo.save(paramsPfx + name); err != nil {
+			return err
+		}
+		hbd := bd
+		old, err := lookupHost(name)
+		if err != nil {
+			old.Provenance = nil
+		}
+		hbd.Provenance = provenance(old.Provenance, who)
+		return storeHostData(name, hbd, expect)
+	}
 	var err error
 	switch {
+	case len(bp.Hosts) >= bulkStoreMin && expect == nil:
+		err = storeHostsBulk(bp.Hosts, bd, who, &undo)
 	case len(bp.Hosts) > 0:
 		for _, h := range bp.Hosts {
-			err = storeData(paramsPfx+h, bd)
+			err = storeHost(h)
 			if err != nil {
 				break
 			}
@@ -421,16 +827,16 @@ func Store(bp bssTypes.BootParams) (error, string) {
 	case len(bp.Macs) > 0:
 		// Deal with MAC addresses
 		for _, m := range bp.Macs {
-			comp, ok := FindSMCompByMAC(m)
+			comp, ok := findCompByMACFresh(m)
 			if ok {
-				err = storeData(paramsPfx+comp.ID, bd)
+				err = storeHost(comp.ID)
 				if err != nil {
 					break
 				}
 			} else {
 				// If the State Manager doesn't know about
 				// it, store based on the MAC address.
-				err = storeData(paramsPfx+m, bd)
+				err = storeHost(macKeyName(m))
 				if err != nil {
 					break
 				}
@@ -439,40 +845,50 @@ func Store(bp bssTypes.BootParams) (error, string) {
 	case len(bp.Nids) > 0:
 		// Deal with Nids addresses
 		for _, n := range bp.Nids {
-			comp, ok := FindSMCompByNid(int(n))
+			comp, ok := findCompByNidFresh(int(n))
 			if ok {
-				err = storeData(paramsPfx+comp.ID, bd)
+				err = storeHost(comp.ID)
 				if err != nil {
 					break
 				}
 			} else {
 				// If the State Manager doesn't know about
 				// it, store based on the NID.
-				err = storeData(paramsPfx+nidName(int(n)), bd)
+				err = storeHost(nidName(int(n)))
 				if err != nil {
 					break
 				}
 			}
 		}
 	case kernel_id != "":
-		idata := ImageData{bp.Kernel, bp.Params}
+		idata := ImageData{Path: bp.Kernel, Params: bp.Params}
 		debugf("Ready to store data: %s, %v\n", kernel_id, idata)
-		err = storeData(kernel_id, idata)
+		err = updateImage(kernel_id, idata)
 		referralToken = "" // referralToken was not needed
 	case initrd_id != "":
-		err = storeData(initrd_id, ImageData{bp.Initrd, bp.Params})
+		err = updateImage(initrd_id, ImageData{Path: bp.Initrd, Params: bp.Params})
 		referralToken = "" // referralToken was not needed
 	default:
 		herr := base.NewHMSError("Storage", "Nothing to Store")
 		herr.AddProblem(base.NewProblemDetailsStatus("Nothing to Store", http.StatusBadRequest))
 		referralToken = "" // referralToken was not needed
 	}
+	if err != nil {
+		// Leave none of the hosts half done.
+		undo.rollback()
+	}
 	debugf("Store referralToken: %s\n", referralToken)
 	return err, referralToken
 }
 
 // The update function will update entries but not NULL out existing entries.
-func Update(bp bssTypes.BootParams) error {
+func Update(bp bssTypes.BootParams, who string) error {
+	return updateIf(bp, who, nil)
+}
+
+// Function updateIf() is Update() changing the boot parameters of the hosts
+// in expect only if they still have the stored value given there.
+func updateIf(bp bssTypes.BootParams, who string, expect map[string]string) error {
 	debugf("Update(%v)\n", bp)
 	var kernel_id, initrd_id string
 	var err error
@@ -482,6 +898,22 @@ func Update(bp bssTypes.BootParams) error {
 	if bp.Initrd != "" {
 		initrd_id = imageStore(bp.Initrd, initrdImageType)
 	}
+	if err = setImageDigests(bp, kernel_id, initrd_id); err != nil {
+		return err
+	}
+	flagUnapprovedImages(bp, kernel_id, initrd_id)
+	if err = storeFirstBootImages(bp.FirstBoot); err != nil {
+		return err
+	}
+	if err = checkMessages(bp.Messages); err != nil {
+		return err
+	}
+	if err = checkReasons(bp.Reasons); err != nil {
+		return err
+	}
+	if err = checkConditionals(bp.Params); err != nil {
+		return err
+	}
 	checkHost := func(hostMap *map[string]BootDataStore, h string) error {
 		_, ok := (*hostMap)[h]
 		if !ok {
@@ -507,7 +939,7 @@ func Update(bp bssTypes.BootParams) error {
 			// let's see if this host name has boot data.
 			err = checkHost(&hostMap, comp.ID)
 			if err != nil {
-				err = checkHost(&hostMap, m)
+				err = checkHost(&hostMap, macKeyName(m))
 			}
 			if err != nil {
 				return err
@@ -527,8 +959,27 @@ func Update(bp bssTypes.BootParams) error {
 		}
 	}
 
+	// Validate the payloads first, so that nothing is stored if one is bad.
+	for h, bd := range hostMap {
+		if err = checkPayload(patchPayload(bp, bd)); err != nil {
+			return fmt.Errorf("%s: %s", h, err)
+		}
+		payload, _ := patchPayload(bp, bd)
+		dtb, acpi := patchDeviceFiles(bp, bd)
+		if err = checkDeviceFiles([]string{h}, payload, dtb, acpi); err != nil {
+			return fmt.Errorf("%s: %s", h, err)
+		}
+		initrd := bd.Initrd
+		if bp.Initrd != "" {
+			initrd = bp.Initrd
+		}
+		if err = checkOverlays(payload, initrd, patchOverlays(bp, bd)); err != nil {
+			return fmt.Errorf("%s: %s", h, err)
+		}
+	}
 	switch {
 	case len(hostMap) > 0:
+		var undo kvUndo
 		for h, bd := range hostMap {
 			updated := false
 			if bp.Params != "" && bp.Params != bd.Params {
@@ -546,18 +997,53 @@ func Update(bp bssTypes.BootParams) error {
 			if updateCloudInit(&bd.CloudInit, bp.CloudInit) {
 				updated = true
 			}
+			if bp.FirstBoot != nil && !reflect.DeepEqual(bp.FirstBoot, bd.FirstBoot) {
+				updated = true
+				bd.FirstBoot = bp.FirstBoot
+			}
+			if bp.Messages != nil && !reflect.DeepEqual(bp.Messages, bd.Messages) {
+				updated = true
+				bd.Messages = bp.Messages
+			}
+			if bp.Labels != nil && !reflect.DeepEqual(bp.Labels, bd.Labels) {
+				updated = true
+				bd.Labels = bp.Labels
+			}
+			if bp.Reasons != nil && !reflect.DeepEqual(bp.Reasons, bd.Reasons) {
+				updated = true
+				bd.Reasons = bp.Reasons
+			}
+			if payload, files := patchPayload(bp, bd); payload != bd.Payload || !reflect.DeepEqual(files, bd.Files) {
+				updated = true
+				bd.Payload, bd.Files = payload, files
+			}
+			if dtb, acpi := patchDeviceFiles(bp, bd); dtb != bd.DTB || !reflect.DeepEqual(acpi, bd.ACPI) {
+				updated = true
+				bd.DTB, bd.ACPI = dtb, acpi
+			}
+			if overlays := patchOverlays(bp, bd); !reflect.DeepEqual(overlays, bd.Overlays) {
+				updated = true
+				bd.Overlays = overlays
+			}
 			if updated {
-				err = storeData(paramsPfx+h, bd)
+				if err = undo.save(paramsPfx + h); err == nil {
+					bd.Provenance = provenance(bd.Provenance, who)
+					err = storeHostData(h, bd, expect)
+				}
+				if err != nil {
+					undo.rollback()
+					return err
+				}
 			}
 		}
 	case kernel_id != "":
 		// If no hosts were specified, then we should update the
 		// parameters associated with the kernel image.
-		idata := ImageData{bp.Kernel, bp.Params}
+		idata := ImageData{Path: bp.Kernel, Params: bp.Params}
 		debugf("Ready to store data: %s, %v\n", kernel_id, idata)
-		err = storeData(kernel_id, idata)
+		err = updateImage(kernel_id, idata)
 	case initrd_id != "":
-		err = storeData(initrd_id, ImageData{bp.Initrd, bp.Params})
+		err = updateImage(initrd_id, ImageData{Path: bp.Initrd, Params: bp.Params})
 	default:
 		// No changes required so we are done.
 		return nil
@@ -628,7 +1114,9 @@ func updateCloudInit(d *bssTypes.CloudInit, p bssTypes.CloudInit) bool {
 }
 
 func updateEndpointAccessed(name string, accessType bssTypes.EndpointType) {
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	now := time.Now()
+	countAccess(name, accessType, now)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
 	key := fmt.Sprintf("%s/%s/%s", endpointAccessPfx, name, accessType)
 	if err := kvstore.Store(key, timestamp); err != nil {
 		log.Printf("Failed to store last access timestamp %s to key %s: %s",
@@ -680,7 +1168,18 @@ func getAccessesForPrefix(prefix string) (accesses []bssTypes.EndpointAccess, er
 	return
 }
 
-func SearchEndpointAccessed(name string, endpointType bssTypes.EndpointType) (accesses []bssTypes.EndpointAccess,
+// Function SearchEndpointAccessed() returns the endpoint accesses matching
+// name and endpointType that scope can see.
+func SearchEndpointAccessed(name string, endpointType bssTypes.EndpointType,
+	scope historyScope) ([]bssTypes.EndpointAccess, error) {
+	accesses, err := searchEndpointAccessed(name, endpointType)
+	if err != nil {
+		return nil, err
+	}
+	return scope.apply(accesses), nil
+}
+
+func searchEndpointAccessed(name string, endpointType bssTypes.EndpointType) (accesses []bssTypes.EndpointAccess,
 	err error) {
 	if name == "" && endpointType == "" {
 		return getAccessesForPrefix(fmt.Sprintf("%s/", endpointAccessPfx))
@@ -744,11 +1243,21 @@ func getEndpointAccessed(name string, endpointType bssTypes.EndpointType) (int64
 }
 
 func getTags() ([]hmetcd.Kvi_KV, error) {
-	return kvstore.GetRange(paramsPfx+keyMin, paramsPfx+keyMax)
+	return getTagsFrom(kvstore)
+}
+
+// Function getTagsFrom() reads the boot parameter entries from kv, e.g. the
+// replica returned by replicaReads().
+func getTagsFrom(kv hmetcd.Kvi) ([]hmetcd.Kvi_KV, error) {
+	return kv.GetRange(paramsPfx+keyMin, paramsPfx+keyMax)
 }
 
 func GetNamesAndValues() map[string]string {
-	kvl, err := getTags()
+	return getNamesAndValuesFrom(kvstore)
+}
+
+func getNamesAndValuesFrom(kv hmetcd.Kvi) map[string]string {
+	kvl, err := getTagsFrom(kv)
 	m := make(map[string]string)
 	if err == nil {
 		for _, x := range kvl {
@@ -770,8 +1279,12 @@ func GetNames() (ret []string) {
 }
 
 func LookupBootData(name string) (BootData, error) {
+	return lookupBootDataFrom(kvstore, name)
+}
+
+func lookupBootDataFrom(kv hmetcd.Kvi, name string) (BootData, error) {
 	var bd BootData
-	bds, err := lookupHost(name)
+	bds, err := lookupHostFrom(kv, name)
 	if err != nil {
 		return bd, err
 	}
@@ -780,8 +1293,12 @@ func LookupBootData(name string) (BootData, error) {
 }
 
 func lookupHost(name string) (BootDataStore, error) {
+	return lookupHostFrom(kvstore, name)
+}
+
+func lookupHostFrom(kv hmetcd.Kvi, name string) (BootDataStore, error) {
 	key := paramsPfx + name
-	val, exists, err := kvstore.Get(key)
+	val, exists, err := kv.Get(key)
 	var bds BootDataStore
 	if !exists && err == nil {
 		err = fmt.Errorf("Key %s does not exist", key)
@@ -806,39 +1323,69 @@ func lookupHost(name string) (BootDataStore, error) {
 // the default tag.  If boot parameter data is found, it will then convert from
 // storage format to an external format.  This conversion process involves
 // looking up the keys for the kernel and initrd images to their actual values,
-// namely their paths and any associated parameters.
+// namely their paths and any associated parameters.  If the host has not yet
+// completed its first boot, any first boot configuration is applied.  A
+// temporary override of the host replaces all of it.
 func lookup(name, altName, role, defaultTag string) BootData {
-	bds, err := lookupHost(name)
+	return lookupFrom(kvstore, name, altName, role, defaultTag)
+}
+
+// Function lookupFrom() is lookup() reading the entries from kv.
+func lookupFrom(kv hmetcd.Kvi, name, altName, role, defaultTag string) BootData {
+	var bd BootData
+	bds, err := lookupStoreFrom(kv, name, altName, role, defaultTag)
+	if err == nil {
+		bd = bdConvert(bds)
+		if firstBootPending(name, bds) {
+			applyFirstBoot(&bd)
+		}
+	}
+	applyOverride(name, &bd)
+	return bd
+}
+
+// Function lookupStore() finds the boot parameter data in storage format
+// following the same name, alternate name, role, default order as lookup().
+func lookupStore(name, altName, role, defaultTag string) (BootDataStore, error) {
+	return lookupStoreFrom(kvstore, name, altName, role, defaultTag)
+}
+
+func lookupStoreFrom(kv hmetcd.Kvi, name, altName, role, defaultTag string) (BootDataStore, error) {
+	bds, err := lookupHostFrom(kv, name)
 	if err != nil && name != altName && altName != "" {
-		bds, err = lookupHost(altName)
+		bds, err = lookupHostFrom(kv, altName)
 	}
 
 	var tmpErr error
 	if err != nil && role != "" {
-		bds, tmpErr = lookupHost(role)
+		bds, tmpErr = lookupHostFrom(kv, role)
 		if tmpErr == nil {
 			err = nil
 		}
 	}
 	if err != nil && defaultTag != "" {
-		bds, tmpErr = lookupHost(defaultTag)
+		bds, tmpErr = lookupHostFrom(kv, defaultTag)
 		if tmpErr != nil {
 			debugf("Boot data for %s not available: %v\n", name, err)
 		} else {
 			err = nil
 		}
 	}
-
-	var bd BootData
-	if err == nil {
-		bd = bdConvert(bds)
-	}
-	return bd
+	return bds, err
 }
 
 func bdConvertUsingImageCache(bds BootDataStore, kernelImages map[string]ImageData, initrdImages map[string]ImageData) (ret BootData) {
 	ret.Params = bds.Params
 	ret.CloudInit = bds.CloudInit
+	ret.FirstBoot = bds.FirstBoot
+	ret.Messages = bds.Messages
+	ret.Labels = bds.Labels
+	ret.Reasons = bds.Reasons
+	ret.Payload = bds.Payload
+	ret.Files = bds.Files
+	ret.DTB, ret.ACPI = bds.DTB, bds.ACPI
+	ret.Overlays = bds.Overlays
+	ret.Provenance = bds.Provenance
 	if bds.Kernel != "" {
 		if value, ok := kernelImages[bds.Kernel]; ok {
 			ret.Kernel = value
@@ -868,6 +1415,15 @@ func bdConvert(bds BootDataStore) (ret BootData) {
 	ret.Params = bds.Params
 	ret.CloudInit = bds.CloudInit
 	ret.ReferralToken = bds.ReferralToken
+	ret.FirstBoot = bds.FirstBoot
+	ret.Messages = bds.Messages
+	ret.Labels = bds.Labels
+	ret.Reasons = bds.Reasons
+	ret.Payload = bds.Payload
+	ret.Files = bds.Files
+	ret.DTB, ret.ACPI = bds.DTB, bds.ACPI
+	ret.Overlays = bds.Overlays
+	ret.Provenance = bds.Provenance
 	if bds.Kernel != "" {
 		imdata, err := getImage(bds.Kernel, "")
 		if err == nil {
@@ -918,6 +1474,10 @@ func ToBootData(value string, kernelImages map[string]ImageData, initrdImages ma
 }
 
 func LookupByName(name string) (BootData, SMComponent) {
+	return lookupByNameFrom(kvstore, name)
+}
+
+func lookupByNameFrom(kv hmetcd.Kvi, name string) (BootData, SMComponent) {
 	comp_name := name
 	comp, ok := FindSMCompByName(name)
 	role := ""
@@ -925,10 +1485,15 @@ func LookupByName(name string) (BootData, SMComponent) {
 		comp_name = comp.ID
 		role = comp.Role
 	}
-	return lookup(comp_name, name, role, DefaultTag), comp
+	return lookupFrom(kv, comp_name, name, role, DefaultTag), comp
 }
 
 func LookupByMAC(mac string) (BootData, SMComponent) {
+	return lookupByMACFrom(kvstore, mac)
+}
+
+func lookupByMACFrom(kv hmetcd.Kvi, mac string) (BootData, SMComponent) {
+	mac = macKeyName(mac)
 	comp_name := mac
 	comp, ok := FindSMCompByMAC(mac)
 	role := ""
@@ -936,10 +1501,14 @@ func LookupByMAC(mac string) (BootData, SMComponent) {
 		comp_name = comp.ID
 		role = comp.Role
 	}
-	return lookup(comp_name, mac, role, DefaultTag), comp
+	return lookupFrom(kv, comp_name, mac, role, DefaultTag), comp
 }
 
 func LookupByNid(nid int) (BootData, SMComponent) {
+	return lookupByNidFrom(kvstore, nid)
+}
+
+func lookupByNidFrom(kv hmetcd.Kvi, nid int) (BootData, SMComponent) {
 	nid_str := nidName(nid)
 	comp_name := nid_str
 	comp, ok := FindSMCompByNid(nid)
@@ -948,7 +1517,7 @@ func LookupByNid(nid int) (BootData, SMComponent) {
 		comp_name = comp.ID
 		role = comp.Role
 	}
-	return lookup(comp_name, nid_str, role, DefaultTag), comp
+	return lookupFrom(kv, comp_name, nid_str, role, DefaultTag), comp
 }
 
 func dumpDataStore() {
@@ -0,0 +1,277 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot verification.
+//
+// Serving a boot script does not mean the node actually booted.  When a PCS
+// (Power Control Service) URL is configured, BSS follows up on each boot
+// script it serves, polling PCS for the node's power state and HSM for its
+// heartbeat state until the node either comes up or the verification times
+// out.  A node counts as up once it has phoned home since the boot script was
+// served, or once PCS reports it powered on and HSM reports it Ready.  The
+// results are kept in the KV store so "served but never booted" nodes can be
+// found for failure triage.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	bootVerifyPfx     = "/bootverify/"
+	bootVerifyPending = "pending"
+	bootVerifyBooted  = "booted"
+	bootVerifyFailed  = "failed"
+)
+
+var (
+	pcsBase                = ""
+	bootVerifyTimeout      = uint(900)
+	bootVerifyInterval     = uint(30)
+	pcsClient              *http.Client
+	bootVerifyMutex        sync.Mutex
+	bootVerifyServed       = make(map[string]int64)
+	bootVerifyState        = getHSMState
+	bootVerifyPowerState   = getPCSPowerState
+	bootVerifyPollInterval = func() time.Duration { return time.Duration(bootVerifyInterval) * time.Second }
+)
+
+type bootVerification struct {
+	Name       string `json:"name"`
+	Result     string `json:"result"`
+	Served     int64  `json:"served"`
+	Checked    int64  `json:"checked,omitempty"`
+	PowerState string `json:"power-state,omitempty"`
+	State      string `json:"state,omitempty"`
+
+	// Tail of the console of a failed boot, see boot_console.go.
+	Console      []string `json:"console,omitempty"`
+	ConsoleError string   `json:"console-error,omitempty"`
+}
+
+func pcsInit(urlBase, opts string) error {
+	if urlBase == "" {
+		return nil
+	}
+	u, err := url.Parse(urlBase)
+	if err != nil {
+		return fmt.Errorf("URL parse error %s, URL: %s", err, urlBase)
+	}
+	https := u.Scheme == "https"
+	insecure := false
+	for _, opt := range strings.Split(opts, ",") {
+		if strings.ToLower(opt) == "insecure" {
+			insecure = true
+			break
+		}
+	}
+	pcsClient = &http.Client{Timeout: 30 * time.Second}
+	if https && insecure {
+		tcfg := new(tls.Config)
+		tcfg.InsecureSkipVerify = true
+		trans := new(http.Transport)
+		trans.TLSClientConfig = tcfg
+		pcsClient.Transport = trans
+		log.Printf("WARNING: insecure https connection to power control service\n")
+	}
+	log.Printf("Verifying boots via PCS at %s, timeout %ds", urlBase, bootVerifyTimeout)
+	return nil
+}
+
+func getJSON(client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s failed: %s", u, rsp.Status)
+	}
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
+
+func getPCSPowerState(name string) (string, error) {
+	var rsp struct {
+		Status []struct {
+			Xname      string `json:"xname"`
+			PowerState string `json:"powerState"`
+			Error      string `json:"error"`
+		} `json:"status"`
+	}
+	err := getJSON(pcsClient, strings.TrimRight(pcsBase, "/")+"/v1/power-status?xname="+url.QueryEscape(name), &rsp)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range rsp.Status {
+		if s.Xname == name {
+			if s.Error != "" {
+				return s.PowerState, fmt.Errorf("PCS: %s", s.Error)
+			}
+			return s.PowerState, nil
+		}
+	}
+	return "", fmt.Errorf("PCS has no power status for %s", name)
+}
+
+// The cached HSM state is not refreshed often enough to follow a boot, so ask
+// HSM directly when possible.
+func getHSMState(name string) (string, error) {
+	if smClient == nil {
+		comp, _ := FindSMCompByNameInCache(name)
+		return comp.State, nil
+	}
+	var comp SMComponent
+	err := getJSON(smClient, smBaseURL+"/State/Components/"+url.PathEscape(name), &comp)
+	return comp.State, err
+}
+
+func getBootVerification(name string) (bootVerification, bool, error) {
+	var v bootVerification
+	val, exists, err := kvstore.Get(bootVerifyPfx + name)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &v)
+	}
+	return v, exists, err
+}
+
+// Function checkBoot() polls the node state once and updates the result.
+func checkBoot(v *bootVerification) {
+	v.Checked = time.Now().Unix()
+	phoned, err := getEndpointAccessed(v.Name, bssTypes.EndpointTypePhoneHome)
+	if err == nil && phoned >= v.Served {
+		v.Result = bootVerifyBooted
+		return
+	}
+	if v.PowerState, err = bootVerifyPowerState(v.Name); err != nil {
+		debugf("Boot verification of %s: %s", v.Name, err)
+	}
+	if v.State, err = bootVerifyState(v.Name); err != nil {
+		debugf("Boot verification of %s: %s", v.Name, err)
+	}
+	if strings.EqualFold(v.PowerState, "on") && strings.EqualFold(v.State, "Ready") {
+		v.Result = bootVerifyBooted
+	}
+}
+
+func verifyBootLoop(v bootVerification) {
+	deadline := time.Unix(v.Served, 0).Add(time.Duration(bootVerifyTimeout) * time.Second)
+	for v.Result == bootVerifyPending {
+		time.Sleep(bootVerifyPollInterval())
+		bootVerifyMutex.Lock()
+		current := bootVerifyServed[v.Name]
+		bootVerifyMutex.Unlock()
+		if current != v.Served {
+			// A newer boot script was served, its own loop takes over.
+			return
+		}
+		checkBoot(&v)
+		if v.Result == bootVerifyPending && time.Now().After(deadline) {
+			v.Result = bootVerifyFailed
+			log.Printf("Boot verification failed for %s: power %s, state %s",
+				v.Name, v.PowerState, v.State)
+			captureConsole(&v)
+		}
+		if err := storeData(bootVerifyPfx+v.Name, v); err != nil {
+			log.Printf("Failed to store boot verification for %s: %s", v.Name, err)
+		}
+	}
+	bootVerifyMutex.Lock()
+	if bootVerifyServed[v.Name] == v.Served {
+		delete(bootVerifyServed, v.Name)
+	}
+	bootVerifyMutex.Unlock()
+}
+
+// Function verifyBoot() starts verification of a node's boot after its boot
+// script was served.
+func verifyBoot(name string) {
+	if pcsClient == nil || name == "" {
+		return
+	}
+	v := bootVerification{Name: name, Result: bootVerifyPending, Served: time.Now().Unix()}
+	if err := storeData(bootVerifyPfx+name, v); err != nil {
+		log.Printf("Failed to store boot verification for %s: %s", name, err)
+		return
+	}
+	bootVerifyMutex.Lock()
+	bootVerifyServed[name] = v.Served
+	bootVerifyMutex.Unlock()
+	go verifyBootLoop(v)
+}
+
+func BootVerifyGet(w http.ResponseWriter, r *http.Request) {
+	debugf("BootVerifyGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	result := strings.Join(r.Form["result"], "")
+	results := []bootVerification{}
+	if names := r.Form["name"]; len(names) > 0 {
+		for _, n := range strings.Split(strings.Join(names, ","), ",") {
+			v, exists, err := getBootVerification(n)
+			if err != nil {
+				base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+					fmt.Sprintf("Failed to retrieve boot verification for %s: %s", n, err))
+				return
+			}
+			if exists && (result == "" || v.Result == result) {
+				results = append(results, v)
+			}
+		}
+	} else {
+		kvl, err := kvstore.GetRange(bootVerifyPfx+keyMin, bootVerifyPfx+keyMax)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to retrieve boot verifications: %s", err))
+			return
+		}
+		for _, kv := range kvl {
+			var v bootVerification
+			if err = json.Unmarshal([]byte(kv.Value), &v); err == nil &&
+				(result == "" || v.Result == result) {
+				results = append(results, v)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(results)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,93 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPCSPowerState(t *testing.T) {
+	pcs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/power-status" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": []map[string]string{{"xname": r.URL.Query().Get("xname"), "powerState": "on"}},
+		})
+	}))
+	defer pcs.Close()
+	savedBase, savedClient := pcsBase, pcsClient
+	pcsBase, pcsClient = pcs.URL, pcs.Client()
+	defer func() { pcsBase, pcsClient = savedBase, savedClient }()
+
+	if state, err := getPCSPowerState("x0c0s1b0n0"); err != nil || state != "on" {
+		t.Errorf("getPCSPowerState returned '%s', %v", state, err)
+	}
+	v := bootVerification{Name: "x0c0s1b0n0", Result: bootVerifyPending, Served: time.Now().Unix()}
+	checkBoot(&v)
+	if v.Result != bootVerifyBooted {
+		t.Errorf("checkBoot returned %s with power %s state %s", v.Result, v.PowerState, v.State)
+	}
+}
+
+func TestBootVerifyTimeout(t *testing.T) {
+	const host = "x0c0s5b0n0"
+	savedClient, savedTimeout, savedPower, savedInterval :=
+		pcsClient, bootVerifyTimeout, bootVerifyPowerState, bootVerifyPollInterval
+	pcsClient = http.DefaultClient
+	bootVerifyTimeout = 0
+	bootVerifyPowerState = func(string) (string, error) { return "off", nil }
+	bootVerifyPollInterval = func() time.Duration { return time.Millisecond }
+	defer func() {
+		pcsClient, bootVerifyTimeout, bootVerifyPowerState, bootVerifyPollInterval =
+			savedClient, savedTimeout, savedPower, savedInterval
+		kvstore.Delete(bootVerifyPfx + host)
+	}()
+
+	verifyBoot(host)
+	for i := 0; i < 1000; i++ {
+		bootVerifyMutex.Lock()
+		_, running := bootVerifyServed[host]
+		bootVerifyMutex.Unlock()
+		if !running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootverify?result="+bootVerifyFailed, nil)
+	rr := httptest.NewRecorder()
+	bootVerify(rr, req)
+	var results []bootVerification
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Failed to decode bootverify response: %s", err)
+	}
+	if len(results) != 1 || results[0].Name != host || results[0].PowerState != "off" {
+		t.Errorf("Unexpected failed boot verifications: %v", results)
+	}
+}
@@ -0,0 +1,300 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot ordering dependencies.
+//
+// A boot dependency says that the nodes in one group should not boot until
+// the nodes in other groups are up, for example storage before compute.  A
+// node counts as up once it has phoned home after its most recent boot script
+// request.  When enabled, the boot script of a dependent node loops, sleeping
+// and polling the readiness endpoint, until its dependencies are up.
+//
+// Dependencies are stored per group in the KV store.  This is off by default.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const bootDepsPfx = "/bootdeps/"
+
+var (
+	bootDepsEnabled = false
+	bootDepsWait    = uint(10)
+)
+
+type bootDepsStatus struct {
+	Name    string   `json:"name"`
+	Ready   bool     `json:"ready"`
+	Waiting []string `json:"waiting,omitempty"`
+}
+
+func getBootDeps() ([]bssTypes.BootDependency, error) {
+	deps := []bssTypes.BootDependency{}
+	kvl, err := kvstore.GetRange(bootDepsPfx+keyMin, bootDepsPfx+keyMax)
+	if err != nil {
+		return deps, err
+	}
+	for _, kv := range kvl {
+		var dep bssTypes.BootDependency
+		if err = json.Unmarshal([]byte(kv.Value), &dep); err != nil {
+			log.Printf("Bad boot dependency at %s: %s", kv.Key, err)
+			continue
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// Function bootDepsFor() returns the groups the component has to wait for.
+func bootDepsFor(comp SMComponent) []string {
+	var after []string
+	deps, err := getBootDeps()
+	if err != nil {
+		log.Printf("Failed to retrieve boot dependencies: %s", err)
+		return after
+	}
+	for _, dep := range deps {
+		if inGroup(comp, dep.Group) {
+			after = append(after, dep.After...)
+		}
+	}
+	return after
+}
+
+// Function bootDepsCycle() returns the groups through which dep, replacing
+// the stored dependency of its group, would make the group wait for itself,
+// or nil if it would not.  Group names are compared ignoring case.
+func bootDepsCycle(deps []bssTypes.BootDependency, dep bssTypes.BootDependency) []string {
+	after := make(map[string][]string)
+	for _, d := range deps {
+		after[strings.ToLower(d.Group)] = d.After
+	}
+	after[strings.ToLower(dep.Group)] = dep.After
+	seen := make(map[string]bool)
+	var path []string
+	var visit func(group string) bool
+	visit = func(group string) bool {
+		g := strings.ToLower(group)
+		path = append(path, group)
+		if len(path) > 1 && strings.EqualFold(group, dep.Group) {
+			return true
+		}
+		if !seen[g] {
+			seen[g] = true
+			for _, a := range after[g] {
+				if visit(a) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(dep.Group) {
+		return path
+	}
+	return nil
+}
+
+func nodeIsUp(name string) bool {
+	phoned, err := getEndpointAccessed(name, bssTypes.EndpointTypePhoneHome)
+	if err != nil || phoned <= 0 {
+		return false
+	}
+	booted, err := getEndpointAccessed(name, bssTypes.EndpointTypeBootscript)
+	return err == nil && phoned >= booted
+}
+
+// Function bootDepsWaiting() returns the nodes the component is still
+// waiting for.
+func bootDepsWaiting(comp SMComponent) []string {
+	var waiting []string
+	seen := make(map[string]bool)
+	for _, group := range bootDepsFor(comp) {
+		for _, m := range groupMembers(group) {
+			if m.ID == comp.ID || seen[m.ID] {
+				continue
+			}
+			seen[m.ID] = true
+			if !nodeIsUp(m.ID) {
+				waiting = append(waiting, m.ID)
+			}
+		}
+	}
+	return waiting
+}
+
+// Function bootDepsWaitScript() returns the iPXE wait loop for a node with
+// boot dependencies, or an empty string if it has none.
+func bootDepsWaitScript(comp SMComponent, msgs bootMessages) string {
+	if comp.ID == "" || len(bootDepsFor(comp)) == 0 {
+		return ""
+	}
+	ready := chainProto + "://" + urlHost(ipxeServer) + gwURI + baseEndpoint +
+		"/bootdeps/ready?name=" + url.QueryEscape(comp.ID)
+	script := ":bss_deps_wait\n"
+	script += "imgfetch --name bss_deps " + ready + " && goto bss_deps_ready ||\n"
+	script += msgs.echo(msgBootDeps)
+	script += fmt.Sprintf("sleep %d\n", bootDepsWait)
+	script += "goto bss_deps_wait\n"
+	script += ":bss_deps_ready\n"
+	script += "imgfree bss_deps\n"
+	return script
+}
+
+func BootDepsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("BootDepsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	deps, err := getBootDeps()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve boot dependencies: %s", err))
+		return
+	}
+	if group := strings.Join(r.Form["group"], ""); group != "" {
+		found := []bssTypes.BootDependency{}
+		for _, d := range deps {
+			if d.Group == group {
+				found = append(found, d)
+			}
+		}
+		if len(found) == 0 {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No boot dependencies for group '%s'", group))
+			return
+		}
+		deps = found
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(deps)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func BootDepsPut(w http.ResponseWriter, r *http.Request) {
+	debugf("BootDepsPut(): Received request %v\n", r.URL)
+	var dep bssTypes.BootDependency
+	err := json.NewDecoder(r.Body).Decode(&dep)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if dep.Group == "" || len(dep.After) == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: both group and after are required")
+		return
+	}
+	for _, a := range dep.After {
+		if strings.EqualFold(a, dep.Group) {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: group '%s' cannot depend on itself", a))
+			return
+		}
+	}
+	// Under the lock so that two updates cannot make a cycle between them.
+	var cycle []string
+	err = distLocked(func() error {
+		deps, err := getBootDeps()
+		if err != nil {
+			return err
+		}
+		if cycle = bootDepsCycle(deps, dep); cycle != nil {
+			return nil
+		}
+		return storeData(bootDepsPfx+dep.Group, dep)
+	})
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store boot dependency: %s", err))
+		return
+	}
+	if cycle != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: boot-after cycle %s", strings.Join(cycle, " -> ")))
+		return
+	}
+	log.Printf("/bootdeps PUT: %s after %s", dep.Group, strings.Join(dep.After, ","))
+	w.WriteHeader(http.StatusOK)
+}
+
+func BootDepsDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("BootDepsDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	group := strings.Join(r.Form["group"], "")
+	if group == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a group= parameter")
+		return
+	}
+	if err := kvstore.Delete(bootDepsPfx + group); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove boot dependency for %s: %s", group, err))
+		return
+	}
+	log.Printf("/bootdeps DELETE: %s", group)
+	w.WriteHeader(http.StatusOK)
+}
+
+// The readiness endpoint returns 200 once all of a node's dependencies are up
+// and 503 while it is still waiting, so the iPXE imgfetch in the wait loop
+// fails until then.
+func BootDepsReadyGet(w http.ResponseWriter, r *http.Request) {
+	debugf("BootDepsReadyGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	if name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	comp, ok := FindSMCompByName(name)
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - Unknown host '%s'", name))
+		return
+	}
+	status := bootDepsStatus{Name: name}
+	status.Waiting = bootDepsWaiting(comp)
+	status.Ready = len(status.Waiting) == 0
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if status.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(status)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootDeps(t *testing.T) {
+	const dependent, dependency = "x0c0s5b0n0", "x0c0s1b0n0"
+	defer kvstore.Delete(bootDepsPfx + dependent)
+	defer kvstore.Delete(endpointAccessPfx + "/" + dependency + "/" + string(bssTypes.EndpointTypePhoneHome))
+
+	body := bytes.NewBufferString(`{"group":"` + dependent + `","after":["` + dependency + `"]}`)
+	req := httptest.NewRequest(http.MethodPut, baseEndpoint+"/bootdeps", body)
+	rr := httptest.NewRecorder()
+	bootDeps(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT bootdeps returned %d: %s", rr.Code, rr.Body)
+	}
+
+	ready := func() int {
+		req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootdeps/ready?name="+dependent, nil)
+		rr := httptest.NewRecorder()
+		bootDepsReady(rr, req)
+		return rr.Code
+	}
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Errorf("Ready before phone-home returned %d, expected %d", code, http.StatusServiceUnavailable)
+	}
+	updateEndpointAccessed(dependency, bssTypes.EndpointTypePhoneHome)
+	if code := ready(); code != http.StatusOK {
+		t.Errorf("Ready after phone-home returned %d, expected %d", code, http.StatusOK)
+	}
+
+	comp, _ := FindSMCompByName(dependent)
+	if script := bootDepsWaitScript(comp, scriptMessages(BootData{}, messageData{})); !strings.Contains(script, "/bootdeps/ready?name="+dependent) {
+		t.Errorf("Wait script is missing the readiness URL:\n%s", script)
+	}
+	comp, _ = FindSMCompByName(dependency)
+	if script := bootDepsWaitScript(comp, scriptMessages(BootData{}, messageData{})); script != "" {
+		t.Errorf("Unexpected wait script for %s:\n%s", dependency, script)
+	}
+
+	body = bytes.NewBufferString(`{"group":"Compute","after":["compute"]}`)
+	req = httptest.NewRequest(http.MethodPut, baseEndpoint+"/bootdeps", body)
+	rr = httptest.NewRecorder()
+	bootDeps(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("PUT self dependency returned %d, expected %d", rr.Code, http.StatusBadRequest)
+	}
+
+	// Making dependency wait for dependent would close the loop.
+	body = bytes.NewBufferString(`{"group":"` + dependency + `","after":["` + dependent + `"]}`)
+	req = httptest.NewRequest(http.MethodPut, baseEndpoint+"/bootdeps", body)
+	rr = httptest.NewRecorder()
+	bootDeps(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("PUT cyclic dependency returned %d, expected %d", rr.Code, http.StatusBadRequest)
+	}
+	if _, exists, _ := kvstore.Get(bootDepsPfx + dependency); exists {
+		kvstore.Delete(bootDepsPfx + dependency)
+		t.Errorf("Cyclic dependency for %s was stored", dependency)
+	}
+}
@@ -0,0 +1,88 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot group delivery limits.
+//
+// A boot group with max-booting set paces how fast its members storm the
+// image store and the other shared infrastructure: a member that gets its
+// boot script holds one of the group's max-booting slots for
+// --bootgroup-boot-seconds, the time it takes to load its images.  While
+// all slots are held, boot scripts for the other members only sleep
+// --bootgroup-retry-delay seconds and chain back.  A member holding a slot
+// is served again without taking another one, so retries of a node that is
+// already booting are not paced.  The slots are counted per BSS instance.
+
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	bootGroupBootSeconds = uint(60)
+	bootGroupRetryDelay  = uint(10)
+)
+
+var bootGroupSlots = struct {
+	sync.Mutex
+	held map[string]map[string]time.Time // Group ID, node, end of the slot
+}{held: make(map[string]map[string]time.Time)}
+
+// Function bootGroupAdmit() returns whether the node, whose boot parameters
+// come from bds, may get its boot script now.
+func bootGroupAdmit(node string, bds BootDataStore) bool {
+	id := bootGroupID(bds.Kernel, bds.Initrd, bds.Params)
+	label, err := getBootGroupLabel(id)
+	if err != nil || label.MaxBooting <= 0 {
+		return true
+	}
+	now := time.Now()
+	bootGroupSlots.Lock()
+	defer bootGroupSlots.Unlock()
+	slots := bootGroupSlots.held[id]
+	if slots == nil {
+		slots = make(map[string]time.Time)
+		bootGroupSlots.held[id] = slots
+	}
+	for n, end := range slots {
+		if now.After(end) {
+			delete(slots, n)
+		}
+	}
+	if _, ok := slots[node]; ok {
+		return true
+	}
+	if len(slots) >= label.MaxBooting {
+		return false
+	}
+	slots[node] = now.Add(time.Duration(bootGroupBootSeconds) * time.Second)
+	return true
+}
+
+// Function bootGroupPaced() returns whether a node has to wait for a slot
+// of its boot group.
+func bootGroupPaced(comp SMComponent, name string) bool {
+	bds, err := lookupStore(comp.ID, name, comp.Role, DefaultTag)
+	return err == nil && !bootGroupAdmit(comp.ID, bds)
+}
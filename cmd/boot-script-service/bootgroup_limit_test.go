@@ -0,0 +1,89 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootGroupLimit(t *testing.T) {
+	nodes := []string{"x0c0s2b0n0", "x0c0s3b0n0"}
+	const kernel = "s3://boot-images/paced/kernel"
+	savedMode := s3SignerMode
+	s3SignerMode = s3SignerMock
+	if err, _ := Store(bssTypes.BootParams{Hosts: nodes, Params: "console=ttyS0", Kernel: kernel}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	bds, _ := lookupHost(nodes[0])
+	id := bootGroupID(bds.Kernel, bds.Initrd, bds.Params)
+	defer func() {
+		s3SignerMode = savedMode
+		for _, n := range nodes {
+			kvstore.Delete(paramsPfx + n)
+		}
+		kvstore.Delete(makeImageKey(kernelImageType, kernel))
+		kvstore.Delete(bootGroupsPfx + id)
+		bootGroupSlots.Lock()
+		delete(bootGroupSlots.held, id)
+		bootGroupSlots.Unlock()
+	}()
+	boot := func(node string) string {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+		return w.Body.String()
+	}
+
+	if script := boot(nodes[1]); !strings.Contains(script, "console=ttyS0") {
+		t.Errorf("Expected the boot script without a limit:\n%s", script)
+	}
+	if err := storeData(bootGroupsPfx+id, bootGroupLabel{Name: "paced", MaxBooting: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if script := boot(nodes[0]); !strings.Contains(script, "console=ttyS0") {
+		t.Errorf("Expected the first node to get its boot script:\n%s", script)
+	}
+	if script := boot(nodes[1]); strings.Contains(script, "console=ttyS0") ||
+		!strings.Contains(script, "sleep 10\nchain ") {
+		t.Errorf("Expected the second node to be told to retry:\n%s", script)
+	}
+	if script := boot(nodes[0]); !strings.Contains(script, "console=ttyS0") {
+		t.Errorf("Expected the booting node to be served again:\n%s", script)
+	}
+	g, _, _ := findBootGroup("paced")
+	if g.MaxBooting != 1 {
+		t.Errorf("Expected the limit in the boot group, got %+v", g)
+	}
+
+	bootGroupSlots.Lock()
+	bootGroupSlots.held[id][nodes[0]] = bootGroupSlots.held[id][nodes[0]].Add(-2 * time.Minute)
+	bootGroupSlots.Unlock()
+	if script := boot(nodes[1]); !strings.Contains(script, "console=ttyS0") {
+		t.Errorf("Expected the second node to get its boot script once the slot ended:\n%s", script)
+	}
+}
@@ -0,0 +1,115 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// HSM-managed boot groups.
+//
+// A boot group labeled with an HSM group follows the membership of that HSM
+// group: nodes added to the HSM group get the boot configuration of the boot
+// group, and nodes removed from it lose their host entry so that they fall
+// back to their role or the default boot parameters.  Tags are never removed.
+
+package main
+
+import (
+	"log"
+	"net/url"
+	"time"
+)
+
+var (
+	bootGroupSyncInterval uint = 300 // seconds, 0 disables the reconciler
+	hsmGroupMembers            = getHSMGroupMembers
+)
+
+func getHSMGroupMembers(group string) ([]string, error) {
+	var rsp struct {
+		IDs []string `json:"ids"`
+	}
+	err := getJSON(smClient, smBaseURL+"/groups/"+url.PathEscape(group)+"/members", &rsp)
+	return rsp.IDs, err
+}
+
+// Function reconcileBootGroups() brings the HSM-managed boot groups in line
+// with their HSM groups.  It returns the number of nodes added and removed.
+func reconcileBootGroups() (added, removed int) {
+	groups, err := getBootGroups()
+	if err != nil {
+		log.Printf("Boot group sync: %s", err)
+		return
+	}
+	for _, g := range groups {
+		if g.HSMGroup == "" {
+			continue
+		}
+		ids, err := hsmGroupMembers(g.HSMGroup)
+		if err != nil {
+			log.Printf("Boot group sync: HSM group %s: %s", g.HSMGroup, err)
+			continue
+		}
+		want := make(map[string]bool)
+		for _, id := range ids {
+			want[id] = true
+		}
+		have := make(map[string]bool)
+		for _, m := range g.Members {
+			have[m] = true
+			if want[m] {
+				continue
+			}
+			// Only hosts known to HSM are removed, tags such as roles
+			// stay in the group.
+			if _, ok := FindSMCompByNameInCache(m); !ok {
+				continue
+			}
+			if err := removeHost(m); err != nil {
+				log.Printf("Boot group sync: failed to remove %s from %s: %s", m, g.Name, err)
+				continue
+			}
+			log.Printf("Boot group sync: removed %s from %s", m, g.Name)
+			removed++
+		}
+		var missing []string
+		for _, id := range ids {
+			if !have[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if err := assignBootGroup(g, missing, "hsm-group:"+g.HSMGroup); err != nil {
+			log.Printf("Boot group sync: %s", err)
+			continue
+		}
+		log.Printf("Boot group sync: added %v to %s", missing, g.Name)
+		added += len(missing)
+	}
+	return
+}
+
+func bootGroupSyncLoop() {
+	for {
+		time.Sleep(time.Duration(bootGroupSyncInterval) * time.Second)
+		reconcileBootGroups()
+	}
+}
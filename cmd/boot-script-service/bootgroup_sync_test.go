@@ -0,0 +1,85 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestReconcileBootGroups(t *testing.T) {
+	const kernel = "s3://boot-images/sync/kernel"
+	defer func() {
+		for _, m := range []string{"x0c1s21b0n0", "x0c3s5b0n0", "x0c0s9b0n0", "Storage"} {
+			kvstore.Delete(paramsPfx + m)
+		}
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+
+	body := bytes.NewBufferString(`{"name":"sync","hsm-group":"sync","kernel":"` + kernel + `",` +
+		`"members":["x0c1s21b0n0","x0c3s5b0n0","Storage"]}`)
+	req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/bootgroups", body)
+	rr := httptest.NewRecorder()
+	bootGroups(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	var g bssTypes.BootGroup
+	json.Unmarshal(rr.Body.Bytes(), &g)
+	defer kvstore.Delete(bootGroupsPfx + g.ID)
+
+	hsm := []string{"x0c3s5b0n0", "x0c0s9b0n0"}
+	defer func() { hsmGroupMembers = getHSMGroupMembers }()
+	hsmGroupMembers = func(group string) ([]string, error) { return hsm, nil }
+
+	if added, removed := reconcileBootGroups(); added != 1 || removed != 1 {
+		t.Errorf("Reconcile added %d and removed %d, expected 1 and 1", added, removed)
+	}
+	g, _, _ = findBootGroup("sync")
+	sort.Strings(g.Members)
+	if want := []string{"Storage", "x0c0s9b0n0", "x0c3s5b0n0"}; len(g.Members) != 3 ||
+		g.Members[0] != want[0] || g.Members[1] != want[1] || g.Members[2] != want[2] {
+		t.Errorf("Members after reconcile %v, expected %v", g.Members, want)
+	}
+	if _, err := lookupHost("x0c1s21b0n0"); err == nil {
+		t.Errorf("x0c1s21b0n0 still has a host entry")
+	}
+
+	// The group survives losing all of its members.
+	hsm = nil
+	reconcileBootGroups()
+	kvstore.Delete(paramsPfx + "Storage")
+	hsm = []string{"x0c1s21b0n0"}
+	if added, _ := reconcileBootGroups(); added != 1 {
+		t.Errorf("Reconcile of an empty group added %d, expected 1", added)
+	}
+	if bd, err := LookupBootData("x0c1s21b0n0"); err != nil || bd.Kernel.Path != kernel {
+		t.Errorf("x0c1s21b0n0 did not get the group kernel: %+v %v", bd, err)
+	}
+}
@@ -0,0 +1,401 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot groups.
+//
+// Hosts are configured individually or by tag, so large systems end up with
+// many entries that share the same boot configuration.  The boot group API
+// presents them grouped by kernel, initrd and kernel parameters.  Groups are
+// derived from the boot parameters on every request; only the optional name
+// and description labels are stored, keyed by the group ID.  Changing the
+// configuration of a member moves it to a different group.  A group can limit
+// how many of its members get a boot script at once, see
+// bootgroup_limit.go.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const bootGroupsPfx = "/bootgroups/"
+
+type bootGroupLabel struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	HSMGroup    string `json:"hsm-group,omitempty"`
+	Release     string `json:"release,omitempty"`
+	Channel     string `json:"channel,omitempty"`
+	MaxBooting  int    `json:"max-booting,omitempty"`
+
+	// HSM-managed groups keep their configuration so that they survive
+	// losing all of their members.
+	Kernel string `json:"kernel,omitempty"`
+	Initrd string `json:"initrd,omitempty"`
+	Params string `json:"params,omitempty"`
+}
+
+func bootGroupLabelOf(g bssTypes.BootGroup) bootGroupLabel {
+	label := bootGroupLabel{Name: g.Name, Description: g.Description, HSMGroup: g.HSMGroup,
+		Release: g.Release, Channel: g.Channel, MaxBooting: g.MaxBooting}
+	if g.HSMGroup != "" {
+		label.Kernel, label.Initrd, label.Params = g.Kernel, g.Initrd, g.Params
+	}
+	return label
+}
+
+func bootGroupID(kernel, initrd, params string) string {
+	sum := sha256.Sum256([]byte(kernel + "\n" + initrd + "\n" + params))
+	return hex.EncodeToString(sum[:6])
+}
+
+func getBootGroupLabel(id string) (bootGroupLabel, error) {
+	var label bootGroupLabel
+	val, exists, err := kvstore.Get(bootGroupsPfx + id)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &label)
+	}
+	return label, err
+}
+
+// Function getBootGroups() derives the boot groups from the stored boot
+// parameters, sorted by name.
+func getBootGroups() ([]bssTypes.BootGroup, error) {
+	labels := make(map[string]bootGroupLabel)
+	kvl, err := kvstore.GetRange(bootGroupsPfx+keyMin, bootGroupsPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvl {
+		var label bootGroupLabel
+		if json.Unmarshal([]byte(kv.Value), &label) == nil {
+			labels[strings.TrimPrefix(kv.Key, bootGroupsPfx)] = label
+		}
+	}
+	kvl, err = getTags()
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*bssTypes.BootGroup)
+	for _, kv := range kvl {
+		var bds BootDataStore
+		if err := json.Unmarshal([]byte(kv.Value), &bds); err != nil {
+			log.Printf("Boot groups: skipping %s: %s", kv.Key, err)
+			continue
+		}
+		id := bootGroupID(bds.Kernel, bds.Initrd, bds.Params)
+		g, ok := groups[id]
+		if !ok {
+			bd := bdConvert(bds)
+			g = &bssTypes.BootGroup{ID: id, Params: bd.Params, Kernel: bd.Kernel.Path, Initrd: bd.Initrd.Path}
+			label := labels[id]
+			g.Name, g.Description, g.HSMGroup = label.Name, label.Description, label.HSMGroup
+			g.Release, g.Channel, g.MaxBooting = label.Release, label.Channel, label.MaxBooting
+			if g.Name == "" {
+				g.Name = fmt.Sprintf("BootGroup(kernel=%s)", g.Kernel)
+			}
+			groups[id] = g
+		}
+		g.Members = append(g.Members, extractParamName(kv))
+	}
+	for id, label := range labels {
+		if _, ok := groups[id]; !ok && label.HSMGroup != "" && label.Kernel != "" {
+			groups[id] = &bssTypes.BootGroup{ID: id, Name: label.Name, Description: label.Description,
+				HSMGroup: label.HSMGroup, Release: label.Release, Channel: label.Channel,
+				MaxBooting: label.MaxBooting, Params: label.Params, Kernel: label.Kernel, Initrd: label.Initrd}
+		}
+	}
+	ret := []bssTypes.BootGroup{}
+	for _, g := range groups {
+		ret = append(ret, *g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	return ret, nil
+}
+
+// Function findBootGroup() finds a boot group by name or ID.
+func findBootGroup(name string) (bssTypes.BootGroup, bool, error) {
+	groups, err := getBootGroups()
+	if err != nil {
+		return bssTypes.BootGroup{}, false, err
+	}
+	for _, g := range groups {
+		if g.ID == name || g.Name == name {
+			return g, true, nil
+		}
+	}
+	return bssTypes.BootGroup{}, false, nil
+}
+
+// Group names must be unique, the derived names of unlabeled groups
+// notwithstanding.
+func bootGroupNameTaken(name, id string) (bool, error) {
+	kvl, err := kvstore.GetRange(bootGroupsPfx+keyMin, bootGroupsPfx+keyMax)
+	if err != nil {
+		return false, err
+	}
+	for _, kv := range kvl {
+		var label bootGroupLabel
+		if json.Unmarshal([]byte(kv.Value), &label) == nil &&
+			label.Name == name && strings.TrimPrefix(kv.Key, bootGroupsPfx) != id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Function assignBootGroup() gives the members the boot configuration of the
+// group.  Cloud-init, first boot data, messages, labels and reasons of existing members are kept.
+func assignBootGroup(g bssTypes.BootGroup, members []string, who string) error {
+	for _, m := range members {
+		bp := bssTypes.BootParams{Hosts: []string{m}, Params: g.Params, Kernel: g.Kernel, Initrd: g.Initrd}
+		if bds, err := lookupHost(m); err == nil {
+			bp.CloudInit, bp.FirstBoot, bp.Messages, bp.Labels = bds.CloudInit, bds.FirstBoot, bds.Messages, bds.Labels
+			bp.Reasons, bp.Payload, bp.Files = bds.Reasons, bds.Payload, bds.Files
+			bp.DTB, bp.ACPI, bp.InitrdOverlays = bds.DTB, bds.ACPI, bds.Overlays
+		}
+		if err, _ := Store(bp, who); err != nil {
+			return fmt.Errorf("Failed to store boot parameters for %s: %s", m, err)
+		}
+	}
+	return nil
+}
+
+func sendBootGroups(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func BootGroupsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("BootGroupsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	if name := strings.Join(r.Form["name"], ""); name != "" {
+		g, found, err := findBootGroup(name)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to retrieve boot groups: %s", err))
+		} else if !found {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No boot group '%s'", name))
+		} else {
+			sendBootGroups(w, http.StatusOK, g)
+		}
+		return
+	}
+	groups, err := getBootGroups()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve boot groups: %s", err))
+		return
+	}
+	sendBootGroups(w, http.StatusOK, groups)
+}
+
+// Posting a boot group assigns its configuration to the members and labels
+// the resulting group.
+func BootGroupsPost(w http.ResponseWriter, r *http.Request) {
+	debugf("BootGroupsPost(): Received request %v\n", r.URL)
+	var g bssTypes.BootGroup
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if g.Name == "" || g.Kernel == "" || len(g.Members) == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: name, kernel and members are required")
+		return
+	}
+	if g.MaxBooting < 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: max-booting cannot be negative")
+		return
+	}
+	g.ID = bootGroupID(imageFind(g.Kernel, kernelImageType), imageFind(g.Initrd, initrdImageType), g.Params)
+	if taken, err := bootGroupNameTaken(g.Name, g.ID); err != nil || taken {
+		base.SendProblemDetailsGeneric(w, http.StatusConflict,
+			fmt.Sprintf("Conflict - boot group name '%s' is already in use", g.Name))
+		return
+	}
+	who := requestSubject(r)
+	if err := assignBootGroup(g, g.Members, who); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The image keys are only known once the members are stored.
+	g.ID = bootGroupID(imageFind(g.Kernel, kernelImageType), imageFind(g.Initrd, initrdImageType), g.Params)
+	if err := storeData(bootGroupsPfx+g.ID, bootGroupLabelOf(g)); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store boot group: %s", err))
+		return
+	}
+	log.Printf("/bootgroups POST: %s (%s) members %s by %s", g.Name, g.ID, strings.Join(g.Members, ","), who)
+	g, _, _ = findBootGroup(g.ID)
+	sendBootGroups(w, http.StatusCreated, g)
+}
+
+// Patching a boot group renames it, changes its description, sets the HSM
+// group it follows, binds it to a release channel or changes its limit of
+// members booting at once.
+func BootGroupsPatch(w http.ResponseWriter, r *http.Request) {
+	debugf("BootGroupsPatch(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	if name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	// Fields left out of the request keep their current value.
+	var patch struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		HSMGroup    *string `json:"hsm-group"`
+		Release     *string `json:"release"`
+		Channel     *string `json:"channel"`
+		MaxBooting  *int    `json:"max-booting"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	g, found, err := findBootGroup(name)
+	if err != nil || !found {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No boot group '%s'", name))
+		return
+	}
+	label, _ := getBootGroupLabel(g.ID)
+	if patch.Name != nil {
+		label.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		label.Description = *patch.Description
+	}
+	if patch.HSMGroup != nil {
+		label.HSMGroup = *patch.HSMGroup
+	}
+	if patch.Release != nil {
+		label.Release = *patch.Release
+	}
+	if patch.Channel != nil {
+		label.Channel = *patch.Channel
+	}
+	if patch.MaxBooting != nil {
+		label.MaxBooting = *patch.MaxBooting
+	}
+	if label.MaxBooting < 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: max-booting cannot be negative")
+		return
+	}
+	if label.Release != "" && label.Channel == "" {
+		label.Channel = "stable"
+	}
+	if label.Release == "" {
+		label.Channel = ""
+	}
+	rebind := label.Release != "" && (label.Release != g.Release || label.Channel != g.Channel)
+	if rebind {
+		if rec, exists, err := getRelease(label.Release); err != nil || !exists {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: no release '%s'", label.Release))
+			return
+		} else if _, ok := rec.current(label.Channel); !ok {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: release '%s' has no channel '%s'", label.Release, label.Channel))
+			return
+		}
+	}
+	old := g
+	g.Name, g.Description, g.HSMGroup = label.Name, label.Description, label.HSMGroup
+	g.Release, g.Channel, g.MaxBooting = label.Release, label.Channel, label.MaxBooting
+	label = bootGroupLabelOf(g)
+	if taken, err := bootGroupNameTaken(label.Name, g.ID); label.Name != "" && (err != nil || taken) {
+		base.SendProblemDetailsGeneric(w, http.StatusConflict,
+			fmt.Sprintf("Conflict - boot group name '%s' is already in use", label.Name))
+		return
+	}
+	if err = storeData(bootGroupsPfx+g.ID, label); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store boot group: %s", err))
+		return
+	}
+	if rebind {
+		if err = bindBootGroup(g, requestSubject(r)); err != nil {
+			storeData(bootGroupsPfx+g.ID, bootGroupLabelOf(old))
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to apply release: %s", err))
+			return
+		}
+	}
+	log.Printf("/bootgroups PATCH: %s (%s) -> %s", name, g.ID, label.Name)
+	if g, found, _ = findBootGroup(g.ID); !found {
+		g, _, _ = findBootGroup(label.Name)
+	}
+	sendBootGroups(w, http.StatusOK, g)
+}
+
+// Deleting a boot group only removes its labels, the boot parameters of the
+// members are not changed.
+func BootGroupsDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("BootGroupsDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	if name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	g, found, err := findBootGroup(name)
+	if err != nil || !found {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No boot group '%s'", name))
+		return
+	}
+	if err = kvstore.Delete(bootGroupsPfx + g.ID); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove boot group: %s", err))
+		return
+	}
+	log.Printf("/bootgroups DELETE: %s (%s)", name, g.ID)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,100 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootGroups(t *testing.T) {
+	members := []string{"x3000c0s1b0n0", "x3000c0s2b0n0"}
+	defer func() {
+		for _, m := range members {
+			kvstore.Delete(paramsPfx + m)
+		}
+		kvstore.Delete(imageFind("s3://boot-images/gpu/kernel", kernelImageType))
+		kvstore.Delete(imageFind("s3://boot-images/gpu/initrd", initrdImageType))
+	}()
+
+	body := bytes.NewBufferString(`{"name":"gpu","description":"GPU nodes",` +
+		`"kernel":"s3://boot-images/gpu/kernel","initrd":"s3://boot-images/gpu/initrd",` +
+		`"params":"console=ttyS0","members":["x3000c0s1b0n0","x3000c0s2b0n0"]}`)
+	req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/bootgroups", body)
+	rr := httptest.NewRecorder()
+	bootGroups(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	var g bssTypes.BootGroup
+	json.Unmarshal(rr.Body.Bytes(), &g)
+	defer kvstore.Delete(bootGroupsPfx + g.ID)
+	if g.Name != "gpu" || len(g.Members) != 2 {
+		t.Errorf("Unexpected boot group %+v", g)
+	}
+
+	body = bytes.NewBufferString(`{"name":"gpu","kernel":"s3://boot-images/other/kernel","members":["x3000c0s3b0n0"]}`)
+	req = httptest.NewRequest(http.MethodPost, baseEndpoint+"/bootgroups", body)
+	rr = httptest.NewRecorder()
+	bootGroups(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("POST duplicate name returned %d, expected %d", rr.Code, http.StatusConflict)
+	}
+	if _, err := lookupHost("x3000c0s3b0n0"); err == nil {
+		kvstore.Delete(paramsPfx + "x3000c0s3b0n0")
+		t.Errorf("Conflicting POST stored boot parameters")
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, baseEndpoint+"/bootgroups?name="+g.ID,
+		bytes.NewBufferString(`{"name":"gpu-a100"}`))
+	rr = httptest.NewRecorder()
+	bootGroups(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PATCH bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	json.Unmarshal(rr.Body.Bytes(), &g)
+	if g.Name != "gpu-a100" || g.Description != "GPU nodes" {
+		t.Errorf("Unexpected boot group after rename %+v", g)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, baseEndpoint+"/bootgroups?name=gpu-a100", nil)
+	rr = httptest.NewRecorder()
+	bootGroups(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("DELETE bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	if _, err := lookupHost(members[0]); err != nil {
+		t.Errorf("Deleting the group removed member %s: %s", members[0], err)
+	}
+	req = httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootgroups?name=gpu-a100", nil)
+	rr = httptest.NewRecorder()
+	bootGroups(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET deleted group returned %d, expected %d", rr.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,139 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Diffs of boot parameter changes.
+//
+// PUT and PATCH of /bootparameters answer with what the change did to each
+// node or tag it names: the effective boot configuration afterwards, as
+// /bootparameters/<name> would return it, and a diff against the one
+// before, with the kernel command line arguments added and removed and the
+// kernel, initrd and cloud-init changes.  Changes to kernel or initrd
+// image entries, which name no node, get an empty list.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+// Function bootParamsTargets() returns the names whose boot data a
+// bootparameters request changes, the way Store() and Update() map MACs and
+// NIDs to names.
+func bootParamsTargets(bp bssTypes.BootParams) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, h := range bp.Hosts {
+		add(h)
+	}
+	if len(bp.Hosts) > 0 {
+		return names
+	}
+	for _, m := range bp.Macs {
+		if comp, ok := findCompByMACFresh(m); ok {
+			add(comp.ID)
+		} else {
+			add(m)
+		}
+	}
+	if len(bp.Macs) > 0 {
+		return names
+	}
+	for _, n := range bp.Nids {
+		if comp, ok := findCompByNidFresh(int(n)); ok {
+			add(comp.ID)
+		} else {
+			add(nidName(int(n)))
+		}
+	}
+	return names
+}
+
+func effectiveOf(names []string) []bssTypes.BootParams {
+	ret := make([]bssTypes.BootParams, len(names))
+	for i, name := range names {
+		ret[i], _ = effectiveBootParams(name)
+	}
+	return ret
+}
+
+// Function paramTokens() returns the arguments of a that are not in b,
+// counting repeated arguments.
+func paramTokens(a, b string) []string {
+	count := make(map[string]int)
+	for _, t := range strings.Fields(b) {
+		count[t]++
+	}
+	var ret []string
+	for _, t := range strings.Fields(a) {
+		if count[t] > 0 {
+			count[t]--
+		} else {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+func uriChange(before, after string) *bssTypes.URIChange {
+	if before == after {
+		return nil
+	}
+	return &bssTypes.URIChange{Before: before, After: after}
+}
+
+func bootParamsDiff(before, after bssTypes.BootParams) bssTypes.BootParamsDiff {
+	return bssTypes.BootParamsDiff{
+		Added:     paramTokens(after.Params, before.Params),
+		Removed:   paramTokens(before.Params, after.Params),
+		Kernel:    uriChange(before.Kernel, after.Kernel),
+		Initrd:    uriChange(before.Initrd, after.Initrd),
+		CloudInit: !reflect.DeepEqual(before.CloudInit, after.CloudInit),
+	}
+}
+
+// Function sendApplied() answers a change of the boot data of names with
+// their effective boot configuration and what changed since before.
+func sendApplied(w http.ResponseWriter, names []string, before []bssTypes.BootParams) {
+	applied := []bssTypes.AppliedBootParams{}
+	for i, after := range effectiveOf(names) {
+		applied = append(applied, bssTypes.AppliedBootParams{Name: names[i],
+			Effective: after, Diff: bootParamsDiff(before[i], after)})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(applied); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,72 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootParamsDiff(t *testing.T) {
+	const node = "x0c0s6b0n0"
+	defer func() {
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(imageFind("http://s3/diff/kernel", kernelImageType))
+		kvstore.Delete(imageFind("http://s3/diff/kernel2", kernelImageType))
+	}()
+	send := func(method, body string) []bssTypes.AppliedBootParams {
+		t.Helper()
+		w := httptest.NewRecorder()
+		bootParameters(w, httptest.NewRequest(method, baseEndpoint+"/bootparameters", strings.NewReader(body)))
+		var applied []bssTypes.AppliedBootParams
+		if err := json.Unmarshal(w.Body.Bytes(), &applied); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%s bootparameters returned %d: %s", method, w.Code, w.Body.String())
+		}
+		return applied
+	}
+	send(http.MethodPut, `{"hosts":["`+node+`"],"params":"a b c b","kernel":"http://s3/diff/kernel"}`)
+
+	applied := send(http.MethodPatch, `{"hosts":["`+node+`"],"params":"a c d b"}`)
+	if len(applied) != 1 || applied[0].Name != node || applied[0].Effective.Params != "a c d b" {
+		t.Fatalf("Unexpected PATCH answer %+v", applied)
+	}
+	diff := applied[0].Diff
+	if strings.Join(diff.Added, " ") != "d" || strings.Join(diff.Removed, " ") != "b" ||
+		diff.Kernel != nil || diff.CloudInit {
+		t.Errorf("Unexpected params diff %+v", diff)
+	}
+
+	applied = send(http.MethodPatch, `{"nids":[28],"kernel":"http://s3/diff/kernel2"}`)
+	if len(applied) != 1 || applied[0].Name != node {
+		t.Fatalf("NID not mapped to %s: %+v", node, applied)
+	}
+	if k := applied[0].Diff.Kernel; k == nil || k.Before != "http://s3/diff/kernel" ||
+		k.After != "http://s3/diff/kernel2" || len(applied[0].Diff.Added) != 0 {
+		t.Errorf("Unexpected kernel diff %+v", applied[0].Diff)
+	}
+}
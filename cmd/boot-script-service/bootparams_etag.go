@@ -0,0 +1,107 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot parameter ETags.
+//
+// GET /bootparameters for a single host returns the ETag of what is stored
+// under that host, and PUT and PATCH with If-Match are refused with 412
+// unless the stored boot parameters of every host they name still have one
+// of the given ETags, so that two operators cannot silently overwrite each
+// other's changes:
+//
+//	GET   /boot/v1/bootparameters?name=x3000c0s1b0n0   ETag: "9f2c..."
+//	PATCH /boot/v1/bootparameters  If-Match: "9f2c..."
+//
+// The ETag only covers the stored value, so that a change to the role
+// parameters or to the HSM role of a host does not fail its If-Match; the
+// watch in watch.go has an ETag of the effective configuration instead.
+// If-Match: * only requires the hosts to exist.  The stored value that
+// matched is compared again when it is replaced, by a test-and-set in the
+// datastore, so that a change by another instance in between is refused with
+// 412 too.  Writes without If-Match are not checked.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+// A PreconditionFailed is returned when the boot parameters of a host were
+// changed after its If-Match was checked.
+type PreconditionFailed struct {
+	Host string
+}
+
+func (e PreconditionFailed) Error() string {
+	return fmt.Sprintf("Precondition Failed - boot parameters of %s have changed", e.Host)
+}
+
+// Function hostETag() returns the ETag of raw, the stored boot parameters of
+// a host.
+func hostETag(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// Function setHostETag() sets the ETag header to that of the stored boot
+// parameters of host, if there are any.
+func setHostETag(w http.ResponseWriter, host string) {
+	if raw, exists, err := kvstore.Get(paramsPfx + host); err == nil && exists {
+		w.Header().Set("ETag", hostETag(raw))
+	}
+}
+
+// Function bootParamsPreconditions() checks If-Match against the stored
+// boot parameters of names.  It returns the stored values that matched, by
+// name, for the write to replace only if unchanged, and false after sending
+// 412.
+func bootParamsPreconditions(w http.ResponseWriter, r *http.Request, names []string) (map[string]string, bool) {
+	m := r.Header.Get("If-Match")
+	if m == "" {
+		return nil, true
+	}
+	wildcard := strings.TrimSpace(m) == "*"
+	expect := make(map[string]string)
+	for _, name := range names {
+		raw, exists, err := kvstore.Get(paramsPfx + name)
+		if err == nil && exists && (wildcard || strings.Contains(m, hostETag(raw))) {
+			if !wildcard {
+				expect[name] = raw
+			}
+			continue
+		}
+		sendPreconditionFailed(w, PreconditionFailed{name})
+		return nil, false
+	}
+	return expect, true
+}
+
+func sendPreconditionFailed(w http.ResponseWriter, e PreconditionFailed) {
+	base.SendProblemDetailsGeneric(w, http.StatusPreconditionFailed, e.Error())
+}
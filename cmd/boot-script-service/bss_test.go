@@ -33,9 +33,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
 )
 
 func TestMain(m *testing.M) {
@@ -122,7 +124,7 @@ func TestStoreAndLookup(t *testing.T) {
 		{Initrd: "/test/path/initrd.gz", Params: "def-initrd"},
 	}
 	for _, bp := range tables {
-		err, referralToken := Store(bp)
+		err, referralToken := Store(bp, "test")
 		if err != nil {
 			t.Errorf("Store failed for '%v': %s", bp, err.Error())
 		} else if referralToken == "" && (bp.Hosts != nil || bp.Nids != nil || bp.Macs != nil) {
@@ -200,3 +202,204 @@ func TestStoreAndLookup(t *testing.T) {
 			len(tables), len(bplist))
 	}
 }
+
+func TestProvenance(t *testing.T) {
+	const host = "x0c0s18b0n0"
+	defer kvstore.Delete(paramsPfx + host)
+
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{host}, Params: "a"}, "alice"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	bd, err := LookupBootData(host)
+	if err != nil || bd.Provenance == nil || bd.Provenance.UpdatedBy != "alice" || bd.Provenance.CreatedAt == 0 {
+		t.Fatalf("Unexpected provenance after Store: %v, %v", bd.Provenance, err)
+	}
+	created := bd.Provenance.CreatedAt
+
+	if err = Update(bssTypes.BootParams{Hosts: []string{host}, Params: "b"}, "bob"); err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+	bd, _ = LookupBootData(host)
+	if bd.Provenance.UpdatedBy != "bob" || bd.Provenance.CreatedAt != created {
+		t.Errorf("Unexpected provenance after Update: %v", bd.Provenance)
+	}
+
+	for _, query := range []string{"", "&verbose=true"} {
+		req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootparameters?name="+host+query, nil)
+		rr := httptest.NewRecorder()
+		BootparametersGet(rr, req)
+		var bplist []bssTypes.BootParams
+		if err = json.NewDecoder(rr.Body).Decode(&bplist); err != nil || len(bplist) != 1 {
+			t.Fatalf("GET bootparameters%s returned %v, %v", query, bplist, err)
+		}
+		if (bplist[0].Provenance != nil) != (query != "") {
+			t.Errorf("GET bootparameters%s returned provenance %v", query, bplist[0].Provenance)
+		}
+	}
+}
+
+func TestRemovePolicy(t *testing.T) {
+	const kernel = "/policy/vmlinuz"
+	hosts := []string{"x0c1s21b0n0", "x0c3s5b0n0"}
+	store := func() {
+		for _, h := range hosts {
+			Store(bssTypes.BootParams{Hosts: []string{h}, Kernel: kernel}, "test")
+		}
+	}
+	defer func() {
+		for _, h := range hosts {
+			kvstore.Delete(paramsPfx + h)
+		}
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+
+	store()
+	err := Remove(bssTypes.BootParams{Kernel: kernel}, deleteRestrict)
+	if _, ok := err.(DeleteConflict); !ok {
+		t.Errorf("Restricted removal of a used image returned %v", err)
+	}
+	if imageFind(kernel, kernelImageType) == "" {
+		t.Errorf("Restricted removal removed the image")
+	}
+
+	if err = Remove(bssTypes.BootParams{Hosts: hosts[:1]}, deleteCascade); err != nil {
+		t.Errorf("Cascading removal of %s failed: %s", hosts[0], err)
+	}
+	if imageFind(kernel, kernelImageType) == "" {
+		t.Errorf("Cascading removal of a host removed an image still in use")
+	}
+	if err = Remove(bssTypes.BootParams{Hosts: hosts[1:]}, deleteCascade); err != nil {
+		t.Errorf("Cascading removal of %s failed: %s", hosts[1], err)
+	}
+	if imageFind(kernel, kernelImageType) != "" {
+		t.Errorf("Cascading removal of the last host left the image")
+	}
+
+	store()
+	if err = Remove(bssTypes.BootParams{Kernel: kernel}, deleteCascade); err != nil {
+		t.Errorf("Cascading removal of the image failed: %s", err)
+	}
+	for _, h := range hosts {
+		if _, err := lookupHost(h); err == nil {
+			t.Errorf("Cascading removal of the image left %s", h)
+		}
+	}
+
+	store()
+	saved := kvstore
+	kvstore = failKvi{saved, paramsPfx + hosts[1]}
+	err = Remove(bssTypes.BootParams{Kernel: kernel}, deleteCascade)
+	kvstore = saved
+	if err == nil {
+		t.Errorf("Cascading removal with a failing datastore succeeded")
+	}
+	for _, h := range hosts {
+		if bds, err := lookupHost(h); err != nil || bds.Kernel == "" {
+			t.Errorf("Failed cascading removal left %s with %+v, %v", h, bds, err)
+		}
+	}
+	if imageFind(kernel, kernelImageType) == "" {
+		t.Errorf("Failed cascading removal removed the image")
+	}
+
+	if err = Remove(bssTypes.BootParams{Kernel: kernel}, deleteOrphan); err != nil {
+		t.Errorf("Removal of the image failed: %s", err)
+	}
+	if bds, err := lookupHost(hosts[0]); err != nil || bds.Kernel != "" {
+		t.Errorf("Removal of the image left %s with %+v, %v", hosts[0], bds, err)
+	}
+}
+
+// Writes to one key fail, as a datastore going away part way would.
+type failKvi struct {
+	hmetcd.Kvi
+	key string
+}
+
+func (kv failKvi) Store(key, value string) error {
+	if key == kv.key {
+		return fmt.Errorf("store of %s failed", key)
+	}
+	return kv.Kvi.Store(key, value)
+}
+
+func (kv failKvi) Delete(key string) error {
+	if key == kv.key {
+		return fmt.Errorf("delete of %s failed", key)
+	}
+	return kv.Kvi.Delete(key)
+}
+
+func TestPartialFailureRollback(t *testing.T) {
+	hosts := []string{"x0c1s22b0n0", "x0c1s23b0n0"}
+	saved := kvstore
+	defer func() {
+		kvstore = saved
+		for _, h := range hosts {
+			kvstore.Delete(paramsPfx + h)
+		}
+	}()
+	params := func(h string) string {
+		bds, err := lookupHost(h)
+		if err != nil {
+			return "<none>"
+		}
+		return bds.Params
+	}
+
+	kvstore = failKvi{saved, paramsPfx + hosts[1]}
+	if err, _ := Store(bssTypes.BootParams{Hosts: hosts, Params: "console=ttyS0"}, "test"); err == nil {
+		t.Errorf("Store with a failing datastore succeeded")
+	}
+	if p := params(hosts[0]); p != "<none>" {
+		t.Errorf("Failed Store left %s with %q", hosts[0], p)
+	}
+
+	kvstore = saved
+	if err, _ := Store(bssTypes.BootParams{Hosts: hosts, Params: "console=ttyS0"}, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	kvstore = failKvi{saved, paramsPfx + hosts[1]}
+	if err := Update(bssTypes.BootParams{Hosts: hosts, Params: "console=ttyS1"}, "test"); err == nil {
+		t.Errorf("Update with a failing datastore succeeded")
+	}
+	if err := Remove(bssTypes.BootParams{Hosts: hosts}, deleteOrphan); err == nil {
+		t.Errorf("Remove with a failing datastore succeeded")
+	}
+	kvstore = saved
+	for _, h := range hosts {
+		if p := params(h); p != "console=ttyS0" {
+			t.Errorf("Failed Update or Remove left %s with %q", h, p)
+		}
+	}
+}
+
+func TestImageStoreConcurrent(t *testing.T) {
+	const kernel = "/concurrent/vmlinuz"
+	defer kvstore.Delete(makeImageKey(kernelImageType, kernel))
+
+	keys := make(chan string, 20)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys <- imageStore(kernel, kernelImageType)
+		}()
+	}
+	wg.Wait()
+	close(keys)
+	want := makeImageKey(kernelImageType, kernel)
+	for k := range keys {
+		if k != want {
+			t.Errorf("imageStore returned %s, expected %s", k, want)
+		}
+	}
+
+	if err := updateImage(want, ImageData{Path: kernel, Params: "quiet"}); err != nil {
+		t.Errorf("updateImage failed: %s", err)
+	}
+	if err := updateImage(want, ImageData{Path: "/other/vmlinuz", Params: "quiet"}); err == nil {
+		t.Errorf("updateImage replaced the image at %s with a different path", want)
+	}
+}
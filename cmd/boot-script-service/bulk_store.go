@@ -0,0 +1,112 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Bulk stores.
+//
+// Registering thousands of nodes in one request took minutes, as every host
+// cost several datastore round trips in turn: reading the old entry, writing
+// the new one and recording the change.  When boot parameters name at least
+// bulkStoreMin hosts, the old entries are read with one range read instead,
+// the writes go out bulkStoreWorkers at a time and the changes are recorded
+// with one revision allocation.  As for smaller stores, a failed write
+// restores all the hosts.
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const bulkStoreMin = 64
+
+var bulkStoreWorkers = uint(32)
+
+// Function existingHosts() returns the stored value of every host entry.
+func existingHosts() (map[string]string, error) {
+	kvl, err := getTags()
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string, len(kvl))
+	for _, x := range kvl {
+		ret[extractParamName(x)] = x.Value
+	}
+	return ret, nil
+}
+
+// Function storeHostsBulk() stores bd for each of the hosts, saving the old
+// entries in undo.  The caller rolls back on error.
+func storeHostsBulk(hosts []string, bd BootDataStore, who string, undo *kvUndo) error {
+	existing, err := existingHosts()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, h := range hosts {
+		key := paramsPfx + h
+		if undo.seen[key] {
+			continue
+		}
+		old, exists := existing[h]
+		undo.record(key, old, exists)
+		names = append(names, h)
+	}
+
+	workers := bulkStoreWorkers
+	if workers == 0 {
+		workers = 1
+	}
+	jobs := make(chan string)
+	errs := make(chan error, len(names))
+	var wg sync.WaitGroup
+	for i := uint(0); i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range jobs {
+				hbd := bd
+				var old BootDataStore
+				if v, ok := existing[h]; ok {
+					json.Unmarshal([]byte(v), &old)
+				}
+				hbd.Provenance = provenance(old.Provenance, who)
+				if err := storeValue(paramsPfx+h, hbd); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	for _, h := range names {
+		jobs <- h
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	if err = <-errs; err != nil {
+		return err
+	}
+	debugf("storeHostsBulk: stored %d hosts", len(names))
+	recordChanges(names, changeUpdate)
+	return nil
+}
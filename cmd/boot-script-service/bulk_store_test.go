@@ -0,0 +1,78 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestStoreBulk(t *testing.T) {
+	var hosts []string
+	for i := 0; i < 2*bulkStoreMin; i++ {
+		hosts = append(hosts, fmt.Sprintf("x9c1s%db0n0", i))
+	}
+	defer func() {
+		for _, h := range hosts {
+			kvstore.Delete(paramsPfx + h)
+		}
+	}()
+	storeData(paramsPfx+hosts[1], BootDataStore{Params: "old",
+		Provenance: &bssTypes.Provenance{CreatedAt: 1, UpdatedAt: 1}})
+
+	if err, _ := StoreNew(bssTypes.BootParams{Hosts: hosts, Params: "console=ttyS0"}, "test"); err == nil {
+		t.Errorf("StoreNew of an existing host succeeded")
+	}
+	_, start := getChangesAPI(t, "")
+	if err, _ := Store(bssTypes.BootParams{Hosts: append(hosts, hosts[0]), Params: "console=ttyS0"}, "test"); err != nil {
+		t.Fatalf("Bulk Store failed: %s", err)
+	}
+	for _, h := range hosts {
+		if bds, err := lookupHost(h); err != nil || bds.Params != "console=ttyS0" || bds.Provenance == nil {
+			t.Fatalf("Bulk Store left %s with %+v, %v", h, bds, err)
+		}
+	}
+	if bds, _ := lookupHost(hosts[1]); bds.Provenance.CreatedAt != 1 || bds.Provenance.UpdatedBy != "test" {
+		t.Errorf("Bulk Store lost the provenance of %s: %+v", hosts[1], bds.Provenance)
+	}
+	if _, c := getChangesAPI(t, strconv.FormatInt(start.Revision, 10)); len(c.Changes) != len(hosts) ||
+		c.Revision != start.Revision+int64(len(hosts)) {
+		t.Errorf("Bulk Store recorded %d changes up to %d, expected %d", len(c.Changes), c.Revision, len(hosts))
+	}
+
+	saved := kvstore
+	kvstore = failKvi{saved, paramsPfx + hosts[len(hosts)/2]}
+	err, _ := Store(bssTypes.BootParams{Hosts: hosts, Params: "console=ttyS1"}, "test")
+	kvstore = saved
+	if err == nil {
+		t.Errorf("Bulk Store with a failing datastore succeeded")
+	}
+	for _, h := range hosts {
+		if bds, _ := lookupHost(h); bds.Params != "console=ttyS0" {
+			t.Fatalf("Failed bulk Store left %s with %q", h, bds.Params)
+		}
+	}
+}
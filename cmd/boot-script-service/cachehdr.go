@@ -0,0 +1,97 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Caching headers.
+//
+// Sites can front BSS with a caching proxy on the provisioning network.  A
+// boot script is addressed by its query parameters (mac, name or nid), so
+// the proxy must key on the full URL, query string included.  Boot scripts
+// of known, enabled nodes carry an ETag and may be stored: with
+// BSS_BOOTSCRIPT_CACHE_MAX_AGE they are fresh for that many seconds,
+// otherwise the proxy has to revalidate them each time.  They vary on
+// Authorization, so a response to one token is never served for another.
+// Discovery scripts, state-refresh delays, scripts with a join token (one per
+// boot) and errors are never stored, nor is cloud-init data, which is chosen
+// by the client address (X-Forwarded-For).  The max age has to leave the
+// presigned URLs in a script time to be used, so it is at most the warm-up
+// window, half their life.
+//
+// Scripts change with every retry, as the retry count and a timestamp are in
+// the chain URL.  BSS_BOOTSCRIPT_DETERMINISTIC leaves them out so that all
+// requests for a node get the same script.
+// Requests served by the proxy do not reach BSS, so the last access times
+// and boot verification only see the misses.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	bootscriptCacheMaxAge   = uint(0) // seconds
+	bootscriptDeterministic = false
+)
+
+func noStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// Cloud-init data is chosen by the address of the client.
+func noStoreByAddr(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("Vary", "X-Forwarded-For")
+}
+
+// Function writeBootscript() sends a boot script with its caching headers.
+// A cacheable script matching If-None-Match gets a bare 304.
+func writeBootscript(w http.ResponseWriter, r *http.Request, script string, cacheable bool) error {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	body := script + "\n"
+	if !cacheable {
+		noStore(w)
+		w.WriteHeader(http.StatusOK)
+		_, err := fmt.Fprint(w, body)
+		return err
+	}
+	sum := sha256.Sum256([]byte(body))
+	etag := `"` + hex.EncodeToString(sum[:12]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Authorization")
+	if bootscriptCacheMaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", bootscriptCacheMaxAge))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" && (m == "*" || strings.Contains(m, etag)) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprint(w, body)
+	return err
+}
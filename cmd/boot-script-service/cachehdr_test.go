@@ -0,0 +1,84 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteBootscript(t *testing.T) {
+	defer func(age uint) { bootscriptCacheMaxAge = age }(bootscriptCacheMaxAge)
+	script := "#!ipxe\nboot"
+
+	rr := httptest.NewRecorder()
+	writeBootscript(rr, httptest.NewRequest(http.MethodGet, "/", nil), script, false)
+	if rr.Header().Get("Cache-Control") != "no-store" || rr.Header().Get("ETag") != "" {
+		t.Errorf("Uncacheable script got headers %v", rr.Header())
+	}
+
+	rr = httptest.NewRecorder()
+	writeBootscript(rr, httptest.NewRequest(http.MethodGet, "/", nil), script, true)
+	etag := rr.Header().Get("ETag")
+	if rr.Header().Get("Cache-Control") != "no-cache" || etag == "" ||
+		rr.Header().Get("Vary") != "Authorization" || rr.Body.String() != script+"\n" {
+		t.Errorf("Cacheable script got headers %v, body %q", rr.Header(), rr.Body)
+	}
+
+	bootscriptCacheMaxAge = 60
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	writeBootscript(rr, req, script, true)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 ||
+		rr.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("Revalidation returned %d, headers %v", rr.Code, rr.Header())
+	}
+	rr = httptest.NewRecorder()
+	writeBootscript(rr, req, script+"\nsleep 1", true)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Changed script returned %d", rr.Code)
+	}
+}
+
+func TestBootscriptDeterministic(t *testing.T) {
+	defer func(d bool) { bootscriptDeterministic = d }(bootscriptDeterministic)
+	bootscriptDeterministic = true
+	if chain := bootScriptChain("/boot/v1/bootscript", "", "x0c0s1b0n0", 2); strings.Contains(chain, "retry=") {
+		t.Errorf("Deterministic chain has a retry count: %s", chain)
+	}
+	script, _, _ := unknownBootScript("x86_64", "aa:bb:cc:dd:ee:ff", "", -1, 1791987100, "", "", "test")
+	if strings.Contains(script, "ts=") {
+		t.Errorf("Deterministic script has a timestamp:\n%s", script)
+	}
+	bd := BootData{Params: "quiet", Kernel: ImageData{Path: "s3://boot/k"}}
+	if issuesJoinToken(bd, "") {
+		t.Errorf("No join token expected in %q", bd.Params)
+	}
+	bd.Kernel.Params = "spire_join_token=${SPIRE_JOIN_TOKEN}"
+	if !issuesJoinToken(bd, "") {
+		t.Errorf("Expected a join token in %q", bd.Kernel.Params)
+	}
+}
@@ -0,0 +1,98 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Cache statistics.
+//
+// GET /boot/v1/service/cache reports the age and size of the cached HSM
+// state, how often MAC, IP and NID lookups found a component in it, and the
+// size of the artifact and image digest caches.  A boot storm that keeps
+// missing the cache shows up as misses climbing with the forced refreshes.
+// The support bundle includes the same report as caches.json.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+type lookupCounter struct {
+	hits, misses atomic.Uint64
+}
+
+func (c *lookupCounter) count(found bool) {
+	if found {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (c *lookupCounter) report() map[string]uint64 {
+	return map[string]uint64{"hits": c.hits.Load(), "misses": c.misses.Load()}
+}
+
+var smLookups struct {
+	mac, ip, nid lookupCounter
+}
+
+func cacheStats() map[string]interface{} {
+	ret := make(map[string]interface{})
+	smMutex.RLock()
+	hsm := map[string]interface{}{"components": len(smDataMap), "updated": smTimeStamp}
+	if smData != nil {
+		hsm["ip-addresses"] = len(smData.IPAddrs)
+	}
+	if smTimeStamp > 0 {
+		hsm["age-seconds"] = time.Now().Unix() - smTimeStamp
+	}
+	smMutex.RUnlock()
+	unknownIPMutex.Lock()
+	hsm["unknown-ips"] = len(unknownIPs)
+	unknownIPMutex.Unlock()
+	hsm["lookups"] = map[string]interface{}{"mac": smLookups.mac.report(),
+		"ip": smLookups.ip.report(), "nid": smLookups.nid.report()}
+	ret["hsm"] = hsm
+	if artifacts != nil {
+		artifacts.mutex.Lock()
+		ret["artifacts"] = map[string]interface{}{"entries": len(artifacts.entries),
+			"bytes": artifacts.size, "limit": artifacts.limit}
+		artifacts.mutex.Unlock()
+	}
+	imageDigestMutex.Lock()
+	ret["image-digests"] = map[string]interface{}{"checked": len(imageDigestChecked)}
+	imageDigestMutex.Unlock()
+	return ret
+}
+
+func ServiceCacheGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ServiceCacheGet(): Received request %v\n", r.URL)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(cacheStats()); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,69 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServiceCache(t *testing.T) {
+	macHits, macMisses := smLookups.mac.hits.Load(), smLookups.mac.misses.Load()
+	nidMisses := smLookups.nid.misses.Load()
+	FindSMCompByMAC("00:1e:67:e3:46:51")
+	FindSMCompByMAC("00:00:00:00:00:00")
+	FindSMCompByNid(999999)
+
+	w := httptest.NewRecorder()
+	serviceCache(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/service/cache", nil))
+	var got struct {
+		HSM struct {
+			Components  int `json:"components"`
+			IPAddresses int `json:"ip-addresses"`
+			Lookups     map[string]struct {
+				Hits   uint64 `json:"hits"`
+				Misses uint64 `json:"misses"`
+			} `json:"lookups"`
+		} `json:"hsm"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET cache: %d %s", w.Code, w.Body.String())
+	}
+	if got.HSM.Components != len(getState().Components) || got.HSM.IPAddresses != len(getState().IPAddrs) {
+		t.Errorf("Unexpected HSM cache sizes %+v", got.HSM)
+	}
+	if mac := got.HSM.Lookups["mac"]; mac.Hits != macHits+1 || mac.Misses != macMisses+1 {
+		t.Errorf("MAC lookups not counted: %+v", mac)
+	}
+	if nid := got.HSM.Lookups["nid"]; nid.Misses != nidMisses+1 {
+		t.Errorf("NID lookups not counted: %+v", nid)
+	}
+
+	w = httptest.NewRecorder()
+	serviceCache(w, httptest.NewRequest(http.MethodPost, baseEndpoint+"/service/cache", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST cache: %d", w.Code)
+	}
+}
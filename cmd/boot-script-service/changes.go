@@ -0,0 +1,329 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Change feed for downstream caches.
+//
+// Every store and removal of a boot parameter entry is recorded under a
+// monotonically increasing revision, so edge caches and DHCP generators can
+// ask for what changed since the revision they last saw instead of
+// refetching everything.  The hmetcd interface does not expose etcd's own
+// revisions, so BSS keeps its own counter.  Only the newest changesMax
+// changes are kept; a since= older than that gets 410 Gone and the client
+// has to do a full sync.
+//
+// Revisions are allocated from one counter and a change is only published,
+// by moving the revision clients see past it, once its record is written and
+// every earlier revision has been published, so that a poller never skips a
+// record written late.  An instance that stalls in between holds the others
+// back for at most changesPublishWait; after that the later changes are
+// published anyway and a record written even later is missed.
+//
+// The revision key also tells the other BSS instances about a change: they
+// watch it in etcd (watchInit()) and wake their boot configuration watchers.
+// Instances keep no copy of the boot data of their own, so there is nothing
+// else to invalidate.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+const (
+	changesPfx          = "/changes/"
+	changesRevisionKey  = "/changes-revision"  // the last published revision
+	changesAllocatedKey = "/changes-allocated" // the last allocated one
+	changesPublishWait  = 5 * time.Second
+	changeUpdate        = "update"
+	changeDelete        = "delete"
+)
+
+var changesMax = uint(10000)
+
+func changeKey(rev int64) string {
+	return fmt.Sprintf("%s%020d", changesPfx, rev)
+}
+
+func currentRevision() (int64, error) {
+	val, exists, err := kvstore.Get(changesRevisionKey)
+	if err != nil || !exists {
+		return 0, err
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
+
+// Function initRevisions() creates the revision counters, the allocated one
+// starting at the published one of a datastore from before there were two.
+func initRevisions() error {
+	// Test-and-set does not work on a missing key in etcd.
+	return distLocked(func() error {
+		_, exists, err := kvstore.Get(changesAllocatedKey)
+		if err != nil || exists {
+			return err
+		}
+		val, exists, err := kvstore.Get(changesRevisionKey)
+		if err == nil && !exists {
+			val = "0"
+			err = kvstore.Store(changesRevisionKey, val)
+		}
+		if err == nil {
+			err = kvstore.Store(changesAllocatedKey, val)
+		}
+		return err
+	})
+}
+
+// Function nextRevisions() atomically allocates n revisions and returns the
+// first of them.
+func nextRevisions(n int64) (int64, error) {
+	for i := 0; i < 100; i++ {
+		val, exists, err := kvstore.Get(changesAllocatedKey)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			if err = initRevisions(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		rev, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		ok, err := kvstore.TAS(changesAllocatedKey, val, strconv.FormatInt(rev+n, 10))
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return rev + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("Too much contention on %s", changesAllocatedKey)
+}
+
+// Function publishRevisions() moves the published revision to last once
+// the revisions before first are published.
+func publishRevisions(first, last int64) error {
+	deadline := time.Now().Add(changesPublishWait)
+	for {
+		val, _, err := kvstore.Get(changesRevisionKey)
+		if err != nil {
+			return err
+		}
+		cur, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		if cur >= last {
+			return nil
+		}
+		late := time.Now().After(deadline)
+		if cur < first-1 && !late {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		ok, err := kvstore.TAS(changesRevisionKey, val, strconv.FormatInt(last, 10))
+		if err != nil {
+			return err
+		}
+		if ok {
+			if cur < first-1 {
+				log.Printf("WARNING: Change revisions %d to %d were not published in time, skipping them", cur+1, first-1)
+			}
+			return nil
+		}
+	}
+}
+
+// Function recordChange() adds a change of a boot parameter entry to the
+// change feed.  Failures are logged; they only cost downstream caches a full
+// sync.
+func recordChange(name, op string) {
+	recordChanges([]string{name}, op)
+}
+
+// Function recordChanges() records the same change of many entries with one
+// revision allocation, e.g. for a bulk store.
+func recordChanges(names []string, op string) {
+	if len(names) == 0 {
+		return
+	}
+	replicaChanged()
+	first, err := nextRevisions(int64(len(names)))
+	if err != nil {
+		log.Printf("Failed to record %s of %s: %s", op, strings.Join(names, ","), err)
+		return
+	}
+	now := time.Now().Unix()
+	last := first + int64(len(names)) - 1
+	stored, prune := false, false
+	for i, name := range names {
+		rev := first + int64(i)
+		err = storeData(changeKey(rev), bssTypes.Change{Revision: rev, Name: name, Op: op, Time: now})
+		if err != nil {
+			log.Printf("Failed to record %s of %s: %s", op, name, err)
+			continue
+		}
+		auditEmit(auditConfigChange, "", "", name, "Boot parameters %s, revision %d", op, rev)
+		stored, prune = true, prune || rev%100 == 0
+	}
+	// Published even if no record was stored, so the others do not wait.
+	if err = publishRevisions(first, last); err != nil {
+		log.Printf("Failed to publish change revisions %d to %d: %s", first, last, err)
+	}
+	if stored {
+		signalChange()
+	}
+	if prune {
+		pruneChanges()
+	}
+}
+
+// Function getChangeRange() returns the recorded changes sorted by revision,
+// which the mem: store does not do.
+func getChangeRange() ([]hmetcd.Kvi_KV, error) {
+	kvl, err := kvstore.GetRange(changesPfx+keyMin, changesPfx+keyMax)
+	sort.Slice(kvl, func(i, j int) bool { return kvl[i].Key < kvl[j].Key })
+	return kvl, err
+}
+
+func pruneChanges() {
+	kvl, err := getChangeRange()
+	if err != nil || uint(len(kvl)) <= changesMax {
+		return
+	}
+	for _, kv := range kvl[:uint(len(kvl))-changesMax] {
+		kvstore.Delete(kv.Key)
+	}
+}
+
+// Function getChanges() returns the latest change of every entry changed
+// after the revision or at or after the Unix time, oldest first.  It fails
+// with gone set if changes after the marker have already been pruned.
+func getChanges(since int64, isTime bool) (ret bssTypes.Changes, gone bool, err error) {
+	ret.Changes = []bssTypes.Change{}
+	ret.Revision, err = currentRevision()
+	if err != nil {
+		return
+	}
+	kvl, err := getChangeRange()
+	if err != nil {
+		return
+	}
+	var changes []bssTypes.Change
+	for _, kv := range kvl {
+		var c bssTypes.Change
+		if json.Unmarshal([]byte(kv.Value), &c) == nil && c.Revision <= ret.Revision {
+			changes = append(changes, c)
+		}
+	}
+	if len(changes) > 0 && changes[0].Revision > 1 {
+		if isTime && changes[0].Time > since || !isTime && changes[0].Revision > since+1 {
+			gone = true
+			return
+		}
+	}
+	latest := make(map[string]int)
+	for _, c := range changes {
+		if isTime && c.Time < since || !isTime && c.Revision <= since {
+			continue
+		}
+		if i, ok := latest[c.Name]; ok {
+			ret.Changes[i].Revision = -1
+		}
+		latest[c.Name] = len(ret.Changes)
+		ret.Changes = append(ret.Changes, c)
+	}
+	kept := ret.Changes[:0]
+	for _, c := range ret.Changes {
+		if c.Revision < 0 {
+			continue
+		}
+		if c.Op == changeUpdate {
+			if bd, e := LookupBootData(c.Name); e == nil {
+				bp := bssTypes.BootParams{Hosts: []string{c.Name}, Params: bd.Params,
+					Kernel: bd.Kernel.Path, Initrd: bd.Initrd.Path, CloudInit: bd.CloudInit, FirstBoot: bd.FirstBoot,
+					Messages: bd.Messages, Labels: bd.Labels, Reasons: bd.Reasons, Payload: bd.Payload, Files: bd.Files,
+					DTB: bd.DTB, ACPI: bd.ACPI, InitrdOverlays: bd.Overlays}
+				c.Params = &bp
+			} else {
+				// Removed behind the change feed's back.
+				c.Op = changeDelete
+			}
+		}
+		kept = append(kept, c)
+	}
+	ret.Changes = kept
+	return
+}
+
+func ChangesGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ChangesGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	str := strings.Join(r.Form["since"], "")
+	var changes bssTypes.Changes
+	var gone bool
+	var err error
+	if str == "" {
+		// Without since only the current revision is returned, to be used
+		// as the starting point after a full sync.
+		changes.Changes = []bssTypes.Change{}
+		changes.Revision, err = currentRevision()
+	} else if since, perr := strconv.ParseInt(str, 10, 64); perr == nil {
+		changes, gone, err = getChanges(since, false)
+	} else if t, terr := time.Parse(time.RFC3339, str); terr == nil {
+		changes, gone, err = getChanges(t.Unix(), true)
+	} else {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: since must be a revision or an RFC 3339 time: %s", str))
+		return
+	}
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve changes: %s", err))
+		return
+	}
+	if gone {
+		base.SendProblemDetailsGeneric(w, http.StatusGone,
+			fmt.Sprintf("Changes since %s are no longer available, a full sync is needed", str))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(changes)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
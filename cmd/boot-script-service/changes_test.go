@@ -0,0 +1,109 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func getChangesAPI(t *testing.T, since string) (int, bssTypes.Changes) {
+	req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/changes?since="+since, nil)
+	rr := httptest.NewRecorder()
+	changes(rr, req)
+	var c bssTypes.Changes
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &c); err != nil {
+			t.Fatalf("Bad changes response %s: %s", rr.Body, err)
+		}
+	}
+	return rr.Code, c
+}
+
+func TestChanges(t *testing.T) {
+	const host, other = "x0c1s21b0n0", "x0c3s5b0n0"
+	defer kvstore.Delete(paramsPfx + host)
+	defer kvstore.Delete(paramsPfx + other)
+
+	_, start := getChangesAPI(t, "")
+	Store(bssTypes.BootParams{Hosts: []string{host}, Params: "a"}, "test")
+	Store(bssTypes.BootParams{Hosts: []string{host}, Params: "b"}, "test")
+	Store(bssTypes.BootParams{Hosts: []string{other}, Params: "c"}, "test")
+	Remove(bssTypes.BootParams{Hosts: []string{other}}, deleteOrphan)
+
+	code, c := getChangesAPI(t, strconv.FormatInt(start.Revision, 10))
+	if code != http.StatusOK || c.Revision != start.Revision+4 || len(c.Changes) != 2 {
+		t.Fatalf("Changes returned %d %+v", code, c)
+	}
+	if c.Changes[0].Name != host || c.Changes[0].Op != changeUpdate ||
+		c.Changes[0].Params == nil || c.Changes[0].Params.Params != "b" {
+		t.Errorf("Unexpected change %+v", c.Changes[0])
+	}
+	if c.Changes[1].Name != other || c.Changes[1].Op != changeDelete || c.Changes[1].Params != nil {
+		t.Errorf("Unexpected change %+v", c.Changes[1])
+	}
+	if _, c = getChangesAPI(t, strconv.FormatInt(c.Revision, 10)); len(c.Changes) != 0 {
+		t.Errorf("Changes since the latest revision returned %+v", c.Changes)
+	}
+
+	defer func(max uint) { changesMax = max }(changesMax)
+	changesMax = 1
+	pruneChanges()
+	if code, _ = getChangesAPI(t, strconv.FormatInt(start.Revision, 10)); code != http.StatusGone {
+		t.Errorf("Changes since a pruned revision returned %d, expected %d", code, http.StatusGone)
+	}
+	if code, _ = getChangesAPI(t, "yesterday"); code != http.StatusBadRequest {
+		t.Errorf("Bad since returned %d, expected %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestChangesPublishedInOrder(t *testing.T) {
+	first, err := nextRevisions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := nextRevisions(1)
+	done := make(chan error)
+	go func() { done <- publishRevisions(second, second) }()
+	time.Sleep(50 * time.Millisecond)
+	if rev, _ := currentRevision(); rev >= first {
+		t.Errorf("Revision %d published before revision %d", second, first)
+	}
+	if err = publishRevisions(first, first); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-done:
+	case <-time.After(changesPublishWait):
+		t.Fatalf("Revision %d not published after revision %d", second, first)
+	}
+	if rev, _ := currentRevision(); err != nil || rev != second {
+		t.Errorf("Published revision is %d, %v, expected %d", rev, err, second)
+	}
+}
@@ -71,18 +71,16 @@ func generateInstanceID(prefix string) string {
 func findRemoteAddr(r *http.Request) string {
 	remoteaddr := r.Header.Get("X-Forwarded-For")
 	if remoteaddr == "" {
-		// Since IPV6 address have colons we only strip the last colon which
-		// is the port. We know this from the http docs indicating IP:PORT
-		// will always be set. https://golang.org/pkg/net/http/#Request
-		remoteaddrSlice := strings.Split(r.RemoteAddr, ":")
-		remoteaddr = strings.Join(remoteaddrSlice[:len(remoteaddrSlice)-1], ":")
+		// RemoteAddr is always IP:PORT, with an IPv6 address in brackets.
+		// https://golang.org/pkg/net/http/#Request
+		remoteaddr = r.RemoteAddr
 	} else {
 		// XFF is a comma seperated list of IPs forwarded through.
 		// Envoy will append the trusted client IP, which is what we want.
 		remoteaddrSlice := strings.Split(remoteaddr, ",")
 		remoteaddr = remoteaddrSlice[len(remoteaddrSlice)-1]
 	}
-	return remoteaddr
+	return canonicalIP(remoteaddr)
 }
 
 // generateMetaData attempts to inject and discoverable meta-data we know about
@@ -108,6 +106,7 @@ func generateMetaData(xname string, metadata map[string]interface{}) error {
 	if metadata["shasta-role"] == nil {
 		metadata["shasta-role"] = comp.SubRole
 	}
+	addKdumpMetaData(xname, comp.Role, metadata)
 
 	return nil
 }
@@ -133,11 +132,76 @@ func mergeMaps(first, second map[string]interface{}) map[string]interface{} {
 	return first
 }
 
+func emptyIfNil(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return make(map[string]interface{})
+	}
+	return m
+}
+
+// The data the cloud-init responses of a node are merged from, shared by
+// /meta-data, /user-data and /cloudinit/<xname>/resolved.
+type cloudInitLayers struct {
+	metaData, userData map[string]interface{} // The node's, meta-data with generated values
+	generated          []string               // Meta-data keys filled in from HSM
+	role               string                 // shasta-role
+	roleData           BootData
+}
+
+// Function cloudInitLayersOf() looks up the cloud-init data of xname, a node
+// known to HSM, or with known false the default data.
+func cloudInitLayersOf(xname string, known bool) cloudInitLayers {
+	// If name is "" here, LookupByName uses the default tag, which is what we want.
+	bootdata, _ := LookupByName(xname)
+	l := cloudInitLayers{metaData: emptyIfNil(bootdata.CloudInit.MetaData),
+		userData: emptyIfNil(bootdata.CloudInit.UserData)}
+	had := make(map[string]bool)
+	for k, v := range l.metaData {
+		had[k] = v != nil
+	}
+	if !known {
+		l.metaData["instance-id"] = generateInstanceID("")
+	} else if err := generateMetaData(xname, l.metaData); err != nil {
+		log.Printf("Warning - %s: Some meta data could not be found!\n", xname)
+	}
+	for k := range l.metaData {
+		if k == "instance-id" || !had[k] {
+			l.generated = append(l.generated, k)
+		}
+	}
+	if shastaRole, ok := l.metaData["shasta-role"].(string); ok {
+		l.role = shastaRole
+		l.roleData, _ = LookupByRole(shastaRole)
+	}
+	return l
+}
+
+// Function mergedMetaData() returns the meta-data a node gets: that of its
+// shasta-role tag overridden by its own, plus the Global tag's under Global.
+// It changes the role data.
+func (l cloudInitLayers) mergedMetaData() map[string]interface{} {
+	merged := mergeMaps(emptyIfNil(l.roleData.CloudInit.MetaData), l.metaData)
+	globaldata, _ := LookupGlobalData()
+	merged["Global"] = emptyIfNil(globaldata.CloudInit.MetaData)
+	return merged
+}
+
+// Function mergedUserData() returns the user-data a node gets, merged the
+// same way without generated values, except local-hostname.  It changes the
+// role data.
+func (l cloudInitLayers) mergedUserData() map[string]interface{} {
+	merged := mergeMaps(emptyIfNil(l.roleData.CloudInit.UserData), l.userData)
+	if merged["local-hostname"] == nil && l.metaData["local-hostname"] != nil {
+		merged["local-hostname"] = l.metaData["local-hostname"]
+	}
+	return merged
+}
+
 func metaDataGetAPI(w http.ResponseWriter, r *http.Request) {
-	var respData map[string]interface{}
 	var httpStatus = http.StatusOK
 	var isDefault = false
 
+	noStoreByAddr(w)
 	remoteaddr := findRemoteAddr(r)
 
 	// Get the xname to lookup metadata.
@@ -145,48 +209,13 @@ func metaDataGetAPI(w http.ResponseWriter, r *http.Request) {
 	if !found {
 		isDefault = true
 		log.Printf("CloudInit -> No XName found for: %s, using default data\n", remoteaddr)
+	} else if isSuspect(remoteaddr) {
+		log.Printf("CloudInit -> %s (%s) failed identity checks, using default data\n", remoteaddr, xname)
+		xname, isDefault = "", true
 	}
 
-	// If name is "" here, LookupByName uses the default tag, which is what we want.
-	bootdata, _ := LookupByName(xname)
-	globaldata, _ := LookupGlobalData()
-
 	log.Printf("GET /meta-data, xname: %s ip: %s", xname, remoteaddr)
-	respData = bootdata.CloudInit.MetaData
-	// If empty, initialize an empty map
-	if len(respData) == 0 {
-		respData = make(map[string]interface{})
-	}
-
-	if isDefault {
-		respData["instance-id"] = generateInstanceID("")
-	} else {
-		err := generateMetaData(xname, respData)
-		if err != nil {
-			log.Printf("Warning - %s: Some meta data could not be found!\n", xname)
-		}
-	}
-
-	roleData := BootData{}
-	shastaRole := respData["shasta-role"]
-	if shastaRole != nil {
-		roleData, _ = LookupByRole(shastaRole.(string))
-	}
-	roleInitData := roleData.CloudInit.MetaData
-	if len(roleInitData) == 0 {
-		roleInitData = make(map[string]interface{})
-	}
-
-	// Override any role data from the per node data
-	mergedData := mergeMaps(roleInitData, respData)
-
-	globalRespData := globaldata.CloudInit.MetaData
-	// If empty, initialize an empty map
-	if len(globalRespData) == 0 {
-		globalRespData = make(map[string]interface{})
-	}
-
-	mergedData["Global"] = globalRespData
+	mergedData := cloudInitLayersOf(xname, !isDefault).mergedMetaData()
 	queries := r.URL.Query()
 
 	lookupKeys, ok := queries[QUERYKEY]
@@ -195,7 +224,7 @@ func metaDataGetAPI(w http.ResponseWriter, r *http.Request) {
 		lookupKey := strings.Split(lookupKeys[0], ".")
 		rval, err := mapLookup(mergedData, lookupKey...)
 		if err != nil {
-			debugf("CloudInit MetaData: Query Not Found: %v\n", err)
+			debugmf(debugCloudInit, "CloudInit MetaData: Query Not Found: %v\n", err)
 			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
 				fmt.Sprintf("Not Found"))
 			return
@@ -212,10 +241,10 @@ func metaDataGetAPI(w http.ResponseWriter, r *http.Request) {
 }
 
 func userDataGetAPI(w http.ResponseWriter, r *http.Request) {
-	var respData map[string]interface{}
 	var httpStatus = http.StatusOK
 	isDefault := false
 
+	noStoreByAddr(w)
 	remoteaddr := findRemoteAddr(r)
 
 	// Get the xname to lookup metadata.
@@ -223,45 +252,13 @@ func userDataGetAPI(w http.ResponseWriter, r *http.Request) {
 	if !found {
 		isDefault = true
 		log.Printf("CloudInit -> No XName found for: %s, using default data\n", remoteaddr)
-	}
-
-	// If name is "" here, LookupByName uses the default tag, which is what we want.
-	bootdata, _ := LookupByName(xname)
-	metaData := bootdata.CloudInit.MetaData
-
-	if len(metaData) == 0 {
-		metaData = make(map[string]interface{})
-	}
-
-	if !isDefault {
-		err := generateMetaData(xname, metaData)
-		if err != nil {
-			log.Printf("Warning - %s: Some meta data could not be found!\n", xname)
-		}
-	}
-
-	roleData := BootData{}
-	shastaRole := metaData["shasta-role"]
-	if shastaRole != nil {
-		roleData, _ = LookupByRole(shastaRole.(string))
-	}
-	roleInitData := roleData.CloudInit.UserData
-	if len(roleInitData) == 0 {
-		roleInitData = make(map[string]interface{})
+	} else if isSuspect(remoteaddr) {
+		log.Printf("CloudInit -> %s (%s) failed identity checks, using default data\n", remoteaddr, xname)
+		xname, isDefault = "", true
 	}
 
 	log.Printf("GET /user-data, xname: %s ip: %s", xname, remoteaddr)
-	respData = bootdata.CloudInit.UserData
-	if len(respData) == 0 {
-		respData = make(map[string]interface{})
-	}
-
-	// Override any role data from the per node data
-	mergedData := mergeMaps(roleInitData, respData)
-
-	if mergedData["local-hostname"] == nil && metaData["local-hostname"] != nil {
-		mergedData["local-hostname"] = metaData["local-hostname"]
-	}
+	mergedData := cloudInitLayersOf(xname, !isDefault).mergedUserData()
 
 	databytes, err := yaml.Marshal(mergedData)
 	if err != nil {
@@ -292,7 +289,7 @@ func endpointHistoryGetAPI(w http.ResponseWriter, r *http.Request) {
 		lastAccessTypeStruct = bssTypes.EndpointType(endpoint)
 	}
 
-	accesses, err := SearchEndpointAccessed(name, lastAccessTypeStruct)
+	accesses, err := SearchEndpointAccessed(name, lastAccessTypeStruct, historyScopeOf(r))
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to search for name: %s, endpoint: %s", name, endpoint)
 		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError, errMsg)
@@ -321,7 +318,7 @@ func phoneHomePostAPI(w http.ResponseWriter, r *http.Request) {
 	dec := json.NewDecoder(r.Body)
 	err := dec.Decode(&args)
 	if err != nil {
-		debugf("CloudInit PhoneHome: Bad Request: %v\n", err)
+		debugmf(debugCloudInit, "CloudInit PhoneHome: Bad Request: %v\n", err)
 		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
 			fmt.Sprintf("Bad Request"))
 		return
@@ -331,25 +328,35 @@ func phoneHomePostAPI(w http.ResponseWriter, r *http.Request) {
 	// Get the xname to lookup metadata.
 	xname, found := FindXnameByIP(remoteaddr)
 	if !found {
-		debugf("CloudInit -> Phone Home called for unknown xname, ip: %s", remoteaddr)
+		debugmf(debugCloudInit, "CloudInit -> Phone Home called for unknown xname, ip: %s", remoteaddr)
 		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
 			fmt.Sprintf("XName not found for IP"))
 		return
 	}
+	if !checkNodeToken(w, r, xname, scopePhoneHome) {
+		return
+	}
 	hosts = append(hosts, xname)
+	// Record the completed first boot before the lookup, so the regular
+	// cloud-init data is the one updated below.
+	if comp, ok := FindSMCompByName(xname); ok {
+		markFirstBootDone(comp, xname)
+	}
 	bootdata, _ := LookupByName(xname)
 
 	bootdata.CloudInit.PhoneHome = args
 	bp.Hosts = hosts
 	bp.CloudInit = bootdata.CloudInit
 
-	if err = Update(bp); err != nil {
+	if err = Update(bp, xname); err != nil {
 		LogBootParameters(fmt.Sprintf("/phone-home FAILED: %s", err.Error()), args)
 		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
 			fmt.Sprintf("Not Found: %s", err))
 		return
 	}
 
+	updateEndpointAccessed(xname, bssTypes.EndpointTypePhoneHome)
+
 	log.Printf("POST /phone-home, xname: %s ip: %s", xname, remoteaddr)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
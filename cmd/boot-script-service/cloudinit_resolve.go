@@ -0,0 +1,173 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Resolved cloud-init data.
+//
+// GET /boot/v1/cloudinit/<xname>/resolved returns the meta-data and
+// user-data a node gets, merged the same way as /meta-data and /user-data.
+// With ?explain=true it also returns where each key came from:
+//
+//	node            the node's own boot parameters
+//	role:<role>     the HSM role tag, when the node has no entry of its own
+//	default         the Default tag, when there is neither
+//	subrole:<name>  the tag named by shasta-role, overridden by the above
+//	generated       filled in from HSM (instance-id, local-hostname, ...)
+//	global          the Global tag, under the Global key of meta-data
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const cloudInitEndpoint = baseEndpoint + "/cloudinit/"
+
+// Function explainLeaves() records src as the source of every leaf of m.
+func explainLeaves(prov map[string]string, m map[string]interface{}, src, prefix string) {
+	for key, val := range m {
+		if sub, ok := val.(map[string]interface{}); ok && len(sub) > 0 {
+			explainLeaves(prov, sub, src, prefix+key+".")
+		} else {
+			prov[prefix+key] = src
+		}
+	}
+}
+
+// Function explainMerge() updates prov, the sources of the leaves of first,
+// for mergeMaps(first, second) given secondProv, the sources of second.  It
+// must be called before mergeMaps() changes first.
+func explainMerge(prov map[string]string, first, second map[string]interface{},
+	secondProv map[string]string, prefix string) {
+	for key, val := range second {
+		path := prefix + key
+		firstMap, firstOK := first[key].(map[string]interface{})
+		secondMap, secondOK := val.(map[string]interface{})
+		if firstOK && secondOK {
+			explainMerge(prov, firstMap, secondMap, secondProv, path+".")
+			continue
+		}
+		for p := range prov {
+			if p == path || strings.HasPrefix(p, path+".") {
+				delete(prov, p)
+			}
+		}
+		for p, src := range secondProv {
+			if p == path || strings.HasPrefix(p, path+".") {
+				prov[p] = src
+			}
+		}
+	}
+}
+
+func cloudInitNodeSource(xname string, comp SMComponent) string {
+	if _, err := lookupHost(xname); err == nil {
+		return "node"
+	}
+	if comp.Role != "" {
+		if _, err := lookupHost(comp.Role); err == nil {
+			return "role:" + comp.Role
+		}
+	}
+	return strings.ToLower(DefaultTag)
+}
+
+// Function resolveCloudInit() merges the cloud-init data for a node known to
+// HSM.
+func resolveCloudInit(xname string) (bssTypes.CloudInitResolved, error) {
+	ret := bssTypes.CloudInitResolved{Xname: xname}
+	comp, found := FindSMCompByName(xname)
+	if !found {
+		return ret, fmt.Errorf("Unknown component %s", xname)
+	}
+	l := cloudInitLayersOf(comp.ID, true)
+	nodeSrc := cloudInitNodeSource(comp.ID, comp)
+	roleSrc := ""
+	if l.role != "" {
+		roleSrc = "subrole:" + l.role
+	}
+
+	// The sources are worked out before merging changes the role data.
+	nodeProv := make(map[string]string)
+	explainLeaves(nodeProv, l.metaData, nodeSrc, "")
+	for _, k := range l.generated {
+		nodeProv[k] = "generated"
+	}
+	metaProv := make(map[string]string)
+	roleMeta := emptyIfNil(l.roleData.CloudInit.MetaData)
+	explainLeaves(metaProv, roleMeta, roleSrc, "")
+	explainMerge(metaProv, roleMeta, l.metaData, nodeProv, "")
+
+	userProv := make(map[string]string)
+	roleUser := emptyIfNil(l.roleData.CloudInit.UserData)
+	explainLeaves(userProv, roleUser, roleSrc, "")
+	nodeUserProv := make(map[string]string)
+	explainLeaves(nodeUserProv, l.userData, nodeSrc, "")
+	explainMerge(userProv, roleUser, l.userData, nodeUserProv, "")
+
+	l.roleData.CloudInit.MetaData, l.roleData.CloudInit.UserData = roleMeta, roleUser
+	ret.MetaData, ret.UserData = l.mergedMetaData(), l.mergedUserData()
+	for p := range metaProv {
+		if p == "Global" || strings.HasPrefix(p, "Global.") {
+			delete(metaProv, p)
+		}
+	}
+	explainLeaves(metaProv, map[string]interface{}{"Global": ret.MetaData["Global"]}, "global", "")
+	if _, ok := userProv["local-hostname"]; !ok && ret.UserData["local-hostname"] != nil {
+		userProv["local-hostname"] = nodeProv["local-hostname"]
+	}
+
+	ret.Provenance = &bssTypes.CloudInitProvenance{MetaData: metaProv, UserData: userProv}
+	return ret, nil
+}
+
+func CloudInitResolvedGet(w http.ResponseWriter, r *http.Request) {
+	debugf("CloudInitResolvedGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, cloudInitEndpoint), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "resolved" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	resolved, err := resolveCloudInit(parts[0])
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", err))
+		return
+	}
+	if strings.Join(r.Form["explain"], "") != "true" {
+		resolved.Provenance = nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resolved); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,107 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestCloudInitResolved(t *testing.T) {
+	const node = "x0c0s1b0n0"
+	defer func() {
+		for _, name := range []string{node, "TestSubRole", GlobalTag} {
+			kvstore.Delete(paramsPfx + name)
+		}
+	}()
+	storeData(paramsPfx+node, BootDataStore{CloudInit: bssTypes.CloudInit{
+		MetaData: map[string]interface{}{"shasta-role": "TestSubRole", "a": 1,
+			"nested": map[string]interface{}{"x": 1}},
+		UserData: map[string]interface{}{"u": 1},
+	}})
+	storeData(paramsPfx+"TestSubRole", BootDataStore{CloudInit: bssTypes.CloudInit{
+		MetaData: map[string]interface{}{"a": 0, "b": 2, "nested": map[string]interface{}{"y": 2}},
+		UserData: map[string]interface{}{"u": 0, "v": 2},
+	}})
+	storeData(paramsPfx+GlobalTag, BootDataStore{CloudInit: bssTypes.CloudInit{
+		MetaData: map[string]interface{}{"g": 1},
+	}})
+
+	req := httptest.NewRequest(http.MethodGet, cloudInitEndpoint+node+"/resolved?explain=true", nil)
+	w := httptest.NewRecorder()
+	cloudInitResolved(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET resolved returned %d: %s", w.Code, w.Body.String())
+	}
+	var res bssTypes.CloudInitResolved
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Bad response: %s", err)
+	}
+	if res.MetaData["a"] != float64(1) || res.MetaData["b"] != float64(2) ||
+		res.MetaData["local-hostname"] != node || res.UserData["v"] != float64(2) {
+		t.Errorf("Unexpected resolved data %+v", res)
+	}
+	if res.Provenance == nil {
+		t.Fatalf("No provenance with explain=true")
+	}
+	for key, want := range map[string]string{
+		"a":              "node",
+		"b":              "subrole:TestSubRole",
+		"nested.x":       "node",
+		"nested.y":       "subrole:TestSubRole",
+		"local-hostname": "generated",
+		"instance-id":    "generated",
+		"shasta-role":    "node",
+		"Global.g":       "global",
+	} {
+		if got := res.Provenance.MetaData[key]; got != want {
+			t.Errorf("meta-data %s: expected source %s, got %s", key, want, got)
+		}
+	}
+	for key, want := range map[string]string{"u": "node", "v": "subrole:TestSubRole",
+		"local-hostname": "generated"} {
+		if got := res.Provenance.UserData[key]; got != want {
+			t.Errorf("user-data %s: expected source %s, got %s", key, want, got)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, cloudInitEndpoint+node+"/resolved", nil)
+	w = httptest.NewRecorder()
+	cloudInitResolved(w, req)
+	res = bssTypes.CloudInitResolved{}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Provenance != nil {
+		t.Errorf("Provenance returned without explain")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, cloudInitEndpoint+"x9999c0s0b0n0/resolved", nil)
+	w = httptest.NewRecorder()
+	cloudInitResolved(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Unknown node returned %d, expected %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,297 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Kernel command line history.
+//
+// The endpoint history only says when a node last fetched its boot
+// script.  To answer what exactly a node booted with at some time, the
+// kernel and command line of every boot script a node is served are kept
+// under /cmdline-history/<xname>, the newest --cmdline-history-max of them.
+// Boots with the same command line as the one before only move the Last
+// time of its record, so a node that reboots the same way takes one record.
+// Those repeats are counted in memory and written with the next change, or
+// the next boot over a minute after the last write, so a boot script only
+// costs a datastore round trip when there is something new; until then
+// the other instances see a Last time and Count up to a minute behind, and
+// a restart loses them.  URL query strings (presigned URL signatures) are
+// dropped and the values of parameters with "token" in their name are
+// redacted.  Command lines can hold other secrets, so with a datastore key
+// the records are sealed (datastore_crypt.go).
+//
+// GET /boot/v1/history/cmdline/<xname> returns the records oldest first,
+// and with at= (an RFC 3339 or Unix time) only the one in effect then.
+// What a caller sees is scoped like the endpoint history, see
+// history_scope.go.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	cmdlineHistoryEndpoint = baseEndpoint + "/history/cmdline/"
+	cmdlineHistoryPfx      = "/cmdline-history/"
+	cmdlineRedacted        = "<redacted>"
+)
+
+// Seconds repeats of a command line are counted without writing them
+const cmdlineHistoryBatch = 60
+
+var cmdlineHistoryMax = uint(50) // per node, 0 disables the history
+
+// The newest record of each node as last written by this instance, and the
+// repeats not written yet.
+type cmdlineTail struct {
+	kernel, cmdline string
+	written, last   int64
+	pending         int
+}
+
+var cmdlineHistoryTails = struct {
+	sync.Mutex
+	nodes map[string]*cmdlineTail
+}{nodes: make(map[string]*cmdlineTail)}
+
+// Serializes the read-modify-write of the history of each node within this
+// instance, so that nodes booting at the same time do not wait on each other.
+var cmdlineHistoryLocks = struct {
+	sync.Mutex
+	nodes map[string]*cmdlineHistoryLock
+}{nodes: make(map[string]*cmdlineHistoryLock)}
+
+type cmdlineHistoryLock struct {
+	sync.Mutex
+	users int
+}
+
+// Function lockCmdlineHistory() locks the history of a node, and returns the
+// function that unlocks it.
+func lockCmdlineHistory(name string) func() {
+	cmdlineHistoryLocks.Lock()
+	l := cmdlineHistoryLocks.nodes[name]
+	if l == nil {
+		l = &cmdlineHistoryLock{}
+		cmdlineHistoryLocks.nodes[name] = l
+	}
+	l.users++
+	cmdlineHistoryLocks.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		cmdlineHistoryLocks.Lock()
+		if l.users--; l.users == 0 {
+			delete(cmdlineHistoryLocks.nodes, name)
+		}
+		cmdlineHistoryLocks.Unlock()
+	}
+}
+
+// Function stripQuery() returns a URL without its query string.
+func stripQuery(u string) string {
+	if i := strings.IndexByte(u, '?'); i >= 0 && strings.Contains(u[:i], "://") {
+		return u[:i]
+	}
+	return u
+}
+
+// Function scriptCmdline() returns the kernel and the command line of the
+// kernel line of a boot script, as they are kept in the history.
+func scriptCmdline(script string) (string, string, bool) {
+	for _, line := range strings.Split(script, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "kernel" {
+			continue
+		}
+		fields = fields[1:]
+		if len(fields) > 2 && fields[0] == "--name" {
+			fields = fields[2:]
+		}
+		kernel := stripQuery(fields[0])
+		var args []string
+		for _, f := range fields[1:] {
+			if f == "||" {
+				break
+			}
+			if key, value, ok := strings.Cut(f, "="); ok {
+				if strings.Contains(strings.ToLower(key), "token") {
+					f = key + "=" + cmdlineRedacted
+				} else {
+					f = key + "=" + stripQuery(value)
+				}
+			}
+			args = append(args, f)
+		}
+		return kernel, strings.Join(args, " "), true
+	}
+	return "", "", false
+}
+
+func readCmdlineHistory(name string) ([]bssTypes.CmdlineRecord, error) {
+	var recs []bssTypes.CmdlineRecord
+	val, exists, err := kvstore.Get(cmdlineHistoryPfx + name)
+	if err == nil && exists {
+		err = unsealValue(val, &recs)
+	}
+	return recs, err
+}
+
+// Function addPending() counts the repeats of tail not written yet into the
+// newest record, if it is still the one tail was written as.
+func (tail *cmdlineTail) addPending(recs []bssTypes.CmdlineRecord) {
+	if n := len(recs); tail != nil && tail.pending > 0 && n > 0 &&
+		recs[n-1].Kernel == tail.kernel && recs[n-1].Cmdline == tail.cmdline {
+		recs[n-1].Last = tail.last
+		recs[n-1].Count += tail.pending
+	}
+}
+
+// Function getCmdlineHistory() returns the history of a node, with the
+// repeats this instance has not written yet.
+func getCmdlineHistory(name string) ([]bssTypes.CmdlineRecord, error) {
+	recs, err := readCmdlineHistory(name)
+	cmdlineHistoryTails.Lock()
+	cmdlineHistoryTails.nodes[name].addPending(recs)
+	cmdlineHistoryTails.Unlock()
+	return recs, err
+}
+
+// Function recordCmdline() adds the kernel line of a boot script served to
+// a node to its history.  Failures are logged, the script has been sent.
+func recordCmdline(name, script string) {
+	if cmdlineHistoryMax == 0 || name == "" {
+		return
+	}
+	kernel, cmdline, ok := scriptCmdline(script)
+	if !ok {
+		return
+	}
+	now := time.Now().Unix()
+	defer lockCmdlineHistory(name)()
+	cmdlineHistoryTails.Lock()
+	tail := cmdlineHistoryTails.nodes[name]
+	if tail != nil && tail.kernel == kernel && tail.cmdline == cmdline && now-tail.written < cmdlineHistoryBatch {
+		tail.last = now
+		tail.pending++
+		cmdlineHistoryTails.Unlock()
+		return
+	}
+	cmdlineHistoryTails.Unlock()
+
+	recs, err := readCmdlineHistory(name)
+	if err != nil {
+		log.Printf("Failed to retrieve the command line history of %s: %s", name, err)
+		return
+	}
+	cmdlineHistoryTails.Lock()
+	tail.addPending(recs)
+	cmdlineHistoryTails.Unlock()
+	if n := len(recs); n > 0 && recs[n-1].Kernel == kernel && recs[n-1].Cmdline == cmdline {
+		recs[n-1].Last = now
+		recs[n-1].Count++
+	} else {
+		recs = append(recs, bssTypes.CmdlineRecord{First: now, Last: now, Count: 1,
+			Kernel: kernel, Cmdline: cmdline})
+	}
+	if uint(len(recs)) > cmdlineHistoryMax {
+		recs = recs[uint(len(recs))-cmdlineHistoryMax:]
+	}
+	value, err := sealValue(recs)
+	if err == nil {
+		err = kvstore.Store(cmdlineHistoryPfx+name, value)
+	}
+	if err != nil {
+		log.Printf("Failed to store the command line history of %s: %s", name, err)
+		return
+	}
+	cmdlineHistoryTails.Lock()
+	cmdlineHistoryTails.nodes[name] = &cmdlineTail{kernel: kernel, cmdline: cmdline, written: now, last: now}
+	cmdlineHistoryTails.Unlock()
+}
+
+func CmdlineHistoryGet(w http.ResponseWriter, r *http.Request) {
+	debugf("CmdlineHistoryGet(): Received request %v\n", r.URL)
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, cmdlineHistoryEndpoint))
+	if err != nil || name == "" || strings.Contains(name, "/") {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - expected %s<xname>", cmdlineHistoryEndpoint))
+		return
+	}
+	scope := historyScopeOf(r)
+	if !scope.visible(name) {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No command line history for %s", name))
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	at := int64(-1)
+	if v := r.Form.Get("at"); v != "" {
+		if at, err = auditTime(v); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request - at %s", err))
+			return
+		}
+	}
+	recs, err := getCmdlineHistory(name)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve the command line history of %s: %s", name, err))
+		return
+	}
+	if at >= 0 {
+		var inEffect []bssTypes.CmdlineRecord
+		for _, rec := range recs {
+			if rec.First <= at {
+				inEffect = []bssTypes.CmdlineRecord{rec}
+			}
+		}
+		recs = inEffect
+	}
+	if recs == nil {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No command line history for %s", name))
+		return
+	}
+	for i := range recs {
+		if scope.coarse > 0 {
+			recs[i].First -= recs[i].First % scope.coarse
+			recs[i].Last -= recs[i].Last % scope.coarse
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(bssTypes.CmdlineHistory{Name: name, Records: recs})
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,154 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestScriptCmdline(t *testing.T) {
+	script := "#!ipxe\n" +
+		"kernel --name kernel https://s3/k?X-Amz-Signature=ab quiet metal.server=https://s3/r?X-Amz-Signature=cd spire_join_token=secret bss_referral_token=uuid ds=nocloud-net;s=http://10.1.1.1:8888/ || goto boot_retry\n" +
+		"initrd --name initrd https://s3/i || goto boot_retry\n"
+	kernel, cmdline, ok := scriptCmdline(script)
+	want := "quiet metal.server=https://s3/r spire_join_token=<redacted> bss_referral_token=<redacted> ds=nocloud-net;s=http://10.1.1.1:8888/"
+	if !ok || kernel != "https://s3/k" || cmdline != want {
+		t.Errorf("Got %v %q %q, expected %q", ok, kernel, cmdline, want)
+	}
+	if _, _, ok = scriptCmdline("#!ipxe\nsleep 10\nchain x\n"); ok {
+		t.Error("Expected no command line in a script without a kernel")
+	}
+}
+
+func TestCmdlineHistory(t *testing.T) {
+	const node = "x0c0s5b0n0"
+	savedMax := cmdlineHistoryMax
+	cmdlineHistoryMax = 2
+	defer func() {
+		cmdlineHistoryMax = savedMax
+		kvstore.Delete(cmdlineHistoryPfx + node)
+		delete(cmdlineHistoryTails.nodes, node)
+	}()
+	script := func(params string) string {
+		return "#!ipxe\nkernel --name kernel http://s3/k " + params + " || goto boot_retry\n"
+	}
+	recordCmdline(node, script("quiet"))
+	recordCmdline(node, script("quiet"))
+	recordCmdline(node, script("debug"))
+	get := func(query string) (int, bssTypes.CmdlineHistory) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, cmdlineHistoryEndpoint+node+query, nil)
+		r.Header.Set("Authorization", testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`))
+		cmdlineHistory(w, r)
+		var h bssTypes.CmdlineHistory
+		json.Unmarshal(w.Body.Bytes(), &h)
+		return w.Code, h
+	}
+	code, h := get("")
+	if code != http.StatusOK || len(h.Records) != 2 || h.Records[0].Cmdline != "quiet" ||
+		h.Records[0].Count != 2 || h.Records[1].Cmdline != "debug" {
+		t.Fatalf("GET returned %d: %+v", code, h)
+	}
+	first := h.Records[0].First
+	if _, h = get("?at=" + strconv.FormatInt(first, 10)); len(h.Records) != 1 || h.Records[0].Kernel != "http://s3/k" {
+		t.Errorf("GET at %d returned %+v", first, h)
+	}
+	if code, _ = get("?at=" + strconv.FormatInt(first-1, 10)); code != http.StatusNotFound {
+		t.Errorf("GET before the first boot returned %d", code)
+	}
+	recordCmdline(node, script("quiet"))
+	if _, h = get(""); len(h.Records) != 2 || h.Records[0].Cmdline != "debug" {
+		t.Errorf("Expected the oldest record to be dropped: %+v", h)
+	}
+	if code, _ = get("?at=never"); code != http.StatusBadRequest {
+		t.Errorf("GET with a bad time returned %d", code)
+	}
+}
+
+func TestCmdlineHistoryConcurrent(t *testing.T) {
+	nodes := []string{"x0c0s6b0n0", "x0c0s7b0n0"}
+	defer func() {
+		for _, n := range nodes {
+			kvstore.Delete(cmdlineHistoryPfx + n)
+			delete(cmdlineHistoryTails.nodes, n)
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, n := range nodes {
+			wg.Add(1)
+			go func(n string) {
+				defer wg.Done()
+				recordCmdline(n, "#!ipxe\nkernel http://s3/k quiet\n")
+			}(n)
+		}
+	}
+	wg.Wait()
+	for _, n := range nodes {
+		if recs, err := getCmdlineHistory(n); err != nil || len(recs) != 1 || recs[0].Count != 20 {
+			t.Errorf("History of %s is %+v, %v", n, recs, err)
+		}
+	}
+	cmdlineHistoryLocks.Lock()
+	if len(cmdlineHistoryLocks.nodes) != 0 {
+		t.Errorf("Locks left behind: %v", cmdlineHistoryLocks.nodes)
+	}
+	cmdlineHistoryLocks.Unlock()
+}
+
+func TestCmdlineHistoryBatched(t *testing.T) {
+	const node = "x0c0s5b0n0"
+	t.Setenv("BSS_DATASTORE_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	datastoreCryptInit()
+	defer func() {
+		sealKey, openKeys = nil, make(map[string]*datastoreKey)
+		kvstore.Delete(cmdlineHistoryPfx + node)
+		delete(cmdlineHistoryTails.nodes, node)
+	}()
+	script := "#!ipxe\nkernel http://s3/k quiet password=hunter2\n"
+	recordCmdline(node, script)
+	recordCmdline(node, script)
+	recordCmdline(node, script)
+	raw, _, _ := kvstore.Get(cmdlineHistoryPfx + node)
+	if !strings.HasPrefix(raw, sealedPrefix) || strings.Contains(raw, "hunter2") {
+		t.Errorf("Command line history is not sealed: %s", raw)
+	}
+	if recs, _ := readCmdlineHistory(node); len(recs) != 1 || recs[0].Count != 1 {
+		t.Errorf("Repeats should not be written yet: %+v", recs)
+	}
+	if recs, _ := getCmdlineHistory(node); len(recs) != 1 || recs[0].Count != 3 {
+		t.Errorf("Repeats should be counted: %+v", recs)
+	}
+	recordCmdline(node, "#!ipxe\nkernel http://s3/k debug\n")
+	if recs, _ := readCmdlineHistory(node); len(recs) != 2 || recs[0].Count != 3 || recs[1].Count != 1 {
+		t.Errorf("Repeats should be written with the next change: %+v", recs)
+	}
+}
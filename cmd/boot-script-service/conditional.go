@@ -0,0 +1,256 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Conditional kernel parameters.
+//
+// Kernel parameters may contain conditional blocks, so a single entry can
+// cover minor hardware variations:
+//
+//	{{if role=Compute,Application}}hugepagelist=2m-2g{{else}}quiet{{end}}
+//	{{ipxe iseq ${buildarch} arm64}}console=ttyAMA0{{else}}console=ttyS0{{end}}
+//
+// "if" blocks are evaluated while the boot script is built.  A condition is
+// key=values or key!=values, the values a comma separated list, over the
+// role, subrole, xname, nid and arch of the node and its labels
+// (label.<name>).  The arch is only known when the node passed arch=.
+//
+// "ipxe" blocks are decided by the node: the script sets a variable from
+// the iPXE test (iseq, isset, ...) and the kernel line refers to it.  "if"
+// blocks nest, "ipxe" blocks only contain plain text.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	condOpen  = "{{"
+	condClose = "}}"
+	condVar   = "bss-cond"
+)
+
+type condNode struct {
+	text  string // Plain text if kind is ""
+	kind  string // "if" or "ipxe"
+	test  string
+	then  []condNode
+	other []condNode
+}
+
+// Facts conditions are evaluated against.
+type condFacts map[string]string
+
+func nodeFacts(sp scriptParams, role, subRole string, labels map[string]string) condFacts {
+	facts := condFacts{"role": role, "subrole": subRole, "xname": sp.xname,
+		"nid": sp.nid, "arch": sp.arch}
+	for k, v := range labels {
+		facts["label."+k] = v
+	}
+	return facts
+}
+
+// Function scriptLabels() returns the labels of bd, with those of the
+// Global tag as defaults.
+func scriptLabels(bd BootData) map[string]string {
+	labels := make(map[string]string)
+	if g, err := LookupGlobalData(); err == nil {
+		for k, v := range g.Labels {
+			labels[k] = v
+		}
+	}
+	for k, v := range bd.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// Function parseConditionals() parses s up to the closing directive, which
+// is returned: "", "else" or "end".
+func parseConditionals(s string, depth int) ([]condNode, string, string, error) {
+	var nodes []condNode
+	for {
+		start := strings.Index(s, condOpen)
+		if start < 0 {
+			if depth > 0 {
+				return nil, "", "", fmt.Errorf("missing %send%s", condOpen, condClose)
+			}
+			if s != "" {
+				nodes = append(nodes, condNode{text: s})
+			}
+			return nodes, "", "", nil
+		}
+		if start > 0 {
+			nodes = append(nodes, condNode{text: s[:start]})
+		}
+		end := strings.Index(s[start:], condClose)
+		if end < 0 {
+			return nil, "", "", fmt.Errorf("unterminated %s", condOpen)
+		}
+		// The directive may end with an iPXE variable: ${net1/mac}}}
+		for start+end+len(condClose) < len(s) && s[start+end+len(condClose)] == '}' {
+			end++
+		}
+		directive := strings.TrimSpace(s[start+len(condOpen) : start+end])
+		s = s[start+end+len(condClose):]
+		kind, test, _ := strings.Cut(directive, " ")
+		switch kind {
+		case "else", "end":
+			if depth == 0 {
+				return nil, "", "", fmt.Errorf("%s%s%s without if", condOpen, kind, condClose)
+			}
+			return nodes, kind, s, nil
+		case "if", "ipxe":
+			test = strings.TrimSpace(test)
+			if test == "" {
+				return nil, "", "", fmt.Errorf("%s%s%s without a condition", condOpen, kind, condClose)
+			}
+			if kind == "if" {
+				if _, _, err := parseCondition(test); err != nil {
+					return nil, "", "", err
+				}
+			}
+			n := condNode{kind: kind, test: test}
+			var closing string
+			var err error
+			n.then, closing, s, err = parseConditionals(s, depth+1)
+			if err == nil && closing == "else" {
+				n.other, closing, s, err = parseConditionals(s, depth+1)
+				if err == nil && closing != "end" {
+					err = fmt.Errorf("%selse%s follows %selse%s", condOpen, condClose, condOpen, condClose)
+				}
+			}
+			if err != nil {
+				return nil, "", "", err
+			}
+			if kind == "ipxe" && (!plainText(n.then) || !plainText(n.other)) {
+				return nil, "", "", fmt.Errorf("%sipxe %s%s may only contain text", condOpen, test, condClose)
+			}
+			nodes = append(nodes, n)
+		default:
+			return nil, "", "", fmt.Errorf("unknown directive %s%s%s", condOpen, directive, condClose)
+		}
+	}
+}
+
+func plainText(nodes []condNode) bool {
+	for _, n := range nodes {
+		if n.kind != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseCondition(test string) (key string, values []string, err error) {
+	negate := false
+	key, value, ok := strings.Cut(test, "!=")
+	if ok {
+		negate = true
+	} else if key, value, ok = strings.Cut(test, "="); !ok {
+		return "", nil, fmt.Errorf("condition '%s' is not key=values or key!=values", test)
+	}
+	key = strings.TrimSpace(key)
+	if negate {
+		key = "!" + key
+	}
+	for _, v := range strings.Split(value, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return key, values, nil
+}
+
+func (facts condFacts) holds(test string) bool {
+	key, values, _ := parseCondition(test)
+	negate := strings.HasPrefix(key, "!")
+	have := facts[strings.TrimPrefix(key, "!")]
+	for _, v := range values {
+		if strings.EqualFold(v, have) {
+			return !negate
+		}
+	}
+	return negate
+}
+
+// Function checkConditionals() validates the conditional blocks of boot
+// parameters before they are stored.
+func checkConditionals(params string) error {
+	if _, _, _, err := parseConditionals(params, 0); err != nil {
+		return fmt.Errorf("Bad conditional in params: %s", err)
+	}
+	return nil
+}
+
+// Function renderConditionals() evaluates the if blocks in params and
+// replaces ipxe blocks with variables.  It returns the resulting params and
+// the iPXE commands setting those variables.
+func renderConditionals(params string, facts condFacts) (string, string, error) {
+	if !strings.Contains(params, condOpen) {
+		return params, "", nil
+	}
+	nodes, _, _, err := parseConditionals(params, 0)
+	if err != nil {
+		return params, "", err
+	}
+	var out, setup strings.Builder
+	n := 0
+	var render func([]condNode)
+	render = func(nodes []condNode) {
+		for _, c := range nodes {
+			switch c.kind {
+			case "":
+				out.WriteString(c.text)
+			case "if":
+				if facts.holds(c.test) {
+					render(c.then)
+				} else {
+					render(c.other)
+				}
+			case "ipxe":
+				v := fmt.Sprintf("%s%d", condVar, n)
+				n++
+				fmt.Fprintf(&setup, "%s && %s || %s\n", c.test,
+					ipxeSet(v, condText(c.then)), ipxeSet(v, condText(c.other)))
+				out.WriteString("${" + v + "}")
+			}
+		}
+	}
+	render(nodes)
+	return strings.Join(strings.Fields(out.String()), " "), setup.String(), nil
+}
+
+func condText(nodes []condNode) string {
+	var s string
+	for _, n := range nodes {
+		s += n.text
+	}
+	return strings.TrimSpace(s)
+}
+
+func ipxeSet(name, value string) string {
+	if value == "" {
+		return "clear " + name
+	}
+	return "set " + name + " " + value
+}
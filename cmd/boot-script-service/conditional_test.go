@@ -0,0 +1,86 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestRenderConditionals(t *testing.T) {
+	facts := condFacts{"role": "Compute", "arch": "", "label.gpu": "a100"}
+	tests := []struct {
+		params, want, setup string
+	}{
+		{"quiet", "quiet", ""},
+		{"a {{if role=Compute,Application}}hp=2m{{else}}hp=0{{end}} b", "a hp=2m b", ""},
+		{"a {{ if role!=Compute }}x{{ end }} b", "a b", ""},
+		{"{{if label.gpu=A100}}nvidia{{if arch=arm64}}.arm{{end}}{{end}}", "nvidia", ""},
+		{"{{if label.none=}}unset{{end}}", "unset", ""},
+		{"a {{ipxe iseq ${buildarch} arm64}}console=ttyAMA0{{else}}console=ttyS0{{end}} {{ipxe isset ${net1/mac}}}bond{{end}}",
+			"a ${bss-cond0} ${bss-cond1}",
+			"iseq ${buildarch} arm64 && set bss-cond0 console=ttyAMA0 || set bss-cond0 console=ttyS0\n" +
+				"isset ${net1/mac} && set bss-cond1 bond || clear bss-cond1\n"},
+	}
+	for _, tc := range tests {
+		params, setup, err := renderConditionals(tc.params, facts)
+		if err != nil || params != tc.want || setup != tc.setup {
+			t.Errorf("renderConditionals(%q) = %q, %q, %v; want %q, %q", tc.params, params, setup, err, tc.want, tc.setup)
+		}
+	}
+
+	for _, bad := range []string{
+		"{{if role=Compute}}x",
+		"x{{end}}",
+		"{{if role}}x{{end}}",
+		"{{if role=A}}x{{else}}y{{else}}z{{end}}",
+		"{{ipxe iseq a b}}{{if role=A}}x{{end}}{{end}}",
+		"{{unless role=A}}x{{end}}",
+		"{{if role=A",
+	} {
+		if err := checkConditionals(bad); err == nil {
+			t.Errorf("checkConditionals(%q) accepted", bad)
+		}
+	}
+}
+
+func TestConditionalBootScript(t *testing.T) {
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{"x0c0s9b0n0"}, Kernel: "http://s3/kernel",
+		Params: "{{if role=Compute}}hp=2m"}, ""); err == nil {
+		kvstore.Delete(paramsPfx + "x0c0s9b0n0")
+		t.Errorf("Store accepted an unbalanced conditional")
+	}
+
+	bd := BootData{Kernel: ImageData{Path: "http://s3/kernel"}, Labels: map[string]string{"gpu": "a100"},
+		Params: "quiet {{if label.gpu=a100}}nvidia-drm.modeset=1{{end}} {{ipxe iseq ${buildarch} arm64}}console=ttyAMA0{{end}}"}
+	script, err := buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	setup := "iseq ${buildarch} arm64 && set bss-cond0 console=ttyAMA0 || clear bss-cond0\nkernel --name kernel "
+	if !strings.Contains(script, setup) || !strings.Contains(script, " quiet nvidia-drm.modeset=1 ${bss-cond0} ") {
+		t.Errorf("Unexpected boot script:\n%s", script)
+	}
+}
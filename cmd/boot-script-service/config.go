@@ -0,0 +1,339 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Service settings.
+//
+// Every command line flag and environment variable BSS reads is listed in
+// the settings table below along with the variable it sets.  The
+// environment gives a setting its default and the flag overrides it.
+// GET /boot/v1/service/config reports defaults and current values, with
+// secrets redacted, and --config-doc prints the table for the docs.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+// A setting: its flag, the environment variable for it and the variable
+// it is kept in.  Settings without a flag are only taken from the
+// environment, and those without a variable are read where they are used.
+type setting struct {
+	flag   string
+	env    string
+	v      interface{}
+	usage  string
+	secret bool
+	def    string
+	// An empty value in the environment is used rather than ignored.
+	emptyOK bool
+}
+
+var configDocMode = false
+
+var settings = []*setting{
+	{flag: "http-listen", env: "BSS_HTTP_LISTEN", v: &httpListen, usage: "HTTP server IP + port binding"},
+	{flag: "hsm", env: "HSM_URL", v: &hsmBase, usage: "Hardware State Manager location as URI, e.g. [scheme]://[host[:port]]"},
+	{flag: "hsm-test-source", env: "BSS_HSM_TEST_SOURCE", v: &hsmTestSource, usage: "HSM fixture, mem: or file:<path>, that MAC lookups of HSM test nodes use; empty disables test nodes"},
+	{flag: "nfd", env: "NFD_URL", v: &nfdBase, usage: "Notification daemon location as URI, e.g. [scheme]://[host[:port]]"},
+	{flag: "datastore", env: "DATASTORE_BASE", v: &datastoreBase, usage: "Datastore Service location as URI, etcd at ETCD_HOST:ETCD_PORT if they are set"},
+	{flag: "service-name", v: &serviceName, usage: "Boot script service name"},
+	{flag: "spire-url", env: "SPIRE_TOKEN_URL", v: &spireTokensBaseURL, usage: "Spire join token service base URL"},
+	{flag: "cloud-init-address", env: "BSS_ADVERTISE_ADDRESS", v: &advertiseAddress, usage: "IP:PORT to advertise for cloud-init calls. This needs to be an IP as we do not have DNS when cloud-init runs"},
+	{flag: "insecure", env: "BSS_INSECURE", v: &insecure, usage: "Don't enforce https certificate security"},
+	{flag: "debug", env: "BSS_DEBUG", v: &debugFlag, usage: "Enable debug output"},
+	{flag: "retry-delay", env: "BSS_RETRY_DELAY", v: &retryDelay, usage: "Retry delay in seconds"},
+	{flag: "hsm-fetch-timeout", env: "BSS_HSM_FETCH_TIMEOUT", v: &hsmFetchTimeout, usage: "Seconds each HSM state request may take, 0 for no limit"},
+	{flag: "hsm-full-sync-interval", env: "BSS_HSM_FULL_SYNC_INTERVAL", v: &hsmFullSyncInterval, usage: "Seconds between full fetches of the HSM ethernet interfaces, refreshes in between only fetch changes (0 always fetches all)"},
+	{flag: "hsm-ready-wait", env: "BSS_HSM_READY_WAIT", v: &hsmReadyWait, usage: "Seconds to stay unready while waiting for the first HSM state, 0 is ready right away"},
+	{flag: "hsm-retrieval-delay", env: "BSS_RETRIEVAL_DELAY", v: &hsmRetrievalDelay, usage: "SM Retrieval delay in seconds"},
+	{flag: "artifact-proxy", env: "BSS_ARTIFACT_PROXY", v: &artifactProxy, usage: "Serve kernel and initrd images through BSS"},
+	{flag: "artifact-dir", env: "BSS_ARTIFACT_DIR", v: &artifactDir, usage: "Local directory for artifact proxy images given as plain paths"},
+	{flag: "artifact-cache-dir", env: "BSS_ARTIFACT_CACHE_DIR", v: &artifactCacheDir, usage: "Artifact proxy download cache directory"},
+	{flag: "artifact-cache-size", env: "BSS_ARTIFACT_CACHE_SIZE", v: &artifactCacheSizeMB, usage: "Artifact proxy download cache size in MiB"},
+	{flag: "boot-deps", env: "BSS_BOOT_DEPS", v: &bootDepsEnabled, usage: "Make boot scripts wait for boot-after dependencies"},
+	{flag: "boot-deps-wait", env: "BSS_BOOT_DEPS_WAIT", v: &bootDepsWait, usage: "Boot dependency poll interval in seconds"},
+	{flag: "pcs", env: "PCS_URL", v: &pcsBase, usage: "Power Control Service location as URI, enables boot verification"},
+	{flag: "boot-verify-timeout", env: "BSS_BOOT_VERIFY_TIMEOUT", v: &bootVerifyTimeout, usage: "Boot verification timeout in seconds"},
+	{flag: "boot-verify-interval", env: "BSS_BOOT_VERIFY_INTERVAL", v: &bootVerifyInterval, usage: "Boot verification poll interval in seconds"},
+	{flag: "console", env: "CONSOLE_URL", v: &consoleBase, usage: "Console log URL template with {xname} and {lines}, captures the console of failed boots"},
+	{flag: "console-lines", env: "BSS_CONSOLE_LINES", v: &consoleLines, usage: "Console lines kept with a failed boot verification"},
+	{flag: "spoof-protect", env: "BSS_SPOOF_PROTECT", v: &spoofProtect, usage: "Serve default cloud-init data to IPs that failed identity checks"},
+	{flag: "security-events-max", env: "BSS_SECURITY_EVENTS_MAX", v: &securityEventsMax, usage: "Number of security events to keep"},
+	{flag: "unknown-ip-ttl", env: "BSS_UNKNOWN_IP_TTL", v: &unknownIPTTL, usage: "Seconds before an unknown IP can force another HSM refresh"},
+	{flag: "hsm-refresh-interval", env: "BSS_HSM_REFRESH_INTERVAL", v: &forcedRefreshInterval, usage: "Minimum seconds between HSM refreshes forced by unknown IPs"},
+	{flag: "boot-group-sync-interval", env: "BSS_BOOT_GROUP_SYNC_INTERVAL", v: &bootGroupSyncInterval, usage: "Seconds between HSM-managed boot group syncs, 0 disables"},
+	{flag: "changes-max", env: "BSS_CHANGES_MAX", v: &changesMax, usage: "Number of boot parameter changes to keep for /changes"},
+	{flag: "snapshot-dir", env: "BSS_SNAPSHOT_DIR", v: &snapshotDir, usage: "Directory for periodic boot parameter snapshots, empty disables them"},
+	{flag: "snapshot-interval", env: "BSS_SNAPSHOT_INTERVAL", v: &snapshotInterval, usage: "Seconds between boot parameter snapshots"},
+	{flag: "snapshot-keep", env: "BSS_SNAPSHOT_KEEP", v: &snapshotKeep, usage: "Number of boot parameter snapshots to keep"},
+	{flag: "snapshot-restore", env: "BSS_SNAPSHOT_RESTORE", v: &snapshotRestore, usage: "Seed an empty datastore from the newest snapshot at startup"},
+	{flag: "etcd-get-timeout", env: "BSS_ETCD_GET_TIMEOUT", v: &etcdGetTimeout, usage: "Datastore read deadline in milliseconds"},
+	{flag: "etcd-put-timeout", env: "BSS_ETCD_PUT_TIMEOUT", v: &etcdPutTimeout, usage: "Datastore write deadline in milliseconds"},
+	{flag: "etcd-delete-timeout", env: "BSS_ETCD_DELETE_TIMEOUT", v: &etcdDeleteTimeout, usage: "Datastore delete deadline in milliseconds"},
+	{flag: "etcd-lock-timeout", env: "BSS_ETCD_LOCK_TIMEOUT", v: &etcdLockTimeout, usage: "Datastore distributed lock deadline in milliseconds (at least 1000)"},
+	{flag: "etcd-max-in-flight", env: "BSS_ETCD_MAX_IN_FLIGHT", v: &etcdMaxInFlight, usage: "Maximum number of datastore operations in flight"},
+	{flag: "bulk-store-workers", env: "BSS_BULK_STORE_WORKERS", v: &bulkStoreWorkers, usage: "Parallel datastore writes when boot parameters name many hosts"},
+	{flag: "image-digests", env: "BSS_IMAGE_DIGESTS", v: &imageDigests, usage: "Record image ETags and re-check image digests when serving boot scripts"},
+	{flag: "image-digest-interval", env: "BSS_IMAGE_DIGEST_INTERVAL", v: &imageDigestInterval, usage: "Minimum seconds between digest checks of an image"},
+	{flag: "approved-images-strict", env: "BSS_APPROVED_IMAGES_STRICT", v: &approvedImagesStrict, usage: "Refuse boot scripts with images or other boot files not in /boot/v1/approved-images"},
+	{flag: "pin-digests", env: "BSS_PIN_DIGESTS", v: &pinDigests, usage: "Pass known image sha256 digests to nodes in boot scripts"},
+	{flag: "imgverify-suffix", env: "BSS_IMGVERIFY_SUFFIX", v: &imgverifySuffix, usage: "Suffix of detached image signatures to check with imgverify when pinning digests"},
+	{flag: "debug-modules", env: "BSS_DEBUG_MODULES", v: &debugModules, usage: "Comma separated modules to debug (hsm, datastore, cloudinit)"},
+	{flag: "jwks-url", env: "BSS_JWKS_URL", v: &jwksURL, usage: "JWKS, e.g. of Keycloak, bearer token signatures are verified against (default none, the API gateway verifies them)"},
+	{flag: "authz-webhook", env: "BSS_AUTHZ_WEBHOOK", v: &authzWebhookURL, usage: "Policy engine URL, e.g. OPA's /v1/data/bss/allow, that decides on API changes (default none)"},
+	{flag: "authz-webhook-fail-open", env: "BSS_AUTHZ_WEBHOOK_FAIL_OPEN", v: &authzWebhookFailOpen, usage: "Allow API changes when the authorization webhook is unavailable"},
+	{flag: "authz-webhook-cache", env: "BSS_AUTHZ_WEBHOOK_CACHE", v: &authzWebhookCache, usage: "Seconds authorization webhook decisions are cached, 0 disables caching"},
+	{flag: "authz-webhook-timeout", env: "BSS_AUTHZ_WEBHOOK_TIMEOUT", v: &authzWebhookTimeout, usage: "Seconds to wait for the authorization webhook"},
+	{flag: "audit-export", env: "BSS_AUDIT_EXPORT", v: &auditExportURL, usage: "Audit event receiver: udp://, tcp:// or tls:// syslog, or a Splunk HEC https:// URL (default none)"},
+	{flag: "audit-export-token", env: "BSS_AUDIT_EXPORT_TOKEN", v: &auditExportToken, secret: true, usage: "Splunk HEC token for the audit export"},
+	{flag: "audit-export-ca", env: "BSS_AUDIT_EXPORT_CA", v: &auditExportCA, usage: "PEM file with the CA certificates of the audit receiver"},
+	{flag: "audit-export-secret", env: "BSS_AUDIT_EXPORT_SECRET", v: &auditExportSecret, secret: true, usage: "Sign exported audit events with an HMAC under this secret"},
+	{flag: "audit-log-retention", env: "BSS_AUDIT_LOG_RETENTION", v: &auditLogRetention, usage: "Days boot parameter changes are kept in /boot/v1/auditlog, 0 keeps them forever"},
+	{flag: "access-log", env: "BSS_ACCESS_LOG", v: &accessLog, usage: "Access log destination: stdout or a file (default none)"},
+	{flag: "access-log-format", env: "BSS_ACCESS_LOG_FORMAT", v: &accessLogFormat, usage: "Access log format: common or json"},
+	{flag: "access-log-sample", env: "BSS_ACCESS_LOG_SAMPLE", v: &accessLogSample, usage: "Log one in this many boot script and cloud-init requests"},
+	{flag: "access-log-max-size", env: "BSS_ACCESS_LOG_MAX_SIZE", v: &accessLogMaxSize, usage: "Rotate the access log file at this many megabytes"},
+	{flag: "access-log-keep", env: "BSS_ACCESS_LOG_KEEP", v: &accessLogKeep, usage: "Number of rotated access log files to keep"},
+	{flag: "support-log-lines", env: "BSS_SUPPORT_LOG_LINES", v: &supportLogLines, usage: "Number of recent log lines kept for support bundles"},
+	{flag: "support-failed-requests", env: "BSS_SUPPORT_FAILED_REQUESTS", v: &supportFailedRequests, usage: "Number of recent failed requests kept for support bundles"},
+	{flag: "backfill-interval", env: "BSS_BACKFILL_INTERVAL", v: &backfillInterval, usage: "Seconds between moves of MAC and NID keyed boot parameters to xnames, 0 disables"},
+	{flag: "cmdline-history-max", env: "BSS_CMDLINE_HISTORY_MAX", v: &cmdlineHistoryMax, usage: "Kernel command lines kept per node for /boot/v1/history/cmdline, 0 disables the history"},
+	{flag: "history-tenant-roles", env: "BSS_HISTORY_TENANT_ROLES", v: &historyTenantRoles, usage: "Comma separated role=group pairs: the endpoint history callers with the token role see is limited to the nodes of the group"},
+	{flag: "history-readonly-roles", env: "BSS_HISTORY_READONLY_ROLES", v: &historyReadonlyRoles, usage: "Comma separated token roles that get endpoint history times rounded to --history-coarse-seconds"},
+	{flag: "history-coarse-seconds", env: "BSS_HISTORY_COARSE_SECONDS", v: &historyCoarseSeconds, usage: "Rounding of the endpoint history times read-only and unmapped roles see"},
+	{flag: "analytics-interval", env: "BSS_ANALYTICS_INTERVAL", v: &analyticsInterval, usage: "Seconds between writes of the daily endpoint access counts, 0 disables them"},
+	{flag: "analytics-retention", env: "BSS_ANALYTICS_RETENTION", v: &analyticsRetention, usage: "Days daily endpoint access counts are kept"},
+	{flag: "reconcile-interval", env: "BSS_RECONCILE_INTERVAL", v: &reconcileInterval, usage: "Seconds between checks for boot parameters of nodes HSM no longer has, 0 disables"},
+	{flag: "reconcile-archive", env: "BSS_RECONCILE_ARCHIVE", v: &reconcileArchive, usage: "Archive boot parameters of nodes missing from HSM"},
+	{flag: "reconcile-archive-after", env: "BSS_RECONCILE_ARCHIVE_AFTER", v: &reconcileArchiveAfter, usage: "Seconds a node must be missing from HSM before its boot parameters are archived"},
+	{flag: "export-ipxe-binary", env: "BSS_EXPORT_IPXE_BINARY", v: &exportIPXEBinary, usage: "iPXE binary PXE firmware is sent to in exported DHCP configuration"},
+	{flag: "export-domain", env: "BSS_EXPORT_DOMAIN", v: &exportDomain, usage: "Domain of the exported DNS zone of the nodes"},
+	{flag: "release-rootfs-param", env: "BSS_RELEASE_ROOTFS_PARAM", v: &releaseRootfsParam, usage: "Kernel parameter set to the rootfs URL of a release"},
+	{flag: "datastore-migrate-to", env: "BSS_DATASTORE_MIGRATE_TO", v: &datastoreMigrateTo, usage: "Datastore being migrated to: written along with the datastore and compared on reads"},
+	{flag: "datastore-migrate-copy", env: "BSS_DATASTORE_MIGRATE_COPY", v: &datastoreMigrateCopy, usage: "Copy the datastore contents to the migration target at startup"},
+	{flag: "registry", env: "BSS_REGISTRY", v: &registryURL, usage: "Consul agent URL, e.g. http://localhost:8500, to register this instance with (default none)"},
+	{flag: "registry-service", env: "BSS_REGISTRY_SERVICE", v: &registryService, usage: "Service name this instance registers under"},
+	{flag: "registry-address", env: "BSS_REGISTRY_ADDRESS", v: &registryAddress, usage: "host[:port] to register, the cloud-init address if empty"},
+	{flag: "registry-token", env: "CONSUL_HTTP_TOKEN", v: &registryToken, secret: true, usage: "ACL token for the service registry"},
+	{flag: "registry-check-interval", env: "BSS_REGISTRY_CHECK_INTERVAL", v: &registryCheckInterval, usage: "Seconds between registry health checks of the readiness endpoint"},
+	{flag: "registry-drop-after", env: "BSS_REGISTRY_DROP_AFTER", v: &registryDropAfter, usage: "Seconds a failing instance stays registered"},
+	{flag: "namespace", env: "BSS_NAMESPACE", v: &datastoreNamespace, usage: "Datastore namespace of this instance, so that several can share an etcd cluster"},
+	{flag: "datastore-replica", env: "BSS_DATASTORE_REPLICA", v: &datastoreReplica, usage: "Read-only datastore serving the boot parameter and boot script reads"},
+	{flag: "datastore-replica-lag", env: "BSS_DATASTORE_REPLICA_LAG", v: &datastoreReplicaLag, usage: "Milliseconds after a change of the boot parameters during which they are read from the datastore, not the replica"},
+	{flag: "bootscript-concurrency", env: "BSS_BOOTSCRIPT_CONCURRENCY", v: &bootscriptConcurrency, usage: "Boot script requests served at once, the others queue fairly per node (0 for no limit)"},
+	{flag: "bootscript-queue-depth", env: "BSS_BOOTSCRIPT_QUEUE_DEPTH", v: &bootscriptQueueDepth, usage: "Boot script requests one node can have waiting"},
+	{flag: "bootscript-queue-max", env: "BSS_BOOTSCRIPT_QUEUE_MAX", v: &bootscriptQueueMax, usage: "Boot script requests all nodes together can have waiting"},
+	{flag: "bootscript-cache-max-age", env: "BSS_BOOTSCRIPT_CACHE_MAX_AGE", v: &bootscriptCacheMaxAge, usage: "Seconds a caching proxy may serve a boot script without asking BSS (0 to always revalidate, at most 43200 so presigned URLs stay valid)"},
+	{flag: "bootscript-deterministic", env: "BSS_BOOTSCRIPT_DETERMINISTIC", v: &bootscriptDeterministic, usage: "Leave the retry count and timestamp out of boot scripts so they can be cached"},
+	{flag: "bootgroup-boot-seconds", env: "BSS_BOOTGROUP_BOOT_SECONDS", v: &bootGroupBootSeconds, usage: "Seconds a node that got its boot script counts against the max-booting limit of its boot group"},
+	{flag: "bootgroup-retry-delay", env: "BSS_BOOTGROUP_RETRY_DELAY", v: &bootGroupRetryDelay, usage: "Seconds nodes of a boot group at its max-booting limit wait before asking again"},
+	{flag: "watch-poll-interval", env: "BSS_WATCH_POLL_INTERVAL", v: &watchPollInterval, usage: "Seconds between re-checks of watched boot configurations"},
+	{flag: "watch-max-timeout", env: "BSS_WATCH_MAX_TIMEOUT", v: &watchMaxTimeout, usage: "Longest a boot configuration watch is held, in seconds"},
+	{flag: "protected", env: "BSS_PROTECTED", v: &protectedNames, usage: "Comma separated boot parameters entries that need the override header to be replaced or deleted"},
+	{flag: "override-max-ttl", env: "BSS_OVERRIDE_MAX_TTL", v: &overrideMaxTTL, usage: "Longest a temporary boot override may last, in seconds"},
+	{flag: "rescue-images", env: "BSS_RESCUE_IMAGES", v: &rescueImages, usage: "Location of the images of the builtin rescue targets"},
+	{flag: "approval-tags", env: "BSS_APPROVAL_TAGS", v: &approvalTags, usage: "Comma separated tags, e.g. Default,Global, whose changes are proposed and need approval by a second identity"},
+	{flag: "fallback-limit", env: "BSS_FALLBACK_LIMIT", v: &fallbackLimit, usage: "Default tag boots after which a node without boot parameters of its own is flagged, 0 disables fallback tracking"},
+	{flag: "fallback-tag", env: "BSS_FALLBACK_TAG", v: &fallbackTag, usage: "Boot parameters tag served instead of Default to nodes without boot parameters of their own while fallback tracking is on"},
+	{flag: "node-token-ttl", env: "BSS_NODE_TOKEN_TTL", v: &nodeTokenTTL, usage: "Seconds a node token is valid"},
+	{flag: "node-token-required", env: "BSS_NODE_TOKEN_REQUIRED", v: &nodeTokenRequired, usage: "Refuse phone home requests without a node token"},
+	{env: "BSS_NODE_TOKEN_KEY", v: &nodeTokenKey, secret: true, usage: "Key node tokens are signed with (default a generated key kept in the datastore, sealed with BSS_DATASTORE_KEY)"},
+	{flag: "validate-payloads", env: "BSS_VALIDATE_PAYLOADS", v: &validatePayloads, usage: "Reject boot parameter requests that do not match the published JSON Schema"},
+	{flag: "config-doc", v: &configDocMode, usage: "Print the settings as an AsciiDoc table and exit"},
+	{flag: "validate-config", v: &validateConfigMode, usage: "Validate the configuration, print a report and exit"},
+
+	// Read where they are used, listed for the inventory.
+	{env: "BSS_ADMIN_ROLES", v: &adminRoles, usage: "Comma separated token roles allowed to use the admin endpoints"},
+	{env: "BSS_ENDPOINT_HOST", usage: "Host (and port) HSM sends state change notifications to"},
+	{env: "BSS_IPXE_SERVER", v: &ipxeServer, usage: "Host name nodes chain load boot scripts and images from", emptyOK: true},
+	{env: "BSS_CHAIN_PROTO", v: &chainProto, usage: "Protocol nodes chain load with: http or https", emptyOK: true},
+	{env: "BSS_GW_URI", v: &gwURI, usage: "Path prefix of BSS behind the API gateway", emptyOK: true},
+	{env: "BSS_HANDOFF_SEEDER", v: &handoffSeeder, usage: "Peer seeder told about handoff artifacts"},
+	{env: "BSS_HANDOFF_CHUNK_SIZE", v: &handoffChunkMB, usage: "Handoff range hint size in MiB"},
+	{env: "BSS_S3_SIGNER", v: &s3SignerMode, usage: "S3 URL signer: aws or mock"},
+	{env: "BSS_S3_MOCK_URL", v: &s3MockBaseURL, usage: "Base URL S3 URLs are rewritten to by the mock signer"},
+	{env: "BSS_S3_MOCK_SECRET", v: &s3MockSecret, secret: true, usage: "Key the mock signer signs URLs with"},
+	{env: "BSS_DATASTORE_KEY", secret: true, usage: "Base64 AES key boot parameters are encrypted with in the datastore"},
+	{env: "BSS_DATASTORE_KEY_FILE", usage: "File holding BSS_DATASTORE_KEY"},
+	{env: "BSS_DATASTORE_OLD_KEYS", secret: true, usage: "Comma separated keys still accepted for decryption"},
+	{env: "ETCD_HOST", usage: "etcd host, used for the default --datastore"},
+	{env: "ETCD_PORT", usage: "etcd port, used for the default --datastore"},
+	{env: "ETCD_RETRY_COUNT", usage: "Datastore connection attempts at startup"},
+	{env: "ETCD_RETRY_WAIT", usage: "Seconds between datastore connection attempts"},
+	{env: "HMS_CUSTOM_HDRS", secret: true, usage: "Newline separated \"Name: value\" headers added to notification requests"},
+}
+
+func settingType(v interface{}) string {
+	switch v.(type) {
+	case *uint:
+		return "uint"
+	case *int:
+		return "int"
+	case *bool:
+		return "bool"
+	case *[]string:
+		return "list"
+	}
+	return "string"
+}
+
+func (s *setting) value() string {
+	switch vp := s.v.(type) {
+	case nil:
+		return os.Getenv(s.env)
+	case *string:
+		return *vp
+	case *uint:
+		return strconv.FormatUint(uint64(*vp), 10)
+	case *int:
+		return strconv.Itoa(*vp)
+	case *bool:
+		return strconv.FormatBool(*vp)
+	case *[]string:
+		return strings.Join(*vp, ",")
+	}
+	return ""
+}
+
+// Function redact() hides the value of a secret setting, and that of a
+// string setting whose name suggests it is one, should it not be flagged.
+func (s *setting) redact(value string) string {
+	if s.secret && value != "" {
+		return "REDACTED"
+	}
+	if settingType(s.v) != "string" {
+		return value
+	}
+	name := s.flag
+	if name == "" {
+		name = s.env
+	}
+	return redactSetting(name, value)
+}
+
+// Function registerSettings() records the defaults of the settings,
+// applies their environment variables and defines their flags in fs.
+func registerSettings(fs *flag.FlagSet, list []*setting) {
+	for _, s := range list {
+		if s.v == nil {
+			continue
+		}
+		s.def = s.value()
+		if vp, ok := s.v.(*string); ok && s.emptyOK {
+			*vp = getEnvVal(s.env, *vp)
+		} else if s.env != "" {
+			if err := parseEnv(s.env, s.v); err != nil {
+				log.Printf("WARNING: ignoring %s: %s", s.env, err)
+			}
+		}
+		if s.flag == "" {
+			continue
+		}
+		switch vp := s.v.(type) {
+		case *string:
+			fs.StringVar(vp, s.flag, *vp, s.usage)
+		case *uint:
+			fs.UintVar(vp, s.flag, *vp, s.usage)
+		case *int:
+			fs.IntVar(vp, s.flag, *vp, s.usage)
+		case *bool:
+			fs.BoolVar(vp, s.flag, *vp, s.usage)
+		case *[]string:
+			fs.Func(s.flag, s.usage, func(v string) error {
+				*vp = strings.Split(v, ",")
+				return nil
+			})
+		}
+	}
+}
+
+func settingsInit() {
+	// Without DATASTORE_BASE or --datastore BSS uses etcd at
+	// ETCD_HOST:ETCD_PORT, or an in-memory store if they are not set.
+	datastoreBase = kvDefaultURL()
+	registerSettings(flag.CommandLine, settings)
+}
+
+func configInventory(list []*setting) []bssTypes.ConfigSetting {
+	ret := make([]bssTypes.ConfigSetting, 0, len(list))
+	for _, s := range list {
+		ret = append(ret, bssTypes.ConfigSetting{
+			Flag:    s.flag,
+			Env:     s.env,
+			Type:    settingType(s.v),
+			Default: s.redact(s.def),
+			Value:   s.redact(s.value()),
+			Usage:   s.usage,
+		})
+	}
+	return ret
+}
+
+// Function configDoc() writes the settings as an AsciiDoc section; it is
+// what docs/configuration.adoc is generated with.
+func configDoc(w io.Writer, list []*setting) {
+	fmt.Fprintf(w, "== Configuration\n")
+	fmt.Fprintf(w, "Settings are taken from the environment and can be overridden on the\n")
+	fmt.Fprintf(w, "command line.  Settings without a flag can only be set in the environment.\n")
+	fmt.Fprintf(w, "This section is generated with `boot-script-service --config-doc`.\n\n")
+	fmt.Fprintf(w, "[options=\"header\",cols=\"2,2,1,2,5\"]\n|===\n")
+	fmt.Fprintf(w, "|Flag |Environment |Type |Default |Description\n")
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + s + "`"
+	}
+	for _, s := range list {
+		f := s.flag
+		if f != "" {
+			f = "--" + f
+		}
+		fmt.Fprintf(w, "|%s |%s |%s |%s |%s\n", code(f), code(s.env), settingType(s.v),
+			code(s.redact(s.def)), strings.ReplaceAll(s.usage, "|", "\\|"))
+	}
+	fmt.Fprintf(w, "|===\n")
+}
+
+func ServiceConfigGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ServiceConfigGet(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(configInventory(settings)); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
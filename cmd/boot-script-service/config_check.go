@@ -0,0 +1,296 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Startup configuration validation.
+//
+// The configuration is checked once at startup and the results are collected
+// in a single report.  Checks that would make BSS useless, such as a malformed
+// datastore URL, are fatal.  Others, such as HSM being unreachable, are only
+// warnings at startup since the condition may clear up later.  With
+// --validate-config, BSS prints the report as JSON and exits, nonzero if any
+// check failed, without starting the service.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	hms_s3 "github.com/Cray-HPE/hms-s3"
+)
+
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+var validateConfigMode = false
+
+type configCheck struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	Fatal  bool   `json:"fatal,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type configReport struct {
+	Result string        `json:"result"`
+	Checks []configCheck `json:"checks"`
+}
+
+func (r *configReport) add(name string, fatal bool, err error) {
+	c := configCheck{Name: name, Result: checkOK}
+	if err != nil {
+		c.Result, c.Fatal, c.Detail = checkFail, fatal, err.Error()
+		r.Result = checkFail
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+func (r *configReport) skip(name, why string) {
+	r.Checks = append(r.Checks, configCheck{Name: name, Result: checkSkip, Detail: why})
+}
+
+func (r *configReport) fatal() bool {
+	for _, c := range r.Checks {
+		if c.Fatal {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *configReport) String() string {
+	var sb strings.Builder
+	for _, c := range r.Checks {
+		fmt.Fprintf(&sb, "  %-20s %s", c.Name, c.Result)
+		if c.Detail != "" {
+			fmt.Fprintf(&sb, ": %s", c.Detail)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func checkServiceURL(u string, schemes ...string) (*url.URL, error) {
+	p, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schemes {
+		if p.Scheme == s {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported URL scheme in '%s', expected one of %s", u, strings.Join(schemes, ", "))
+}
+
+// The etcd client also accepts plain host:port endpoints.
+func checkDatastoreURL(u string) error {
+	if strings.HasPrefix(u, "mem:") {
+		return nil
+	}
+	if strings.Contains(u, "://") {
+		_, err := checkServiceURL(u, "http", "https", "unix", "unixs")
+		return err
+	}
+	if _, _, err := net.SplitHostPort(u); err != nil {
+		return fmt.Errorf("invalid datastore endpoint '%s': %s", u, err)
+	}
+	return nil
+}
+
+// The advertised address ends up in the cloud-init data source URL, where an
+// IPv6 literal has to be in brackets.
+func checkAdvertiseAddress(a string) error {
+	host := a
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	if strings.Count(host, ":") > 1 && !strings.HasPrefix(host, "[") {
+		return fmt.Errorf("IPv6 address in '%s' must be in brackets", a)
+	}
+	return nil
+}
+
+func checkReachable(u string) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	client := &http.Client{Timeout: 5 * time.Second}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", u, rsp.Status)
+	}
+	return nil
+}
+
+// Function validateConfig() runs all configuration checks.  Checks that need
+// other services are only run if reachability is set.
+func validateConfig(reachability bool) configReport {
+	report := configReport{Result: checkOK}
+
+	var err error
+	if advertiseAddress == "" {
+		err = fmt.Errorf("--cloud-init-address or BSS_ADVERTISE_ADDRESS required")
+	} else {
+		err = checkAdvertiseAddress(advertiseAddress)
+	}
+	report.add("advertise-address", true, err)
+
+	report.add("s3-signer", true, validateS3SignerMode(s3SignerMode))
+	if s3SignerMode == s3SignerMock {
+		report.skip("s3-credentials", "mock S3 signer")
+	} else {
+		_, err = hms_s3.LoadConnectionInfoFromEnvVars()
+		report.add("s3-credentials", false, err)
+	}
+
+	report.add("datastore-url", true, checkDatastoreURL(datastoreBase))
+	report.add("datastore-timeouts", true, checkDatastoreTimeouts())
+
+	hsm, err := checkServiceURL(hsmBase, "mem", "file", "http", "https")
+	report.add("hsm-url", true, err)
+	switch {
+	case err != nil:
+	case hsm.Scheme == "mem" || hsm.Scheme == "file":
+		report.skip("hsm-reachable", "HSM data is local")
+	case !reachability:
+		report.skip("hsm-reachable", "not checked at startup")
+	default:
+		report.add("hsm-reachable", false, checkReachable(hsmBase+"/hsm/v2/service/ready"))
+	}
+
+	if hsmTestSource != "" {
+		_, err = readHSMFixture(hsmTestSource)
+		report.add("hsm-test-source", false, err)
+	}
+
+	if pcsBase != "" {
+		_, err = checkServiceURL(pcsBase, "http", "https")
+		report.add("pcs-url", false, err)
+	}
+	if consoleBase != "" {
+		_, err = checkServiceURL(consoleURL("x0"), "http", "https")
+		if err == nil && pcsBase == "" {
+			err = fmt.Errorf("console capture needs boot verification, set a PCS URL")
+		}
+		report.add("console-url", false, err)
+	}
+	if authzWebhookURL != "" {
+		_, err = checkServiceURL(authzWebhookURL, "http", "https")
+		report.add("authz-webhook", true, err)
+	}
+	if registryURL != "" {
+		if _, err = checkServiceURL(registryURL, "http", "https"); err == nil {
+			_, _, err = registryHostPort()
+		}
+		if err == nil && registryCheckInterval == 0 {
+			err = fmt.Errorf("registry health checks need an interval of at least one second")
+		}
+		report.add("registry", false, err)
+	}
+	if auditExportURL != "" {
+		_, err = newAuditExporter(auditExportURL, auditExportToken, auditExportCA, auditExportSecret)
+		report.add("audit-export", false, err)
+	}
+	if analyticsInterval > 0 && analyticsRetention == 0 {
+		report.add("analytics-retention", true, fmt.Errorf("access counts need a retention of at least one day"))
+	}
+	for _, tr := range historyTenantRoles {
+		if role, group, _ := strings.Cut(tr, "="); role == "" || group == "" {
+			report.add("history-tenant-roles", true, fmt.Errorf("'%s' is not role=group", tr))
+			break
+		}
+	}
+	if bootscriptCacheMaxAge > warmupMaxWindow {
+		// Warm scripts have URLs signed up to a window earlier
+		report.add("bootscript-cache-max-age", true,
+			fmt.Errorf("%d seconds would outlive presigned URLs, at most %d", bootscriptCacheMaxAge, warmupMaxWindow))
+	}
+	if fallbackLimit < 0 {
+		report.add("fallback-limit", true, fmt.Errorf("%d is negative", fallbackLimit))
+	} else if fallbackTag != "" && fallbackLimit == 0 {
+		report.add("fallback-tag", false, fmt.Errorf("fallback tag %s is only served with a fallback limit", fallbackTag))
+	}
+
+	if artifactProxy && artifactDir != "" {
+		var fi os.FileInfo
+		if fi, err = os.Stat(artifactDir); err == nil && !fi.IsDir() {
+			err = fmt.Errorf("%s is not a directory", artifactDir)
+		}
+		report.add("artifact-dir", true, err)
+	}
+
+	if snapshotDir != "" {
+		var fi os.FileInfo
+		if fi, err = os.Stat(snapshotDir); err == nil && !fi.IsDir() {
+			err = fmt.Errorf("%s is not a directory", snapshotDir)
+		}
+		report.add("snapshot-dir", false, err)
+	}
+	return report
+}
+
+func printConfigReport(report configReport) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+// Function checkDatastoreTimeouts() rejects deadlines no operation could
+// meet.  The lock is taken in whole seconds.
+func checkDatastoreTimeouts() error {
+	for _, t := range []struct {
+		name string
+		ms   uint
+	}{{"etcd-get-timeout", etcdGetTimeout}, {"etcd-put-timeout", etcdPutTimeout}, {"etcd-delete-timeout", etcdDeleteTimeout}} {
+		if t.ms == 0 {
+			return fmt.Errorf("%s must be at least 1 millisecond", t.name)
+		}
+	}
+	if etcdLockTimeout < 1000 {
+		return fmt.Errorf("etcd-lock-timeout must be at least 1000 milliseconds, got %d", etcdLockTimeout)
+	}
+	if etcdMaxInFlight == 0 {
+		return fmt.Errorf("etcd-max-in-flight must be at least 1")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	savedAddr, savedDS, savedHSM, savedSigner := advertiseAddress, datastoreBase, hsmBase, s3SignerMode
+	defer func() {
+		advertiseAddress, datastoreBase, hsmBase, s3SignerMode = savedAddr, savedDS, savedHSM, savedSigner
+	}()
+
+	hsm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hsm/v2/service/ready" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer hsm.Close()
+
+	advertiseAddress, datastoreBase, hsmBase, s3SignerMode = "http://10.92.100.71:8888", "etcd:2379", hsm.URL, s3SignerMock
+	report := validateConfig(true)
+	if report.Result != checkOK || report.fatal() {
+		t.Errorf("Valid configuration failed validation:\n%s", report.String())
+	}
+
+	advertiseAddress = "http://[fd00::71]:8888"
+	if report = validateConfig(false); report.fatal() {
+		t.Errorf("Bracketed IPv6 advertise address failed validation:\n%s", report.String())
+	}
+	advertiseAddress = "http://fd00::71:8888"
+	if report = validateConfig(false); !report.fatal() {
+		t.Errorf("IPv6 advertise address without brackets passed validation")
+	}
+
+	advertiseAddress, datastoreBase, hsmBase = "", "ftp://etcd", "http://127.0.0.1:1"
+	report = validateConfig(true)
+	failed := make(map[string]bool)
+	for _, c := range report.Checks {
+		failed[c.Name] = c.Result == checkFail
+	}
+	for _, name := range []string{"advertise-address", "datastore-url", "hsm-reachable"} {
+		if !failed[name] {
+			t.Errorf("Check %s did not fail:\n%s", name, report.String())
+		}
+	}
+	if !report.fatal() {
+		t.Errorf("Invalid configuration is not fatal")
+	}
+
+	defer func(get uint) { etcdGetTimeout = get }(etcdGetTimeout)
+	etcdGetTimeout = 0
+	if err := checkDatastoreTimeouts(); err == nil {
+		t.Errorf("A zero datastore read deadline passed validation")
+	}
+}
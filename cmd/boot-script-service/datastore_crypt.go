@@ -0,0 +1,224 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Datastore encryption.
+//
+// Kernel command lines and cloud-init data can contain secrets, and datastore
+// backups do not always stay within the security boundary.  When a datastore
+// key is configured, those fields of the stored boot parameters are sealed with
+// AES-256-GCM before they are written and opened again when read, so API
+// consumers never notice.  Image references and referral tokens stay in the
+// clear.
+//
+// The key is given base64 encoded in BSS_DATASTORE_KEY, or read from the file
+// named by BSS_DATASTORE_KEY_FILE, e.g. a secret mounted from a KMS.  Previous
+// keys can be listed in BSS_DATASTORE_OLD_KEYS so data sealed with them can
+// still be read after a key rotation; it is resealed with the current key on
+// the next update.  Unsealed data from before encryption was enabled is read
+// as is.  Records derived from the boot parameters, the audit log and the
+// command line history, are sealed whole with sealValue().
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const sealedPrefix = "v1:"
+
+type datastoreKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+var (
+	sealKey  *datastoreKey
+	openKeys = make(map[string]*datastoreKey)
+)
+
+// The sealed fields of BootDataStore
+type sealedFields struct {
+	Params    string              `json:"params,omitempty"`
+	CloudInit bssTypes.CloudInit  `json:"cloud-init,omitempty"`
+	FirstBoot *bssTypes.FirstBoot `json:"first-boot,omitempty"`
+}
+
+type bootDataStoreFields BootDataStore
+
+type sealedBootDataStore struct {
+	bootDataStoreFields
+	Sealed string `json:"sealed,omitempty"`
+}
+
+func newDatastoreKey(b64 string) (*datastoreKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+	if err != nil {
+		return nil, fmt.Errorf("key is not base64 encoded: %s", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, not %d", len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &datastoreKey{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+// Function datastoreCryptInit() loads the datastore keys from the
+// environment.  Encryption stays off if no key is configured.
+func datastoreCryptInit() error {
+	current := getEnvVal("BSS_DATASTORE_KEY", "")
+	if file := getEnvVal("BSS_DATASTORE_KEY_FILE", ""); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		current = string(data)
+	}
+	sealKey = nil
+	openKeys = make(map[string]*datastoreKey)
+	for _, k := range strings.Split(getEnvVal("BSS_DATASTORE_OLD_KEYS", ""), ",") {
+		if k == "" {
+			continue
+		}
+		key, err := newDatastoreKey(k)
+		if err != nil {
+			return fmt.Errorf("BSS_DATASTORE_OLD_KEYS: %s", err)
+		}
+		openKeys[key.id] = key
+	}
+	if current != "" {
+		key, err := newDatastoreKey(current)
+		if err != nil {
+			return fmt.Errorf("datastore key: %s", err)
+		}
+		sealKey = key
+		openKeys[key.id] = key
+	}
+	return nil
+}
+
+func seal(plain []byte) (string, error) {
+	nonce := make([]byte, sealKey.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	ct := sealKey.aead.Seal(nonce, nonce, plain, nil)
+	return sealedPrefix + sealKey.id + ":" + base64.StdEncoding.EncodeToString(ct), nil
+}
+
+func unseal(sealed string) ([]byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(sealed, sealedPrefix), ":", 2)
+	if !strings.HasPrefix(sealed, sealedPrefix) || len(parts) != 2 {
+		return nil, fmt.Errorf("unknown sealed data format")
+	}
+	key, ok := openKeys[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("no datastore key with id %s", parts[0])
+	}
+	ct, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	n := key.aead.NonceSize()
+	if len(ct) < n {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+	return key.aead.Open(nil, ct[:n], ct[n:], nil)
+}
+
+// Function sealValue() returns the JSON of v to store, sealed if there is a
+// datastore key.
+func sealValue(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil || sealKey == nil {
+		return string(data), err
+	}
+	return seal(data)
+}
+
+// Function unsealValue() decodes a value stored by sealValue().
+func unsealValue(value string, v interface{}) error {
+	data := []byte(value)
+	if strings.HasPrefix(value, sealedPrefix) {
+		var err error
+		if data, err = unseal(value); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (bds BootDataStore) MarshalJSON() ([]byte, error) {
+	if sealKey == nil {
+		return json.Marshal(bootDataStoreFields(bds))
+	}
+	plain, err := json.Marshal(sealedFields{bds.Params, bds.CloudInit, bds.FirstBoot})
+	if err != nil {
+		return nil, err
+	}
+	out := sealedBootDataStore{bootDataStoreFields: bootDataStoreFields(bds)}
+	out.Params, out.CloudInit, out.FirstBoot = "", bssTypes.CloudInit{}, nil
+	if out.Sealed, err = seal(plain); err != nil {
+		return nil, err
+	}
+	return json.Marshal(out)
+}
+
+func (bds *BootDataStore) UnmarshalJSON(data []byte) error {
+	var in sealedBootDataStore
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*bds = BootDataStore(in.bootDataStoreFields)
+	if in.Sealed == "" {
+		return nil
+	}
+	plain, err := unseal(in.Sealed)
+	if err != nil {
+		return fmt.Errorf("cannot unseal boot parameters: %s", err)
+	}
+	var f sealedFields
+	if err = json.Unmarshal(plain, &f); err != nil {
+		return err
+	}
+	bds.Params, bds.CloudInit, bds.FirstBoot = f.Params, f.CloudInit, f.FirstBoot
+	return nil
+}
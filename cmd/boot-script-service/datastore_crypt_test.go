@@ -0,0 +1,81 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestDatastoreEncryption(t *testing.T) {
+	const host = "x0c0s18b0n0"
+	key1 := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("1", 32)))
+	key2 := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("2", 32)))
+	t.Cleanup(func() {
+		kvstore.Delete(paramsPfx + host)
+		sealKey, openKeys = nil, make(map[string]*datastoreKey)
+	})
+
+	t.Setenv("BSS_DATASTORE_KEY", key1)
+	if err := datastoreCryptInit(); err != nil {
+		t.Fatalf("datastoreCryptInit failed: %s", err)
+	}
+	bp := bssTypes.BootParams{Hosts: []string{host}, Params: "password=hunter2"}
+	if err, _ := Store(bp, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	raw, _, _ := kvstore.Get(paramsPfx + host)
+	if strings.Contains(raw, "hunter2") || !strings.Contains(raw, `"sealed":"v1:`) {
+		t.Errorf("Stored boot parameters are not sealed: %s", raw)
+	}
+	if bd, err := LookupBootData(host); err != nil || bd.Params != bp.Params {
+		t.Errorf("LookupBootData returned '%s', %v", bd.Params, err)
+	}
+
+	// Rotate the key, the old data must still be readable
+	t.Setenv("BSS_DATASTORE_KEY", key2)
+	t.Setenv("BSS_DATASTORE_OLD_KEYS", key1)
+	if err := datastoreCryptInit(); err != nil {
+		t.Fatalf("datastoreCryptInit failed: %s", err)
+	}
+	if bd, err := LookupBootData(host); err != nil || bd.Params != bp.Params {
+		t.Errorf("LookupBootData after key rotation returned '%s', %v", bd.Params, err)
+	}
+
+	// Without the old key the data cannot be read
+	t.Setenv("BSS_DATASTORE_OLD_KEYS", "")
+	if err := datastoreCryptInit(); err != nil {
+		t.Fatalf("datastoreCryptInit failed: %s", err)
+	}
+	if _, err := LookupBootData(host); err == nil {
+		t.Errorf("LookupBootData succeeded without the sealing key")
+	}
+
+	t.Setenv("BSS_DATASTORE_KEY", "c2hvcnQ=")
+	if err := datastoreCryptInit(); err == nil {
+		t.Errorf("datastoreCryptInit accepted a short key")
+	}
+}
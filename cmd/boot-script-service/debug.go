@@ -0,0 +1,158 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Runtime debug logging.
+//
+// --debug and BSS_DEBUG set the initial level; PUT /boot/v1/service/debug
+// changes it, or turns on debug output for just the HSM client, the
+// datastore or cloud-init, without a restart.  Changes need an admin token.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	debugLevelInfo  = "info"
+	debugLevelDebug = "debug"
+
+	debugHSM       = "hsm"
+	debugDatastore = "datastore"
+	debugCloudInit = "cloudinit"
+)
+
+var (
+	debugModules []string
+
+	debugOn         atomic.Bool
+	debugMutex      sync.Mutex
+	debugModulesSet atomic.Value // map[string]bool
+)
+
+func init() {
+	debugOn.Store(debugFlag)
+	debugModulesSet.Store(map[string]bool{})
+}
+
+func validDebugModule(m string) bool {
+	return m == debugHSM || m == debugDatastore || m == debugCloudInit
+}
+
+func setDebugModules(modules []string) error {
+	set := make(map[string]bool)
+	for _, m := range modules {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		if !validDebugModule(m) {
+			return fmt.Errorf("Unknown debug module '%s'", m)
+		}
+		set[m] = true
+	}
+	debugModulesSet.Store(set)
+	return nil
+}
+
+func debugInit() error {
+	debugOn.Store(debugFlag)
+	return setDebugModules(debugModules)
+}
+
+// Debug output for one module, shown when debugging is on for the whole
+// service or for that module.
+func debugmf(module string, format string, v ...interface{}) {
+	if debugOn.Load() || debugModulesSet.Load().(map[string]bool)[module] {
+		log.Printf("DEBUG["+module+"]: "+format, v...)
+	}
+}
+
+func currentDebugSettings() bssTypes.DebugSettings {
+	ds := bssTypes.DebugSettings{Level: debugLevelInfo}
+	if debugOn.Load() {
+		ds.Level = debugLevelDebug
+	}
+	for m := range debugModulesSet.Load().(map[string]bool) {
+		ds.Modules = append(ds.Modules, m)
+	}
+	sort.Strings(ds.Modules)
+	return ds
+}
+
+func sendDebugSettings(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(currentDebugSettings()); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func ServiceDebugGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ServiceDebugGet(): Received request %v\n", r.URL)
+	sendDebugSettings(w)
+}
+
+func ServiceDebugPut(w http.ResponseWriter, r *http.Request) {
+	debugf("ServiceDebugPut(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var ds bssTypes.DebugSettings
+	if err := json.NewDecoder(r.Body).Decode(&ds); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if ds.Level != "" && ds.Level != debugLevelInfo && ds.Level != debugLevelDebug {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: level must be '%s' or '%s'", debugLevelInfo, debugLevelDebug))
+		return
+	}
+	debugMutex.Lock()
+	defer debugMutex.Unlock()
+	if ds.Modules != nil {
+		if err := setDebugModules(ds.Modules); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: %s", err))
+			return
+		}
+	}
+	if ds.Level != "" {
+		debugOn.Store(ds.Level == debugLevelDebug)
+	}
+	cur := currentDebugSettings()
+	log.Printf("Debug settings changed by %s: level %s, modules %v",
+		requestSubject(r), cur.Level, cur.Modules)
+	sendDebugSettings(w)
+}
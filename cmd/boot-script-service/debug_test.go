@@ -0,0 +1,98 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func testToken(claims string) string {
+	return "Bearer eyJhbGciOiJSUzI1NiJ9." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
+
+func TestServiceDebug(t *testing.T) {
+	defer func(on bool, modules map[string]bool) {
+		debugOn.Store(on)
+		debugModulesSet.Store(modules)
+	}(debugOn.Load(), debugModulesSet.Load().(map[string]bool))
+
+	put := func(auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/boot/v1/service/debug", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		serviceDebug(w, req)
+		return w
+	}
+	admin := testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`)
+
+	for _, tc := range []struct {
+		auth, body string
+		status     int
+	}{
+		{"", `{"level":"debug"}`, http.StatusUnauthorized},
+		{testToken(`{"sub":"jdoe"}`), `{"level":"debug"}`, http.StatusForbidden},
+		{admin, `{"level":"trace"}`, http.StatusBadRequest},
+		{admin, `{"modules":["hsm","bogus"]}`, http.StatusBadRequest},
+		{admin, `{"level":`, http.StatusBadRequest},
+	} {
+		if w := put(tc.auth, tc.body); w.Code != tc.status {
+			t.Errorf("PUT %s: expected status %d, got %d", tc.body, tc.status, w.Code)
+		}
+	}
+
+	w := put(admin, `{"level":"info","modules":["hsm","datastore"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT failed: %d %s", w.Code, w.Body.String())
+	}
+	var ds bssTypes.DebugSettings
+	if err := json.Unmarshal(w.Body.Bytes(), &ds); err != nil {
+		t.Fatalf("Bad response: %s", err)
+	}
+	if ds.Level != debugLevelInfo || strings.Join(ds.Modules, ",") != "datastore,hsm" {
+		t.Errorf("Unexpected debug settings %+v", ds)
+	}
+	if debugOn.Load() {
+		t.Errorf("Debug level was not lowered")
+	}
+
+	// Modules are left alone when not given
+	put(admin, `{"level":"debug"}`)
+	req := httptest.NewRequest(http.MethodGet, "/boot/v1/service/debug", nil)
+	w = httptest.NewRecorder()
+	serviceDebug(w, req)
+	ds = bssTypes.DebugSettings{}
+	json.Unmarshal(w.Body.Bytes(), &ds)
+	if ds.Level != debugLevelDebug || len(ds.Modules) != 2 {
+		t.Errorf("Unexpected debug settings %+v", ds)
+	}
+}
@@ -39,8 +39,10 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -61,6 +63,8 @@ const (
 	bootDataBasePath = "/bootdata/"
 	unknownPrefix    = "Unknown-"
 	joinTokenVarName = "SPIRE_JOIN_TOKEN"
+	// How long presigned S3 URLs are valid
+	presignedURLLifetime = 24 * 3600 // seconds
 )
 
 var blockedRoles []string
@@ -68,9 +72,9 @@ var blockedRoles []string
 // Figure out what server the ipxe boot scripts should reference when chaining
 // to new BSS requests.  This is normally the API gateway.  Allow this to be
 // overridden with the BSS_IPXE_SERVER environment variable.
-var ipxeServer = getEnvVal("BSS_IPXE_SERVER", "api-gw-service-nmn.local")
-var chainProto = getEnvVal("BSS_CHAIN_PROTO", "https")
-var gwURI = getEnvVal("BSS_GW_URI", "/apis/bss")
+var ipxeServer = "api-gw-service-nmn.local"
+var chainProto = "https"
+var gwURI = "/apis/bss"
 
 // Store ptr to S3 client
 var s3Client *hms_s3.S3Client
@@ -78,6 +82,9 @@ var s3Client *hms_s3.S3Client
 // regex for matching s3 URIs in the params field
 var s3ParamsRegex = "(^|[ ])((metal.server=|root=live:)(s3://[^ ]*))"
 
+// Characters of request values that are escaped in chain URLs.
+var chainUnsafe = regexp.MustCompile(`[^A-Za-z0-9:._-]+`)
+
 type (
 	// function interface for checkURL()
 	// this enables writing unit tests for replaceS3Params()
@@ -88,6 +95,7 @@ type scriptParams struct {
 	xname         string
 	nid           string
 	referralToken string
+	arch          string
 }
 
 // Note that we allow an empty string if the env variable is defined as such.
@@ -132,26 +140,35 @@ func replaceS3Params(params string, getSignedS3Url signedS3UrlGetter) (newParams
 	return newParams, nil
 }
 
+// This is an S3 "url".  The way we are using them are that the "host" part
+// of the URL is the bucket, and the rest is the key.  If the "host" is
+// nil, then we will use the first part of the path as the bucket.
+func splitS3URL(p *url.URL) (bucket, key string) {
+	if p.Host == "" {
+		tmp := strings.Split(strings.Trim(p.Path, "/"), "/")
+		bucket = tmp[0]
+		key = strings.Join(tmp[1:], "/")
+	} else {
+		bucket = p.Host
+		key = p.Path
+	}
+	return bucket, key
+}
+
 func checkURL(u string) (string, error) {
 	p, err := url.Parse(u)
 	if err != nil || !strings.EqualFold(p.Scheme, "s3") {
 		return u, nil
 	}
-	// This is an S3 "url".  The way we are using them are that the "host" part
-	// of the URL is the bucket, and the rest is the key.  If the "host" is
-	// nil, then we will use the first part of the path as the bucket.
 	if err != nil {
 		return "", err
 	}
-	bucket := ""
-	key := ""
-	if p.Host == "" {
-		tmp := strings.Split(strings.Trim(p.Path, "/"), "/")
-		bucket = tmp[0]
-		key = strings.Join(tmp[1:], "/")
-	} else {
-		bucket = p.Host
-		key = p.Path
+	if signed, ok := warmSignedURL(u); ok {
+		return signed, nil
+	}
+	bucket, key := splitS3URL(p)
+	if s3SignerMode == s3SignerMock {
+		return mockS3SignedURL(bucket, key), nil
 	}
 	if s3Client == nil {
 		tr := &http.Transport{
@@ -168,33 +185,100 @@ func checkURL(u string) (string, error) {
 		s3Client.SetBucket(bucket)
 	}
 	if s3Client != nil {
-		return s3Client.GetURL(key, 24*time.Hour)
+		signed, err := s3Client.GetURL(key, presignedURLLifetime*time.Second)
+		if err == nil {
+			keepSignedURL(u, signed)
+		}
+		return signed, err
 	}
 	return "", err
 }
 
-func BootparametersGetAll(w http.ResponseWriter, r *http.Request) {
+func BootparametersGetAll(w http.ResponseWriter, r *http.Request, fields map[string]bool) {
+	verbose := isVerbose(r)
+	offset, limit, err := pageParams(r)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, total := bootParamsPage(r.Context(), verbose, offset, limit)
+	if results == nil && (offset > 0 || limit >= 0) {
+		results = []bssTypes.BootParams{}
+	}
+	if verbose && (fields == nil || fields["annotations"]) {
+		annotateResults(results)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("BSS-Total-Count", strconv.Itoa(total))
+	w.WriteHeader(http.StatusOK)
+	err = encodeBootParams(w, results, fields)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Function pageParams() returns the offset and limit query parameters of a
+// request, with a limit of -1 if there is none.
+func pageParams(r *http.Request) (int, int, error) {
+	offset, limit := 0, -1
+	var err error
+	if v := r.Form.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("Bad Request - Invalid offset '%s'", v)
+		}
+	}
+	if v := r.Form.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("Bad Request - Invalid limit '%s'", v)
+		}
+	}
+	return offset, limit, nil
+}
+
+// Function allBootParams() returns the boot parameters of all images, hosts
+// and tags.
+func allBootParams(verbose bool) []bssTypes.BootParams {
+	results, _ := bootParamsPage(context.Background(), verbose, 0, -1)
+	return results
+}
+
+// Function bootParamsPage() returns up to limit (all if limit is negative)
+// of the boot parameters allBootParams() would, starting at offset, and how
+// many there are in all.  Only the entries of the page are converted.
+func bootParamsPage(ctx context.Context, verbose bool, offset, limit int) ([]bssTypes.BootParams, int) {
 	var results []bssTypes.BootParams
+	total := 0
+	inPage := func() bool {
+		total++
+		return total > offset && (limit < 0 || total <= offset+limit)
+	}
 	for _, image := range GetKernelInfo() {
+		if !inPage() {
+			continue
+		}
 		var bp bssTypes.BootParams
 		bp.Params = image.Params
 		bp.Kernel = image.Path
 		results = append(results, bp)
 	}
 	for _, image := range GetInitrdInfo() {
+		if !inPage() {
+			continue
+		}
 		var bp bssTypes.BootParams
 		bp.Params = image.Params
 		bp.Initrd = image.Path
 		results = append(results, bp)
 	}
 	var names []string
-	if kvl, e := getTags(); e == nil {
+	reads := replicaReads(ctx)
+	if kvl, e := getTagsFrom(reads); e == nil {
 		for _, x := range kvl {
 			name := extractParamName(x)
 			names = append(names, name)
 			var bds BootDataStore
 			e = json.Unmarshal([]byte(x.Value), &bds)
-			if e == nil {
+			if e == nil && inPage() {
 				bd := bdConvert(bds)
 				var bp bssTypes.BootParams
 				bp.Hosts = append(bp.Hosts, name)
@@ -202,17 +286,21 @@ func BootparametersGetAll(w http.ResponseWriter, r *http.Request) {
 				bp.Kernel = bd.Kernel.Path
 				bp.Initrd = bd.Initrd.Path
 				bp.CloudInit = bd.CloudInit
+				bp.FirstBoot = bd.FirstBoot
+				bp.Messages = bd.Messages
+				bp.Labels = bd.Labels
+				bp.Reasons = bd.Reasons
+				bp.Payload, bp.Files = bd.Payload, bd.Files
+				bp.DTB, bp.ACPI, bp.InitrdOverlays = bd.DTB, bd.ACPI, bd.Overlays
+				if verbose {
+					bp.Provenance = bd.Provenance
+				}
 				results = append(results, bp)
 			}
 		}
 	}
 	debugf("Retreived names: %v", names)
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(results)
-	if err != nil {
-		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
-	}
+	return results, total
 }
 
 func BootparametersGet(w http.ResponseWriter, r *http.Request) {
@@ -231,11 +319,20 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 	mac := strings.Join(r.Form["mac"], ",")
 	name := strings.Join(r.Form["name"], ",")
 	nid := strings.Join(r.Form["nid"], ",")
-	qparams := mac != "" || name != "" || nid != ""
+	role := strings.Join(r.Form["role"], ",")
+	subRole := strings.Join(r.Form["subrole"], ",")
+	qparams := mac != "" || name != "" || nid != "" || role != "" || subRole != ""
+	verbose := isVerbose(r)
+	reads := replicaReads(r.Context())
+	fields, err := parseFields(r.Form["fields"])
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	if len(p) == 0 && !qparams {
 		// No body sent, so send all the boot parameters
-		BootparametersGetAll(w, r)
+		BootparametersGetAll(w, r, fields)
 		return
 	}
 	err = json.Unmarshal(p, &args)
@@ -267,6 +364,23 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 
 	debugf("Received boot parameters: %v\n", args)
 	var results []bssTypes.BootParams
+	if role != "" || subRole != "" {
+		// The role tag, then the nodes with the role that have their own
+		// boot parameters.
+		var roles, subRoles []string
+		if role != "" {
+			roles = strings.Split(role, ",")
+		}
+		if subRole != "" {
+			subRoles = strings.Split(subRole, ",")
+		}
+		names := append(roles, roleMembers(roles, subRoles)...)
+		for _, v := range names {
+			if bd, err := lookupBootDataFrom(reads, v); err == nil {
+				results = append(results, hostBootParams(v, bd, verbose))
+			}
+		}
+	}
 	if args.Kernel != "" || args.Initrd != "" {
 		for _, image := range GetKernelInfo() {
 			if image.Path == args.Kernel {
@@ -287,15 +401,9 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 	}
 	var unfoundHosts []string
 	for _, v := range args.Hosts {
-		bd, err := LookupBootData(v)
+		bd, err := lookupBootDataFrom(reads, v)
 		if err == nil {
-			var bp bssTypes.BootParams
-			bp.Hosts = append(bp.Hosts, v)
-			bp.Params = bd.Params
-			bp.Kernel = bd.Kernel.Path
-			bp.Initrd = bd.Initrd.Path
-			bp.CloudInit = bd.CloudInit
-			results = append(results, bp)
+			results = append(results, hostBootParams(v, bd, verbose))
 		} else {
 			unfoundHosts = append(unfoundHosts, v)
 		}
@@ -304,7 +412,7 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 
 	if len(args.Hosts) > 0 || len(args.Macs) > 0 || len(args.Nids) > 0 {
 
-		nameValues := GetNamesAndValues()
+		nameValues := getNamesAndValuesFrom(reads)
 
 		kernelImages := make(map[string]ImageData)
 		initrdImages := make(map[string]ImageData)
@@ -349,6 +457,15 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 				bp.Kernel = bd.Kernel.Path
 				bp.Initrd = bd.Initrd.Path
 				bp.CloudInit = bd.CloudInit
+				bp.FirstBoot = bd.FirstBoot
+				bp.Messages = bd.Messages
+				bp.Labels = bd.Labels
+				bp.Reasons = bd.Reasons
+				bp.Payload, bp.Files = bd.Payload, bd.Files
+				bp.DTB, bp.ACPI, bp.InitrdOverlays = bd.DTB, bd.ACPI, bd.Overlays
+				if verbose {
+					bp.Provenance = bd.Provenance
+				}
 				results = append(results, bp)
 			}
 		}
@@ -366,6 +483,9 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 		if len(args.Nids) > 0 {
 			objs = append(objs, "NIDs")
 		}
+		if role != "" || subRole != "" {
+			objs = append(objs, "role")
+		}
 		if args.Kernel != "" {
 			objs = append(objs, "kernel")
 		}
@@ -387,14 +507,41 @@ func BootparametersGet(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if verbose && (fields == nil || fields["annotations"]) {
+		annotateResults(results)
+	}
+	if len(results) == 1 && len(results[0].Hosts) == 1 {
+		setHostETag(w, results[0].Hosts[0])
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(results)
+	err = encodeBootParams(w, results, fields)
 	if err != nil {
 		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
 	}
 }
 
+// Function hostBootParams() returns the boot parameters of a host as they
+// are sent by GET.
+func hostBootParams(host string, bd BootData, verbose bool) bssTypes.BootParams {
+	var bp bssTypes.BootParams
+	bp.Hosts = append(bp.Hosts, host)
+	bp.Params = bd.Params
+	bp.Kernel = bd.Kernel.Path
+	bp.Initrd = bd.Initrd.Path
+	bp.CloudInit = bd.CloudInit
+	bp.FirstBoot = bd.FirstBoot
+	bp.Messages = bd.Messages
+	bp.Labels = bd.Labels
+	bp.Reasons = bd.Reasons
+	bp.Payload, bp.Files = bd.Payload, bd.Files
+	bp.DTB, bp.ACPI, bp.InitrdOverlays = bd.DTB, bd.ACPI, bd.Overlays
+	if verbose {
+		bp.Provenance = bd.Provenance
+	}
+	return bp
+}
+
 func LogBootParameters(prefix string, v interface{}) {
 	j, e := json.MarshalIndent(v, "", "  ")
 	if e == nil {
@@ -416,7 +563,11 @@ func BootparametersPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	debugf("Received boot parameters: %v\n", args)
-	err, referralToken := StoreNew(args)
+	warnRiskyParams(w, args.Params)
+	if stageChange(w, r, args, "") {
+		return
+	}
+	err, referralToken := StoreNew(args, requestSubject(r))
 	if err == nil {
 		LogBootParameters("/bootparameters POST", args)
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
@@ -443,18 +594,32 @@ func BootparametersPut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	debugf("Received boot parameters: %v\n", args)
-	err, referralToken := Store(args)
+	warnRiskyParams(w, args.Params)
+	if !checkProtection(w, r, args) || stageChange(w, r, args, "") {
+		return
+	}
+	names := bootParamsTargets(args)
+	expect, ok := bootParamsPreconditions(w, r, names)
+	if !ok {
+		return
+	}
+	before := effectiveOf(names)
+	err, referralToken := storeIf(args, requestSubject(r), expect)
 	if err == nil {
 		LogBootParameters("/bootparameters PUT", args)
-		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		if referralToken != "" {
 			w.Header().Set("BSS-Referral-Token", referralToken)
 		}
-		w.WriteHeader(http.StatusOK)
+		if len(names) == 1 {
+			setHostETag(w, names[0])
+		}
+		sendApplied(w, names, before)
 	} else {
 		LogBootParameters(fmt.Sprintf("/bootparameters PATCH FAILED: %s", err.Error()), args)
 		herr, ok := base.GetHMSError(err)
-		if ok && herr.GetProblem() != nil {
+		if pf, isPF := err.(PreconditionFailed); isPF {
+			sendPreconditionFailed(w, pf)
+		} else if ok && herr.GetProblem() != nil {
 			base.SendProblemDetails(w, herr.GetProblem(), 0)
 		} else {
 			base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "No data")
@@ -474,20 +639,45 @@ func BootparametersPatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	debugf("Received boot parameters: %v\n", args)
-	err = Update(args)
-	if err != nil {
+	warnRiskyParams(w, args.Params)
+	if stageChange(w, r, args, "") {
+		return
+	}
+	names := bootParamsTargets(args)
+	expect, ok := bootParamsPreconditions(w, r, names)
+	if !ok {
+		return
+	}
+	before := effectiveOf(names)
+	err = updateIf(args, requestSubject(r), expect)
+	if pf, isPF := err.(PreconditionFailed); isPF {
+		LogBootParameters(fmt.Sprintf("/bootparameters PATCH FAILED: %s", err.Error()), args)
+		sendPreconditionFailed(w, pf)
+	} else if err != nil {
 		LogBootParameters(fmt.Sprintf("/bootparameters PATCH FAILED: %s", err.Error()), args)
 		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
 			fmt.Sprintf("Not Found: %s", err))
 	} else {
 		LogBootParameters("/bootparameters PATCH", args)
-		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-		w.WriteHeader(http.StatusOK)
+		if len(names) == 1 {
+			setHostETag(w, names[0])
+		}
+		sendApplied(w, names, before)
 	}
 }
 
 func BootparametersDelete(w http.ResponseWriter, r *http.Request) {
 	debugf("BootParametersDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	policy := deleteOrphan
+	if p := strings.Join(r.Form["cascade"], ""); p != "" {
+		policy = p
+	}
+	if !validDeletePolicy(policy) {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: cascade must be %s, %s or %s", deleteOrphan, deleteCascade, deleteRestrict))
+		return
+	}
 	var args bssTypes.BootParams
 	dec := json.NewDecoder(r.Body)
 	err := dec.Decode(&args)
@@ -497,10 +687,17 @@ func BootparametersDelete(w http.ResponseWriter, r *http.Request) {
 			fmt.Sprintf("Bad Request: %s", err))
 		return
 	}
+	if !checkProtection(w, r, args) || stageChange(w, r, args, policy) {
+		return
+	}
 	if err == nil {
-		err = Remove(args)
+		err = Remove(args, policy)
 	}
-	if err != nil {
+	var conflict DeleteConflict
+	if errors.As(err, &conflict) {
+		LogBootParameters(fmt.Sprintf("/bootparameters DELETE REFUSED: %s", err.Error()), args)
+		base.SendProblemDetailsGeneric(w, http.StatusConflict, err.Error())
+	} else if err != nil {
 		LogBootParameters(fmt.Sprintf("/bootparameters DELETE FAILED: %s", err.Error()), args)
 		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, err.Error())
 	} else {
@@ -579,14 +776,34 @@ func buildBootScript(bd BootData, sp scriptParams, chain, role, subRole, descr s
 	if bd.Kernel.Path == "" {
 		return "", fmt.Errorf("%s: this host not configured for booting.", descr)
 	}
+	if err := checkApprovedImages(bd); err != nil {
+		return "", fmt.Errorf("%s: %s", descr, err)
+	}
+	return renderBootScript(bd, sp, chain, role, subRole, descr)
+}
 
+// Function renderBootScript() builds the script without the checks on the
+// images, for the self test and its synthetic ones.
+func renderBootScript(bd BootData, sp scriptParams, chain, role, subRole, descr string) (string, error) {
+	if bd.Kernel.Path == "" {
+		return "", fmt.Errorf("%s: this host not configured for booting.", descr)
+	}
 	params := bd.Params
-	if bd.Kernel.Params != "" {
-		params += " " + bd.Kernel.Params
+	if linuxPayload(bd) {
+		if bd.Kernel.Params != "" {
+			params += " " + bd.Kernel.Params
+		}
+		if bd.Initrd.Params != "" {
+			params += " " + bd.Initrd.Params
+		}
+		params = applyRoleParams(params, role)
+		params = applyKdumpParams(params, sp.xname, role)
 	}
-	if bd.Initrd.Params != "" {
-		params += " " + bd.Initrd.Params
+	params, condSetup, err := renderConditionals(params, nodeFacts(sp, role, subRole, scriptLabels(bd)))
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", descr, err)
 	}
+	payloadParams := params // wimboot and mboot get none of the below
 
 	// Check for special boot parameters.
 	params = checkParam(params, "xname=", sp.xname)
@@ -599,8 +816,8 @@ func buildBootScript(bd BootData, sp scriptParams, chain, role, subRole, descr s
 	// image does not have cloud-init enabled this wont hurt anything.
 	// If it does, it tells it to come back to us for the cloud-init meta-data
 	params = checkParam(params, "ds=", fmt.Sprintf("nocloud-net;s=%s/", advertiseAddress))
+	params = pinDigestParams(params, bd)
 
-	var err error
 	params, err = paramSubstitute(params, joinTokenVarName,
 		func() (string, error) { return getJoinToken(sp.xname, role, subRole) })
 
@@ -608,13 +825,34 @@ func buildBootScript(bd BootData, sp scriptParams, chain, role, subRole, descr s
 		return "", err
 	}
 
+	degraded := ""
 	params, err = replaceS3Params(params, checkURL)
 	if err != nil {
 		log.Printf("Error replacing s3 URIs. error: %v, params:\n%s", err, params)
+		degraded = degradedLine(degradedUnsigned, err.Error())
 		err = nil
 	}
 
-	script := "#!ipxe\n"
+	msgs := scriptMessages(bd, messageData{Name: sp.xname, NID: sp.nid,
+		Role: role, SubRole: subRole, RetryDelay: retryDelay})
+	script := "#!ipxe\n" + degraded
+	script += msgs.echo(msgBanner)
+	if handoffSeeder != "" {
+		// Hint for boot tooling that can fetch artifacts from peers
+		script += "set bss-seeder " + handoffSeeder + "\n"
+	}
+	if bootDepsEnabled {
+		script += bootDepsWaitScript(SMComponent{Component: base.Component{
+			ID: sp.xname, Role: role, SubRole: subRole}}, msgs)
+	}
+	script += condSetup
+	if !linuxPayload(bd) {
+		stanza, err := payloadStanza(bd, strings.Join(strings.Fields(payloadParams), " "))
+		if err != nil {
+			return script, err
+		}
+		return script + stanza + bootRetry(msgs, chain), nil
+	}
 	if bd.Initrd.Path != "" {
 		start := strings.Index(params, "initrd")
 		if start != -1 {
@@ -626,26 +864,70 @@ func buildBootScript(bd BootData, sp scriptParams, chain, role, subRole, descr s
 		}
 		params = "initrd=initrd " + params
 	}
+	overlayScript, overlayParams, err := overlayStanza(bd, sp)
+	if err != nil {
+		return script, err
+	}
+	if overlayParams != "" {
+		params = strings.Replace(params, "initrd=initrd ", "initrd=initrd "+overlayParams+" ", 1)
+	}
+	devScript, devParams, err := deviceFileStanza(bd, sp)
+	if err != nil {
+		return script, err
+	}
+	if devParams != "" {
+		params = devParams + " " + params
+	}
 	u := bd.Kernel.Path
-	u, err = checkURL(u)
+	if artifactProxy {
+		u = artifactProxyURL(kernelImageType, u)
+	} else {
+		u, err = checkURL(u)
+	}
 	if err != nil {
 		return script, err
 	}
 	script += "kernel --name kernel " + u + " " + strings.Trim(params, " ")
 	script += " || goto boot_retry\n"
+	v, err := imgverifyLine("kernel", bd.Kernel)
+	if err != nil {
+		return script, err
+	}
+	script += v
+	script += devScript
 	if bd.Initrd.Path != "" {
-		u, err = checkURL(bd.Initrd.Path)
+		if artifactProxy {
+			u = artifactProxyURL(initrdImageType, bd.Initrd.Path)
+		} else {
+			u, err = checkURL(bd.Initrd.Path)
+		}
 		if err == nil {
 			script += "initrd --name initrd " + u + " || goto boot_retry\n"
-			script += "imgstat || echo Could not show image information.\n"
+			v, err = imgverifyLine("initrd", bd.Initrd)
+			script += v + overlayScript
+			script += strings.TrimSpace("imgstat || "+msgs.inline(msgImageInfo)) + "\n"
 		}
 	}
-	script += "boot || goto boot_retry\n:boot_retry\n"
+	script += bootRetry(msgs, chain)
+	return script, err
+}
+
+// Function bootRetry() returns the end of a boot script: the boot command
+// and the retry after a failure.
+func bootRetry(msgs bootMessages, chain string) string {
+	script := "boot || goto boot_retry\n:boot_retry\n"
+	script += msgs.echo(msgBootRetry)
 	// We could vary the length of the sleep based on retry count or some
 	// other criteria.
 	// For now, just sleep a bit
 	script += fmt.Sprintf("sleep %d\n", retryDelay) + chain + "\n"
-	return script, err
+	return script
+}
+
+// Function chainValue() escapes a request value for the query of a chain
+// URL, so that it stays one argument of the iPXE chain command.
+func chainValue(v string) string {
+	return chainUnsafe.ReplaceAllStringFunc(v, url.QueryEscape)
 }
 
 // Function unknownBootScript() constructs the boot script for an unknown host
@@ -656,17 +938,20 @@ func unknownBootScript(arch, mac, name string, nid int, ts int64, role string, s
 	debugf("unknownBootScript(%s)", arch)
 	var script string
 	var err error
-	chain := "chain " + chainProto + "://" + ipxeServer + gwURI + "/boot/v1/bootscript"
+	chain := "chain " + chainProto + "://" + urlHost(ipxeServer) + gwURI + "/boot/v1/bootscript"
 	if mac != "" {
-		chain += "?mac=" + mac
+		chain += "?mac=" + chainValue(mac)
 	} else if name != "" {
-		chain += "?name=" + name
+		chain += "?name=" + chainValue(name)
 	} else if nid >= 0 {
 		chain += fmt.Sprintf("?nid=%d", nid)
 	} else {
 		chain += "?mac=${net/net0}" // FIXME: What should this be????
 	}
-	chain += fmt.Sprintf("&arch=${buildarch}&ts=%d", ts)
+	chain += "&arch=${buildarch}"
+	if !bootscriptDeterministic {
+		chain += fmt.Sprintf("&ts=%d", ts)
+	}
 	debugf("ts: %d, smTimeStamp: %d", ts, smTimeStamp)
 	retrievingState := checkState(arch == "")
 	if retrievingState {
@@ -722,13 +1007,50 @@ func blacklist(comp SMComponent) (err error) {
 	return
 }
 
+// Function bootScriptChain() returns the chain command a boot script ends
+// with, for the request of a known node.
+func bootScriptChain(path, mac, name string, retry int) string {
+	chain := "chain " + chainProto + "://" + urlHost(ipxeServer) + gwURI + path
+	if mac != "" {
+		chain += "?mac=" + chainValue(mac)
+	} else {
+		chain += "?name=" + chainValue(name)
+	}
+	if !bootscriptDeterministic {
+		chain += fmt.Sprintf("&retry=%d", retry+1)
+	}
+	return chain
+}
+
 func BootscriptGet(w http.ResponseWriter, r *http.Request) {
 	debugf("BootscriptGet(): Received request %v\n", r.URL)
 
 	r.ParseForm() // r.Form is empty until after parsing
+	// With profile= an admin previews the script a node would get when
+	// booting that rescue target or boot group.  Nothing is recorded.
+	var preview bssTypes.RescueTarget
+	if profile := strings.Join(r.Form["profile"], ""); profile != "" {
+		if !requestAdmin(w, r) {
+			return
+		}
+		t, found, _ := resolveProfile(profile)
+		if !found {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No profile %s", profile))
+			return
+		}
+		preview = t
+	}
+	if bootscriptQueue != nil {
+		if !bootscriptAdmit(w, r) {
+			return
+		}
+		defer bootscriptQueue.release()
+	}
 	mac := strings.Join(r.Form["mac"], "")
 	name := strings.Join(r.Form["name"], "")
 	arch := strings.Join(r.Form["arch"], "")
+	reason := strings.Join(r.Form["reason"], "")
 
 	tmp_nid, _ := getIntParam(r, "nid", -1)
 	tmp_retry, _ := getIntParam(r, "retry", 0)
@@ -741,20 +1063,21 @@ func BootscriptGet(w http.ResponseWriter, r *http.Request) {
 	var comp SMComponent
 	var descr string
 
+	reads := replicaReads(r.Context())
 	if mac != "" {
-		bd, comp = LookupByMAC(mac)
+		bd, comp = lookupByMACFrom(reads, mac)
 		descr = fmt.Sprintf("MAC %s", mac)
 		if comp.ID != "" {
 			descr += fmt.Sprintf(" (%s)", comp.ID)
 		}
 	} else if name != "" {
-		bd, comp = LookupByName(name)
+		bd, comp = lookupByNameFrom(reads, name)
 		descr = name
 		if comp.ID != "" && comp.ID != name {
 			descr += fmt.Sprintf(" (%s)", comp.ID)
 		}
 	} else if nid >= 0 {
-		bd, comp = LookupByNid(nid)
+		bd, comp = lookupByNidFrom(reads, nid)
 		descr = fmt.Sprintf("NID %d", nid)
 		if comp.ID != "" {
 			descr += fmt.Sprintf(" (%s)", comp.ID)
@@ -764,6 +1087,28 @@ func BootscriptGet(w http.ResponseWriter, r *http.Request) {
 		log.Printf("BSS request failed: bootscript request without mac=, name=, or nid= parameter")
 		return
 	}
+	fallback := false
+	if preview.Kernel != "" {
+		applyProfile(preview, &bd)
+	} else {
+		checkIdentity(r, "bootscript", mac, name)
+		if comp.ID != "" {
+			alt := mac
+			if alt == "" {
+				alt = name
+			}
+			if alt == "" {
+				alt = strconv.Itoa(nid)
+			}
+			fallback = defaultFallback(comp, alt)
+			if fallback && fallbackLimit > 0 && fallbackTag != "" {
+				bd = fallbackData(comp.ID, bd)
+			}
+		}
+		if applyReason(&bd, reason) {
+			descr += fmt.Sprintf(" for a %s boot", reason)
+		}
+	}
 
 	debugf("bd: %v\n", bd)
 	debugf("comp: %v\n", comp)
@@ -776,6 +1121,7 @@ func BootscriptGet(w http.ResponseWriter, r *http.Request) {
 	// either of these cases, we want to boot the discovery kernel.
 	unknown := comp.ID == "" || !comp.EndpointEnabled || bd.Kernel.Path == ""
 	retreivingState := false
+	paced := false
 	if unknown {
 		debugf("Unknown: comp: %v", comp)
 		if name == "" {
@@ -815,41 +1161,52 @@ func BootscriptGet(w http.ResponseWriter, r *http.Request) {
 			if mac == "" && comp.Mac != nil {
 				mac = comp.Mac[0]
 			}
-			sp := scriptParams{comp.ID, comp.NID.String(), bd.ReferralToken}
-			chain := "chain " + chainProto + "://" + ipxeServer + gwURI + r.URL.Path
-			if mac != "" {
-				chain += "?mac=" + mac
-			} else {
-				chain += "?name=" + comp.ID
+			sp := scriptParams{comp.ID, comp.NID.String(), bd.ReferralToken, arch}
+			chain := bootScriptChain(r.URL.Path, mac, comp.ID, retry)
+			if reasonNameRE.MatchString(reason) {
+				chain += "&reason=" + reason
 			}
-			chain += fmt.Sprintf("&retry=%d", retry+1)
 			retreivingState = checkState(false)
 			if retreivingState {
 				// We want to respond with a delayed chain response so that the
 				// node will retry in a bit after we have updated our state info
 				script = "#!ipxe\nsleep 10\n" + chain + "\n"
+			} else if paced = preview.Kernel == "" && bootGroupPaced(comp, name); paced {
+				script = fmt.Sprintf("#!ipxe\nsleep %d\n%s\n", bootGroupRetryDelay, chain)
 			} else {
-				script, err = buildBootScript(bd, sp, chain, comp.Role, comp.SubRole, descr)
+				script, err = bootScriptFor(bd, sp, chain, comp, descr)
 			}
 		}
 	}
 	if err == nil {
-		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
-		w.WriteHeader(http.StatusOK)
-		_, err = fmt.Fprintf(w, "%s\n", script)
+		mirrored := mirrorScript(script, findRemoteAddr(r))
+		// A mirrored script is only good for the client's subnet
+		cacheable := !unknown && !retreivingState && !paced && preview.Kernel == "" &&
+			mirrored == script && !issuesJoinToken(bd, comp.Role)
+		script = reportDegraded(w, mirrored, comp.ID, fallback && !unknown)
+		err = writeBootscript(w, r, script, cacheable)
 		if err == nil {
-			if retreivingState {
+			if preview.Kernel != "" {
+				log.Printf("BSS preview of %s for %s by %s", preview.Name, descr, requestSubject(r))
+			} else if retreivingState {
 				log.Printf("BSS request delayed for %s while updating state", descr)
+			} else if paced {
+				log.Printf("BSS request delayed for %s by the boot group limit", descr)
 			} else {
 				log.Printf("BSS request succeeded for %s", descr)
 
 				// Record the fact this was asked for.
 				updateEndpointAccessed(comp.ID, bssTypes.EndpointTypeBootscript)
+				recordCmdline(comp.ID, script)
+				verifyBoot(comp.ID)
+				verifyImageDigests(bd)
+				noteFallback(comp.ID, fallback)
 			}
 		} else {
 			log.Printf("BSS request failed writing response for %s: %s", descr, err.Error())
 		}
 	} else {
+		noStore(w)
 		base.SendProblemDetailsGeneric(w, http.StatusNotFound, err.Error())
 		if strings.HasPrefix(err.Error(), descr) {
 			log.Printf("BSS request failed: %s", err.Error())
@@ -865,7 +1222,9 @@ func HostsGet(w http.ResponseWriter, r *http.Request) {
 	mac := strings.Join(r.Form["mac"], ",")
 	name := strings.Join(r.Form["name"], ",")
 	nid := strings.Join(r.Form["nid"], ",")
-	qparams := mac != "" || name != "" || nid != ""
+	role := strings.Join(r.Form["role"], ",")
+	subRole := strings.Join(r.Form["subrole"], ",")
+	qparams := mac != "" || name != "" || nid != "" || role != "" || subRole != ""
 	state := getState()
 	results := state.Components
 	if qparams {
@@ -23,9 +23,18 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
 )
 
 func mockGetSignedS3Url(s3Url string) (string, error) {
@@ -179,3 +188,193 @@ func TestReplaceS3Params_error(t *testing.T) {
 		t.Errorf("replaceS3Params failed.\n  expected: %s\n  actual: %s\n", expected_params, newParams)
 	}
 }
+
+func TestCheckURL_mock_signer(t *testing.T) {
+	saved := s3SignerMode
+	s3SignerMode = s3SignerMock
+	defer func() { s3SignerMode = saved }()
+
+	expected := s3MockBaseURL + "/boot-images/k8s/kernel?" + mockSignatureParam + "=" +
+		mockS3Signature("boot-images", "k8s/kernel")
+	for _, u := range []string{"s3://boot-images/k8s/kernel", "s3:///boot-images/k8s/kernel"} {
+		signed, err := checkURL(u)
+		if err != nil {
+			t.Errorf("checkURL(%s) returned an error: %v\n", u, err)
+		}
+		if signed != expected {
+			t.Errorf("checkURL(%s) failed.\n  expected: %s\n  actual: %s\n", u, expected, signed)
+		}
+	}
+	if signed, _ := checkURL("http://example.com/kernel"); signed != "http://example.com/kernel" {
+		t.Errorf("checkURL changed a non-S3 URL: %s\n", signed)
+	}
+	if mockS3Signature("boot-images", "k8s/kernel") == mockS3Signature("boot-images", "k8s/initrd") {
+		t.Errorf("mockS3Signature returned the same signature for different keys\n")
+	}
+}
+
+func TestChainValue(t *testing.T) {
+	for v, expected := range map[string]string{
+		"00:1e:67:dd:d0:00":      "00:1e:67:dd:d0:00",
+		"x0c0s3b0n0":             "x0c0s3b0n0",
+		"aa\nshell":              "aa%0Ashell",
+		"x0c0s3b0n0&nid=1 || sh": "x0c0s3b0n0%26nid%3D1+%7C%7C+sh",
+	} {
+		if got := chainValue(v); got != expected {
+			t.Errorf("chainValue(%q) = %s, expected %s", v, got, expected)
+		}
+	}
+	script, _, _ := unknownBootScript("", "aa\nshell", "", -1, 0, "", "", "test")
+	if !strings.Contains(script, "?mac=aa%0Ashell&") {
+		t.Errorf("Expected the escaped MAC in the chain command:\n%s", script)
+	}
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(line, "shell") {
+			t.Errorf("Request value escaped the chain command:\n%s", script)
+		}
+	}
+}
+
+func TestBootparametersPaging(t *testing.T) {
+	hosts := []string{"x9c2s1b0n0", "x9c2s2b0n0", "x9c2s3b0n0"}
+	defer func() {
+		for _, h := range hosts {
+			kvstore.Delete(paramsPfx + h)
+		}
+	}()
+	for _, h := range hosts {
+		storeData(paramsPfx+h, BootDataStore{Params: "console=ttyS0"})
+	}
+	get := func(query string) (int, string, []bssTypes.BootParams) {
+		w := httptest.NewRecorder()
+		BootparametersGet(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootparameters"+query, nil))
+		var bps []bssTypes.BootParams
+		json.Unmarshal(w.Body.Bytes(), &bps)
+		return w.Code, w.Header().Get("BSS-Total-Count"), bps
+	}
+	_, total, all := get("")
+	if total != strconv.Itoa(len(all)) || len(all) < 3 {
+		t.Fatalf("Expected at least 3 entries and a total count, got %d and %q", len(all), total)
+	}
+	code, total, page := get("?offset=1&limit=2")
+	if code != http.StatusOK || total != strconv.Itoa(len(all)) || !reflect.DeepEqual(page, all[1:3]) {
+		t.Errorf("Page 1+2 returned %d, total %s: %+v", code, total, page)
+	}
+	if _, _, page = get("?offset=" + strconv.Itoa(len(all))); page == nil || len(page) != 0 {
+		t.Errorf("Page past the end returned %+v", page)
+	}
+	for _, q := range []string{"?limit=0", "?limit=x", "?offset=-1"} {
+		if code, _, _ = get(q); code != http.StatusBadRequest {
+			t.Errorf("GET %s returned %d, expected %d", q, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestBootparametersByRole(t *testing.T) {
+	state := getState()
+	var worker, other string
+	for i, c := range state.Components {
+		if c.Role != "Compute" || c.SubRole != "" {
+			continue
+		}
+		if worker == "" {
+			worker = c.ID
+			state.Components[i].SubRole = "TestWorker"
+			defer func() { state.Components[i].SubRole = "" }()
+		} else if other == "" {
+			other = c.ID
+		}
+	}
+	if other == "" {
+		t.Fatal("Expected two Compute nodes in the test data")
+	}
+	defer func() {
+		for _, h := range []string{worker, other, "Compute"} {
+			kvstore.Delete(paramsPfx + h)
+		}
+	}()
+	for _, h := range []string{worker, other, "Compute"} {
+		storeData(paramsPfx+h, BootDataStore{Params: "console=" + h})
+	}
+	get := func(query string) (int, []string) {
+		w := httptest.NewRecorder()
+		BootparametersGet(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootparameters"+query, nil))
+		var bps []bssTypes.BootParams
+		json.Unmarshal(w.Body.Bytes(), &bps)
+		var hosts []string
+		for _, bp := range bps {
+			hosts = append(hosts, bp.Hosts...)
+		}
+		sort.Strings(hosts)
+		return w.Code, hosts
+	}
+	want := []string{"Compute", worker, other}
+	sort.Strings(want)
+	if code, hosts := get("?role=Compute"); code != http.StatusOK || !reflect.DeepEqual(hosts, want) {
+		t.Errorf("?role=Compute returned %d %v, expected %v", code, hosts, want)
+	}
+	want = []string{"Compute", worker}
+	sort.Strings(want)
+	if code, hosts := get("?role=Compute&subrole=TestWorker"); code != http.StatusOK || !reflect.DeepEqual(hosts, want) {
+		t.Errorf("?role=Compute&subrole=TestWorker returned %d %v, expected %v", code, hosts, want)
+	}
+	if code, _ := get("?role=NoSuchRole"); code != http.StatusNotFound {
+		t.Errorf("?role=NoSuchRole returned %d, expected %d", code, http.StatusNotFound)
+	}
+}
+
+func TestBootparametersIfMatch(t *testing.T) {
+	const host = "x9c4s1b0n0"
+	defer kvstore.Delete(paramsPfx + host)
+	send := func(method, query, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, baseEndpoint+"/bootparameters"+query, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		bootParameters(w, req)
+		return w
+	}
+	body := func(params string) string { return `{"hosts":["` + host + `"],"params":"` + params + `"}` }
+
+	if w := send(http.MethodPut, "", body("quiet"), "*"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT of a new host with If-Match: * returned %d", w.Code)
+	}
+	w := send(http.MethodPut, "", body("quiet"), "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+		t.Fatalf("PUT returned %d with ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	w = send(http.MethodGet, "?name="+host, "", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET returned %d with ETag %q", w.Code, etag)
+	}
+	if w = send(http.MethodPatch, "", body("debug"), etag); w.Code != http.StatusOK ||
+		w.Header().Get("ETag") == etag {
+		t.Fatalf("PATCH with the current ETag returned %d with ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w = send(http.MethodPatch, "", body("quiet"), etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH with a stale ETag returned %d", w.Code)
+	}
+	if bd, _ := LookupBootData(host); bd.Params != "debug" {
+		t.Errorf("Refused PATCH changed the params to %q", bd.Params)
+	}
+	if w = send(http.MethodPut, "", body("quiet"), "*"); w.Code != http.StatusOK {
+		t.Errorf("PUT with If-Match: * returned %d", w.Code)
+	}
+
+	// A change by another instance after the check is caught by the store.
+	stale, _, _ := kvstore.Get(paramsPfx + host)
+	send(http.MethodPatch, "", body("debug"), "")
+	expect := map[string]string{host: stale}
+	bp := bssTypes.BootParams{Hosts: []string{host}, Params: "quiet"}
+	if err, _ := storeIf(bp, "test", expect); err != (PreconditionFailed{host}) {
+		t.Errorf("PUT over a changed host returned %v", err)
+	}
+	if err := updateIf(bp, "test", expect); err != (PreconditionFailed{host}) {
+		t.Errorf("PATCH over a changed host returned %v", err)
+	}
+	if bd, _ := LookupBootData(host); bd.Params != "debug" {
+		t.Errorf("Refused writes changed the params to %q", bd.Params)
+	}
+}
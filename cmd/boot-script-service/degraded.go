@@ -0,0 +1,117 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Degradation report.
+//
+// BSS keeps serving boot scripts when some of its inputs are not what they
+// should be, so that nodes can still boot.  Such a script says so in comment
+// lines after #!ipxe, and the response names the reasons in BSS-Degraded,
+// so that node logs and support bundles show why a node booted the way it
+// did:
+//
+//	#!ipxe
+//	#bss-degraded reason=hsm-stale detail="HSM refreshes failing since ..."
+//	#bss-degraded reason=fallback detail="no boot parameters for x3000c0s1b0n0 or its role"
+//
+// The reasons are hsm-stale (the HSM state is a cached copy or the latest
+// refreshes failed), fallback (the node has no boot parameters of its own or
+// of its role) and s3-unsigned (S3 URLs in the kernel parameters could not
+// be presigned and are passed on as they are).
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	degradedHeader   = "BSS-Degraded"
+	degradedComment  = "#bss-degraded"
+	degradedHSM      = "hsm-stale"
+	degradedFallback = "fallback"
+	degradedUnsigned = "s3-unsigned"
+)
+
+func degradedLine(reason, detail string) string {
+	return fmt.Sprintf("%s reason=%s detail=%q\n", degradedComment, reason, detail)
+}
+
+// Function hsmDegraded() describes what is wrong with the HSM state, ""
+// if nothing is.
+func hsmDegraded() string {
+	smMutex.RLock()
+	defer smMutex.RUnlock()
+	switch {
+	case smClient == nil && hsmFailingSince == 0:
+		// mem: and file: HSM data is local.
+		return ""
+	case smData == nil:
+		return "no HSM state"
+	case !hsmSynced:
+		return "using the cached HSM state, HSM has not answered yet"
+	case hsmFailingSince != 0:
+		return fmt.Sprintf("HSM refreshes failing since %s, state from %s",
+			time.Unix(hsmFailingSince, 0).UTC().Format(time.RFC3339),
+			time.Unix(hsmLoadedAt, 0).UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+// Function reportDegraded() adds the degradations of the request to the
+// comment lines already in the script, and names them all in the
+// BSS-Degraded header.
+func reportDegraded(w http.ResponseWriter, script, name string, fallback bool) string {
+	var lines string
+	if d := hsmDegraded(); d != "" {
+		lines += degradedLine(degradedHSM, d)
+	}
+	if fallback {
+		lines += degradedLine(degradedFallback,
+			fmt.Sprintf("no boot parameters for %s or its role", name))
+	}
+	if lines != "" {
+		if i := strings.IndexByte(script, '\n'); i >= 0 && strings.HasPrefix(script, "#!ipxe") {
+			script = script[:i+1] + lines + script[i+1:]
+		}
+	}
+	var reasons []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(script, "\n") {
+		rest, ok := strings.CutPrefix(line, degradedComment+" reason=")
+		if !ok {
+			continue
+		}
+		reason, _, _ := strings.Cut(rest, " ")
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(reasons) > 0 {
+		w.Header().Set(degradedHeader, strings.Join(reasons, ", "))
+	}
+	return script
+}
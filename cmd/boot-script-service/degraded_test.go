@@ -0,0 +1,92 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestDegradedBootScript(t *testing.T) {
+	const node = "x0c0s4b0n0"
+	savedMode := s3SignerMode
+	s3SignerMode = s3SignerMock
+	defer func() {
+		s3SignerMode = savedMode
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(paramsPfx + DefaultTag)
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{DefaultTag}, Params: "quiet",
+		Kernel: "s3://boot-images/default/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	boot := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+		return w
+	}
+
+	w := boot()
+	if w.Header().Get(degradedHeader) != degradedFallback ||
+		!strings.HasPrefix(w.Body.String(), "#!ipxe\n"+degradedComment+" reason=fallback ") {
+		t.Errorf("Expected a fallback report, got %q:\n%s", w.Header().Get(degradedHeader), w.Body.String())
+	}
+
+	smMutex.Lock()
+	hsmFailingSince = time.Now().Unix()
+	smMutex.Unlock()
+	defer func() {
+		smMutex.Lock()
+		hsmFailingSince = 0
+		smMutex.Unlock()
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "quiet",
+		Kernel: "s3://boot-images/default/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	w = boot()
+	if w.Header().Get(degradedHeader) != degradedHSM || !strings.Contains(w.Body.String(), degradedComment+" reason=hsm-stale ") {
+		t.Errorf("Expected an HSM report, got %q:\n%s", w.Header().Get(degradedHeader), w.Body.String())
+	}
+}
+
+func TestReportDegraded(t *testing.T) {
+	w := httptest.NewRecorder()
+	script := "#!ipxe\n" + degradedLine(degradedUnsigned, "no credentials") + "kernel s3://b/k\n"
+	if got := reportDegraded(w, script, "x0", true); !strings.Contains(got, "#!ipxe\n"+degradedComment+" reason=fallback") ||
+		!strings.Contains(got, degradedLine(degradedUnsigned, "no credentials")) {
+		t.Errorf("Unexpected script:\n%s", got)
+	}
+	if h := w.Header().Get(degradedHeader); h != "fallback, s3-unsigned" {
+		t.Errorf("Unexpected %s header %q", degradedHeader, h)
+	}
+	w = httptest.NewRecorder()
+	if got := reportDegraded(w, "#!ipxe\nboot\n", "x0", false); got != "#!ipxe\nboot\n" || w.Header().Get(degradedHeader) != "" {
+		t.Errorf("Script without degradations changed: %q %q", got, w.Header().Get(degradedHeader))
+	}
+}
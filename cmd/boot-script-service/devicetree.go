@@ -0,0 +1,128 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Device tree and ACPI overrides.
+//
+// Some ARM nodes need a device tree blob or replacement ACPI tables that
+// their firmware does not provide.  An entry can list a dtb and acpi
+// overrides, which the boot script of an ARM node loads along with the
+// kernel:
+//
+//	imgfetch --name dtb <dtb>         kernel parameter dtb=dtb
+//	initrd --name acpi0 <acpi[0]>     kernel parameter initrd=acpi0, before
+//	                                  initrd=initrd
+//
+// The ACPI overrides are cpio archives of kernel/firmware/acpi/*.aml, which
+// the kernel only finds at the start of the initrd.  A node is ARM if it
+// passed arch=arm64 (the ${buildarch} of ARM iPXE) or, without arch=, if HSM
+// says so.  Other nodes boot without them, so a role entry can be shared,
+// but storing them for a node HSM knows is not ARM is refused.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	dtbImageName = "dtb"
+	acpiImagePfx = "acpi"
+	armBuildArch = "arm64"
+)
+
+// Function checkDeviceFiles() validates the device tree and ACPI overrides
+// of the given hosts.
+func checkDeviceFiles(hosts []string, payload, dtb string, acpi []string) error {
+	if dtb == "" && len(acpi) == 0 {
+		return nil
+	}
+	if payload != "" && payload != payloadLinux {
+		return fmt.Errorf("A dtb or acpi overrides need a %s payload", payloadLinux)
+	}
+	for i, a := range acpi {
+		if a == "" {
+			return fmt.Errorf("ACPI override %d has no path", i)
+		}
+	}
+	for _, h := range hosts {
+		if comp, ok := FindSMCompByNameInCache(h); ok && comp.Arch != "" &&
+			!strings.EqualFold(comp.Arch, base.ArchARM.String()) {
+			return fmt.Errorf("%s is %s, a dtb or acpi overrides are only for %s nodes", h, comp.Arch, base.ArchARM)
+		}
+	}
+	return nil
+}
+
+// Function patchDeviceFiles() returns the device tree and ACPI overrides
+// of an entry after applying bp to it.
+func patchDeviceFiles(bp bssTypes.BootParams, bd BootDataStore) (string, []string) {
+	dtb, acpi := bd.DTB, bd.ACPI
+	if bp.DTB != "" {
+		dtb = bp.DTB
+	}
+	if bp.ACPI != nil {
+		acpi = bp.ACPI
+	}
+	return dtb, acpi
+}
+
+func armNode(sp scriptParams) bool {
+	if sp.arch != "" {
+		return sp.arch == armBuildArch
+	}
+	comp, ok := FindSMCompByNameInCache(sp.xname)
+	return ok && strings.EqualFold(comp.Arch, base.ArchARM.String())
+}
+
+// Function deviceFileStanza() returns the iPXE commands loading the device
+// tree and ACPI overrides of an ARM node, and the kernel parameters naming
+// them.  The initrd= parameters go before the one of the initrd.
+func deviceFileStanza(bd BootData, sp scriptParams) (string, string, error) {
+	if bd.DTB == "" && len(bd.ACPI) == 0 || !armNode(sp) {
+		return "", "", nil
+	}
+	var script string
+	var params []string
+	for i, a := range bd.ACPI {
+		u, err := checkURL(a)
+		if err != nil {
+			return "", "", err
+		}
+		name := fmt.Sprintf("%s%d", acpiImagePfx, i)
+		script += "initrd --name " + name + " " + u + " || goto boot_retry\n"
+		params = append(params, "initrd="+name)
+	}
+	if bd.DTB != "" {
+		u, err := checkURL(bd.DTB)
+		if err != nil {
+			return "", "", err
+		}
+		script += "imgfetch --name " + dtbImageName + " " + u + " || goto boot_retry\n"
+		params = append(params, "dtb="+dtbImageName)
+	}
+	return script, strings.Join(params, " "), nil
+}
@@ -0,0 +1,99 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestCheckDeviceFiles(t *testing.T) {
+	const node = "x0c0s2b0n0"
+	_, stateMap := getStateAndMap()
+	saved := stateMap[node]
+	defer func() { stateMap[node] = saved }()
+	comp := saved
+	comp.Arch = base.ArchX86.String()
+	stateMap[node] = comp
+
+	tests := []struct {
+		hosts   []string
+		payload string
+		dtb     string
+		acpi    []string
+		ok      bool
+	}{
+		{[]string{node}, "", "", nil, true},
+		{[]string{"Compute"}, "", "http://s3/arm/board.dtb", []string{"http://s3/arm/acpi.cpio"}, true},
+		{[]string{"Compute"}, payloadWimboot, "http://s3/arm/board.dtb", nil, false},
+		{[]string{"Compute"}, "", "", []string{""}, false},
+		{[]string{node}, "", "http://s3/arm/board.dtb", nil, false},
+	}
+	for _, tc := range tests {
+		if err := checkDeviceFiles(tc.hosts, tc.payload, tc.dtb, tc.acpi); (err == nil) != tc.ok {
+			t.Errorf("checkDeviceFiles(%v, %s, %s, %v) = %v", tc.hosts, tc.payload, tc.dtb, tc.acpi, err)
+		}
+	}
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Kernel: "http://s3/kernel",
+		DTB: "http://s3/arm/board.dtb"}, ""); err == nil {
+		kvstore.Delete(paramsPfx + node)
+		t.Errorf("Store accepted a dtb for an X86 node")
+	}
+	const arm = "x0c0s2b0n9"
+	defer kvstore.Delete(paramsPfx + arm)
+	w := httptest.NewRecorder()
+	bootParameters(w, httptest.NewRequest(http.MethodPut, baseEndpoint+"/bootparameters",
+		strings.NewReader(`{"hosts":["`+arm+`"],"kernel":"http://s3/arm/kernel",`+
+			`"dtb":"http://s3/arm/board.dtb","acpi":["http://s3/arm/acpi.cpio"]}`)))
+	if w.Code != http.StatusOK {
+		t.Errorf("PUT with dtb and acpi returned %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDeviceFileBootScript(t *testing.T) {
+	bd := BootData{Kernel: ImageData{Path: "http://s3/arm/kernel"}, Initrd: ImageData{Path: "http://s3/arm/initrd"},
+		Params: "console=ttyAMA0", DTB: "http://s3/arm/board.dtb",
+		ACPI: []string{"http://s3/arm/dsdt.cpio", "http://s3/arm/ssdt.cpio"}}
+	script, err := buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0", arch: armBuildArch}, "chain x", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	want := "initrd --name acpi0 http://s3/arm/dsdt.cpio || goto boot_retry\n" +
+		"initrd --name acpi1 http://s3/arm/ssdt.cpio || goto boot_retry\n" +
+		"imgfetch --name dtb http://s3/arm/board.dtb || goto boot_retry\n" +
+		"initrd --name initrd http://s3/arm/initrd || goto boot_retry\n"
+	if !strings.Contains(script, want) ||
+		!strings.Contains(script, "kernel --name kernel http://s3/arm/kernel initrd=acpi0 initrd=acpi1 dtb=dtb initrd=initrd console=ttyAMA0") {
+		t.Errorf("Unexpected ARM script:\n%s", script)
+	}
+
+	script, err = buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0", arch: "x86_64"}, "chain x", "Compute", "", "test")
+	if err != nil || strings.Contains(script, "acpi") || strings.Contains(script, "dtb") {
+		t.Errorf("Device files loaded on x86_64 (%v):\n%s", err, script)
+	}
+}
@@ -0,0 +1,235 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Entries: a plain CRUD view of the boot parameters.
+//
+// Tools such as Terraform need stable IDs, idempotent writes and a read
+// that returns exactly what was written.  /boot/v1/entries/<id> is the boot
+// parameters stored under one host name or tag:
+//
+//	GET     the entry, with an ETag
+//	PUT     replace (or create) the whole entry; a PUT that matches what is
+//	        stored changes nothing
+//	DELETE  remove the entry
+//
+// PUT and DELETE honour If-Match, and PUT honours If-None-Match: *, so
+// concurrent changes are detected.  The ETag is that of the stored boot
+// parameters, hostETag(), so it also works with If-Match on
+// /bootparameters, and a PUT with If-Match replaces the stored value only if
+// it is still the one that matched.  Reads go to the datastore, never to a
+// cache, so a GET after a successful PUT sees it.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const entriesEndpoint = baseEndpoint + "/entries"
+
+func entryOf(id string, bds BootDataStore) bssTypes.Entry {
+	bd := bdConvert(bds)
+	return bssTypes.Entry{ID: id, Params: bds.Params, Kernel: bd.Kernel.Path, Initrd: bd.Initrd.Path,
+		MetaData: bds.CloudInit.MetaData, UserData: bds.CloudInit.UserData}
+}
+
+// Function getEntry() returns the entry stored under id and the stored value
+// it comes from.
+func getEntry(id string) (bssTypes.Entry, string, bool) {
+	raw, exists, err := kvstore.Get(paramsPfx + id)
+	var bds BootDataStore
+	if err != nil || !exists || json.Unmarshal([]byte(raw), &bds) != nil {
+		return bssTypes.Entry{}, "", false
+	}
+	return entryOf(id, bds), raw, true
+}
+
+func sameEntry(a, b bssTypes.Entry) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return bytes.Equal(da, db)
+}
+
+// Function entryPreconditions() checks If-Match and If-None-Match against
+// raw, the stored value of entry id, sending 412 and returning false if they
+// fail.
+func entryPreconditions(w http.ResponseWriter, r *http.Request, id, raw string, exists bool) bool {
+	ok := true
+	if m := r.Header.Get("If-Match"); m != "" {
+		ok = exists && (m == "*" || strings.Contains(m, hostETag(raw)))
+	}
+	if m := r.Header.Get("If-None-Match"); m == "*" && exists {
+		ok = false
+	}
+	if !ok {
+		sendPreconditionFailed(w, PreconditionFailed{id})
+	}
+	return ok
+}
+
+func sendEntry(w http.ResponseWriter, status int, e bssTypes.Entry, raw string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("ETag", hostETag(raw))
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Function entryID() returns the ID of an /entries/<id> path, or "" for the
+// collection.
+func entryID(path string) (string, bool) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(path, entriesEndpoint), "/")
+	if strings.Contains(rest, "/") {
+		return "", false
+	}
+	return rest, true
+}
+
+func EntriesGet(w http.ResponseWriter, r *http.Request) {
+	debugf("EntriesGet(): Received request %v\n", r.URL)
+	id, ok := entryID(r.URL.Path)
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	if id != "" {
+		e, raw, exists := getEntry(id)
+		if !exists {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No entry %s", id))
+			return
+		}
+		sendEntry(w, http.StatusOK, e, raw)
+		return
+	}
+	kvl, err := getTags()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read entries: %s", err))
+		return
+	}
+	entries := []bssTypes.Entry{}
+	for _, kv := range kvl {
+		var bds BootDataStore
+		if json.Unmarshal([]byte(kv.Value), &bds) == nil {
+			entries = append(entries, entryOf(extractParamName(kv), bds))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func EntriesPut(w http.ResponseWriter, r *http.Request) {
+	debugf("EntriesPut(): Received request %v\n", r.URL)
+	id, ok := entryID(r.URL.Path)
+	if !ok || id == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	var e bssTypes.Entry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if e.ID != "" && e.ID != id {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: id %s does not match the path", e.ID))
+		return
+	}
+	e.ID = id
+	cur, raw, exists := getEntry(id)
+	if !entryPreconditions(w, r, id, raw, exists) {
+		return
+	}
+	if exists && sameEntry(cur, e) {
+		sendEntry(w, http.StatusOK, cur, raw)
+		return
+	}
+	bp := bssTypes.BootParams{Hosts: []string{id}, Params: e.Params, Kernel: e.Kernel, Initrd: e.Initrd,
+		CloudInit: bssTypes.CloudInit{MetaData: e.MetaData, UserData: e.UserData}}
+	if !checkProtection(w, r, bp) || stageChange(w, r, bp, "") {
+		return
+	}
+	var expect map[string]string
+	if m := r.Header.Get("If-Match"); m != "" && m != "*" {
+		expect = map[string]string{id: raw}
+	}
+	if err, _ := storeIf(bp, requestSubject(r), expect); err != nil {
+		if pf, isPF := err.(PreconditionFailed); isPF {
+			sendPreconditionFailed(w, pf)
+			return
+		}
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot store entry %s: %s", id, err))
+		return
+	}
+	stored, raw, _ := getEntry(id)
+	status := http.StatusOK
+	if !exists {
+		status = http.StatusCreated
+	}
+	sendEntry(w, status, stored, raw)
+}
+
+func EntriesDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("EntriesDelete(): Received request %v\n", r.URL)
+	id, ok := entryID(r.URL.Path)
+	if !ok || id == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	_, raw, exists := getEntry(id)
+	if !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No entry %s", id))
+		return
+	}
+	bp := bssTypes.BootParams{Hosts: []string{id}}
+	if !entryPreconditions(w, r, id, raw, exists) || !checkProtection(w, r, bp) || stageChange(w, r, bp, "") {
+		return
+	}
+	if err := removeHost(id); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot delete entry %s: %s", id, err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
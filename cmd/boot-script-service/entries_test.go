@@ -0,0 +1,100 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEntries(t *testing.T) {
+	const id = "x0c0s5b0n0"
+	path := entriesEndpoint + "/" + id
+	defer func() {
+		kvstore.Delete(paramsPfx + id)
+		kvstore.Delete(imageFind("s3://boot-images/entries/kernel", kernelImageType))
+	}()
+	do := func(method, body string, hdr ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		for i := 0; i+1 < len(hdr); i += 2 {
+			req.Header.Set(hdr[i], hdr[i+1])
+		}
+		w := httptest.NewRecorder()
+		entries(w, req)
+		return w
+	}
+	body := `{"params":"console=ttyS0","kernel":"s3://boot-images/entries/kernel",` +
+		`"meta-data":{"a":"b"}}`
+
+	if w := do(http.MethodGet, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET before PUT returned %d", w.Code)
+	}
+	w := do(http.MethodPut, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("PUT returned %d: %s", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	created := w.Body.String()
+	if raw, _, _ := kvstore.Get(paramsPfx + id); hostETag(raw) != etag {
+		t.Errorf("PUT returned ETag %s, expected that of the stored boot parameters", etag)
+	}
+
+	w = do(http.MethodGet, "")
+	if w.Code != http.StatusOK || w.Body.String() != created || w.Header().Get("ETag") != etag {
+		t.Errorf("GET did not read back the entry: %d %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != `{"id":"x0c0s5b0n0","params":"console=ttyS0","kernel":"s3://boot-images/entries/kernel","meta-data":{"a":"b"}}`+"\n" {
+		t.Errorf("Read back differs from what was written: %s", w.Body.String())
+	}
+
+	before, _ := lookupHost(id)
+	if w = do(http.MethodPut, body); w.Code != http.StatusOK || w.Header().Get("ETag") != etag {
+		t.Errorf("Repeated PUT returned %d with ETag %s", w.Code, w.Header().Get("ETag"))
+	}
+	if after, _ := lookupHost(id); after.ReferralToken != before.ReferralToken {
+		t.Errorf("Repeated PUT rewrote the entry")
+	}
+
+	if w = do(http.MethodPut, body, "If-None-Match", "*"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with If-None-Match on an existing entry returned %d", w.Code)
+	}
+	if w = do(http.MethodPut, `{"params":"quiet"}`, "If-Match", `"stale"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with a stale If-Match returned %d", w.Code)
+	}
+	if w = do(http.MethodPut, `{"id":"other"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT with a mismatched id returned %d", w.Code)
+	}
+	w = do(http.MethodPut, `{"params":"quiet"}`, "If-Match", etag)
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":"x0c0s5b0n0","params":"quiet"}`+"\n" {
+		t.Errorf("PUT replacing the entry returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if w = do(http.MethodDelete, ""); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE returned %d", w.Code)
+	}
+	if w = do(http.MethodDelete, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Second DELETE returned %d", w.Code)
+	}
+}
@@ -0,0 +1,282 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// DHCP configuration export.
+//
+// Small sites without a separate DHCP management tool can generate their
+// DHCP configuration from BSS: /boot/v1/export/dnsmasq returns dnsmasq
+// configuration and /boot/v1/export/kea a Kea DHCPv4 configuration
+// fragment.  Both have a host reservation for each MAC address of every
+// node that has boot parameters, with the IP address HSM knows for it, and
+// boot options that chain PXE firmware to the iPXE binary
+// (--export-ipxe-binary) and iPXE to the BSS boot script.
+//
+// For name resolution on the provisioning network /boot/v1/export/hosts
+// returns an /etc/hosts file with the xname and nid<N> alias of every IP
+// address in the HSM cache, and with format=zone a DNS zone file for the
+// --export-domain (or domain=) domain, e.g. for the CoreDNS file plugin.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const exportEndpoint = baseEndpoint + "/export/"
+
+var (
+	exportIPXEBinary = "ipxe.efi"
+	exportDomain     = ""
+)
+
+type exportHost struct {
+	Name string
+	MAC  string
+	IP   string
+}
+
+// Function exportHosts() lists the MAC addresses of the nodes with boot
+// parameters, sorted by node.
+func exportHosts() []exportHost {
+	state := getState()
+	if state == nil {
+		return nil
+	}
+	ips := make(map[string]string)
+	for ip, eth := range state.IPAddrs {
+		if mac := strings.ToLower(eth.MACAddr); mac != "" {
+			if cur, ok := ips[mac]; !ok || ip < cur {
+				ips[mac] = ip
+			}
+		}
+	}
+	comps := append([]SMComponent(nil), state.Components...)
+	sort.Slice(comps, func(i, j int) bool { return comps[i].ID < comps[j].ID })
+	var ret []exportHost
+	for _, comp := range comps {
+		if strings.EqualFold(comp.State, "empty") || len(comp.Mac) == 0 {
+			continue
+		}
+		if bd := lookup(comp.ID, "", comp.Role, DefaultTag); bd.Kernel.Path == "" {
+			continue
+		}
+		for _, mac := range comp.Mac {
+			mac = strings.ToLower(mac)
+			if mac == badMAC || mac == undefinedMAC {
+				continue
+			}
+			ret = append(ret, exportHost{Name: comp.ID, MAC: mac, IP: ips[mac]})
+		}
+	}
+	return ret
+}
+
+type exportAddr struct {
+	IP      string
+	Name    string
+	Aliases []string
+}
+
+// Function exportAddrs() lists the IP addresses HSM has for components,
+// sorted by component and address.
+func exportAddrs() []exportAddr {
+	state := getState()
+	if state == nil {
+		return nil
+	}
+	comps := make(map[string]SMComponent, len(state.Components))
+	for _, comp := range state.Components {
+		comps[comp.ID] = comp
+	}
+	var ret []exportAddr
+	for ip, eth := range state.IPAddrs {
+		if eth.CompID == "" || net.ParseIP(ip) == nil {
+			continue
+		}
+		a := exportAddr{IP: ip, Name: eth.CompID}
+		if comp, ok := comps[eth.CompID]; ok {
+			if nid, err := comp.NID.Int64(); err == nil && nid > 0 {
+				a.Aliases = append(a.Aliases, nidName(int(nid)))
+			}
+			if comp.Fqdn != "" && comp.Fqdn != comp.ID {
+				a.Aliases = append(a.Aliases, comp.Fqdn)
+			}
+		}
+		ret = append(ret, a)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].IP < ret[j].IP
+	})
+	return ret
+}
+
+func exportHostsFile(addrs []exportAddr) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by %s at %s\n", serviceName, time.Now().UTC().Format(time.RFC3339))
+	for _, a := range addrs {
+		fmt.Fprintf(&b, "%s\t%s\n", a.IP, strings.Join(append([]string{a.Name}, a.Aliases...), " "))
+	}
+	return b.String()
+}
+
+// Function exportZone() returns a zone file of the domain with an A or AAAA
+// record of each address for the xname and the nid<N> alias.  Aliases that
+// are not in the domain, such as HSM FQDNs, are left out.  The serial is the
+// time of the HSM data.
+func exportZone(addrs []exportAddr, domain string, serial int64) string {
+	domain = strings.TrimSuffix(domain, ".")
+	var b strings.Builder
+	fmt.Fprintf(&b, "; Generated by %s at %s\n", serviceName, time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "$ORIGIN %s.\n$TTL 300\n", domain)
+	fmt.Fprintf(&b, "@\tIN\tSOA\tns.%s. hostmaster.%s. %d 3600 600 86400 300\n", domain, domain, serial)
+	for _, a := range addrs {
+		rr := "A"
+		if net.ParseIP(a.IP).To4() == nil {
+			rr = "AAAA"
+		}
+		for _, name := range append([]string{a.Name}, a.Aliases...) {
+			if strings.Contains(name, ".") {
+				continue
+			}
+			fmt.Fprintf(&b, "%s\tIN\t%s\t%s\n", name, rr, a.IP)
+		}
+	}
+	return b.String()
+}
+
+func exportBootScriptURL() string {
+	return chainProto + "://" + urlHost(ipxeServer) + gwURI + baseEndpoint + "/bootscript?mac=${net0/mac}"
+}
+
+func exportDnsmasq(hosts []exportHost) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by %s at %s\n", serviceName, time.Now().UTC().Format(time.RFC3339))
+	b.WriteString("dhcp-match=set:ipxe,175\n")
+	fmt.Fprintf(&b, "dhcp-boot=tag:bss,tag:!ipxe,%s\n", exportIPXEBinary)
+	fmt.Fprintf(&b, "dhcp-boot=tag:bss,tag:ipxe,%s\n", exportBootScriptURL())
+	for _, h := range hosts {
+		if h.IP != "" {
+			fmt.Fprintf(&b, "dhcp-host=%s,set:bss,%s,%s\n", h.MAC, h.IP, h.Name)
+		} else {
+			fmt.Fprintf(&b, "dhcp-host=%s,set:bss,%s\n", h.MAC, h.Name)
+		}
+	}
+	return b.String()
+}
+
+type keaReservation struct {
+	HWAddress     string   `json:"hw-address"`
+	IPAddress     string   `json:"ip-address,omitempty"`
+	Hostname      string   `json:"hostname"`
+	ClientClasses []string `json:"client-classes"`
+}
+
+type keaClientClass struct {
+	Name         string `json:"name"`
+	Test         string `json:"test"`
+	BootFileName string `json:"boot-file-name"`
+}
+
+type keaConfig struct {
+	Dhcp4 struct {
+		ClientClasses []keaClientClass `json:"client-classes"`
+		Reservations  []keaReservation `json:"reservations"`
+	} `json:"Dhcp4"`
+}
+
+func exportKea(hosts []exportHost) keaConfig {
+	var cfg keaConfig
+	cfg.Dhcp4.ClientClasses = []keaClientClass{
+		{Name: "bss-ipxe", Test: "member('bss') and option[77].hex == 'iPXE'",
+			BootFileName: exportBootScriptURL()},
+		{Name: "bss-pxe", Test: "member('bss') and not option[77].hex == 'iPXE'",
+			BootFileName: exportIPXEBinary},
+	}
+	cfg.Dhcp4.Reservations = []keaReservation{}
+	for _, h := range hosts {
+		cfg.Dhcp4.Reservations = append(cfg.Dhcp4.Reservations, keaReservation{
+			HWAddress: h.MAC, IPAddress: h.IP, Hostname: h.Name, ClientClasses: []string{"bss"}})
+	}
+	return cfg
+}
+
+func ExportGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ExportGet(): Received request %v\n", r.URL)
+	switch strings.TrimPrefix(r.URL.Path, exportEndpoint) {
+	case "dnsmasq":
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, exportDnsmasq(exportHosts()))
+	case "kea":
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(exportKea(exportHosts())); err != nil {
+			log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+		}
+	case "hosts":
+		r.ParseForm() // r.Form is empty until after parsing
+		switch strings.Join(r.Form["format"], "") {
+		case "", "hosts":
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, exportHostsFile(exportAddrs()))
+		case "zone":
+			domain := strings.Join(r.Form["domain"], "")
+			if domain == "" {
+				domain = exportDomain
+			}
+			if domain == "" {
+				base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+					"Bad Request - A zone needs domain= or --export-domain")
+				return
+			}
+			smMutex.RLock()
+			serial := smTimeStamp
+			smMutex.RUnlock()
+			w.Header().Set("Content-Type", "text/dns; charset=UTF-8")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, exportZone(exportAddrs(), domain, serial))
+		default:
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				"Bad Request - Unknown hosts format, use hosts or zone")
+		}
+	default:
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - Unknown export format '%s', use dnsmasq, kea or hosts",
+				strings.TrimPrefix(r.URL.Path, exportEndpoint)))
+	}
+}
@@ -0,0 +1,135 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+)
+
+func TestExportHosts(t *testing.T) {
+	bp := bssTypes.BootParams{Hosts: []string{"x0c0s1b0n0"}, Kernel: "http://s3/export/kernel"}
+	defer func() {
+		kvstore.Delete(paramsPfx + "x0c0s1b0n0")
+		kvstore.Delete(imageFind(bp.Kernel, kernelImageType))
+	}()
+	if err, _ := Store(bp, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	var macs []string
+	for _, h := range exportHosts() {
+		if h.Name == "x0c0s1b0n0" {
+			macs = append(macs, h.MAC)
+		}
+	}
+	if strings.Join(macs, ",") != "00:1e:67:e3:46:51,00:1e:67:e3:46:52" {
+		t.Errorf("Unexpected exported MACs %v", macs)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, exportEndpoint+"dnsmasq", nil)
+	w := httptest.NewRecorder()
+	export(w, req)
+	if !strings.Contains(w.Body.String(), "dhcp-host=00:1e:67:e3:46:51,set:bss,x0c0s1b0n0\n") {
+		t.Errorf("dnsmasq export is missing the host:\n%s", w.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodGet, exportEndpoint+"bogus", nil)
+	w = httptest.NewRecorder()
+	export(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Unknown format returned %d", w.Code)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	hosts := []exportHost{{Name: "x1", MAC: "02:00:00:00:00:01", IP: "10.0.0.1"}}
+	conf := exportDnsmasq(hosts)
+	for _, line := range []string{
+		"dhcp-boot=tag:bss,tag:!ipxe," + exportIPXEBinary + "\n",
+		"dhcp-boot=tag:bss,tag:ipxe," + exportBootScriptURL() + "\n",
+		"dhcp-host=02:00:00:00:00:01,set:bss,10.0.0.1,x1\n",
+	} {
+		if !strings.Contains(conf, line) {
+			t.Errorf("dnsmasq config is missing %q:\n%s", line, conf)
+		}
+	}
+
+	data, _ := json.Marshal(exportKea(hosts))
+	var kea map[string]map[string][]map[string]interface{}
+	if err := json.Unmarshal(data, &kea); err != nil {
+		t.Fatalf("Bad Kea config: %s", err)
+	}
+	res := kea["Dhcp4"]["reservations"]
+	if len(res) != 1 || res[0]["hw-address"] != "02:00:00:00:00:01" || res[0]["ip-address"] != "10.0.0.1" {
+		t.Errorf("Unexpected Kea reservations %v", res)
+	}
+	if len(kea["Dhcp4"]["client-classes"]) != 2 {
+		t.Errorf("Unexpected Kea client classes %v", kea["Dhcp4"]["client-classes"])
+	}
+}
+
+func TestExportDNS(t *testing.T) {
+	state := getState()
+	savedAddrs := state.IPAddrs
+	state.IPAddrs = map[string]sm.CompEthInterfaceV2{
+		"10.252.1.16":    {CompID: "x0c0s3b0n0"},
+		"fd00::16":       {CompID: "x0c0s3b0n0"},
+		"10.252.1.4":     {CompID: "x0c0s1b0n0"},
+		"10.252.1.99":    {},
+		"not-an-address": {CompID: "x0c0s2b0n0"},
+	}
+	defer func() { state.IPAddrs = savedAddrs }()
+
+	get := func(query string) (int, string) {
+		w := httptest.NewRecorder()
+		export(w, httptest.NewRequest(http.MethodGet, exportEndpoint+"hosts"+query, nil))
+		return w.Code, w.Body.String()
+	}
+	code, hosts := get("")
+	lines := strings.Split(strings.TrimSpace(hosts), "\n")[1:]
+	if code != http.StatusOK || strings.Join(lines, "|") !=
+		"10.252.1.4\tx0c0s1b0n0 nid8 x0c0s1b0n0.test.com|"+
+			"10.252.1.16\tx0c0s3b0n0 nid16 x0c0s3b0n0.test.com|"+
+			"fd00::16\tx0c0s3b0n0 nid16 x0c0s3b0n0.test.com" {
+		t.Errorf("Unexpected hosts file (%d):\n%s", code, hosts)
+	}
+
+	if code, _ = get("?format=zone"); code != http.StatusBadRequest {
+		t.Errorf("Expected a zone without a domain to be refused, got %d", code)
+	}
+	code, zone := get("?format=zone&domain=nmn.example.com.")
+	for _, rr := range []string{"$ORIGIN nmn.example.com.\n", "x0c0s3b0n0\tIN\tA\t10.252.1.16\n",
+		"nid16\tIN\tA\t10.252.1.16\n", "x0c0s3b0n0\tIN\tAAAA\tfd00::16\n"} {
+		if !strings.Contains(zone, rr) {
+			t.Errorf("Zone is missing %q:\n%s", rr, zone)
+		}
+	}
+	if code != http.StatusOK || strings.Contains(zone, "test.com") || !strings.Contains(zone, "\tSOA\t") {
+		t.Errorf("Unexpected zone (%d):\n%s", code, zone)
+	}
+}
@@ -0,0 +1,193 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Fair admission for boot script requests.
+//
+// During a boot storm a few nodes retrying in a tight loop can take all the
+// capacity and starve the others.  With BSS_BOOTSCRIPT_CONCURRENCY set, at
+// most that many boot script requests are served at once.  The others wait
+// in a queue per node (the mac, name or nid asked for, else the client
+// address) and free slots go round robin over the nodes that are waiting,
+// so every node is served at a bounded rate however often the others ask.
+// A node with BSS_BOOTSCRIPT_QUEUE_DEPTH requests already waiting gets a 429
+// and the iPXE retry, and so does every node once BSS_BOOTSCRIPT_QUEUE_MAX
+// requests are waiting in all, which also bounds the number of queues when
+// many distinct MACs or addresses ask at once.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+var (
+	bootscriptConcurrency = uint(0) // 0 admits every request at once
+	bootscriptQueueDepth  = uint(4)
+	bootscriptQueueMax    = uint(1000)
+
+	bootscriptQueue *fairQueue
+)
+
+type fairQueue struct {
+	mu      sync.Mutex
+	free    int
+	depth   int
+	max     int // Waiters in all
+	queued  int
+	waiting map[string][]chan struct{}
+	order   []string // Identities with waiters, next to be served first
+}
+
+func newFairQueue(concurrency, depth, max int) *fairQueue {
+	return &fairQueue{free: concurrency, depth: depth, max: max, waiting: make(map[string][]chan struct{})}
+}
+
+var errQueueFull = fmt.Errorf("too many requests queued")
+
+// Function acquire() waits for a slot.  On success the caller must call
+// release() when done.
+func (q *fairQueue) acquire(ctx context.Context, id string) error {
+	q.mu.Lock()
+	if q.free > 0 && len(q.order) == 0 {
+		q.free--
+		q.mu.Unlock()
+		return nil
+	}
+	if len(q.waiting[id]) >= q.depth || q.queued >= q.max {
+		q.mu.Unlock()
+		return errQueueFull
+	}
+	ch := make(chan struct{})
+	if len(q.waiting[id]) == 0 {
+		q.order = append(q.order, id)
+	}
+	q.waiting[id] = append(q.waiting[id], ch)
+	q.queued++
+	q.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, c := range q.waiting[id] {
+		if c == ch {
+			q.waiting[id] = append(q.waiting[id][:i], q.waiting[id][i+1:]...)
+			q.queued--
+			if len(q.waiting[id]) == 0 {
+				q.drop(id)
+			}
+			return ctx.Err()
+		}
+	}
+	// Granted while giving up, pass the slot on.
+	q.handOff()
+	return ctx.Err()
+}
+
+func (q *fairQueue) drop(id string) {
+	delete(q.waiting, id)
+	for i, o := range q.order {
+		if o == id {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Function handOff() gives a slot to the next identity in turn, or frees it.
+// Called with mu held.
+func (q *fairQueue) handOff() {
+	if len(q.order) == 0 {
+		q.free++
+		return
+	}
+	id := q.order[0]
+	ch := q.waiting[id][0]
+	q.waiting[id] = q.waiting[id][1:]
+	q.queued--
+	q.order = q.order[1:]
+	if len(q.waiting[id]) > 0 {
+		q.order = append(q.order, id)
+	} else {
+		delete(q.waiting, id)
+	}
+	close(ch)
+}
+
+func (q *fairQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handOff()
+}
+
+func fairQueueInit() {
+	if bootscriptConcurrency > 0 {
+		depth, max := int(bootscriptQueueDepth), int(bootscriptQueueMax)
+		if depth < 1 {
+			depth = 1
+		}
+		if max < depth {
+			max = depth
+		}
+		bootscriptQueue = newFairQueue(int(bootscriptConcurrency), depth, max)
+	}
+}
+
+// The identity a boot script request is queued under.
+func bootscriptIdentity(r *http.Request) string {
+	switch {
+	case r.Form.Get("mac") != "":
+		return "mac:" + strings.ToLower(r.Form.Get("mac"))
+	case r.Form.Get("name") != "":
+		return "name:" + r.Form.Get("name")
+	case r.Form.Get("nid") != "":
+		return "nid:" + r.Form.Get("nid")
+	}
+	return "addr:" + findRemoteAddr(r)
+}
+
+// Function bootscriptAdmit() waits for the turn of the request.  If it sends
+// an error response instead it returns false; otherwise the caller must call
+// bootscriptQueue.release() when done.
+func bootscriptAdmit(w http.ResponseWriter, r *http.Request) bool {
+	id := bootscriptIdentity(r)
+	err := bootscriptQueue.acquire(r.Context(), id)
+	if err == nil {
+		return true
+	}
+	if err == errQueueFull {
+		w.Header().Set("Retry-After", "1")
+		base.SendProblemDetailsGeneric(w, http.StatusTooManyRequests,
+			fmt.Sprintf("Too many boot script requests queued for %s", id))
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFairQueue(t *testing.T) {
+	q := newFairQueue(1, 2, 4)
+	ctx := context.Background()
+	if err := q.acquire(ctx, "a"); err != nil {
+		t.Fatalf("acquire() of a free slot failed: %s", err)
+	}
+	served := make(chan string, 3)
+	queued := func(n int) {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			q.mu.Lock()
+			total := 0
+			for _, w := range q.waiting {
+				total += len(w)
+			}
+			q.mu.Unlock()
+			if total == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("Expected %d waiters", n)
+	}
+	wait := func(id string) {
+		go func() {
+			if q.acquire(ctx, id) == nil {
+				served <- id
+			}
+		}()
+	}
+	wait("a")
+	queued(1)
+	wait("a")
+	queued(2)
+	wait("b")
+	queued(3)
+	if err := q.acquire(ctx, "a"); err != errQueueFull {
+		t.Errorf("acquire() past the queue depth returned %v", err)
+	}
+	wait("d")
+	queued(4)
+	if err := q.acquire(ctx, "e"); err != errQueueFull {
+		t.Errorf("acquire() past the queue maximum returned %v", err)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := q.acquire(cctx, "c"); err == nil {
+		t.Errorf("acquire() with a canceled context succeeded")
+	}
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		q.release()
+		order = append(order, <-served)
+	}
+	if order[0] != "a" || order[1] != "b" || order[2] != "d" || order[3] != "a" {
+		t.Errorf("Served in order %v, expected a b d a", order)
+	}
+	q.release()
+	if q.free != 1 || len(q.order) != 0 || q.queued != 0 {
+		t.Errorf("Queue not idle: free %d, order %v", q.free, q.order)
+	}
+}
+
+func TestBootscriptAdmit(t *testing.T) {
+	defer func() { bootscriptQueue = nil }()
+	bootscriptQueue = newFairQueue(0, 1, 1)
+	bootscriptQueue.waiting["mac:00:1e:67:e3:46:51"] = []chan struct{}{make(chan struct{})}
+	bootscriptQueue.order = []string{"mac:00:1e:67:e3:46:51"}
+
+	req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?mac=00:1E:67:E3:46:51", nil)
+	rr := httptest.NewRecorder()
+	bootScript(rr, req)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Bootscript with a full queue returned %d", rr.Code)
+	}
+}
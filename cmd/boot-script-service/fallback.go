@@ -0,0 +1,224 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Default tag fallback tracking.
+//
+// A node HSM knows but that has no boot parameters of its own, for its name,
+// MAC, NID or role, boots whatever the Default tag holds.  Sites often want
+// that only for a limited number of boots: Default then brings a node up far
+// enough to register, and a node that keeps coming back to it needs an
+// operator.  With --fallback-limit set, BSS records every boot script served
+// to such a node and flags the node once it has had that many fallback boots.
+// --fallback-tag names a boot parameters tag, e.g. a registration image, that
+// is served to these nodes instead of Default while tracking is on.
+//
+// The records are listed by GET /boot/v1/fallback and the flagged nodes are
+// counted by the bss_fallback_flagged_nodes gauge.  A record is removed as
+// soon as the node boots with boot parameters of its own again, or with
+// DELETE /boot/v1/fallback.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const fallbackPfx = "/fallback/"
+
+var (
+	fallbackLimit = 0
+	fallbackTag   = ""
+)
+
+// Serializes the read-modify-write of the records within this instance.
+var fallbackMutex sync.Mutex
+
+// Function defaultFallback() reports whether the boot data of a component
+// comes from the Default tag.  alt is the MAC, name or NID the node asked
+// with, as boot parameters may be stored under any of those.
+func defaultFallback(comp SMComponent, alt string) bool {
+	_, err := lookupStore(comp.ID, alt, comp.Role, "")
+	return err != nil
+}
+
+// Function fallbackData() returns the boot data of the fallback tag for a
+// node, or bd when that tag has no boot parameters.
+func fallbackData(name string, bd BootData) BootData {
+	if _, err := lookupHost(fallbackTag); err != nil {
+		debugf("Fallback tag %s not available: %v\n", fallbackTag, err)
+		return bd
+	}
+	return lookup(name, "", "", fallbackTag)
+}
+
+func getFallbackRecord(name string) (bssTypes.FallbackNode, bool, error) {
+	var rec bssTypes.FallbackNode
+	val, exists, err := kvstore.Get(fallbackPfx + name)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &rec)
+	}
+	return rec, exists, err
+}
+
+// Function noteFallback() records a boot script served to a node.  A fallback
+// boot is counted, the record of a node that boots with its own boot
+// parameters again is removed.
+func noteFallback(name string, fallback bool) {
+	if fallbackLimit <= 0 || name == "" {
+		return
+	}
+	fallbackMutex.Lock()
+	defer fallbackMutex.Unlock()
+	rec, exists, err := getFallbackRecord(name)
+	if err != nil {
+		log.Printf("Failed to retrieve fallback state for %s: %s", name, err)
+		return
+	}
+	if !fallback {
+		if exists {
+			if err = kvstore.Delete(fallbackPfx + name); err != nil {
+				log.Printf("Failed to remove fallback state for %s: %s", name, err)
+			} else {
+				log.Printf("%s boots with its own boot parameters again", name)
+			}
+		}
+		return
+	}
+	now := time.Now().Unix()
+	if !exists {
+		rec = bssTypes.FallbackNode{Name: name, First: now}
+	}
+	rec.Boots++
+	rec.Last = now
+	if !rec.Flagged && rec.Boots >= fallbackLimit {
+		rec.Flagged = true
+		log.Printf("WARNING: %s has booted %d times with the %s tag, it needs its own boot parameters",
+			name, rec.Boots, DefaultTag)
+	}
+	if err = storeData(fallbackPfx+name, rec); err != nil {
+		log.Printf("Failed to store fallback state for %s: %s", name, err)
+	}
+}
+
+func fallbackRecords() ([]bssTypes.FallbackNode, error) {
+	kvl, err := kvstore.GetRange(fallbackPfx+keyMin, fallbackPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	recs := []bssTypes.FallbackNode{}
+	for _, kv := range kvl {
+		var rec bssTypes.FallbackNode
+		if err = json.Unmarshal([]byte(kv.Value), &rec); err != nil {
+			log.Printf("Skipping bad fallback record %s: %s", kv.Key, err)
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// Function fallbackFlagged() counts the flagged nodes for the metrics.
+func fallbackFlagged() int {
+	if fallbackLimit <= 0 {
+		return 0
+	}
+	recs, err := fallbackRecords()
+	if err != nil {
+		log.Printf("Failed to retrieve fallback state: %s", err)
+	}
+	n := 0
+	for _, rec := range recs {
+		if rec.Flagged {
+			n++
+		}
+	}
+	return n
+}
+
+func FallbackGet(w http.ResponseWriter, r *http.Request) {
+	debugf("FallbackGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	flaggedOnly := false
+	if f := r.Form.Get("flagged"); f != "" {
+		var err error
+		if flaggedOnly, err = strconv.ParseBool(f); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad flagged= value '%s'", f))
+			return
+		}
+	}
+	recs, err := fallbackRecords()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve fallback state: %s", err))
+		return
+	}
+	results := []bssTypes.FallbackNode{}
+	for _, rec := range recs {
+		if rec.Flagged || !flaggedOnly {
+			results = append(results, rec)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(results)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Deleting the fallback state of a node starts its count over, for example
+// once an operator has looked into it.
+func FallbackDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("FallbackDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	names := r.Form["name"]
+	if len(names) == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	fallbackMutex.Lock()
+	defer fallbackMutex.Unlock()
+	for _, n := range strings.Split(strings.Join(names, ","), ",") {
+		if comp, ok := FindSMCompByName(n); ok {
+			n = comp.ID
+		}
+		if err := kvstore.Delete(fallbackPfx + n); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to reset fallback state for %s: %s", n, err))
+			return
+		}
+		log.Printf("/fallback DELETE: %s by %s", n, requestSubject(r))
+	}
+	w.WriteHeader(http.StatusOK)
+}
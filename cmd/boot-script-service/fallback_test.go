@@ -0,0 +1,107 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestFallback(t *testing.T) {
+	const node = "x0c0s4b0n0"
+	const tag = "Registration"
+	savedMode, savedLimit, savedTag := s3SignerMode, fallbackLimit, fallbackTag
+	s3SignerMode, fallbackLimit, fallbackTag = s3SignerMock, 2, tag
+	defer func() {
+		s3SignerMode, fallbackLimit, fallbackTag = savedMode, savedLimit, savedTag
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(paramsPfx + tag)
+		kvstore.Delete(fallbackPfx + node)
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{tag}, Params: "register",
+		Kernel: "s3://boot-images/register/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	boot := func() string {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+		return w.Body.String()
+	}
+	list := func(query string) []bssTypes.FallbackNode {
+		w := httptest.NewRecorder()
+		fallback(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/fallback"+query, nil))
+		var recs []bssTypes.FallbackNode
+		if err := json.Unmarshal(w.Body.Bytes(), &recs); err != nil {
+			t.Fatalf("GET fallback returned %d: %s", w.Code, w.Body.String())
+		}
+		return recs
+	}
+
+	if script := boot(); !strings.Contains(script, "register") {
+		t.Errorf("Fallback tag not served:\n%s", script)
+	}
+	if recs := list(""); len(recs) != 1 || recs[0].Name != node || recs[0].Boots != 1 || recs[0].Flagged {
+		t.Errorf("After one fallback boot: %v", recs)
+	}
+	if recs := list("?flagged=true"); len(recs) != 0 {
+		t.Errorf("Flagged after one fallback boot: %v", recs)
+	}
+	boot()
+	if recs := list("?flagged=true"); len(recs) != 1 || recs[0].Boots != 2 {
+		t.Errorf("Not flagged after two fallback boots: %v", recs)
+	}
+	var sb strings.Builder
+	writeMetrics(&sb)
+	if !strings.Contains(sb.String(), "bss_fallback_flagged_nodes 1\n") {
+		t.Errorf("Flagged node not counted:\n%s", sb.String())
+	}
+
+	// Booting with its own boot parameters removes the record.
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "own",
+		Kernel: "s3://boot-images/own/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if script := boot(); !strings.Contains(script, "own") {
+		t.Errorf("Own boot parameters not served:\n%s", script)
+	}
+	if recs := list(""); len(recs) != 0 {
+		t.Errorf("Record kept after booting with own boot parameters: %v", recs)
+	}
+
+	noteFallback(node, true)
+	w := httptest.NewRecorder()
+	fallback(w, httptest.NewRequest(http.MethodDelete, baseEndpoint+"/fallback?name="+node, nil))
+	if recs := list(""); w.Code != http.StatusOK || len(recs) != 0 {
+		t.Errorf("DELETE fallback returned %d, left %v", w.Code, recs)
+	}
+	w = httptest.NewRecorder()
+	fallback(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/fallback?flagged=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET fallback with a bad flagged= returned %d", w.Code)
+	}
+}
@@ -0,0 +1,114 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot parameters field selection.
+//
+// GET /bootparameters?fields=hosts,kernel returns only the named fields of
+// each entry, so that clients such as DHCP generators and dashboards do not
+// transfer cloud-init data they never look at.  The names are the JSON field
+// names of the boot parameters.  The selection is applied when the answer is
+// encoded, so it works the same whatever the datastore is.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+// The JSON field names of the boot parameters.
+var bootParamsFieldNames = func() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(bssTypes.BootParams{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// Function parseFields() returns the fields selected with fields=, or nil
+// when all of them are wanted.
+func parseFields(values []string) (map[string]bool, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	fields := make(map[string]bool)
+	var unknown []string
+	for _, f := range strings.Split(strings.Join(values, ","), ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !bootParamsFieldNames[f] {
+			unknown = append(unknown, f)
+		}
+		fields[f] = true
+	}
+	if len(unknown) > 0 {
+		var known []string
+		for n := range bootParamsFieldNames {
+			known = append(known, n)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("Unknown fields %s, known are %s",
+			strings.Join(unknown, ","), strings.Join(known, ","))
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("No fields given")
+	}
+	return fields, nil
+}
+
+// Function encodeBootParams() writes the boot parameters as JSON, with only
+// the selected fields if fields is not nil.
+func encodeBootParams(w io.Writer, results []bssTypes.BootParams, fields map[string]bool) error {
+	if fields == nil {
+		return json.NewEncoder(w).Encode(results)
+	}
+	selected := make([]map[string]json.RawMessage, 0, len(results))
+	for _, bp := range results {
+		data, err := json.Marshal(bp)
+		if err != nil {
+			return err
+		}
+		var m map[string]json.RawMessage
+		if err = json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		for k := range m {
+			if !fields[k] {
+				delete(m, k)
+			}
+		}
+		selected = append(selected, m)
+	}
+	return json.NewEncoder(w).Encode(selected)
+}
@@ -0,0 +1,78 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootParamsFields(t *testing.T) {
+	const node = "x9c0s4b0n0"
+	defer kvstore.Delete(paramsPfx + node)
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "quiet",
+		Kernel: "http://fields/kernel", Initrd: "http://fields/initrd",
+		CloudInit: bssTypes.CloudInit{UserData: bssTypes.CloudDataType{"big": "data"}}}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	get := func(query string) (int, []map[string]json.RawMessage) {
+		w := httptest.NewRecorder()
+		bootParameters(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootparameters"+query, nil))
+		var results []map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &results)
+		return w.Code, results
+	}
+
+	code, results := get("?name=" + node + "&fields=hosts,kernel")
+	if code != http.StatusOK || len(results) != 1 || len(results[0]) != 2 ||
+		string(results[0]["kernel"]) != `"http://fields/kernel"` || results[0]["hosts"] == nil {
+		t.Errorf("fields=hosts,kernel returned %d: %v", code, results)
+	}
+	code, results = get("?fields=hosts&fields=cloud-init")
+	found := false
+	for _, bp := range results {
+		for k := range bp {
+			if k != "hosts" && k != "cloud-init" {
+				t.Errorf("Unselected field %s returned", k)
+			}
+		}
+		if string(bp["hosts"]) == `["`+node+`"]` {
+			found = bp["cloud-init"] != nil
+		}
+	}
+	if code != http.StatusOK || !found {
+		t.Errorf("All boot parameters with fields= returned %d: %v", code, results)
+	}
+	if code, results = get("?name=" + node); code != http.StatusOK || len(results) != 1 || results[0]["params"] == nil {
+		t.Errorf("Without fields= returned %d: %v", code, results)
+	}
+	for _, q := range []string{"?fields=hosts,nope", "?fields=,"} {
+		if code, _ = get(q); code != http.StatusBadRequest {
+			t.Errorf("%s returned %d", q, code)
+		}
+	}
+}
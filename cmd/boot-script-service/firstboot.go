@@ -0,0 +1,228 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// First boot configuration.
+//
+// Boot parameters may carry a separate first-boot configuration, for example
+// a provisioning image.  It is used until the node phones home, at which point
+// a fingerprint of the node's boot configuration is recorded and subsequent
+// boots use the regular configuration.  Changing the boot configuration (but
+// not cloud-init data) invalidates the fingerprint, so the new configuration
+// starts with a first boot again.  The recorded state can be reset to force
+// another first boot.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const firstBootPfx = "/firstboot/"
+
+type firstBootRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	When        int64  `json:"when"`
+}
+
+func firstBootFingerprint(bds BootDataStore) string {
+	data, _ := json.Marshal(struct {
+		Params    string
+		Kernel    string
+		Initrd    string
+		FirstBoot *bssTypes.FirstBoot
+	}{bds.Params, bds.Kernel, bds.Initrd, bds.FirstBoot})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func getFirstBootRecord(name string) (firstBootRecord, bool, error) {
+	var rec firstBootRecord
+	val, exists, err := kvstore.Get(firstBootPfx + name)
+	if err == nil && exists {
+		err = json.Unmarshal([]byte(val), &rec)
+	}
+	return rec, exists, err
+}
+
+// Function firstBootPending() reports whether the host still has to boot with
+// the first boot configuration.
+func firstBootPending(name string, bds BootDataStore) bool {
+	if bds.FirstBoot == nil {
+		return false
+	}
+	rec, exists, err := getFirstBootRecord(name)
+	if err != nil {
+		log.Printf("Failed to retrieve first boot state for %s: %s", name, err)
+		return false
+	}
+	return !exists || rec.Fingerprint != firstBootFingerprint(bds)
+}
+
+func firstBootImage(path, imtype string) ImageData {
+	if key := imageFind(path, imtype); key != "" {
+		if imdata, err := getImage(key, ""); err == nil {
+			return imdata
+		}
+	}
+	return ImageData{Path: path}
+}
+
+// Function applyFirstBoot() replaces the regular configuration with any
+// fields that are set in the first boot configuration.
+func applyFirstBoot(bd *BootData) {
+	fb := bd.FirstBoot
+	if fb.Params != "" {
+		bd.Params = fb.Params
+	}
+	if fb.Kernel != "" {
+		bd.Kernel = firstBootImage(fb.Kernel, kernelImageType)
+	}
+	if fb.Initrd != "" {
+		bd.Initrd = firstBootImage(fb.Initrd, initrdImageType)
+	}
+	if fb.CloudInit.MetaData != nil {
+		bd.CloudInit.MetaData = fb.CloudInit.MetaData
+	}
+	if fb.CloudInit.UserData != nil {
+		bd.CloudInit.UserData = fb.CloudInit.UserData
+	}
+}
+
+// The first boot images are stored by path in the boot parameters, but are
+// also registered as images so they can be found by the artifact proxy.
+func storeFirstBootImages(fb *bssTypes.FirstBoot) error {
+	if fb == nil {
+		return nil
+	}
+	if fb.Kernel != "" && imageStore(fb.Kernel, kernelImageType) == "" {
+		return fmt.Errorf("Cannot store image path %s", fb.Kernel)
+	}
+	if fb.Initrd != "" && imageStore(fb.Initrd, initrdImageType) == "" {
+		return fmt.Errorf("Cannot store image path %s", fb.Initrd)
+	}
+	return nil
+}
+
+// Function markFirstBootDone() records that the host has booted successfully
+// with its current configuration.  It is called on phone-home.
+func markFirstBootDone(comp SMComponent, name string) {
+	bds, err := lookupStore(comp.ID, name, comp.Role, DefaultTag)
+	if err != nil || bds.FirstBoot == nil {
+		return
+	}
+	if comp.ID != "" {
+		name = comp.ID
+	}
+	rec := firstBootRecord{firstBootFingerprint(bds), time.Now().Unix()}
+	if err = storeData(firstBootPfx+name, rec); err != nil {
+		log.Printf("Failed to store first boot state for %s: %s", name, err)
+		return
+	}
+	log.Printf("First boot of %s completed", name)
+}
+
+func firstBootState(name string) (bssTypes.FirstBootState, error) {
+	state := bssTypes.FirstBootState{Name: name}
+	comp, ok := FindSMCompByName(name)
+	if ok {
+		state.Name = comp.ID
+	}
+	rec, exists, err := getFirstBootRecord(state.Name)
+	if err != nil || !exists {
+		return state, err
+	}
+	state.When = rec.When
+	bds, err := lookupStore(state.Name, name, comp.Role, DefaultTag)
+	if err == nil {
+		state.Done = !firstBootPending(state.Name, bds)
+	}
+	return state, nil
+}
+
+func FirstBootGet(w http.ResponseWriter, r *http.Request) {
+	debugf("FirstBootGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	names := r.Form["name"]
+	if len(names) == 0 {
+		kvl, err := kvstore.GetRange(firstBootPfx+keyMin, firstBootPfx+keyMax)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to retrieve first boot state: %s", err))
+			return
+		}
+		for _, kv := range kvl {
+			names = append(names, strings.TrimPrefix(kv.Key, firstBootPfx))
+		}
+	} else {
+		names = strings.Split(strings.Join(names, ","), ",")
+	}
+	results := []bssTypes.FirstBootState{}
+	for _, n := range names {
+		state, err := firstBootState(n)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to retrieve first boot state for %s: %s", n, err))
+			return
+		}
+		results = append(results, state)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(results)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Deleting the first boot state makes the next boot of the host a first boot.
+func FirstBootDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("FirstBootDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	names := r.Form["name"]
+	if len(names) == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	for _, n := range strings.Split(strings.Join(names, ","), ",") {
+		if comp, ok := FindSMCompByName(n); ok {
+			n = comp.ID
+		}
+		if err := kvstore.Delete(firstBootPfx + n); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to reset first boot state for %s: %s", n, err))
+			return
+		}
+		log.Printf("/firstboot DELETE: %s", n)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,86 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestFirstBoot(t *testing.T) {
+	const host = "x0c0s18b0n0"
+	bp := bssTypes.BootParams{
+		Hosts:  []string{host},
+		Params: "steady",
+		Kernel: "/steady/vmlinuz",
+		FirstBoot: &bssTypes.FirstBoot{
+			Params: "provision",
+			Kernel: "/provision/vmlinuz",
+		},
+	}
+	if err, _ := Store(bp, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	defer func() {
+		Remove(bssTypes.BootParams{Hosts: []string{host}}, deleteOrphan)
+		kvstore.Delete(firstBootPfx + host)
+		kvstore.Delete(imageFind("/steady/vmlinuz", kernelImageType))
+		kvstore.Delete(imageFind("/provision/vmlinuz", kernelImageType))
+	}()
+
+	bd, comp := LookupByName(host)
+	if bd.Params != "provision" || bd.Kernel.Path != "/provision/vmlinuz" {
+		t.Errorf("First boot returned params '%s' kernel '%s'", bd.Params, bd.Kernel.Path)
+	}
+
+	markFirstBootDone(comp, host)
+	bd, _ = LookupByName(host)
+	if bd.Params != "steady" || bd.Kernel.Path != "/steady/vmlinuz" {
+		t.Errorf("Subsequent boot returned params '%s' kernel '%s'", bd.Params, bd.Kernel.Path)
+	}
+	if state, err := firstBootState(host); err != nil || !state.Done {
+		t.Errorf("First boot state is %v, %v; expected done", state, err)
+	}
+
+	// A configuration change starts over with a first boot
+	if err := Update(bssTypes.BootParams{Hosts: []string{host}, Params: "steady2"}, "test"); err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+	if bd, _ = LookupByName(host); bd.Params != "provision" {
+		t.Errorf("Boot after configuration change returned params '%s'", bd.Params)
+	}
+
+	markFirstBootDone(comp, host)
+	req := httptest.NewRequest(http.MethodDelete, baseEndpoint+"/firstboot?name="+host, nil)
+	rr := httptest.NewRecorder()
+	firstBoot(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("DELETE firstboot returned %d: %s", rr.Code, rr.Body)
+	}
+	if bd, _ = LookupByName(host); bd.Params != "provision" {
+		t.Errorf("Boot after reset returned params '%s'", bd.Params)
+	}
+}
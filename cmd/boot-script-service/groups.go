@@ -0,0 +1,90 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Node groups.
+//
+// Several APIs operate on a group of nodes.  BSS already uses HSM roles as
+// boot parameter tags, so a group is named by an HSM role (or role/subrole).
+// A node xname is also accepted as a group of one, and "all" selects every
+// node known to HSM.
+
+package main
+
+import (
+	"strings"
+)
+
+const allGroup = "all"
+
+func inGroup(comp SMComponent, group string) bool {
+	if strings.EqualFold(group, allGroup) || comp.ID == group {
+		return true
+	}
+	role, subRole, hasSub := strings.Cut(group, "/")
+	if !strings.EqualFold(comp.Role, role) {
+		return false
+	}
+	return !hasSub || strings.EqualFold(comp.SubRole, subRole)
+}
+
+// Function groupMembers() returns the components in the named group.
+func groupMembers(group string) []SMComponent {
+	var members []SMComponent
+	state := getState()
+	if state == nil || group == "" {
+		return members
+	}
+	for _, c := range state.Components {
+		if inGroup(c, group) {
+			members = append(members, c)
+		}
+	}
+	return members
+}
+
+// Function roleMembers() returns the IDs of the components with one of the
+// roles and one of the subroles (any if none are given).
+func roleMembers(roles, subRoles []string) []string {
+	var ids []string
+	match := func(v string, list []string) bool {
+		if len(list) == 0 {
+			return true
+		}
+		for _, l := range list {
+			if strings.EqualFold(v, l) {
+				return true
+			}
+		}
+		return false
+	}
+	state := getState()
+	if state == nil {
+		return ids
+	}
+	for _, c := range state.Components {
+		if match(c.Role, roles) && match(c.SubRole, subRoles) {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
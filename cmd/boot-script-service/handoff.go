@@ -0,0 +1,205 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Artifact hand-off metadata for large boots.
+//
+// When thousands of nodes reboot at the same time they all download the same
+// kernel and initrd images.  The hand-off API summarizes, for a group of
+// nodes, which artifacts will be fetched, how large they are, and how many
+// nodes will fetch each of them, along with HTTP range chunking hints and any
+// peers offered by a configured seeder service.  Multicast or peer-to-peer
+// distribution tooling can use this to take load off the object store.
+//
+// The seeder service is expected to answer GET <seeder>/peers?url=<artifact>
+// with a JSON list of peer URLs from which the artifact can be fetched.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+var handoffSeeder = ""
+var handoffChunkMB = "64"
+
+var handoffClient = &http.Client{Timeout: 10 * time.Second}
+
+type handoffArtifact struct {
+	Type      string   `json:"type"`
+	Path      string   `json:"path"`
+	URL       string   `json:"url"`
+	Size      int64    `json:"size"`
+	Nodes     int      `json:"nodes"`
+	ChunkSize int64    `json:"chunk-size,omitempty"`
+	Chunks    int64    `json:"chunks,omitempty"`
+	Peers     []string `json:"peers,omitempty"`
+}
+
+type handoffReport struct {
+	Group     string            `json:"group"`
+	Nodes     int               `json:"nodes"`
+	Seeder    string            `json:"seeder,omitempty"`
+	Artifacts []handoffArtifact `json:"artifacts"`
+}
+
+// Function artifactSize() determines the size of the artifact at the given
+// URL.  A single byte range is requested rather than using HEAD so this also
+// works with presigned S3 URLs, which are only valid for GET.
+func artifactSize(u string) (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	base.SetHTTPUserAgent(req, serviceName)
+	rsp, err := handoffClient.Do(req)
+	if err != nil {
+		return -1, err
+	}
+	rsp.Body.Close()
+	switch rsp.StatusCode {
+	case http.StatusPartialContent:
+		cr := rsp.Header.Get("Content-Range")
+		if n := strings.LastIndex(cr, "/"); n >= 0 {
+			return strconv.ParseInt(cr[n+1:], 10, 64)
+		}
+		return -1, fmt.Errorf("Unexpected Content-Range '%s' from %s", cr, u)
+	case http.StatusOK:
+		return rsp.ContentLength, nil
+	}
+	return -1, fmt.Errorf("Size request for %s failed: %s", u, rsp.Status)
+}
+
+func seederPeers(u string) ([]string, error) {
+	var peers []string
+	if handoffSeeder == "" {
+		return peers, nil
+	}
+	req, err := http.NewRequest(http.MethodGet,
+		strings.TrimRight(handoffSeeder, "/")+"/peers?url="+url.QueryEscape(u), nil)
+	if err != nil {
+		return peers, err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	rsp, err := handoffClient.Do(req)
+	if err != nil {
+		return peers, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return peers, fmt.Errorf("Seeder request failed: %s", rsp.Status)
+	}
+	err = json.NewDecoder(rsp.Body).Decode(&peers)
+	return peers, err
+}
+
+func artifactFetchURL(imtype, path string) (string, error) {
+	if artifactProxy {
+		return artifactProxyURL(imtype, path), nil
+	}
+	return checkURL(path)
+}
+
+func buildHandoffReport(group string) handoffReport {
+	report := handoffReport{Group: group, Seeder: handoffSeeder, Artifacts: []handoffArtifact{}}
+	chunkMB, err := strconv.ParseInt(handoffChunkMB, 10, 64)
+	if err != nil || chunkMB <= 0 {
+		log.Printf("Invalid BSS_HANDOFF_CHUNK_SIZE '%s', not providing range hints", handoffChunkMB)
+		chunkMB = 0
+	}
+
+	counts := make(map[string]map[string]int)
+	counts[kernelImageType] = make(map[string]int)
+	counts[initrdImageType] = make(map[string]int)
+	members := groupMembers(group)
+	report.Nodes = len(members)
+	for _, comp := range members {
+		bd, _ := LookupByName(comp.ID)
+		if bd.Kernel.Path != "" {
+			counts[kernelImageType][bd.Kernel.Path]++
+		}
+		if bd.Initrd.Path != "" {
+			counts[initrdImageType][bd.Initrd.Path]++
+		}
+	}
+
+	for _, imtype := range []string{kernelImageType, initrdImageType} {
+		for path, n := range counts[imtype] {
+			a := handoffArtifact{Type: imtype, Path: path, Nodes: n, Size: -1}
+			if a.URL, err = artifactFetchURL(imtype, path); err != nil {
+				log.Printf("Hand-off: cannot resolve %s: %s", path, err)
+			} else if a.Size, err = artifactSize(a.URL); err != nil {
+				log.Printf("Hand-off: cannot determine size of %s: %s", path, err)
+			}
+			if a.Size > 0 && chunkMB > 0 {
+				a.ChunkSize = chunkMB << 20
+				a.Chunks = (a.Size + a.ChunkSize - 1) / a.ChunkSize
+			}
+			if a.URL != "" {
+				if a.Peers, err = seederPeers(a.URL); err != nil {
+					log.Printf("Hand-off: seeder peer lookup for %s failed: %s", path, err)
+				}
+			}
+			report.Artifacts = append(report.Artifacts, a)
+		}
+	}
+	sort.Slice(report.Artifacts, func(i, j int) bool {
+		if report.Artifacts[i].Type != report.Artifacts[j].Type {
+			return report.Artifacts[i].Type == kernelImageType
+		}
+		return report.Artifacts[i].Path < report.Artifacts[j].Path
+	})
+	return report
+}
+
+func handoffGetAPI(w http.ResponseWriter, r *http.Request) {
+	debugf("handoffGetAPI(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	group := strings.Join(r.Form["group"], "")
+	if group == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a group= parameter")
+		return
+	}
+	report := buildHandoffReport(group)
+	if report.Nodes == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No nodes in group '%s'", group))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(report)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,118 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroupMembers(t *testing.T) {
+	compute := groupMembers("compute")
+	if len(compute) == 0 {
+		t.Fatal("No members found for group 'compute'")
+	}
+	for _, c := range compute {
+		if c.Role != "Compute" {
+			t.Errorf("Group 'compute' contains %s with role %s", c.ID, c.Role)
+		}
+	}
+	if m := groupMembers("x0c0s1b0n0"); len(m) != 1 || m[0].ID != "x0c0s1b0n0" {
+		t.Errorf("Group 'x0c0s1b0n0' returned %v", m)
+	}
+	if m := groupMembers(allGroup); len(m) < len(compute) {
+		t.Errorf("Group '%s' has fewer members than 'compute'", allGroup)
+	}
+	if m := groupMembers("no-such-role"); len(m) != 0 {
+		t.Errorf("Group 'no-such-role' returned %d members", len(m))
+	}
+}
+
+func TestHandoffArtifactSize(t *testing.T) {
+	image := bytes.Repeat([]byte("x"), 12345)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "kernel", time.Time{}, bytes.NewReader(image))
+	}))
+	defer upstream.Close()
+
+	size, err := artifactSize(upstream.URL + "/kernel")
+	if err != nil {
+		t.Fatalf("artifactSize failed: %s", err)
+	}
+	if size != int64(len(image)) {
+		t.Errorf("artifactSize returned %d, expected %d", size, len(image))
+	}
+}
+
+func TestHandoffSeederPeers(t *testing.T) {
+	seeder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/peers" || r.URL.Query().Get("url") != "http://s3/kernel" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode([]string{"http://peer1/kernel", "http://peer2/kernel"})
+	}))
+	defer seeder.Close()
+	saved := handoffSeeder
+	handoffSeeder = seeder.URL
+	defer func() { handoffSeeder = saved }()
+
+	peers, err := seederPeers("http://s3/kernel")
+	if err != nil {
+		t.Fatalf("seederPeers failed: %s", err)
+	}
+	if len(peers) != 2 || peers[0] != "http://peer1/kernel" {
+		t.Errorf("seederPeers returned unexpected peers %v", peers)
+	}
+
+	script, err := buildBootScript(BootData{Kernel: ImageData{Path: "http://s3/kernel"}},
+		scriptParams{xname: "x0c0s1b0n0"}, "", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	if !strings.Contains(script, "set bss-seeder "+seeder.URL+"\n") {
+		t.Errorf("Boot script is missing the seeder hint:\n%s", script)
+	}
+}
+
+func TestHandoffGetErrors(t *testing.T) {
+	for _, tc := range []struct {
+		query  string
+		status int
+	}{
+		{"", http.StatusBadRequest},
+		{"?group=no-such-role", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/handoff"+tc.query, nil)
+		rr := httptest.NewRecorder()
+		handoff(rr, req)
+		if rr.Code != tc.status {
+			t.Errorf("GET handoff%s returned %d, expected %d", tc.query, rr.Code, tc.status)
+		}
+	}
+}
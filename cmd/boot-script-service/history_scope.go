@@ -0,0 +1,119 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Endpoint history visibility.
+//
+// /boot/v1/endpoint-history tells when each node last fetched its boot
+// script and cloud-init data.  Callers with an admin role see all of it.
+// A token role listed in --history-tenant-roles as role=group, the group
+// as in groups.go, only sees the nodes of its groups.  Callers with a role
+// in --history-readonly-roles get the times rounded down to
+// --history-coarse-seconds, and so does any other caller that is not an
+// admin: a token without a mapped role, or no token at all.  The roles are
+// only as good as the token, so run with --jwks-url or behind a gateway
+// that verifies it.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+var (
+	historyTenantRoles   []string // role=group
+	historyReadonlyRoles []string
+	historyCoarseSeconds = uint(3600)
+)
+
+// What a caller may see of the endpoint history.  Without groups every node
+// is visible; coarse is the rounding of the times in seconds, 0 for exact.
+type historyScope struct {
+	groups []string
+	coarse int64
+}
+
+// Function historyScopeOf() returns the endpoint history scope of the
+// caller of a request.
+func historyScopeOf(r *http.Request) historyScope {
+	var scope historyScope
+	claims, _ := requestClaims(r)
+	for _, role := range claims.RealmAccess.Roles {
+		for _, admin := range adminRoles {
+			if role == admin {
+				return historyScope{}
+			}
+		}
+	}
+	readonly := false
+	for _, role := range claims.RealmAccess.Roles {
+		for _, tr := range historyTenantRoles {
+			if name, group, _ := strings.Cut(tr, "="); name == role {
+				scope.groups = append(scope.groups, group)
+			}
+		}
+		for _, ro := range historyReadonlyRoles {
+			if role == ro {
+				readonly = true
+			}
+		}
+	}
+	if readonly || scope.groups == nil {
+		scope.coarse = int64(historyCoarseSeconds)
+	}
+	return scope
+}
+
+func (scope historyScope) visible(name string) bool {
+	if scope.groups == nil {
+		return true
+	}
+	comp, ok := FindSMCompByNameInCache(name)
+	if !ok {
+		return false
+	}
+	for _, g := range scope.groups {
+		if inGroup(comp, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function apply() drops the accesses of the nodes the scope does not see
+// and rounds the times of the others.
+func (scope historyScope) apply(accesses []bssTypes.EndpointAccess) []bssTypes.EndpointAccess {
+	var ret []bssTypes.EndpointAccess
+	for _, a := range accesses {
+		if !scope.visible(a.Name) {
+			continue
+		}
+		if scope.coarse > 0 {
+			a.LastEpoch -= a.LastEpoch % scope.coarse
+		}
+		ret = append(ret, a)
+	}
+	return ret
+}
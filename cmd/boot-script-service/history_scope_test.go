@@ -0,0 +1,89 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestEndpointHistoryScope(t *testing.T) {
+	const compute, other = "x0c0s2b0n0", "x0c0s3b0n0"
+	savedTenants, savedReadonly := historyTenantRoles, historyReadonlyRoles
+	for _, name := range []string{compute, other} {
+		kvstore.Store(endpointAccessPfx+"/"+name+"/bootscript", "1791987100")
+	}
+	defer func() {
+		historyTenantRoles, historyReadonlyRoles = savedTenants, savedReadonly
+		for _, name := range []string{compute, other} {
+			kvstore.Delete(endpointAccessPfx + "/" + name + "/bootscript")
+		}
+	}()
+	historyTenantRoles = []string{"tenant-a=Compute"}
+	historyReadonlyRoles = []string{"monitor"}
+	get := func(roles, query string) map[string]int64 {
+		r := httptest.NewRequest(http.MethodGet, baseEndpoint+"/endpoint-history"+query, nil)
+		if roles != "" {
+			r.Header.Set("Authorization", testToken(`{"sub":"jdoe","realm_access":{"roles":[`+roles+`]}}`))
+		}
+		w := httptest.NewRecorder()
+		endpointHistoryGetAPI(w, r)
+		var accesses []bssTypes.EndpointAccess
+		if err := json.Unmarshal(w.Body.Bytes(), &accesses); err != nil {
+			t.Fatalf("GET returned %d: %s", w.Code, w.Body.String())
+		}
+		ret := map[string]int64{}
+		for _, a := range accesses {
+			if a.Name == compute || a.Name == other {
+				ret[a.Name] = a.LastEpoch
+			}
+		}
+		return ret
+	}
+
+	if got := get(`"admin","monitor"`, ""); len(got) != 2 || got[compute] != 1791987100 {
+		t.Errorf("Admins should see everything exactly, got %v", got)
+	}
+	if got := get(`"tenant-a"`, ""); len(got) != 1 || got[compute] != 1791987100 {
+		t.Errorf("Tenants should only see their nodes, got %v", got)
+	}
+	if got := get(`"tenant-a"`, "?name="+other); len(got) != 0 {
+		t.Errorf("Tenants should not see other nodes by name, got %v", got)
+	}
+	if got := get(`"monitor"`, ""); len(got) != 2 || got[other] != 1791986400 {
+		t.Errorf("Read-only roles should see coarse times, got %v", got)
+	}
+	if got := get(`"tenant-a","monitor"`, "?name="+compute+"&endpoint=bootscript"); got[compute] != 1791986400 {
+		t.Errorf("Read-only tenants should see coarse times of their nodes, got %v", got)
+	}
+	if got := get(`"operator"`, ""); len(got) != 2 || got[compute] != 1791986400 {
+		t.Errorf("Unmapped roles should see coarse times, got %v", got)
+	}
+	if got := get("", ""); len(got) != 2 || got[compute] != 1791986400 {
+		t.Errorf("Callers without a token should see coarse times, got %v", got)
+	}
+}
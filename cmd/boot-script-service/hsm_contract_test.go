@@ -0,0 +1,149 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// HSM contract tests.
+//
+// testdata/hsm holds HSM v2 responses recorded for the three requests BSS
+// makes, trimmed to a few nodes with the odd cases seen on real systems:
+// NICs with no MAC or "Not Available", an ff:ff:ff:ff:ff:ff endpoint MAC, a
+// MAC without colons, a disabled endpoint, an endpoint without Enabled, a
+// node with no endpoint, IPv6 addresses and an interface of a component HSM
+// did not list.  When HSM payloads change, record them here again.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func hsmFixtureServer(t *testing.T) *httptest.Server {
+	fixtures := map[string]string{
+		"/hsm/v2/State/Components":             "components.json",
+		"/hsm/v2/Inventory/ComponentEndpoints": "component_endpoints.json",
+		"/hsm/v2/Inventory/EthernetInterfaces": "ethernet_interfaces.json",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("type") != "Node" {
+			t.Errorf("%s requested without type=Node", r.URL)
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "hsm", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+}
+
+func TestHSMContract(t *testing.T) {
+	srv := hsmFixtureServer(t)
+	defer srv.Close()
+	savedClient, savedURL, savedNotifier := smClient, smBaseURL, notifier
+	defer func() {
+		smClient, smBaseURL, notifier = savedClient, savedURL, savedNotifier
+		hsmLastSections.ep, hsmLastSections.mep, hsmLastSections.ifaces = nil, nil, nil
+	}()
+	smClient, smBaseURL = srv.Client(), srv.URL+"/hsm/v2"
+	notifier = newNotifier(serviceName, srv.URL+"/hmi/v1/subscribe", "http://bss", "")
+	hsmLastSections.ep, hsmLastSections.mep, hsmLastSections.ifaces = nil, nil, nil
+
+	state := getStateFromHSM()
+	if state == nil {
+		t.Fatal("getStateFromHSM failed on the recorded responses")
+	}
+	comps := make(map[string]SMComponent)
+	for _, c := range state.Components {
+		comps[c.ID] = c
+	}
+
+	tests := []struct {
+		id      string
+		role    string
+		nid     string
+		fqdn    string
+		enabled bool
+		macs    []string
+	}{
+		// The endpoint MAC, the other NIC and the interface MAC again.
+		{"x9c1s0b0n0", "Compute", "100", "x9c1s0b0n0.hmn", true,
+			[]string{"b4:2e:99:3b:70:10", "b4:2e:99:3b:70:11", "b4:2e:99:3b:70:10"}},
+		// A disabled endpoint, with the interface MAC given without colons.
+		{"x9c1s1b0n0", "Compute", "101", "x9c1s1b0n0.hmn", false,
+			[]string{"b4:2e:99:3b:71:20", "b4:2e:99:3b:71:22"}},
+		// No Enabled is enabled; ff:ff:ff:ff:ff:ff and empty NIC MACs are
+		// dropped, an empty interface MAC becomes "not available".
+		{"x9c1s2b0n0", "Application", "102", "x9c1s2b0n0.hmn", true,
+			[]string{badMAC}},
+		// No endpoint at all.
+		{"x9c1s3b0n0", "Management", "103", "", false,
+			[]string{"b4:2e:99:3b:73:30"}},
+	}
+	if len(comps) != len(tests) {
+		t.Errorf("Got %d components, want %d", len(comps), len(tests))
+	}
+	for _, tt := range tests {
+		c, ok := comps[tt.id]
+		if !ok {
+			t.Errorf("%s missing", tt.id)
+			continue
+		}
+		if c.Role != tt.role || c.NID.String() != tt.nid || c.Fqdn != tt.fqdn || c.EndpointEnabled != tt.enabled {
+			t.Errorf("%s: role %s, nid %s, fqdn %s, enabled %t, want %s, %s, %s, %t",
+				tt.id, c.Role, c.NID, c.Fqdn, c.EndpointEnabled, tt.role, tt.nid, tt.fqdn, tt.enabled)
+		}
+		if !reflect.DeepEqual(c.Mac, tt.macs) {
+			t.Errorf("%s: MACs %v, want %v", tt.id, c.Mac, tt.macs)
+		}
+	}
+
+	// Every non-empty address maps to its interface, IPv6 included, also for
+	// components HSM did not list.
+	addrs := map[string]string{
+		"10.252.9.10":               "x9c1s0b0n0",
+		"fd66:0:0:9::10":            "x9c1s0b0n0",
+		"10.252.9.12":               "x9c1s2b0n0",
+		"fe80::b62e:99ff:fe3b:7330": "x9c1s3b0n0",
+		"10.252.9.19":               "x9c1s9b0n0",
+	}
+	if len(state.IPAddrs) != len(addrs) {
+		t.Errorf("Got %d IP addresses, want %d: %v", len(state.IPAddrs), len(addrs), state.IPAddrs)
+	}
+	for ip, id := range addrs {
+		if got := state.IPAddrs[ip].CompID; got != id {
+			t.Errorf("%s maps to '%s', want %s", ip, got, id)
+		}
+	}
+	if e := state.IPAddrs["10.252.9.10"]; e.MACAddr != "b4:2e:99:3b:70:10" || len(e.IPAddrs) != 2 || e.IPAddrs[0].Network != "NMN" {
+		t.Errorf("Interface of 10.252.9.10 not kept as recorded: %+v", e)
+	}
+}
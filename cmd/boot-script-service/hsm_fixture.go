@@ -0,0 +1,234 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// HSM test nodes.
+//
+// To try out a change to the HSM data, such as a new parser, on a live
+// system without the risk of breaking every node, an admin can mark single
+// nodes by MAC address as test nodes under /boot/v1/service/hsm-test-nodes.
+// MAC lookups of a test node use the HSM fixture named by --hsm-test-source
+// (mem: or file:<path>, in the --hsm formats) instead of the live HSM, while
+// the rest of the system carries on as before.  Without a source the
+// mechanism is off.  The fixture is read again whenever a test node is set.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const hsmTestNodesPfx = "/hsm-test-nodes/"
+
+var hsmTestSource = "" // mem: or file:<path>, empty disables test nodes
+
+var hsmFixture struct {
+	sync.Mutex
+	macMap map[string]SMComponent
+}
+
+// Function readHSMFixture() reads the HSM data of a mem: or file: source.
+func readHSMFixture(source string) (*SMData, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	switch u.Scheme {
+	case "mem":
+		data = []byte(state_manager_data_temp)
+	case "file":
+		if data, err = os.ReadFile(u.Path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a mem: or file: HSM source", source)
+	}
+	var state SMData
+	if err = json.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return nil, fmt.Errorf("%s: %s", source, err)
+	}
+	return &state, nil
+}
+
+func loadHSMFixture() (map[string]SMComponent, error) {
+	state, err := readHSMFixture(hsmTestSource)
+	if err != nil {
+		return nil, err
+	}
+	macMap := makeSmMacMap(state)
+	hsmFixture.Lock()
+	hsmFixture.macMap = macMap
+	hsmFixture.Unlock()
+	return macMap, nil
+}
+
+// Function testNodeCompByMAC() looks a test node up in the HSM fixture.  The
+// last result is false if the MAC is not a test node.
+func testNodeCompByMAC(mac string) (SMComponent, bool, bool) {
+	if hsmTestSource == "" || kvstore == nil {
+		return SMComponent{}, false, false
+	}
+	mac = strings.ToLower(mac)
+	if _, exists, err := kvstore.Get(hsmTestNodesPfx + mac); err != nil || !exists {
+		return SMComponent{}, false, false
+	}
+	hsmFixture.Lock()
+	macMap := hsmFixture.macMap
+	hsmFixture.Unlock()
+	if macMap == nil {
+		var err error
+		if macMap, err = loadHSMFixture(); err != nil {
+			log.Printf("WARNING: Cannot read the HSM fixture for test node %s: %s", mac, err)
+			return SMComponent{}, false, true
+		}
+	}
+	comp, ok := macMap[mac]
+	return comp, ok, true
+}
+
+func getHSMTestNodes() ([]bssTypes.HSMTestNode, error) {
+	kvl, err := kvstore.GetRange(hsmTestNodesPfx+keyMin, hsmTestNodesPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	ret := []bssTypes.HSMTestNode{}
+	for _, kv := range kvl {
+		var n bssTypes.HSMTestNode
+		if json.Unmarshal([]byte(kv.Value), &n) == nil {
+			ret = append(ret, n)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].MAC < ret[j].MAC })
+	return ret, nil
+}
+
+func sendHSMTestNodes(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Function hsmTestNodeMAC() returns the lower case MAC of the request, or
+// sends an error response.
+func hsmTestNodeMAC(w http.ResponseWriter, mac string) (string, bool) {
+	if hsmTestSource == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusConflict,
+			"Conflict - HSM test nodes need --hsm-test-source")
+		return "", false
+	}
+	if _, err := net.ParseMAC(mac); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - Need a MAC address: %s", err))
+		return "", false
+	}
+	return strings.ToLower(mac), true
+}
+
+func HSMTestNodesGet(w http.ResponseWriter, r *http.Request) {
+	debugf("HSMTestNodesGet(): Received request %v\n", r.URL)
+	nodes, err := getHSMTestNodes()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read HSM test nodes: %s", err))
+		return
+	}
+	sendHSMTestNodes(w, http.StatusOK, nodes)
+}
+
+func HSMTestNodesPut(w http.ResponseWriter, r *http.Request) {
+	debugf("HSMTestNodesPut(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var n bssTypes.HSMTestNode
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	mac, ok := hsmTestNodeMAC(w, n.MAC)
+	if !ok {
+		return
+	}
+	macMap, err := loadHSMFixture()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read the HSM fixture: %s", err))
+		return
+	}
+	comp, found := macMap[mac]
+	if !found {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - %s is not in the HSM fixture %s", mac, hsmTestSource))
+		return
+	}
+	n = bssTypes.HSMTestNode{MAC: mac, Source: hsmTestSource, Component: comp.ID,
+		AddedBy: requestSubject(r), AddedAt: time.Now().Unix()}
+	if err = storeData(hsmTestNodesPfx+mac, n); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot store the HSM test node: %s", err))
+		return
+	}
+	log.Printf("HSM test node %s (%s in %s) set by %s", mac, comp.ID, hsmTestSource, n.AddedBy)
+	sendHSMTestNodes(w, http.StatusOK, n)
+}
+
+func HSMTestNodesDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("HSMTestNodesDelete(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	mac, ok := hsmTestNodeMAC(w, strings.Join(r.Form["mac"], ""))
+	if !ok {
+		return
+	}
+	if _, exists, _ := kvstore.Get(hsmTestNodesPfx + mac); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s is not an HSM test node", mac))
+		return
+	}
+	if err := kvstore.Delete(hsmTestNodesPfx + mac); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot delete the HSM test node: %s", err))
+		return
+	}
+	log.Printf("HSM test node %s removed by %s", mac, requestSubject(r))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,89 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestHSMTestNodes(t *testing.T) {
+	const mac, live = "02:00:00:00:0F:01", "00:1e:67:e3:46:51"
+	fixture := filepath.Join(t.TempDir(), "hsm.json")
+	os.WriteFile(fixture, []byte(`{"Components":[{"ID":"x9c1s5b0n0","Type":"Node","Role":"Compute",
+		"NID":905,"MAC":["02:00:00:00:0f:01"],"EndpointEnabled":true}]}`), 0600)
+	saved := hsmTestSource
+	defer func() {
+		hsmTestSource = saved
+		hsmFixture.macMap = nil
+		kvstore.Delete(hsmTestNodesPfx + strings.ToLower(mac))
+	}()
+	call := func(method, query, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, baseEndpoint+"/service/hsm-test-nodes"+query, strings.NewReader(body))
+		r.Header.Set("Authorization", testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`))
+		w := httptest.NewRecorder()
+		serviceHSMTestNodes(w, r)
+		return w
+	}
+
+	hsmTestSource = ""
+	if w := call(http.MethodPut, "", `{"mac":"`+mac+`"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected test nodes to be off without a source, got %d", w.Code)
+	}
+	hsmTestSource = "file:" + fixture
+	if w := call(http.MethodPut, "", `{"mac":"02:00:00:00:0f:99"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a MAC the fixture does not have to be refused, got %d", w.Code)
+	}
+	if _, ok := FindSMCompByMAC(mac); ok {
+		t.Fatalf("%s found before it is a test node", mac)
+	}
+	w := call(http.MethodPut, "", `{"mac":"`+mac+`"}`)
+	var n bssTypes.HSMTestNode
+	json.Unmarshal(w.Body.Bytes(), &n)
+	if w.Code != http.StatusOK || n.Component != "x9c1s5b0n0" || n.AddedBy != "jdoe" {
+		t.Fatalf("PUT returned %d: %s", w.Code, w.Body.String())
+	}
+	if comp, ok := FindSMCompByMAC(mac); !ok || comp.ID != "x9c1s5b0n0" || comp.Role != "Compute" {
+		t.Errorf("Test node not looked up in the fixture: %+v %t", comp, ok)
+	}
+	if comp, ok := FindSMCompByMAC(live); !ok || comp.ID != "x0c0s1b0n0" {
+		t.Errorf("Other MACs should use the live HSM: %+v %t", comp, ok)
+	}
+	if w = call(http.MethodGet, "", ""); !strings.Contains(w.Body.String(), `"mac":"02:00:00:00:0f:01"`) {
+		t.Errorf("GET returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if w = call(http.MethodDelete, "?mac="+mac, ""); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE returned %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := FindSMCompByMAC(mac); ok {
+		t.Errorf("%s still found after it was removed", mac)
+	}
+}
@@ -0,0 +1,122 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Identification of the caller making an API request.
+//
+// BSS sits behind the API gateway which is responsible for validating the
+// bearer tokens presented by clients.  The admin roles and the subjects the
+// approval of proposals depends on come from the token, so a deployment
+// where clients can reach BSS without going through the gateway must set
+// BSS_JWKS_URL to have BSS verify the tokens itself (see jwks.go).
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const unknownSubject = "unknown"
+
+// Callers holding one of these realm roles may change service settings.
+var adminRoles = []string{"admin"}
+
+type tokenClaims struct {
+	Subject     string `json:"sub"`
+	Expires     int64  `json:"exp"`
+	RealmAccess struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+}
+
+// Function requestClaims() decodes the payload of the bearer token in the
+// Authorization header of the request.  The token signature is only checked
+// with BSS_JWKS_URL set; unsigned tokens are always refused.
+func requestClaims(r *http.Request) (tokenClaims, bool) {
+	var claims tokenClaims
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return claims, false
+	}
+	parts := strings.Split(strings.TrimSpace(auth[7:]), ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, false
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	if err = verifyTokenSignature(parts); err != nil {
+		debugf("Bearer token refused: %s", err)
+		return tokenClaims{}, false
+	}
+	if jwksURL != "" && claims.Expires != 0 && claims.Expires <= time.Now().Unix() {
+		debugf("Bearer token of %s expired", claims.Subject)
+		return tokenClaims{}, false
+	}
+	return claims, true
+}
+
+// Function requestSubject() returns the subject of the bearer token for the
+// request, or "unknown" if there is no usable token.
+func requestSubject(r *http.Request) string {
+	claims, ok := requestClaims(r)
+	if !ok || claims.Subject == "" {
+		return unknownSubject
+	}
+	return claims.Subject
+}
+
+// Function requestAdmin() checks that the bearer token for the request
+// carries one of the admin roles.  If not, it sends the error response and
+// returns false.
+func requestAdmin(w http.ResponseWriter, r *http.Request) bool {
+	claims, ok := requestClaims(r)
+	if !ok {
+		auditEmit(auditAuthFailure, "", findRemoteAddr(r), r.URL.Path, "%s without a bearer token", r.Method)
+		base.SendProblemDetailsGeneric(w, http.StatusUnauthorized,
+			"A bearer token is required")
+		return false
+	}
+	for _, role := range claims.RealmAccess.Roles {
+		for _, admin := range adminRoles {
+			if role == admin {
+				return true
+			}
+		}
+	}
+	auditEmit(auditAuthFailure, claims.Subject, findRemoteAddr(r), r.URL.Path,
+		"%s without an admin role", r.Method)
+	base.SendProblemDetailsGeneric(w, http.StatusForbidden,
+		fmt.Sprintf("One of the roles %v is required", adminRoles))
+	return false
+}
@@ -0,0 +1,322 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Image digests.
+//
+// Image keys are a hash of the path, so replacing the image behind a path
+// goes unnoticed.  An image can carry a digest: "sha256:<hex>" given as
+// kernel-digest or initrd-digest with the boot parameters, or "etag:<etag>"
+// recorded from the artifact server in the background after the image is
+// first stored if --image-digests is set.  With --image-digests, serving a boot script also
+// re-checks the digests of its images, at most once every
+// imageDigestInterval seconds per image, in the background.  Checking a
+// sha256 digest downloads the whole image; an ETag only needs a one byte
+// range request.  Results are kept under /image-digests/ and
+// /boot/v1/imagedigests reports mismatches.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	imageDigestPfx = "/image-digests"
+	digestSHA256   = "sha256:"
+	digestETag     = "etag:"
+)
+
+var (
+	imageDigests        = false
+	imageDigestInterval = uint(300) // seconds
+	imageDigestClient   = &http.Client{Timeout: 10 * time.Minute}
+
+	imageDigestMutex   sync.Mutex
+	imageDigestChecked = make(map[string]time.Time)
+)
+
+func validDigest(d string) bool {
+	if strings.HasPrefix(d, digestSHA256) {
+		h, err := hex.DecodeString(strings.TrimPrefix(d, digestSHA256))
+		return err == nil && len(h) == sha256.Size
+	}
+	return strings.HasPrefix(d, digestETag) && len(d) > len(digestETag)
+}
+
+func fetchImage(path string, rangeHeader string) (*http.Response, error) {
+	u, err := checkURL(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	rsp, err := imageDigestClient.Do(req)
+	if err == nil && rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusPartialContent {
+		rsp.Body.Close()
+		err = fmt.Errorf("GET %s failed: %s", path, rsp.Status)
+	}
+	return rsp, err
+}
+
+// Function currentDigest() determines the digest of the image at path of the
+// same kind as expected.
+func currentDigest(path, expected string) (string, error) {
+	if strings.HasPrefix(expected, digestSHA256) {
+		rsp, err := fetchImage(path, "")
+		if err != nil {
+			return "", err
+		}
+		defer rsp.Body.Close()
+		h := sha256.New()
+		if _, err = io.Copy(h, rsp.Body); err != nil {
+			return "", err
+		}
+		return digestSHA256 + hex.EncodeToString(h.Sum(nil)), nil
+	}
+	// A one byte range rather than HEAD, presigned S3 URLs are only valid
+	// for GET.
+	rsp, err := fetchImage(path, "bytes=0-0")
+	if err != nil {
+		return "", err
+	}
+	rsp.Body.Close()
+	etag := strings.Trim(strings.TrimPrefix(rsp.Header.Get("ETag"), "W/"), `"`)
+	if etag == "" {
+		return "", fmt.Errorf("No ETag for %s", path)
+	}
+	return digestETag + etag, nil
+}
+
+// Function recordDigest() records the ETag of a new image in the background,
+// so storing it does not wait for the artifact server.  A digest given with
+// the boot parameters in the meantime is kept.
+func recordDigest(key, path string) {
+	if !imageDigests {
+		return
+	}
+	go func() {
+		d, err := currentDigest(path, digestETag)
+		if err != nil {
+			debugf("Not recording a digest for %s: %s", path, err)
+			return
+		}
+		val, exists, err := kvstore.Get(key)
+		var cur ImageData
+		if err != nil || !exists || json.Unmarshal([]byte(val), &cur) != nil || cur.Path != path || cur.Digest != "" {
+			return
+		}
+		cur.Digest = d
+		data, err := json.Marshal(cur)
+		if err == nil {
+			_, err = kvstore.TAS(key, val, string(data))
+		}
+		if err != nil {
+			log.Printf("Failed to record the digest of %s: %s", path, err)
+		}
+	}()
+}
+
+func setImageDigest(key, path, digest string) error {
+	if digest == "" {
+		return nil
+	}
+	if !validDigest(digest) {
+		return fmt.Errorf("Bad digest '%s' for %s, expected sha256:<hex> or etag:<etag>", digest, path)
+	}
+	cur, _ := readImage(key)
+	return updateImage(key, ImageData{Path: path, Params: cur.Params, Digest: digest})
+}
+
+func setImageDigests(bp bssTypes.BootParams, kernelKey, initrdKey string) error {
+	if bp.KernelDigest != "" && kernelKey == "" || bp.InitrdDigest != "" && initrdKey == "" {
+		return fmt.Errorf("A digest needs an image")
+	}
+	if err := setImageDigest(kernelKey, bp.Kernel, bp.KernelDigest); err != nil {
+		return err
+	}
+	return setImageDigest(initrdKey, bp.Initrd, bp.InitrdDigest)
+}
+
+func imageDigestKey(imtype, path string) string {
+	return imageDigestPfx + makeImageKey(imtype, path)
+}
+
+func checkImageDigest(imtype string, imdata ImageData) bssTypes.ImageDigestStatus {
+	status := bssTypes.ImageDigestStatus{Path: imdata.Path, Type: imtype, Expected: imdata.Digest,
+		Checked: time.Now().Unix()}
+	actual, err := currentDigest(imdata.Path, imdata.Digest)
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Actual = actual
+		status.Mismatch = actual != imdata.Digest
+	}
+	if status.Mismatch {
+		log.Printf("WARNING: %s %s digest mismatch: expected %s, found %s", imtype, imdata.Path, imdata.Digest, actual)
+	}
+	if err = storeData(imageDigestKey(imtype, imdata.Path), status); err != nil {
+		log.Printf("Failed to store digest status of %s: %s", imdata.Path, err)
+	}
+	return status
+}
+
+// Function verifyImageDigests() re-checks the digests of the images of a
+// boot script in the background.
+func verifyImageDigests(bd BootData) {
+	if !imageDigests {
+		return
+	}
+	for imtype, imdata := range map[string]ImageData{kernelImageType: bd.Kernel, initrdImageType: bd.Initrd} {
+		if imdata.Digest == "" {
+			continue
+		}
+		imageDigestMutex.Lock()
+		due := time.Since(imageDigestChecked[imdata.Path]) >= time.Duration(imageDigestInterval)*time.Second
+		if due {
+			imageDigestChecked[imdata.Path] = time.Now()
+		}
+		imageDigestMutex.Unlock()
+		if due {
+			go checkImageDigest(imtype, imdata)
+		}
+	}
+}
+
+// Function imageDigestStatus() returns the last check of every image with a
+// digest, optionally limited to one path.
+func imageDigestStatus(path string) []bssTypes.ImageDigestStatus {
+	ret := []bssTypes.ImageDigestStatus{}
+	for _, imtype := range []string{kernelImageType, initrdImageType} {
+		for _, imdata := range getImageInfo(imtype) {
+			if imdata.Digest == "" || path != "" && imdata.Path != path {
+				continue
+			}
+			status := bssTypes.ImageDigestStatus{Path: imdata.Path, Type: imtype, Expected: imdata.Digest}
+			val, exists, err := kvstore.Get(imageDigestKey(imtype, imdata.Path))
+			var last bssTypes.ImageDigestStatus
+			if err == nil && exists && json.Unmarshal([]byte(val), &last) == nil && last.Expected == imdata.Digest {
+				status = last
+			}
+			ret = append(ret, status)
+		}
+	}
+	return ret
+}
+
+func sendDigestStatus(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func ImageDigestsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ImageDigestsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	mismatch := strings.Join(r.Form["mismatch"], "") == "true"
+	results := []bssTypes.ImageDigestStatus{}
+	for _, s := range imageDigestStatus(strings.Join(r.Form["path"], "")) {
+		if !mismatch || s.Mismatch {
+			results = append(results, s)
+		}
+	}
+	sendDigestStatus(w, results)
+}
+
+// Posting checks the digests of images now.
+func ImageDigestsPost(w http.ResponseWriter, r *http.Request) {
+	debugf("ImageDigestsPost(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	path := strings.Join(r.Form["path"], "")
+	results := []bssTypes.ImageDigestStatus{}
+	for _, imtype := range []string{kernelImageType, initrdImageType} {
+		for _, imdata := range getImageInfo(imtype) {
+			if imdata.Digest != "" && (path == "" || imdata.Path == path) {
+				results = append(results, checkImageDigest(imtype, imdata))
+			}
+		}
+	}
+	sendDigestStatus(w, results)
+}
+
+// Putting accepts the current content of an image: its digest is recorded
+// again.
+func ImageDigestsPut(w http.ResponseWriter, r *http.Request) {
+	debugf("ImageDigestsPut(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	path := strings.Join(r.Form["path"], "")
+	if path == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a path= parameter")
+		return
+	}
+	results := []bssTypes.ImageDigestStatus{}
+	for _, imtype := range []string{kernelImageType, initrdImageType} {
+		key := imageFind(path, imtype)
+		if key == "" {
+			continue
+		}
+		imdata, _ := readImage(key)
+		kind := imdata.Digest
+		if kind == "" {
+			kind = digestETag
+		}
+		d, err := currentDigest(path, kind)
+		if err == nil {
+			err = setImageDigest(key, path, d)
+		}
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadGateway,
+				fmt.Sprintf("Cannot record the digest of %s: %s", path, err))
+			return
+		}
+		imdata.Digest = d
+		results = append(results, checkImageDigest(imtype, imdata))
+	}
+	if len(results) == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No image '%s'", path))
+		return
+	}
+	log.Printf("/imagedigests PUT: accepted %s for %s", results[0].Expected, path)
+	sendDigestStatus(w, results)
+}
@@ -0,0 +1,143 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestImageDigests(t *testing.T) {
+	content := "kernel v1"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(content))
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:4])+`"`)
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+	kernel := srv.URL + "/vmlinuz"
+	initrd := srv.URL + "/initrd"
+	const host = "x0c1s21b0n0"
+	defer func() {
+		kvstore.Delete(paramsPfx + host)
+		kvstore.Delete(makeImageKey(kernelImageType, kernel))
+		kvstore.Delete(makeImageKey(initrdImageType, initrd))
+		kvstore.Delete(imageDigestKey(kernelImageType, kernel))
+		kvstore.Delete(imageDigestKey(initrdImageType, initrd))
+	}()
+	defer func(enabled bool) { imageDigests = enabled }(imageDigests)
+	imageDigests = true
+
+	sum := sha256.Sum256([]byte(content))
+	bp := bssTypes.BootParams{Hosts: []string{host}, Kernel: kernel, Initrd: initrd,
+		KernelDigest: digestSHA256 + hex.EncodeToString(sum[:])}
+	if err, _ := Store(bp, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	// The initrd's ETag is recorded in the background.
+	bd, _ := LookupBootData(host)
+	for i := 0; i < 100 && bd.Initrd.Digest == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+		bd, _ = LookupBootData(host)
+	}
+	if bd.Kernel.Digest != bp.KernelDigest || len(bd.Initrd.Digest) <= len(digestETag) {
+		t.Errorf("Unexpected digests kernel %s initrd %s", bd.Kernel.Digest, bd.Initrd.Digest)
+	}
+
+	verify := func(method, query string) []bssTypes.ImageDigestStatus {
+		req := httptest.NewRequest(method, baseEndpoint+"/imagedigests"+query, nil)
+		rr := httptest.NewRecorder()
+		imageDigestsAPI(rr, req)
+		var results []bssTypes.ImageDigestStatus
+		if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+			t.Fatalf("%s imagedigests%s returned %d %s", method, query, rr.Code, rr.Body)
+		}
+		return results
+	}
+	if results := verify(http.MethodPost, ""); len(results) < 2 {
+		t.Errorf("Verify returned %+v", results)
+	}
+	if results := verify(http.MethodGet, "?mismatch=true"); len(results) != 0 {
+		t.Errorf("Mismatches before changing the images: %+v", results)
+	}
+
+	content = "kernel v2"
+	verify(http.MethodPost, "")
+	if results := verify(http.MethodGet, "?mismatch=true"); len(results) != 2 {
+		t.Errorf("Mismatches after changing the images: %+v", results)
+	}
+	verify(http.MethodPut, "?path="+kernel)
+	if results := verify(http.MethodGet, "?mismatch=true"); len(results) != 1 || results[0].Path != initrd {
+		t.Errorf("Mismatches after accepting the kernel: %+v", results)
+	}
+
+	bp.KernelDigest = "md5:1234"
+	if err, _ := Store(bp, "test"); err == nil {
+		t.Errorf("Store accepted a bad digest")
+	}
+}
+
+func TestPinDigests(t *testing.T) {
+	kd := strings.Repeat("ab", sha256.Size)
+	bd := BootData{
+		Params: "console=ttyS0",
+		Kernel: ImageData{Path: "http://s3/kernel", Digest: digestSHA256 + kd},
+		Initrd: ImageData{Path: "http://s3/initrd", Digest: digestETag + "\"1\""},
+	}
+	sp := scriptParams{xname: "x0c0s1b0n0"}
+
+	script, err := buildBootScript(bd, sp, "", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	if strings.Contains(script, "sha256=") || strings.Contains(script, "imgverify") {
+		t.Errorf("Boot script pins digests without --pin-digests:\n%s", script)
+	}
+
+	defer func(p bool, s string) { pinDigests, imgverifySuffix = p, s }(pinDigests, imgverifySuffix)
+	pinDigests, imgverifySuffix = true, ".sig"
+	script, err = buildBootScript(bd, sp, "", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	if !strings.Contains(script, " bss.kernel_sha256="+kd) {
+		t.Errorf("Boot script is missing the kernel digest:\n%s", script)
+	}
+	if strings.Contains(script, "bss.initrd_sha256=") {
+		t.Errorf("Boot script pins an ETag digest:\n%s", script)
+	}
+	if !strings.Contains(script, "imgverify kernel http://s3/kernel.sig || goto boot_retry\n") {
+		t.Errorf("Boot script is missing the kernel imgverify:\n%s", script)
+	}
+	if strings.Contains(script, "imgverify initrd") {
+		t.Errorf("Boot script verifies an image without a sha256 digest:\n%s", script)
+	}
+}
@@ -0,0 +1,348 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Bulk import of node boot assignments.
+//
+// During initial bring-up the boot assignments of thousands of nodes usually
+// come from a spreadsheet.  POST /boot/v1/import takes them as CSV (with a
+// header row) or as JSON lines, one object per line.  Each row names a node
+// by xname, MAC or NID and gives a kernel, initrd and params, or a profile,
+// which is the name of a boot group whose configuration is used for the
+// fields the row leaves out.  All rows are validated before anything is
+// stored; if storing fails part way the earlier rows are rolled back and the
+// images the import created are removed.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+type importRow struct {
+	Xname   string `json:"xname,omitempty"`
+	MAC     string `json:"mac,omitempty"`
+	NID     int32  `json:"nid,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Kernel  string `json:"kernel,omitempty"`
+	Initrd  string `json:"initrd,omitempty"`
+	Params  string `json:"params,omitempty"`
+
+	line int
+}
+
+func importError(line int, msg string) bssTypes.ImportError {
+	return bssTypes.ImportError{Line: line, Message: msg}
+}
+
+var importColumns = []string{"xname", "mac", "nid", "profile", "kernel", "initrd", "params"}
+
+func parseImportCSV(r io.Reader) ([]importRow, []bssTypes.ImportError) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, []bssTypes.ImportError{importError(1, fmt.Sprintf("Cannot read header: %s", err))}
+	}
+	cols := make([]string, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		known := false
+		for _, c := range importColumns {
+			known = known || c == h
+		}
+		if !known {
+			return nil, []bssTypes.ImportError{importError(1, fmt.Sprintf("Unknown column '%s'", header[i]))}
+		}
+		cols[i] = h
+	}
+	var rows []importRow
+	var errs []bssTypes.ImportError
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			errs = append(errs, importError(line, err.Error()))
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			break
+		}
+		if len(rec) > len(cols) {
+			errs = append(errs, importError(line, "Too many fields"))
+			continue
+		}
+		row := importRow{line: line}
+		for i, v := range rec {
+			v = strings.TrimSpace(v)
+			switch cols[i] {
+			case "xname":
+				row.Xname = v
+			case "mac":
+				row.MAC = v
+			case "nid":
+				if v != "" {
+					n, err := strconv.ParseInt(v, 10, 32)
+					if err != nil {
+						errs = append(errs, importError(line, fmt.Sprintf("Bad nid '%s'", v)))
+					}
+					row.NID = int32(n)
+				}
+			case "profile":
+				row.Profile = v
+			case "kernel":
+				row.Kernel = v
+			case "initrd":
+				row.Initrd = v
+			case "params":
+				row.Params = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs
+}
+
+func parseImportJSONLines(r io.Reader) ([]importRow, []bssTypes.ImportError) {
+	var rows []importRow
+	var errs []bssTypes.ImportError
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var row importRow
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&row); err != nil {
+			errs = append(errs, importError(line, err.Error()))
+			continue
+		}
+		row.line = line
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		errs = append(errs, importError(line+1, err.Error()))
+	}
+	return rows, errs
+}
+
+// Function importHost() resolves the node a row refers to.  When more than
+// one of xname, MAC and NID is given they must agree.
+func importHost(row importRow) (string, error) {
+	var names []string
+	if row.Xname != "" {
+		if strings.ContainsAny(row.Xname, " \t/") {
+			return "", fmt.Errorf("Bad xname '%s'", row.Xname)
+		}
+		names = append(names, row.Xname)
+	}
+	if row.MAC != "" {
+		comp, ok := findCompByMACFresh(row.MAC)
+		if !ok {
+			return "", fmt.Errorf("Unknown MAC %s", row.MAC)
+		}
+		names = append(names, comp.ID)
+	}
+	if row.NID != 0 {
+		if comp, ok := findCompByNidFresh(int(row.NID)); ok {
+			names = append(names, comp.ID)
+		} else {
+			names = append(names, nidName(int(row.NID)))
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("Need an xname, mac or nid")
+	}
+	for _, n := range names[1:] {
+		if n != names[0] {
+			return "", fmt.Errorf("xname, mac and nid refer to different nodes (%s, %s)", names[0], n)
+		}
+	}
+	return names[0], nil
+}
+
+// Function validateImport() turns the rows into boot parameters, one host per
+// entry.  Protected hosts are refused unless override is set.
+func validateImport(rows []importRow, override bool) ([]bssTypes.BootParams, []bssTypes.ImportError) {
+	var bps []bssTypes.BootParams
+	var errs []bssTypes.ImportError
+	seen := make(map[string]int)
+	profiles := make(map[string]bssTypes.BootGroup)
+	for _, row := range rows {
+		host, err := importHost(row)
+		if err != nil {
+			errs = append(errs, importError(row.line, err.Error()))
+			continue
+		}
+		if prev, ok := seen[host]; ok {
+			errs = append(errs, importError(row.line, fmt.Sprintf("%s is also assigned on line %d", host, prev)))
+			continue
+		}
+		seen[host] = row.line
+		if !override && isProtected(host) {
+			errs = append(errs, importError(row.line,
+				fmt.Sprintf("%s is protected, set %s: true to replace it", host, protectOverrideHeader)))
+			continue
+		}
+		if needsApproval(host) {
+			errs = append(errs, importError(row.line,
+				fmt.Sprintf("Changes to %s need approval, propose them through %s/bootparameters", host, baseEndpoint)))
+			continue
+		}
+		bp := bssTypes.BootParams{Hosts: []string{host}, Kernel: row.Kernel, Initrd: row.Initrd, Params: row.Params}
+		if row.Profile != "" {
+			g, ok := profiles[row.Profile]
+			if !ok {
+				var found bool
+				g, found, err = findBootGroup(row.Profile)
+				if err != nil || !found {
+					errs = append(errs, importError(row.line, fmt.Sprintf("Unknown profile '%s'", row.Profile)))
+					continue
+				}
+				profiles[row.Profile] = g
+			}
+			if bp.Kernel == "" {
+				bp.Kernel = g.Kernel
+			}
+			if bp.Initrd == "" {
+				bp.Initrd = g.Initrd
+			}
+			if bp.Params == "" {
+				bp.Params = g.Params
+			}
+		}
+		if bp.Kernel == "" {
+			errs = append(errs, importError(row.line, "Need a kernel or profile"))
+			continue
+		}
+		bps = append(bps, bp)
+	}
+	return bps, errs
+}
+
+// Function applyImport() stores the boot parameters, restoring the previous
+// entries if any of them fails and removing the images the import created
+// that nothing else has started to use.
+func applyImport(bps []bssTypes.BootParams, who string) (int, error) {
+	var undo kvUndo
+	var created []string
+	rollback := func() {
+		undo.rollback()
+		for _, key := range created {
+			if len(imageUsers(key)) == 0 {
+				if err := kvstore.Delete(key); err != nil {
+					log.Printf("WARNING: rollback of %s failed: %s", key, err)
+				}
+			}
+		}
+	}
+	for i, bp := range bps {
+		for imtype, path := range map[string]string{kernelImageType: bp.Kernel, initrdImageType: bp.Initrd} {
+			if path != "" && imageFind(path, imtype) == "" {
+				created = append(created, makeImageKey(imtype, path))
+			}
+		}
+		key := paramsPfx + bp.Hosts[0]
+		value, exists, err := kvstore.Get(key)
+		if err == nil {
+			undo.record(key, value, exists)
+			if old, e := lookupHost(bp.Hosts[0]); exists && e == nil {
+				bp.CloudInit, bp.FirstBoot, bp.Messages, bp.Labels = old.CloudInit, old.FirstBoot, old.Messages, old.Labels
+				bp.Reasons, bp.Payload, bp.Files = old.Reasons, old.Payload, old.Files
+				bp.DTB, bp.ACPI, bp.InitrdOverlays = old.DTB, old.ACPI, old.Overlays
+			}
+			err, _ = Store(bp, who)
+		}
+		if err != nil {
+			rollback()
+			return 0, fmt.Errorf("Storing %s failed, import rolled back: %s", bp.Hosts[0], err)
+		}
+		debugf("import: stored %d of %d", i+1, len(bps))
+	}
+	return len(bps), nil
+}
+
+func ImportPost(w http.ResponseWriter, r *http.Request) {
+	debugf("ImportPost(): Received request %v\n", r.URL)
+	dryRun := strings.ToLower(r.URL.Query().Get("dry-run"))
+	report := bssTypes.ImportReport{DryRun: dryRun == "true" || dryRun == "1" || dryRun == "yes"}
+
+	var rows []importRow
+	var errs []bssTypes.ImportError
+	ctype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ctype {
+	case "text/csv":
+		rows, errs = parseImportCSV(r.Body)
+	case "", "application/json", "application/jsonl", "application/x-ndjson":
+		rows, errs = parseImportJSONLines(r.Body)
+	default:
+		base.SendProblemDetailsGeneric(w, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Unsupported Content-Type %s, use text/csv or application/jsonl", ctype))
+		return
+	}
+	bps, verrs := validateImport(rows, protectionOverridden(r))
+	report.Rows = len(rows)
+	report.Errors = append(errs, verrs...)
+
+	status := http.StatusOK
+	if len(report.Errors) > 0 {
+		status = http.StatusBadRequest
+	} else if report.Rows == 0 {
+		status = http.StatusBadRequest
+		report.Errors = []bssTypes.ImportError{importError(0, "No rows")}
+	} else if !report.DryRun {
+		var err error
+		report.Applied, err = applyImport(bps, requestSubject(r))
+		if err != nil {
+			status = http.StatusInternalServerError
+			report.Errors = []bssTypes.ImportError{importError(0, err.Error())}
+		}
+	}
+	log.Printf("/import POST: %d rows, %d applied, %d errors", report.Rows, report.Applied, len(report.Errors))
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(report)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
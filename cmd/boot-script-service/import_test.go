@@ -0,0 +1,117 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func postImport(t *testing.T, ctype, query, body string) (int, bssTypes.ImportReport) {
+	req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", ctype)
+	rr := httptest.NewRecorder()
+	bulkImport(rr, req)
+	var report bssTypes.ImportReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Bad import report %s: %s", rr.Body, err)
+	}
+	return rr.Code, report
+}
+
+func TestImport(t *testing.T) {
+	const kernel = "/import/vmlinuz"
+	defer func() {
+		for _, h := range []string{"x0c1s21b0n0", "x0c3s5b0n0", "x0c0s18b0n0"} {
+			kvstore.Delete(paramsPfx + h)
+		}
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+
+	// One bad row keeps the good ones from being applied.
+	csvBody := "xname,mac,nid,kernel,params\n" +
+		"x0c1s21b0n0,,," + kernel + ",console=ttyS0\n" +
+		",00:1e:67:dd:d0:eb,," + kernel + ",\n" +
+		"x0c0s18b0n0,,24," + kernel + ",\n"
+	code, report := postImport(t, "text/csv", "", csvBody)
+	if code != http.StatusBadRequest || len(report.Errors) != 1 || report.Errors[0].Line != 4 {
+		t.Errorf("Import with a mismatched NID returned %d %+v", code, report)
+	}
+	if _, err := lookupHost("x0c1s21b0n0"); err == nil {
+		t.Errorf("Failed import stored boot parameters")
+	}
+
+	csvBody = strings.Replace(csvBody, ",24,", ",76,", 1)
+	code, report = postImport(t, "text/csv", "?dry-run=true", csvBody)
+	if code != http.StatusOK || report.Rows != 3 || report.Applied != 0 {
+		t.Errorf("Dry run returned %d %+v", code, report)
+	}
+	code, report = postImport(t, "text/csv", "", csvBody)
+	if code != http.StatusOK || report.Applied != 3 {
+		t.Errorf("Import returned %d %+v", code, report)
+	}
+	if bd, err := LookupBootData("x0c3s5b0n0"); err != nil || bd.Kernel.Path != kernel {
+		t.Errorf("Import by MAC stored %+v, %v", bd, err)
+	}
+
+	jsonl := `{"xname":"x0c1s21b0n0","kernel":"` + kernel + `","params":"quiet"}` + "\n" +
+		`{"xname":"x0c1s21b0n0","kernel":"` + kernel + `"}` + "\n" +
+		`{"nid":76,"profile":"no-such-profile"}` + "\n"
+	code, report = postImport(t, "application/jsonl", "", jsonl)
+	if code != http.StatusBadRequest || len(report.Errors) != 2 {
+		t.Errorf("JSON lines import returned %d %+v", code, report)
+	}
+}
+
+func TestImportRollback(t *testing.T) {
+	const kernel = "/import/rollback/vmlinuz"
+	hosts := []string{"x0c1s24b0n0", "x0c1s25b0n0"}
+	var bps []bssTypes.BootParams
+	for _, h := range hosts {
+		bps = append(bps, bssTypes.BootParams{Hosts: []string{h}, Kernel: kernel})
+	}
+	saved := kvstore
+	kvstore = failKvi{saved, paramsPfx + hosts[1]}
+	_, err := applyImport(bps, "test")
+	kvstore = saved
+	defer func() {
+		for _, h := range hosts {
+			kvstore.Delete(paramsPfx + h)
+		}
+		kvstore.Delete(makeImageKey(kernelImageType, kernel))
+	}()
+	if err == nil {
+		t.Fatalf("Import with a failing store succeeded")
+	}
+	if _, err = lookupHost(hosts[0]); err == nil {
+		t.Errorf("Rollback left %s", hosts[0])
+	}
+	if key := imageFind(kernel, kernelImageType); key != "" {
+		t.Errorf("Rollback left the kernel image %s", key)
+	}
+}
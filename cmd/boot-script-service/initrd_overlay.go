@@ -0,0 +1,116 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Initrd overlays.
+//
+// Small additions to an image, site configuration or secrets, need not mean
+// rebuilding its initrd.  An entry can list overlays, more initrds which the
+// boot script loads after the initrd, in order:
+//
+//	initrd --name initrd <initrd>
+//	initrd --name overlay0 <initrd-overlays[0]>
+//	...
+//
+// and names on the kernel command line after initrd=initrd, so the kernel
+// unpacks them over it.  ${xname} and ${nid} in an overlay path are replaced
+// by those of the node, so a role entry can give every node its own secrets.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	overlayImagePfx = "overlay"
+	overlaysMax     = 8
+)
+
+// Function checkOverlays() validates the initrd overlays of an entry with
+// the given payload and initrd.
+func checkOverlays(payload, initrd string, overlays []string) error {
+	if len(overlays) == 0 {
+		return nil
+	}
+	if payload != "" && payload != payloadLinux {
+		return fmt.Errorf("Initrd overlays need a %s payload", payloadLinux)
+	}
+	if initrd == "" {
+		return fmt.Errorf("Initrd overlays need an initrd")
+	}
+	if len(overlays) > overlaysMax {
+		return fmt.Errorf("%d initrd overlays, at most %d are allowed", len(overlays), overlaysMax)
+	}
+	seen := make(map[string]bool)
+	for i, o := range overlays {
+		if o == "" {
+			return fmt.Errorf("Initrd overlay %d has no path", i)
+		}
+		if strings.ContainsAny(o, " \t\r\n") {
+			return fmt.Errorf("Initrd overlay %d has white space in its path", i)
+		}
+		if u, err := url.Parse(o); err != nil || u.Host == "" ||
+			(u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "s3") {
+			return fmt.Errorf("Initrd overlay %s is not an http, https or s3 URL", o)
+		}
+		if o == initrd || seen[o] {
+			return fmt.Errorf("Initrd overlay %s is listed twice", o)
+		}
+		seen[o] = true
+	}
+	return nil
+}
+
+// Function patchOverlays() returns the initrd overlays of an entry after
+// applying bp to it.
+func patchOverlays(bp bssTypes.BootParams, bd BootDataStore) []string {
+	if bp.InitrdOverlays != nil {
+		return bp.InitrdOverlays
+	}
+	return bd.Overlays
+}
+
+// Function overlayStanza() returns the iPXE commands loading the initrd
+// overlays of a node, and the kernel parameters naming them.
+func overlayStanza(bd BootData, sp scriptParams) (string, string, error) {
+	if bd.Initrd.Path == "" {
+		return "", "", nil
+	}
+	nodeVars := strings.NewReplacer("${xname}", sp.xname, "${nid}", sp.nid)
+	var script string
+	var params []string
+	for i, o := range bd.Overlays {
+		u, err := checkURL(nodeVars.Replace(o))
+		if err != nil {
+			return "", "", err
+		}
+		name := fmt.Sprintf("%s%d", overlayImagePfx, i)
+		script += "initrd --name " + name + " " + u + " || goto boot_retry\n"
+		params = append(params, "initrd="+name)
+	}
+	return script, strings.Join(params, " "), nil
+}
@@ -0,0 +1,102 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestCheckOverlays(t *testing.T) {
+	const initrd = "http://s3/initrd"
+	tests := []struct {
+		payload, initrd string
+		overlays        []string
+		ok              bool
+	}{
+		{"", "", nil, true},
+		{"", initrd, []string{"http://s3/site.cpio", "http://s3/${xname}.cpio"}, true},
+		{"", "", []string{"http://s3/site.cpio"}, false},
+		{payloadWimboot, initrd, []string{"http://s3/site.cpio"}, false},
+		{"", initrd, []string{""}, false},
+		{"", initrd, []string{"http://s3/site.cpio", "http://s3/site.cpio"}, false},
+		{"", initrd, []string{initrd}, false},
+		{"", initrd, []string{"http://s3/site.cpio || shell"}, false},
+		{"", initrd, []string{"site.cpio"}, false},
+		{"", initrd, []string{"tftp://s3/site.cpio"}, false},
+		{"", initrd, []string{"s3://boot-images/${xname}.cpio"}, true},
+		{"", initrd, make([]string, overlaysMax+1), false},
+	}
+	for _, tc := range tests {
+		if err := checkOverlays(tc.payload, tc.initrd, tc.overlays); (err == nil) != tc.ok {
+			t.Errorf("checkOverlays(%s, %s, %v) = %v", tc.payload, tc.initrd, tc.overlays, err)
+		}
+	}
+}
+
+func TestOverlayBootScript(t *testing.T) {
+	bd := BootData{Kernel: ImageData{Path: "http://s3/kernel"}, Initrd: ImageData{Path: "http://s3/initrd"},
+		Params: "console=ttyS0", Overlays: []string{"http://s3/site.cpio", "http://s3/secrets/${xname}-${nid}.cpio"}}
+	script, err := buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0", nid: "8"}, "chain x", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	want := "initrd --name initrd http://s3/initrd || goto boot_retry\n" +
+		"initrd --name overlay0 http://s3/site.cpio || goto boot_retry\n" +
+		"initrd --name overlay1 http://s3/secrets/x0c0s1b0n0-8.cpio || goto boot_retry\n"
+	if !strings.Contains(script, want) ||
+		!strings.Contains(script, "kernel --name kernel http://s3/kernel initrd=initrd initrd=overlay0 initrd=overlay1 console=ttyS0") {
+		t.Errorf("Unexpected overlay script:\n%s", script)
+	}
+}
+
+func TestStoreOverlays(t *testing.T) {
+	const node = "x0c0s9b0n0"
+	overlays := []string{"http://s3/site.cpio"}
+	defer func() {
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(makeImageKey(initrdImageType, "http://s3/initrd"))
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Kernel: "http://s3/kernel",
+		InitrdOverlays: overlays}, ""); err == nil {
+		t.Errorf("Store accepted overlays without an initrd")
+	}
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Kernel: "http://s3/kernel",
+		Initrd: "http://s3/initrd"}, ""); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	w := httptest.NewRecorder()
+	bootParameters(w, httptest.NewRequest(http.MethodPatch, baseEndpoint+"/bootparameters",
+		strings.NewReader(`{"hosts":["`+node+`"],"initrd-overlays":["http://s3/site.cpio"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH with initrd-overlays returned %d: %s", w.Code, w.Body.String())
+	}
+	if bd, err := LookupBootData(node); err != nil || !reflect.DeepEqual(bd.Overlays, overlays) {
+		t.Errorf("Update stored overlays %v, %v", bd.Overlays, err)
+	}
+}
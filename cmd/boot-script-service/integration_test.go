@@ -0,0 +1,154 @@
+//go:build integration
+
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Backend parity tests.
+//
+// Run with "go test -tags integration", see runIntegrationTest.sh.  The same
+// boot parameters, boot script and cloud-init requests are sent to BSS on the
+// in-memory datastore and on etcd at BSS_TEST_ETCD_URL, and the answers must
+// be identical.  Each backend gets a namespace of its own, which is removed
+// afterwards.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+)
+
+type parityStep struct {
+	method, target, body string
+}
+
+const (
+	parityNode = "x0c0s3b0n0"
+	parityIP   = "10.252.3.10"
+)
+
+var paritySteps = []parityStep{
+	{http.MethodPost, "/bootparameters", `{"hosts":["` + parityNode + `"],"params":"console=ttyS0",
+		"kernel":"http://images/kernel","initrd":"http://images/initrd",
+		"cloud-init":{"meta-data":{"site":"a"},"user-data":{"runcmd":["true"]}}}`},
+	{http.MethodGet, "/bootparameters?name=" + parityNode, ""},
+	{http.MethodPatch, "/bootparameters", `{"hosts":["` + parityNode + `"],"params":"console=ttyS1 quiet"}`},
+	{http.MethodPut, "/bootparameters", `{"hosts":["` + parityNode + `"],"params":"console=ttyS1",
+		"kernel":"http://images/kernel2","initrd":"http://images/initrd",
+		"cloud-init":{"meta-data":{"site":"b"},"user-data":{"runcmd":["false"]}}}`},
+	{http.MethodGet, "/bootparameters?name=" + parityNode, ""},
+	{http.MethodGet, "/bootscript?name=" + parityNode, ""},
+	{http.MethodGet, "/meta-data", ""},
+	{http.MethodGet, "/user-data", ""},
+	{http.MethodDelete, "/bootparameters", `{"hosts":["` + parityNode + `"]}`},
+	{http.MethodGet, "/bootparameters?name=" + parityNode, ""},
+	{http.MethodGet, "/bootscript?name=" + parityNode, ""},
+}
+
+// Timestamps, referral tokens and instance IDs differ between runs, not
+// between backends.
+var parityVolatile = regexp.MustCompile(`\b1[0-9]{9}\b|` +
+	`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|"instance-id":"[^"]*"`)
+
+func runParitySteps(t *testing.T) []string {
+	var transcript []string
+	for _, s := range paritySteps {
+		req := httptest.NewRequest(s.method, baseEndpoint+s.target, strings.NewReader(s.body))
+		req.RemoteAddr = parityIP + ":4011"
+		w := httptest.NewRecorder()
+		switch path := strings.SplitN(s.target, "?", 2)[0]; path {
+		case "/bootparameters":
+			bootParameters(w, req)
+		case "/bootscript":
+			bootScript(w, req)
+		case "/meta-data":
+			metaDataGet(w, req)
+		case "/user-data":
+			userDataGet(w, req)
+		default:
+			t.Fatalf("No handler for %s", path)
+		}
+		if w.Code >= http.StatusInternalServerError {
+			t.Errorf("%s %s returned %d: %s", s.method, s.target, w.Code, w.Body.String())
+		}
+		body := parityVolatile.ReplaceAllString(w.Body.String(), "<volatile>")
+		transcript = append(transcript, fmt.Sprintf("%s %s -> %d\n%s", s.method, s.target, w.Code, body))
+	}
+	return transcript
+}
+
+// Function withBackend() points BSS at a namespace of the datastore at url
+// while f runs.
+func withBackend(t *testing.T, url string, f func()) {
+	kv, err := hmetcd.Open(url, "")
+	if err != nil {
+		t.Fatalf("Cannot open %s: %s", url, err)
+	}
+	ns := fmt.Sprintf("parity-%d", time.Now().UnixNano())
+	savedStore, savedRoot := kvstore, kvstoreRoot
+	kvstoreRoot = newTimedKvi(kv)
+	kvstore = inNamespace(kvstoreRoot, ns)
+	defer func() {
+		pfx := namespaceRoot + ns
+		if kvl, err := kvstoreRoot.GetRange(pfx+keyMin, pfx+keyMax); err == nil {
+			for _, kv := range kvl {
+				kvstoreRoot.Delete(kv.Key)
+			}
+		}
+		kvstore, kvstoreRoot = savedStore, savedRoot
+		kv.Close()
+	}()
+	f()
+}
+
+func TestIntegrationBackendParity(t *testing.T) {
+	etcdURL := os.Getenv("BSS_TEST_ETCD_URL")
+	if etcdURL == "" {
+		t.Skip("BSS_TEST_ETCD_URL is not set")
+	}
+	state := getState()
+	savedAddrs, savedDeterministic := state.IPAddrs, bootscriptDeterministic
+	state.IPAddrs = map[string]sm.CompEthInterfaceV2{parityIP: {CompID: parityNode}}
+	bootscriptDeterministic = true
+	defer func() {
+		state.IPAddrs, bootscriptDeterministic = savedAddrs, savedDeterministic
+	}()
+
+	var mem, etcd []string
+	withBackend(t, "mem:", func() { mem = runParitySteps(t) })
+	withBackend(t, etcdURL, func() { etcd = runParitySteps(t) })
+	for i := range mem {
+		if mem[i] != etcd[i] {
+			t.Errorf("Backends differ\nmem:\n%s\netcd:\n%s", mem[i], etcd[i])
+		}
+	}
+}
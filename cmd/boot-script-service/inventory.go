@@ -0,0 +1,156 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Ansible dynamic inventory.
+//
+// GET /boot/v1/inventory/ansible returns the nodes with boot parameters in
+// the JSON format of an Ansible dynamic inventory script, grouped as
+// role_<role>, subrole_<subrole> and bootgroup_<boot group>, with the nid,
+// MAC addresses, role and boot images of each node as host variables.
+// Group names are lower case with anything but letters, digits and
+// underscores replaced by underscores.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+type inventoryGroup struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Children []string `json:"children,omitempty"`
+}
+
+func inventoryGroupName(prefix, name string) string {
+	var b strings.Builder
+	b.WriteString(prefix + "_")
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func ansibleInventory() (map[string]interface{}, error) {
+	state := getState()
+	if state == nil {
+		return nil, fmt.Errorf("HSM data is not available")
+	}
+	groups, err := getBootGroups()
+	if err != nil {
+		return nil, err
+	}
+	bootGroupOf := make(map[string]string)
+	for _, g := range groups {
+		name := g.Name
+		if name == "" {
+			name = g.ID
+		}
+		for _, m := range g.Members {
+			bootGroupOf[m] = name
+		}
+	}
+
+	inv := make(map[string]interface{})
+	hostvars := make(map[string]interface{})
+	members := make(map[string][]string)
+	for _, comp := range state.Components {
+		if strings.EqualFold(comp.State, "empty") {
+			continue
+		}
+		bd := lookup(comp.ID, "", comp.Role, DefaultTag)
+		if bd.Kernel.Path == "" {
+			continue
+		}
+		vars := map[string]interface{}{"xname": comp.ID, "role": comp.Role, "kernel": bd.Kernel.Path}
+		if nid, err := comp.NID.Int64(); err == nil {
+			vars["nid"] = nid
+		}
+		if len(comp.Mac) > 0 {
+			vars["mac"] = comp.Mac
+		}
+		if comp.SubRole != "" {
+			vars["subrole"] = comp.SubRole
+		}
+		if bd.Initrd.Path != "" {
+			vars["initrd"] = bd.Initrd.Path
+		}
+		if comp.Role != "" {
+			g := inventoryGroupName("role", comp.Role)
+			members[g] = append(members[g], comp.ID)
+		}
+		if comp.SubRole != "" {
+			g := inventoryGroupName("subrole", comp.SubRole)
+			members[g] = append(members[g], comp.ID)
+		}
+		if name, ok := bootGroupOf[comp.ID]; ok {
+			vars["boot_group"] = name
+			g := inventoryGroupName("bootgroup", name)
+			members[g] = append(members[g], comp.ID)
+		}
+		hostvars[comp.ID] = vars
+	}
+	var children []string
+	for g, hosts := range members {
+		sort.Strings(hosts)
+		inv[g] = inventoryGroup{Hosts: hosts}
+		children = append(children, g)
+	}
+	sort.Strings(children)
+	var ungrouped []string
+	for h, vars := range hostvars {
+		if vars.(map[string]interface{})["role"] == "" {
+			ungrouped = append(ungrouped, h)
+		}
+	}
+	sort.Strings(ungrouped)
+	inv["all"] = inventoryGroup{Children: append(children, "ungrouped")}
+	inv["ungrouped"] = inventoryGroup{Hosts: ungrouped}
+	inv["_meta"] = map[string]interface{}{"hostvars": hostvars}
+	return inv, nil
+}
+
+func InventoryAnsibleGet(w http.ResponseWriter, r *http.Request) {
+	debugf("InventoryAnsibleGet(): Received request %v\n", r.URL)
+	inv, err := ansibleInventory()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusServiceUnavailable,
+			fmt.Sprintf("Cannot build the inventory: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(inv); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,83 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestInventoryAnsible(t *testing.T) {
+	bp := bssTypes.BootParams{Hosts: []string{"x0c0s1b0n0"}, Kernel: "http://s3/inventory/kernel"}
+	defer func() {
+		kvstore.Delete(paramsPfx + "x0c0s1b0n0")
+		kvstore.Delete(imageFind(bp.Kernel, kernelImageType))
+	}()
+	if err, _ := Store(bp, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/inventory/ansible", nil)
+	w := httptest.NewRecorder()
+	inventoryAnsible(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET inventory returned %d: %s", w.Code, w.Body.String())
+	}
+	var inv struct {
+		Meta struct {
+			Hostvars map[string]map[string]interface{} `json:"hostvars"`
+		} `json:"_meta"`
+		All        inventoryGroup `json:"all"`
+		Management inventoryGroup `json:"role_management"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &inv); err != nil {
+		t.Fatalf("Bad inventory: %s", err)
+	}
+	vars := inv.Meta.Hostvars["x0c0s1b0n0"]
+	if vars["nid"] != float64(8) || vars["kernel"] != bp.Kernel || vars["role"] != "Management" {
+		t.Errorf("Unexpected host vars %v", vars)
+	}
+	if _, ok := vars["boot_group"]; !ok {
+		t.Errorf("Host vars are missing the boot group: %v", vars)
+	}
+	found := false
+	for _, h := range inv.Management.Hosts {
+		found = found || h == "x0c0s1b0n0"
+	}
+	if !found {
+		t.Errorf("role_management is missing the node: %v", inv.Management)
+	}
+	if len(inv.All.Children) < 2 {
+		t.Errorf("Unexpected all group %v", inv.All)
+	}
+}
+
+func TestInventoryGroupName(t *testing.T) {
+	if got := inventoryGroupName("bootgroup", "GPU a100-nodes"); got != "bootgroup_gpu_a100_nodes" {
+		t.Errorf("inventoryGroupName returned %s", got)
+	}
+}
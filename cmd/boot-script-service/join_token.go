@@ -55,7 +55,7 @@ type spireRespType struct {
 
 var (
 	spireTokenClient   *http.Client // Spire Token Service client
-	spireTokensBaseURL string
+	spireTokensBaseURL = "https://spire-tokens.spire:54440"
 )
 
 func spireTokenServiceInit(urlBase, opts string) error {
@@ -83,6 +83,13 @@ func spireTokenServiceInit(urlBase, opts string) error {
 	return nil
 }
 
+// Function issuesJoinToken() tells whether the boot script of bd asks for a
+// join token, a new one for every script.
+func issuesJoinToken(bd BootData, role string) bool {
+	params := bd.Params + " " + bd.Kernel.Params + " " + bd.Initrd.Params
+	return strings.Contains(applyRoleParams(params, role), joinTokenVarName)
+}
+
 func getJoinToken(xname, role, subRole string) (string, error) {
 	spireType := ""
 	if strings.EqualFold(role, "Compute") {
@@ -0,0 +1,170 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Bearer token signatures.
+//
+// Tokens are normally verified by the API gateway in front of BSS.  With
+// BSS_JWKS_URL, e.g. Keycloak's
+// /keycloak/realms/shasta/protocol/openid-connect/certs, BSS checks the
+// RS256, RS384 or RS512 signature and the expiry of every token itself, so
+// that the admin roles and the subjects used for approvals cannot be forged
+// by a client reaching BSS directly.  Tokens without a signature algorithm
+// (alg none) are refused either way.
+
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var jwksURL = ""
+
+const (
+	jwksMaxAge       = 10 * time.Minute
+	jwksRefetchDelay = 30 * time.Second
+)
+
+var jwksClient = &http.Client{Timeout: 5 * time.Second}
+
+var jwks struct {
+	sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func fetchJWKS() (map[string]*rsa.PublicKey, error) {
+	resp, err := jwksClient.Get(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", jwksURL, resp.Status)
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, nerr := base64.RawURLEncoding.DecodeString(k.N)
+		e, eerr := base64.RawURLEncoding.DecodeString(k.E)
+		if nerr != nil || eerr != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// Function jwksKey() returns the key kid of the JWKS.  The set is fetched
+// again when it is old, or for an unknown kid at most every
+// jwksRefetchDelay, so that rotated keys are picked up.
+func jwksKey(kid string) (*rsa.PublicKey, error) {
+	jwks.Lock()
+	defer jwks.Unlock()
+	key, ok := jwks.keys[kid]
+	age := time.Since(jwks.fetched)
+	if age > jwksMaxAge || (!ok && age > jwksRefetchDelay) {
+		keys, err := fetchJWKS()
+		if err != nil {
+			if ok {
+				return key, nil
+			}
+			return nil, fmt.Errorf("cannot fetch the JWKS: %s", err)
+		}
+		jwks.keys, jwks.fetched = keys, time.Now()
+		key, ok = keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id '%s'", kid)
+	}
+	return key, nil
+}
+
+// Function verifyTokenSignature() checks the signature of the bearer token
+// split into its parts, if a JWKS is configured, and refuses unsigned
+// tokens.
+func verifyTokenSignature(parts []string) error {
+	var hdr struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if err == nil {
+		err = json.Unmarshal(data, &hdr)
+	}
+	if err != nil {
+		return fmt.Errorf("malformed token header")
+	}
+	if hdr.Alg == "" || strings.EqualFold(hdr.Alg, "none") {
+		return fmt.Errorf("unsigned token")
+	}
+	if jwksURL == "" {
+		return nil
+	}
+	var hash crypto.Hash
+	switch hdr.Alg {
+	case "RS256":
+		hash = crypto.SHA256
+	case "RS384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token algorithm %s", hdr.Alg)
+	}
+	key, err := jwksKey(hdr.Kid)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed token signature")
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), sig); err != nil {
+		return fmt.Errorf("bad token signature")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJWKSVerification(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}}})
+	}))
+	defer srv.Close()
+	defer func(u string) {
+		jwksURL = u
+		jwks.keys, jwks.fetched = nil, time.Time{}
+	}(jwksURL)
+
+	sign := func(claims string) string {
+		msg := b64([]byte(`{"alg":"RS256","kid":"k1"}`)) + "." + b64([]byte(claims))
+		sum := sha256.Sum256([]byte(msg))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+		return msg + "." + b64(sig)
+	}
+	subject := func(token string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return requestSubject(req)
+	}
+
+	if s := subject("eyJhbGciOiJub25lIn0." + b64([]byte(`{"sub":"mallory"}`)) + "."); s != unknownSubject {
+		t.Errorf("Unsigned token accepted for %s", s)
+	}
+	jwksURL = srv.URL
+	if s := subject(sign(`{"sub":"jdoe"}`)); s != "jdoe" {
+		t.Errorf("Signed token returned subject %s", s)
+	}
+	forged := sign(`{"sub":"jdoe"}`)
+	forged = forged[:len(forged)-4] + "AAAA"
+	if s := subject(forged); s != unknownSubject {
+		t.Errorf("Token with a bad signature accepted for %s", s)
+	}
+	if s := subject(testToken(`{"sub":"mallory"}`)[len("Bearer "):]); s != unknownSubject {
+		t.Errorf("Token signed by no known key accepted for %s", s)
+	}
+	if s := subject(sign(`{"sub":"jdoe","exp":1}`)); s != unknownSubject {
+		t.Errorf("Expired token accepted for %s", s)
+	}
+}
@@ -0,0 +1,274 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Kdump configuration.
+//
+// The crashkernel reservation and the kdump target of a node are kept under
+// /boot/v1/kdump/<name>, where name is the node's xname or an HSM role, so
+// they no longer have to be edited into every node's parameters by hand.
+// The node's own entry wins over that of its role.  The boot script gets
+// crashkernel= unless the node's parameters already have it, and the
+// cloud-init meta-data gets a kdump section with both, unless the node's
+// meta-data has one of its own.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	kdumpPfx      = "/kdump/"
+	kdumpEndpoint = baseEndpoint + "/kdump"
+)
+
+// The crashkernel= forms of the kernel documentation: size[@offset],
+// size,high or size,low, and range:size[,range:size...][@offset].
+var (
+	crashSize     = `[0-9]+[KMG]?`
+	crashRange    = crashSize + `-(` + crashSize + `)?:` + crashSize
+	crashKernelRE = regexp.MustCompile(`^(` + crashSize + `(@` + crashSize + `)?|` +
+		crashSize + `,(high|low)|` +
+		crashRange + `(,` + crashRange + `)*(@` + crashSize + `)?)$`)
+)
+
+// Function checkKdump() validates the crashkernel size syntax and the target,
+// an nfs://host/path, ssh://[user@]host/path or file:///path URL.
+func checkKdump(kc bssTypes.KdumpConfig) error {
+	if kc.CrashKernel == "" {
+		return fmt.Errorf("crashkernel is required")
+	}
+	if !crashKernelRE.MatchString(kc.CrashKernel) {
+		return fmt.Errorf("bad crashkernel size '%s'", kc.CrashKernel)
+	}
+	if kc.Target == "" {
+		return nil
+	}
+	u, err := url.Parse(kc.Target)
+	if err != nil {
+		return fmt.Errorf("bad kdump target: %s", err)
+	}
+	switch u.Scheme {
+	case "nfs", "ssh":
+		if u.Host == "" || u.Path == "" {
+			return fmt.Errorf("kdump target %s needs a host and a path", kc.Target)
+		}
+	case "file":
+		if u.Host != "" || u.Path == "" {
+			return fmt.Errorf("kdump target %s needs a local path", kc.Target)
+		}
+	default:
+		return fmt.Errorf("kdump target %s is not an nfs, ssh or file URL", kc.Target)
+	}
+	return nil
+}
+
+func getKdump(name string) (bssTypes.KdumpConfig, bool, error) {
+	kc := bssTypes.KdumpConfig{Name: name}
+	val, exists, err := kvstore.Get(kdumpPfx + name)
+	if err != nil || !exists {
+		return kc, false, err
+	}
+	err = json.Unmarshal([]byte(val), &kc)
+	return kc, err == nil, err
+}
+
+// Function kdumpFor() returns the kdump configuration of a node: its own,
+// or failing that that of its role.
+func kdumpFor(xname, role string) (bssTypes.KdumpConfig, bool) {
+	for _, name := range []string{xname, role} {
+		if name == "" {
+			continue
+		}
+		kc, exists, err := getKdump(name)
+		if err != nil {
+			log.Printf("WARNING: Cannot read the kdump configuration of %s: %s", name, err)
+		}
+		if exists {
+			return kc, true
+		}
+	}
+	return bssTypes.KdumpConfig{}, false
+}
+
+func applyKdumpParams(params, xname, role string) string {
+	kc, ok := kdumpFor(xname, role)
+	if !ok {
+		return params
+	}
+	for _, arg := range strings.Fields(params) {
+		if paramName(arg) == "crashkernel" {
+			return params
+		}
+	}
+	return strings.TrimSpace(params + " crashkernel=" + kc.CrashKernel)
+}
+
+func addKdumpMetaData(xname, role string, metadata map[string]interface{}) {
+	if _, ok := metadata["kdump"]; ok {
+		return
+	}
+	kc, ok := kdumpFor(xname, role)
+	if !ok {
+		return
+	}
+	section := map[string]interface{}{"crashkernel": kc.CrashKernel}
+	if kc.Target != "" {
+		section["target"] = kc.Target
+	}
+	metadata["kdump"] = section
+}
+
+func allKdump() ([]bssTypes.KdumpConfig, error) {
+	kvl, err := kvstore.GetRange(kdumpPfx+keyMin, kdumpPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	ret := []bssTypes.KdumpConfig{}
+	for _, kv := range kvl {
+		var kc bssTypes.KdumpConfig
+		if json.Unmarshal([]byte(kv.Value), &kc) == nil {
+			ret = append(ret, kc)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+// Function kdumpPath() returns the name of a /boot/v1/kdump/<name> path, or
+// "" for the list.
+func kdumpPath(path string) (string, bool) {
+	rest := strings.Trim(strings.TrimPrefix(path, kdumpEndpoint), "/")
+	if strings.Contains(rest, "/") {
+		return "", false
+	}
+	return rest, true
+}
+
+func sendKdump(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func KdumpGet(w http.ResponseWriter, r *http.Request) {
+	debugf("KdumpGet(): Received request %v\n", r.URL)
+	name, ok := kdumpPath(r.URL.Path)
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	if name == "" {
+		all, err := allKdump()
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Cannot read kdump configurations: %s", err))
+			return
+		}
+		sendKdump(w, http.StatusOK, all)
+		return
+	}
+	kc, exists, err := getKdump(name)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read the kdump configuration: %s", err))
+		return
+	}
+	if !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No kdump configuration for %s", name))
+		return
+	}
+	sendKdump(w, http.StatusOK, kc)
+}
+
+func KdumpPut(w http.ResponseWriter, r *http.Request) {
+	debugf("KdumpPut(): Received request %v\n", r.URL)
+	name, ok := kdumpPath(r.URL.Path)
+	if !ok || name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	var kc bssTypes.KdumpConfig
+	if err := json.NewDecoder(r.Body).Decode(&kc); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if kc.Name != "" && kc.Name != name {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: name %s does not match the path", kc.Name))
+		return
+	}
+	kc.Name = name
+	if err := checkKdump(kc); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if err := storeData(kdumpPfx+name, kc); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot store the kdump configuration: %s", err))
+		return
+	}
+	signalChange()
+	log.Printf("Kdump configuration of %s set by %s: crashkernel=%s, target '%s'",
+		name, requestSubject(r), kc.CrashKernel, kc.Target)
+	sendKdump(w, http.StatusOK, kc)
+}
+
+func KdumpDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("KdumpDelete(): Received request %v\n", r.URL)
+	name, ok := kdumpPath(r.URL.Path)
+	if !ok || name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	if _, exists, _ := getKdump(name); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No kdump configuration for %s", name))
+		return
+	}
+	if err := kvstore.Delete(kdumpPfx + name); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot delete the kdump configuration: %s", err))
+		return
+	}
+	signalChange()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestCheckKdump(t *testing.T) {
+	for _, tc := range []struct {
+		kc bssTypes.KdumpConfig
+		ok bool
+	}{
+		{bssTypes.KdumpConfig{CrashKernel: "512M"}, true},
+		{bssTypes.KdumpConfig{CrashKernel: "256M@16M"}, true},
+		{bssTypes.KdumpConfig{CrashKernel: "1G,high"}, true},
+		{bssTypes.KdumpConfig{CrashKernel: "1G-64G:256M,64G-:512M"}, true},
+		{bssTypes.KdumpConfig{CrashKernel: "512M", Target: "nfs://fs1/var/crash"}, true},
+		{bssTypes.KdumpConfig{CrashKernel: "512M", Target: "ssh://dump@fs1/var/crash"}, true},
+		{bssTypes.KdumpConfig{CrashKernel: "512M", Target: "file:///var/crash"}, true},
+		{bssTypes.KdumpConfig{}, false},
+		{bssTypes.KdumpConfig{CrashKernel: "512MB"}, false},
+		{bssTypes.KdumpConfig{CrashKernel: "1G-64G"}, false},
+		{bssTypes.KdumpConfig{CrashKernel: "1G,middle"}, false},
+		{bssTypes.KdumpConfig{CrashKernel: "512M", Target: "http://fs1/var/crash"}, false},
+		{bssTypes.KdumpConfig{CrashKernel: "512M", Target: "nfs://fs1"}, false},
+	} {
+		if err := checkKdump(tc.kc); (err == nil) != tc.ok {
+			t.Errorf("checkKdump(%+v) = %v, expected ok %t", tc.kc, err, tc.ok)
+		}
+	}
+}
+
+func TestKdump(t *testing.T) {
+	const node = "x0c0s2b0n0"
+	savedMode := s3SignerMode
+	s3SignerMode = s3SignerMock
+	defer func() {
+		s3SignerMode = savedMode
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(kdumpPfx + node)
+		kvstore.Delete(kdumpPfx + "Compute")
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "console=ttyS0",
+		Kernel: "s3://boot-images/kdump/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	put := func(name, body string) int {
+		w := httptest.NewRecorder()
+		kdump(w, httptest.NewRequest(http.MethodPut, kdumpEndpoint+"/"+name, strings.NewReader(body)))
+		return w.Code
+	}
+	boot := func() string {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+		return w.Body.String()
+	}
+
+	if code := put("Compute", `{"crashkernel":"512"}`); code != http.StatusOK {
+		t.Fatalf("PUT kdump for the role returned %d", code)
+	}
+	if code := put("Compute", `{"crashkernel":"lots"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected a bad size to be refused, got %d", code)
+	}
+	if script := boot(); !strings.Contains(script, "console=ttyS0 crashkernel=512 ") {
+		t.Errorf("Role crashkernel not in the script:\n%s", script)
+	}
+	if code := put(node, `{"crashkernel":"1G","target":"nfs://fs1/var/crash"}`); code != http.StatusOK {
+		t.Fatalf("PUT kdump for the node returned %d", code)
+	}
+	if script := boot(); !strings.Contains(script, "crashkernel=1G") || strings.Contains(script, "crashkernel=512") {
+		t.Errorf("Node crashkernel not in the script:\n%s", script)
+	}
+	md := map[string]interface{}{}
+	generateMetaData(node, md)
+	section, _ := md["kdump"].(map[string]interface{})
+	if section["crashkernel"] != "1G" || section["target"] != "nfs://fs1/var/crash" {
+		t.Errorf("Unexpected kdump meta-data %v", md["kdump"])
+	}
+
+	if err := Update(bssTypes.BootParams{Hosts: []string{node}, Params: "crashkernel=2G"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if script := boot(); !strings.Contains(script, "crashkernel=2G") || strings.Contains(script, "crashkernel=1G") {
+		t.Errorf("Node params did not override the kdump configuration:\n%s", script)
+	}
+
+	w := httptest.NewRecorder()
+	kdump(w, httptest.NewRequest(http.MethodDelete, kdumpEndpoint+"/"+node, nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DELETE kdump returned %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	kdump(w, httptest.NewRequest(http.MethodGet, kdumpEndpoint, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"Compute"`) ||
+		strings.Contains(w.Body.String(), node) {
+		t.Errorf("GET kdump returned %d: %s", w.Code, w.Body.String())
+	}
+}
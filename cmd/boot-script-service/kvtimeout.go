@@ -0,0 +1,213 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Per-operation deadlines for the datastore.
+//
+// The hmetcd client uses a fixed 5 second context for every operation and
+// none at all for DistLock, so a slow etcd stacks up request goroutines
+// behind it.  timedKvi wraps the store with configurable deadlines per
+// operation type and a limit on the number of operations in flight.  A
+// caller that hits a deadline gets an error right away; the operation itself
+// keeps its slot until hmetcd returns, so a storm cannot start more than
+// etcdMaxInFlight of them.  An update that timed out may still be applied.
+// The store returned by kvWithContext() also gives up when the caller's
+// context is done, e.g. when the client of a boot script request hangs up;
+// the read paths of boot parameters and boot scripts use it.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+var (
+	etcdGetTimeout    = uint(5000) // milliseconds
+	etcdPutTimeout    = uint(5000)
+	etcdDeleteTimeout = uint(5000)
+	etcdLockTimeout   = uint(30000)
+	etcdMaxInFlight   = uint(256)
+)
+
+type timedKvi struct {
+	hmetcd.Kvi
+	store               string // Metrics label
+	get, put, del, lock time.Duration
+	slots               chan struct{}
+	ctx                 context.Context // nil for none
+}
+
+// A contextKvi passes a caller's context on to the timedKvi it wraps.
+type contextKvi interface {
+	withContext(ctx context.Context) hmetcd.Kvi
+}
+
+// Function kvWithContext() returns kv with its operations abandoned when ctx
+// is done, as well as on their deadlines.
+func kvWithContext(kv hmetcd.Kvi, ctx context.Context) hmetcd.Kvi {
+	if c, ok := kv.(contextKvi); ok {
+		return c.withContext(ctx)
+	}
+	return kv
+}
+
+func (kv *timedKvi) withContext(ctx context.Context) hmetcd.Kvi {
+	c := *kv
+	c.ctx = ctx
+	return &c
+}
+
+func newTimedKvi(kv hmetcd.Kvi) *timedKvi {
+	ms := func(v uint) time.Duration { return time.Duration(v) * time.Millisecond }
+	max := etcdMaxInFlight
+	if max == 0 {
+		max = 1
+	}
+	return &timedKvi{Kvi: kv, store: "primary", get: ms(etcdGetTimeout), put: ms(etcdPutTimeout),
+		del: ms(etcdDeleteTimeout), lock: ms(etcdLockTimeout), slots: make(chan struct{}, max)}
+}
+
+// Function run() calls f in its own goroutine and waits at most timeout, or
+// until the context is done, for a slot and for f to finish.  f returns the
+// outcome for the metrics, which count an operation that timed out or was
+// canceled as that only, whatever f returns later.
+func (kv *timedKvi) run(kind, key string, timeout time.Duration, f func() error) error {
+	op := kind + " " + key
+	ctx := kv.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case kv.slots <- struct{}{}:
+	case <-timer.C:
+		observeDatastore(kv.store, kind, "busy", timeout)
+		return fmt.Errorf("datastore %s: no free slot within %v, %d operations in flight", op, timeout, cap(kv.slots))
+	case <-ctx.Done():
+		return fmt.Errorf("datastore %s: %s", op, ctx.Err())
+	}
+	start := time.Now()
+	var observed atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer func() { <-kv.slots }()
+		outcome := "ok"
+		if f() != nil {
+			outcome = "error"
+		}
+		if observed.CompareAndSwap(false, true) {
+			observeDatastore(kv.store, kind, outcome, time.Since(start))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		debugmf(debugDatastore, "%s took %v\n", op, time.Since(start))
+		return nil
+	case <-timer.C:
+		if !observed.CompareAndSwap(false, true) {
+			// f finished just now.
+			<-done
+			return nil
+		}
+		observeDatastore(kv.store, kind, "timeout", timeout)
+		debugmf(debugDatastore, "%s timed out after %v\n", op, timeout)
+		return fmt.Errorf("datastore %s timed out after %v", op, timeout)
+	case <-ctx.Done():
+		if !observed.CompareAndSwap(false, true) {
+			<-done
+			return nil
+		}
+		observeDatastore(kv.store, kind, "canceled", time.Since(start))
+		return fmt.Errorf("datastore %s: %s", op, ctx.Err())
+	}
+}
+
+func (kv *timedKvi) Get(key string) (val string, exists bool, err error) {
+	var v string
+	var ok bool
+	var e error
+	if err = kv.run("get", key, kv.get, func() error { v, ok, e = kv.Kvi.Get(key); return e }); err == nil {
+		val, exists, err = v, ok, e
+	}
+	return
+}
+
+// Ranges come back in key order.  etcd sorts them already, the in-memory
+// store returns them in map order, which would make list responses change
+// between calls.
+func (kv *timedKvi) GetRange(keystart, keyend string) (kvl []hmetcd.Kvi_KV, err error) {
+	var l []hmetcd.Kvi_KV
+	var e error
+	if err = kv.run("get range", keystart, kv.get, func() error {
+		l, e = kv.Kvi.GetRange(keystart, keyend)
+		countDatastoreRows(kv.store, "get range", len(l))
+		return e
+	}); err == nil {
+		sort.Slice(l, func(i, j int) bool { return l[i].Key < l[j].Key })
+		kvl, err = l, e
+	}
+	return
+}
+
+func (kv *timedKvi) Store(key, value string) error {
+	var e error
+	if err := kv.run("store", key, kv.put, func() error { e = kv.Kvi.Store(key, value); return e }); err != nil {
+		return err
+	}
+	return e
+}
+
+func (kv *timedKvi) TAS(key, testval, setval string) (ok bool, err error) {
+	var set bool
+	var e error
+	if err = kv.run("test-and-set", key, kv.put, func() error { set, e = kv.Kvi.TAS(key, testval, setval); return e }); err == nil {
+		ok, err = set, e
+	}
+	return
+}
+
+func (kv *timedKvi) Delete(key string) error {
+	var e error
+	if err := kv.run("delete", key, kv.del, func() error { e = kv.Kvi.Delete(key); return e }); err != nil {
+		return err
+	}
+	return e
+}
+
+// DistLock() waits forever in hmetcd.  Abandoning it on a deadline would
+// leave the lock to be taken later with nobody to release it, so use the
+// timed lock instead.
+func (kv *timedKvi) DistLock() error {
+	secs := int(kv.lock / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return kv.Kvi.DistTimedLock(secs)
+}
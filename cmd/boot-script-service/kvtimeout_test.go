@@ -0,0 +1,138 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+type slowKvi struct {
+	hmetcd.Kvi
+	delay   time.Duration
+	release chan struct{}
+}
+
+func (kv slowKvi) Get(key string) (string, bool, error) {
+	select {
+	case <-time.After(kv.delay):
+	case <-kv.release:
+	}
+	return "value", true, nil
+}
+
+func TestTimedKvi(t *testing.T) {
+	defer func(get, max uint) { etcdGetTimeout, etcdMaxInFlight = get, max }(etcdGetTimeout, etcdMaxInFlight)
+	etcdGetTimeout, etcdMaxInFlight = 50, 1
+
+	release := make(chan struct{})
+	defer close(release)
+	kv := newTimedKvi(slowKvi{delay: time.Hour, release: release})
+	start := time.Now()
+	if _, _, err := kv.Get("/slow"); err == nil {
+		t.Errorf("Get of a stuck datastore succeeded")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Get took %v, expected about %dms", d, etcdGetTimeout)
+	}
+	// The stuck Get still holds the only slot.
+	if _, _, err := kv.Get("/slow"); err == nil {
+		t.Errorf("Get without a free slot succeeded")
+	}
+
+	kv = newTimedKvi(slowKvi{delay: time.Millisecond})
+	if val, exists, err := kv.Get("/fast"); err != nil || !exists || val != "value" {
+		t.Errorf("Get returned %s, %v, %v", val, exists, err)
+	}
+
+	etcdGetTimeout = 60000
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	kvc := kvWithContext(newTimedKvi(slowKvi{delay: time.Hour, release: release}), ctx)
+	start = time.Now()
+	if _, _, err := kvc.Get("/slow"); err == nil || time.Since(start) > time.Second {
+		t.Errorf("Get with a canceled context returned %v after %v", err, time.Since(start))
+	}
+}
+
+func TestTimedKviCountsTimeoutOnce(t *testing.T) {
+	defer func(get uint) { etcdGetTimeout = get }(etcdGetTimeout)
+	etcdGetTimeout = 20
+
+	release := make(chan struct{})
+	kv := newTimedKvi(slowKvi{delay: time.Hour, release: release})
+	kv.store = "timeout-test"
+	if _, _, err := kv.Get("/slow"); err == nil {
+		t.Errorf("Get of a stuck datastore succeeded")
+	}
+	close(release)
+	for i := 0; i < 100 && len(kv.slots) > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	metrics.Lock()
+	defer metrics.Unlock()
+	if h := metrics.latency[labelSet{"timeout-test", "get", "timeout"}]; h == nil || h.count != 1 {
+		t.Errorf("Timeout not counted once: %+v", h)
+	}
+	if h := metrics.latency[labelSet{"timeout-test", "get", "ok"}]; h != nil {
+		t.Errorf("Timed out Get also counted as ok")
+	}
+}
+
+func TestRangeOrder(t *testing.T) {
+	hosts := []string{"x9c0s10b0n0", "x9c0s2b0n0", "x9c0s1b0n0", "x9c0s3b0n0"}
+	defer func() {
+		for _, h := range hosts {
+			kvstore.Delete(paramsPfx + h)
+		}
+	}()
+	for i, h := range hosts {
+		kernel := "http://order/" + hosts[len(hosts)-1-i] + "/kernel"
+		if err, _ := Store(bssTypes.BootParams{Hosts: []string{h}, Kernel: kernel}, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The in-memory store returns ranges in map order, so read a few times.
+	for n := 0; n < 5; n++ {
+		var names, kernels []string
+		for _, bp := range allBootParams(false) {
+			if len(bp.Hosts) == 1 && strings.HasPrefix(bp.Hosts[0], "x9c0s") {
+				names = append(names, bp.Hosts[0])
+			} else if len(bp.Hosts) == 0 && strings.HasPrefix(bp.Kernel, "http://order/") {
+				kernels = append(kernels, bp.Kernel)
+			}
+		}
+		if len(names) != len(hosts) || !sort.StringsAreSorted(names) {
+			t.Fatalf("Boot parameters not listed by name: %v", names)
+		}
+		if len(kernels) != len(hosts) || !sort.StringsAreSorted(kernels) {
+			t.Fatalf("Kernels not listed by path: %v", kernels)
+		}
+	}
+}
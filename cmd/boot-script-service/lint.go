@@ -0,0 +1,411 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Offline boot parameter linter.
+//
+// Run as bss-lint (the image links it to boot-script-service), BSS checks a
+// directory of boot parameter definitions without a datastore or HSM and
+// exits, so that configuration repositories can refuse bad definitions
+// before they are synced to BSS.  Each .json, .yaml or .yml file holds one
+// boot parameters object, as sent to /boot/v1/bootparameters, or a list of
+// them.  The checks are the request schema, host names, the kernel command
+// line, and the syntax of the image and S3 URLs.  An optional policy file
+// lists kernel parameters that must or must not be present.
+//
+// The report is JSON, or one finding per line with -format text.  The exit
+// status is 0 when no errors were found, 1 when some were, and 2 when the
+// definitions could not be read.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+	"gopkg.in/yaml.v3"
+)
+
+const lintCommand = "bss-lint"
+
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+// Tags boot parameters are commonly stored under besides xnames: the BSS
+// tags and the HSM roles.
+var lintKnownTags = map[string]bool{
+	DefaultTag:    true,
+	GlobalTag:     true,
+	"Compute":     true,
+	"Service":     true,
+	"System":      true,
+	"Application": true,
+	"Storage":     true,
+	"Management":  true,
+}
+
+var (
+	lintXnameLike = regexp.MustCompile(`^[xX][0-9]`)
+	lintS3Params  = regexp.MustCompile(s3ParamsRegex)
+)
+
+type lintPolicy struct {
+	Require   []string `json:"require,omitempty" yaml:"require"`
+	Forbid    []string `json:"forbid,omitempty" yaml:"forbid"`
+	MaxLength int      `json:"max-length,omitempty" yaml:"max-length"`
+}
+
+type lintFinding struct {
+	File    string `json:"file"`
+	Entry   int    `json:"entry"`
+	Level   string `json:"level"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+type lintReport struct {
+	Result   string        `json:"result"`
+	Files    int           `json:"files"`
+	Entries  int           `json:"entries"`
+	Findings []lintFinding `json:"findings"`
+}
+
+type lintEntry struct {
+	file  string
+	index int
+}
+
+type linter struct {
+	policy lintPolicy
+	report lintReport
+	hosts  map[string]lintEntry
+}
+
+func (l *linter) add(e lintEntry, level, check, format string, a ...interface{}) {
+	l.report.Findings = append(l.report.Findings,
+		lintFinding{e.file, e.index, level, check, fmt.Sprintf(format, a...)})
+	if level == lintError {
+		l.report.Result = checkFail
+	}
+}
+
+// Function lintDecode() reads a JSON or YAML definition file into generic
+// JSON values, numbers as json.Number as validateJSON() expects.
+func lintDecode(name string, data []byte) ([]interface{}, error) {
+	if ext := filepath.Ext(name); ext == ".yaml" || ext == ".yml" {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if list, ok := v.([]interface{}); ok {
+		return list, nil
+	}
+	return []interface{}{v}, nil
+}
+
+// Function lintParamNames() returns the names of the kernel parameters in
+// params, the part before any '='.
+func lintParamNames(params string) map[string]bool {
+	names := make(map[string]bool)
+	for _, p := range strings.Fields(params) {
+		names[strings.SplitN(p, "=", 2)[0]] = true
+	}
+	return names
+}
+
+// Function lintURL() checks the syntax of an image location: an http, https
+// or s3 URL, or an absolute path served by the artifact proxy.
+func lintURL(u string) error {
+	p, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(p.Scheme) {
+	case "":
+		if !strings.HasPrefix(u, "/") {
+			return fmt.Errorf("'%s' is neither a URL nor an absolute path", u)
+		}
+	case "http", "https":
+		if p.Host == "" {
+			return fmt.Errorf("'%s' has no host", u)
+		}
+	case "s3":
+		if bucket, key := splitS3URL(p); bucket == "" || strings.Trim(key, "/") == "" {
+			return fmt.Errorf("'%s' needs a bucket and a key", u)
+		}
+	default:
+		return fmt.Errorf("'%s' has unsupported scheme %s", u, p.Scheme)
+	}
+	return nil
+}
+
+func (l *linter) lintHosts(e lintEntry, bp bssTypes.BootParams) {
+	for _, h := range bp.Hosts {
+		switch {
+		case lintXnameLike.MatchString(h):
+			if !xnametypes.IsHMSCompIDValid(h) {
+				l.add(e, lintError, "xname", "'%s' is not a valid xname", h)
+			} else if t := xnametypes.GetHMSType(h); t != xnametypes.Node {
+				l.add(e, lintWarning, "xname", "'%s' is a %s, not a node", h, t)
+			}
+		case !lintKnownTags[h]:
+			l.add(e, lintWarning, "xname", "'%s' is neither an xname nor a known tag", h)
+		}
+	}
+	for _, m := range bp.Macs {
+		if _, err := net.ParseMAC(m); err != nil {
+			l.add(e, lintError, "xname", "'%s' is not a MAC address", m)
+		}
+	}
+	for _, n := range bp.Nids {
+		if n <= 0 {
+			l.add(e, lintError, "xname", "%d is not a valid nid", n)
+		}
+	}
+	names := append(append([]string{}, bp.Hosts...), bp.Macs...)
+	for _, n := range bp.Nids {
+		names = append(names, nidName(int(n)))
+	}
+	if len(names) == 0 {
+		l.add(e, lintError, "xname", "Need hosts, macs or nids")
+	}
+	for _, n := range names {
+		if prev, ok := l.hosts[n]; ok {
+			l.add(e, lintError, "xname", "%s is also defined in %s entry %d", n, prev.file, prev.index)
+			continue
+		}
+		l.hosts[n] = e
+	}
+}
+
+func (l *linter) lintParams(e lintEntry, bp bssTypes.BootParams) {
+	if err := checkConditionals(bp.Params); err != nil {
+		l.add(e, lintError, "cmdline", "%s", err)
+	}
+	if l.policy.MaxLength > 0 && len(bp.Params) > l.policy.MaxLength {
+		l.add(e, lintError, "cmdline", "Parameters are %d characters long, more than %d",
+			len(bp.Params), l.policy.MaxLength)
+	}
+	names := lintParamNames(bp.Params)
+	for _, p := range l.policy.Require {
+		if !names[p] {
+			l.add(e, lintError, "cmdline", "Required parameter %s is missing", p)
+		}
+	}
+	for _, p := range l.policy.Forbid {
+		if names[p] {
+			l.add(e, lintError, "cmdline", "Parameter %s is not allowed", p)
+		}
+	}
+	if err := checkPayload(bp.Payload, bp.Files); err != nil {
+		l.add(e, lintError, "cmdline", "%s", err)
+	}
+	if err := checkDeviceFiles(nil, bp.Payload, bp.DTB, bp.ACPI); err != nil {
+		l.add(e, lintError, "cmdline", "%s", err)
+	}
+	if err := checkOverlays(bp.Payload, bp.Initrd, bp.InitrdOverlays); err != nil {
+		l.add(e, lintError, "cmdline", "%s", err)
+	}
+	if err := checkMessages(bp.Messages); err != nil {
+		l.add(e, lintError, "cmdline", "%s", err)
+	}
+	if err := checkReasons(bp.Reasons); err != nil {
+		l.add(e, lintError, "cmdline", "%s", err)
+	}
+	for _, risk := range paramRisks(bp.Params) {
+		l.add(e, lintWarning, "cmdline", "Risky parameters: %s", risk)
+	}
+}
+
+func (l *linter) lintURLs(e lintEntry, bp bssTypes.BootParams) {
+	urls := append([]string{bp.Kernel, bp.Initrd, bp.DTB}, bp.ACPI...)
+	for _, u := range append(urls, bp.InitrdOverlays...) {
+		if u == "" {
+			continue
+		}
+		if err := lintURL(u); err != nil {
+			l.add(e, lintError, "url", "%s", err)
+		}
+	}
+	for _, f := range bp.Files {
+		if err := lintURL(f.Path); err != nil {
+			l.add(e, lintError, "url", "%s", err)
+		}
+	}
+	for _, m := range lintS3Params.FindAllStringSubmatch(bp.Params, -1) {
+		if err := lintURL(m[4]); err != nil {
+			l.add(e, lintError, "url", "%s%s", m[3], err)
+		}
+	}
+}
+
+func (l *linter) lintFile(dir, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	name, _ := filepath.Rel(dir, path)
+	l.report.Files++
+	entries, err := lintDecode(path, data)
+	if err != nil {
+		l.add(lintEntry{name, 0}, lintError, "schema", "%s", err)
+		return nil
+	}
+	for i, v := range entries {
+		e := lintEntry{name, i}
+		l.report.Entries++
+		problems := validateJSON(bootParamsSchema, bootParamsSchema, v, "")
+		for _, p := range problems {
+			l.add(e, lintError, "schema", "%s", p)
+		}
+		if len(problems) > 0 {
+			continue
+		}
+		var bp bssTypes.BootParams
+		raw, _ := json.Marshal(v)
+		if err := json.Unmarshal(raw, &bp); err != nil {
+			l.add(e, lintError, "schema", "%s", err)
+			continue
+		}
+		l.lintHosts(e, bp)
+		l.lintParams(e, bp)
+		l.lintURLs(e, bp)
+	}
+	return nil
+}
+
+// Function lintDir() checks the definition files below dir.
+func lintDir(dir string, policy lintPolicy) (lintReport, error) {
+	l := linter{policy: policy, hosts: make(map[string]lintEntry)}
+	l.report = lintReport{Result: checkOK, Findings: []lintFinding{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".json", ".yaml", ".yml":
+			return l.lintFile(dir, path)
+		}
+		return nil
+	})
+	return l.report, err
+}
+
+func readLintPolicy(path string) (lintPolicy, error) {
+	var p lintPolicy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		err = yaml.Unmarshal(data, &p)
+	} else {
+		err = json.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return p, fmt.Errorf("%s: %s", path, err)
+	}
+	return p, nil
+}
+
+func (r lintReport) text(w io.Writer) {
+	sort.SliceStable(r.Findings, func(i, j int) bool { return r.Findings[i].File < r.Findings[j].File })
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "%s[%d]: %s: %s: %s\n", f.File, f.Entry, f.Level, f.Check, f.Message)
+	}
+	fmt.Fprintf(w, "%s: %d files, %d entries, %d findings\n", r.Result, r.Files, r.Entries, len(r.Findings))
+}
+
+// Function lintMain() is the bss-lint command, returning its exit status.
+func lintMain(args []string, stdout, stderr io.Writer) int {
+	fl := flag.NewFlagSet(lintCommand, flag.ContinueOnError)
+	fl.SetOutput(stderr)
+	policyFile := fl.String("policy", "", "JSON or YAML file with require, forbid and max-length kernel parameter rules")
+	format := fl.String("format", "json", "Report format: json or text")
+	fl.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s [options] directory\n", lintCommand)
+		fl.PrintDefaults()
+	}
+	if err := fl.Parse(args); err != nil {
+		return 2
+	}
+	if fl.NArg() != 1 || (*format != "json" && *format != "text") {
+		fl.Usage()
+		return 2
+	}
+	var policy lintPolicy
+	if *policyFile != "" {
+		var err error
+		if policy, err = readLintPolicy(*policyFile); err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", lintCommand, err)
+			return 2
+		}
+	}
+	report, err := lintDir(fl.Arg(0), policy)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", lintCommand, err)
+		return 2
+	}
+	if *format == "text" {
+		report.text(stdout)
+	} else {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+	if report.Result != checkOK {
+		return 1
+	}
+	return 0
+}
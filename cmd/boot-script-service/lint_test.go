@@ -0,0 +1,108 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLintFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLintClean(t *testing.T) {
+	dir := writeLintFiles(t, map[string]string{
+		"compute.json": `[{"hosts":["x3000c0s19b1n0"],"kernel":"s3://boot-images/k","params":"console=ttyS0 metal.server=s3://boot-images/rootfs"},
+			{"hosts":["Default"],"kernel":"http://s3/kernel"}]`,
+		"uan/uan.yaml": "hosts: [x3000c0s27b0n0]\nkernel: /images/kernel\nnids: [7]\n",
+		"README.md":    "not a definition",
+	})
+	var out, errs bytes.Buffer
+	if rc := lintMain([]string{dir}, &out, &errs); rc != 0 {
+		t.Fatalf("Clean definitions returned %d: %s %s", rc, out.String(), errs.String())
+	}
+	var r lintReport
+	json.Unmarshal(out.Bytes(), &r)
+	if r.Result != checkOK || r.Files != 2 || r.Entries != 3 || len(r.Findings) != 0 {
+		t.Errorf("Unexpected report %+v", r)
+	}
+}
+
+func TestLintFindings(t *testing.T) {
+	dir := writeLintFiles(t, map[string]string{
+		"a.json": `{"hosts":["x3000c0s19b1n0x"],"kernel":"s3://boot-images","params":"rd.break"}`,
+		"b.yaml": "hosts: [x3000c0s19b1n0]\nkernel: kernel\nmacs: [nonsense]\n",
+		"c.json": `{"hosts":["x3000c0s19b1n0"],"kernels":"http://s3/kernel"}`,
+		"d.yml":  "hosts: [Computer]\nparams: metal.server=s3:///\n",
+		"e.json": `{"hosts":`,
+	})
+	policy := filepath.Join(t.TempDir(), "policy.yaml")
+	os.WriteFile(policy, []byte("require: [console]\nforbid: [rd.break]\n"), 0644)
+	var out, errs bytes.Buffer
+	if rc := lintMain([]string{"-policy", policy, "-format", "text", dir}, &out, &errs); rc != 1 {
+		t.Fatalf("Bad definitions returned %d: %s", rc, errs.String())
+	}
+	for _, want := range []string{
+		"a.json[0]: error: xname: 'x3000c0s19b1n0x' is not a valid xname",
+		"a.json[0]: error: url: 's3://boot-images' needs a bucket and a key",
+		"a.json[0]: error: cmdline: Parameter rd.break is not allowed",
+		"a.json[0]: error: cmdline: Required parameter console is missing",
+		"b.yaml[0]: error: url: 'kernel' is neither a URL nor an absolute path",
+		"b.yaml[0]: error: xname: 'nonsense' is not a MAC address",
+		"c.json[0]: error: schema: /kernels: unknown field",
+		"d.yml[0]: warning: xname: 'Computer' is neither an xname nor a known tag",
+		"d.yml[0]: error: url: metal.server='s3:///' needs a bucket and a key",
+		"e.json[0]: error: schema:",
+		"fail: 5 files, 4 entries",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Missing '%s' in:\n%s", want, out.String())
+		}
+	}
+
+	dup := writeLintFiles(t, map[string]string{
+		"a.json": `{"hosts":["x3000c0s19b1n0"],"kernel":"http://s3/kernel"}`,
+		"b.json": `{"hosts":["x3000c0s19b1n0"],"kernel":"http://s3/kernel"}`,
+	})
+	out.Reset()
+	if rc := lintMain([]string{"-format", "text", dup}, &out, &errs); rc != 1 ||
+		!strings.Contains(out.String(), "x3000c0s19b1n0 is also defined in a.json entry 0") {
+		t.Errorf("Duplicate host not found (%d): %s", rc, out.String())
+	}
+	if rc := lintMain([]string{filepath.Join(dir, "missing")}, &out, &errs); rc != 2 {
+		t.Errorf("Missing directory returned %d", rc)
+	}
+}
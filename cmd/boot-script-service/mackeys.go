@@ -0,0 +1,107 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Lower-case MAC address keys.
+//
+// Boot parameters for MAC addresses HSM does not know are stored under the
+// MAC address itself.  Older releases kept the MAC as given, so entries
+// written with upper or mixed case MACs are missed by lookups, which use the
+// lower case form iPXE and HSM report.  New entries are now stored lower
+// case, and existing ones are renamed at startup or on request through
+// /boot/v1/service/mac-keys.  An entry whose lower case key already exists
+// is left alone and reported as a conflict.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+// Function macKeyName() returns the key name for a host name, lower case if
+// it is a MAC address.
+func macKeyName(name string) string {
+	if _, err := net.ParseMAC(name); err == nil {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// Function migrateMACKeys() renames boot parameters stored under a MAC
+// address that is not lower case.
+func migrateMACKeys() (report bssTypes.MACKeyReport) {
+	report.Renamed = []bssTypes.MACKeyRename{}
+	report.Conflicts = []bssTypes.MACKeyRename{}
+	kvl, err := getTags()
+	if err != nil {
+		log.Printf("MAC keys: cannot read boot parameters: %s", err)
+		return report
+	}
+	for _, kv := range kvl {
+		name := extractParamName(kv)
+		key := macKeyName(name)
+		if key == name {
+			continue
+		}
+		rename := bssTypes.MACKeyRename{From: name, To: key}
+		if _, err := lookupHost(key); err == nil {
+			log.Printf("MAC keys: %s already has boot parameters, leaving %s alone", key, name)
+			report.Conflicts = append(report.Conflicts, rename)
+			continue
+		}
+		bds, err := lookupHost(name)
+		if err != nil {
+			continue
+		}
+		if err = storeData(paramsPfx+key, bds); err != nil {
+			log.Printf("MAC keys: cannot store %s: %s", key, err)
+			continue
+		}
+		if err = removeHost(name); err != nil {
+			log.Printf("MAC keys: cannot remove %s after copying it to %s: %s", name, key, err)
+		}
+		log.Printf("MAC keys: renamed boot parameters of %s to %s", name, key)
+		report.Renamed = append(report.Renamed, rename)
+	}
+	return report
+}
+
+func MACKeysPost(w http.ResponseWriter, r *http.Request) {
+	debugf("MACKeysPost(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	report := migrateMACKeys()
+	log.Printf("MAC key migration by %s: %d renamed, %d conflicts", requestSubject(r),
+		len(report.Renamed), len(report.Conflicts))
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,93 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestMigrateMACKeys(t *testing.T) {
+	names := []string{"02:AA:BB:CC:DD:01", "02:aa:bb:cc:dd:01", "02:Aa:bB:cc:DD:02",
+		"02:aa:bb:cc:dd:02", "x9c0s9b0n0"}
+	defer func() {
+		for _, n := range names {
+			kvstore.Delete(paramsPfx + n)
+		}
+	}()
+	for _, n := range []string{"02:AA:BB:CC:DD:01", "02:Aa:bB:cc:DD:02", "x9c0s9b0n0"} {
+		storeData(paramsPfx+n, BootDataStore{Params: "from=" + n})
+	}
+	storeData(paramsPfx+"02:aa:bb:cc:dd:02", BootDataStore{Params: "own"})
+
+	report := migrateMACKeys()
+	has := func(list []bssTypes.MACKeyRename, from, to string) bool {
+		for _, r := range list {
+			if r.From == from && r.To == to {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(report.Renamed, "02:AA:BB:CC:DD:01", "02:aa:bb:cc:dd:01") {
+		t.Errorf("Expected 02:AA:BB:CC:DD:01 renamed, got %+v", report)
+	}
+	if !has(report.Conflicts, "02:Aa:bB:cc:DD:02", "02:aa:bb:cc:dd:02") {
+		t.Errorf("Expected a conflict for 02:Aa:bB:cc:DD:02, got %+v", report)
+	}
+	for n, params := range map[string]string{
+		"02:aa:bb:cc:dd:01": "from=02:AA:BB:CC:DD:01",
+		"02:Aa:bB:cc:DD:02": "from=02:Aa:bB:cc:DD:02",
+		"02:aa:bb:cc:dd:02": "own",
+		"x9c0s9b0n0":        "from=x9c0s9b0n0",
+	} {
+		bds, err := lookupHost(n)
+		if err != nil || bds.Params != params {
+			t.Errorf("%s: expected params %q, got %q (%v)", n, params, bds.Params, err)
+		}
+	}
+	if _, err := lookupHost("02:AA:BB:CC:DD:01"); err == nil {
+		t.Errorf("Expected 02:AA:BB:CC:DD:01 to be gone")
+	}
+	if bd, _ := LookupByMAC("02:AA:BB:CC:DD:01"); bd.Params != "from=02:AA:BB:CC:DD:01" {
+		t.Errorf("Expected an upper case lookup to find the renamed entry, got %q", bd.Params)
+	}
+	if report = migrateMACKeys(); has(report.Renamed, "02:AA:BB:CC:DD:01", "02:aa:bb:cc:dd:01") {
+		t.Errorf("Expected nothing left to rename, got %+v", report)
+	}
+}
+
+func TestMacKeyName(t *testing.T) {
+	for name, want := range map[string]string{
+		"02:AA:BB:CC:DD:01": "02:aa:bb:cc:dd:01",
+		"02-AA-BB-CC-DD-01": "02-aa-bb-cc-dd-01",
+		"x9c0s9b0n0":        "x9c0s9b0n0",
+		"nid12":             "nid12",
+		"Global":            "Global",
+	} {
+		if got := macKeyName(name); got != want {
+			t.Errorf("macKeyName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -44,6 +44,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -114,7 +115,7 @@ func parseEnv(evar string, v interface{}) (ret error) {
 }
 
 func debugf(format string, v ...interface{}) {
-	if debugFlag {
+	if debugOn.Load() {
 		log.Printf("DEBUG: "+format, v...)
 	}
 }
@@ -162,6 +163,8 @@ func kvOpen(url, opts string, retryCount, retryWait uint64) (err error) {
 		if err != nil {
 			log.Println("ERROR opening connection to ETCD (attempt ", ix, "):", err)
 		} else {
+			kvstoreRoot = newTimedKvi(kvstore)
+			kvstore = inNamespace(kvstoreRoot, datastoreNamespace)
 			break
 		}
 
@@ -201,9 +204,6 @@ func getNotifierURL() string {
 }
 
 func main() {
-	insecure := false
-	spireServiceURL := "https://spire-tokens.spire:54440"
-
 	// Note: Default for --hsm is somewhat irrelevant since it is explicitly
 	//       specified in the Dockerfile, and can be overridden via
 	//       an environment variable.  Note that the Dockerfile can also be
@@ -212,29 +212,23 @@ func main() {
 	//       ETCD_HOST and ETCD_PORT, which boot-script-service looks for
 	//       explicitly.  See func kvDefaultURL()
 
-	parseEnv("BSS_HTTP_LISTEN", &httpListen)
-	parseEnv("HSM_URL", &hsmBase)
-	parseEnv("NFD_URL", &nfdBase)
-	parseEnv("DATASTORE_BASE", &datastoreBase)
-	parseEnv("BSS_INSECURE", &insecure)
-	parseEnv("BSS_DEBUG", &debugFlag)
-	parseEnv("BSS_RETRY_DELAY", &retryDelay)
-	parseEnv("BSS_RETRIEVAL_DELAY", &hsmRetrievalDelay)
-	parseEnv("SPIRE_TOKEN_URL", &spireServiceURL)
-	parseEnv("BSS_ADVERTISE_ADDRESS", &advertiseAddress)
-
-	flag.StringVar(&httpListen, "http-listen", httpListen, "HTTP server IP + port binding")
-	flag.StringVar(&hsmBase, "hsm", hsmBase, "Hardware State Manager location as URI, e.g. [scheme]://[host[:port]]")
-	flag.StringVar(&nfdBase, "nfd", nfdBase, "Notification daemon location as URI, e.g. [scheme]://[host[:port]]")
-	flag.StringVar(&datastoreBase, "datastore", kvDefaultURL(), "Datastore Service location as URI")
-	flag.StringVar(&serviceName, "service-name", serviceName, "Boot script service name")
-	flag.StringVar(&spireTokensBaseURL, "spire-url", spireServiceURL, "Spire join token service base URL")
-	flag.StringVar(&advertiseAddress, "cloud-init-address", advertiseAddress, "IP:PORT to advertise for cloud-init calls. This needs to be an IP as we do not have DNS when cloud-init runs")
-	flag.BoolVar(&insecure, "insecure", insecure, "Don't enforce https certificate security")
-	flag.BoolVar(&debugFlag, "debug", debugFlag, "Enable debug output")
-	flag.UintVar(&retryDelay, "retry-delay", retryDelay, "Retry delay in seconds")
-	flag.UintVar(&hsmRetrievalDelay, "hsm-retrieval-delay", hsmRetrievalDelay, "SM Retrieval delay in seconds")
+	if filepath.Base(os.Args[0]) == lintCommand {
+		os.Exit(lintMain(os.Args[1:], os.Stdout, os.Stderr))
+	}
+	if filepath.Base(os.Args[0]) == restoreCommand {
+		os.Exit(restoreMain(os.Args[1:], os.Stdout, os.Stderr))
+	}
+	settingsInit()
 	flag.Parse()
+	if configDocMode {
+		configDoc(os.Stdout, settings)
+		os.Exit(0)
+	}
+	supportInit()
+	fairQueueInit()
+	if err := debugInit(); err != nil {
+		log.Fatalf("Invalid debug settings: %s", err)
+	}
 
 	sn, snerr := base.GetServiceInstanceName()
 	if snerr == nil {
@@ -251,8 +245,22 @@ func main() {
 		svcOpts += "debug"
 	}
 
-	if advertiseAddress == "" {
-		log.Fatalf("--cloud-init-address or BSS_ADVERTISE_ADDRESS required.")
+	report := validateConfig(validateConfigMode)
+	if validateConfigMode {
+		printConfigReport(report)
+		if report.Result != checkOK {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if report.fatal() {
+		log.Fatalf("Invalid configuration:\n%s", report.String())
+	}
+	if report.Result != checkOK {
+		log.Printf("WARNING: configuration problems:\n%s", report.String())
+	}
+	if s3SignerMode == s3SignerMock {
+		log.Printf("WARNING: using mock S3 signer, S3 URLs will be rewritten to %s", s3MockBaseURL)
 	}
 
 	err := SmOpen(hsmBase, svcOpts)
@@ -267,14 +275,82 @@ func main() {
 		log.Fatal("Unable to parse ETCD default")
 	}
 
+	err = datastoreCryptInit()
+	if err != nil {
+		log.Fatalf("Datastore encryption: %s", err)
+	}
+	if sealKey != nil {
+		log.Printf("Datastore encryption enabled with key %s", sealKey.id)
+	}
+	if err = checkNamespace(datastoreNamespace); err != nil {
+		log.Fatalf("Datastore namespace: %s", err)
+	}
 	err = kvOpen(datastoreBase, svcOpts, kvRetyCount, kvRetryWait)
 	if err != nil {
 		log.Fatalf("Access to Datastore service %s with name %s failed: %v\n", datastoreBase, serviceName, err)
 	}
-	err = spireTokenServiceInit(spireServiceURL, svcOpts)
+	namespaceInit()
+	if datastoreMigrateTo != "" {
+		if err = migrateOpen(svcOpts); err != nil {
+			log.Fatalf("Datastore migration to %s failed: %v\n", datastoreMigrateTo, err)
+		}
+	}
+	if datastoreReplica != "" {
+		if err = replicaOpen(svcOpts); err != nil {
+			log.Fatalf("Access to Datastore replica %s failed: %v\n", datastoreReplica, err)
+		}
+	}
+	watchInit()
+	if snapshotDir != "" {
+		if snapshotRestore {
+			if _, err = restoreSnapshot(snapshotDir); err != nil {
+				log.Printf("WARNING: Snapshot restore failed: %s", err)
+			}
+		}
+		go snapshotLoop()
+	}
+	migrateMACKeys()
+	readyInit()
+	err = artifactProxyInit(svcOpts)
+	if err != nil {
+		log.Fatalf("Artifact proxy: %s", err)
+	}
+	err = pcsInit(pcsBase, svcOpts)
+	if err != nil {
+		log.Printf("WARNING: Boot verification disabled: %s", err)
+	}
+	err = consoleInit(consoleBase, svcOpts)
+	if err != nil {
+		log.Printf("WARNING: Console capture disabled: %s", err)
+	}
+	err = auditInit()
+	if err != nil {
+		log.Printf("WARNING: Audit export disabled: %s", err)
+	}
+	if bootGroupSyncInterval > 0 {
+		go bootGroupSyncLoop()
+	}
+	if backfillInterval > 0 {
+		go backfillLoop()
+	}
+	if reconcileInterval > 0 {
+		go reconcileLoop()
+	}
+	if analyticsInterval > 0 {
+		go analyticsLoop()
+	}
+	err = registryInit()
+	if err != nil {
+		log.Printf("WARNING: Service registration disabled: %s", err)
+	}
+	err = spireTokenServiceInit(spireTokensBaseURL, svcOpts)
 	if err != nil {
 		// NOTE: Should this be fatal???  Right now, we will continue.
-		log.Printf("WARNING: Spire join token service %s access failure: %s", spireServiceURL, err)
+		log.Printf("WARNING: Spire join token service %s access failure: %s", spireTokensBaseURL, err)
+	}
+	handler, err := accessLogInit(supportRecorder(authzHandler(http.DefaultServeMux)))
+	if err != nil {
+		log.Fatalf("Access log: %s", err)
 	}
-	log.Fatal(http.ListenAndServe(httpListen, nil))
+	log.Fatal(http.ListenAndServe(httpListen, handler))
 }
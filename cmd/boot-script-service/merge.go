@@ -0,0 +1,269 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Merging duplicate boot parameter entries of a node.
+//
+// Entries added by MAC address or NID before HSM knew the node are moved to
+// the xname by the backfill job, unless the xname already has boot parameters
+// of its own.  A node is then left with two independent entries, and which
+// one it boots with depends on how it asks.  POST /boot/v1/nodes/merge keeps
+// one of them, the winner, under the xname and removes the others, moving
+// their annotations, overrides, first boot records and protection to the
+// xname.  With dry-run set it only reports what it would do.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+// Function duplicateNodes() returns the names of the boot parameter entries
+// of each node that has more than one, the xname first if it has an entry.
+func duplicateNodes() (map[string][]string, error) {
+	kvl, err := getTags()
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool)
+	aliases := make(map[string][]string)
+	for _, kv := range kvl {
+		name := extractParamName(kv)
+		have[name] = true
+		if xname, ok := backfillName(name); ok {
+			aliases[xname] = append(aliases[xname], name)
+		}
+	}
+	dups := make(map[string][]string)
+	for xname, names := range aliases {
+		sort.Strings(names)
+		if have[xname] {
+			names = append([]string{xname}, names...)
+		}
+		if len(names) > 1 {
+			dups[xname] = names
+		}
+	}
+	return dups, nil
+}
+
+// Function mergeWinner() picks the most recently updated entry, preferring
+// the earlier name on a tie.
+func mergeWinner(names []string) string {
+	winner, newest := "", int64(-1)
+	for _, n := range names {
+		bds, err := lookupHost(n)
+		if err != nil {
+			continue
+		}
+		var updated int64
+		if bds.Provenance != nil {
+			updated = bds.Provenance.UpdatedAt
+		}
+		if updated > newest {
+			winner, newest = n, updated
+		}
+	}
+	return winner
+}
+
+// Function mergeAssignments() moves what is kept per host from the alias to
+// the xname, returning what was (or would be) moved.  Overrides, first boot
+// records and protection the xname already has are kept.
+func mergeAssignments(alias, xname string, dryRun bool) (moved []string) {
+	if notes, err := getAnnotations(alias); err == nil && len(notes) > 0 {
+		moved = append(moved, "annotations")
+		if !dryRun {
+			for _, note := range notes {
+				note.Name = xname
+				if err = addAnnotation(note); err != nil {
+					log.Printf("Merge: cannot add annotation of %s to %s: %s", alias, xname, err)
+				}
+			}
+			removeAnnotations(alias)
+		}
+	}
+	if o, ok, err := getOverride(alias); err == nil && ok {
+		if _, exists, _ := kvstore.Get(overridePfx + xname); !exists {
+			moved = append(moved, "override")
+			if !dryRun {
+				o.Name = xname
+				if err = storeData(overridePfx+xname, o); err == nil {
+					kvstore.Delete(overridePfx + alias)
+				}
+			}
+		}
+	}
+	if rec, ok, err := getFirstBootRecord(alias); err == nil && ok {
+		if _, exists, _ := kvstore.Get(firstBootPfx + xname); !exists {
+			moved = append(moved, "firstboot")
+			if !dryRun {
+				if err = storeData(firstBootPfx+xname, rec); err == nil {
+					kvstore.Delete(firstBootPfx + alias)
+				}
+			}
+		}
+	}
+	if val, exists, err := kvstore.Get(protectedPfx + alias); err == nil && exists {
+		if _, exists, _ = kvstore.Get(protectedPfx + xname); !exists {
+			moved = append(moved, "protection")
+			var p bssTypes.ProtectedEntry
+			if !dryRun && json.Unmarshal([]byte(val), &p) == nil {
+				p.Name = xname
+				if err = storeData(protectedPfx+xname, p); err == nil {
+					kvstore.Delete(protectedPfx + alias)
+				}
+			}
+		}
+	}
+	return moved
+}
+
+// Function mergeNode() keeps the winner's boot parameters under the xname
+// and removes the other entries of the node.
+func mergeNode(xname, winner string, names []string, dryRun bool) (bssTypes.NodeMerge, error) {
+	m := bssTypes.NodeMerge{Node: xname, Winner: winner, Removed: []string{}}
+	bds, err := lookupHost(winner)
+	if err != nil {
+		return m, err
+	}
+	if !dryRun && winner != xname {
+		if err = storeData(paramsPfx+xname, bds); err != nil {
+			return m, err
+		}
+	}
+	for _, n := range names {
+		if n == xname {
+			continue
+		}
+		m.Removed = append(m.Removed, n)
+		if !dryRun {
+			if err = removeHost(n); err != nil {
+				log.Printf("Merge: cannot remove %s after merging it into %s: %s", n, xname, err)
+			}
+		}
+		for _, what := range mergeAssignments(n, xname, dryRun) {
+			m.Moved = append(m.Moved, what+":"+n)
+		}
+	}
+	return m, nil
+}
+
+func NodesMergePost(w http.ResponseWriter, r *http.Request) {
+	debugf("NodesMergePost(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var req bssTypes.NodeMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if req.Winner != "" && req.Node == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request - A winner needs a node")
+		return
+	}
+	dups, err := duplicateNodes()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read boot parameters: %s", err))
+		return
+	}
+	var nodes []string
+	if req.Node != "" {
+		if _, ok := dups[req.Node]; !ok {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - %s has no duplicate entries", req.Node))
+			return
+		}
+		nodes = []string{req.Node}
+	} else {
+		for xname := range dups {
+			nodes = append(nodes, xname)
+		}
+		sort.Strings(nodes)
+	}
+	known := req.Winner == ""
+	for _, n := range dups[req.Node] {
+		known = known || n == req.Winner
+	}
+	if !known {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - %s is not an entry of %s: %s", req.Winner, req.Node,
+				strings.Join(dups[req.Node], ", ")))
+		return
+	}
+	if !req.DryRun {
+		var protected []string
+		changed := append([]string{}, nodes...)
+		for _, xname := range nodes {
+			changed = append(changed, dups[xname]...)
+			for _, n := range dups[xname] {
+				if isProtected(n) {
+					protected = append(protected, n)
+				}
+			}
+		}
+		if len(protected) > 0 && !protectionOverridden(r) {
+			base.SendProblemDetailsGeneric(w, http.StatusConflict,
+				fmt.Sprintf("Conflict: %s protected, set %s: true to merge anyway",
+					strings.Join(protected, ", "), protectOverrideHeader))
+			return
+		}
+		if refuseUnstaged(w, changed) {
+			return
+		}
+	}
+
+	report := bssTypes.NodeMergeReport{DryRun: req.DryRun, Merges: []bssTypes.NodeMerge{}}
+	for _, xname := range nodes {
+		winner := req.Winner
+		if winner == "" {
+			winner = mergeWinner(dups[xname])
+		}
+		m, err := mergeNode(xname, winner, dups[xname], req.DryRun)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Cannot merge %s: %s", xname, err))
+			return
+		}
+		report.Merges = append(report.Merges, m)
+	}
+	if !req.DryRun {
+		log.Printf("Node merge by %s: %d nodes merged", requestSubject(r), len(report.Merges))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
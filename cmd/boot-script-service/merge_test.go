@@ -0,0 +1,96 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestNodesMerge(t *testing.T) {
+	const xname, mac = "x0c1s21b0n0", "00:1e:67:d6:24:ce"
+	defer func() {
+		for _, n := range []string{xname, mac} {
+			kvstore.Delete(paramsPfx + n)
+			removeAnnotations(n)
+			kvstore.Delete(overridePfx + n)
+		}
+	}()
+	storeData(paramsPfx+xname, BootDataStore{Params: "own",
+		Provenance: &bssTypes.Provenance{UpdatedAt: 100}})
+	storeData(paramsPfx+mac, BootDataStore{Params: "by-mac",
+		Provenance: &bssTypes.Provenance{UpdatedAt: 200}})
+	addAnnotation(bssTypes.Annotation{Name: mac, Note: "added by MAC"})
+	storeData(overridePfx+mac, bssTypes.BootOverride{Name: mac, Params: "once",
+		Expires: time.Now().Add(time.Hour).Unix()})
+
+	merge := func(body string) (int, bssTypes.NodeMergeReport) {
+		w := httptest.NewRecorder()
+		nodesMerge(w, adminRequest(http.MethodPost, body))
+		var rep bssTypes.NodeMergeReport
+		json.Unmarshal(w.Body.Bytes(), &rep)
+		return w.Code, rep
+	}
+	if code, _ := merge(`{"node":"` + xname + `","winner":"nid1"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown winner to be refused, got %d", code)
+	}
+	if code, _ := merge(`{"winner":"` + mac + `"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected a winner without a node to be refused, got %d", code)
+	}
+
+	code, rep := merge(`{"node":"` + xname + `","dry-run":true}`)
+	if code != http.StatusOK || !rep.DryRun || len(rep.Merges) != 1 {
+		t.Fatalf("Dry run returned %d: %+v", code, rep)
+	}
+	m := rep.Merges[0]
+	if m.Winner != mac || len(m.Removed) != 1 || m.Removed[0] != mac || len(m.Moved) != 2 {
+		t.Errorf("Unexpected dry run merge %+v", m)
+	}
+	if bds, err := lookupHost(mac); err != nil || bds.Params != "by-mac" {
+		t.Errorf("Dry run changed %s: %v", mac, err)
+	}
+
+	if code, rep = merge(`{"node":"` + xname + `"}`); code != http.StatusOK || len(rep.Merges) != 1 {
+		t.Fatalf("Merge returned %d: %+v", code, rep)
+	}
+	if bds, err := lookupHost(xname); err != nil || bds.Params != "by-mac" {
+		t.Errorf("Expected %s to have the winner's params, got %q (%v)", xname, bds.Params, err)
+	}
+	if _, err := lookupHost(mac); err == nil {
+		t.Errorf("Expected %s to be removed", mac)
+	}
+	if notes, _ := getAnnotations(xname); len(notes) != 1 || notes[0].Name != xname {
+		t.Errorf("Expected the annotation moved to %s, got %+v", xname, notes)
+	}
+	if o, ok, _ := getOverride(xname); !ok || o.Name != xname || o.Params != "once" {
+		t.Errorf("Expected the override moved to %s, got %+v", xname, o)
+	}
+	if code, _ = merge(`{"node":"` + xname + `"}`); code != http.StatusNotFound {
+		t.Errorf("Expected nothing left to merge, got %d", code)
+	}
+}
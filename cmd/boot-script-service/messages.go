@@ -0,0 +1,147 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot script messages.
+//
+// The text boot scripts echo to the console comes from named templates.
+// A site sets its own wording, branding or runbook links in the messages
+// of the Global boot parameters and can override them for a host in the
+// host's own boot parameters:
+//
+//	{"hosts": ["Global"], "messages": {"boot-retry": "Boot of {{.Name}} failed, see https://wiki.example.com/boot"}}
+//
+// Messages are text/template templates over messageData.  An empty message
+// leaves its echo out of the script.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+)
+
+const (
+	msgBanner    = "banner"
+	msgBootDeps  = "boot-deps-wait"
+	msgImageInfo = "image-info"
+	msgBootRetry = "boot-retry"
+)
+
+var defaultMessages = map[string]string{
+	msgBanner:    "",
+	msgBootDeps:  "Waiting for boot dependencies...",
+	msgImageInfo: "Could not show image information.",
+	msgBootRetry: "",
+}
+
+// The values message templates can use.
+type messageData struct {
+	Name       string
+	NID        string
+	Role       string
+	SubRole    string
+	RetryDelay uint
+}
+
+type bootMessages struct {
+	text map[string]string
+	data messageData
+}
+
+func parseMessage(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+// Function checkMessages() rejects unknown message names and templates that
+// do not parse.
+func checkMessages(m map[string]string) error {
+	for name, text := range m {
+		if _, ok := defaultMessages[name]; !ok {
+			return fmt.Errorf("unknown message '%s'", name)
+		}
+		if _, err := parseMessage(name, text); err != nil {
+			return fmt.Errorf("message '%s': %s", name, err)
+		}
+	}
+	return nil
+}
+
+// Function scriptMessages() returns the messages of a boot script: the
+// defaults, overridden by those of the Global tag and then by those of bd.
+func scriptMessages(bd BootData, data messageData) bootMessages {
+	text := make(map[string]string, len(defaultMessages))
+	for k, v := range defaultMessages {
+		text[k] = v
+	}
+	if g, err := LookupGlobalData(); err == nil {
+		for k, v := range g.Messages {
+			text[k] = v
+		}
+	}
+	for k, v := range bd.Messages {
+		text[k] = v
+	}
+	return bootMessages{text, data}
+}
+
+// Function lines() returns the non-empty lines of a message.  If the
+// template fails the default text is used.
+func (m bootMessages) lines(name string) []string {
+	var buf strings.Builder
+	t, err := parseMessage(name, m.text[name])
+	if err == nil {
+		err = t.Execute(&buf, m.data)
+	}
+	if err != nil {
+		log.Printf("Message '%s' failed, using the default: %s", name, err)
+		buf.Reset()
+		buf.WriteString(defaultMessages[name])
+	}
+	var ret []string
+	for _, l := range strings.Split(buf.String(), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+// Function echo() returns the iPXE commands printing a message.
+func (m bootMessages) echo(name string) string {
+	script := ""
+	for _, l := range m.lines(name) {
+		script += "echo " + l + "\n"
+	}
+	return script
+}
+
+// Function inline() returns a message as a single echo command, for use
+// after || in iPXE, or an empty string.
+func (m bootMessages) inline(name string) string {
+	if l := m.lines(name); len(l) > 0 {
+		return "echo " + strings.Join(l, " ")
+	}
+	return ""
+}
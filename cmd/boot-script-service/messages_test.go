@@ -0,0 +1,84 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestCheckMessages(t *testing.T) {
+	if err := checkMessages(map[string]string{msgBanner: "Welcome to {{.Name}}", msgBootRetry: ""}); err != nil {
+		t.Errorf("Valid messages rejected: %s", err)
+	}
+	if err := checkMessages(map[string]string{"motd": "hi"}); err == nil {
+		t.Errorf("Unknown message accepted")
+	}
+	if err := checkMessages(map[string]string{msgBanner: "{{.Name"}); err == nil {
+		t.Errorf("Bad template accepted")
+	}
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{"x0c0s9b0n0"}, Kernel: "http://s3/kernel",
+		Messages: map[string]string{"motd": "hi"}}, ""); err == nil {
+		kvstore.Delete(paramsPfx + "x0c0s9b0n0")
+		t.Errorf("Store accepted an unknown message")
+	}
+}
+
+func TestScriptMessages(t *testing.T) {
+	defer kvstore.Delete(paramsPfx + GlobalTag)
+	storeData(paramsPfx+GlobalTag, BootDataStore{Messages: map[string]string{
+		msgBanner:    "Example site\nRunbook: https://wiki.example.com/boot",
+		msgBootRetry: "{{.Name}} did not boot, retrying in {{.RetryDelay}}s",
+		msgImageInfo: "",
+	}})
+	bd := BootData{Kernel: ImageData{Path: "http://s3/kernel"}, Initrd: ImageData{Path: "http://s3/initrd"},
+		Messages: map[string]string{msgBootRetry: "{{.Nope}}"}}
+	script, err := buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	for _, want := range []string{
+		"#!ipxe\necho Example site\necho Runbook: https://wiki.example.com/boot\n",
+		"imgstat ||\n",
+		":boot_retry\nsleep ",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Boot script is missing %q:\n%s", want, script)
+		}
+	}
+
+	bd.Messages = nil
+	script, _ = buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "", "Compute", "", "test")
+	if !strings.Contains(script, ":boot_retry\necho x0c0s1b0n0 did not boot, retrying in 30s\n") {
+		t.Errorf("Boot script is missing the Global retry message:\n%s", script)
+	}
+
+	kvstore.Delete(paramsPfx + GlobalTag)
+	script, _ = buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "", "Compute", "", "test")
+	if !strings.Contains(script, "imgstat || echo Could not show image information.\n") ||
+		strings.Contains(script, ":boot_retry\necho") {
+		t.Errorf("Boot script does not have the default messages:\n%s", script)
+	}
+}
@@ -0,0 +1,152 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Metrics.
+//
+// GET /boot/v1/metrics returns the service metrics in the Prometheus text
+// format.  The datastore operations are timed in histograms by store
+// (primary, replica or migration target), operation and outcome, and range
+// reads count the rows returned, so the queries that blow up during a boot
+// storm stand out.  A gauge counts the NIDs HSM gives to more than one
+// component.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Upper bounds in seconds.
+var latencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets)+1)
+	}
+	i := sort.SearchFloat64s(latencyBuckets, v)
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+type labelSet struct {
+	store, op, outcome string
+}
+
+func (l labelSet) String() string {
+	s := fmt.Sprintf(`store="%s",op="%s"`, l.store, l.op)
+	if l.outcome != "" {
+		s += fmt.Sprintf(`,outcome="%s"`, l.outcome)
+	}
+	return s
+}
+
+var metrics = struct {
+	sync.Mutex
+	latency map[labelSet]*histogram
+	rows    map[labelSet]uint64
+}{latency: make(map[labelSet]*histogram), rows: make(map[labelSet]uint64)}
+
+func observeDatastore(store, op, outcome string, d time.Duration) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	l := labelSet{store, op, outcome}
+	h := metrics.latency[l]
+	if h == nil {
+		h = &histogram{}
+		metrics.latency[l] = h
+	}
+	h.observe(d.Seconds())
+}
+
+func countDatastoreRows(store, op string, n int) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.rows[labelSet{store: store, op: op}] += uint64(n)
+}
+
+func sortedLabels[V any](m map[labelSet]V) []labelSet {
+	ret := make([]labelSet, 0, len(m))
+	for l := range m {
+		ret = append(ret, l)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].String() < ret[j].String() })
+	return ret
+}
+
+func writeMetrics(w io.Writer) {
+	smMutex.RLock()
+	nidDups := len(smNidDups)
+	smMutex.RUnlock()
+	flagged := fallbackFlagged()
+	metrics.Lock()
+	defer metrics.Unlock()
+	fmt.Fprintln(w, "# HELP bss_datastore_operation_seconds Datastore operation latency.")
+	fmt.Fprintln(w, "# TYPE bss_datastore_operation_seconds histogram")
+	for _, l := range sortedLabels(metrics.latency) {
+		h := metrics.latency[l]
+		var cum uint64
+		for i, le := range latencyBuckets {
+			cum += h.counts[i]
+			fmt.Fprintf(w, "bss_datastore_operation_seconds_bucket{%s,le=\"%g\"} %d\n", l, le, cum)
+		}
+		fmt.Fprintf(w, "bss_datastore_operation_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, h.count)
+		fmt.Fprintf(w, "bss_datastore_operation_seconds_sum{%s} %g\n", l, h.sum)
+		fmt.Fprintf(w, "bss_datastore_operation_seconds_count{%s} %d\n", l, h.count)
+	}
+	fmt.Fprintln(w, "# HELP bss_datastore_rows_total Keys returned by datastore range reads.")
+	fmt.Fprintln(w, "# TYPE bss_datastore_rows_total counter")
+	for _, l := range sortedLabels(metrics.rows) {
+		fmt.Fprintf(w, "bss_datastore_rows_total{%s} %d\n", l, metrics.rows[l])
+	}
+	fmt.Fprintln(w, "# HELP bss_hsm_duplicate_nids NIDs claimed by more than one HSM component.")
+	fmt.Fprintln(w, "# TYPE bss_hsm_duplicate_nids gauge")
+	fmt.Fprintf(w, "bss_hsm_duplicate_nids %d\n", nidDups)
+	fmt.Fprintln(w, "# HELP bss_fallback_flagged_nodes Nodes flagged for booting with the Default tag too often.")
+	fmt.Fprintln(w, "# TYPE bss_fallback_flagged_nodes gauge")
+	fmt.Fprintf(w, "bss_fallback_flagged_nodes %d\n", flagged)
+}
+
+func metricsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("metricsGet(): Received request %v\n", r.URL)
+	var sb strings.Builder
+	writeMetrics(&sb)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("Yikes, I couldn't write the metrics: %s\n", err)
+	}
+}
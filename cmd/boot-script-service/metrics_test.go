@@ -0,0 +1,57 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	kv := newTimedKvi(slowKvi{delay: time.Millisecond})
+	kv.store = "metrics-test"
+	kv.Get("/fast")
+	observeDatastore("metrics-test", "store", "error", 20*time.Millisecond)
+	countDatastoreRows("metrics-test", "get range", 7)
+
+	rr := httptest.NewRecorder()
+	metricsAPI(rr, httptest.NewRequest(http.MethodGet, baseEndpoint+"/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET metrics returned %d", rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`bss_datastore_operation_seconds_count{store="metrics-test",op="get",outcome="ok"} 1`,
+		`bss_datastore_operation_seconds_bucket{store="metrics-test",op="store",outcome="error",le="0.01"} 0`,
+		`bss_datastore_operation_seconds_bucket{store="metrics-test",op="store",outcome="error",le="0.025"} 1`,
+		`bss_datastore_operation_seconds_bucket{store="metrics-test",op="store",outcome="error",le="+Inf"} 1`,
+		`bss_datastore_rows_total{store="metrics-test",op="get range"} 7`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics lack %s:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Dual-write datastore migration.
+//
+// While moving BSS to a new datastore, BSS_DATASTORE_MIGRATE_TO names the
+// target.  Every update goes to both stores, reads are served from the
+// current store and compared with the target, and differences are logged and
+// counted, so the target can be validated under the production load before
+// the cut over.  The current store stays authoritative: a failed write to the
+// target is logged and does not fail the request.  Locks and watches only use
+// the current store.  With --datastore-migrate-copy the keys already in the
+// current store are copied to the target at startup.
+//
+// To cut over, point --datastore at the target and drop the migrate option.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync/atomic"
+
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+var (
+	datastoreMigrateTo   = ""
+	datastoreMigrateCopy = false
+
+	migrateMismatches    atomic.Int64
+	migrateWriteFailures atomic.Int64
+)
+
+type dualKvi struct {
+	hmetcd.Kvi
+	target hmetcd.Kvi
+}
+
+func newDualKvi(current, target hmetcd.Kvi) *dualKvi {
+	return &dualKvi{Kvi: current, target: target}
+}
+
+func (kv *dualKvi) withContext(ctx context.Context) hmetcd.Kvi {
+	return newDualKvi(kvWithContext(kv.Kvi, ctx), kvWithContext(kv.target, ctx))
+}
+
+// Values are only logged by digest, they may contain secrets.
+func valueDigest(val string, exists bool) string {
+	if !exists {
+		return "(none)"
+	}
+	sum := sha256.Sum256([]byte(val))
+	return hex.EncodeToString(sum[:6])
+}
+
+func (kv *dualKvi) mismatch(key, cur string, curOK bool, tgt string, tgtOK bool) {
+	migrateMismatches.Add(1)
+	log.Printf("Datastore migration: %s differs, current %s, target %s",
+		key, valueDigest(cur, curOK), valueDigest(tgt, tgtOK))
+}
+
+func (kv *dualKvi) writeFailed(op, key string, err error) {
+	migrateWriteFailures.Add(1)
+	log.Printf("Datastore migration: %s %s on the target failed: %s", op, key, err)
+}
+
+func (kv *dualKvi) Get(key string) (string, bool, error) {
+	val, exists, err := kv.Kvi.Get(key)
+	if err != nil {
+		return val, exists, err
+	}
+	if tval, texists, terr := kv.target.Get(key); terr != nil {
+		log.Printf("Datastore migration: get %s from the target failed: %s", key, terr)
+	} else if tval != val || texists != exists {
+		kv.mismatch(key, val, exists, tval, texists)
+	}
+	return val, exists, nil
+}
+
+func (kv *dualKvi) GetRange(keystart, keyend string) ([]hmetcd.Kvi_KV, error) {
+	kvl, err := kv.Kvi.GetRange(keystart, keyend)
+	if err != nil {
+		return kvl, err
+	}
+	tkvl, terr := kv.target.GetRange(keystart, keyend)
+	if terr != nil {
+		log.Printf("Datastore migration: get range %s from the target failed: %s", keystart, terr)
+		return kvl, nil
+	}
+	tvals := make(map[string]string, len(tkvl))
+	for _, t := range tkvl {
+		tvals[t.Key] = t.Value
+	}
+	for _, c := range kvl {
+		tval, texists := tvals[c.Key]
+		if !texists || tval != c.Value {
+			kv.mismatch(c.Key, c.Value, true, tval, texists)
+		}
+		delete(tvals, c.Key)
+	}
+	for key, tval := range tvals {
+		kv.mismatch(key, "", false, tval, true)
+	}
+	return kvl, nil
+}
+
+func (kv *dualKvi) Store(key, value string) error {
+	if err := kv.Kvi.Store(key, value); err != nil {
+		return err
+	}
+	if err := kv.target.Store(key, value); err != nil {
+		kv.writeFailed("store", key, err)
+	}
+	return nil
+}
+
+func (kv *dualKvi) Delete(key string) error {
+	if err := kv.Kvi.Delete(key); err != nil {
+		return err
+	}
+	if err := kv.target.Delete(key); err != nil {
+		kv.writeFailed("delete", key, err)
+	}
+	return nil
+}
+
+// The test of a test-and-set or transaction is decided by the current store;
+// the target gets its outcome.
+func (kv *dualKvi) TAS(key, testval, setval string) (bool, error) {
+	ok, err := kv.Kvi.TAS(key, testval, setval)
+	if err == nil && ok {
+		if terr := kv.target.Store(key, setval); terr != nil {
+			kv.writeFailed("test-and-set", key, terr)
+		}
+	}
+	return ok, err
+}
+
+func (kv *dualKvi) Transaction(key, op, value, thenkey, thenval, elsekey, elseval string) (bool, error) {
+	ok, err := kv.Kvi.Transaction(key, op, value, thenkey, thenval, elsekey, elseval)
+	if err != nil {
+		return ok, err
+	}
+	setkey, setval := thenkey, thenval
+	if !ok {
+		setkey, setval = elsekey, elseval
+	}
+	if setkey != "" {
+		if terr := kv.target.Store(setkey, setval); terr != nil {
+			kv.writeFailed("transaction", setkey, terr)
+		}
+	}
+	return ok, nil
+}
+
+func (kv *dualKvi) Close() error {
+	kv.target.Close()
+	return kv.Kvi.Close()
+}
+
+// Function migrateCopy() copies the keys of the current store that are
+// missing or different in the target.
+func (kv *dualKvi) migrateCopy() (int, error) {
+	kvl, err := kv.Kvi.GetRange(keyMin, keyMax)
+	if err != nil {
+		return 0, err
+	}
+	copied := 0
+	for _, c := range kvl {
+		if tval, texists, err := kv.target.Get(c.Key); err == nil && texists && tval == c.Value {
+			continue
+		}
+		if err = kv.target.Store(c.Key, c.Value); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// Function migrateOpen() opens the migration target and puts it behind the
+// current store.
+func migrateOpen(opts string) error {
+	target, err := hmetcd.Open(datastoreMigrateTo, opts)
+	if err != nil {
+		return err
+	}
+	timed := newTimedKvi(target)
+	timed.store = "migrate-to"
+	dual := newDualKvi(kvstore, inNamespace(timed, datastoreNamespace))
+	kvstore = dual
+	log.Printf("Datastore migration: writing to both stores, comparing reads with %s", datastoreMigrateTo)
+	if datastoreMigrateCopy {
+		n, err := dual.migrateCopy()
+		if err != nil {
+			return err
+		}
+		log.Printf("Datastore migration: copied %d keys to the target", n)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+// The mem: backing is shared by every handle, so the targets are maps.
+type mapKvi struct {
+	hmetcd.Kvi
+	m map[string]string
+}
+
+func (kv *mapKvi) Get(key string) (string, bool, error) {
+	val, ok := kv.m[key]
+	return val, ok, nil
+}
+
+func (kv *mapKvi) GetRange(keystart, keyend string) ([]hmetcd.Kvi_KV, error) {
+	var kvl []hmetcd.Kvi_KV
+	for k, v := range kv.m {
+		if k >= keystart && k <= keyend {
+			kvl = append(kvl, hmetcd.Kvi_KV{Key: k, Value: v})
+		}
+	}
+	return kvl, nil
+}
+
+func (kv *mapKvi) Store(key, value string) error { kv.m[key] = value; return nil }
+func (kv *mapKvi) Delete(key string) error       { delete(kv.m, key); return nil }
+
+func TestDualKvi(t *testing.T) {
+	current := &mapKvi{m: map[string]string{"/a": "1"}}
+	target := &mapKvi{m: map[string]string{}}
+	dual := newDualKvi(current, target)
+
+	if n, err := dual.migrateCopy(); err != nil || n != 1 || target.m["/a"] != "1" {
+		t.Fatalf("migrateCopy() copied %d: %v, target %v", n, err, target.m)
+	}
+	dual.Store("/b", "2")
+	dual.Delete("/a")
+	if target.m["/b"] != "2" || len(target.m) != 1 {
+		t.Errorf("Writes not mirrored: %v", target.m)
+	}
+
+	before := migrateMismatches.Load()
+	if val, exists, _ := dual.Get("/b"); val != "2" || !exists {
+		t.Errorf("Get() returned %s %v", val, exists)
+	}
+	if migrateMismatches.Load() != before {
+		t.Errorf("Equal values counted as a mismatch")
+	}
+	target.m["/b"] = "other"
+	target.m["/c"] = "3"
+	if val, _, _ := dual.Get("/b"); val != "2" {
+		t.Errorf("Get() served %s from the target", val)
+	}
+	kvl, _ := dual.GetRange("/", "/~")
+	if len(kvl) != 1 || kvl[0].Value != "2" {
+		t.Errorf("GetRange() returned %v", kvl)
+	}
+	if got := migrateMismatches.Load() - before; got != 3 {
+		t.Errorf("Counted %d mismatches, expected 3", got)
+	}
+}
@@ -0,0 +1,335 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Datastore namespaces.
+//
+// Several BSS instances, e.g. prod and staging, can share one etcd cluster.
+// BSS_NAMESPACE names the instance; its keys are kept below ~bss/<name>,
+// which sorts after every key of an instance without a namespace (the
+// "default" one, which keeps the existing unprefixed layout), so neither
+// sees the other.  The namespace applies to the migration target and the
+// read replica as well.  Distributed locks are shared by all instances.
+//
+// /boot/v1/namespaces lists the instances that have started on the
+// datastore and copies boot parameters from another one to that of the
+// instance, e.g. to promote the configuration tested in staging to prod
+// through a prod instance.  The entries are stored as a PUT would store
+// them, so instances using datastore encryption need the same key.  Image
+// records the instance does not have are copied along; those it has,
+// with their parameters and digests, are kept.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+const (
+	defaultNamespace = "default"
+	namespaceRoot    = "~bss/"
+	namespacesPfx    = "/namespaces/" // In the default namespace
+)
+
+var (
+	datastoreNamespace = defaultNamespace
+	namespaceName      = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+	// The primary store without a namespace
+	kvstoreRoot hmetcd.Kvi
+)
+
+type namespaceKvi struct {
+	hmetcd.Kvi
+	pfx string
+}
+
+// Function inNamespace() returns kv as seen by the instance in namespace
+// name.
+func inNamespace(kv hmetcd.Kvi, name string) hmetcd.Kvi {
+	if name == "" || name == defaultNamespace {
+		return kv
+	}
+	return &namespaceKvi{Kvi: kv, pfx: namespaceRoot + name}
+}
+
+func (kv *namespaceKvi) withContext(ctx context.Context) hmetcd.Kvi {
+	return &namespaceKvi{Kvi: kvWithContext(kv.Kvi, ctx), pfx: kv.pfx}
+}
+
+func (kv *namespaceKvi) key(k string) string {
+	if k == "" {
+		return ""
+	}
+	return kv.pfx + k
+}
+
+func (kv *namespaceKvi) Get(key string) (string, bool, error) {
+	return kv.Kvi.Get(kv.key(key))
+}
+
+func (kv *namespaceKvi) GetRange(keystart, keyend string) ([]hmetcd.Kvi_KV, error) {
+	kvl, err := kv.Kvi.GetRange(kv.key(keystart), kv.key(keyend))
+	for i := range kvl {
+		kvl[i].Key = strings.TrimPrefix(kvl[i].Key, kv.pfx)
+	}
+	return kvl, err
+}
+
+func (kv *namespaceKvi) Store(key, value string) error {
+	return kv.Kvi.Store(kv.key(key), value)
+}
+
+func (kv *namespaceKvi) TempKey(key string) error {
+	return kv.Kvi.TempKey(kv.key(key))
+}
+
+func (kv *namespaceKvi) Delete(key string) error {
+	return kv.Kvi.Delete(kv.key(key))
+}
+
+func (kv *namespaceKvi) TAS(key, testval, setval string) (bool, error) {
+	return kv.Kvi.TAS(kv.key(key), testval, setval)
+}
+
+func (kv *namespaceKvi) Transaction(key, op, value, thenkey, thenval, elsekey, elseval string) (bool, error) {
+	return kv.Kvi.Transaction(kv.key(key), op, value, kv.key(thenkey), thenval, kv.key(elsekey), elseval)
+}
+
+func (kv *namespaceKvi) Watch(key string) (string, int) {
+	return kv.Kvi.Watch(kv.key(key))
+}
+
+func (kv *namespaceKvi) WatchWithCB(key string, op int, cb hmetcd.WatchCBFunc, userdata interface{}) (hmetcd.WatchCBHandle, error) {
+	return kv.Kvi.WatchWithCB(kv.key(key), op, func(k, val string, op int, userdata interface{}) bool {
+		return cb(strings.TrimPrefix(k, kv.pfx), val, op, userdata)
+	}, userdata)
+}
+
+func checkNamespace(name string) error {
+	if !namespaceName.MatchString(name) {
+		return fmt.Errorf("invalid namespace '%s': lower case letters, digits and dashes", name)
+	}
+	return nil
+}
+
+// Function namespaceInit() records the namespace of this instance, so that
+// the others can list it.
+func namespaceInit() {
+	err := kvstoreRoot.Store(namespacesPfx+datastoreNamespace, fmt.Sprintf("%d", time.Now().Unix()))
+	if err != nil {
+		log.Printf("WARNING: cannot record namespace %s: %s", datastoreNamespace, err)
+	}
+	if datastoreNamespace != defaultNamespace {
+		log.Printf("Datastore namespace %s", datastoreNamespace)
+	}
+}
+
+// Function namespaceStore() returns the store of namespace name.  That of
+// this instance goes through migration and replica handling.
+func namespaceStore(name string) hmetcd.Kvi {
+	if name == datastoreNamespace {
+		return kvstore
+	}
+	return inNamespace(kvstoreRoot, name)
+}
+
+func NamespacesGet(w http.ResponseWriter, r *http.Request) {
+	debugf("NamespacesGet(): Received request %v\n", r.URL)
+	ns := bssTypes.Namespaces{Current: datastoreNamespace, Known: []string{}}
+	kvl, err := kvstoreRoot.GetRange(namespacesPfx+keyMin, namespacesPfx+keyMax)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve namespaces: %s", err))
+		return
+	}
+	for _, kv := range kvl {
+		ns.Known = append(ns.Known, strings.TrimPrefix(kv.Key, namespacesPfx))
+	}
+	sendNamespaces(w, ns)
+}
+
+// Function copyBootParams() copies boot parameters entries, all if names
+// is empty, from another namespace to this one, along with the records of
+// the images they use that this one does not have.  The entries are stored
+// as PUT would, and a failure leaves none of them changed.
+func copyBootParams(from hmetcd.Kvi, names []string, dryRun bool, who string) (bssTypes.NamespaceCopyReport, error) {
+	var rep bssTypes.NamespaceCopyReport
+	var kvl []hmetcd.Kvi_KV
+	if len(names) == 0 {
+		var err error
+		if kvl, err = from.GetRange(paramsPfx+keyMin, paramsPfx+keyMax); err != nil {
+			return rep, err
+		}
+	}
+	for _, n := range names {
+		val, exists, err := from.Get(paramsPfx + n)
+		if err != nil {
+			return rep, err
+		}
+		if !exists {
+			return rep, fmt.Errorf("no boot parameters for %s", n)
+		}
+		kvl = append(kvl, hmetcd.Kvi_KV{Key: paramsPfx + n, Value: val})
+	}
+
+	var undo kvUndo
+	images := make(map[string]ImageData)
+	image := func(key, imtype string) (string, error) {
+		if key == "" {
+			return "", nil
+		}
+		if im, ok := images[key]; ok {
+			return im.Path, nil
+		}
+		val, exists, err := from.Get(key)
+		if err != nil || !exists {
+			return "", err
+		}
+		var im ImageData
+		if err = json.Unmarshal([]byte(val), &im); err != nil {
+			return "", fmt.Errorf("bad image %s: %s", key, err)
+		}
+		images[key] = im
+		if imageFind(im.Path, imtype) != "" {
+			return im.Path, nil
+		}
+		if _, taken, err := kvstore.Get(key); err != nil || taken {
+			return im.Path, err
+		}
+		rep.Images++
+		if !dryRun {
+			if err = undo.save(key); err == nil {
+				err = kvstore.Store(key, val)
+			}
+		}
+		return im.Path, err
+	}
+	var bps []bssTypes.BootParams
+	for _, kv := range kvl {
+		name := strings.TrimPrefix(kv.Key, paramsPfx)
+		var bds BootDataStore
+		if err := json.Unmarshal([]byte(kv.Value), &bds); err != nil {
+			undo.rollback()
+			return rep, fmt.Errorf("bad boot parameters for %s: %s", name, err)
+		}
+		kernel, err := image(bds.Kernel, kernelImageType)
+		var initrd string
+		if err == nil {
+			initrd, err = image(bds.Initrd, initrdImageType)
+		}
+		if err != nil {
+			undo.rollback()
+			return rep, err
+		}
+		bps = append(bps, bssTypes.BootParams{Hosts: []string{name}, Params: bds.Params,
+			Kernel: kernel, Initrd: initrd, CloudInit: bds.CloudInit, FirstBoot: bds.FirstBoot,
+			Messages: bds.Messages, Labels: bds.Labels, Reasons: bds.Reasons, Payload: bds.Payload,
+			Files: bds.Files, DTB: bds.DTB, ACPI: bds.ACPI, InitrdOverlays: bds.Overlays})
+		rep.Copied = append(rep.Copied, name)
+	}
+	if dryRun {
+		return rep, nil
+	}
+	for _, bp := range bps {
+		err := undo.save(paramsPfx + bp.Hosts[0])
+		if err == nil {
+			err, _ = Store(bp, who)
+		}
+		if err != nil {
+			undo.rollback()
+			return rep, fmt.Errorf("storing %s failed, copy rolled back: %s", bp.Hosts[0], err)
+		}
+	}
+	return rep, nil
+}
+
+func NamespacesPost(w http.ResponseWriter, r *http.Request) {
+	debugf("NamespacesPost(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var req bssTypes.NamespaceCopy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if req.To == "" {
+		req.To = datastoreNamespace
+	}
+	for _, n := range []string{req.From, req.To} {
+		if err := checkNamespace(n); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: %s", err))
+			return
+		}
+	}
+	if req.To != datastoreNamespace {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: copy to %s through its own instance", req.To))
+		return
+	}
+	if req.From == req.To {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: from and to are the same namespace")
+		return
+	}
+	from := namespaceStore(req.From)
+	rep, err := copyBootParams(from, req.Names, true, requestSubject(r))
+	if err == nil && !req.DryRun {
+		if !checkProtection(w, r, bssTypes.BootParams{Hosts: rep.Copied}) || refuseUnstaged(w, rep.Copied) {
+			return
+		}
+		rep, err = copyBootParams(from, req.Names, false, requestSubject(r))
+	}
+	rep.From, rep.To, rep.DryRun = req.From, req.To, req.DryRun
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Copy from %s to %s failed: %s", req.From, req.To, err))
+		return
+	}
+	if !req.DryRun {
+		log.Printf("/namespaces: %d entries and %d images copied from %s to %s by %s",
+			len(rep.Copied), rep.Images, req.From, req.To, requestSubject(r))
+	}
+	sendNamespaces(w, rep)
+}
+
+func sendNamespaces(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
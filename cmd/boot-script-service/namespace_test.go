@@ -0,0 +1,138 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestNamespaceKvi(t *testing.T) {
+	staging := inNamespace(kvstoreRoot, "staging")
+	defer kvstoreRoot.Delete(namespaceRoot + "staging/params/x0c0s7b0n0")
+	if err := staging.Store(paramsPfx+"x0c0s7b0n0", `{"params":"staging"}`); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	if _, exists, _ := kvstoreRoot.Get(namespaceRoot + "staging/params/x0c0s7b0n0"); !exists {
+		t.Errorf("Key not stored below the namespace")
+	}
+	if _, exists, _ := kvstore.Get(paramsPfx + "x0c0s7b0n0"); exists {
+		t.Errorf("Key visible in the default namespace")
+	}
+	all, _ := kvstore.GetRange(keyMin, keyMax)
+	for _, kv := range all {
+		if strings.HasPrefix(kv.Key, namespaceRoot) {
+			t.Errorf("Default namespace range includes %s", kv.Key)
+		}
+	}
+	kvl, err := staging.GetRange(paramsPfx+keyMin, paramsPfx+keyMax)
+	if err != nil || len(kvl) != 1 || kvl[0].Key != paramsPfx+"x0c0s7b0n0" {
+		t.Errorf("Unexpected namespace range %v: %v", kvl, err)
+	}
+	if inNamespace(kvstoreRoot, defaultNamespace) != kvstoreRoot {
+		t.Errorf("The default namespace is prefixed")
+	}
+}
+
+func TestNamespaceCopy(t *testing.T) {
+	const node, bad, image = "x0c0s7b0n0", "x0c0s7b0n1", "/kernel/namespacetest"
+	staging := inNamespace(kvstoreRoot, "staging")
+	defer func() {
+		for _, k := range []string{paramsPfx + node, paramsPfx + bad, image} {
+			staging.Delete(k)
+			kvstore.Delete(k)
+		}
+		kvstoreRoot.Delete(namespacesPfx + "staging")
+	}()
+	kvstoreRoot.Store(namespacesPfx+"staging", "1")
+	staging.Store(image, `{"path":"http://s3/staging-kernel"}`)
+	staging.Store(paramsPfx+node, `{"params":"staging","kernel":"`+image+`"}`)
+
+	send := func(method, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, baseEndpoint+"/namespaces", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		namespaces(w, req)
+		return w
+	}
+	var ns bssTypes.Namespaces
+	json.Unmarshal(send(http.MethodGet, "", "").Body.Bytes(), &ns)
+	if ns.Current != defaultNamespace || !strings.Contains(strings.Join(ns.Known, ","), "staging") {
+		t.Errorf("Unexpected namespaces %+v", ns)
+	}
+
+	admin := testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`)
+	body := `{"from":"staging","names":["` + node + `"]`
+	if w := send(http.MethodPost, "", body+`}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("Unauthenticated copy returned %d", w.Code)
+	}
+	if w := send(http.MethodPost, admin, `{"from":"Staging"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Copy from a bad namespace returned %d", w.Code)
+	}
+	w := send(http.MethodPost, admin, body+`,"dry-run":true}`)
+	var rep bssTypes.NamespaceCopyReport
+	json.Unmarshal(w.Body.Bytes(), &rep)
+	if w.Code != http.StatusOK || len(rep.Copied) != 1 || rep.Images != 1 || rep.To != defaultNamespace {
+		t.Errorf("Unexpected dry run: %d %s", w.Code, w.Body.String())
+	}
+	if _, exists, _ := kvstore.Get(paramsPfx + node); exists {
+		t.Errorf("Dry run copied the entry")
+	}
+	if w = send(http.MethodPost, admin, body+`}`); w.Code != http.StatusOK {
+		t.Fatalf("Copy returned %d: %s", w.Code, w.Body.String())
+	}
+	bd, err := LookupBootData(node)
+	if err != nil || bd.Params != "staging" || bd.Kernel.Path != "http://s3/staging-kernel" {
+		t.Errorf("Entry not promoted: %+v %v", bd, err)
+	}
+
+	// Image records of the target are kept, and a failure copies nothing.
+	kvstore.Store(image, `{"path":"http://s3/staging-kernel","params":"prod"}`)
+	staging.Store(image, `{"path":"http://s3/staging-kernel","params":"staging"}`)
+	staging.Store(paramsPfx+bad, `{"params":"bad","reasons":{"Bad Reason":"x"}}`)
+	staging.Store(paramsPfx+node, `{"params":"staging-2","kernel":"`+image+`"}`)
+	if w = send(http.MethodPost, admin, `{"from":"staging","names":["`+node+`","`+bad+`"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Copy of a bad entry returned %d", w.Code)
+	}
+	if bd, _ = LookupBootData(node); bd.Params != "staging" {
+		t.Errorf("Failed copy changed %s: %+v", node, bd)
+	}
+	if im, _ := readImage(image); im.Params != "prod" {
+		t.Errorf("Copy overwrote the image record: %+v", im)
+	}
+	staging.Store(paramsPfx+DefaultTag, `{"params":"staging"}`)
+	defer staging.Delete(paramsPfx + DefaultTag)
+	if w = send(http.MethodPost, admin, `{"from":"staging","names":["Default"]}`); w.Code != http.StatusConflict {
+		t.Errorf("Copy of protected Default returned %d", w.Code)
+	}
+	if w = send(http.MethodPost, admin, `{"from":"default","to":"staging"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Copy to another namespace returned %d", w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// IP address handling.
+//
+// Nothing in BSS may assume IPv4.  Addresses are compared in their canonical
+// text form, so that the address a node connects from matches the address
+// HSM has for it however either side spells it ("2001:DB8:0::5" and
+// "2001:db8::5" are the same node), and host names put into URLs get the
+// brackets an IPv6 literal needs there.
+
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// Function canonicalIP() returns the canonical text form of an IP address,
+// with any brackets, port and zone removed.  Strings which are not an IP
+// address are returned trimmed but otherwise unchanged.
+func canonicalIP(s string) string {
+	s = strings.TrimSpace(s)
+	if h, _, err := net.SplitHostPort(s); err == nil {
+		s = h
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		s = s[:i]
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.String()
+	}
+	return s
+}
+
+// Function urlHost() returns a host, optionally with a port, in the form it
+// has to take in a URL.  A bare IPv6 literal gets brackets, anything else,
+// including an already bracketed literal, is returned unchanged.
+func urlHost(h string) string {
+	if strings.Contains(h, ":") && net.ParseIP(h) != nil {
+		return "[" + h + "]"
+	}
+	return h
+}
@@ -0,0 +1,127 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+)
+
+func TestCanonicalIP(t *testing.T) {
+	for in, expected := range map[string]string{
+		"10.252.1.16":              "10.252.1.16",
+		"10.252.1.16:40000":        "10.252.1.16",
+		" 10.252.1.16":             "10.252.1.16",
+		"2001:DB8:0:0::5":          "2001:db8::5",
+		"[2001:db8::5]:40000":      "2001:db8::5",
+		"[2001:db8::5]":            "2001:db8::5",
+		"fe80::1%eth0":             "fe80::1",
+		"[fe80::1%eth0]:40000":     "fe80::1",
+		"::ffff:10.252.1.16":       "10.252.1.16",
+		"api-gw-service-nmn.local": "api-gw-service-nmn.local",
+	} {
+		if got := canonicalIP(in); got != expected {
+			t.Errorf("canonicalIP(%q) = %s, expected %s", in, got, expected)
+		}
+	}
+}
+
+func TestURLHost(t *testing.T) {
+	for in, expected := range map[string]string{
+		"api-gw-service-nmn.local": "api-gw-service-nmn.local",
+		"10.92.100.71":             "10.92.100.71",
+		"10.92.100.71:8888":        "10.92.100.71:8888",
+		"2001:db8::1":              "[2001:db8::1]",
+		"[2001:db8::1]:8888":       "[2001:db8::1]:8888",
+		"::ffff:10.92.100.71":      "[::ffff:10.92.100.71]",
+	} {
+		if got := urlHost(in); got != expected {
+			t.Errorf("urlHost(%q) = %s, expected %s", in, got, expected)
+		}
+	}
+}
+
+func TestFindRemoteAddrIPv6(t *testing.T) {
+	for _, tc := range []struct{ remote, xff, expected string }{
+		{"[2001:db8::5]:40000", "", "2001:db8::5"},
+		{"[2001:db8::ff]:40000", "2001:db8::1, 2001:DB8::5", "2001:db8::5"},
+		{"[2001:db8::ff]:40000", "[2001:db8::5]:40000", "2001:db8::5"},
+		{"10.252.1.16:40000", "", "10.252.1.16"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/meta-data", nil)
+		r.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			r.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if got := findRemoteAddr(r); got != tc.expected {
+			t.Errorf("findRemoteAddr(%s, XFF %q) = %s, expected %s", tc.remote, tc.xff, got, tc.expected)
+		}
+	}
+}
+
+// A node on an IPv6 only network gets its own cloud-init data and a boot
+// script chaining to an IPv6 literal.
+func TestIPv6Provisioning(t *testing.T) {
+	const node = "x0c0s2b0n0"
+	state := getState()
+	savedAddrs, savedServer, savedSigner, savedMock := state.IPAddrs, ipxeServer, s3SignerMode, s3MockBaseURL
+	state.IPAddrs = map[string]sm.CompEthInterfaceV2{"2001:db8::10": {CompID: node}}
+	ipxeServer, s3SignerMode, s3MockBaseURL = "2001:db8::1", s3SignerMock, "http://[2001:db8::2]:8080"
+	defer func() {
+		state.IPAddrs, ipxeServer, s3SignerMode, s3MockBaseURL = savedAddrs, savedServer, savedSigner, savedMock
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(makeImageKey(kernelImageType, "s3://boot-images/v6/kernel"))
+	}()
+
+	if xname, found := FindXnameByIP("2001:DB8:0::10"); !found || xname != node {
+		t.Errorf("FindXnameByIP() = %s, %v, expected %s", xname, found, node)
+	}
+
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Kernel: "s3://boot-images/v6/kernel",
+		CloudInit: bssTypes.CloudInit{MetaData: map[string]interface{}{"site": "v6"}}}, "test"); err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/meta-data", nil)
+	r.RemoteAddr = "[2001:db8::10]:40000"
+	w := httptest.NewRecorder()
+	metaDataGet(w, r)
+	if !strings.Contains(w.Body.String(), `"site":"v6"`) {
+		t.Errorf("IPv6 client did not get its own meta-data: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+	if !strings.Contains(w.Body.String(), "kernel --name kernel http://[2001:db8::2]:8080/boot-images/v6/kernel?") {
+		t.Errorf("Kernel URL does not use the IPv6 S3 host:\n%s", w.Body.String())
+	}
+
+	script, _, _ := unknownBootScript("", "00:1e:67:dd:d0:00", "", -1, 0, "", "", "test")
+	if !strings.Contains(script, "chain "+chainProto+"://[2001:db8::1]"+gwURI+"/boot/v1/bootscript?") {
+		t.Errorf("Chain URL does not bracket the IPv6 server:\n%s", script)
+	}
+}
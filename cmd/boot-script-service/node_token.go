@@ -0,0 +1,291 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Node tokens.
+//
+// A booted node has no credentials of its own, so post-boot reports such as
+// the cloud-init phone home are unauthenticated.  Instead of baking secrets
+// into images, a node exchanges the referral token of its boot script
+// (bss_referral_token=) at POST /boot/v1/node-token for a short-lived JWT
+// for its own xname, scoped to the node-side endpoints only.
+//
+// Since the referral token is in the boot script, which anyone can fetch,
+// a token is only issued to the node HSM knows the source address of.
+//
+// The tokens are HS256 signed with BSS_NODE_TOKEN_KEY.  Without one a key
+// is generated and kept in the KV store, sealed with the datastore key, so
+// all replicas share it; without either node tokens cannot be issued.  They
+// are checked by BSS itself, not the API gateway, so nodes reach BSS
+// directly as they do for cloud-init.  A phone home with a node token for
+// another xname is refused; with BSS_NODE_TOKEN_REQUIRED one without a
+// token is refused as well.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	nodeTokenKeyKey   = "/node-token-key"
+	nodeTokenIssuer   = "bss"
+	nodeTokenAudience = "bss-node"
+	scopePhoneHome    = "phone-home"
+)
+
+var (
+	nodeTokenKey      = ""
+	nodeTokenTTL      = uint(900)
+	nodeTokenRequired = false
+)
+
+type nodeTokenClaims struct {
+	Issuer   string `json:"iss"`
+	Audience string `json:"aud"`
+	Subject  string `json:"sub"`
+	Scope    string `json:"scope"`
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+}
+
+// Function nodeTokenSigningKey() returns the key node tokens are signed
+// with.
+func nodeTokenSigningKey() ([]byte, error) {
+	if nodeTokenKey != "" {
+		return []byte(nodeTokenKey), nil
+	}
+	if sealKey == nil {
+		return nil, fmt.Errorf("node tokens need BSS_NODE_TOKEN_KEY or BSS_DATASTORE_KEY")
+	}
+	val, exists, err := kvstore.Get(nodeTokenKeyKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		key := make([]byte, 32)
+		if _, err = rand.Read(key); err != nil {
+			return nil, err
+		}
+		sealed, err := seal(key)
+		if err != nil {
+			return nil, err
+		}
+		// The key is only stored if no other replica stored one first, so
+		// tokens signed with it stay valid.
+		if _, err = storeIfMissing(nodeTokenKeyKey, sealed); err != nil {
+			return nil, err
+		}
+		if val, exists, err = kvstore.Get(nodeTokenKeyKey); err != nil || !exists {
+			return nil, fmt.Errorf("node token key vanished: %v", err)
+		}
+	}
+	if !strings.HasPrefix(val, sealedPrefix) {
+		// Kept in clear by an earlier version, seal it.
+		key, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return nil, err
+		}
+		if sealed, err := seal(key); err == nil {
+			kvstore.TAS(nodeTokenKeyKey, val, sealed)
+		}
+		return key, nil
+	}
+	return unseal(val)
+}
+
+func signNodeToken(claims nodeTokenClaims) (string, error) {
+	key, err := nodeTokenSigningKey()
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	msg := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return msg + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Function verifyNodeToken() checks the signature, audience and expiry of a
+// node token.
+func verifyNodeToken(token string) (nodeTokenClaims, error) {
+	var claims nodeTokenClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("malformed token")
+	}
+	key, err := nodeTokenSigningKey()
+	if err != nil {
+		return claims, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, fmt.Errorf("malformed signature")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return claims, fmt.Errorf("bad signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err == nil {
+		err = json.Unmarshal(payload, &claims)
+	}
+	if err != nil {
+		return claims, fmt.Errorf("malformed claims")
+	}
+	if claims.Issuer != nodeTokenIssuer || claims.Audience != nodeTokenAudience {
+		return claims, fmt.Errorf("not a node token")
+	}
+	if claims.Expires <= time.Now().Unix() {
+		return claims, fmt.Errorf("token expired")
+	}
+	return claims, nil
+}
+
+// Function requestNodeToken() reports whether the bearer token of the
+// request claims to be a node token, and its claims if it is valid.
+func requestNodeToken(r *http.Request) (nodeTokenClaims, bool, error) {
+	var claims nodeTokenClaims
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return claims, false, nil
+	}
+	token := strings.TrimSpace(auth[7:])
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false, nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims.Audience != nodeTokenAudience {
+		return claims, false, nil
+	}
+	claims, err = verifyNodeToken(token)
+	return claims, true, err
+}
+
+// Function checkNodeToken() authorizes a node-side request of xname for
+// scope.  On failure it sends the error response and returns false.
+func checkNodeToken(w http.ResponseWriter, r *http.Request, xname, scope string) bool {
+	claims, present, err := requestNodeToken(r)
+	if !present {
+		if nodeTokenRequired {
+			base.SendProblemDetailsGeneric(w, http.StatusUnauthorized, "A node token is required")
+			return false
+		}
+		return true
+	}
+	if err == nil && claims.Subject != xname {
+		err = fmt.Errorf("token is for %s", claims.Subject)
+	}
+	if err == nil && !strings.Contains(" "+claims.Scope+" ", " "+scope+" ") {
+		err = fmt.Errorf("token is not valid for %s", scope)
+	}
+	if err != nil {
+		log.Printf("Node token of %s refused: %s", xname, err)
+		auditEmit(auditAuthFailure, xname, findRemoteAddr(r), r.URL.Path, "Node token refused: %s", err)
+		base.SendProblemDetailsGeneric(w, http.StatusUnauthorized,
+			fmt.Sprintf("Invalid node token: %s", err))
+		return false
+	}
+	return true
+}
+
+func NodeTokenPost(w http.ResponseWriter, r *http.Request) {
+	debugf("NodeTokenPost(): Received request %v\n", r.URL)
+	var req bssTypes.NodeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if req.ReferralToken == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: referral-token is required")
+		return
+	}
+	remote := findRemoteAddr(r)
+	xname, found := FindXnameByIP(remote)
+	switch {
+	case !found:
+		log.Printf("Node token requested from %s, which HSM does not know", remote)
+		auditEmit(auditAuthFailure, req.Xname, remote, r.URL.Path, "Node token requested from an unknown address")
+		base.SendProblemDetailsGeneric(w, http.StatusForbidden,
+			fmt.Sprintf("Forbidden: %s is not the address of a known node", remote))
+		return
+	case req.Xname == "":
+		req.Xname = xname
+	case xname != req.Xname:
+		log.Printf("Node token for %s requested from %s, which belongs to %s", req.Xname, remote, xname)
+		auditEmit(auditAuthFailure, req.Xname, remote, r.URL.Path,
+			"Node token requested from the address of %s", xname)
+		base.SendProblemDetailsGeneric(w, http.StatusForbidden,
+			fmt.Sprintf("Forbidden: %s is not %s", remote, req.Xname))
+		return
+	}
+	bd, _ := LookupByName(req.Xname)
+	if bd.ReferralToken == "" ||
+		subtle.ConstantTimeCompare([]byte(bd.ReferralToken), []byte(req.ReferralToken)) != 1 {
+		log.Printf("Node token for %s refused: referral token does not match", req.Xname)
+		auditEmit(auditAuthFailure, req.Xname, remote, r.URL.Path, "Referral token does not match")
+		base.SendProblemDetailsGeneric(w, http.StatusForbidden,
+			"Forbidden: referral token does not match")
+		return
+	}
+	now := time.Now()
+	claims := nodeTokenClaims{Issuer: nodeTokenIssuer, Audience: nodeTokenAudience,
+		Subject: req.Xname, Scope: scopePhoneHome, IssuedAt: now.Unix(),
+		Expires: now.Add(time.Duration(nodeTokenTTL) * time.Second).Unix()}
+	token, err := signNodeToken(claims)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to sign node token: %s", err))
+		return
+	}
+	log.Printf("Node token issued to %s (%s) until %s", req.Xname, remote,
+		time.Unix(claims.Expires, 0).UTC().Format(time.RFC3339))
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	noStore(w)
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(bssTypes.NodeToken{Token: token, Expires: claims.Expires,
+		Scope: strings.Fields(claims.Scope)})
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,144 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+)
+
+func TestNodeToken(t *testing.T) {
+	const ip, node = "10.252.1.13", "x0c0s3b0n0"
+	state := getState()
+	savedAddrs, savedRequired := state.IPAddrs, nodeTokenRequired
+	state.IPAddrs = map[string]sm.CompEthInterfaceV2{ip: {CompID: node}}
+	defer func() {
+		state.IPAddrs, nodeTokenRequired = savedAddrs, savedRequired
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(nodeTokenKeyKey)
+	}()
+	t.Setenv("BSS_DATASTORE_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	datastoreCryptInit()
+	defer func() {
+		os.Unsetenv("BSS_DATASTORE_KEY")
+		datastoreCryptInit()
+	}()
+	err, referral := Store(bssTypes.BootParams{Hosts: []string{node}, Kernel: "http://s3/kernel"}, "")
+	if err != nil {
+		t.Fatalf("Store failed: %s", err)
+	}
+
+	mint := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/node-token", strings.NewReader(body))
+		req.RemoteAddr = ip + ":4011"
+		w := httptest.NewRecorder()
+		nodeToken(w, req)
+		return w
+	}
+	if w := mint(`{"referral-token":"nope"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Wrong referral token returned %d", w.Code)
+	}
+	if w := mint(`{"xname":"x0c0s1b0n0","referral-token":"` + referral + `"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Token for another node's xname returned %d", w.Code)
+	}
+	unknown := httptest.NewRequest(http.MethodPost, baseEndpoint+"/node-token",
+		strings.NewReader(`{"xname":"`+node+`","referral-token":"`+referral+`"}`))
+	unknown.RemoteAddr = "10.252.9.9:4011"
+	uw := httptest.NewRecorder()
+	nodeToken(uw, unknown)
+	if uw.Code != http.StatusForbidden {
+		t.Errorf("Token for an unknown address returned %d", uw.Code)
+	}
+	w := mint(`{"referral-token":"` + referral + `"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Minting failed: %d %s", w.Code, w.Body.String())
+	}
+	var tok bssTypes.NodeToken
+	json.Unmarshal(w.Body.Bytes(), &tok)
+	claims, err := verifyNodeToken(tok.Token)
+	if err != nil || claims.Subject != node || tok.Expires > time.Now().Unix()+int64(nodeTokenTTL) {
+		t.Errorf("Unexpected token %+v: %+v %v", tok, claims, err)
+	}
+	if _, err = verifyNodeToken(tok.Token[:len(tok.Token)-2] + "AA"); err == nil {
+		t.Errorf("Token with a bad signature verified")
+	}
+	claims.Expires = time.Now().Unix() - 1
+	expired, _ := signNodeToken(claims)
+	claims.Subject, claims.Expires = "x0c0s1b0n0", time.Now().Unix()+60
+	other, _ := signNodeToken(claims)
+
+	phoneHome := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/phone-home", strings.NewReader(`{"hostname":"nid000003"}`))
+		req.RemoteAddr = ip + ":4011"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		phoneHomePost(w, req)
+		return w.Code
+	}
+	for name, token := range map[string]string{"expired": expired, "other node": other} {
+		if code := phoneHome(token); code != http.StatusUnauthorized {
+			t.Errorf("Phone home with the %s token returned %d", name, code)
+		}
+	}
+	if code := phoneHome(tok.Token); code != http.StatusOK {
+		t.Errorf("Phone home with a node token returned %d", code)
+	}
+	nodeTokenRequired = true
+	if code := phoneHome(""); code != http.StatusUnauthorized {
+		t.Errorf("Phone home without a required token returned %d", code)
+	}
+}
+
+func TestNodeTokenSigningKey(t *testing.T) {
+	defer kvstore.Delete(nodeTokenKeyKey)
+	kvstore.Delete(nodeTokenKeyKey)
+	if _, err := nodeTokenSigningKey(); err == nil {
+		t.Errorf("Key generated without a datastore key")
+	}
+	t.Setenv("BSS_DATASTORE_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	datastoreCryptInit()
+	defer func() {
+		os.Unsetenv("BSS_DATASTORE_KEY")
+		datastoreCryptInit()
+	}()
+	first, err := nodeTokenSigningKey()
+	if err != nil || len(first) != 32 {
+		t.Fatalf("Key creation returned %v, %v", first, err)
+	}
+	if again, err := nodeTokenSigningKey(); err != nil || string(again) != string(first) {
+		t.Errorf("Stored key was replaced: %v, %v", again, err)
+	}
+	if val, _, _ := kvstore.Get(nodeTokenKeyKey); !strings.HasPrefix(val, sealedPrefix) {
+		t.Errorf("Key stored in clear: %s", val)
+	}
+}
@@ -0,0 +1,207 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Temporary boot overrides.
+//
+// POST /boot/v1/override/{xname} makes a node boot another configuration,
+// e.g. memtest or a rescue image, for a limited time.  Until the TTL runs
+// out or the override is deleted, lookups for the node return the override
+// in place of its regular (or first boot) configuration; afterwards the node
+// boots as before without anything to undo.  Expired overrides are removed
+// the next time they are looked at.  Instead of a kernel an override can
+// name a profile from the rescue catalog, see rescue.go.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const overridePfx = "/overrides/"
+
+var overrideMaxTTL = uint(86400)
+
+func getOverride(name string) (bssTypes.BootOverride, bool, error) {
+	var o bssTypes.BootOverride
+	val, exists, err := kvstore.Get(overridePfx + name)
+	if err != nil || !exists {
+		return o, false, err
+	}
+	if err = json.Unmarshal([]byte(val), &o); err != nil {
+		return o, false, err
+	}
+	if o.Expires <= time.Now().Unix() {
+		if err = kvstore.Delete(overridePfx + name); err == nil {
+			log.Printf("Boot override of %s expired, back to its regular configuration", name)
+			signalChange()
+		}
+		return o, false, nil
+	}
+	return o, true, nil
+}
+
+// Function applyOverride() replaces the boot configuration of a node with
+// its override, if it has one.
+func applyOverride(name string, bd *BootData) {
+	if name == "" {
+		return
+	}
+	o, ok, err := getOverride(name)
+	if err != nil {
+		log.Printf("Failed to retrieve boot override of %s: %s", name, err)
+	}
+	if !ok {
+		return
+	}
+	applyProfile(bssTypes.RescueTarget{Kernel: o.Kernel, Initrd: o.Initrd, Params: o.Params}, bd)
+}
+
+func overrideName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, baseEndpoint+"/override"), "/")
+	if name == "" || strings.Contains(name, "/") {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Bad Request: need /override/{xname}")
+		return "", false
+	}
+	return name, true
+}
+
+func sendOverrides(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// GET /override lists the active overrides, GET /override/{xname} shows one.
+func OverrideGet(w http.ResponseWriter, r *http.Request) {
+	debugf("OverrideGet(): Received request %v\n", r.URL)
+	if strings.Trim(strings.TrimPrefix(r.URL.Path, baseEndpoint+"/override"), "/") == "" {
+		kvl, err := kvstore.GetRange(overridePfx+keyMin, overridePfx+keyMax)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to retrieve boot overrides: %s", err))
+			return
+		}
+		overrides := []bssTypes.BootOverride{}
+		for _, kv := range kvl {
+			if o, ok, _ := getOverride(strings.TrimPrefix(kv.Key, overridePfx)); ok {
+				overrides = append(overrides, o)
+			}
+		}
+		sendOverrides(w, http.StatusOK, overrides)
+		return
+	}
+	name, ok := overrideName(w, r)
+	if !ok {
+		return
+	}
+	o, ok, err := getOverride(name)
+	if err != nil || !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No boot override for %s", name))
+		return
+	}
+	sendOverrides(w, http.StatusOK, o)
+}
+
+func OverridePost(w http.ResponseWriter, r *http.Request) {
+	debugf("OverridePost(): Received request %v\n", r.URL)
+	name, ok := overrideName(w, r)
+	if !ok {
+		return
+	}
+	var o bssTypes.BootOverride
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if o.Profile != "" {
+		t, found, err := resolveProfile(o.Profile)
+		if err != nil || !found {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: unknown profile %s", o.Profile))
+			return
+		}
+		o.Kernel, o.Initrd = t.Kernel, t.Initrd
+		if o.Params == "" {
+			o.Params = t.Params
+		}
+	}
+	if o.Kernel == "" || o.TTL == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: a kernel or profile and a ttl are required")
+		return
+	}
+	if o.TTL > overrideMaxTTL {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: ttl is limited to %d seconds", overrideMaxTTL))
+		return
+	}
+	if imageStore(o.Kernel, kernelImageType) == "" ||
+		(o.Initrd != "" && imageStore(o.Initrd, initrdImageType) == "") {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			"Cannot store the override images")
+		return
+	}
+	o.Name, o.CreatedBy = name, requestSubject(r)
+	o.Expires = time.Now().Add(time.Duration(o.TTL) * time.Second).Unix()
+	if err := storeData(overridePfx+name, o); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store boot override: %s", err))
+		return
+	}
+	signalChange()
+	log.Printf("/override POST: %s boots %s for %ds (%s) by %s", name, o.Kernel, o.TTL, o.Reason, o.CreatedBy)
+	sendOverrides(w, http.StatusCreated, o)
+}
+
+func OverrideDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("OverrideDelete(): Received request %v\n", r.URL)
+	name, ok := overrideName(w, r)
+	if !ok {
+		return
+	}
+	if _, ok, _ = getOverride(name); !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No boot override for %s", name))
+		return
+	}
+	if err := kvstore.Delete(overridePfx + name); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove boot override of %s: %s", name, err))
+		return
+	}
+	signalChange()
+	log.Printf("/override DELETE: %s by %s", name, requestSubject(r))
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootOverride(t *testing.T) {
+	const node = "x0c0s1b0n0"
+	defer kvstore.Delete(overridePfx + node)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		override(w, req)
+		return w
+	}
+	regular, _ := LookupByName(node)
+
+	if w := send(http.MethodPost, baseEndpoint+"/override/"+node, `{"kernel":"http://s3/memtest"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Override without ttl returned %d", w.Code)
+	}
+	if w := send(http.MethodPost, baseEndpoint+"/override/"+node, `{"kernel":"http://s3/memtest","ttl":999999}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Override with a too long ttl returned %d", w.Code)
+	}
+	w := send(http.MethodPost, baseEndpoint+"/override/"+node,
+		`{"kernel":"http://s3/memtest","params":"console=ttyS0","ttl":600,"reason":"memory errors"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Override returned %d: %s", w.Code, w.Body.String())
+	}
+	var o bssTypes.BootOverride
+	json.Unmarshal(w.Body.Bytes(), &o)
+	if o.Name != node || o.Expires < time.Now().Unix()+590 {
+		t.Errorf("Unexpected override %+v", o)
+	}
+	bd, _ := LookupByName(node)
+	if bd.Kernel.Path != "http://s3/memtest" || bd.Initrd.Path != "" || bd.Params != "console=ttyS0" {
+		t.Errorf("Override not applied: %+v", bd)
+	}
+	var list []bssTypes.BootOverride
+	json.Unmarshal(send(http.MethodGet, baseEndpoint+"/override", "").Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Reason != "memory errors" {
+		t.Errorf("Unexpected overrides %+v", list)
+	}
+
+	if w = send(http.MethodDelete, baseEndpoint+"/override/"+node, ""); w.Code != http.StatusOK {
+		t.Errorf("Clearing the override returned %d", w.Code)
+	}
+	if bd, _ = LookupByName(node); bd.Kernel.Path != regular.Kernel.Path {
+		t.Errorf("Regular configuration not restored: %+v", bd)
+	}
+
+	o.Expires = time.Now().Unix() - 1
+	storeData(overridePfx+node, o)
+	if bd, _ = LookupByName(node); bd.Kernel.Path != regular.Kernel.Path {
+		t.Errorf("Expired override applied: %+v", bd)
+	}
+	if _, exists, _ := kvstore.Get(overridePfx + node); exists {
+		t.Errorf("Expired override not removed")
+	}
+	if w = send(http.MethodGet, baseEndpoint+"/override/"+node, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expired override returned %d", w.Code)
+	}
+}
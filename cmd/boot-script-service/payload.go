@@ -0,0 +1,143 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Non-Linux payloads.
+//
+// Besides a Linux kernel and initrd an entry can boot WinPE through
+// wimboot or ESXi through mboot.  The kernel is then the wimboot or mboot
+// binary and files lists what it loads:
+//
+//	wimboot: BCD, boot.sdi and boot.wim, plus any other named file; each
+//	         becomes an "initrd <url> <name>" line for wimboot to find.
+//	mboot:   a boot.cfg, passed with -c, or else the ESXi modules in order,
+//	         passed separated by "---".
+//
+// Params go to the wimboot or mboot command line as they are; none of the
+// Linux parameters (xname=, ds=, ...) are added.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	payloadLinux   = "linux"
+	payloadWimboot = "wimboot"
+	payloadMboot   = "mboot"
+	mbootConfig    = "boot.cfg"
+)
+
+var wimbootRequired = []string{"BCD", "boot.sdi", "boot.wim"}
+
+// Function checkPayload() validates the payload type of an entry and the
+// files it needs.
+func checkPayload(payload string, files []bssTypes.BootFile) error {
+	names := make(map[string]bool)
+	for _, f := range files {
+		if f.Path == "" {
+			return fmt.Errorf("File %s has no path", f.Name)
+		}
+		if names[f.Name] && f.Name != "" {
+			return fmt.Errorf("File %s is listed twice", f.Name)
+		}
+		names[f.Name] = true
+	}
+	switch payload {
+	case "", payloadLinux:
+		if len(files) > 0 {
+			return fmt.Errorf("Files need a wimboot or mboot payload")
+		}
+	case payloadWimboot:
+		for _, n := range wimbootRequired {
+			if !names[n] {
+				return fmt.Errorf("The wimboot payload needs the file %s", n)
+			}
+		}
+		if names[""] {
+			return fmt.Errorf("Every wimboot file needs a name")
+		}
+	case payloadMboot:
+		if len(files) == 0 {
+			return fmt.Errorf("The mboot payload needs a %s or the ESXi modules", mbootConfig)
+		}
+		if names[mbootConfig] && len(files) > 1 {
+			return fmt.Errorf("The mboot modules are listed in %s", mbootConfig)
+		}
+	default:
+		return fmt.Errorf("Unknown payload %s, use %s, %s or %s", payload, payloadLinux, payloadWimboot, payloadMboot)
+	}
+	return nil
+}
+
+// Function patchPayload() returns the payload and files of an entry after
+// applying bp to it.
+func patchPayload(bp bssTypes.BootParams, bd BootDataStore) (string, []bssTypes.BootFile) {
+	payload, files := bd.Payload, bd.Files
+	if bp.Payload != "" {
+		payload = bp.Payload
+	}
+	if bp.Files != nil {
+		files = bp.Files
+	}
+	return payload, files
+}
+
+func linuxPayload(bd BootData) bool {
+	return bd.Payload == "" || bd.Payload == payloadLinux
+}
+
+// Function payloadStanza() returns the iPXE commands loading a wimboot or
+// mboot payload, up to the boot command.
+func payloadStanza(bd BootData, params string) (string, error) {
+	kernel, err := checkURL(bd.Kernel.Path)
+	if err != nil {
+		return "", err
+	}
+	urls := make([]string, len(bd.Files))
+	for i, f := range bd.Files {
+		if urls[i], err = checkURL(f.Path); err != nil {
+			return "", err
+		}
+	}
+	var script string
+	switch bd.Payload {
+	case payloadWimboot:
+		script = strings.TrimSpace("kernel --name kernel "+kernel+" "+params) + " || goto boot_retry\n"
+		for i, f := range bd.Files {
+			script += "initrd " + urls[i] + " " + f.Name + " || goto boot_retry\n"
+		}
+	case payloadMboot:
+		args := "-c " + urls[0]
+		if bd.Files[0].Name != mbootConfig {
+			args = strings.Join(urls, " --- ")
+		}
+		script = strings.TrimSpace("kernel --name kernel "+kernel+" "+args+" "+params) + " || goto boot_retry\n"
+	default:
+		return "", fmt.Errorf("Unknown payload %s", bd.Payload)
+	}
+	return script, nil
+}
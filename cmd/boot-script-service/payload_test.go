@@ -0,0 +1,94 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+var testWinPE = []bssTypes.BootFile{
+	{Name: "BCD", Path: "http://s3/winpe/BCD"},
+	{Name: "boot.sdi", Path: "http://s3/winpe/boot.sdi"},
+	{Name: "boot.wim", Path: "http://s3/winpe/boot.wim"},
+}
+
+func TestCheckPayload(t *testing.T) {
+	tests := []struct {
+		payload string
+		files   []bssTypes.BootFile
+		ok      bool
+	}{
+		{"", nil, true},
+		{payloadLinux, testWinPE, false},
+		{payloadWimboot, testWinPE, true},
+		{payloadWimboot, testWinPE[:2], false},
+		{payloadWimboot, append(testWinPE, bssTypes.BootFile{Path: "http://s3/x"}), false},
+		{payloadMboot, nil, false},
+		{payloadMboot, []bssTypes.BootFile{{Name: mbootConfig, Path: "http://s3/esxi/boot.cfg"}}, true},
+		{payloadMboot, []bssTypes.BootFile{{Path: "http://s3/esxi/b.b00"}, {Path: "http://s3/esxi/s.v00"}}, true},
+		{payloadMboot, []bssTypes.BootFile{{Name: mbootConfig, Path: "http://s3/esxi/boot.cfg"}, {Path: "http://s3/esxi/s.v00"}}, false},
+		{"pxelinux", nil, false},
+	}
+	for _, tc := range tests {
+		if err := checkPayload(tc.payload, tc.files); (err == nil) != tc.ok {
+			t.Errorf("checkPayload(%s, %v) = %v", tc.payload, tc.files, err)
+		}
+	}
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{"x0c0s9b0n0"}, Kernel: "http://s3/kernel",
+		Files: testWinPE}, ""); err == nil {
+		kvstore.Delete(paramsPfx + "x0c0s9b0n0")
+		t.Errorf("Store accepted files for a Linux payload")
+	}
+}
+
+func TestPayloadBootScript(t *testing.T) {
+	bd := BootData{Kernel: ImageData{Path: "http://s3/wimboot", Params: "rd.shell"}, Params: "gui",
+		Payload: payloadWimboot, Files: testWinPE}
+	script, err := buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "chain x", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	want := "kernel --name kernel http://s3/wimboot gui || goto boot_retry\n" +
+		"initrd http://s3/winpe/BCD BCD || goto boot_retry\n" +
+		"initrd http://s3/winpe/boot.sdi boot.sdi || goto boot_retry\n" +
+		"initrd http://s3/winpe/boot.wim boot.wim || goto boot_retry\n" +
+		"boot || goto boot_retry\n:boot_retry\n"
+	if !strings.Contains(script, want) || strings.Contains(script, "xname=") {
+		t.Errorf("Unexpected wimboot script:\n%s", script)
+	}
+
+	bd = BootData{Kernel: ImageData{Path: "http://s3/esxi/mboot.efi"}, Payload: payloadMboot,
+		Files: []bssTypes.BootFile{{Name: mbootConfig, Path: "http://s3/esxi/boot.cfg"}}}
+	script, _ = buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "chain x", "Compute", "", "test")
+	if !strings.Contains(script, "kernel --name kernel http://s3/esxi/mboot.efi -c http://s3/esxi/boot.cfg || goto boot_retry\nboot") {
+		t.Errorf("Unexpected mboot script:\n%s", script)
+	}
+	bd.Files = []bssTypes.BootFile{{Path: "http://s3/esxi/b.b00"}, {Path: "http://s3/esxi/s.v00"}}
+	script, _ = buildBootScript(bd, scriptParams{xname: "x0c0s1b0n0"}, "chain x", "Compute", "", "test")
+	if !strings.Contains(script, "mboot.efi http://s3/esxi/b.b00 --- http://s3/esxi/s.v00 || goto") {
+		t.Errorf("Unexpected mboot module script:\n%s", script)
+	}
+}
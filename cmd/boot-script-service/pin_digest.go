@@ -0,0 +1,73 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Digest pinning in boot scripts.
+//
+// With --pin-digests, a boot script for images with a known sha256 digest
+// passes the digests on the kernel command line as bss.kernel_sha256= and
+// bss.initrd_sha256=, so the initrd can verify what it was given.  If
+// --imgverify-suffix is also set, the script runs iPXE imgverify against a
+// detached signature at the image path plus that suffix before booting;
+// this needs a trusted certificate built into or loaded by iPXE.  A failed
+// verification goes to boot_retry rather than booting the image.
+
+package main
+
+import (
+	"strings"
+)
+
+var (
+	pinDigests      = false
+	imgverifySuffix = ""
+)
+
+// Return the hex sha256 of an image, or "" if none is known.
+func pinnedSHA256(im ImageData) string {
+	if !strings.HasPrefix(im.Digest, digestSHA256) || !validDigest(im.Digest) {
+		return ""
+	}
+	return strings.TrimPrefix(im.Digest, digestSHA256)
+}
+
+func pinDigestParams(params string, bd BootData) string {
+	if !pinDigests {
+		return params
+	}
+	params = checkParam(params, "bss.kernel_sha256=", pinnedSHA256(bd.Kernel))
+	if bd.Initrd.Path != "" {
+		params = checkParam(params, "bss.initrd_sha256=", pinnedSHA256(bd.Initrd))
+	}
+	return params
+}
+
+func imgverifyLine(name string, im ImageData) (string, error) {
+	if !pinDigests || imgverifySuffix == "" || pinnedSHA256(im) == "" {
+		return "", nil
+	}
+	u, err := checkURL(im.Path + imgverifySuffix)
+	if err != nil {
+		return "", err
+	}
+	return "imgverify " + name + " " + u + " || goto boot_retry\n", nil
+}
@@ -0,0 +1,207 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Deletion protection.
+//
+// Some boot parameters entries are fallbacks the whole system depends on:
+// the Default and Global tags, or the configuration of management nodes.
+// Protected entries cannot be replaced with PUT, through /bootparameters or
+// /entries, deleted, or overwritten by an import, a node merge or a copy
+// from another namespace unless the request carries
+// X-BSS-Override-Protection: true.
+// PATCH still works, since it cannot remove anything.
+//
+// BSS_PROTECTED lists the entries protected by configuration, Default and
+// Global unless set.  Others are protected through /boot/v1/protected and
+// kept in the KV store; removing their protection needs the override
+// header as well.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	protectedPfx          = "/protected/"
+	protectOverrideHeader = "X-BSS-Override-Protection"
+)
+
+var protectedNames = []string{DefaultTag, GlobalTag}
+
+func protectedByConfig(name string) bool {
+	for _, p := range protectedNames {
+		if p != "" && strings.EqualFold(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isProtected(name string) bool {
+	if protectedByConfig(name) {
+		return true
+	}
+	_, exists, err := kvstore.Get(protectedPfx + name)
+	if err != nil {
+		// Err on the safe side.
+		log.Printf("Failed to check protection of %s: %s", name, err)
+		return true
+	}
+	return exists
+}
+
+func protectionOverridden(r *http.Request) bool {
+	ok, _ := strconv.ParseBool(r.Header.Get(protectOverrideHeader))
+	return ok
+}
+
+// Function checkProtection() refuses requests replacing or deleting
+// protected entries without the override header.  If it sends an error
+// response it returns false.
+func checkProtection(w http.ResponseWriter, r *http.Request, bp bssTypes.BootParams) bool {
+	names := append([]string{}, bp.Hosts...)
+	names = append(names, bp.Macs...)
+	for _, n := range bp.Nids {
+		names = append(names, nidName(int(n)))
+	}
+	var protected []string
+	for _, n := range names {
+		if isProtected(n) {
+			protected = append(protected, n)
+		}
+	}
+	if len(protected) == 0 {
+		return true
+	}
+	if protectionOverridden(r) {
+		log.Printf("%s %s: protection of %s overridden by %s", r.Method, r.URL.Path,
+			strings.Join(protected, ","), requestSubject(r))
+		return true
+	}
+	base.SendProblemDetailsGeneric(w, http.StatusConflict,
+		fmt.Sprintf("Conflict: %s protected, set %s: true to change it anyway",
+			strings.Join(protected, ", "), protectOverrideHeader))
+	return false
+}
+
+func getProtected() ([]bssTypes.ProtectedEntry, error) {
+	ret := []bssTypes.ProtectedEntry{}
+	for _, n := range protectedNames {
+		if n != "" {
+			ret = append(ret, bssTypes.ProtectedEntry{Name: n, Config: true})
+		}
+	}
+	kvl, err := kvstore.GetRange(protectedPfx+keyMin, protectedPfx+keyMax)
+	if err != nil {
+		return ret, err
+	}
+	for _, kv := range kvl {
+		var p bssTypes.ProtectedEntry
+		if err = json.Unmarshal([]byte(kv.Value), &p); err != nil {
+			log.Printf("Bad protected entry at %s: %s", kv.Key, err)
+			continue
+		}
+		if !protectedByConfig(p.Name) {
+			ret = append(ret, p)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+func ProtectedGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ProtectedGet(): Received request %v\n", r.URL)
+	entries, err := getProtected()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve protected entries: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(entries)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func ProtectedPut(w http.ResponseWriter, r *http.Request) {
+	debugf("ProtectedPut(): Received request %v\n", r.URL)
+	var p bssTypes.ProtectedEntry
+	err := json.NewDecoder(r.Body).Decode(&p)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if p.Name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Bad Request: name is required")
+		return
+	}
+	p.ProtectedBy, p.ProtectedAt, p.Config = requestSubject(r), time.Now().Unix(), false
+	if err = storeData(protectedPfx+p.Name, p); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to protect %s: %s", p.Name, err))
+		return
+	}
+	log.Printf("/protected PUT: %s by %s", p.Name, p.ProtectedBy)
+	w.WriteHeader(http.StatusOK)
+}
+
+func ProtectedDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("ProtectedDelete(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	if name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	if protectedByConfig(name) {
+		base.SendProblemDetailsGeneric(w, http.StatusConflict,
+			fmt.Sprintf("Conflict: %s is protected by BSS_PROTECTED", name))
+		return
+	}
+	if !protectionOverridden(r) {
+		base.SendProblemDetailsGeneric(w, http.StatusConflict,
+			fmt.Sprintf("Conflict: set %s: true to remove the protection of %s", protectOverrideHeader, name))
+		return
+	}
+	if err := kvstore.Delete(protectedPfx + name); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove protection of %s: %s", name, err))
+		return
+	}
+	log.Printf("/protected DELETE: %s by %s", name, requestSubject(r))
+	w.WriteHeader(http.StatusOK)
+}
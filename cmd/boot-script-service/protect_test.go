@@ -0,0 +1,114 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestProtection(t *testing.T) {
+	const node = "x0c0s7b0n0"
+	defer func() {
+		kvstore.Delete(paramsPfx + DefaultTag)
+		kvstore.Delete(paramsPfx + node)
+		kvstore.Delete(protectedPfx + node)
+	}()
+	send := func(method, target, body string, override bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if override {
+			req.Header.Set(protectOverrideHeader, "true")
+		}
+		w := httptest.NewRecorder()
+		if strings.HasPrefix(target, baseEndpoint+"/protected") {
+			protected(w, req)
+		} else if strings.HasPrefix(target, entriesEndpoint) {
+			entries(w, req)
+		} else {
+			bootParameters(w, req)
+		}
+		return w
+	}
+	defaultBody := `{"hosts":["Default"],"kernel":"http://s3/kernel"}`
+	if w := send(http.MethodPut, baseEndpoint+"/bootparameters", defaultBody, false); w.Code != http.StatusConflict {
+		t.Errorf("PUT of Default without override returned %d", w.Code)
+	}
+	if w := send(http.MethodPut, baseEndpoint+"/bootparameters", defaultBody, true); w.Code != http.StatusOK {
+		t.Errorf("PUT of Default with override returned %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, baseEndpoint+"/bootparameters", `{"hosts":["Default"]}`, false); w.Code != http.StatusConflict {
+		t.Errorf("DELETE of Default without override returned %d", w.Code)
+	}
+	if w := send(http.MethodPut, entriesEndpoint+"/Default", `{"kernel":"http://s3/other"}`, false); w.Code != http.StatusConflict {
+		t.Errorf("PUT of /entries/Default without override returned %d", w.Code)
+	}
+	if w := send(http.MethodDelete, entriesEndpoint+"/Default", "", false); w.Code != http.StatusConflict {
+		t.Errorf("DELETE of /entries/Default without override returned %d", w.Code)
+	}
+	if _, err := lookupHost(DefaultTag); err != nil {
+		t.Errorf("Default was deleted: %s", err)
+	}
+
+	nodeBody := `{"hosts":["` + node + `"],"kernel":"http://s3/kernel"}`
+	if w := send(http.MethodPut, baseEndpoint+"/bootparameters", nodeBody, false); w.Code != http.StatusOK {
+		t.Fatalf("PUT of %s returned %d", node, w.Code)
+	}
+	if w := send(http.MethodPut, baseEndpoint+"/protected", `{"name":"`+node+`","reason":"ncn"}`, false); w.Code != http.StatusOK {
+		t.Fatalf("Protecting %s returned %d", node, w.Code)
+	}
+	w := send(http.MethodGet, baseEndpoint+"/protected", "", false)
+	var entries []bssTypes.ProtectedEntry
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	if len(entries) != 3 || entries[2].Name != node || entries[2].Reason != "ncn" || entries[2].Config ||
+		!entries[0].Config {
+		t.Errorf("Unexpected protected entries %+v", entries)
+	}
+	if w := send(http.MethodDelete, baseEndpoint+"/bootparameters", `{"hosts":["`+node+`"]}`, false); w.Code != http.StatusConflict {
+		t.Errorf("DELETE of protected %s returned %d", node, w.Code)
+	}
+	if w := send(http.MethodPatch, baseEndpoint+"/bootparameters", `{"hosts":["`+node+`"],"params":"quiet"}`, false); w.Code != http.StatusOK {
+		t.Errorf("PATCH of protected %s returned %d", node, w.Code)
+	}
+	if bps, errs := validateImport([]importRow{{Xname: node, Kernel: "http://s3/k", line: 2}}, false); len(bps) != 0 ||
+		len(errs) != 1 || errs[0].Line != 2 {
+		t.Errorf("Import of a protected host was not refused: %v %v", bps, errs)
+	}
+
+	if w := send(http.MethodDelete, baseEndpoint+"/protected?name=Default", "", true); w.Code != http.StatusConflict {
+		t.Errorf("Removing configured protection returned %d", w.Code)
+	}
+	if w := send(http.MethodDelete, baseEndpoint+"/protected?name="+node, "", false); w.Code != http.StatusConflict {
+		t.Errorf("Removing protection without override returned %d", w.Code)
+	}
+	if w := send(http.MethodDelete, baseEndpoint+"/protected?name="+node, "", true); w.Code != http.StatusOK {
+		t.Errorf("Removing protection returned %d", w.Code)
+	}
+	if w := send(http.MethodDelete, baseEndpoint+"/bootparameters", `{"hosts":["`+node+`"]}`, false); w.Code != http.StatusOK {
+		t.Errorf("DELETE of unprotected %s returned %d: %s", node, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,168 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Readiness.
+//
+// GET /boot/v1/service/ready answers 200 once BSS is ready to serve boot
+// scripts and 503 before, for use as a readiness probe.  By default BSS is
+// ready right away.  With --hsm-ready-wait it is only ready after the HSM
+// state has been loaded once, since until then MAC and IP lookups do not
+// find the nodes and early boot script requests get the defaults.  When a
+// snapshot directory is configured BSS keeps a copy of the last HSM state
+// there, and loading that copy at startup counts as well.  After
+// hsm-ready-wait seconds BSS becomes ready regardless, so that an HSM outage
+// cannot keep it from serving.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const hsmCacheFile = "hsm-state.json"
+
+var (
+	hsmReadyWait = uint(0) // seconds, 0 is ready right away
+	hsmReadyPoll = 5 * time.Second
+)
+
+// Set with smMutex held once the state was loaded from HSM.
+var hsmSynced = false
+
+// Unix times, with smMutex held, of the last HSM state load and of the
+// first of the refreshes that failed since (0 if the last one worked).
+var hsmLoadedAt, hsmFailingSince int64
+
+var readiness = struct {
+	sync.Mutex
+	ready  bool
+	reason string
+}{reason: "waiting for the HSM state"}
+
+func setReady(reason string) {
+	readiness.Lock()
+	defer readiness.Unlock()
+	if !readiness.ready {
+		log.Printf("Ready: %s", reason)
+	}
+	readiness.ready, readiness.reason = true, reason
+}
+
+func isReady() (bool, string) {
+	readiness.Lock()
+	defer readiness.Unlock()
+	return readiness.ready, readiness.reason
+}
+
+// Function hsmStateLoaded() is called with smMutex held whenever the HSM
+// state was loaded.  It keeps a copy of state fetched from HSM in the
+// snapshot directory.
+func hsmStateLoaded(state *SMData) {
+	hsmSynced = true
+	hsmLoadedAt, hsmFailingSince = time.Now().Unix(), 0
+	setReady("HSM state loaded")
+	if snapshotDir == "" || smClient == nil {
+		return
+	}
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(snapshotDir, hsmCacheFile), data)
+	}
+	if err != nil {
+		log.Printf("WARNING: Cannot keep a copy of the HSM state: %s", err)
+	}
+}
+
+// Function loadHSMCache() uses the HSM state copy in dir until HSM answers.
+func loadHSMCache(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, hsmCacheFile))
+	if err != nil {
+		return err
+	}
+	var state SMData
+	if err = json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("%s: %s", hsmCacheFile, err)
+	}
+	smMutex.Lock()
+	defer smMutex.Unlock()
+	if smData == nil {
+		smData = &state
+		makeSmIndexes(smData).install()
+		setReady(fmt.Sprintf("cached HSM state of %d components loaded", len(state.Components)))
+	}
+	return nil
+}
+
+// Function readyInit() starts waiting for the HSM state: it loads the
+// cached copy if there is one and fetches the state from HSM until that
+// succeeds, becoming ready after hsmReadyWait seconds at the latest.
+func readyInit() {
+	if hsmReadyWait == 0 {
+		setReady("not waiting for HSM")
+		return
+	}
+	if snapshotDir != "" {
+		if err := loadHSMCache(snapshotDir); err != nil && !os.IsNotExist(err) {
+			log.Printf("WARNING: Cannot load the cached HSM state: %s", err)
+		}
+	}
+	go readyLoop(time.Now().Add(time.Duration(hsmReadyWait) * time.Second))
+}
+
+func readyLoop(deadline time.Time) {
+	for {
+		smMutex.RLock()
+		synced := hsmSynced
+		smMutex.RUnlock()
+		if synced {
+			return
+		}
+		refreshState(-1)
+		if ready, _ := isReady(); !ready && time.Now().After(deadline) {
+			log.Printf("WARNING: No HSM state after %d seconds, serving without it", hsmReadyWait)
+			setReady("gave up waiting for the HSM state")
+		}
+		time.Sleep(hsmReadyPoll)
+	}
+}
+
+func ServiceReadyGet(w http.ResponseWriter, r *http.Request) {
+	ready, reason := isReady()
+	status := serviceStatus{Status: "ready"}
+	httpStatus := http.StatusOK
+	if !ready {
+		status.Status, httpStatus = "not ready: "+reason, http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(httpStatus)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
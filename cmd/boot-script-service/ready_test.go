@@ -0,0 +1,109 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readyStatus() int {
+	w := httptest.NewRecorder()
+	serviceReady(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/service/ready", nil))
+	return w.Code
+}
+
+func TestReadiness(t *testing.T) {
+	savedData, savedDir, savedPoll := smData, snapshotDir, hsmReadyPoll
+	defer func() {
+		smMutex.Lock()
+		smData, smJSONFile, hsmSynced = savedData, "", false
+		makeSmIndexes(smData).install()
+		smMutex.Unlock()
+		snapshotDir, hsmReadyPoll = savedDir, savedPoll
+		setReady("test done")
+	}()
+	unready := func() {
+		smMutex.Lock()
+		smData, hsmSynced = nil, false
+		smIndexes{}.install()
+		smMutex.Unlock()
+		readiness.Lock()
+		readiness.ready = false
+		readiness.Unlock()
+	}
+
+	unready()
+	if code := readyStatus(); code != http.StatusServiceUnavailable {
+		t.Errorf("Unready service returned %d", code)
+	}
+	dir := t.TempDir()
+	state := `{"Components":[{"ID":"x9c0s0b0n0","Role":"Compute","NID":900}]}`
+	os.WriteFile(filepath.Join(dir, hsmCacheFile), []byte(state), 0644)
+	if err := loadHSMCache(dir); err != nil {
+		t.Fatalf("Cannot load the HSM cache: %s", err)
+	}
+	if code := readyStatus(); code != http.StatusOK {
+		t.Errorf("Service with cached HSM state returned %d", code)
+	}
+	if comp, ok := FindSMCompByNameInCache("x9c0s0b0n0"); !ok || comp.Role != "Compute" {
+		t.Errorf("Cached component not found: %+v", comp)
+	}
+
+	// Without HSM the service becomes ready at the deadline, and the loop
+	// ends once the state is loaded.
+	unready()
+	snapshotDir, hsmReadyPoll = "", 10*time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		readyLoop(time.Now())
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		if ready, _ := isReady(); ready {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ready, reason := isReady(); !ready || !strings.Contains(reason, "gave up") {
+		t.Errorf("Not ready after the deadline: %s", reason)
+	}
+	file := filepath.Join(dir, "hsm.json")
+	os.WriteFile(file, []byte(state), 0644)
+	smMutex.Lock()
+	smJSONFile = file
+	smMutex.Unlock()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Errorf("Ready loop did not end after the HSM state was loaded")
+	}
+	if _, reason := isReady(); reason != "HSM state loaded" {
+		t.Errorf("Unexpected readiness %s", reason)
+	}
+}
@@ -0,0 +1,69 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot parameters by boot reason.
+//
+// A node boots for different reasons: a cold boot, a warm reboot or kexec,
+// or into a crash kernel for kdump.  The reason parameter of a boot script
+// request, e.g. reason=${reason} set from iPXE variables or BMC data, selects
+// the kernel parameters added for that reason from the reasons of the node's
+// boot parameters.  A reason the node has no parameters for boots the regular
+// configuration.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var reasonNameRE = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Function checkReasons() rejects bad reason names and parameters that do
+// not parse.
+func checkReasons(reasons map[string]string) error {
+	for name, params := range reasons {
+		if !reasonNameRE.MatchString(name) {
+			return fmt.Errorf("bad boot reason '%s', need lower case letters, digits and dashes", name)
+		}
+		if err := checkConditionals(params); err != nil {
+			return fmt.Errorf("boot reason '%s': %s", name, err)
+		}
+	}
+	return nil
+}
+
+// Function applyReason() adds the kernel parameters of the boot reason to
+// bd, returning whether it has any for the reason.
+func applyReason(bd *BootData, reason string) bool {
+	params, ok := bd.Reasons[reason]
+	if !ok || reason == "" {
+		return false
+	}
+	if bd.Params == "" {
+		bd.Params = params
+	} else if params != "" {
+		bd.Params += " " + params
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootReasons(t *testing.T) {
+	const node = "x0c0s3b0n0"
+	savedMode := s3SignerMode
+	s3SignerMode = s3SignerMock
+	defer func() {
+		s3SignerMode = savedMode
+		kvstore.Delete(paramsPfx + node)
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "console=ttyS0",
+		Kernel: "s3://boot-images/reasons/kernel", Reasons: map[string]string{"Crash": "x"}}, "test"); err == nil {
+		t.Errorf("Expected a bad reason name to be refused")
+	}
+	w := httptest.NewRecorder()
+	bootParameters(w, httptest.NewRequest(http.MethodPut, baseEndpoint+"/bootparameters",
+		strings.NewReader(`{"hosts":["`+node+`"],"params":"console=ttyS0","kernel":"s3://boot-images/reasons/kernel",`+
+			`"reasons":{"crash":"irqpoll maxcpus=1 reset_devices"}}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT with reasons returned %d: %s", w.Code, w.Body.String())
+	}
+	boot := func(query string) string {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node+query, nil))
+		return w.Body.String()
+	}
+	if script := boot(""); strings.Contains(script, "irqpoll") {
+		t.Errorf("Crash parameters without a reason:\n%s", script)
+	}
+	if script := boot("&reason=cold"); strings.Contains(script, "irqpoll") || !strings.Contains(script, "console=ttyS0") {
+		t.Errorf("Unexpected script for a reason without parameters:\n%s", script)
+	}
+	script := boot("&reason=crash")
+	if !strings.Contains(script, "console=ttyS0 irqpoll maxcpus=1 reset_devices") {
+		t.Errorf("Crash parameters not added:\n%s", script)
+	}
+	if !strings.Contains(script, "&reason=crash") {
+		t.Errorf("Reason not kept in the chain:\n%s", script)
+	}
+
+	if err := Update(bssTypes.BootParams{Hosts: []string{node},
+		Reasons: map[string]string{"kexec": "quiet"}}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if script = boot("&reason=kexec"); !strings.Contains(script, "console=ttyS0 quiet") {
+		t.Errorf("Updated reason not used:\n%s", script)
+	}
+}
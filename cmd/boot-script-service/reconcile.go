@@ -0,0 +1,293 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Stale boot parameters.
+//
+// Nodes that are decommissioned in HSM keep their boot parameters in BSS
+// forever.  Every reconcileInterval seconds the node entries (xnames, MAC
+// addresses and nid<N> names; tags are left alone) are checked against a
+// fresh copy of the HSM data, and those HSM does not have are flagged under
+// /stale/.  With --reconcile-archive, an entry still flagged after
+// reconcileArchiveAfter seconds is moved out of the boot parameters into its
+// /stale/ record, from where it can be restored.  Entries that reappear in
+// HSM are unflagged.  Nothing is done if HSM returns no components at all.
+// /boot/v1/stale reports the flagged and archived entries; posting to it,
+// which reconciles or restores an entry, needs an admin token.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	stalePfx = "/stale/"
+
+	staleXname = "xname"
+	staleMAC   = "mac"
+	staleNID   = "nid"
+)
+
+var (
+	reconcileInterval     = uint(3600) // seconds, 0 disables
+	reconcileArchive      = false
+	reconcileArchiveAfter = uint(7 * 24 * 3600) // seconds
+)
+
+type staleRecord struct {
+	Name      string         `json:"name"`
+	Kind      string         `json:"kind"`
+	FirstSeen int64          `json:"first-seen"`
+	LastSeen  int64          `json:"last-seen"`
+	Archived  int64          `json:"archived,omitempty"`
+	Data      *BootDataStore `json:"data,omitempty"`
+}
+
+// Function nodeKind() tells whether a boot parameters name is for a node,
+// and how HSM knows it.  Tags return "".
+func nodeKind(name string) string {
+	if len(name) > 1 && (name[0] == 'x' || name[0] == 'X') && name[1] >= '0' && name[1] <= '9' {
+		return staleXname
+	}
+	if _, err := net.ParseMAC(name); err == nil {
+		return staleMAC
+	}
+	if strings.HasPrefix(name, "nid") {
+		if _, err := strconv.Atoi(strings.TrimPrefix(name, "nid")); err == nil {
+			return staleNID
+		}
+	}
+	return ""
+}
+
+func inHSM(name, kind string, comps map[string]SMComponent, macs map[string]bool, nids map[int]bool) bool {
+	switch kind {
+	case staleXname:
+		_, ok := comps[strings.ToLower(name)]
+		return ok
+	case staleMAC:
+		return macs[strings.ToLower(name)]
+	case staleNID:
+		nid, _ := strconv.Atoi(strings.TrimPrefix(name, "nid"))
+		return nids[nid]
+	}
+	return true
+}
+
+func getStaleRecords() (map[string]staleRecord, error) {
+	kvl, err := kvstore.GetRange(stalePfx+keyMin, stalePfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]staleRecord)
+	for _, kv := range kvl {
+		var rec staleRecord
+		if json.Unmarshal([]byte(kv.Value), &rec) == nil {
+			ret[rec.Name] = rec
+		}
+	}
+	return ret, nil
+}
+
+// Function reconcileHosts() flags, unflags and archives stale boot
+// parameters, returning how many entries are flagged and how many were
+// archived by this run.
+func reconcileHosts(now time.Time) (flagged, archived int, err error) {
+	state := refreshState(now.Unix())
+	if state == nil || len(state.Components) == 0 {
+		return 0, 0, fmt.Errorf("HSM returned no components, not reconciling")
+	}
+	comps := make(map[string]SMComponent)
+	macs := make(map[string]bool)
+	nids := make(map[int]bool)
+	for _, c := range state.Components {
+		comps[strings.ToLower(c.ID)] = c
+		for _, m := range c.Mac {
+			macs[strings.ToLower(m)] = true
+		}
+		if n, e := c.NID.Int64(); e == nil {
+			nids[int(n)] = true
+		}
+	}
+	records, err := getStaleRecords()
+	if err != nil {
+		return 0, 0, err
+	}
+	kvl, err := getTags()
+	if err != nil {
+		return 0, 0, err
+	}
+	present := make(map[string]bool)
+	for _, kv := range kvl {
+		name := extractParamName(kv)
+		kind := nodeKind(name)
+		if kind == "" {
+			continue
+		}
+		present[name] = true
+		rec, flaggedBefore := records[name]
+		if inHSM(name, kind, comps, macs, nids) {
+			if flaggedBefore && rec.Archived == 0 {
+				kvstore.Delete(stalePfx + name)
+				log.Printf("Reconcile: %s is back in HSM", name)
+			}
+			continue
+		}
+		if rec.Archived != 0 {
+			// Stored again since; keep the archived copy until restored.
+			continue
+		}
+		if !flaggedBefore {
+			rec = staleRecord{Name: name, Kind: kind, FirstSeen: now.Unix()}
+			log.Printf("Reconcile: %s is not in HSM", name)
+		}
+		rec.LastSeen = now.Unix()
+		if reconcileArchive && now.Unix()-rec.FirstSeen >= int64(reconcileArchiveAfter) {
+			if bds, e := lookupHost(name); e == nil {
+				rec.Archived, rec.Data = now.Unix(), &bds
+				if e = storeData(stalePfx+name, rec); e == nil {
+					if e = removeHost(name); e == nil {
+						log.Printf("Reconcile: archived boot parameters of %s", name)
+						archived++
+						continue
+					}
+				}
+				log.Printf("Reconcile: cannot archive %s: %s", name, e)
+				rec.Archived, rec.Data = 0, nil
+			}
+		}
+		if e := storeData(stalePfx+name, rec); e != nil {
+			log.Printf("Reconcile: cannot flag %s: %s", name, e)
+			continue
+		}
+		flagged++
+	}
+	// Flags for entries removed since are no longer of interest.
+	for name, rec := range records {
+		if rec.Archived == 0 && !present[name] {
+			kvstore.Delete(stalePfx + name)
+		}
+	}
+	return flagged, archived, nil
+}
+
+func reconcileLoop() {
+	for {
+		time.Sleep(time.Duration(reconcileInterval) * time.Second)
+		if _, _, err := reconcileHosts(time.Now()); err != nil {
+			log.Printf("Reconcile: %s", err)
+		}
+	}
+}
+
+// Function restoreStale() puts archived boot parameters back.
+func restoreStale(name string) error {
+	records, err := getStaleRecords()
+	if err != nil {
+		return err
+	}
+	rec, ok := records[name]
+	if !ok || rec.Archived == 0 || rec.Data == nil {
+		return fmt.Errorf("%s is not archived", name)
+	}
+	if _, err = lookupHost(name); err == nil {
+		return fmt.Errorf("%s has boot parameters again", name)
+	}
+	if err = storeData(paramsPfx+name, *rec.Data); err != nil {
+		return err
+	}
+	return kvstore.Delete(stalePfx + name)
+}
+
+func staleEntries() ([]bssTypes.StaleEntry, error) {
+	records, err := getStaleRecords()
+	if err != nil {
+		return nil, err
+	}
+	ret := []bssTypes.StaleEntry{}
+	for _, rec := range records {
+		e := bssTypes.StaleEntry{Name: rec.Name, Kind: rec.Kind, FirstSeen: rec.FirstSeen,
+			LastSeen: rec.LastSeen, Archived: rec.Archived}
+		if rec.Data != nil {
+			bd := bdConvert(*rec.Data)
+			e.Params = &bssTypes.BootParams{Hosts: []string{rec.Name}, Params: bd.Params,
+				Kernel: bd.Kernel.Path, Initrd: bd.Initrd.Path, CloudInit: bd.CloudInit}
+		}
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+func sendStaleEntries(w http.ResponseWriter) {
+	entries, err := staleEntries()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read stale entries: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func StaleGet(w http.ResponseWriter, r *http.Request) {
+	debugf("StaleGet(): Received request %v\n", r.URL)
+	sendStaleEntries(w)
+}
+
+// POST runs the reconciler now, or with ?restore=<name> restores an
+// archived entry.
+func StalePost(w http.ResponseWriter, r *http.Request) {
+	debugf("StalePost(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	if name := r.Form.Get("restore"); name != "" {
+		if err := restoreStale(name); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusConflict,
+				fmt.Sprintf("Cannot restore %s: %s", name, err))
+			return
+		}
+		log.Printf("Reconcile: %s restored by %s", name, requestSubject(r))
+	} else if _, _, err := reconcileHosts(time.Now()); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusServiceUnavailable,
+			fmt.Sprintf("Reconcile failed: %s", err))
+		return
+	}
+	sendStaleEntries(w)
+}
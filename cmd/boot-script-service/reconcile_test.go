@@ -0,0 +1,106 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReconcileHosts(t *testing.T) {
+	names := []string{"x9999c0s0b0n0", "nid99998", "x0c0s1b0n0", "TestReconcileTag"}
+	defer func(a bool) {
+		reconcileArchive = a
+		for _, n := range names {
+			kvstore.Delete(paramsPfx + n)
+			kvstore.Delete(stalePfx + n)
+		}
+	}(reconcileArchive)
+	for _, n := range names {
+		storeData(paramsPfx+n, BootDataStore{Params: "from=" + n})
+	}
+
+	now := time.Now()
+	if _, _, err := reconcileHosts(now); err != nil {
+		t.Fatalf("reconcileHosts failed: %s", err)
+	}
+	records, _ := getStaleRecords()
+	for _, n := range names {
+		_, flagged := records[n]
+		if want := n == "x9999c0s0b0n0" || n == "nid99998"; flagged != want {
+			t.Errorf("%s: expected flagged %t", n, want)
+		}
+	}
+
+	// Not archived before the grace period is up
+	reconcileArchive = true
+	reconcileHosts(now.Add(time.Second))
+	if _, err := lookupHost("x9999c0s0b0n0"); err != nil {
+		t.Errorf("Archived before reconcileArchiveAfter")
+	}
+	_, archived, _ := reconcileHosts(now.Add(time.Duration(reconcileArchiveAfter) * time.Second))
+	if archived != 2 {
+		t.Errorf("Expected 2 archived, got %d", archived)
+	}
+	if _, err := lookupHost("x9999c0s0b0n0"); err == nil {
+		t.Errorf("Archived entry was not removed")
+	}
+	entries, _ := staleEntries()
+	found := false
+	for _, e := range entries {
+		if e.Name == "nid99998" {
+			found = e.Archived != 0 && e.Params != nil && e.Params.Params == "from=nid99998"
+		}
+	}
+	if !found {
+		t.Errorf("Archived entry missing from the report: %+v", entries)
+	}
+
+	if err := restoreStale("x9999c0s0b0n0"); err != nil {
+		t.Fatalf("restoreStale failed: %s", err)
+	}
+	if bds, err := lookupHost("x9999c0s0b0n0"); err != nil || bds.Params != "from=x9999c0s0b0n0" {
+		t.Errorf("Restore did not bring the boot parameters back: %v %v", bds, err)
+	}
+	if err := restoreStale("x0c0s1b0n0"); err == nil {
+		t.Errorf("Restored an entry that was never archived")
+	}
+}
+
+func TestStalePostNeedsAdmin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/stale?restore=x9999c0s0b0n0", nil)
+	rr := httptest.NewRecorder()
+	stale(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("POST /stale without a token returned %d", rr.Code)
+	}
+	req = httptest.NewRequest(http.MethodPost, baseEndpoint+"/stale?restore=x9999c0s0b0n0", nil)
+	req.Header.Set("Authorization", testToken(`{"sub":"jdoe","realm_access":{"roles":["user"]}}`))
+	rr = httptest.NewRecorder()
+	stale(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("POST /stale without an admin role returned %d", rr.Code)
+	}
+}
@@ -0,0 +1,213 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Service registry.
+//
+// With --registry set to a Consul agent, e.g. http://localhost:8500, BSS
+// registers itself at startup so that DHCP configuration and other
+// consumers can find the boot servers of a multi-instance deployment:
+//
+//	PUT /v1/agent/service/register
+//	{"ID": "<instance>", "Name": "boot-script-service",
+//	 "Address": "10.1.1.5", "Port": 27778,
+//	 "Check": {"HTTP": "http://10.1.1.5:27778/boot/v1/service/ready", ...}}
+//
+// The address is --registry-address, or the cloud-init address.  Consul
+// polls the readiness endpoint, so an instance still waiting for HSM is
+// registered but not passing.  BSS deregisters on SIGINT and SIGTERM; an
+// instance that dies without doing so is dropped after its check has been
+// critical for a while.  Registration is retried until the agent answers.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+var (
+	registryURL           = "" // Consul agent, empty disables registration
+	registryService       = "boot-script-service"
+	registryAddress       = "" // host[:port], the cloud-init address if empty
+	registryToken         = ""
+	registryCheckInterval = uint(10) // seconds
+	registryDropAfter     = uint(600)
+	registryRetry         = 30 * time.Second
+)
+
+var registryClient = &http.Client{Timeout: 10 * time.Second}
+
+type registryCheck struct {
+	HTTP                           string `json:"HTTP"`
+	Interval                       string `json:"Interval"`
+	Timeout                        string `json:"Timeout"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+type registryEntry struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Tags    []string          `json:"Tags,omitempty"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   registryCheck     `json:"Check"`
+}
+
+// Function registryHostPort() returns the address the instance registers
+// with, the port of --http-listen if the address has none.
+func registryHostPort() (string, int, error) {
+	a := registryAddress
+	if a == "" {
+		a = advertiseAddress
+	}
+	if i := strings.Index(a, "://"); i >= 0 {
+		a = a[i+3:]
+	}
+	if i := strings.IndexByte(a, '/'); i >= 0 {
+		a = a[:i]
+	}
+	if a == "" {
+		return "", 0, fmt.Errorf("no registry address, set --registry-address or --cloud-init-address")
+	}
+	host, port, err := net.SplitHostPort(a)
+	if err != nil {
+		// No port
+		host = strings.Trim(a, "[]")
+		if _, port, err = net.SplitHostPort(httpListen); err != nil {
+			return "", 0, fmt.Errorf("no port in '%s' or --http-listen", a)
+		}
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p <= 0 || p > 65535 {
+		return "", 0, fmt.Errorf("bad port '%s' in '%s'", port, a)
+	}
+	return host, p, nil
+}
+
+func registryRegistration() (registryEntry, error) {
+	host, port, err := registryHostPort()
+	if err != nil {
+		return registryEntry{}, err
+	}
+	hp := net.JoinHostPort(host, strconv.Itoa(port))
+	return registryEntry{
+		ID:      serviceName,
+		Name:    registryService,
+		Address: host,
+		Port:    port,
+		Tags:    []string{"bss"},
+		Meta:    map[string]string{"cloud-init-address": advertiseAddress},
+		Check: registryCheck{
+			HTTP:                           "http://" + hp + baseEndpoint + "/service/ready",
+			Interval:                       fmt.Sprintf("%ds", registryCheckInterval),
+			Timeout:                        "5s",
+			DeregisterCriticalServiceAfter: fmt.Sprintf("%ds", registryDropAfter),
+		},
+	}, nil
+}
+
+func registryPut(path string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPut, strings.TrimRight(registryURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	req.Header.Set("Content-Type", "application/json")
+	if registryToken != "" {
+		req.Header.Set("X-Consul-Token", registryToken)
+	}
+	rsp, err := registryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, rsp.Status)
+	}
+	return nil
+}
+
+func registryRegister(entry registryEntry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return registryPut("/v1/agent/service/register", body)
+}
+
+func registryDeregister(id string) error {
+	return registryPut("/v1/agent/service/deregister/"+url.PathEscape(id), nil)
+}
+
+// Function registryInit() starts registering this instance with the
+// service registry, if one is configured.
+func registryInit() error {
+	if registryURL == "" {
+		return nil
+	}
+	entry, err := registryRegistration()
+	if err != nil {
+		return err
+	}
+	go registryLoop(entry)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		s := <-sig
+		if err := registryDeregister(entry.ID); err != nil {
+			log.Printf("WARNING: Cannot deregister %s: %s", entry.ID, err)
+		} else {
+			log.Printf("Deregistered %s from %s", entry.ID, registryURL)
+		}
+		log.Printf("Exiting on %s", s)
+		os.Exit(0)
+	}()
+	return nil
+}
+
+func registryLoop(entry registryEntry) {
+	for {
+		err := registryRegister(entry)
+		if err == nil {
+			log.Printf("Registered %s as %s at %s:%d with %s", entry.ID, entry.Name,
+				entry.Address, entry.Port, registryURL)
+			return
+		}
+		log.Printf("WARNING: Service registration with %s failed, retrying: %s", registryURL, err)
+		time.Sleep(registryRetry)
+	}
+}
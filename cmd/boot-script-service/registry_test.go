@@ -0,0 +1,96 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistryHostPort(t *testing.T) {
+	savedAddr, savedAdv := registryAddress, advertiseAddress
+	defer func() { registryAddress, advertiseAddress = savedAddr, savedAdv }()
+	for _, c := range []struct {
+		reg, adv string
+		host     string
+		port     int
+		ok       bool
+	}{
+		{"", "http://10.1.1.5:8888", "10.1.1.5", 8888, true},
+		{"bss-1.local", "http://10.1.1.5:8888", "bss-1.local", 27778, true},
+		{"[fd00::5]:80", "", "fd00::5", 80, true},
+		{"", "http://[fd00::71]/", "fd00::71", 27778, true},
+		{"", "", "", 0, false},
+		{"host:0", "", "", 0, false},
+	} {
+		registryAddress, advertiseAddress = c.reg, c.adv
+		host, port, err := registryHostPort()
+		if (err == nil) != c.ok || host != c.host || port != c.port {
+			t.Errorf("%q/%q: got %s %d %v", c.reg, c.adv, host, port, err)
+		}
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	var got registryEntry
+	var paths, tokens []string
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		tokens = append(tokens, r.Header.Get("X-Consul-Token"))
+		if r.URL.Path == "/v1/agent/service/register" {
+			json.NewDecoder(r.Body).Decode(&got)
+		}
+	}))
+	defer agent.Close()
+	savedURL, savedAddr, savedToken := registryURL, registryAddress, registryToken
+	defer func() { registryURL, registryAddress, registryToken = savedURL, savedAddr, savedToken }()
+	registryURL, registryAddress, registryToken = agent.URL+"/", "10.1.1.5:8888", "secret"
+
+	entry, err := registryRegistration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = registryRegister(entry); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != registryService || got.Address != "10.1.1.5" || got.Port != 8888 ||
+		got.Check.HTTP != "http://10.1.1.5:8888/boot/v1/service/ready" || got.Check.Interval != "10s" {
+		t.Errorf("Unexpected registration %+v", got)
+	}
+	if err = registryDeregister(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PUT /v1/agent/service/register", "PUT /v1/agent/service/deregister/" + entry.ID}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] || tokens[1] != "secret" {
+		t.Errorf("Agent saw %v with tokens %v, expected %v", paths, tokens, want)
+	}
+
+	agent.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	if err = registryRegister(entry); err == nil {
+		t.Error("Expected a refused registration to fail")
+	}
+}
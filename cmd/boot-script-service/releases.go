@@ -0,0 +1,376 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Image releases.
+//
+// A release names a kernel, initrd and rootfs that are published together,
+// with a version per channel such as stable and testing.  A boot group
+// (a boot profile) follows one channel of one release: PATCH the group with
+// release and channel.  Publishing a new version to a channel moves every
+// group following it to the new images and rootfs (the releaseRootfsParam
+// kernel parameter), all or nothing: if any member cannot be updated,
+// everything already changed is put back.  Each channel keeps the last
+// releaseHistory versions so a publish can be rolled back.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	releasesPfx    = "/releases/"
+	releaseHistory = 10
+)
+
+var (
+	releaseRootfsParam = "metal.server="
+
+	// Serializes publishing and binding; storing boot parameters takes its
+	// own locks.
+	releaseMutex sync.Mutex
+)
+
+type releaseRecord struct {
+	Name     string                               `json:"name"`
+	Channels map[string][]bssTypes.ReleaseVersion `json:"channels"` // Oldest first
+}
+
+func (rec releaseRecord) current(channel string) (bssTypes.ReleaseVersion, bool) {
+	h := rec.Channels[channel]
+	if len(h) == 0 {
+		return bssTypes.ReleaseVersion{}, false
+	}
+	return h[len(h)-1], true
+}
+
+func getRelease(name string) (releaseRecord, bool, error) {
+	rec := releaseRecord{Name: name, Channels: make(map[string][]bssTypes.ReleaseVersion)}
+	val, exists, err := kvstore.Get(releasesPfx + name)
+	if err != nil || !exists {
+		return rec, false, err
+	}
+	err = json.Unmarshal([]byte(val), &rec)
+	return rec, err == nil, err
+}
+
+func releaseOf(rec releaseRecord, groups []bssTypes.BootGroup) bssTypes.Release {
+	rel := bssTypes.Release{Name: rec.Name, Channels: make(map[string]bssTypes.ReleaseVersion)}
+	for ch := range rec.Channels {
+		if v, ok := rec.current(ch); ok {
+			rel.Channels[ch] = v
+		}
+	}
+	for _, g := range groups {
+		if g.Release == rec.Name {
+			if rel.Profiles == nil {
+				rel.Profiles = make(map[string][]string)
+			}
+			rel.Profiles[g.Channel] = append(rel.Profiles[g.Channel], g.Name)
+		}
+	}
+	return rel
+}
+
+// Function setParam() sets the value of a kernel parameter, replacing any
+// existing one.
+func setParam(params, pname, pval string) string {
+	var args []string
+	for _, arg := range strings.Fields(params) {
+		if !strings.HasPrefix(arg, pname) {
+			args = append(args, arg)
+		}
+	}
+	return strings.Join(append(args, pname+pval), " ")
+}
+
+// Function applyRelease() moves the boot groups following a channel of a
+// release, or only the group with ID only, to version v.  On failure nothing
+// is changed.
+func applyRelease(name, channel string, v bssTypes.ReleaseVersion, only, who string) ([]string, error) {
+	groups, err := getBootGroups()
+	if err != nil {
+		return nil, err
+	}
+	var targets []bssTypes.BootGroup
+	for _, g := range groups {
+		if g.Release == name && g.Channel == channel && (only == "" || g.ID == only) {
+			targets = append(targets, g)
+		}
+	}
+	var undo kvUndo
+	for _, g := range targets {
+		for _, m := range g.Members {
+			if err = undo.save(paramsPfx + m); err != nil {
+				return nil, err
+			}
+		}
+		if err = undo.save(bootGroupsPfx + g.ID); err != nil {
+			return nil, err
+		}
+	}
+	var updated []string
+	fail := func(err error) ([]string, error) {
+		undo.rollback()
+		return nil, err
+	}
+	for _, g := range targets {
+		ng := g
+		ng.Kernel, ng.Initrd = v.Kernel, v.Initrd
+		if v.Rootfs != "" {
+			ng.Params = setParam(g.Params, releaseRootfsParam, v.Rootfs)
+		}
+		if err = assignBootGroup(ng, g.Members, who); err != nil {
+			return fail(err)
+		}
+		ng.ID = bootGroupID(imageFind(ng.Kernel, kernelImageType), imageFind(ng.Initrd, initrdImageType), ng.Params)
+		if ng.ID != g.ID {
+			if err = undo.save(bootGroupsPfx + ng.ID); err != nil {
+				return fail(err)
+			}
+			if label, _ := getBootGroupLabel(ng.ID); label.Name != "" && label.Name != g.Name {
+				return fail(fmt.Errorf("Boot group %s would merge with boot group %s", g.Name, label.Name))
+			}
+			if err = kvstore.Delete(bootGroupsPfx + g.ID); err != nil {
+				return fail(err)
+			}
+		}
+		if err = storeData(bootGroupsPfx+ng.ID, bootGroupLabelOf(ng)); err != nil {
+			return fail(err)
+		}
+		updated = append(updated, g.Name)
+	}
+	return updated, nil
+}
+
+// Function publishRelease() makes v the current version of a channel and
+// applies it, or with v nil rolls the channel back to its previous version.
+func publishRelease(name, channel string, v *bssTypes.ReleaseVersion, who string) (bssTypes.Release, int, error) {
+	releaseMutex.Lock()
+	defer releaseMutex.Unlock()
+	rec, exists, err := getRelease(name)
+	if err != nil {
+		return bssTypes.Release{}, http.StatusInternalServerError, err
+	}
+	oldHistory := rec.Channels[channel]
+	if v == nil {
+		if len(oldHistory) < 2 {
+			return bssTypes.Release{}, http.StatusConflict,
+				fmt.Errorf("No earlier version of %s %s to roll back to", name, channel)
+		}
+		rec.Channels[channel] = oldHistory[:len(oldHistory)-1]
+	} else {
+		h := append(append([]bssTypes.ReleaseVersion(nil), oldHistory...), *v)
+		if len(h) > releaseHistory {
+			h = h[len(h)-releaseHistory:]
+		}
+		rec.Channels[channel] = h
+	}
+	cur, _ := rec.current(channel)
+	if err = storeData(releasesPfx+name, rec); err != nil {
+		return bssTypes.Release{}, http.StatusInternalServerError, err
+	}
+	updated, err := applyRelease(name, channel, cur, "", who)
+	if err != nil {
+		if exists {
+			rec.Channels[channel] = oldHistory
+			storeData(releasesPfx+name, rec)
+		} else {
+			kvstore.Delete(releasesPfx + name)
+		}
+		return bssTypes.Release{}, http.StatusInternalServerError, err
+	}
+	log.Printf("Release %s %s is now %s (by %s), boot groups updated: %s",
+		name, channel, cur.Version, who, strings.Join(updated, ","))
+	groups, _ := getBootGroups()
+	return releaseOf(rec, groups), http.StatusOK, nil
+}
+
+// Function bindBootGroup() makes a boot group follow a release channel and
+// moves it to the current version.
+func bindBootGroup(g bssTypes.BootGroup, who string) error {
+	// Read under the lock so that a publish cannot move the channel on
+	// between reading the version and applying it.
+	releaseMutex.Lock()
+	defer releaseMutex.Unlock()
+	rec, exists, err := getRelease(g.Release)
+	if err != nil {
+		return err
+	}
+	v, ok := rec.current(g.Channel)
+	if !exists || !ok {
+		return fmt.Errorf("No release %s with a %s channel", g.Release, g.Channel)
+	}
+	_, err = applyRelease(g.Release, g.Channel, v, g.ID, who)
+	return err
+}
+
+func sendReleases(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func releaseArgs(w http.ResponseWriter, r *http.Request, needChannel bool) (string, string, bool) {
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	channel := strings.Join(r.Form["channel"], "")
+	if name == "" || strings.Contains(name, "/") || (needChannel && channel == "") {
+		msg := "Need a name= parameter"
+		if needChannel {
+			msg = "Need name= and channel= parameters"
+		}
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, msg)
+		return "", "", false
+	}
+	return name, channel, true
+}
+
+func ReleasesGet(w http.ResponseWriter, r *http.Request) {
+	debugf("ReleasesGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	groups, err := getBootGroups()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve boot groups: %s", err))
+		return
+	}
+	if name := strings.Join(r.Form["name"], ""); name != "" {
+		rec, exists, err := getRelease(name)
+		if err != nil || !exists {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No release '%s'", name))
+			return
+		}
+		sendReleases(w, http.StatusOK, releaseOf(rec, groups))
+		return
+	}
+	kvl, err := kvstore.GetRange(releasesPfx+keyMin, releasesPfx+keyMax)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve releases: %s", err))
+		return
+	}
+	releases := []bssTypes.Release{}
+	for _, kv := range kvl {
+		var rec releaseRecord
+		if json.Unmarshal([]byte(kv.Value), &rec) == nil {
+			releases = append(releases, releaseOf(rec, groups))
+		}
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Name < releases[j].Name })
+	sendReleases(w, http.StatusOK, releases)
+}
+
+// PUT publishes a new version to a channel.
+func ReleasesPut(w http.ResponseWriter, r *http.Request) {
+	debugf("ReleasesPut(): Received request %v\n", r.URL)
+	name, channel, ok := releaseArgs(w, r, true)
+	if !ok {
+		return
+	}
+	var v bssTypes.ReleaseVersion
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if v.Version == "" || v.Kernel == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: version and kernel are required")
+		return
+	}
+	rel, status, err := publishRelease(name, channel, &v, requestSubject(r))
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, status,
+			fmt.Sprintf("Cannot publish %s %s: %s", name, channel, err))
+		return
+	}
+	sendReleases(w, http.StatusOK, rel)
+}
+
+// POST with action=rollback restores the previous version of a channel.
+func ReleasesPost(w http.ResponseWriter, r *http.Request) {
+	debugf("ReleasesPost(): Received request %v\n", r.URL)
+	name, channel, ok := releaseArgs(w, r, true)
+	if !ok {
+		return
+	}
+	if action := strings.Join(r.Form["action"], ""); action != "rollback" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: unknown action '%s'", action))
+		return
+	}
+	if _, exists, _ := getRelease(name); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No release '%s'", name))
+		return
+	}
+	rel, status, err := publishRelease(name, channel, nil, requestSubject(r))
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, status,
+			fmt.Sprintf("Cannot roll back %s %s: %s", name, channel, err))
+		return
+	}
+	sendReleases(w, http.StatusOK, rel)
+}
+
+func ReleasesDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("ReleasesDelete(): Received request %v\n", r.URL)
+	name, _, ok := releaseArgs(w, r, false)
+	if !ok {
+		return
+	}
+	rec, exists, err := getRelease(name)
+	if err != nil || !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No release '%s'", name))
+		return
+	}
+	groups, err := getBootGroups()
+	if err == nil {
+		if rel := releaseOf(rec, groups); len(rel.Profiles) > 0 {
+			base.SendProblemDetailsGeneric(w, http.StatusConflict,
+				fmt.Sprintf("Conflict - release '%s' is followed by boot groups", name))
+			return
+		}
+		err = kvstore.Delete(releasesPfx + name)
+	}
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove release: %s", err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
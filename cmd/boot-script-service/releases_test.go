@@ -0,0 +1,142 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestReleases(t *testing.T) {
+	members := []string{"x3000c0s11b0n0", "x3000c0s12b0n0"}
+	paths := []string{"s3://boot-images/r1/kernel", "s3://boot-images/r1/initrd",
+		"s3://boot-images/r2/kernel", "s3://boot-images/r2/initrd", "s3://boot-images/r0/kernel"}
+	defer func() {
+		for _, m := range members {
+			kvstore.Delete(paramsPfx + m)
+		}
+		for i, p := range paths {
+			imtype := kernelImageType
+			if i%2 == 1 && i < 4 {
+				imtype = initrdImageType
+			}
+			kvstore.Delete(imageFind(p, imtype))
+		}
+		kvstore.Delete(releasesPfx + "cos")
+		kvl, _ := kvstore.GetRange(bootGroupsPfx+keyMin, bootGroupsPfx+keyMax)
+		for _, kv := range kvl {
+			if strings.Contains(kv.Value, `"compute"`) {
+				kvstore.Delete(kv.Key)
+			}
+		}
+	}()
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, baseEndpoint+url, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		if strings.HasPrefix(url, "/releases") {
+			releases(rr, req)
+		} else {
+			bootGroups(rr, req)
+		}
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/bootgroups", `{"name":"compute","kernel":"s3://boot-images/r0/kernel",`+
+		`"params":"console=ttyS0","members":["x3000c0s11b0n0","x3000c0s12b0n0"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	rr = do(http.MethodPatch, "/bootgroups?name=compute", `{"release":"cos"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Binding to an unknown release returned %d", rr.Code)
+	}
+
+	rr = do(http.MethodPut, "/releases?name=cos&channel=stable", `{"version":"1.0",`+
+		`"kernel":"s3://boot-images/r1/kernel","initrd":"s3://boot-images/r1/initrd","rootfs":"http://rgw/r1/rootfs"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT release returned %d: %s", rr.Code, rr.Body)
+	}
+	rr = do(http.MethodPatch, "/bootgroups?name=compute", `{"release":"cos","channel":"stable"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PATCH bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	check := func(kernel, rootfs string) {
+		t.Helper()
+		for _, m := range members {
+			bds, err := lookupHost(m)
+			if err != nil {
+				t.Fatalf("%s: %s", m, err)
+			}
+			bd := bdConvert(bds)
+			if bd.Kernel.Path != kernel || !strings.Contains(bd.Params, "metal.server="+rootfs) ||
+				strings.Count(bd.Params, "metal.server=") != 1 || !strings.Contains(bd.Params, "console=ttyS0") {
+				t.Errorf("%s: kernel %s params '%s', expected %s and %s", m, bd.Kernel.Path, bd.Params, kernel, rootfs)
+			}
+		}
+		g, found, _ := findBootGroup("compute")
+		if !found || g.Kernel != kernel || g.Release != "cos" || len(g.Members) != len(members) {
+			t.Errorf("Unexpected boot group %+v", g)
+		}
+	}
+	check("s3://boot-images/r1/kernel", "http://rgw/r1/rootfs")
+
+	rr = do(http.MethodPut, "/releases?name=cos&channel=stable", `{"version":"2.0",`+
+		`"kernel":"s3://boot-images/r2/kernel","initrd":"s3://boot-images/r2/initrd","rootfs":"http://rgw/r2/rootfs"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT release returned %d: %s", rr.Code, rr.Body)
+	}
+	var rel bssTypes.Release
+	json.Unmarshal(rr.Body.Bytes(), &rel)
+	if rel.Channels["stable"].Version != "2.0" || len(rel.Profiles["stable"]) != 1 {
+		t.Errorf("Unexpected release %+v", rel)
+	}
+	check("s3://boot-images/r2/kernel", "http://rgw/r2/rootfs")
+
+	rr = do(http.MethodPost, "/releases?name=cos&channel=stable&action=rollback", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Rollback returned %d: %s", rr.Code, rr.Body)
+	}
+	check("s3://boot-images/r1/kernel", "http://rgw/r1/rootfs")
+	rr = do(http.MethodPost, "/releases?name=cos&channel=stable&action=rollback", "")
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Rollback past the first version returned %d", rr.Code)
+	}
+
+	rr = do(http.MethodDelete, "/releases?name=cos", "")
+	if rr.Code != http.StatusConflict {
+		t.Errorf("DELETE of a followed release returned %d", rr.Code)
+	}
+}
+
+func TestSetParam(t *testing.T) {
+	got := setParam("console=ttyS0 metal.server=old quiet", "metal.server=", "new")
+	if got != "console=ttyS0 quiet metal.server=new" {
+		t.Errorf("setParam() returned '%s'", got)
+	}
+}
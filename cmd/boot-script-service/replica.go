@@ -0,0 +1,133 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Datastore read replica.
+//
+// BSS_DATASTORE_REPLICA names a read-only datastore, e.g. an etcd learner or
+// a proxy near the service, that serves the boot parameter entries read by
+// GET /bootparameters and GET /bootscript (replicaReads()).  Everything else,
+// updates and the reads they depend on included, uses the primary.  A replica
+// lags behind, so for datastoreReplicaLag milliseconds after any change of
+// the boot parameters these reads go to the primary as well.  Changes are
+// seen through the change feed: this instance's own as they are recorded,
+// other instances' once the revision watch reports them, so clients read
+// their own writes on any instance as long as the watch is not slower than
+// the lag.  Without the watch the replica is not used.  Reads fall back to
+// the primary when the replica fails.
+
+package main
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+var (
+	datastoreReplica    = ""
+	datastoreReplicaLag = uint(2000) // milliseconds
+)
+
+// The replica in front of the primary, nil without one.
+var replicaStore *replicaKvi
+
+type replicaKvi struct {
+	hmetcd.Kvi
+	replica hmetcd.Kvi
+	lag     time.Duration
+	changed atomic.Int64 // UnixNano of the last change seen
+}
+
+func newReplicaKvi(primary, replica hmetcd.Kvi, lag time.Duration) *replicaKvi {
+	return &replicaKvi{Kvi: primary, replica: replica, lag: lag}
+}
+
+// Function fresh() tells whether the replica has caught up with the last
+// change seen.
+func (kv *replicaKvi) fresh() bool {
+	return time.Since(time.Unix(0, kv.changed.Load())) > kv.lag
+}
+
+func (kv *replicaKvi) Get(key string) (string, bool, error) {
+	val, exists, err := kv.replica.Get(key)
+	if err == nil {
+		return val, exists, nil
+	}
+	log.Printf("Datastore replica: get %s failed, using the primary: %s", key, err)
+	return kv.Kvi.Get(key)
+}
+
+func (kv *replicaKvi) GetRange(keystart, keyend string) ([]hmetcd.Kvi_KV, error) {
+	kvl, err := kv.replica.GetRange(keystart, keyend)
+	if err == nil {
+		return kvl, nil
+	}
+	log.Printf("Datastore replica: get range %s failed, using the primary: %s", keystart, err)
+	return kv.Kvi.GetRange(keystart, keyend)
+}
+
+func (kv *replicaKvi) withContext(ctx context.Context) hmetcd.Kvi {
+	c := newReplicaKvi(kvWithContext(kv.Kvi, ctx), kvWithContext(kv.replica, ctx), kv.lag)
+	c.changed.Store(kv.changed.Load())
+	return c
+}
+
+func (kv *replicaKvi) Close() error {
+	kv.replica.Close()
+	return kv.Kvi.Close()
+}
+
+// Function replicaReads() returns the store the boot parameter and boot
+// script reads use: the replica if it is fresh, else the primary, giving up
+// when ctx is done.
+func replicaReads(ctx context.Context) hmetcd.Kvi {
+	if rep := replicaStore; rep != nil && rep.fresh() {
+		return kvWithContext(rep, ctx)
+	}
+	return kvWithContext(kvstore, ctx)
+}
+
+// Function replicaChanged() notes a change of the boot parameters, made by
+// this instance or another.
+func replicaChanged() {
+	if rep := replicaStore; rep != nil {
+		rep.changed.Store(time.Now().UnixNano())
+	}
+}
+
+// Function replicaOpen() opens the read replica.
+func replicaOpen(opts string) error {
+	replica, err := hmetcd.Open(datastoreReplica, opts)
+	if err != nil {
+		return err
+	}
+	timed := newTimedKvi(replica)
+	timed.store = "replica"
+	replicaStore = newReplicaKvi(kvstore, inNamespace(timed, datastoreNamespace),
+		time.Duration(datastoreReplicaLag)*time.Millisecond)
+	log.Printf("Datastore replica %s serves boot parameter reads", datastoreReplica)
+	return nil
+}
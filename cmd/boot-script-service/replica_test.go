@@ -0,0 +1,55 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplicaKvi(t *testing.T) {
+	primary := &mapKvi{m: map[string]string{"/a": "new"}}
+	replica := &mapKvi{m: map[string]string{"/a": "old", "/b": "1"}}
+	saved := kvstore
+	kvstore = primary
+	replicaStore = newReplicaKvi(primary, replica, time.Hour)
+	defer func() { kvstore, replicaStore = saved, nil }()
+
+	if val, _, _ := replicaReads(context.Background()).Get("/a"); val != "old" {
+		t.Errorf("Get() returned %s, expected the replica's value", val)
+	}
+	if val, _, _ := kvstore.Get("/a"); val != "new" {
+		t.Errorf("Get() of the datastore returned %s", val)
+	}
+	replicaChanged()
+	if val, _, _ := replicaReads(context.Background()).Get("/a"); val != "new" {
+		t.Errorf("Get() after a change returned %s, expected the primary's value", val)
+	}
+
+	replicaStore.lag = 0
+	time.Sleep(time.Millisecond)
+	if val, _, _ := replicaReads(context.Background()).Get("/a"); val != "old" {
+		t.Errorf("Get() past the lag did not use the replica")
+	}
+}
@@ -0,0 +1,215 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Rescue and diagnostic boot catalog.
+//
+// The catalog holds named utility boot targets such as memtest or a disk
+// wiper.  A few builtin targets point at images below BSS_RESCUE_IMAGES;
+// admins change them, or add their own, through /boot/v1/rescue.  Stored
+// targets live under /rescue/ next to the boot group labels, and deleting
+// a builtin one brings back its default.
+//
+// Targets are used as profiles: an override with "profile": "memtest"
+// boots the node into memtest for its TTL, and GET /bootscript with
+// profile= previews the script a node would get with that target.  A
+// profile that is not in the catalog is looked up as a boot group.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const rescuePfx = "/rescue/"
+
+var rescueImages = "s3://boot-images/rescue"
+
+func rescueBuiltin() []bssTypes.RescueTarget {
+	img := strings.TrimSuffix(rescueImages, "/")
+	return []bssTypes.RescueTarget{
+		{Name: "memtest", Description: "Memory test",
+			Kernel: img + "/memtest/memtest.efi"},
+		{Name: "wipefs", Description: "Erase the signatures of all local disks",
+			Kernel: img + "/wipefs/kernel", Initrd: img + "/wipefs/initrd",
+			Params: "console=ttyS0,115200 rd.wipefs=all"},
+		{Name: "firmware", Description: "Vendor firmware updater",
+			Kernel: img + "/firmware/kernel", Initrd: img + "/firmware/initrd",
+			Params: "console=ttyS0,115200"},
+	}
+}
+
+func getRescueTargets() ([]bssTypes.RescueTarget, error) {
+	targets := make(map[string]bssTypes.RescueTarget)
+	for _, t := range rescueBuiltin() {
+		t.Builtin = true
+		targets[t.Name] = t
+	}
+	kvl, err := kvstore.GetRange(rescuePfx+keyMin, rescuePfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvl {
+		var t bssTypes.RescueTarget
+		if err = json.Unmarshal([]byte(kv.Value), &t); err != nil {
+			log.Printf("Skipping bad rescue target %s: %s", kv.Key, err)
+			continue
+		}
+		_, t.Builtin = targets[t.Name]
+		t.Modified = t.Builtin
+		targets[t.Name] = t
+	}
+	ret := make([]bssTypes.RescueTarget, 0, len(targets))
+	for _, t := range targets {
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+func findRescueTarget(name string) (bssTypes.RescueTarget, bool, error) {
+	targets, err := getRescueTargets()
+	if err != nil {
+		return bssTypes.RescueTarget{}, false, err
+	}
+	for _, t := range targets {
+		if t.Name == name {
+			return t, true, nil
+		}
+	}
+	return bssTypes.RescueTarget{}, false, nil
+}
+
+// Function resolveProfile() gives the boot configuration of a profile: a
+// rescue target, or failing that a boot group.
+func resolveProfile(name string) (bssTypes.RescueTarget, bool, error) {
+	t, found, err := findRescueTarget(name)
+	if err != nil || found {
+		return t, found, err
+	}
+	g, found, err := findBootGroup(name)
+	if err != nil || !found || g.Kernel == "" {
+		return bssTypes.RescueTarget{}, false, err
+	}
+	return bssTypes.RescueTarget{Name: name, Description: g.Description,
+		Kernel: g.Kernel, Initrd: g.Initrd, Params: g.Params}, true, nil
+}
+
+// Function applyProfile() replaces the boot configuration in bd with that
+// of a profile.
+func applyProfile(t bssTypes.RescueTarget, bd *BootData) {
+	bd.Params, bd.Payload, bd.Files = t.Params, "", nil
+	bd.Kernel = firstBootImage(t.Kernel, kernelImageType)
+	bd.Initrd = ImageData{}
+	if t.Initrd != "" {
+		bd.Initrd = firstBootImage(t.Initrd, initrdImageType)
+	}
+}
+
+func RescueGet(w http.ResponseWriter, r *http.Request) {
+	debugf("RescueGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	targets, err := getRescueTargets()
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve rescue targets: %s", err))
+		return
+	}
+	if name := strings.Join(r.Form["name"], ""); name != "" {
+		var found []bssTypes.RescueTarget
+		for _, t := range targets {
+			if t.Name == name {
+				found = append(found, t)
+			}
+		}
+		if len(found) == 0 {
+			base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+				fmt.Sprintf("Not Found - No rescue target %s", name))
+			return
+		}
+		targets = found
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(targets); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func RescuePut(w http.ResponseWriter, r *http.Request) {
+	debugf("RescuePut(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var t bssTypes.RescueTarget
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if t.Name == "" || t.Kernel == "" || strings.Contains(t.Name, "/") {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request: a name and a kernel are required")
+		return
+	}
+	t.Builtin, t.Modified = false, false
+	if err := storeData(rescuePfx+t.Name, t); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to store rescue target %s: %s", t.Name, err))
+		return
+	}
+	log.Printf("/rescue PUT: %s boots %s, by %s", t.Name, t.Kernel, requestSubject(r))
+	w.WriteHeader(http.StatusOK)
+}
+
+func RescueDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("RescueDelete(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	name := strings.Join(r.Form["name"], "")
+	if name == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Need a name= parameter")
+		return
+	}
+	if _, exists, _ := kvstore.Get(rescuePfx + name); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No configured rescue target %s", name))
+		return
+	}
+	if err := kvstore.Delete(rescuePfx + name); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to remove rescue target %s: %s", name, err))
+		return
+	}
+	log.Printf("/rescue DELETE: %s by %s", name, requestSubject(r))
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,103 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestRescueCatalog(t *testing.T) {
+	const node = "x0c0s2b0n0"
+	defer kvstore.Delete(overridePfx + node)
+	admin := testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`)
+	send := func(method, target, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		switch {
+		case strings.HasPrefix(target, baseEndpoint+"/rescue"):
+			rescue(w, req)
+		case strings.HasPrefix(target, baseEndpoint+"/override"):
+			override(w, req)
+		default:
+			bootScript(w, req)
+		}
+		return w
+	}
+	list := func() map[string]bssTypes.RescueTarget {
+		var targets []bssTypes.RescueTarget
+		json.Unmarshal(send(http.MethodGet, baseEndpoint+"/rescue", "", "").Body.Bytes(), &targets)
+		ret := make(map[string]bssTypes.RescueTarget)
+		for _, t := range targets {
+			ret[t.Name] = t
+		}
+		return ret
+	}
+
+	if targets := list(); len(targets) != 3 || !targets["memtest"].Builtin {
+		t.Fatalf("Unexpected catalog %+v", targets)
+	}
+	memtest := `{"name":"memtest","kernel":"http://s3/memtest","params":"console=ttyS0"}`
+	if w := send(http.MethodPut, baseEndpoint+"/rescue", "", memtest); w.Code != http.StatusUnauthorized {
+		t.Errorf("Unauthenticated PUT returned %d", w.Code)
+	}
+	if w := send(http.MethodPut, baseEndpoint+"/rescue", admin, memtest); w.Code != http.StatusOK {
+		t.Fatalf("PUT returned %d: %s", w.Code, w.Body.String())
+	}
+	if m := list()["memtest"]; m.Kernel != "http://s3/memtest" || !m.Modified {
+		t.Errorf("memtest not reconfigured: %+v", m)
+	}
+
+	w := send(http.MethodPost, baseEndpoint+"/override/"+node, "", `{"profile":"memtest","ttl":60}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Override with a profile returned %d: %s", w.Code, w.Body.String())
+	}
+	if bd, _ := LookupByName(node); bd.Kernel.Path != "http://s3/memtest" || bd.Params != "console=ttyS0" {
+		t.Errorf("Profile not applied: %+v", bd)
+	}
+	if w = send(http.MethodPost, baseEndpoint+"/override/"+node, "", `{"profile":"nope","ttl":60}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Override with an unknown profile returned %d", w.Code)
+	}
+
+	if w = send(http.MethodGet, baseEndpoint+"/bootscript?name="+node+"&profile=memtest", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Unauthenticated preview returned %d", w.Code)
+	}
+	if w = send(http.MethodGet, baseEndpoint+"/bootscript?name="+node+"&profile=nope", admin, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Preview of an unknown profile returned %d", w.Code)
+	}
+
+	if w = send(http.MethodDelete, baseEndpoint+"/rescue?name=memtest", admin, ""); w.Code != http.StatusOK {
+		t.Errorf("DELETE returned %d", w.Code)
+	}
+	if m := list()["memtest"]; m.Modified || !strings.HasSuffix(m.Kernel, "/memtest/memtest.efi") {
+		t.Errorf("memtest default not restored: %+v", m)
+	}
+}
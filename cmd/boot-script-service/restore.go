@@ -0,0 +1,345 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Restore rehearsal.
+//
+// Run as bss-restore (the image links it to boot-script-service) with
+// -verify-only, BSS loads a snapshot (see snapshot.go) into an in-memory
+// datastore and cross-checks it against a live service, without changing
+// either: the entries must survive the load with their images, the hosts
+// and images of the snapshot and of the live service are counted and
+// compared, and for a sample of nodes the boot script rendered from the
+// snapshot is compared with the one rendered from the live boot parameters.
+// Both are rendered locally with the mock S3 signer, fetching the live boot
+// scripts would count as boots of the nodes.  Sealed snapshots need the
+// datastore key in the environment, as for the service.
+//
+// Restoring itself is done by the service with --snapshot-restore.  The
+// report is JSON, or one finding per line with -format text.  The exit
+// status is 0 when no errors were found, warnings are expected for changes
+// since the snapshot, 1 when some were, and 2 when the check could not run.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/Cray-HPE/hms-hmetcd"
+)
+
+const restoreCommand = "bss-restore"
+
+type restoreFinding struct {
+	Name    string `json:"name,omitempty"`
+	Level   string `json:"level"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+type restoreReport struct {
+	Result     string           `json:"result"`
+	Snapshot   string           `json:"snapshot"`
+	Hosts      int              `json:"hosts"`
+	Images     int              `json:"images"`
+	LiveHosts  int              `json:"live-hosts"`
+	LiveImages int              `json:"live-images"`
+	Rendered   int              `json:"rendered"`
+	Findings   []restoreFinding `json:"findings"`
+}
+
+func (r *restoreReport) add(name, level, check, format string, a ...interface{}) {
+	r.Findings = append(r.Findings, restoreFinding{name, level, check, fmt.Sprintf(format, a...)})
+	if level == lintError {
+		r.Result = checkFail
+	}
+}
+
+// The boot parameters of a snapshot or of GET /bootparameters, by host, and
+// the parameters of the images.
+type restoreSet struct {
+	hosts  map[string]bssTypes.BootParams
+	images map[string]string
+}
+
+func newRestoreSet(bps []bssTypes.BootParams) restoreSet {
+	set := restoreSet{hosts: map[string]bssTypes.BootParams{}, images: map[string]string{}}
+	for _, bp := range bps {
+		switch {
+		case len(bp.Hosts) > 0:
+			for _, h := range bp.Hosts {
+				set.hosts[h] = bp
+			}
+		case bp.Kernel != "":
+			set.images[kernelImageType+" "+bp.Kernel] = bp.Params
+		case bp.Initrd != "":
+			set.images[initrdImageType+" "+bp.Initrd] = bp.Params
+		}
+	}
+	return set
+}
+
+// Function bootData() returns the boot data of a host, as lookup() would
+// for a host entry.
+func (set restoreSet) bootData(name string) BootData {
+	bp := set.hosts[name]
+	return BootData{Params: bp.Params, CloudInit: bp.CloudInit, FirstBoot: bp.FirstBoot,
+		Messages: bp.Messages, Labels: bp.Labels, Reasons: bp.Reasons, Payload: bp.Payload, Files: bp.Files,
+		DTB: bp.DTB, ACPI: bp.ACPI, Overlays: bp.InitrdOverlays,
+		Kernel: ImageData{Path: bp.Kernel, Params: set.images[kernelImageType+" "+bp.Kernel]},
+		Initrd: ImageData{Path: bp.Initrd, Params: set.images[initrdImageType+" "+bp.Initrd]}}
+}
+
+func (set restoreSet) render(name string) (string, error) {
+	return buildBootScript(set.bootData(name), scriptParams{xname: name}, "", "", "", name)
+}
+
+// Function liveBootParams() reads all the boot parameters of a live service.
+func liveBootParams(live, token string) ([]bssTypes.BootParams, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(live, "/")+baseEndpoint+"/bootparameters", nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	base.SetHTTPUserAgent(req, restoreCommand)
+	rsp, err := (&http.Client{Timeout: 5 * time.Minute}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s failed: %s", req.URL, rsp.Status)
+	}
+	var bps []bssTypes.BootParams
+	err = json.NewDecoder(rsp.Body).Decode(&bps)
+	return bps, err
+}
+
+// Function loadSnapshot() stores the boot parameters of a snapshot in an
+// empty in-memory datastore and reads them back.
+func loadSnapshot(bps []bssTypes.BootParams, report *restoreReport) ([]bssTypes.BootParams, error) {
+	mem, err := hmetcd.Open("mem:", "")
+	if err != nil {
+		return nil, err
+	}
+	kvstore, kvstoreRoot = mem, mem
+	for _, bp := range bps {
+		bp.Provenance = nil
+		if err, _ = Store(bp, restoreCommand); err != nil {
+			name := strings.Join(bp.Hosts, ",")
+			if name == "" {
+				name = bp.Kernel + bp.Initrd
+			}
+			report.add(name, lintError, "load", "%s", err)
+		}
+	}
+	return allBootParams(false), nil
+}
+
+// Function verifyRestore() checks that the snapshot loads and compares it
+// with the boot parameters of the live service.
+func verifyRestore(snapshot string, bps, live []bssTypes.BootParams, sample int) (restoreReport, error) {
+	report := restoreReport{Result: checkOK, Snapshot: snapshot, Findings: []restoreFinding{}}
+	savedStore, savedRoot := kvstore, kvstoreRoot
+	savedSigner, savedDeterministic := s3SignerMode, bootscriptDeterministic
+	defer func() {
+		kvstore, kvstoreRoot = savedStore, savedRoot
+		s3SignerMode, bootscriptDeterministic = savedSigner, savedDeterministic
+	}()
+	s3SignerMode, bootscriptDeterministic = s3SignerMock, true
+
+	loaded, err := loadSnapshot(bps, &report)
+	if err != nil {
+		return report, err
+	}
+	want, got, cur := newRestoreSet(bps), newRestoreSet(loaded), newRestoreSet(live)
+	report.Hosts, report.Images = len(got.hosts), len(got.images)
+	report.LiveHosts, report.LiveImages = len(cur.hosts), len(cur.images)
+
+	var names []string
+	for name, bp := range want.hosts {
+		names = append(names, name)
+		r, ok := got.hosts[name]
+		switch {
+		case !ok:
+			report.add(name, lintError, "load", "missing after the load")
+		case r.Kernel != bp.Kernel:
+			report.add(name, lintError, "images", "kernel %s missing after the load", bp.Kernel)
+		case r.Initrd != bp.Initrd:
+			report.add(name, lintError, "images", "initrd %s missing after the load", bp.Initrd)
+		case r.Params != bp.Params:
+			report.add(name, lintError, "load", "params changed by the load")
+		}
+		if _, ok = cur.hosts[name]; !ok {
+			report.add(name, lintWarning, "hosts", "not in the live service")
+		}
+	}
+	for name := range cur.hosts {
+		if _, ok := want.hosts[name]; !ok {
+			report.add(name, lintWarning, "hosts", "not in the snapshot")
+		}
+	}
+	for im := range cur.images {
+		if _, ok := want.images[im]; !ok {
+			report.add(im, lintWarning, "images", "not in the snapshot")
+		}
+	}
+	sort.Strings(names)
+
+	// Nodes spread over the host names, tags do not boot.
+	var nodes []string
+	for _, name := range names {
+		if _, ok := cur.hosts[name]; ok && lintXnameLike.MatchString(name) {
+			nodes = append(nodes, name)
+		}
+	}
+	step := 1
+	if sample > 0 && len(nodes) > sample {
+		step = len(nodes) / sample
+	}
+	for i := 0; i < len(nodes) && report.Rendered < sample; i += step {
+		name := nodes[i]
+		script, err := got.render(name)
+		if err != nil {
+			report.add(name, lintError, "bootscript", "snapshot does not render: %s", err)
+			continue
+		}
+		report.Rendered++
+		liveScript, err := cur.render(name)
+		if err != nil {
+			report.add(name, lintWarning, "bootscript", "live boot parameters do not render: %s", err)
+			continue
+		}
+		if script != liveScript {
+			report.add(name, lintWarning, "bootscript", "differs from the live one: %s", firstDiff(script, liveScript))
+		}
+	}
+	return report, nil
+}
+
+// Function firstDiff() describes the first line that differs between two
+// boot scripts.
+func firstDiff(a, b string) string {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(al) || i < len(bl); i++ {
+		var x, y string
+		if i < len(al) {
+			x = al[i]
+		}
+		if i < len(bl) {
+			y = bl[i]
+		}
+		if x != y {
+			return fmt.Sprintf("line %d %q, live %q", i+1, x, y)
+		}
+	}
+	return ""
+}
+
+func (r restoreReport) text(w io.Writer) {
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "%s: %s: %s: %s\n", f.Name, f.Level, f.Check, f.Message)
+	}
+	fmt.Fprintf(w, "%s: %s: %d hosts, %d images (live %d, %d), %d boot scripts compared, %d findings\n",
+		r.Result, r.Snapshot, r.Hosts, r.Images, r.LiveHosts, r.LiveImages, r.Rendered, len(r.Findings))
+}
+
+// Function restoreMain() is the bss-restore command, returning its exit
+// status.
+func restoreMain(args []string, stdout, stderr io.Writer) int {
+	fl := flag.NewFlagSet(restoreCommand, flag.ContinueOnError)
+	fl.SetOutput(stderr)
+	verifyOnly := fl.Bool("verify-only", false, "Load the snapshot into memory and compare it with the live service")
+	live := fl.String("bss", "http://localhost"+httpListen, "Base URL of the live service")
+	token := fl.String("token", "", "Bearer token for the live service")
+	sample := fl.Int("sample", 20, "Number of nodes whose boot scripts are compared")
+	format := fl.String("format", "json", "Report format: json or text")
+	fl.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s -verify-only [options] snapshot-file-or-directory\n", restoreCommand)
+		fl.PrintDefaults()
+	}
+	if err := fl.Parse(args); err != nil {
+		return 2
+	}
+	if fl.NArg() != 1 || (*format != "json" && *format != "text") {
+		fl.Usage()
+		return 2
+	}
+	if !*verifyOnly {
+		fmt.Fprintf(stderr, "%s: only -verify-only is supported, the service restores with --snapshot-restore\n",
+			restoreCommand)
+		return 2
+	}
+	fail := func(err error) int {
+		fmt.Fprintf(stderr, "%s: %s\n", restoreCommand, err)
+		return 2
+	}
+	if err := datastoreCryptInit(); err != nil {
+		return fail(err)
+	}
+	name := fl.Arg(0)
+	if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+		names, err := listSnapshots(name)
+		if err != nil {
+			return fail(err)
+		}
+		if len(names) == 0 {
+			return fail(fmt.Errorf("no snapshots in %s", name))
+		}
+		name = names[len(names)-1]
+	}
+	bps, err := readSnapshot(name)
+	if err != nil {
+		return fail(fmt.Errorf("%s: %s", name, err))
+	}
+	liveBps, err := liveBootParams(*live, *token)
+	if err != nil {
+		return fail(err)
+	}
+	report, err := verifyRestore(name, bps, liveBps, *sample)
+	if err != nil {
+		return fail(err)
+	}
+	if *format == "text" {
+		report.text(stdout)
+	} else {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+	if report.Result != checkOK {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestRestoreVerify(t *testing.T) {
+	const same, changed = "x0c1s21b0n0", "x0c1s22b0n0"
+	kernel := "s3://boot-images/restore/kernel"
+	for _, h := range []string{same, changed} {
+		if err, _ := Store(bssTypes.BootParams{Hosts: []string{h}, Params: "console=ttyS0",
+			Kernel: kernel}, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() {
+		kvstore.Delete(paramsPfx + same)
+		kvstore.Delete(paramsPfx + changed)
+		kvstore.Delete(makeImageKey(kernelImageType, kernel))
+	}()
+	dir := t.TempDir()
+	if _, err := writeSnapshot(dir, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	liveBps := allBootParams(false)
+	for i, bp := range liveBps {
+		if len(bp.Hosts) == 1 && bp.Hosts[0] == changed {
+			liveBps[i].Params = "console=ttyS1"
+		}
+	}
+	liveBps = append(liveBps, bssTypes.BootParams{Hosts: []string{"x9c0s0b0n0"}, Params: "new"})
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != baseEndpoint+"/bootparameters" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(liveBps)
+	}))
+	defer live.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := restoreMain([]string{"-bss", live.URL, dir}, &stdout, &stderr); code != 2 ||
+		!strings.Contains(stderr.String(), "-verify-only") {
+		t.Errorf("Expected a restore without -verify-only to be refused, got %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	code := restoreMain([]string{"-verify-only", "-bss", live.URL, dir}, &stdout, &stderr)
+	var report restoreReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil || code != 0 {
+		t.Fatalf("bss-restore returned %d: %s %s", code, stdout.String(), stderr.String())
+	}
+	found := map[string]string{}
+	for _, f := range report.Findings {
+		found[f.Name+" "+f.Check] = f.Level
+	}
+	if found[changed+" bootscript"] != lintWarning || found["x9c0s0b0n0 hosts"] != lintWarning {
+		t.Errorf("Expected warnings for the changed and the new host, got %+v", report.Findings)
+	}
+	if _, ok := found[same+" bootscript"]; ok || report.Rendered < 2 {
+		t.Errorf("Expected %s to render the same, got %+v", same, report)
+	}
+	if _, exists, _ := kvstore.Get(paramsPfx + same); !exists {
+		t.Errorf("The datastore was not put back")
+	}
+
+	// A snapshot that does not load is an error.
+	bad := filepath.Join(t.TempDir(), snapshotPrefix+"bad"+snapshotSuffix)
+	os.WriteFile(bad, []byte(`[{"hosts":["x0c0s1b0n0"],"params":"{{if}}"}]`), 0600)
+	stdout.Reset()
+	if code = restoreMain([]string{"-verify-only", "-format", "text", "-bss", live.URL, bad}, &stdout, &stderr); code != 1 ||
+		!strings.Contains(stdout.String(), "error: load") {
+		t.Errorf("Expected a load error, got %d: %s", code, stdout.String())
+	}
+}
@@ -0,0 +1,117 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Risky kernel parameters.
+//
+// The kernel command line goes through the iPXE command parser and then the
+// init system, dracut's shell code among them, so some characters that pass
+// the schema break a boot in ways that are hard to trace back to the boot
+// parameters.  Storing parameters with any of these is allowed but answered
+// with a Warning header per problem (as Kubernetes does), bss-lint reports
+// them as warnings, and GET /boot/v1/risky-params lists all stored entries
+// that have them:
+//
+//   - control characters, which end or corrupt the iPXE kernel line
+//   - || and && as words, which iPXE takes as operators
+//   - backticks and $(, command substitution in init scripts
+//   - semicolons, which end a command in init scripts (except in ds=,
+//     where cloud-init uses them)
+//   - unbalanced double quotes, which make the kernel merge parameters
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const riskyParamsEndpoint = baseEndpoint + "/risky-params"
+
+// Function paramRisks() returns what is risky about a kernel command line,
+// nil if nothing is.
+func paramRisks(params string) []string {
+	var risks []string
+	for _, c := range params {
+		if c < ' ' && c != '\t' || c == 0x7f {
+			risks = append(risks, fmt.Sprintf("control character %q", c))
+			break
+		}
+	}
+	if strings.Count(params, `"`)%2 != 0 {
+		risks = append(risks, "unbalanced double quote")
+	}
+	for _, f := range strings.Fields(params) {
+		switch {
+		case f == "||" || f == "&&":
+			risks = append(risks, fmt.Sprintf("'%s' is an iPXE operator", f))
+		case strings.ContainsRune(f, '`'):
+			risks = append(risks, fmt.Sprintf("backtick in '%s'", f))
+		case strings.Contains(f, "$("):
+			risks = append(risks, fmt.Sprintf("command substitution in '%s'", f))
+		case strings.ContainsRune(f, ';') && !strings.HasPrefix(f, "ds="):
+			risks = append(risks, fmt.Sprintf("unescaped semicolon in '%s'", f))
+		}
+	}
+	return risks
+}
+
+// Function warnRiskyParams() adds a Warning header for each risk of the
+// parameters of a request.
+func warnRiskyParams(w http.ResponseWriter, params string) {
+	for _, risk := range paramRisks(params) {
+		w.Header().Add("Warning", "299 - "+strconv.Quote("Risky kernel parameters: "+risk))
+	}
+}
+
+// Function riskyParams() returns the stored entries with risky parameters,
+// sorted by name.
+func riskyParams() []bssTypes.RiskyParams {
+	ret := []bssTypes.RiskyParams{}
+	for name, value := range GetNamesAndValues() {
+		var bds BootDataStore
+		if err := json.Unmarshal([]byte(value), &bds); err != nil {
+			continue
+		}
+		if risks := paramRisks(bds.Params); risks != nil {
+			ret = append(ret, bssTypes.RiskyParams{Name: name, Params: bds.Params, Risks: risks})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func RiskyParamsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("RiskyParamsGet(): Received request %v\n", r.URL)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(riskyParams()); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,77 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestParamRisks(t *testing.T) {
+	for params, want := range map[string]int{
+		"console=ttyS0 quiet":                    0,
+		"ds=nocloud-net;s=http://10.1.1.1:8888/": 0,
+		`foo="a b" bar`:                          0,
+		"init=/bin/sh;reboot":                    1,
+		"x=`id`":                                 1,
+		"x=$(id)":                                1,
+		"quiet || reboot":                        1,
+		`foo="a b bar`:                           1,
+		"quiet\nimgfetch x":                      1,
+		"x=`id`;y":                               1,
+		"a=1;b && c":                             2,
+	} {
+		if got := paramRisks(params); len(got) != want {
+			t.Errorf("%q: got %v, expected %d risks", params, got, want)
+		}
+	}
+}
+
+func TestRiskyParams(t *testing.T) {
+	const host = "x9c6s1b0n0"
+	defer kvstore.Delete(paramsPfx + host)
+	req := httptest.NewRequest(http.MethodPut, baseEndpoint+"/bootparameters",
+		strings.NewReader(`{"hosts":["`+host+`"],"params":"quiet init=/bin/sh;reboot"}`))
+	w := httptest.NewRecorder()
+	bootParameters(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Warning"), "unescaped semicolon") {
+		t.Fatalf("PUT returned %d with Warning %q", w.Code, w.Header().Get("Warning"))
+	}
+
+	w = httptest.NewRecorder()
+	riskyParamsGet(w, httptest.NewRequest(http.MethodGet, riskyParamsEndpoint, nil))
+	var risky []bssTypes.RiskyParams
+	json.Unmarshal(w.Body.Bytes(), &risky)
+	found := false
+	for _, rp := range risky {
+		found = found || rp.Name == host && len(rp.Risks) == 1
+	}
+	if w.Code != http.StatusOK || !found {
+		t.Errorf("Expected %s in %d %s", host, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,235 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Per-role kernel command line fragments.
+//
+// Arguments common to all nodes of a role, such as cgroup flags, can be set
+// once under /boot/v1/defaults/roles/<role>/params instead of in every
+// node's parameters.  A boot script for a node of that role gets the prefix,
+// then the node's parameters, then the suffix.  A fragment argument is left
+// out if the node's parameters already have one with the same name, so a
+// node can still override it.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	roleParamsPfx      = "/role-params/"
+	roleParamsEndpoint = baseEndpoint + "/defaults/roles"
+)
+
+func paramName(arg string) string {
+	if i := strings.Index(arg, "="); i >= 0 {
+		return arg[:i]
+	}
+	return arg
+}
+
+// Function mergeRoleParams() places the role's prefix and suffix around
+// params, dropping fragment arguments params already has.
+func mergeRoleParams(params string, rp bssTypes.RoleParams) string {
+	have := make(map[string]bool)
+	for _, arg := range strings.Fields(params) {
+		have[paramName(arg)] = true
+	}
+	keep := func(fragment string) []string {
+		var ret []string
+		for _, arg := range strings.Fields(fragment) {
+			if !have[paramName(arg)] {
+				ret = append(ret, arg)
+			}
+		}
+		return ret
+	}
+	args := keep(rp.Prefix)
+	args = append(args, strings.Fields(params)...)
+	args = append(args, keep(rp.Suffix)...)
+	return strings.Join(args, " ")
+}
+
+func getRoleParams(role string) (bssTypes.RoleParams, bool, error) {
+	rp := bssTypes.RoleParams{Role: role}
+	val, exists, err := kvstore.Get(roleParamsPfx + role)
+	if err != nil || !exists {
+		return rp, false, err
+	}
+	err = json.Unmarshal([]byte(val), &rp)
+	return rp, err == nil, err
+}
+
+func applyRoleParams(params, role string) string {
+	if role == "" {
+		return params
+	}
+	rp, exists, err := getRoleParams(role)
+	if err != nil {
+		log.Printf("WARNING: Cannot read %s cmdline fragments: %s", role, err)
+	}
+	if !exists {
+		return params
+	}
+	return mergeRoleParams(params, rp)
+}
+
+func allRoleParams() ([]bssTypes.RoleParams, error) {
+	kvl, err := kvstore.GetRange(roleParamsPfx+keyMin, roleParamsPfx+keyMax)
+	if err != nil {
+		return nil, err
+	}
+	ret := []bssTypes.RoleParams{}
+	for _, kv := range kvl {
+		var rp bssTypes.RoleParams
+		if json.Unmarshal([]byte(kv.Value), &rp) == nil {
+			ret = append(ret, rp)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Role < ret[j].Role })
+	return ret, nil
+}
+
+// Function roleParamsPath() returns the role of a
+// /boot/v1/defaults/roles/<role>/params path, or "" for the role list.
+func roleParamsPath(path string) (string, bool) {
+	rest := strings.Trim(strings.TrimPrefix(path, roleParamsEndpoint), "/")
+	if rest == "" {
+		return "", true
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "params" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func sendRoleParams(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+func RoleParamsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("RoleParamsGet(): Received request %v\n", r.URL)
+	role, ok := roleParamsPath(r.URL.Path)
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	if role == "" {
+		all, err := allRoleParams()
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Cannot read cmdline fragments: %s", err))
+			return
+		}
+		sendRoleParams(w, http.StatusOK, all)
+		return
+	}
+	rp, exists, err := getRoleParams(role)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot read cmdline fragments: %s", err))
+		return
+	}
+	if !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No cmdline fragments for role %s", role))
+		return
+	}
+	sendRoleParams(w, http.StatusOK, rp)
+}
+
+func RoleParamsPut(w http.ResponseWriter, r *http.Request) {
+	debugf("RoleParamsPut(): Received request %v\n", r.URL)
+	role, ok := roleParamsPath(r.URL.Path)
+	if !ok || role == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	var rp bssTypes.RoleParams
+	if err := json.NewDecoder(r.Body).Decode(&rp); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if rp.Role != "" && rp.Role != role {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: role %s does not match the path", rp.Role))
+		return
+	}
+	rp.Role = role
+	rp.Prefix = strings.Join(strings.Fields(rp.Prefix), " ")
+	rp.Suffix = strings.Join(strings.Fields(rp.Suffix), " ")
+	for _, frag := range []string{rp.Prefix, rp.Suffix} {
+		if err := checkConditionals(frag); err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request: %s", err))
+			return
+		}
+	}
+	if err := storeData(roleParamsPfx+role, rp); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot store cmdline fragments: %s", err))
+		return
+	}
+	signalChange()
+	log.Printf("Cmdline fragments for role %s set by %s: prefix '%s', suffix '%s'",
+		role, requestSubject(r), rp.Prefix, rp.Suffix)
+	sendRoleParams(w, http.StatusOK, rp)
+}
+
+func RoleParamsDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("RoleParamsDelete(): Received request %v\n", r.URL)
+	role, ok := roleParamsPath(r.URL.Path)
+	if !ok || role == "" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	if _, exists, _ := getRoleParams(role); !exists {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No cmdline fragments for role %s", role))
+		return
+	}
+	if err := kvstore.Delete(roleParamsPfx + role); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot delete cmdline fragments: %s", err))
+		return
+	}
+	signalChange()
+	w.WriteHeader(http.StatusNoContent)
+}
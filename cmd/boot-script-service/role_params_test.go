@@ -0,0 +1,85 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestMergeRoleParams(t *testing.T) {
+	rp := bssTypes.RoleParams{Prefix: "cgroup_enable=memory quiet", Suffix: "console=ttyS0 panic=10"}
+	for _, tc := range []struct{ params, want string }{
+		{"root=live", "cgroup_enable=memory quiet root=live console=ttyS0 panic=10"},
+		{"panic=0 quiet", "cgroup_enable=memory panic=0 quiet console=ttyS0"},
+		{"", "cgroup_enable=memory quiet console=ttyS0 panic=10"},
+	} {
+		if got := mergeRoleParams(tc.params, rp); got != tc.want {
+			t.Errorf("mergeRoleParams(%q) = %q, expected %q", tc.params, got, tc.want)
+		}
+	}
+}
+
+func TestRoleParamsAPI(t *testing.T) {
+	defer kvstore.Delete(roleParamsPfx + "Compute")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		roleParams(w, req)
+		return w
+	}
+	path := roleParamsEndpoint + "/Compute/params"
+	if w := do(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET before PUT returned %d", w.Code)
+	}
+	if w := do(http.MethodPut, path, `{"role":"Storage","prefix":"a"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT with mismatched role returned %d", w.Code)
+	}
+	if w := do(http.MethodPut, path, `{"prefix":" cgroup_enable=memory ","suffix":"panic=10"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT returned %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, roleParamsEndpoint, ""); !strings.Contains(w.Body.String(), `"prefix":"cgroup_enable=memory"`) {
+		t.Errorf("Unexpected role list %s", w.Body.String())
+	}
+
+	script, err := buildBootScript(BootData{Params: "root=live", Kernel: ImageData{Path: "http://s3/kernel"}},
+		scriptParams{xname: "x0c0s1b0n0"}, "", "Compute", "", "test")
+	if err != nil {
+		t.Fatalf("buildBootScript failed: %s", err)
+	}
+	if !strings.Contains(script, "http://s3/kernel cgroup_enable=memory root=live panic=10 ") {
+		t.Errorf("Boot script is missing the role fragments:\n%s", script)
+	}
+
+	if w := do(http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE returned %d", w.Code)
+	}
+	if w := do(http.MethodGet, roleParamsEndpoint+"/Compute/other", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET of a bad path returned %d", w.Code)
+	}
+}
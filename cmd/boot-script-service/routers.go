@@ -53,12 +53,60 @@ const (
 func initHandlers() {
 	http.HandleFunc(baseEndpoint+"/", Index)
 	// config
-	http.HandleFunc(baseEndpoint+"/bootparameters", bootParameters)
+	http.HandleFunc(baseEndpoint+"/bootparameters", auditLogged(bootParameters))
+	http.HandleFunc(auditLogEndpoint, auditLog)
 	// boot
 	http.HandleFunc(baseEndpoint+"/bootscript", bootScript)
 	http.HandleFunc(baseEndpoint+"/hosts", hosts)
 	http.HandleFunc(baseEndpoint+"/dumpstate", dumpstate)
+	http.HandleFunc(artifactsEndpoint, artifactsGet)
+	http.HandleFunc(baseEndpoint+"/handoff", handoff)
+	http.HandleFunc(baseEndpoint+"/bootdeps", bootDeps)
+	http.HandleFunc(baseEndpoint+"/bootdeps/ready", bootDepsReady)
+	http.HandleFunc(baseEndpoint+"/firstboot", firstBoot)
+	http.HandleFunc(baseEndpoint+"/bootverify", bootVerify)
+	http.HandleFunc(baseEndpoint+"/security-events", securityEvents)
+	http.HandleFunc(baseEndpoint+"/bootgroups", bootGroups)
+	http.HandleFunc(baseEndpoint+"/import", bulkImport)
+	http.HandleFunc(baseEndpoint+"/changes", changes)
+	http.HandleFunc(baseEndpoint+"/imagedigests", imageDigestsAPI)
+	http.HandleFunc(approvedImagesEndpoint, approvedImages)
+	http.HandleFunc(artifactMirrorsEndpoint, artifactMirrors)
+	http.HandleFunc(baseEndpoint+"/service/debug", serviceDebug)
+	http.HandleFunc(baseEndpoint+"/service/config", serviceConfig)
+	http.HandleFunc(baseEndpoint+"/service/ready", serviceReady)
+	http.HandleFunc(baseEndpoint+"/service/duplicate-nids", serviceDuplicateNids)
+	http.HandleFunc(baseEndpoint+"/service/cache", serviceCache)
+	http.HandleFunc(baseEndpoint+"/service/mac-keys", serviceMACKeys)
+	http.HandleFunc(baseEndpoint+"/service/hsm-test-nodes", serviceHSMTestNodes)
+	http.HandleFunc(baseEndpoint+"/support-bundle", supportBundle)
+	http.HandleFunc(cloudInitEndpoint, cloudInitResolved)
+	http.HandleFunc(roleParamsEndpoint, roleParams)
+	http.HandleFunc(roleParamsEndpoint+"/", roleParams)
+	http.HandleFunc(kdumpEndpoint, kdump)
+	http.HandleFunc(kdumpEndpoint+"/", kdump)
+	http.HandleFunc(baseEndpoint+"/stale", stale)
+	http.HandleFunc(exportEndpoint, export)
+	http.HandleFunc(entriesEndpoint, entries)
+	http.HandleFunc(entriesEndpoint+"/", auditLogged(entries))
+	http.HandleFunc(baseEndpoint+"/inventory/ansible", inventoryAnsible)
+	http.HandleFunc(baseEndpoint+"/releases", releases)
+	http.HandleFunc(baseEndpoint+"/metrics", metricsAPI)
+	http.HandleFunc(baseEndpoint+"/bootparameters/", bootParametersWatch)
+	http.HandleFunc(baseEndpoint+"/schema", schema)
+	http.HandleFunc(baseEndpoint+"/protected", protected)
+	http.HandleFunc(proposalsEndpoint, auditLogged(proposals))
+	http.HandleFunc(baseEndpoint+"/override", override)
+	http.HandleFunc(baseEndpoint+"/override/", override)
+	http.HandleFunc(baseEndpoint+"/rescue", rescue)
+	http.HandleFunc(baseEndpoint+"/node-token", nodeToken)
+	http.HandleFunc(baseEndpoint+"/namespaces", namespaces)
+	http.HandleFunc(baseEndpoint+"/simulate", simulate)
+	http.HandleFunc(baseEndpoint+"/warmup", warmupAPI)
+	http.HandleFunc(baseEndpoint+"/fallback", fallback)
+	http.HandleFunc(baseEndpoint+"/nodes/merge", nodesMerge)
 	http.HandleFunc(baseEndpoint+"/service/", service)
+	http.HandleFunc(baseEndpoint+"/selftest", selftest)
 	// cloud-init
 	http.HandleFunc(metaDataRoute, metaDataGet)
 	http.HandleFunc(userDataRoute, userDataGet)
@@ -67,6 +115,11 @@ func initHandlers() {
 	http.HandleFunc(notifierEndpoint, scn)
 	// endpoint-access
 	http.HandleFunc(baseEndpoint+"/endpoint-history", endpointHistoryGet)
+	http.HandleFunc(cmdlineHistoryEndpoint, cmdlineHistory)
+	http.HandleFunc(riskyParamsEndpoint, riskyParamsGet)
+	http.HandleFunc(baseEndpoint+"/analytics/access", analyticsAccess)
+	// maintenance notes
+	http.HandleFunc(baseEndpoint+"/annotations", annotations)
 }
 
 func Index(w http.ResponseWriter, r *http.Request) {
@@ -79,6 +132,10 @@ func sendAllowable(w http.ResponseWriter, allowable string) {
 }
 
 func bootParameters(w http.ResponseWriter, r *http.Request) {
+	// GET tolerates a bad body when there are query parameters.
+	if r.Method != http.MethodGet && !validateBody(w, r, bootParamsSchema) {
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
 		BootparametersGet(w, r)
@@ -115,6 +172,467 @@ func hosts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func artifactsGet(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		artifactsGetAPI(w, r)
+	default:
+		sendAllowable(w, "GET,HEAD")
+	}
+}
+
+func handoff(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		handoffGetAPI(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func bootDeps(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		BootDepsGet(w, r)
+	case http.MethodPut:
+		BootDepsPut(w, r)
+	case http.MethodDelete:
+		BootDepsDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func bootDepsReady(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		BootDepsReadyGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func firstBoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		FirstBootGet(w, r)
+	case http.MethodDelete:
+		FirstBootDelete(w, r)
+	default:
+		sendAllowable(w, "GET,DELETE")
+	}
+}
+
+func bootVerify(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		BootVerifyGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func securityEvents(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		SecurityEventsGet(w, r)
+	case http.MethodDelete:
+		SecurityEventsDelete(w, r)
+	default:
+		sendAllowable(w, "GET,DELETE")
+	}
+}
+
+func bootGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		BootGroupsGet(w, r)
+	case http.MethodPost:
+		BootGroupsPost(w, r)
+	case http.MethodPatch:
+		BootGroupsPatch(w, r)
+	case http.MethodDelete:
+		BootGroupsDelete(w, r)
+	default:
+		sendAllowable(w, "GET,POST,PATCH,DELETE")
+	}
+}
+
+func bulkImport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ImportPost(w, r)
+	default:
+		sendAllowable(w, "POST")
+	}
+}
+
+func changes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ChangesGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func imageDigestsAPI(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ImageDigestsGet(w, r)
+	case http.MethodPost:
+		ImageDigestsPost(w, r)
+	case http.MethodPut:
+		ImageDigestsPut(w, r)
+	default:
+		sendAllowable(w, "GET,POST,PUT")
+	}
+}
+
+func approvedImages(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ApprovedImagesGet(w, r)
+	case http.MethodPut:
+		ApprovedImagesPut(w, r)
+	case http.MethodDelete:
+		ApprovedImagesDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func auditLog(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		AuditLogGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func artifactMirrors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ArtifactMirrorsGet(w, r)
+	case http.MethodPut:
+		ArtifactMirrorsPut(w, r)
+	case http.MethodDelete:
+		ArtifactMirrorsDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func serviceDebug(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ServiceDebugGet(w, r)
+	case http.MethodPut:
+		ServiceDebugPut(w, r)
+	default:
+		sendAllowable(w, "GET,PUT")
+	}
+}
+
+func serviceConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ServiceConfigGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func serviceReady(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ServiceReadyGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func serviceDuplicateNids(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		DuplicateNidsGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func serviceCache(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ServiceCacheGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func serviceMACKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		MACKeysPost(w, r)
+	default:
+		sendAllowable(w, "POST")
+	}
+}
+
+func nodesMerge(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		NodesMergePost(w, r)
+	default:
+		sendAllowable(w, "POST")
+	}
+}
+
+func serviceHSMTestNodes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		HSMTestNodesGet(w, r)
+	case http.MethodPut:
+		HSMTestNodesPut(w, r)
+	case http.MethodDelete:
+		HSMTestNodesDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func supportBundle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		SupportBundleGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func cloudInitResolved(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		CloudInitResolvedGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func roleParams(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		RoleParamsGet(w, r)
+	case http.MethodPut:
+		RoleParamsPut(w, r)
+	case http.MethodDelete:
+		RoleParamsDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func kdump(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		KdumpGet(w, r)
+	case http.MethodPut:
+		KdumpPut(w, r)
+	case http.MethodDelete:
+		KdumpDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func stale(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		StaleGet(w, r)
+	case http.MethodPost:
+		StalePost(w, r)
+	default:
+		sendAllowable(w, "GET,POST")
+	}
+}
+
+func export(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ExportGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func entries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		EntriesGet(w, r)
+	case http.MethodPut:
+		EntriesPut(w, r)
+	case http.MethodDelete:
+		EntriesDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func inventoryAnsible(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		InventoryAnsibleGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func releases(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ReleasesGet(w, r)
+	case http.MethodPut:
+		ReleasesPut(w, r)
+	case http.MethodPost:
+		ReleasesPost(w, r)
+	case http.MethodDelete:
+		ReleasesDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,POST,DELETE")
+	}
+}
+
+func metricsAPI(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		metricsGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func bootParametersWatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		BootparametersWatch(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func schema(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		SchemaGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func protected(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ProtectedGet(w, r)
+	case http.MethodPut:
+		ProtectedPut(w, r)
+	case http.MethodDelete:
+		ProtectedDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func proposals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ProposalsGet(w, r)
+	case http.MethodPost:
+		ProposalsPost(w, r)
+	default:
+		sendAllowable(w, "GET,POST")
+	}
+}
+
+func override(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		OverrideGet(w, r)
+	case http.MethodPost:
+		OverridePost(w, r)
+	case http.MethodDelete:
+		OverrideDelete(w, r)
+	default:
+		sendAllowable(w, "GET,POST,DELETE")
+	}
+}
+
+func rescue(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		RescueGet(w, r)
+	case http.MethodPut:
+		RescuePut(w, r)
+	case http.MethodDelete:
+		RescueDelete(w, r)
+	default:
+		sendAllowable(w, "GET,PUT,DELETE")
+	}
+}
+
+func nodeToken(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		NodeTokenPost(w, r)
+	default:
+		sendAllowable(w, "POST")
+	}
+}
+
+func namespaces(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		NamespacesGet(w, r)
+	case http.MethodPost:
+		NamespacesPost(w, r)
+	default:
+		sendAllowable(w, "GET,POST")
+	}
+}
+
+func simulate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		SimulatePost(w, r)
+	default:
+		sendAllowable(w, "POST")
+	}
+}
+
+func warmupAPI(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		WarmupGet(w, r)
+	case http.MethodPost:
+		WarmupPost(w, r)
+	case http.MethodDelete:
+		WarmupDelete(w, r)
+	default:
+		sendAllowable(w, "GET,POST,DELETE")
+	}
+}
+
+func fallback(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		FallbackGet(w, r)
+	case http.MethodDelete:
+		FallbackDelete(w, r)
+	default:
+		sendAllowable(w, "GET,DELETE")
+	}
+}
+
 func dumpstate(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -133,6 +651,15 @@ func service(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func selftest(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		selfTestAPI(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
 func scn(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
@@ -177,3 +704,43 @@ func endpointHistoryGet(w http.ResponseWriter, r *http.Request) {
 		sendAllowable(w, "GET")
 	}
 }
+
+func riskyParamsGet(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		RiskyParamsGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func cmdlineHistory(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		CmdlineHistoryGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func analyticsAccess(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		AnalyticsAccessGet(w, r)
+	default:
+		sendAllowable(w, "GET")
+	}
+}
+
+func annotations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		AnnotationsGet(w, r)
+	case http.MethodPost:
+		AnnotationsPost(w, r)
+	case http.MethodDelete:
+		AnnotationsDelete(w, r)
+	default:
+		sendAllowable(w, "GET,POST,DELETE")
+	}
+}
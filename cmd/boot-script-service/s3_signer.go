@@ -0,0 +1,85 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// S3 URL signing modes.
+//
+// By default s3:// URLs are converted into presigned URLs using the S3
+// credentials from the environment.  Air-gapped systems and test environments
+// frequently do not have credentials, so a built-in mock signer is provided
+// which produces deterministic "signed" URLs.  The mock signature is an HMAC
+// of the bucket and key, so the URLs can still be checked by a proxy that
+// knows the secret.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	s3SignerAWS  = "aws"
+	s3SignerMock = "mock"
+
+	mockSignatureParam = "X-BSS-Signature"
+)
+
+var s3SignerMode = s3SignerAWS
+var s3MockBaseURL = "http://rgw-vip.nmn"
+var s3MockSecret = ""
+
+func validateS3SignerMode(mode string) error {
+	switch mode {
+	case s3SignerAWS, s3SignerMock:
+		return nil
+	}
+	return fmt.Errorf("Unknown S3 signer '%s', expected one of %s, %s", mode, s3SignerAWS, s3SignerMock)
+}
+
+func mockS3Signature(bucket, key string) string {
+	mac := hmac.New(sha256.New, []byte(s3MockSecret))
+	mac.Write([]byte(bucket + "/" + strings.TrimLeft(key, "/")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Function mockS3SignedURL() returns the deterministic URL for the given S3
+// bucket and key.  The same bucket and key will always produce the same URL.
+func mockS3SignedURL(bucket, key string) string {
+	return fmt.Sprintf("%s/%s/%s?%s=%s", strings.TrimRight(s3MockBaseURL, "/"), bucket,
+		strings.TrimLeft(key, "/"), mockSignatureParam, mockS3Signature(bucket, key))
+}
+
+// Function mockSignS3URL() is a signedS3UrlGetter which always uses the mock
+// signer, regardless of the configured signer mode.  Non-S3 URLs are returned
+// unchanged.
+func mockSignS3URL(u string) (string, error) {
+	p, err := url.Parse(u)
+	if err != nil || !strings.EqualFold(p.Scheme, "s3") {
+		return u, nil
+	}
+	return mockS3SignedURL(splitS3URL(p)), nil
+}
@@ -0,0 +1,280 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// JSON Schema of the boot parameters.
+//
+// GET /boot/v1/schema publishes the JSON Schema of bssTypes.BootParams,
+// with the cloud-init and first boot data it contains.  Request bodies of
+// /bootparameters are checked against it before they are decoded, so an
+// unknown field or a value of the wrong type gets a 400 naming the field,
+// where encoding/json would silently drop the field or stop at the first
+// error with a byte offset.  BSS_VALIDATE_PAYLOADS=false turns the check off
+// for clients that send extra fields.
+//
+// The validator covers the keywords the schema uses: type, properties,
+// additionalProperties, items, $ref, pattern, minimum and maximum.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+var validatePayloads = true
+
+// Nulls are allowed where encoding/json accepts them and BSS itself sends
+// them, e.g. empty cloud-init data.
+const bootParamsSchemaJSON = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://github.com/Cray-HPE/hms-bss/boot/v1/schema",
+  "title": "BootParams",
+  "description": "Boot parameters for hosts, MACs or NIDs, or for a tag such as Default or a role.",
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "hosts": {"type": ["array", "null"], "items": {"type": "string"}},
+    "macs": {"type": ["array", "null"], "items": {"type": "string"}},
+    "nids": {"type": ["array", "null"], "items": {"type": "integer", "minimum": -2147483648, "maximum": 2147483647}},
+    "params": {"type": "string"},
+    "kernel": {"type": "string"},
+    "initrd": {"type": "string"},
+    "cloud-init": {"$ref": "#/$defs/CloudInit"},
+    "first-boot": {"$ref": "#/$defs/FirstBoot"},
+    "messages": {"$ref": "#/$defs/Messages"},
+    "labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+    "reasons": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+    "payload": {"enum": ["", "linux", "wimboot", "mboot"]},
+    "files": {
+      "type": ["array", "null"],
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["path"],
+        "properties": {"name": {"type": "string"}, "path": {"type": "string"}}
+      }
+    },
+    "dtb": {"type": "string"},
+    "acpi": {"type": ["array", "null"], "items": {"type": "string"}},
+    "initrd-overlays": {"type": ["array", "null"], "items": {"type": "string"}},
+    "kernel-digest": {"$ref": "#/$defs/Digest"},
+    "initrd-digest": {"$ref": "#/$defs/Digest"},
+    "annotations": {"type": ["array", "null"], "description": "Ignored on input."},
+    "provenance": {"type": ["object", "null"], "description": "Ignored on input."}
+  },
+  "$defs": {
+    "Messages": {
+      "type": ["object", "null"],
+      "description": "Boot script messages, text/template templates.",
+      "additionalProperties": false,
+      "properties": {
+        "banner": {"type": "string"},
+        "boot-deps-wait": {"type": "string"},
+        "image-info": {"type": "string"},
+        "boot-retry": {"type": "string"}
+      }
+    },
+    "CloudInit": {
+      "type": ["object", "null"],
+      "additionalProperties": false,
+      "properties": {
+        "meta-data": {"type": ["object", "null"]},
+        "user-data": {"type": ["object", "null"]},
+        "phone-home": {"$ref": "#/$defs/PhoneHome"}
+      }
+    },
+    "PhoneHome": {
+      "type": ["object", "null"],
+      "additionalProperties": false,
+      "properties": {
+        "pub_key_dsa": {"type": "string"},
+        "pub_key_rsa": {"type": "string"},
+        "pub_key_ecdsa": {"type": "string"},
+        "pub_key_ed25519": {"type": "string"},
+        "instance_id": {"type": "string"},
+        "hostname": {"type": "string"},
+        "fqdn": {"type": "string"}
+      }
+    },
+    "FirstBoot": {
+      "type": ["object", "null"],
+      "additionalProperties": false,
+      "properties": {
+        "params": {"type": "string"},
+        "kernel": {"type": "string"},
+        "initrd": {"type": "string"},
+        "cloud-init": {"$ref": "#/$defs/CloudInit"}
+      }
+    },
+    "Digest": {
+      "type": "string",
+      "pattern": "^(|sha256:[0-9a-fA-F]{64}|etag:.+)$"
+    }
+  }
+}`
+
+var bootParamsSchema = func() map[string]interface{} {
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(bootParamsSchemaJSON), &s); err != nil {
+		log.Fatalf("Invalid boot parameters schema: %s", err)
+	}
+	return s
+}()
+
+func jsonType(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// Function validateJSON() checks v against the schema s, returning the
+// problems found, each prefixed with the JSON pointer of the value.
+func validateJSON(root, s map[string]interface{}, v interface{}, path string) []string {
+	if ref, ok := s["$ref"].(string); ok {
+		def, _ := root["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		return validateJSON(root, def, v, path)
+	}
+	where := path
+	if where == "" {
+		where = "/"
+	}
+	got := jsonType(v)
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch tt := t.(type) {
+		case string:
+			types = []string{tt}
+		case []interface{}:
+			for _, x := range tt {
+				types = append(types, x.(string))
+			}
+		}
+		match := false
+		for _, want := range types {
+			match = match || want == got || (want == "number" && got == "integer")
+		}
+		if !match {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", where, strings.Join(types, " or "), got)}
+		}
+	}
+	var problems []string
+	switch x := v.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := props[k].(map[string]interface{}); ok {
+				problems = append(problems, validateJSON(root, ps, x[k], path+"/"+k)...)
+			} else if ap, ok := s["additionalProperties"].(bool); ok && !ap {
+				problems = append(problems, fmt.Sprintf("%s/%s: unknown field", path, k))
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range x {
+				problems = append(problems, validateJSON(root, items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	case string:
+		if p, ok := s["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(x) {
+			problems = append(problems, fmt.Sprintf("%s: '%s' does not match %s", where, x, p))
+		}
+	case json.Number:
+		f, _ := x.Float64()
+		if min, ok := s["minimum"].(float64); ok && f < min {
+			problems = append(problems, fmt.Sprintf("%s: %s is less than %v", where, x, min))
+		}
+		if max, ok := s["maximum"].(float64); ok && f > max {
+			problems = append(problems, fmt.Sprintf("%s: %s is greater than %v", where, x, max))
+		}
+	}
+	return problems
+}
+
+// Function validateBody() checks a JSON request body against the schema and
+// puts it back for the handler.  If it sends an error response instead it
+// returns false.
+func validateBody(w http.ResponseWriter, r *http.Request, schema map[string]interface{}) bool {
+	if !validatePayloads || r.Body == nil {
+		return true
+	}
+	p, err := io.ReadAll(r.Body)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Failed to receive request body: %v", err))
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(p))
+	if len(bytes.TrimSpace(p)) == 0 {
+		return true
+	}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var v interface{}
+	if err = dec.Decode(&v); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - Invalid JSON: %s", err))
+		return false
+	}
+	if problems := validateJSON(schema, schema, v, ""); len(problems) > 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Bad Request - "+strings.Join(problems, "; "))
+		return false
+	}
+	return true
+}
+
+func SchemaGet(w http.ResponseWriter, r *http.Request) {
+	debugf("SchemaGet(): Received request %v\n", r.URL)
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.WriteString(w, bootParamsSchemaJSON+"\n"); err != nil {
+		log.Printf("Yikes, I couldn't write the schema: %s\n", err)
+	}
+}
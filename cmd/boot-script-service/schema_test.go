@@ -0,0 +1,110 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func validateString(t *testing.T, body string) []string {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Bad test JSON %s: %s", body, err)
+	}
+	return validateJSON(bootParamsSchema, bootParamsSchema, v, "")
+}
+
+// What BSS itself sends must be valid input.
+func TestSchemaMatchesBootParams(t *testing.T) {
+	bp := bssTypes.BootParams{
+		Hosts: []string{"x0c0s1b0n0"}, Macs: []string{"00:1e:67:e3:46:51"}, Nids: []int32{8},
+		Params: "console=ttyS0", Kernel: "s3://k", Initrd: "s3://i",
+		CloudInit: bssTypes.CloudInit{MetaData: bssTypes.CloudDataType{"a": 1},
+			PhoneHome: bssTypes.PhoneHome{Hostname: "nid000008"}},
+		FirstBoot:    &bssTypes.FirstBoot{Kernel: "s3://fk"},
+		KernelDigest: "sha256:" + strings.Repeat("ab", 32),
+		Annotations:  []bssTypes.Annotation{{Name: "x0c0s1b0n0", Note: "note"}},
+		Provenance:   &bssTypes.Provenance{UpdatedBy: "me"},
+	}
+	for _, v := range []interface{}{bp, bssTypes.BootParams{}} {
+		data, _ := json.Marshal(v)
+		if problems := validateString(t, string(data)); len(problems) > 0 {
+			t.Errorf("%s: %v", data, problems)
+		}
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"hosts":["x1"],"kernal":"s3://k"}`:               "/kernal: unknown field",
+		`{"nids":[1,"2"]}`:                                 "/nids/1: expected integer, got string",
+		`{"nids":[1.5]}`:                                   "/nids/0: expected integer, got number",
+		`{"nids":[4294967296]}`:                            "/nids/0: 4294967296 is greater than",
+		`{"cloud-init":{"meta-data":[]}}`:                  "/cloud-init/meta-data: expected object or null, got array",
+		`{"cloud-init":{"phone-home":{"hostname":1}}}`:     "/cloud-init/phone-home/hostname: expected string",
+		`{"kernel-digest":"md5:abc"}`:                      "/kernel-digest: 'md5:abc' does not match",
+		`["x1"]`:                                           "/: expected object, got array",
+		`{"first-boot":{"cloud-init":{"user-data":null}}}`: "",
+	} {
+		problems := strings.Join(validateString(t, body), "; ")
+		if (want == "") != (problems == "") || !strings.Contains(problems, want) {
+			t.Errorf("%s: got '%s', expected '%s'", body, problems, want)
+		}
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, baseEndpoint+"/bootparameters",
+		bytes.NewBufferString(`{"hosts":["x3000c0s30b0n0"],"paramz":"quiet"}`))
+	rr := httptest.NewRecorder()
+	bootParameters(rr, req)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "/paramz: unknown field") {
+		t.Errorf("POST with an unknown field returned %d: %s", rr.Code, rr.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"hosts":["x1"]}`))
+	if !validateBody(httptest.NewRecorder(), req, bootParamsSchema) {
+		t.Fatalf("Valid body rejected")
+	}
+	var bp bssTypes.BootParams
+	if err := json.NewDecoder(req.Body).Decode(&bp); err != nil || bp.Hosts[0] != "x1" {
+		t.Errorf("Body not restored: %+v %v", bp, err)
+	}
+
+	rr = httptest.NewRecorder()
+	schema(rr, httptest.NewRequest(http.MethodGet, baseEndpoint+"/schema", nil))
+	var doc map[string]interface{}
+	if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &doc) != nil || doc["title"] != "BootParams" {
+		t.Errorf("GET schema returned %d: %s", rr.Code, rr.Body)
+	}
+}
@@ -0,0 +1,202 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Node identity checks.
+//
+// A boot script request identifies the node in up to three ways: the source
+// IP address, the MAC address and the name parameter.  Each of these maps to
+// an xname through HSM.  If they disagree, something is spoofing or
+// misconfigured, so a security event is logged and recorded.  The source IP
+// is then flagged as suspect until a consistent request is seen from it, and
+// with --spoof-protect set, suspect IPs are only given default cloud-init
+// data rather than node-specific data.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const (
+	securityEventsPfx   = "/security-events/"
+	securitySuspectPfx  = "/security-suspect/"
+	identityMismatchEvt = "identity-mismatch"
+)
+
+var (
+	spoofProtect      = false
+	securityEventsMax = uint(1000)
+)
+
+type securityEvent struct {
+	Time      int64  `json:"time"`
+	Type      string `json:"type"`
+	Endpoint  string `json:"endpoint"`
+	Remote    string `json:"remote"`
+	IPXname   string `json:"ip-xname,omitempty"`
+	Mac       string `json:"mac,omitempty"`
+	MacXname  string `json:"mac-xname,omitempty"`
+	Name      string `json:"name,omitempty"`
+	NameXname string `json:"name-xname,omitempty"`
+}
+
+// The HSM cache is refreshed periodically anyway, so it is good enough here
+// and avoids forcing an HSM query on every boot script request.
+func ipXnameInCache(ip string) string {
+	state := getState()
+	if state == nil {
+		return ""
+	}
+	return state.IPAddrs[canonicalIP(ip)].CompID
+}
+
+func recordSecurityEvent(evt securityEvent) {
+	log.Printf("SECURITY: %s on %s from %s: ip->%s mac %s->%s name %s->%s",
+		evt.Type, evt.Endpoint, evt.Remote, evt.IPXname, evt.Mac, evt.MacXname, evt.Name, evt.NameXname)
+	auditEmit(auditIdentity, evt.IPXname, evt.Remote, evt.Endpoint, "MAC %s is %s, name %s is %s",
+		evt.Mac, evt.MacXname, evt.Name, evt.NameXname)
+	key := fmt.Sprintf("%s%020d", securityEventsPfx, time.Now().UnixNano())
+	if err := storeData(key, evt); err != nil {
+		log.Printf("Failed to store security event: %s", err)
+	}
+	if err := kvstore.Store(securitySuspectPfx+evt.Remote, strconv.FormatInt(evt.Time, 10)); err != nil {
+		log.Printf("Failed to flag %s as suspect: %s", evt.Remote, err)
+	}
+	pruneSecurityEvents()
+}
+
+func pruneSecurityEvents() {
+	kvl, err := kvstore.GetRange(securityEventsPfx+keyMin, securityEventsPfx+keyMax)
+	if err != nil || uint(len(kvl)) <= securityEventsMax {
+		return
+	}
+	// Keys sort by time, so the oldest events come first.
+	for _, kv := range kvl[:uint(len(kvl))-securityEventsMax] {
+		kvstore.Delete(kv.Key)
+	}
+}
+
+// Function checkIdentity() compares the xnames the request's source IP, MAC
+// and name map to, and records a security event if they disagree.
+func checkIdentity(r *http.Request, endpoint, mac, name string) {
+	evt := securityEvent{
+		Type:     identityMismatchEvt,
+		Endpoint: endpoint,
+		Remote:   findRemoteAddr(r),
+		Mac:      mac,
+		Name:     name,
+	}
+	evt.IPXname = ipXnameInCache(evt.Remote)
+	if evt.IPXname == "" {
+		// Nothing to compare against.
+		return
+	}
+	if mac != "" {
+		if comp, ok := FindSMCompByMAC(mac); ok {
+			evt.MacXname = comp.ID
+		}
+	}
+	if name != "" {
+		if comp, ok := FindSMCompByNameInCache(name); ok {
+			evt.NameXname = comp.ID
+		}
+	}
+	if (evt.MacXname == "" || evt.MacXname == evt.IPXname) &&
+		(evt.NameXname == "" || evt.NameXname == evt.IPXname) {
+		if evt.MacXname != "" || evt.NameXname != "" {
+			kvstore.Delete(securitySuspectPfx + evt.Remote)
+		}
+		return
+	}
+	evt.Time = time.Now().Unix()
+	recordSecurityEvent(evt)
+}
+
+// Function isSuspect() reports whether node-specific data should be withheld
+// from the given source IP.
+func isSuspect(remote string) bool {
+	if !spoofProtect {
+		return false
+	}
+	_, exists, err := kvstore.Get(securitySuspectPfx + remote)
+	if err != nil {
+		log.Printf("Failed to check suspect state of %s: %s", remote, err)
+	}
+	return exists
+}
+
+func SecurityEventsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("SecurityEventsGet(): Received request %v\n", r.URL)
+	r.ParseForm() // r.Form is empty until after parsing
+	since, err := getIntParam(r, "since", 0)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - Invalid since '%s'", strings.Join(r.Form["since"], "")))
+		return
+	}
+	kvl, err := kvstore.GetRange(securityEventsPfx+keyMin, securityEventsPfx+keyMax)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to retrieve security events: %s", err))
+		return
+	}
+	events := []securityEvent{}
+	for _, kv := range kvl {
+		var evt securityEvent
+		if err = json.Unmarshal([]byte(kv.Value), &evt); err == nil && evt.Time >= since {
+			events = append(events, evt)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(events)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
+
+// Deleting the security events also clears all suspect flags.
+func SecurityEventsDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("SecurityEventsDelete(): Received request %v\n", r.URL)
+	for _, pfx := range []string{securityEventsPfx, securitySuspectPfx} {
+		kvl, err := kvstore.GetRange(pfx+keyMin, pfx+keyMax)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to remove security events: %s", err))
+			return
+		}
+		for _, kv := range kvl {
+			kvstore.Delete(kv.Key)
+		}
+	}
+	log.Printf("/security-events DELETE")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,74 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+)
+
+func TestIdentityMismatch(t *testing.T) {
+	const ip = "10.252.1.10"
+	state := getState()
+	savedAddrs, savedProtect := state.IPAddrs, spoofProtect
+	state.IPAddrs = map[string]sm.CompEthInterfaceV2{ip: {CompID: "x0c0s1b0n0"}}
+	spoofProtect = true
+	defer func() {
+		state.IPAddrs, spoofProtect = savedAddrs, savedProtect
+		SecurityEventsDelete(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/", nil))
+	}()
+
+	bootscript := func(mac string) {
+		req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?mac="+mac, nil)
+		req.RemoteAddr = ip + ":4011"
+		req.ParseForm()
+		checkIdentity(req, "bootscript", mac, "")
+	}
+
+	// MAC of x0c0s5b0n0 requested from the IP of x0c0s1b0n0
+	bootscript("00:1e:67:d8:9a:e1")
+	if !isSuspect(ip) {
+		t.Errorf("%s is not suspect after an identity mismatch", ip)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/security-events", nil)
+	rr := httptest.NewRecorder()
+	securityEvents(rr, req)
+	var events []securityEvent
+	if err := json.NewDecoder(rr.Body).Decode(&events); err != nil {
+		t.Fatalf("Failed to decode security events: %s", err)
+	}
+	if len(events) != 1 || events[0].IPXname != "x0c0s1b0n0" || events[0].MacXname != "x0c0s5b0n0" {
+		t.Errorf("Unexpected security events: %v", events)
+	}
+
+	// A consistent request clears the suspect flag
+	bootscript("00:1e:67:e3:46:51")
+	if isSuspect(ip) {
+		t.Errorf("%s is still suspect after a consistent request", ip)
+	}
+}
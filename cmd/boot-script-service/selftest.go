@@ -0,0 +1,178 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot script pipeline self test.
+//
+// The self test renders a boot script for a synthetic canary node taken from
+// the built-in mem: HSM fixture.  It exercises each stage of the normal boot
+// script path: the HSM component lookup, a round trip through the datastore,
+// S3 URL presigning (with the mock signer so no credentials are needed), and the
+// script rendering itself.  It is intended for smoke tests after deployments.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/idgen"
+)
+
+// Each run stores under its own key so overlapping runs, possibly on
+// different replicas, cannot delete or overwrite each other's entry.
+const selfTestPfx = "/bss/selftest/"
+
+type selfTestStage struct {
+	Name     string  `json:"name"`
+	Result   string  `json:"result"`
+	Duration float64 `json:"duration-ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+type selfTestReport struct {
+	Result string          `json:"result"`
+	Node   string          `json:"node,omitempty"`
+	Stages []selfTestStage `json:"stages"`
+}
+
+// Function runSelfTest() runs each of the self test stages in order, stopping
+// at the first failure.
+func runSelfTest() selfTestReport {
+	var (
+		report selfTestReport
+		comp   SMComponent
+		bd     BootData
+		script string
+	)
+	report.Result = "pass"
+	run := func(name string, f func() error) bool {
+		start := time.Now()
+		err := f()
+		stage := selfTestStage{
+			Name:     name,
+			Result:   "pass",
+			Duration: float64(time.Since(start).Microseconds()) / 1000.0,
+		}
+		if err != nil {
+			stage.Result = "fail"
+			stage.Error = err.Error()
+			report.Result = "fail"
+		}
+		report.Stages = append(report.Stages, stage)
+		return err == nil
+	}
+
+	ok := run("hsm-fixture", func() error {
+		var comps SMData
+		err := json.NewDecoder(bytes.NewBufferString(state_manager_data_temp)).Decode(&comps)
+		if err != nil {
+			return err
+		}
+		if len(comps.Components) == 0 {
+			return fmt.Errorf("HSM fixture contains no components")
+		}
+		comp = comps.Components[0]
+		report.Node = comp.ID
+		return nil
+	})
+	ok = ok && run("datastore", func() error {
+		selfTestKey := selfTestPfx + idgen.New()
+		bds := BootDataStore{
+			Params:        "console=ttyS0 metal.server=s3://selftest/rootfs",
+			ReferralToken: idgen.New(),
+		}
+		if err := storeData(selfTestKey, bds); err != nil {
+			return err
+		}
+		defer kvstore.Delete(selfTestKey)
+		val, exists, err := kvstore.Get(selfTestKey)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("Key %s does not exist after store", selfTestKey)
+		}
+		var readBack BootDataStore
+		if err = json.Unmarshal([]byte(val), &readBack); err != nil {
+			return err
+		}
+		if readBack.ReferralToken != bds.ReferralToken {
+			return fmt.Errorf("Datastore miscompare: expected %s, actual %s",
+				bds.ReferralToken, readBack.ReferralToken)
+		}
+		bd.Params = readBack.Params
+		bd.ReferralToken = readBack.ReferralToken
+		bd.Kernel = ImageData{Path: "http://selftest.invalid/kernel"}
+		bd.Initrd = ImageData{Path: "http://selftest.invalid/initrd"}
+		return nil
+	})
+	ok = ok && run("presign", func() error {
+		params, err := replaceS3Params(bd.Params, mockSignS3URL)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(params, "s3://") {
+			return fmt.Errorf("S3 URL was not presigned: %s", params)
+		}
+		bd.Params = params
+		return nil
+	})
+	ok = ok && run("render", func() error {
+		var err error
+		sp := scriptParams{comp.ID, comp.NID.String(), bd.ReferralToken, ""}
+		// The images are not real, so approval is not checked.
+		script, err = renderBootScript(bd, sp, "chain selftest", comp.Role, comp.SubRole, "selftest")
+		if err != nil {
+			return err
+		}
+		for _, expect := range []string{"#!ipxe", "kernel --name kernel " + bd.Kernel.Path,
+			"initrd --name initrd " + bd.Initrd.Path, "xname=" + comp.ID, "bss_referral_token=" + bd.ReferralToken} {
+			if !strings.Contains(script, expect) {
+				return fmt.Errorf("Rendered script is missing '%s'", expect)
+			}
+		}
+		return nil
+	})
+	return report
+}
+
+func selfTestAPI(w http.ResponseWriter, r *http.Request) {
+	debugf("selfTestAPI(): Received request %v\n", r.URL)
+	report := runSelfTest()
+	httpStatus := http.StatusOK
+	if report.Result != "pass" {
+		httpStatus = http.StatusInternalServerError
+		log.Printf("Self test failed: %v", report.Stages)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(httpStatus)
+	err := json.NewEncoder(w).Encode(report)
+	if err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -23,7 +23,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -88,3 +90,26 @@ func TestServiceStatusAPI(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSelfTest(t *testing.T) {
+	defer func(strict bool) { approvedImagesStrict = strict }(approvedImagesStrict)
+	// The synthetic images are not approved, which must not fail the test.
+	for _, approvedImagesStrict = range []bool{false, true} {
+		req := httptest.NewRequest("GET", URL+"/boot/v1/selftest", nil)
+		recorder := httptest.NewRecorder()
+		selfTestAPI(recorder, req)
+		var report selfTestReport
+		if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Self test response decode failed: %s", err)
+		}
+		if recorder.Code != http.StatusOK || report.Result != "pass" {
+			t.Errorf("Self test with strict %v failed, code %d: %+v", approvedImagesStrict, recorder.Code, report)
+		}
+		if len(report.Stages) != 4 {
+			t.Errorf("Self test expected 4 stages, got %d", len(report.Stages))
+		}
+	}
+	if kvl, _ := kvstore.GetRange(selfTestPfx+keyMin, selfTestPfx+keyMax); len(kvl) != 0 {
+		t.Errorf("Self test left keys behind: %v", kvl)
+	}
+}
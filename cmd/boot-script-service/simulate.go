@@ -0,0 +1,237 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Simulated HSM changes.
+//
+// POST /boot/v1/simulate takes a hypothetical HSM state, components with a
+// new role or subrole, new components, components leaving HSM and changes
+// of HSM group membership, and reports which nodes would boot differently,
+// without changing anything.  Group changes matter through HSM-managed boot
+// groups: a node added to the HSM group of a boot group gets the boot
+// configuration of that group, a node removed from it loses its host entry
+// and falls back to its role or the default, as the boot group sync would
+// do.  Only the nodes named in the request are checked.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+type simulatedHost struct {
+	comp  SMComponent
+	known bool
+	group *bssTypes.BootGroup // boot group the node would join
+	leave bool                // host entry the boot group sync would remove
+}
+
+// Function simulatedBootData() looks up the boot data of a node in the
+// simulated state.
+func simulatedBootData(name string, h simulatedHost) BootData {
+	role := ""
+	if h.known {
+		role = h.comp.Role
+	}
+	if h.group == nil && !h.leave {
+		return lookup(name, name, role, DefaultTag)
+	}
+	var bd BootData
+	if h.group != nil {
+		bds, _ := lookupHost(name)
+		bds.Params = h.group.Params
+		bds.Kernel = imageFind(h.group.Kernel, kernelImageType)
+		bds.Initrd = imageFind(h.group.Initrd, initrdImageType)
+		bd = bdConvert(bds)
+		if bds.Kernel == "" {
+			bd.Kernel.Path = h.group.Kernel
+		}
+		if bds.Initrd == "" {
+			bd.Initrd.Path = h.group.Initrd
+		}
+	} else if bds, err := lookupStore("", "", role, DefaultTag); err == nil {
+		bd = bdConvert(bds)
+	}
+	applyOverride(name, &bd)
+	return bd
+}
+
+// Function simulatedBootParams() returns the effective boot configuration
+// with the conditional parameters rendered for the role and subrole, since
+// those depend on HSM too.
+func simulatedBootParams(name string, bd BootData, comp SMComponent, known bool) bssTypes.BootParams {
+	role, subRole := "", ""
+	if known {
+		role, subRole = comp.Role, comp.SubRole
+	}
+	bp := effectiveBootData(name, bd, role)
+	sp := scriptParams{xname: name}
+	if known {
+		sp.nid = comp.NID.String()
+	}
+	if params, _, err := renderConditionals(bp.Params, nodeFacts(sp, role, subRole, scriptLabels(bd))); err == nil {
+		bp.Params = params
+	}
+	return bp
+}
+
+func changedFields(before, after bssTypes.BootParams) []string {
+	var fields []string
+	if before.Kernel != after.Kernel {
+		fields = append(fields, "kernel")
+	}
+	if before.Initrd != after.Initrd {
+		fields = append(fields, "initrd")
+	}
+	if before.Params != after.Params {
+		fields = append(fields, "params")
+	}
+	if !reflect.DeepEqual(before.CloudInit, after.CloudInit) {
+		fields = append(fields, "cloud-init")
+	}
+	return fields
+}
+
+// Function simulateHSM() evaluates the hypothetical HSM state of req.
+func simulateHSM(req bssTypes.SimulateRequest) (bssTypes.SimulateReport, error) {
+	hosts := make(map[string]*simulatedHost)
+	host := func(name string) *simulatedHost {
+		h, ok := hosts[name]
+		if !ok {
+			h = &simulatedHost{}
+			h.comp, h.known = FindSMCompByNameInCache(name)
+			hosts[name] = h
+		}
+		return h
+	}
+	for _, c := range req.Components {
+		h := host(c.ID)
+		if c.Removed {
+			h.known = false
+			continue
+		}
+		if !h.known {
+			h.comp.ID = c.ID
+		}
+		h.known = true
+		if c.Role != "" {
+			h.comp.Role = c.Role
+		}
+		if c.SubRole != "" {
+			h.comp.SubRole = c.SubRole
+		}
+	}
+	if len(req.Groups) > 0 {
+		groups, err := getBootGroups()
+		if err != nil {
+			return bssTypes.SimulateReport{}, err
+		}
+		for _, change := range req.Groups {
+			for _, m := range append(append([]string{}, change.Remove...), change.Add...) {
+				host(m)
+			}
+		}
+		// As in the boot group sync, removals first, and only hosts known
+		// to HSM lose their entry.
+		for i := range groups {
+			g := &groups[i]
+			if g.HSMGroup == "" {
+				continue
+			}
+			members := make(map[string]bool)
+			for _, m := range g.Members {
+				members[m] = true
+			}
+			for _, change := range req.Groups {
+				if change.Group != g.HSMGroup {
+					continue
+				}
+				for _, m := range change.Remove {
+					if h := hosts[m]; members[m] && h.known {
+						h.leave = true
+					}
+				}
+				for _, m := range change.Add {
+					if !members[m] {
+						hosts[m].group, hosts[m].leave = g, false
+					}
+				}
+			}
+		}
+	}
+
+	report := bssTypes.SimulateReport{Checked: len(hosts), Changed: []bssTypes.SimulatedChange{}}
+	for name, h := range hosts {
+		bd, comp := LookupByName(name)
+		before := simulatedBootParams(name, bd, comp, comp.ID != "")
+		after := simulatedBootParams(name, simulatedBootData(name, *h), h.comp, h.known)
+		if fields := changedFields(before, after); len(fields) > 0 {
+			report.Changed = append(report.Changed,
+				bssTypes.SimulatedChange{Name: name, Fields: fields, Before: before, After: after})
+		}
+	}
+	sort.Slice(report.Changed, func(i, j int) bool { return report.Changed[i].Name < report.Changed[j].Name })
+	return report, nil
+}
+
+func SimulatePost(w http.ResponseWriter, r *http.Request) {
+	debugf("SimulatePost(): Received request %v\n", r.URL)
+	var req bssTypes.SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	for _, c := range req.Components {
+		if c.ID == "" {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				"Bad Request - Components need an id")
+			return
+		}
+	}
+	for _, g := range req.Groups {
+		if g.Group == "" {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				"Bad Request - Group changes need a group")
+			return
+		}
+	}
+	report, err := simulateHSM(req)
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Cannot simulate: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
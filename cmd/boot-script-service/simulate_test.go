@@ -0,0 +1,101 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestSimulate(t *testing.T) {
+	const kernel = "s3://boot-images/sim/kernel"
+	defer func() {
+		for _, m := range []string{"x0c0s4b0n0", "Application"} {
+			kvstore.Delete(paramsPfx + m)
+		}
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{"Application"}, Params: "sim-app",
+		Kernel: "http://s3/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	body := bytes.NewBufferString(`{"name":"sim","hsm-group":"sim","kernel":"` + kernel + `",` +
+		`"params":"{{if role=Compute}}sim-compute{{end}}","members":["x0c0s4b0n0"]}`)
+	rr := httptest.NewRecorder()
+	bootGroups(rr, httptest.NewRequest(http.MethodPost, baseEndpoint+"/bootgroups", body))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST bootgroups returned %d: %s", rr.Code, rr.Body)
+	}
+	var g bssTypes.BootGroup
+	json.Unmarshal(rr.Body.Bytes(), &g)
+	defer kvstore.Delete(bootGroupsPfx + g.ID)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		simulate(w, httptest.NewRequest(http.MethodPost, baseEndpoint+"/simulate", strings.NewReader(body)))
+		return w
+	}
+	w := post(`{"components":[{"id":"x0c0s3b0n0","role":"Application"},{"id":"x0c0s6b0n0"},` +
+		`{"id":"x0c0s5b0n0","role":"Compute"}],` +
+		`"groups":[{"group":"sim","add":["x0c0s5b0n0"],"remove":["x0c0s4b0n0"]}]}`)
+	var rep bssTypes.SimulateReport
+	json.Unmarshal(w.Body.Bytes(), &rep)
+	if w.Code != http.StatusOK || rep.Checked != 4 || len(rep.Changed) != 3 {
+		t.Fatalf("Unexpected simulation %d: %s", w.Code, w.Body.String())
+	}
+	changes := make(map[string]bssTypes.SimulatedChange)
+	for _, c := range rep.Changed {
+		changes[c.Name] = c
+	}
+	if c := changes["x0c0s3b0n0"]; !strings.HasPrefix(c.After.Params, "sim-app") ||
+		strings.Join(c.Fields, ",") == "" {
+		t.Errorf("Role change not simulated: %+v", c)
+	}
+	if c := changes["x0c0s4b0n0"]; c.Before.Kernel != kernel || c.After.Kernel == kernel {
+		t.Errorf("Group removal not simulated: %+v", c)
+	}
+	if c := changes["x0c0s5b0n0"]; c.After.Kernel != kernel || c.After.Params != "sim-compute" {
+		t.Errorf("Group addition not simulated: %+v", c)
+	}
+	if _, err := lookupHost("x0c0s5b0n0"); err == nil {
+		t.Errorf("Simulation stored boot parameters")
+	}
+	if comp, _ := FindSMCompByNameInCache("x0c0s3b0n0"); comp.Role != "" {
+		t.Errorf("Simulation changed the HSM state")
+	}
+
+	if w = post(`{"components":[{"role":"Compute"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Component without id returned %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	simulate(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/simulate", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET returned %d", w.Code)
+	}
+}
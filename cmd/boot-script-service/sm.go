@@ -31,6 +31,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -40,11 +41,13 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
 	rf "github.com/Cray-HPE/hms-smd/v2/pkg/redfish"
 	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
 )
@@ -65,15 +68,33 @@ type SMData struct {
 }
 
 var (
-	smMutex     sync.Mutex
+	smMutex     sync.RWMutex
 	smData      *SMData
 	smClient    *http.Client
 	smDataMap   map[string]SMComponent
+	smMacMap    map[string]SMComponent
+	smNidMap    map[int64]SMComponent
+	smNidDups   []bssTypes.DuplicateNid
 	smBaseURL   string
 	smJSONFile  string
 	smTimeStamp int64
 )
 
+// Requests from IP addresses HSM does not know about force an HSM refresh in
+// case the node just came up.  To keep unknown requesters from hammering HSM,
+// an IP that is still unknown after a refresh does not force a refresh again until
+// unknownIPTTL has passed, and forced refreshes are limited to one every
+// forcedRefreshInterval seconds overall.
+var (
+	unknownIPTTL          = uint(60)
+	forcedRefreshInterval = uint(5)
+	unknownIPMutex        sync.Mutex
+	unknownIPs            = make(map[string]time.Time)
+	lastForcedRefresh     time.Time
+)
+
+const unknownIPsPruneSize = 10000
+
 func makeSmMap(state *SMData) map[string]SMComponent {
 	m := make(map[string]SMComponent)
 	for _, v := range state.Components {
@@ -82,6 +103,73 @@ func makeSmMap(state *SMData) map[string]SMComponent {
 	return m
 }
 
+// Function makeSmMacMap() indexes the components by lower case MAC address.
+// Empty slots are left out, and a MAC listed more than once maps to the
+// first component that has it, as the scan it replaces would return.
+func makeSmMacMap(state *SMData) map[string]SMComponent {
+	m := make(map[string]SMComponent)
+	for _, v := range state.Components {
+		if strings.EqualFold(v.State, "empty") {
+			continue
+		}
+		for _, mac := range v.Mac {
+			mac = strings.ToLower(mac)
+			if _, ok := m[mac]; !ok {
+				m[mac] = v
+			}
+		}
+	}
+	return m
+}
+
+// Function makeSmNidMap() indexes the components by NID.  HSM should not
+// hand out a NID twice, but when it does the lowest component name wins, so
+// the answer does not depend on the order HSM lists them in, and the
+// conflicts are returned to be reported.
+func makeSmNidMap(state *SMData) (map[int64]SMComponent, []bssTypes.DuplicateNid) {
+	m := make(map[int64]SMComponent)
+	claims := make(map[int64][]string)
+	for _, v := range state.Components {
+		nid, err := v.NID.Int64()
+		if err != nil {
+			continue
+		}
+		claims[nid] = append(claims[nid], v.ID)
+		if prev, ok := m[nid]; !ok || v.ID < prev.ID {
+			m[nid] = v
+		}
+	}
+	dups := []bssTypes.DuplicateNid{}
+	for nid, ids := range claims {
+		if len(ids) > 1 {
+			sort.Strings(ids)
+			dups = append(dups, bssTypes.DuplicateNid{Nid: nid, Components: ids, Selected: m[nid].ID})
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i].Nid < dups[j].Nid })
+	return m, dups
+}
+
+// The lookup maps that go with an SMData.
+type smIndexes struct {
+	byName  map[string]SMComponent
+	byMAC   map[string]SMComponent
+	byNid   map[int64]SMComponent
+	nidDups []bssTypes.DuplicateNid
+}
+
+func makeSmIndexes(state *SMData) (ix smIndexes) {
+	ix.byName = makeSmMap(state)
+	ix.byMAC = makeSmMacMap(state)
+	ix.byNid, ix.nidDups = makeSmNidMap(state)
+	return ix
+}
+
+// Function install() makes ix the current lookup maps, with smMutex held.
+func (ix smIndexes) install() {
+	smDataMap, smMacMap, smNidMap, smNidDups = ix.byName, ix.byMAC, ix.byNid, ix.nidDups
+}
+
 func SmOpen(base, options string) error {
 	u, err := url.Parse(base)
 	if err != nil {
@@ -92,16 +180,16 @@ func SmOpen(base, options string) error {
 		// purposes.  A canned set of pre-defined nodes are loaded into memory
 		// and used as state manager data.  This allows for testing of a larger
 		// set of nodes than is currently readily available.
-		debugf("Setting internal HSM data")
+		debugmf(debugHSM, "Setting internal HSM data")
 		buf := bytes.NewBufferString(state_manager_data_temp)
 		dec := json.NewDecoder(buf)
 		var comps SMData
 		err = dec.Decode(&comps)
 		if err != nil {
-			debugf("Internal data conversion failure: %v", err)
+			debugmf(debugHSM, "Internal data conversion failure: %v", err)
 		}
 		smData = &comps
-		smDataMap = makeSmMap(smData)
+		makeSmIndexes(smData).install()
 		return nil
 	}
 	if u.Scheme == "file" {
@@ -109,7 +197,7 @@ func SmOpen(base, options string) error {
 		// little more flexibilty than the mem: interface, but not quite as
 		// stand-alone.
 		smJSONFile = u.Path
-		debugf("Setting externel HSM data file: %s", smJSONFile)
+		debugmf(debugHSM, "Setting externel HSM data file: %s", smJSONFile)
 		return nil
 	}
 	https := u.Scheme == "https"
@@ -181,72 +269,198 @@ func ensureLegalMAC(mac string) string {
 	return hw.String()
 }
 
+type hsmCompEndpt struct {
+	ID           string `json:"ID"`
+	Enabled      *bool  `json:"Enabled"`
+	RfEndpointID string `json:"RedfishEndpointID"`
+}
+
+type hsmCompEndptArray struct {
+	CompEndpts []*hsmCompEndpt `json:"ComponentEndpoints"`
+}
+
+// The HSM state is fetched in three requests at once, each with a deadline of
+// hsmFetchTimeout seconds.  The components are needed, but when one of the
+// inventory requests fails the section from the last refresh is used, so
+// that a slow endpoint does not throw away the MAC and IP mappings.
+var hsmFetchTimeout = uint(60) // seconds, 0 is no deadline
+
+var hsmLastSections struct {
+	ep         *sm.ComponentEndpointArray
+	mep        *hsmCompEndptArray
+	ifaces     []sm.CompEthInterfaceV2
+	ifacesFull time.Time // last time all interfaces were fetched
+}
+
+// With hsmFullSyncInterval set, refreshes only ask HSM for the ethernet
+// interfaces updated since the newest one known, and merge them into the
+// previous list.  Deletions do not show up that way, so all interfaces are
+// still fetched every hsmFullSyncInterval seconds.  HSM has no such filter
+// for components and component endpoints, which are always fetched whole.
+var hsmFullSyncInterval = uint(0) // seconds, 0 always fetches everything
+
+const (
+	hsmComponentsPath = "/State/Components?type=Node"
+	hsmEndpointsPath  = "/Inventory/ComponentEndpoints?type=Node"
+	hsmIfacesPath     = "/Inventory/EthernetInterfaces?type=Node"
+)
+
+// Function hsmGet() returns the body of an HSM state request.
+func hsmGet(path string) ([]byte, error) {
+	ctx := context.Background()
+	if hsmFetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(hsmFetchTimeout)*time.Second)
+		defer cancel()
+	}
+	url := smBaseURL + path
+	debugmf(debugHSM, "url: %s, smClient: %v\n", url, smClient)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create HTTP request for '%s': %v", url, err)
+	}
+	req.Close = true
+	base.SetHTTPUserAgent(req, serviceName)
+	r, err := smClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Sm request %s failed: %v", url, err)
+	}
+	defer r.Body.Close()
+	debugmf(debugHSM, "getStateFromHSM(): GET %s -> r: %v\n", url, r.Status)
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Sm request %s failed: %s", url, r.Status)
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Sm request %s failed: %v", url, err)
+	}
+	return data, nil
+}
+
+// Function hsmIfacesSince() returns the time of the newest ethernet
+// interface update, or "" if all interfaces have to be fetched.
+func hsmIfacesSince(now time.Time) string {
+	last := &hsmLastSections
+	if hsmFullSyncInterval == 0 || last.ifaces == nil ||
+		now.Sub(last.ifacesFull) >= time.Duration(hsmFullSyncInterval)*time.Second {
+		return ""
+	}
+	var newest time.Time
+	for _, e := range last.ifaces {
+		if t, err := time.Parse(time.RFC3339Nano, e.LastUpdate); err == nil && t.After(newest) {
+			newest = t
+		}
+	}
+	if newest.IsZero() {
+		return ""
+	}
+	return newest.UTC().Format(time.RFC3339Nano)
+}
+
+// Function mergeEthIfaces() replaces the interfaces of old that were
+// updated by those in delta and appends the new ones.
+func mergeEthIfaces(old, delta []sm.CompEthInterfaceV2) []sm.CompEthInterfaceV2 {
+	index := make(map[string]int, len(old))
+	merged := make([]sm.CompEthInterfaceV2, len(old), len(old)+len(delta))
+	for i, e := range old {
+		index[e.ID] = i
+		merged[i] = e
+	}
+	for _, e := range delta {
+		if i, ok := index[e.ID]; ok {
+			merged[i] = e
+		} else {
+			index[e.ID] = len(merged)
+			merged = append(merged, e)
+		}
+	}
+	return merged
+}
+
+// Function fetchHSMSections() runs the three HSM requests concurrently and
+// decodes them, falling back to the previous inventory sections.  It fails if
+// the components cannot be fetched or an inventory section is missing.
+func fetchHSMSections() (comps SMData, ep sm.ComponentEndpointArray, mep hsmCompEndptArray,
+	ethIfaces []sm.CompEthInterfaceV2, err error) {
+	now := time.Now()
+	since := hsmIfacesSince(now)
+	ifacesPath := hsmIfacesPath
+	if since != "" {
+		ifacesPath += "&newerThan=" + url.QueryEscape(since)
+	}
+	paths := []string{hsmComponentsPath, hsmEndpointsPath, ifacesPath}
+	bodies := make([][]byte, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			bodies[i], errs[i] = hsmGet(path)
+		}(i, path)
+	}
+	wg.Wait()
+
+	if errs[0] == nil {
+		errs[0] = json.Unmarshal(bodies[0], &comps)
+	}
+	if errs[0] != nil {
+		return comps, ep, mep, nil, errs[0]
+	}
+	if errs[1] == nil {
+		errs[1] = json.Unmarshal(bodies[1], &ep)
+	}
+	if errs[1] == nil {
+		errs[1] = json.Unmarshal(bodies[1], &mep)
+	}
+	if errs[1] == nil {
+		hsmLastSections.ep, hsmLastSections.mep = &ep, &mep
+	} else if hsmLastSections.ep != nil {
+		log.Printf("WARNING: keeping the previous component endpoints: %s", errs[1])
+		ep, mep = *hsmLastSections.ep, *hsmLastSections.mep
+	} else {
+		return comps, ep, mep, nil, errs[1]
+	}
+	if errs[2] == nil {
+		errs[2] = json.Unmarshal(bodies[2], &ethIfaces)
+	}
+	if errs[2] == nil {
+		if since != "" {
+			debugmf(debugHSM, "%d ethernet interfaces updated since %s\n", len(ethIfaces), since)
+			ethIfaces = mergeEthIfaces(hsmLastSections.ifaces, ethIfaces)
+		} else {
+			hsmLastSections.ifacesFull = now
+		}
+		hsmLastSections.ifaces = ethIfaces
+	} else if hsmLastSections.ifaces != nil {
+		log.Printf("WARNING: keeping the previous ethernet interfaces: %s", errs[2])
+		ethIfaces = hsmLastSections.ifaces
+	} else {
+		return comps, ep, mep, nil, errs[2]
+	}
+	return comps, ep, mep, ethIfaces, nil
+}
+
 func getStateFromHSM() *SMData {
 	if smClient != nil {
 		log.Printf("Retrieving state info from %s", smBaseURL)
-		url := smBaseURL + "/State/Components?type=Node"
-		debugf("url: %s, smClient: %v\n", url, smClient)
-		req, rerr := http.NewRequest(http.MethodGet, url, nil)
-		if rerr != nil {
-			log.Printf("Failed to create HTTP request for '%s': %v", url, rerr)
-			return nil
-		}
-		req.Close = true
-		base.SetHTTPUserAgent(req, serviceName)
-		r, err := smClient.Do(req)
+		comps, ep, mep, ethIfaces, err := fetchHSMSections()
 		if err != nil {
-			log.Printf("Sm State request %s failed: %v", url, err)
+			log.Printf("%s", err)
 			return nil
 		}
-		debugf("getStateFromHSM(): GET %s -> r: %v, err: %v\n", url, r, err)
-		var comps SMData
-		err = json.NewDecoder(r.Body).Decode(&comps)
-		r.Body.Close()
 		// Set up an indexing map to speed up lookup of components in the list
 		compsIndex := make(map[string]int, len(comps.Components))
 		for i, c := range comps.Components {
 			compsIndex[c.ID] = i
 		}
 
-		url = smBaseURL + "/Inventory/ComponentEndpoints?type=Node"
-		req, rerr = http.NewRequest(http.MethodGet, url, nil)
-		if err != nil {
-			log.Printf("Failed to create HTTP request for '%s': %v", url, rerr)
-			return nil
-		}
-		req.Close = true
-		base.SetHTTPUserAgent(req, serviceName)
-		r, err = smClient.Do(req)
-		if err != nil {
-			log.Printf("Sm Inventory request %s failed: %v", url, err)
-			return nil
-		}
-		debugf("getStateFromHSM(): GET %s -> r: %v, err: %v\n", url, r, err)
-		var ep sm.ComponentEndpointArray
-		ce, err := ioutil.ReadAll(r.Body)
-		err = json.Unmarshal(ce, &ep)
-		debugf("getStateFromHSM(): GET %s -> r: %v, err: %v\n", url, r, err)
-		r.Body.Close()
-
-		type myCompEndpt struct {
-			ID           string `json:"ID"`
-			Enabled      *bool  `json:"Enabled"`
-			RfEndpointID string `json: "RedfishEndpointID"`
-		}
-		type myCompEndptArray struct {
-			CompEndpts []*myCompEndpt `json:"ComponentEndpoints"`
-		}
-		var mep myCompEndptArray
-		if err == nil {
-			err = json.Unmarshal(ce, &mep)
-		}
-
 		// We use a map rather than a list.  The values in the map don't matter,
 		// just the keys.  This way duplicates get filtered out.  We will most
 		// likely have duplicates in the Redfish Endpoint IDs.
 		cMap := make(map[string]bool)
 		for idx, e := range ep.ComponentEndpoints {
-			debugf("Endpoint: %v\n", e)
+			debugmf(debugHSM, "Endpoint: %v\n", e)
 			if cIndex, gotIt := compsIndex[e.ID]; gotIt {
 				comps.Components[cIndex].Fqdn = e.FQDN
 				if e.MACAddr != "" && !strings.EqualFold(e.MACAddr, badMAC) &&
@@ -254,10 +468,10 @@ func getStateFromHSM() *SMData {
 					comps.Components[cIndex].Mac = append(comps.Components[cIndex].Mac, e.MACAddr)
 				}
 				if mep.CompEndpts[idx].Enabled != nil {
-					debugf("%s: Enable: %s", e.ID, *mep.CompEndpts[idx].Enabled)
+					debugmf(debugHSM, "%s: Enable: %s", e.ID, *mep.CompEndpts[idx].Enabled)
 					comps.Components[cIndex].EndpointEnabled = *mep.CompEndpts[idx].Enabled
 				} else {
-					debugf("%s: Enable: nil (true)", e.ID)
+					debugmf(debugHSM, "%s: Enable: nil (true)", e.ID)
 					comps.Components[cIndex].EndpointEnabled = true
 				}
 				switch e.ComponentEndpointType {
@@ -275,30 +489,9 @@ func getStateFromHSM() *SMData {
 		}
 
 		//ip address
-		url = smBaseURL + "/Inventory/EthernetInterfaces?type=Node"
-		req, rerr = http.NewRequest(http.MethodGet, url, nil)
-		if err != nil {
-			log.Printf("Failed to create HTTP request for '%s': %v", url, rerr)
-			return nil
-		}
-		req.Close = true
-		base.SetHTTPUserAgent(req, serviceName)
-		r, err = smClient.Do(req)
-		if err != nil {
-			log.Printf("Sm Inventory request %s failed: %v", url, err)
-			return nil
-		}
-		debugf("getStateFromHSM(): GET %s -> r: %v, err: %v\n", url, r, err)
-
-		var ethIfaces []sm.CompEthInterfaceV2
-
-		ce, err = ioutil.ReadAll(r.Body)
-		err = json.Unmarshal(ce, &ethIfaces)
-		r.Body.Close()
-
 		addresses := make(map[string]sm.CompEthInterfaceV2)
 		for _, e := range ethIfaces {
-			debugf("EthInterface: %v\n", e)
+			debugmf(debugHSM, "EthInterface: %v\n", e)
 			for _, ip := range e.IPAddrs {
 				if ip.IPAddr != "" {
 					addresses[ip.IPAddr] = e
@@ -306,12 +499,8 @@ func getStateFromHSM() *SMData {
 			}
 
 			// Also see if this EthernetInterface belongs to any Components.
-			for index, _ := range comps.Components {
-				component := comps.Components[index]
-
-				if component.ID == e.CompID {
-					comps.Components[index].Mac = append(comps.Components[index].Mac, ensureLegalMAC(e.MACAddr))
-				}
+			if index, gotIt := compsIndex[e.CompID]; gotIt {
+				comps.Components[index].Mac = append(comps.Components[index].Mac, ensureLegalMAC(e.MACAddr))
 			}
 		}
 
@@ -321,7 +510,7 @@ func getStateFromHSM() *SMData {
 		compList := make([]string, 0, len(cMap)+len(comps.Components))
 		for i, c := range comps.Components {
 			compList = append(compList, c.ID)
-			debugf("Comp[%d]: %v\n", i, c)
+			debugmf(debugHSM, "Comp[%d]: %v\n", i, c)
 		}
 		// Add Redfish Endpoints to the component list for subscription to the notifier
 		for k := range cMap {
@@ -336,7 +525,7 @@ func getStateFromHSM() *SMData {
 func getStateFromFile() (ret *SMData) {
 	if smJSONFile != "" {
 		log.Printf("Retrieving state info from %s", smJSONFile)
-		debugf("Reading HSM info from %s", smJSONFile)
+		debugmf(debugHSM, "Reading HSM info from %s", smJSONFile)
 		f, err := os.Open(smJSONFile)
 		if err != nil {
 			log.Printf("Error: %v\n", err)
@@ -360,24 +549,86 @@ func getStateInfo() (ret *SMData) {
 	if ret == nil {
 		ret = getStateFromFile()
 	}
+	if ret != nil {
+		// So that /hosts and /dumpstate list the components in the same
+		// order whatever order HSM returned them in.
+		sort.SliceStable(ret.Components, func(i, j int) bool {
+			return ret.Components[i].ID < ret.Components[j].ID
+		})
+		// Lookups use the canonical form of the client address, so the
+		// keys have to be in that form too, whatever form HSM used.
+		addrs := make(map[string]sm.CompEthInterfaceV2, len(ret.IPAddrs))
+		for ip, e := range ret.IPAddrs {
+			addrs[canonicalIP(ip)] = e
+		}
+		ret.IPAddrs = addrs
+	}
 	return ret
 }
 
+// The HSM state is copy-on-write: a refresh builds a new SMData and map and
+// swaps them in under the write lock, and nothing changes them after that, so
+// readers only hold the read lock long enough to copy the pointers.
+//
+// Refreshes are single-flight: while one runs, callers that need newer state
+// wait for its result instead of fetching it again, and callers that are
+// content with the current state do not wait at all.  smRefreshDone is closed
+// when the running refresh ends.
+var smRefreshDone chan struct{}
+
 func protectedGetState(ts int64) (*SMData, map[string]SMComponent) {
+	smMutex.RLock()
+	data, dataMap, done := smData, smDataMap, smRefreshDone
+	current := ts >= 0 && ts <= smTimeStamp && data != nil
+	smMutex.RUnlock()
+	if current {
+		return data, dataMap
+	}
+	if done == nil {
+		smMutex.Lock()
+		if done = smRefreshDone; done == nil {
+			done = make(chan struct{})
+			smRefreshDone = done
+			smMutex.Unlock()
+			return refreshHSMState(ts, done)
+		}
+		smMutex.Unlock()
+	}
+	<-done
+	smMutex.RLock()
+	defer smMutex.RUnlock()
+	return smData, smDataMap
+}
+
+// Function refreshHSMState() runs the refresh that owns done, which it closes
+// once the new state is in place.
+func refreshHSMState(ts int64, done chan struct{}) (*SMData, map[string]SMComponent) {
+	stamp := ts
+	if ts <= 0 {
+		stamp = time.Now().Unix()
+	}
+	newSMData := getStateInfo()
+	var ix smIndexes
+	if newSMData != nil {
+		ix = makeSmIndexes(newSMData)
+	}
 	smMutex.Lock()
 	defer smMutex.Unlock()
-	if ts < 0 || ts > smTimeStamp || smData == nil {
-		if ts <= 0 {
-			smTimeStamp = time.Now().Unix()
-		} else {
-			smTimeStamp = ts
-		}
-		newSMData := getStateInfo()
-		if newSMData != nil {
-			smData = newSMData
-			smDataMap = makeSmMap(smData)
+	smTimeStamp = stamp
+	if newSMData != nil {
+		smData = newSMData
+		ix.install()
+		hsmStateLoaded(smData)
+		if len(ix.nidDups) > 0 {
+			log.Printf("WARNING: HSM has %d NIDs claimed by more than one component, see %s",
+				len(ix.nidDups), baseEndpoint+"/service/duplicate-nids")
 		}
+	} else if hsmFailingSince == 0 && (smClient != nil || smJSONFile != "") {
+		// The mem: data has no source to refresh from, so that is no failure.
+		hsmFailingSince = time.Now().Unix()
 	}
+	smRefreshDone = nil
+	close(done)
 	return smData, smDataMap
 }
 
@@ -396,17 +647,16 @@ func refreshState(ts int64) *SMData {
 }
 
 func FindSMCompByMAC(mac string) (SMComponent, bool) {
-	state := getState()
-	for _, v := range state.Components {
-		if !strings.EqualFold(v.State, "empty") {
-			for _, m := range v.Mac {
-				if strings.EqualFold(mac, m) {
-					return v, true
-				}
-			}
-		}
+	if comp, ok, test := testNodeCompByMAC(mac); test {
+		return comp, ok
 	}
-	return SMComponent{}, false
+	getState()
+	smMutex.RLock()
+	macMap := smMacMap
+	smMutex.RUnlock()
+	v, ok := macMap[strings.ToLower(mac)]
+	smLookups.mac.count(ok)
+	return v, ok
 }
 
 func FindSMCompByNameInCache(host string) (SMComponent, bool) {
@@ -418,10 +668,10 @@ func FindSMCompByNameInCache(host string) (SMComponent, bool) {
 }
 
 func FindSMCompByName(host string) (SMComponent, bool) {
-	debugf("Searching SM data for %s\n", host)
+	debugmf(debugHSM, "Searching SM data for %s\n", host)
 	state := getState()
 	for i, v := range state.Components {
-		debugf("SM data[%d]: %v\n", i, v)
+		debugmf(debugHSM, "SM data[%d]: %v\n", i, v)
 		if v.ID == host {
 			return v, true
 		}
@@ -430,13 +680,31 @@ func FindSMCompByName(host string) (SMComponent, bool) {
 }
 
 func FindSMCompByNid(nid int) (SMComponent, bool) {
-	state := getState()
-	for _, v := range state.Components {
-		if vnid, err := v.NID.Int64(); err == nil && vnid == int64(nid) {
-			return v, true
-		}
+	getState()
+	smMutex.RLock()
+	nidMap := smNidMap
+	smMutex.RUnlock()
+	v, ok := nidMap[int64(nid)]
+	smLookups.nid.count(ok)
+	return v, ok
+}
+
+// Function duplicateNids() returns the NIDs more than one component claims
+// in the current HSM state.
+func duplicateNids() []bssTypes.DuplicateNid {
+	getState()
+	smMutex.RLock()
+	defer smMutex.RUnlock()
+	return smNidDups
+}
+
+func DuplicateNidsGet(w http.ResponseWriter, r *http.Request) {
+	debugf("DuplicateNidsGet(): Received request %v\n", r.URL)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(duplicateNids()); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
 	}
-	return SMComponent{}, false
 }
 
 func FindXnameByIP(ip string) (string, bool) {
@@ -446,22 +714,55 @@ func FindXnameByIP(ip string) (string, bool) {
 	// We need to semi-frequently refresh this data in case IP addresses change
 	// due to DHCP lease expirations.
 	cacheEvictionTime := 10
+	ip = canonicalIP(ip)
 
 	currTime := time.Now()
 	ts := currTime.Add(time.Duration(-cacheEvictionTime) * time.Minute)
 	state := refreshState(ts.Unix())
 
 	ethIFace, found := state.IPAddrs[ip]
-	if !found {
+	if !found && allowForcedRefresh(ip) {
 		// If we didn't find the IP, try again with a current timestamp
 		// to force getting new state from HSM. In case the hardware came up
 		// within the last cache eviction period.
 		state = refreshState(time.Now().Unix())
 		ethIFace, found = state.IPAddrs[ip]
+		if !found {
+			markUnknownIP(ip)
+		}
 	}
+	smLookups.ip.count(found)
 	return ethIFace.CompID, found
 }
 
+func allowForcedRefresh(ip string) bool {
+	unknownIPMutex.Lock()
+	defer unknownIPMutex.Unlock()
+	now := time.Now()
+	if t, ok := unknownIPs[ip]; ok && now.Sub(t) < time.Duration(unknownIPTTL)*time.Second {
+		return false
+	}
+	if now.Sub(lastForcedRefresh) < time.Duration(forcedRefreshInterval)*time.Second {
+		return false
+	}
+	lastForcedRefresh = now
+	return true
+}
+
+func markUnknownIP(ip string) {
+	unknownIPMutex.Lock()
+	defer unknownIPMutex.Unlock()
+	now := time.Now()
+	if len(unknownIPs) >= unknownIPsPruneSize {
+		for k, t := range unknownIPs {
+			if now.Sub(t) >= time.Duration(unknownIPTTL)*time.Second {
+				delete(unknownIPs, k)
+			}
+		}
+	}
+	unknownIPs[ip] = now
+}
+
 const state_manager_data_temp = `{
     "Components": [
         { "Id" : "x0c0s0b0n0", "NID":4, "FQDN" : "x0c0s0b0n0.test.com",
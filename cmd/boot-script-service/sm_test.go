@@ -0,0 +1,293 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestUnknownIPRefreshLimits(t *testing.T) {
+	savedTTL, savedInterval := unknownIPTTL, forcedRefreshInterval
+	defer func() {
+		unknownIPTTL, forcedRefreshInterval = savedTTL, savedInterval
+		unknownIPMutex.Lock()
+		unknownIPs = make(map[string]time.Time)
+		lastForcedRefresh = time.Time{}
+		unknownIPMutex.Unlock()
+	}()
+
+	unknownIPTTL, forcedRefreshInterval = 60, 0
+	if !allowForcedRefresh("10.1.1.1") {
+		t.Errorf("First refresh for an unknown IP was not allowed")
+	}
+	markUnknownIP("10.1.1.1")
+	if allowForcedRefresh("10.1.1.1") {
+		t.Errorf("Refresh allowed for a negatively cached IP")
+	}
+	if !allowForcedRefresh("10.1.1.2") {
+		t.Errorf("Refresh not allowed for a different IP")
+	}
+
+	forcedRefreshInterval = 60
+	if allowForcedRefresh("10.1.1.3") {
+		t.Errorf("Refresh allowed within the forced refresh interval")
+	}
+
+	unknownIPTTL, forcedRefreshInterval = 0, 0
+	if !allowForcedRefresh("10.1.1.1") {
+		t.Errorf("Refresh not allowed after the negative cache TTL expired")
+	}
+}
+
+func TestMacIndex(t *testing.T) {
+	if comp, ok := FindSMCompByMAC("00:1E:67:E3:46:52"); !ok || comp.ID != "x0c0s1b0n0" {
+		t.Errorf("Upper case MAC lookup got %v, %t", comp.ID, ok)
+	}
+	state := &SMData{Components: []SMComponent{
+		{Component: base.Component{ID: "x1c0s0b0n0", State: "Empty"}, Mac: []string{"aa:bb:cc:dd:ee:01"}},
+		{Component: base.Component{ID: "x1c0s1b0n0"}, Mac: []string{"AA:BB:CC:DD:EE:01", "aa:bb:cc:dd:ee:02"}},
+		{Component: base.Component{ID: "x1c0s2b0n0"}, Mac: []string{"aa:bb:cc:dd:ee:02"}},
+	}}
+	m := makeSmMacMap(state)
+	if len(m) != 2 || m["aa:bb:cc:dd:ee:01"].ID != "x1c0s1b0n0" || m["aa:bb:cc:dd:ee:02"].ID != "x1c0s1b0n0" {
+		t.Errorf("Unexpected MAC index %v", m)
+	}
+}
+
+func TestNidIndex(t *testing.T) {
+	state := &SMData{Components: []SMComponent{
+		{Component: base.Component{ID: "x1c0s2b0n0", NID: "5"}},
+		{Component: base.Component{ID: "x1c0s1b0n0", NID: "5"}},
+		{Component: base.Component{ID: "x1c0s3b0n0", NID: "6"}},
+		{Component: base.Component{ID: "x1c0s4b0n0"}},
+	}}
+	m, dups := makeSmNidMap(state)
+	if len(m) != 2 || m[5].ID != "x1c0s1b0n0" || m[6].ID != "x1c0s3b0n0" {
+		t.Errorf("Unexpected NID index %v", m)
+	}
+	if len(dups) != 1 || dups[0].Nid != 5 || dups[0].Selected != "x1c0s1b0n0" ||
+		strings.Join(dups[0].Components, ",") != "x1c0s1b0n0,x1c0s2b0n0" {
+		t.Errorf("Unexpected duplicate NIDs %+v", dups)
+	}
+
+	smMutex.Lock()
+	saved := smData
+	makeSmIndexes(state).install()
+	smMutex.Unlock()
+	defer func() {
+		smMutex.Lock()
+		makeSmIndexes(saved).install()
+		smMutex.Unlock()
+	}()
+	w := httptest.NewRecorder()
+	serviceDuplicateNids(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/service/duplicate-nids", nil))
+	var got []bssTypes.DuplicateNid
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK || len(got) != 1 {
+		t.Errorf("GET duplicate-nids: %d %s", w.Code, w.Body.String())
+	}
+	var sb strings.Builder
+	writeMetrics(&sb)
+	if !strings.Contains(sb.String(), "\nbss_hsm_duplicate_nids 1\n") {
+		t.Errorf("Duplicate NID gauge missing:\n%s", sb.String())
+	}
+}
+
+func TestGetStateFromHSM(t *testing.T) {
+	var failIfaces, failComps atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hsm/v2/State/Components":
+			time.Sleep(100 * time.Millisecond)
+			if failComps.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"Components":[{"ID":"x1c0s0b0n0","Type":"Node","Role":"Compute"}]}`))
+		case "/hsm/v2/Inventory/ComponentEndpoints":
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte(`{"ComponentEndpoints":[{"ID":"x1c0s0b0n0","FQDN":"x1c0s0b0n0.local",` +
+				`"MACAddr":"aa:bb:cc:dd:ee:01","RedfishEndpointID":"x1c0s0b0"}]}`))
+		case "/hsm/v2/Inventory/EthernetInterfaces":
+			time.Sleep(100 * time.Millisecond)
+			if failIfaces.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`[{"ID":"aabbccddee02","MACAddress":"aa:bb:cc:dd:ee:02","ComponentID":"x1c0s0b0n0",` +
+				`"IPAddresses":[{"IPAddress":"10.1.0.5"}]}]`))
+		}
+	}))
+	defer srv.Close()
+	savedClient, savedURL, savedNotifier := smClient, smBaseURL, notifier
+	defer func() {
+		smClient, smBaseURL, notifier = savedClient, savedURL, savedNotifier
+		hsmLastSections.ep, hsmLastSections.mep, hsmLastSections.ifaces = nil, nil, nil
+	}()
+	smClient, smBaseURL = srv.Client(), srv.URL+"/hsm/v2"
+	notifier = newNotifier(serviceName, srv.URL+"/hmi/v1/subscribe", "http://bss", "")
+
+	// The interfaces are needed the first time.
+	failIfaces.Store(true)
+	if state := getStateFromHSM(); state != nil {
+		t.Errorf("State without ethernet interfaces: %+v", state)
+	}
+	failIfaces.Store(false)
+
+	start := time.Now()
+	state := getStateFromHSM()
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("HSM requests took %s, not run concurrently", elapsed)
+	}
+	if state == nil || len(state.Components) != 1 || state.Components[0].Fqdn != "x1c0s0b0n0.local" ||
+		len(state.Components[0].Mac) != 2 || state.IPAddrs["10.1.0.5"].CompID != "x1c0s0b0n0" {
+		t.Fatalf("Unexpected state %+v", state)
+	}
+
+	// Later the previous interfaces are kept, but not the components.
+	failIfaces.Store(true)
+	if state = getStateFromHSM(); state == nil || state.IPAddrs["10.1.0.5"].CompID != "x1c0s0b0n0" {
+		t.Errorf("Previous ethernet interfaces not kept: %+v", state)
+	}
+	failComps.Store(true)
+	if state = getStateFromHSM(); state != nil {
+		t.Errorf("State without components: %+v", state)
+	}
+}
+
+func TestHSMIfaceDeltas(t *testing.T) {
+	var newerThan atomic.Value
+	newerThan.Store("")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hsm/v2/State/Components":
+			w.Write([]byte(`{"Components":[{"ID":"x1c0s0b0n0","Type":"Node"},{"ID":"x1c0s1b0n0","Type":"Node"}]}`))
+		case "/hsm/v2/Inventory/ComponentEndpoints":
+			w.Write([]byte(`{"ComponentEndpoints":[]}`))
+		case "/hsm/v2/Inventory/EthernetInterfaces":
+			since := r.URL.Query().Get("newerThan")
+			newerThan.Store(since)
+			if since == "" {
+				w.Write([]byte(`[{"ID":"a1","ComponentID":"x1c0s0b0n0","LastUpdate":"2026-01-02T10:00:00Z",` +
+					`"IPAddresses":[{"IPAddress":"10.1.0.5"}]}]`))
+				return
+			}
+			w.Write([]byte(`[{"ID":"a1","ComponentID":"x1c0s0b0n0","LastUpdate":"2026-01-02T11:00:00Z",` +
+				`"IPAddresses":[{"IPAddress":"10.1.0.6"}]},` +
+				`{"ID":"b1","ComponentID":"x1c0s1b0n0","LastUpdate":"2026-01-02T11:00:00Z",` +
+				`"IPAddresses":[{"IPAddress":"10.1.0.7"}]}]`))
+		}
+	}))
+	defer srv.Close()
+	savedClient, savedURL, savedNotifier, savedInterval := smClient, smBaseURL, notifier, hsmFullSyncInterval
+	defer func() {
+		smClient, smBaseURL, notifier, hsmFullSyncInterval = savedClient, savedURL, savedNotifier, savedInterval
+		hsmLastSections.ep, hsmLastSections.mep, hsmLastSections.ifaces = nil, nil, nil
+	}()
+	smClient, smBaseURL = srv.Client(), srv.URL+"/hsm/v2"
+	notifier = newNotifier(serviceName, srv.URL+"/hmi/v1/subscribe", "http://bss", "")
+	hsmFullSyncInterval = 3600
+
+	if state := getStateFromHSM(); state == nil || newerThan.Load() != "" {
+		t.Fatalf("First refresh was not a full fetch: %+v", state)
+	}
+	state := getStateFromHSM()
+	if since := newerThan.Load(); since != "2026-01-02T10:00:00Z" {
+		t.Errorf("Delta refresh asked for changes since '%s'", since)
+	}
+	if state == nil || len(state.IPAddrs) != 2 || state.IPAddrs["10.1.0.6"].CompID != "x1c0s0b0n0" ||
+		state.IPAddrs["10.1.0.7"].CompID != "x1c0s1b0n0" || state.IPAddrs["10.1.0.5"].ID != "" {
+		t.Errorf("Interfaces not merged: %+v", state)
+	}
+
+	hsmLastSections.ifacesFull = time.Now().Add(-2 * time.Hour)
+	if state = getStateFromHSM(); newerThan.Load() != "" || len(state.IPAddrs) != 1 {
+		t.Errorf("No full fetch after the interval: %+v", state)
+	}
+}
+
+func TestRefreshSingleFlight(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hsm/v2/State/Components":
+			fetches.Add(1)
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"Components":[{"ID":"x9c0s0b0n0","Type":"Node"}]}`))
+		case "/hsm/v2/Inventory/ComponentEndpoints":
+			w.Write([]byte(`{"ComponentEndpoints":[]}`))
+		case "/hsm/v2/Inventory/EthernetInterfaces":
+			w.Write([]byte(`[{"ID":"a1","ComponentID":"x9c0s0b0n0","IPAddresses":[{"IPAddress":"10.9.0.5"}]}]`))
+		}
+	}))
+	defer srv.Close()
+	smMutex.Lock()
+	savedData, savedMap, savedStamp := smData, smDataMap, smTimeStamp
+	smMutex.Unlock()
+	savedClient, savedURL, savedNotifier := smClient, smBaseURL, notifier
+	defer func() {
+		smClient, smBaseURL, notifier = savedClient, savedURL, savedNotifier
+		hsmLastSections.ep, hsmLastSections.mep, hsmLastSections.ifaces = nil, nil, nil
+		smMutex.Lock()
+		smData, smTimeStamp = savedData, savedStamp
+		makeSmIndexes(smData).install()
+		smMutex.Unlock()
+	}()
+	smClient, smBaseURL = srv.Client(), srv.URL+"/hsm/v2"
+	notifier = newNotifier(serviceName, srv.URL+"/hmi/v1/subscribe", "http://bss", "")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if state := refreshState(-1); state == nil || state.IPAddrs["10.9.0.5"].CompID != "x9c0s0b0n0" {
+				t.Errorf("Waiting caller did not get the refreshed state: %+v", state)
+			}
+		}()
+	}
+	// Callers happy with the current state do not wait for the refresh.
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	getState()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Reading the current state waited %s for the refresh", elapsed)
+	}
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Concurrent refreshes fetched the HSM state %d times", n)
+	}
+	// Earlier snapshots are left as they were.
+	if _, ok := savedMap["x9c0s0b0n0"]; ok || savedData.IPAddrs["10.9.0.5"].ID != "" {
+		t.Errorf("Refresh changed the previous HSM state")
+	}
+}
@@ -0,0 +1,176 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Local snapshots of the boot parameters.
+//
+// With a snapshot directory configured, BSS periodically writes all boot
+// parameters to a JSON file there, in the same form as GET /bootparameters.
+// Files are written to a temporary name and renamed, so readers never see a
+// partial snapshot, and only the newest snapshotKeep are kept.  Support can
+// inspect them, and with --snapshot-restore an empty datastore is seeded
+// from the newest snapshot at startup.  When datastore encryption is enabled
+// the snapshot files are sealed with the same key.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	snapshotPrefix = "bss-snapshot-"
+	snapshotSuffix = ".json"
+)
+
+var (
+	snapshotDir      = ""
+	snapshotInterval = uint(3600) // seconds
+	snapshotKeep     = uint(24)
+	snapshotRestore  = false
+)
+
+// Function listSnapshots() returns the snapshot files, oldest first.
+func listSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), snapshotPrefix) && strings.HasSuffix(e.Name(), snapshotSuffix) {
+			names = append(names, filepath.Join(dir, e.Name()))
+		}
+	}
+	// The timestamps in the names sort chronologically.
+	sort.Strings(names)
+	return names, nil
+}
+
+// Function writeFileAtomic() writes data to a temporary file next to name
+// and renames it, so that readers see either the old or the new contents.
+func writeFileAtomic(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	return err
+}
+
+func writeSnapshot(dir string, now time.Time) (string, error) {
+	data, err := json.MarshalIndent(allBootParams(true), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if sealKey != nil {
+		sealed, err := seal(data)
+		if err != nil {
+			return "", err
+		}
+		data = []byte(sealed)
+	}
+	name := filepath.Join(dir, snapshotPrefix+now.UTC().Format("20060102T150405Z")+snapshotSuffix)
+	if err = writeFileAtomic(name, data); err != nil {
+		return "", err
+	}
+	names, err := listSnapshots(dir)
+	for err == nil && uint(len(names)) > snapshotKeep {
+		os.Remove(names[0])
+		names = names[1:]
+	}
+	return name, nil
+}
+
+func readSnapshot(name string) ([]bssTypes.BootParams, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(string(data), sealedPrefix) {
+		if data, err = unseal(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	var bps []bssTypes.BootParams
+	err = json.Unmarshal(data, &bps)
+	return bps, err
+}
+
+// Function restoreSnapshot() seeds an empty datastore from the newest
+// snapshot.  A datastore that already has host entries is left alone.
+func restoreSnapshot(dir string) (int, error) {
+	kvl, err := getTags()
+	if err != nil {
+		return 0, err
+	}
+	if len(kvl) > 0 {
+		return 0, nil
+	}
+	names, err := listSnapshots(dir)
+	if err != nil || len(names) == 0 {
+		return 0, err
+	}
+	name := names[len(names)-1]
+	bps, err := readSnapshot(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", name, err)
+	}
+	for _, bp := range bps {
+		bp.Provenance = nil
+		if err, _ = Store(bp, "snapshot"); err != nil {
+			return 0, fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	log.Printf("Restored %d boot parameter entries from %s", len(bps), name)
+	return len(bps), nil
+}
+
+func snapshotLoop() {
+	for {
+		time.Sleep(time.Duration(snapshotInterval) * time.Second)
+		if name, err := writeSnapshot(snapshotDir, time.Now()); err != nil {
+			log.Printf("Snapshot failed: %s", err)
+		} else {
+			debugf("Wrote snapshot %s", name)
+		}
+	}
+}
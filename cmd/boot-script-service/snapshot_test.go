@@ -0,0 +1,73 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestSnapshot(t *testing.T) {
+	const host = "x0c1s21b0n0"
+	defer kvstore.Delete(paramsPfx + host)
+	Store(bssTypes.BootParams{Hosts: []string{host}, Params: "snapshot"}, "test")
+
+	dir := t.TempDir()
+	defer func(keep uint) { snapshotKeep = keep }(snapshotKeep)
+	snapshotKeep = 2
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var name string
+	for i := 0; i < 3; i++ {
+		var err error
+		if name, err = writeSnapshot(dir, now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("writeSnapshot failed: %s", err)
+		}
+	}
+	names, _ := listSnapshots(dir)
+	if len(names) != 2 || names[1] != name {
+		t.Errorf("Snapshots after pruning %v, expected 2 ending in %s", names, name)
+	}
+	if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("Snapshot %s has mode %v, %v", name, fi.Mode(), err)
+	}
+
+	bps, err := readSnapshot(name)
+	if err != nil {
+		t.Fatalf("readSnapshot failed: %s", err)
+	}
+	found := false
+	for _, bp := range bps {
+		found = found || len(bp.Hosts) == 1 && bp.Hosts[0] == host && bp.Params == "snapshot"
+	}
+	if !found {
+		t.Errorf("Snapshot is missing %s", host)
+	}
+
+	// The datastore is not empty, so nothing is restored.
+	if n, err := restoreSnapshot(dir); n != 0 || err != nil {
+		t.Errorf("restoreSnapshot into a populated datastore returned %d, %v", n, err)
+	}
+}
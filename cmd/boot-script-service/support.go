@@ -0,0 +1,366 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Support bundles.
+//
+// GET /boot/v1/support-bundle returns a gzipped tarball for vendor support
+// cases: the most recent application log lines, the configuration with
+// secrets redacted, cache statistics, a datastore health check and the last
+// failed requests.  Of those only the sizes of the request and response bodies
+// are kept, as bodies carry kernel command lines and cloud-init data, and
+// credentials in headers and query parameters are redacted.  The log and the
+// failed requests are only kept in memory, so the bundle covers the time
+// since this instance started.  Fetching a bundle needs an admin token.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+var (
+	supportLogLines       = uint(1000)
+	supportFailedRequests = uint(100)
+
+	supportLog    = newRing(int(supportLogLines))
+	supportFailed = newRing(int(supportFailedRequests))
+)
+
+// A fixed size ring of the most recent items.
+type ring struct {
+	mutex sync.Mutex
+	items []interface{}
+	next  int
+	full  bool
+}
+
+func newRing(size int) *ring {
+	if size < 1 {
+		size = 1
+	}
+	return &ring{items: make([]interface{}, size)}
+}
+
+func (r *ring) add(v interface{}) {
+	r.mutex.Lock()
+	r.items[r.next] = v
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mutex.Unlock()
+}
+
+// Function list() returns the items, oldest first.
+func (r *ring) list() []interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var ret []interface{}
+	if r.full {
+		ret = append(ret, r.items[r.next:]...)
+	}
+	return append(ret, r.items[:r.next]...)
+}
+
+// The log package writes each entry with one call, so every write is a line.
+func (r *ring) Write(b []byte) (int, error) {
+	r.add(string(b))
+	return len(b), nil
+}
+
+type failedRequest struct {
+	Time     string      `json:"time"`
+	Remote   string      `json:"remote"`
+	User     string      `json:"user"`
+	Method   string      `json:"method"`
+	URI      string      `json:"uri"`
+	Header   http.Header `json:"header"`
+	BodySize int         `json:"body-bytes"`
+	Status   int         `json:"status"`
+	RespSize int         `json:"response-bytes"`
+}
+
+// Keeps the first max bytes written or read through it, and counts all.
+type captureBuffer struct {
+	bytes.Buffer
+	max   int
+	total int
+}
+
+func (c *captureBuffer) keep(b []byte) {
+	c.total += len(b)
+	if room := c.max - c.Len(); room > 0 {
+		if len(b) > room {
+			b = b[:room]
+		}
+		c.Buffer.Write(b)
+	}
+}
+
+type captureBody struct {
+	io.ReadCloser
+	buf *captureBuffer
+}
+
+func (c captureBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.buf.keep(p[:n])
+	return n, err
+}
+
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	buf    captureBuffer
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.buf.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func redactHeader(h http.Header) http.Header {
+	ret := h.Clone()
+	for _, k := range []string{"Authorization", "Cookie", "X-Vault-Token"} {
+		if ret.Get(k) != "" {
+			ret.Set(k, "REDACTED")
+		}
+	}
+	return ret
+}
+
+func redactURI(uri string) string {
+	u, err := url.ParseRequestURI(uri)
+	if err != nil || u.RawQuery == "" {
+		return uri
+	}
+	q := u.Query()
+	for k := range q {
+		if sensitiveSetting(k) {
+			q.Set(k, "REDACTED")
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// Function supportRecorder() wraps inner to remember the requests that fail.
+func supportRecorder(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := &captureBuffer{}
+		if r.Body != nil {
+			r.Body = captureBody{r.Body, body}
+		}
+		cw := &captureWriter{ResponseWriter: w}
+		start := time.Now()
+		inner.ServeHTTP(cw, r)
+		if cw.status < http.StatusBadRequest {
+			return
+		}
+		supportFailed.add(failedRequest{
+			Time:     start.UTC().Format(time.RFC3339Nano),
+			Remote:   r.RemoteAddr,
+			User:     requestSubject(r),
+			Method:   r.Method,
+			URI:      redactURI(r.RequestURI),
+			Header:   redactHeader(r.Header),
+			BodySize: body.total,
+			Status:   cw.status,
+			RespSize: cw.buf.total,
+		})
+	})
+}
+
+func supportInit() {
+	supportLog = newRing(int(supportLogLines))
+	supportFailed = newRing(int(supportFailedRequests))
+	log.SetOutput(io.MultiWriter(os.Stderr, supportLog))
+}
+
+func sensitiveSetting(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"key", "token", "secret", "passw", "credential"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactSetting(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if sensitiveSetting(name) {
+		return "REDACTED"
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
+
+// Function supportConfig() returns the command line settings and the BSS_
+// environment, redacted.
+func supportConfig() map[string]map[string]string {
+	flags := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		flags[f.Name] = redactSetting(f.Name, f.Value.String())
+	})
+	env := make(map[string]string)
+	for _, e := range os.Environ() {
+		if k, v, ok := strings.Cut(e, "="); ok && strings.HasPrefix(k, "BSS_") {
+			env[k] = redactSetting(k, v)
+		}
+	}
+	return map[string]map[string]string{"flags": flags, "environment": env}
+}
+
+func supportDatastore() map[string]interface{} {
+	ret := map[string]interface{}{"status": "ok"}
+	start := time.Now()
+	n := int(start.UnixNano() & 0xff)
+	err := etcdTestStore(n)
+	if err == nil {
+		var got int
+		if got, err = etcdTestGet(); err == nil && got != n {
+			err = fmt.Errorf("read back %d, expected %d", got, n)
+		}
+	}
+	ret["round-trip-ms"] = time.Since(start).Milliseconds()
+	if err != nil {
+		ret["status"] = "error"
+		ret["error"] = err.Error()
+	}
+	if replicaStore != nil {
+		ret["replica"] = datastoreReplica
+	}
+	store := kvstore
+	if dual, ok := store.(*dualKvi); ok {
+		ret["migrate-to"] = datastoreMigrateTo
+		ret["migrate-mismatches"] = migrateMismatches.Load()
+		ret["migrate-write-failures"] = migrateWriteFailures.Load()
+		store = dual.Kvi
+	}
+	if kv, ok := store.(*timedKvi); ok {
+		ret["in-flight"] = len(kv.slots)
+		ret["max-in-flight"] = cap(kv.slots)
+	}
+	return ret
+}
+
+func writeSupportBundle(out io.Writer) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	addJSON := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		return add(name, append(data, '\n'))
+	}
+
+	var logs strings.Builder
+	for _, l := range supportLog.list() {
+		logs.WriteString(l.(string))
+	}
+	version, err := os.ReadFile(".version")
+	if err != nil {
+		version, _ = os.ReadFile("../../.version")
+	}
+	for _, err := range []error{
+		add("bss-support/version", version),
+		add("bss-support/log.txt", []byte(logs.String())),
+		addJSON("bss-support/config.json", supportConfig()),
+		addJSON("bss-support/caches.json", cacheStats()),
+		addJSON("bss-support/datastore.json", supportDatastore()),
+		addJSON("bss-support/failed-requests.json", supportFailed.list()),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func SupportBundleGet(w http.ResponseWriter, r *http.Request) {
+	debugf("SupportBundleGet(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeSupportBundle(&buf); err != nil {
+		log.Printf("Support bundle failed: %s", err)
+		base.SendProblemDetailsGeneric(w, http.StatusInternalServerError,
+			fmt.Sprintf("Support bundle failed: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"bss-support-%s.tar.gz\"",
+		time.Now().UTC().Format("20060102T150405Z")))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
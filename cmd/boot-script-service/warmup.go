@@ -0,0 +1,245 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Boot warm-up.
+//
+// Presigning S3 URLs is the slow part of a boot script, and a mass reboot
+// has every node ask for one at once.  POST /boot/v1/warmup, given a group
+// (see groups.go) and a window in seconds, renders the boot script of every
+// node in the group ahead of time.  Until the window ends the presigned URLs
+// are reused instead of being signed again, for all nodes, and a node whose
+// first request matches its pre-rendered script gets that script.  A
+// pre-rendered script is dropped on any change to the boot configuration,
+// and scripts with a join token are never kept since the tokens are issued
+// per boot.  DELETE /boot/v1/warmup ends the window early.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+const (
+	warmupDefaultWindow = 1800 // seconds
+	// Half the life of presigned URLs, see checkURL().
+	warmupMaxWindow = presignedURLLifetime / 2
+)
+
+type warmScript struct {
+	key     string
+	script  string
+	changes <-chan struct{} // closed on the next boot configuration change
+}
+
+var warmup = struct {
+	sync.Mutex
+	group   string
+	until   time.Time
+	urls    map[string]string
+	scripts map[string]warmScript
+}{}
+
+func warmupActive() bool {
+	return time.Now().Before(warmup.until)
+}
+
+// Function warmSignedURL() returns the URL signed for u during the warm-up
+// window, if there is one.
+func warmSignedURL(u string) (string, bool) {
+	warmup.Lock()
+	defer warmup.Unlock()
+	if !warmupActive() {
+		return "", false
+	}
+	s, ok := warmup.urls[u]
+	return s, ok
+}
+
+func keepSignedURL(u, signed string) {
+	warmup.Lock()
+	defer warmup.Unlock()
+	if warmupActive() {
+		warmup.urls[u] = signed
+	}
+}
+
+// Function warmScriptKey() identifies the inputs of a boot script.  The HSM
+// role is part of it, the rest of the boot configuration is covered by the
+// change signal.
+func warmScriptKey(bd BootData, sp scriptParams, chain string, comp SMComponent) string {
+	data, _ := json.Marshal(bd)
+	return fmt.Sprintf("%v|%s|%s|%s|%s|%s", sp, chain, comp.Role, comp.SubRole, comp.NID, data)
+}
+
+// Function bootScriptFor() builds the boot script of comp, or returns the
+// one rendered for the same inputs during warm-up.
+func bootScriptFor(bd BootData, sp scriptParams, chain string, comp SMComponent, descr string) (string, error) {
+	key := warmScriptKey(bd, sp, chain, comp)
+	warmup.Lock()
+	ws, ok := warmup.scripts[comp.ID]
+	warmup.Unlock()
+	if ok && ws.key == key && warmupActive() {
+		select {
+		case <-ws.changes:
+		default:
+			debugf("Using the warm boot script of %s", comp.ID)
+			return ws.script, nil
+		}
+	}
+	return buildBootScript(bd, sp, chain, comp.Role, comp.SubRole, descr)
+}
+
+// Function warmNode() renders the boot script that the first request of
+// comp would get, and keeps it for the rest of the window.
+func warmNode(comp SMComponent) error {
+	bd, comp := LookupByName(comp.ID)
+	if comp.ID == "" || !comp.EndpointEnabled || bd.Kernel.Path == "" {
+		return fmt.Errorf("not configured for booting")
+	}
+	if err := blacklist(comp); err != nil {
+		return err
+	}
+	mac := ""
+	if len(comp.Mac) > 0 {
+		mac = comp.Mac[0]
+	}
+	if issuesJoinToken(bd, comp.Role) {
+		// Only sign the URLs, rendering the script would issue a token.
+		for _, u := range []string{bd.Kernel.Path, bd.Initrd.Path} {
+			if u != "" {
+				if _, err := checkURL(u); err != nil {
+					return err
+				}
+			}
+		}
+		_, err := replaceS3Params(bd.Params, checkURL)
+		return err
+	}
+	sp := scriptParams{comp.ID, comp.NID.String(), bd.ReferralToken, ""}
+	chain := bootScriptChain(baseEndpoint+"/bootscript", mac, comp.ID, 0)
+	changes := changeWaiter()
+	script, err := buildBootScript(bd, sp, chain, comp.Role, comp.SubRole, comp.ID)
+	if err != nil {
+		return err
+	}
+	warmup.Lock()
+	defer warmup.Unlock()
+	if warmup.scripts != nil {
+		warmup.scripts[comp.ID] = warmScript{warmScriptKey(bd, sp, chain, comp), script, changes}
+	}
+	return nil
+}
+
+func WarmupPost(w http.ResponseWriter, r *http.Request) {
+	debugf("WarmupPost(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	var req bssTypes.WarmupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s", err))
+		return
+	}
+	if req.Window == 0 {
+		req.Window = warmupDefaultWindow
+	}
+	if req.Group == "" || req.Window < 0 || req.Window > warmupMaxWindow {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request - Need a group and a window of at most %d seconds", warmupMaxWindow))
+		return
+	}
+	members := groupMembers(req.Group)
+	if len(members) == 0 {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - No nodes in group %s", req.Group))
+		return
+	}
+	until := time.Now().Add(time.Duration(req.Window) * time.Second)
+	warmup.Lock()
+	warmup.group, warmup.until = req.Group, until
+	warmup.urls, warmup.scripts = make(map[string]string), make(map[string]warmScript)
+	warmup.Unlock()
+
+	report := bssTypes.WarmupReport{Group: req.Group, Nodes: len(members), Until: until.Unix()}
+	for _, comp := range members {
+		if err := warmNode(comp); err != nil {
+			if report.Skipped == nil {
+				report.Skipped = make(map[string]string)
+			}
+			report.Skipped[comp.ID] = err.Error()
+		}
+	}
+	report.Scripts, report.SignedURLs = warmupCounts()
+	log.Printf("BSS warm-up of %s by %s: %d scripts, %d signed URLs until %s", req.Group,
+		requestSubject(r), report.Scripts, report.SignedURLs, until.Format(time.RFC3339))
+	sendWarmup(w, report)
+}
+
+func warmupCounts() (scripts, urls int) {
+	warmup.Lock()
+	defer warmup.Unlock()
+	return len(warmup.scripts), len(warmup.urls)
+}
+
+func WarmupGet(w http.ResponseWriter, r *http.Request) {
+	debugf("WarmupGet(): Received request %v\n", r.URL)
+	var report bssTypes.WarmupReport
+	warmup.Lock()
+	if warmupActive() {
+		report.Group, report.Until = warmup.group, warmup.until.Unix()
+	}
+	warmup.Unlock()
+	if report.Group != "" {
+		report.Scripts, report.SignedURLs = warmupCounts()
+	}
+	sendWarmup(w, report)
+}
+
+func WarmupDelete(w http.ResponseWriter, r *http.Request) {
+	debugf("WarmupDelete(): Received request %v\n", r.URL)
+	if !requestAdmin(w, r) {
+		return
+	}
+	warmup.Lock()
+	warmup.group, warmup.until = "", time.Time{}
+	warmup.urls, warmup.scripts = nil, nil
+	warmup.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendWarmup(w http.ResponseWriter, report bssTypes.WarmupReport) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+	}
+}
@@ -0,0 +1,118 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestWarmup(t *testing.T) {
+	const node = "x0c0s4b0n0"
+	savedMode := s3SignerMode
+	s3SignerMode = s3SignerMock
+	defer func() {
+		s3SignerMode = savedMode
+		kvstore.Delete(paramsPfx + node)
+		warmupAPI(httptest.NewRecorder(), adminRequest(http.MethodDelete, ""))
+	}()
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "warm",
+		Kernel: "s3://boot-images/warm/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	warmupAPI(w, adminRequest(http.MethodPost, `{"group":"`+node+`","window":600}`))
+	var rep bssTypes.WarmupReport
+	json.Unmarshal(w.Body.Bytes(), &rep)
+	if w.Code != http.StatusOK || rep.Nodes != 1 || rep.Scripts != 1 || len(rep.Skipped) != 0 {
+		t.Fatalf("POST warmup returned %d: %s", w.Code, w.Body.String())
+	}
+	warmup.Lock()
+	ws := warmup.scripts[node]
+	ws.script = "#!ipxe\n# warm\n"
+	warmup.scripts[node] = ws
+	warmup.Unlock()
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node, nil))
+		return w.Body.String()
+	}
+	if script := get(); !strings.Contains(script, "# warm") {
+		t.Errorf("Warm script not used:\n%s", script)
+	}
+	// A retry does not match the first request.
+	w = httptest.NewRecorder()
+	bootScript(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootscript?name="+node+"&retry=1", nil))
+	if strings.Contains(w.Body.String(), "# warm") {
+		t.Errorf("Warm script used for a retry")
+	}
+	if err, _ := Store(bssTypes.BootParams{Hosts: []string{node}, Params: "changed",
+		Kernel: "s3://boot-images/warm/kernel"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if script := get(); !strings.Contains(script, "changed") {
+		t.Errorf("Warm script used after a change:\n%s", script)
+	}
+
+	keepSignedURL("s3://boot-images/warm/initrd", "https://signed/initrd")
+	if u, ok := warmSignedURL("s3://boot-images/warm/initrd"); !ok || u != "https://signed/initrd" {
+		t.Errorf("Signed URL not kept: %s", u)
+	}
+	w = httptest.NewRecorder()
+	warmupAPI(w, httptest.NewRequest(http.MethodGet, baseEndpoint+"/warmup", nil))
+	json.Unmarshal(w.Body.Bytes(), &rep)
+	if rep.Group != node || rep.SignedURLs != 1 || rep.Until < time.Now().Unix() {
+		t.Errorf("GET warmup: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	warmupAPI(w, adminRequest(http.MethodDelete, ""))
+	if _, ok := warmSignedURL("s3://boot-images/warm/initrd"); w.Code != http.StatusNoContent || ok {
+		t.Errorf("DELETE warmup returned %d", w.Code)
+	}
+
+	for _, body := range []string{`{"window":600}`, `{"group":"Compute","window":86400}`} {
+		w = httptest.NewRecorder()
+		warmupAPI(w, adminRequest(http.MethodPost, body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("POST warmup %s returned %d", body, w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	warmupAPI(w, httptest.NewRequest(http.MethodPost, baseEndpoint+"/warmup", strings.NewReader(`{"group":"all"}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("POST warmup without a token returned %d", w.Code)
+	}
+}
+
+func adminRequest(method, body string) *http.Request {
+	r := httptest.NewRequest(method, baseEndpoint+"/warmup", strings.NewReader(body))
+	r.Header.Set("Authorization", testToken(`{"sub":"jdoe","realm_access":{"roles":["admin"]}}`))
+	return r
+}
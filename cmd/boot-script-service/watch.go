@@ -0,0 +1,192 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+//
+// Waiting for a change of a node's boot configuration.
+//
+// GET /boot/v1/bootparameters/{xname}/watch is a long poll: with the ETag of
+// the configuration the agent last saw in If-None-Match (or etag=), it is
+// held until the node's effective configuration changes, then returns the
+// new one, or after timeout= seconds returns 304.  Without an ETag the
+// current configuration is returned right away.  The effective
+// configuration is what the node boots with: its own entry or the role or
+// default it falls back to, with the role parameters applied.  Its ETag is
+// not the one GET /bootparameters returns, which only covers the stored
+// entry (see bootparams_etag.go).
+//
+// Waiters wake on every change recorded in the change feed, by any BSS
+// instance through an etcd watch on the feed revision, and every
+// watchPollInterval seconds for changes the feed does not carry, such as
+// role parameters or HSM roles.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	hmetcd "github.com/Cray-HPE/hms-hmetcd"
+)
+
+var (
+	watchPollInterval = uint(5)   // seconds
+	watchMaxTimeout   = uint(300) // seconds
+)
+
+const watchDefaultTimeout = 60
+
+var changeSignal = struct {
+	sync.Mutex
+	ch chan struct{}
+}{ch: make(chan struct{})}
+
+// Function changeWaiter() returns a channel closed on the next change.
+func changeWaiter() <-chan struct{} {
+	changeSignal.Lock()
+	defer changeSignal.Unlock()
+	return changeSignal.ch
+}
+
+func signalChange() {
+	changeSignal.Lock()
+	defer changeSignal.Unlock()
+	close(changeSignal.ch)
+	changeSignal.ch = make(chan struct{})
+}
+
+// Function watchInit() wakes the waiters on changes made by other instances.
+func watchInit() {
+	_, err := kvstore.WatchWithCB(changesRevisionKey, hmetcd.KVC_KEYCHANGE_PUT,
+		func(key, val string, op int, userdata interface{}) bool {
+			replicaChanged()
+			signalChange()
+			return true
+		}, nil)
+	if err != nil {
+		log.Printf("WARNING: Cannot watch %s, changes by other instances are polled: %s", changesRevisionKey, err)
+		if replicaStore != nil {
+			log.Printf("WARNING: Datastore replica %s not used without the watch", datastoreReplica)
+			replicaStore = nil
+		}
+	}
+}
+
+// Function effectiveBootParams() returns the boot configuration the node
+// boots with, and its ETag.
+func effectiveBootParams(name string) (bssTypes.BootParams, string) {
+	bd, comp := LookupByName(name)
+	bp := effectiveBootData(name, bd, comp.Role)
+	data, _ := json.Marshal(bp)
+	sum := sha256.Sum256(data)
+	return bp, `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// Function effectiveBootData() turns what a lookup found for a node of the
+// role into the boot configuration it boots with.
+func effectiveBootData(name string, bd BootData, role string) bssTypes.BootParams {
+	params := bd.Params
+	if bd.Kernel.Params != "" {
+		params += " " + bd.Kernel.Params
+	}
+	if bd.Initrd.Params != "" {
+		params += " " + bd.Initrd.Params
+	}
+	return bssTypes.BootParams{
+		Hosts:     []string{name},
+		Params:    applyKdumpParams(strings.TrimSpace(applyRoleParams(params, role)), name, role),
+		Kernel:    bd.Kernel.Path,
+		Initrd:    bd.Initrd.Path,
+		CloudInit: bd.CloudInit,
+	}
+}
+
+func BootparametersWatch(w http.ResponseWriter, r *http.Request) {
+	debugf("BootparametersWatch(): Received request %v\n", r.URL)
+	name, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, baseEndpoint+"/bootparameters/"), "/")
+	if name == "" || sub != "watch" {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			fmt.Sprintf("Not Found - %s", r.URL.Path))
+		return
+	}
+	r.ParseForm() // r.Form is empty until after parsing
+	etag := r.Header.Get("If-None-Match")
+	if e := r.Form.Get("etag"); e != "" {
+		etag = e
+	}
+	if etag != "" && !strings.HasPrefix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	timeout := uint(watchDefaultTimeout)
+	if t := r.Form.Get("timeout"); t != "" {
+		v, err := strconv.ParseUint(t, 10, 32)
+		if err != nil {
+			base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+				fmt.Sprintf("Bad Request - Invalid timeout '%s'", t))
+			return
+		}
+		timeout = uint(v)
+	}
+	if timeout > watchMaxTimeout {
+		timeout = watchMaxTimeout
+	}
+	poll := time.Duration(watchPollInterval) * time.Second
+	if poll <= 0 {
+		poll = time.Second
+	}
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+	for {
+		changed := changeWaiter()
+		bp, cur := effectiveBootParams(name)
+		if cur != etag {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.Header().Set("ETag", cur)
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(bp); err != nil {
+				log.Printf("Yikes, I couldn't encode a JSON status response: %s\n", err)
+			}
+			return
+		}
+		select {
+		case <-changed:
+		case <-time.After(poll):
+		case <-deadline.C:
+			w.Header().Set("ETag", cur)
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
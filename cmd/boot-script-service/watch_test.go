@@ -0,0 +1,105 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+func TestBootparametersWatch(t *testing.T) {
+	const host = "x3000c0s21b0n0"
+	kernel := "s3://boot-images/watch/kernel"
+	defer func() {
+		kvstore.Delete(paramsPfx + host)
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+	Store(bssTypes.BootParams{Hosts: []string{host}, Params: "console=ttyS0", Kernel: kernel}, "test")
+
+	watch := func(query, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootparameters/"+host+"/watch"+query, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rr := httptest.NewRecorder()
+		bootParametersWatch(rr, req)
+		return rr
+	}
+	rr := watch("", "")
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Watch without an ETag returned %d", rr.Code)
+	}
+	if rr = watch("?timeout=0", etag); rr.Code != http.StatusNotModified {
+		t.Errorf("Watch of an unchanged configuration returned %d", rr.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- watch("?timeout=10", etag) }()
+	time.Sleep(50 * time.Millisecond)
+	Store(bssTypes.BootParams{Hosts: []string{host}, Params: "console=ttyS1", Kernel: kernel}, "test")
+	select {
+	case rr = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Watch did not return after a change")
+	}
+	var bp bssTypes.BootParams
+	json.Unmarshal(rr.Body.Bytes(), &bp)
+	if rr.Code != http.StatusOK || bp.Params != "console=ttyS1" || rr.Header().Get("ETag") == etag {
+		t.Errorf("Watch returned %d %+v", rr.Code, bp)
+	}
+
+	if rr = watch("/../other", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Unknown sub-resource returned %d", rr.Code)
+	}
+}
+
+func TestHostETagRoleParams(t *testing.T) {
+	const host = "x0c0s2b0n0" // Compute
+	kernel := "s3://boot-images/watch/kernel"
+	defer func() {
+		kvstore.Delete(paramsPfx + host)
+		kvstore.Delete(roleParamsPfx + "Compute")
+		kvstore.Delete(imageFind(kernel, kernelImageType))
+	}()
+	Store(bssTypes.BootParams{Hosts: []string{host}, Params: "console=ttyS0", Kernel: kernel}, "test")
+	getETag := func() string {
+		rr := httptest.NewRecorder()
+		BootparametersGet(rr, httptest.NewRequest(http.MethodGet, baseEndpoint+"/bootparameters?name="+host, nil))
+		return rr.Header().Get("ETag")
+	}
+	before := getETag()
+	_, watchETag := effectiveBootParams(host)
+	storeData(roleParamsPfx+"Compute", bssTypes.RoleParams{Role: "Compute", Suffix: "panic=10"})
+	if after := getETag(); after == "" || after != before {
+		t.Errorf("Role parameters changed the ETag of %s from %s to %s", host, before, after)
+	}
+	if _, after := effectiveBootParams(host); after == watchETag {
+		t.Errorf("Role parameters did not change the watch ETag of %s", host)
+	}
+}
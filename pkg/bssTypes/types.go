@@ -51,15 +51,263 @@ type CloudInit struct {
 // provide a "default" selection which provides a way to supply default
 // parameters for any node which is not explicitly configured.
 type BootParams struct {
-	Hosts     []string  `json:"hosts,omitempty"`
-	Macs      []string  `json:"macs,omitempty"`
-	Nids      []int32   `json:"nids,omitempty"`
+	Hosts     []string   `json:"hosts,omitempty"`
+	Macs      []string   `json:"macs,omitempty"`
+	Nids      []int32    `json:"nids,omitempty"`
+	Params    string     `json:"params,omitempty"`
+	Kernel    string     `json:"kernel,omitempty"`
+	Initrd    string     `json:"initrd,omitempty"`
+	CloudInit CloudInit  `json:"cloud-init,omitempty"`
+	FirstBoot *FirstBoot `json:"first-boot,omitempty"`
+
+	// Text of the messages boot scripts echo, by message name.  Those
+	// of the Global host apply to every node without its own.
+	Messages map[string]string `json:"messages,omitempty"`
+
+	// Free form labels, e.g. gpu: a100, for conditional params.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Kernel parameters added when booting for a reason, e.g. kdump, by
+	// the reason parameter of /boot/v1/bootscript.
+	Reasons map[string]string `json:"reasons,omitempty"`
+
+	// Payload type: linux (default), wimboot for WinPE or mboot for
+	// ESXi.  Files are the extra files wimboot and mboot load.
+	Payload string     `json:"payload,omitempty"`
+	Files   []BootFile `json:"files,omitempty"`
+
+	// Device tree blob and ACPI table overrides, URLs or s3:// paths, for
+	// ARM nodes whose firmware does not provide usable ones.  The ACPI
+	// overrides are cpio archives, loaded before the initrd.
+	DTB  string   `json:"dtb,omitempty"`
+	ACPI []string `json:"acpi,omitempty"`
+
+	// Initrds loaded after the initrd, in order, e.g. a site and a secrets
+	// overlay.  ${xname} and ${nid} in a path are replaced by those of the
+	// node, so one entry can give each node an overlay of its own.
+	InitrdOverlays []string `json:"initrd-overlays,omitempty"`
+
+	// Expected digests of the kernel and initrd, "sha256:<hex>" or
+	// "etag:<etag>".  Stored with the image, not returned.
+	KernelDigest string `json:"kernel-digest,omitempty"`
+	InitrdDigest string `json:"initrd-digest,omitempty"`
+
+	// Only returned in verbose responses, ignored on input.
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Provenance  *Provenance  `json:"provenance,omitempty"`
+}
+
+// An extra file of a wimboot or mboot payload.  Name is the name wimboot
+// sees, e.g. BCD, path is a URL or s3:// path.
+type BootFile struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path"`
+}
+
+// Result of checking an image against its recorded digest.
+type ImageDigestStatus struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Checked  int64  `json:"checked,omitempty"`
+	Mismatch bool   `json:"mismatch"`
+	Error    string `json:"error,omitempty"`
+}
+
+// A boot parameters entry as managed through /entries.  ID is the host name
+// or tag the entry is stored under.  Reading an entry returns exactly the
+// fields that were written.
+type Entry struct {
+	ID       string        `json:"id"`
+	Params   string        `json:"params,omitempty"`
+	Kernel   string        `json:"kernel,omitempty"`
+	Initrd   string        `json:"initrd,omitempty"`
+	MetaData CloudDataType `json:"meta-data,omitempty"`
+	UserData CloudDataType `json:"user-data,omitempty"`
+}
+
+// A boot parameters entry for a node HSM no longer has.  Archived entries
+// have been moved out of the boot parameters; Params holds what was removed.
+type StaleEntry struct {
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	FirstSeen int64       `json:"first-seen"`
+	LastSeen  int64       `json:"last-seen"`
+	Archived  int64       `json:"archived,omitempty"`
+	Params    *BootParams `json:"params,omitempty"`
+}
+
+// Kernel command line fragments for every node of an HSM role.  Prefix goes
+// before and Suffix after the node's own parameters.
+type RoleParams struct {
+	Role   string `json:"role"`
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// Kdump configuration of a node or of every node of an HSM role, see
+// /boot/v1/kdump.  CrashKernel is the crashkernel= value, e.g. 512M or
+// 1G-64G:256M,64G-:512M; Target is where dumps go, an nfs, ssh or file URL.
+type KdumpConfig struct {
+	Name        string `json:"name"`
+	CrashKernel string `json:"crashkernel"`
+	Target      string `json:"target,omitempty"`
+}
+
+// Cloud-init data of a node after merging.  With explain, Provenance maps
+// each key (dotted for nested keys) to where its value came from.
+type CloudInitResolved struct {
+	Xname      string                 `json:"xname"`
+	MetaData   map[string]interface{} `json:"meta-data"`
+	UserData   map[string]interface{} `json:"user-data"`
+	Provenance *CloudInitProvenance   `json:"provenance,omitempty"`
+}
+
+type CloudInitProvenance struct {
+	MetaData map[string]string `json:"meta-data"`
+	UserData map[string]string `json:"user-data"`
+}
+
+// Runtime debug logging settings.  Level is "info" or "debug"; Modules
+// enables debug output for individual areas while Level is "info".
+type DebugSettings struct {
+	Level   string   `json:"level,omitempty"`
+	Modules []string `json:"modules,omitempty"`
+}
+
+// A setting of the service as reported by GET /service/config.  Flag or
+// Env is empty for settings that only have the other; the values of
+// secrets are "REDACTED".
+type ConfigSetting struct {
+	Flag    string `json:"flag,omitempty"`
+	Env     string `json:"env,omitempty"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Value   string `json:"value"`
+	Usage   string `json:"usage,omitempty"`
+}
+
+// Creation and last modification of a host's boot parameters.  UpdatedBy is
+// the subject of the token used for the change.
+type Provenance struct {
+	CreatedAt int64  `json:"created-at,omitempty"`
+	UpdatedAt int64  `json:"updated-at,omitempty"`
+	UpdatedBy string `json:"updated-by,omitempty"`
+}
+
+// Boot configuration used instead of the regular one until a node has
+// successfully booted and phoned home with its current configuration, e.g. to
+// boot a provisioning image once.  Empty fields fall back to the regular
+// configuration.
+type FirstBoot struct {
 	Params    string    `json:"params,omitempty"`
 	Kernel    string    `json:"kernel,omitempty"`
 	Initrd    string    `json:"initrd,omitempty"`
 	CloudInit CloudInit `json:"cloud-init,omitempty"`
 }
 
+type FirstBootState struct {
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+	When int64  `json:"when,omitempty"`
+}
+
+// Boot parameter entries changed since a revision, see /boot/v1/changes.
+// Revision is the latest revision, to be passed as since= on the next call.
+type Changes struct {
+	Revision int64    `json:"revision"`
+	Changes  []Change `json:"changes"`
+}
+
+// A change to the boot parameters of a host or tag.  Params is the current
+// entry and is left out for deletions.
+type Change struct {
+	Revision int64       `json:"revision"`
+	Name     string      `json:"name"`
+	Op       string      `json:"op"`
+	Time     int64       `json:"time"`
+	Params   *BootParams `json:"params,omitempty"`
+}
+
+// A boot parameter change request, see /boot/v1/auditlog.  Changes is what
+// the request did to the effective boot parameters when it succeeded.
+type AuditRecord struct {
+	ID      string        `json:"id"`
+	Time    int64         `json:"time"`
+	Subject string        `json:"subject"`
+	Remote  string        `json:"remote,omitempty"`
+	Method  string        `json:"method"`
+	URI     string        `json:"uri"`
+	Names   []string      `json:"names,omitempty"`
+	Status  int           `json:"status"`
+	Changes []AuditChange `json:"changes,omitempty"`
+}
+
+type AuditChange struct {
+	Name string         `json:"name"`
+	Diff BootParamsDiff `json:"diff"`
+}
+
+// Result of a bulk import of node boot assignments.  Nothing is applied when
+// there are errors.
+type ImportReport struct {
+	Rows    int           `json:"rows"`
+	Applied int           `json:"applied"`
+	DryRun  bool          `json:"dry-run,omitempty"`
+	Errors  []ImportError `json:"errors,omitempty"`
+}
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// A boot group is the set of hosts and tags that share the same kernel,
+// initrd and kernel parameters.  Groups are derived from the boot parameters,
+// the ID is a hash of the shared configuration and the name and description
+// are optional labels.  Groups with an HSM group are kept in sync with the
+// membership of that HSM group.  MaxBooting, if set, is the number of members
+// that get a boot script at once, the others are told to retry.
+type BootGroup struct {
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	HSMGroup    string   `json:"hsm-group,omitempty"`
+	Release     string   `json:"release,omitempty"`
+	Channel     string   `json:"channel,omitempty"`
+	MaxBooting  int      `json:"max-booting,omitempty"`
+	Params      string   `json:"params,omitempty"`
+	Kernel      string   `json:"kernel,omitempty"`
+	Initrd      string   `json:"initrd,omitempty"`
+	Members     []string `json:"members,omitempty"`
+}
+
+// One version of an image release.
+type ReleaseVersion struct {
+	Version string `json:"version"`
+	Kernel  string `json:"kernel"`
+	Initrd  string `json:"initrd,omitempty"`
+	Rootfs  string `json:"rootfs,omitempty"`
+}
+
+// An image release with its current version in each channel, such as stable
+// and testing, and the boot groups following each channel.
+type Release struct {
+	Name     string                    `json:"name"`
+	Channels map[string]ReleaseVersion `json:"channels"`
+	Profiles map[string][]string       `json:"profiles,omitempty"`
+}
+
+// Free-text maintenance notes attached to a host, so operators can leave an
+// explanation for an unusual boot configuration.
+type Annotation struct {
+	Name string `json:"name"`
+	Who  string `json:"who,omitempty"`
+	When int64  `json:"when,omitempty"`
+	Note string `json:"note"`
+}
+
 // The following structures and types all related to the last access information for bootscripts and cloud-init data.
 
 type EndpointType string
@@ -67,11 +315,13 @@ type EndpointType string
 const (
 	EndpointTypeBootscript EndpointType = "bootscript"
 	EndpointTypeUserData   EndpointType = "user-data"
+	EndpointTypePhoneHome  EndpointType = "phone-home"
 )
 
 var EndpointTypes = []EndpointType{
 	EndpointTypeBootscript,
 	EndpointTypeUserData,
+	EndpointTypePhoneHome,
 }
 
 type EndpointAccess struct {
@@ -79,3 +329,310 @@ type EndpointAccess struct {
 	Endpoint  EndpointType `json:"endpoint"`
 	LastEpoch int64        `json:"last_epoch"`
 }
+
+// A stored entry whose kernel parameters may break iPXE or the init system,
+// see /boot/v1/risky-params.
+type RiskyParams struct {
+	Name   string   `json:"name"`
+	Params string   `json:"params"`
+	Risks  []string `json:"risks"`
+}
+
+// A kernel command line a node was served, see /boot/v1/history/cmdline.
+// Consecutive boots with the same command line share a record: First and
+// Last are the Unix times of the first and latest of them.
+type CmdlineRecord struct {
+	First   int64  `json:"first"`
+	Last    int64  `json:"last"`
+	Count   int    `json:"count"`
+	Kernel  string `json:"kernel"`
+	Cmdline string `json:"cmdline"`
+}
+
+type CmdlineHistory struct {
+	Name    string          `json:"name"`
+	Records []CmdlineRecord `json:"records"`
+}
+
+// The number of accesses of a node to an endpoint on a UTC day, YYYY-MM-DD,
+// see /boot/v1/analytics/access.
+type AccessCount struct {
+	Day      string       `json:"day"`
+	Name     string       `json:"name"`
+	Endpoint EndpointType `json:"endpoint"`
+	Count    int64        `json:"count"`
+}
+
+// A temporary boot configuration for a node, used instead of its regular
+// boot parameters until Expires.  TTL is the lifetime in seconds requested
+// on input.  Profile names a rescue target or boot group to take the
+// kernel, initrd and params from.
+type BootOverride struct {
+	Name      string `json:"name,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+	Params    string `json:"params,omitempty"`
+	Kernel    string `json:"kernel,omitempty"`
+	Initrd    string `json:"initrd,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	TTL       uint   `json:"ttl,omitempty"`
+	Expires   int64  `json:"expires,omitempty"`
+	CreatedBy string `json:"created-by,omitempty"`
+}
+
+// A utility boot target of the rescue catalog, e.g. memtest.  Builtin
+// targets can be reconfigured, deleting them restores the default.
+type RescueTarget struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Kernel      string `json:"kernel"`
+	Initrd      string `json:"initrd,omitempty"`
+	Params      string `json:"params,omitempty"`
+	Builtin     bool   `json:"builtin,omitempty"`
+	Modified    bool   `json:"modified,omitempty"`
+}
+
+// Request for a node token.  Xname defaults to the node owning the source
+// IP address.
+type NodeTokenRequest struct {
+	Xname         string `json:"xname,omitempty"`
+	ReferralToken string `json:"referral-token"`
+}
+
+// A short-lived bearer token for the node-side endpoints.
+type NodeToken struct {
+	Token   string   `json:"token"`
+	Expires int64    `json:"expires"`
+	Scope   []string `json:"scope"`
+}
+
+// The datastore namespaces BSS instances have used, and that of this one.
+type Namespaces struct {
+	Current string   `json:"current"`
+	Known   []string `json:"known"`
+}
+
+// Request to copy boot parameters between namespaces.  To defaults to the
+// namespace of the instance, no names copies every entry.
+type NamespaceCopy struct {
+	From   string   `json:"from"`
+	To     string   `json:"to,omitempty"`
+	Names  []string `json:"names,omitempty"`
+	DryRun bool     `json:"dry-run,omitempty"`
+}
+
+// Outcome of a NamespaceCopy: the entries copied and the number of images.
+type NamespaceCopyReport struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Copied []string `json:"copied"`
+	Images int      `json:"images"`
+	DryRun bool     `json:"dry-run,omitempty"`
+}
+
+// A hypothetical HSM state for /boot/v1/simulate: changed or new
+// components, and changes of HSM group membership.
+type SimulateRequest struct {
+	Components []SimulatedComponent `json:"components,omitempty"`
+	Groups     []SimulatedGroup     `json:"groups,omitempty"`
+}
+
+// A component as it would be in HSM.  Empty fields keep their current
+// value, Removed takes the component out of HSM.
+type SimulatedComponent struct {
+	ID      string `json:"id"`
+	Role    string `json:"role,omitempty"`
+	SubRole string `json:"subrole,omitempty"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+// Members added to and removed from an HSM group.
+type SimulatedGroup struct {
+	Group  string   `json:"group"`
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// A node whose effective boot configuration would change, with the fields
+// that differ: kernel, initrd, params or cloud-init.
+type SimulatedChange struct {
+	Name   string     `json:"name"`
+	Fields []string   `json:"fields"`
+	Before BootParams `json:"before"`
+	After  BootParams `json:"after"`
+}
+
+// Outcome of a simulation: the number of nodes checked and those that
+// would change.
+type SimulateReport struct {
+	Checked int               `json:"checked"`
+	Changed []SimulatedChange `json:"changed"`
+}
+
+// A NID that more than one HSM component claims.  Lookups by that NID
+// return Selected, the lowest of the component names.
+type DuplicateNid struct {
+	Nid        int64    `json:"nid"`
+	Components []string `json:"components"`
+	Selected   string   `json:"selected"`
+}
+
+// Request to render the boot scripts of a group ahead of a mass reboot,
+// see /boot/v1/warmup.  Window is in seconds.
+type WarmupRequest struct {
+	Group  string `json:"group"`
+	Window int    `json:"window,omitempty"`
+}
+
+// Outcome of a warm-up: the nodes of the group, the scripts and signed URLs
+// kept, and the nodes skipped with the reason.  Until is a Unix time.
+type WarmupReport struct {
+	Group      string            `json:"group"`
+	Nodes      int               `json:"nodes,omitempty"`
+	Scripts    int               `json:"scripts"`
+	SignedURLs int               `json:"signed-urls"`
+	Until      int64             `json:"until,omitempty"`
+	Skipped    map[string]string `json:"skipped,omitempty"`
+}
+
+// A change of an image URI.
+type URIChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// What a change did to the effective boot configuration of a node or tag:
+// the kernel command line arguments added and removed, the kernel and initrd
+// URIs that changed, and whether the cloud-init data changed.
+type BootParamsDiff struct {
+	Added     []string   `json:"added-params,omitempty"`
+	Removed   []string   `json:"removed-params,omitempty"`
+	Kernel    *URIChange `json:"kernel,omitempty"`
+	Initrd    *URIChange `json:"initrd,omitempty"`
+	CloudInit bool       `json:"cloud-init,omitempty"`
+}
+
+// The answer to PUT and PATCH of /bootparameters for each name changed.
+type AppliedBootParams struct {
+	Name      string         `json:"name"`
+	Effective BootParams     `json:"effective"`
+	Diff      BootParamsDiff `json:"diff"`
+}
+
+// A node booting with the Default tag because it has no boot parameters of
+// its own, see /boot/v1/fallback.  First and Last are the Unix times of the
+// first and latest fallback boot; Flagged is set once Boots reaches the
+// configured limit.
+type FallbackNode struct {
+	Name    string `json:"name"`
+	Boots   int    `json:"boots"`
+	First   int64  `json:"first"`
+	Last    int64  `json:"last"`
+	Flagged bool   `json:"flagged"`
+}
+
+// A boot parameter entry renamed from a MAC address key that is not lower
+// case, see /boot/v1/service/mac-keys.
+type MACKeyRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Outcome of the MAC key migration: the entries renamed, and those left
+// alone because the lower case key already has boot parameters.
+type MACKeyReport struct {
+	Renamed   []MACKeyRename `json:"renamed"`
+	Conflicts []MACKeyRename `json:"conflicts"`
+}
+
+// Request to merge the boot parameter entries of a node stored under its
+// xname, MAC addresses and NIDs, see /boot/v1/nodes/merge.  Without a node
+// every node with duplicate entries is merged.  Winner names the entry to
+// keep; by default it is the most recently updated one.
+type NodeMergeRequest struct {
+	Node   string `json:"node,omitempty"`
+	Winner string `json:"winner,omitempty"`
+	DryRun bool   `json:"dry-run,omitempty"`
+}
+
+// The merge of one node: the entry kept under the xname, the entries
+// removed, and what was moved from them, as "<kind>:<name>".
+type NodeMerge struct {
+	Node    string   `json:"node"`
+	Winner  string   `json:"winner"`
+	Removed []string `json:"removed"`
+	Moved   []string `json:"moved,omitempty"`
+}
+
+type NodeMergeReport struct {
+	DryRun bool        `json:"dry-run,omitempty"`
+	Merges []NodeMerge `json:"merges"`
+}
+
+// A node whose MAC lookups use the HSM fixture rather than the live HSM,
+// see /boot/v1/service/hsm-test-nodes.  Component is the xname the fixture
+// has for the MAC; AddedAt is a Unix time.
+type HSMTestNode struct {
+	MAC       string `json:"mac"`
+	Source    string `json:"source,omitempty"`
+	Component string `json:"component,omitempty"`
+	AddedBy   string `json:"added-by,omitempty"`
+	AddedAt   int64  `json:"added-at,omitempty"`
+}
+
+// An approved kernel or initrd image, see /boot/v1/approved-images.  It is
+// either a URI or a digest, sha256:<hex> or etag:<etag>; ApprovedAt is a
+// Unix time.
+type ApprovedImage struct {
+	URI        string `json:"uri,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	ApprovedBy string `json:"approved-by,omitempty"`
+	ApprovedAt int64  `json:"approved-at,omitempty"`
+}
+
+// A stored image that is not approved.
+type UnapprovedImage struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// A rack-local artifact cache, see /boot/v1/artifact-mirrors.  Nodes
+// booting from an address in Subnet (a CIDR) fetch their kernel, initrd and
+// rootfs from BaseURL instead of the host in the image URL.
+type ArtifactMirror struct {
+	Subnet    string `json:"subnet"`
+	BaseURL   string `json:"base-url"`
+	Comment   string `json:"comment,omitempty"`
+	UpdatedBy string `json:"updated-by,omitempty"`
+	UpdatedAt int64  `json:"updated-at,omitempty"`
+}
+
+// A change to a tag that needs approval, see /boot/v1/proposals.  Method
+// is the bootparameters request staged: PUT, POST, PATCH or DELETE.
+type Proposal struct {
+	ID         string     `json:"id"`
+	Method     string     `json:"method"`
+	Params     BootParams `json:"params"`
+	Cascade    string     `json:"cascade,omitempty"`
+	ProposedBy string     `json:"proposed-by"`
+	ProposedAt int64      `json:"proposed-at"`
+}
+
+// A boot parameters entry that cannot be replaced or deleted without the
+// override header.  Config entries come from BSS_PROTECTED and cannot be
+// removed through the API.
+type ProtectedEntry struct {
+	Name        string `json:"name"`
+	Reason      string `json:"reason,omitempty"`
+	ProtectedBy string `json:"protected-by,omitempty"`
+	ProtectedAt int64  `json:"protected-at,omitempty"`
+	Config      bool   `json:"config,omitempty"`
+}
+
+// A BootDependency makes the nodes in Group wait at boot until the nodes in
+// each of the After groups have booted and phoned home.
+type BootDependency struct {
+	Group string   `json:"group"`
+	After []string `json:"after"`
+}
@@ -0,0 +1,135 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+// Package idgen generates the IDs BSS hands out: random UUIDs, as used for
+// referral tokens, and ULIDs where IDs should sort by creation time.  Both
+// kinds can be told apart and parsed, so existing UUIDs stay valid.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// New returns a random (version 4) UUID.
+func New() string {
+	return uuid.New().String()
+}
+
+// Crockford's base32, as ULIDs use.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ulidMutex sync.Mutex
+var lastULID [16]byte
+
+// NewULID returns a ULID: 26 characters which sort by creation time, to the
+// millisecond and within a millisecond by order of creation.
+func NewULID() string {
+	return newULID(time.Now())
+}
+
+func newULID(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+
+	ulidMutex.Lock()
+	defer ulidMutex.Unlock()
+	if string(id[:6]) == string(lastULID[:6]) {
+		// Same millisecond: increment the random part of the last one.
+		id = lastULID
+		for i := 15; i >= 6; i-- {
+			id[i]++
+			if id[i] != 0 {
+				break
+			}
+		}
+	} else if _, err := rand.Read(id[6:]); err != nil {
+		panic(fmt.Sprintf("idgen: no randomness: %s", err))
+	}
+	lastULID = id
+	return encodeULID(id)
+}
+
+// ULIDAt returns the smallest ULID of the millisecond of t, so that ULID
+// keys can be read by time range.
+func ULIDAt(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	return encodeULID(id)
+}
+
+func encodeULID(id [16]byte) string {
+	// 128 bits as 26 5-bit digits, the first one holding only 3 bits.
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// ULIDTime returns the creation time of a ULID.
+func ULIDTime(id string) (time.Time, error) {
+	if !IsULID(id) {
+		return time.Time{}, fmt.Errorf("Not a ULID: '%s'", id)
+	}
+	var ms uint64
+	for _, c := range strings.ToUpper(id[:10]) {
+		ms = ms<<5 | uint64(strings.IndexRune(crockford, c))
+	}
+	return time.UnixMilli(int64(ms)), nil
+}
+
+// IsULID tells whether id is a ULID.
+func IsULID(id string) bool {
+	if len(id) != 26 || id[0] > '7' {
+		return false
+	}
+	for _, c := range strings.ToUpper(id) {
+		if !strings.ContainsRune(crockford, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Valid tells whether id is a UUID or a ULID.
+func Valid(id string) bool {
+	if IsULID(id) {
+		return true
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
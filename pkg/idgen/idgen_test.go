@@ -0,0 +1,66 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package idgen
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	id := New()
+	if len(id) != 36 || !Valid(id) || IsULID(id) {
+		t.Errorf("New() returned '%s'", id)
+	}
+}
+
+func TestULID(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	var ids []string
+	for i := 0; i < 100; i++ {
+		ids = append(ids, newULID(now))
+	}
+	ids = append(ids, newULID(now.Add(time.Millisecond)))
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("ULIDs do not sort by creation")
+	}
+	for _, id := range ids {
+		if !IsULID(id) || !Valid(id) {
+			t.Fatalf("'%s' is not a valid ULID", id)
+		}
+	}
+	if got, err := ULIDTime(ids[0]); err != nil || !got.Equal(now) {
+		t.Errorf("ULIDTime() returned %v, %v, expected %v", got, err, now)
+	}
+	if at := ULIDAt(now); at > ids[0] || ULIDAt(now.Add(time.Millisecond)) > ids[100] || at < ULIDAt(now.Add(-time.Millisecond)) {
+		t.Errorf("ULIDAt() of %v returned %s, out of order with %s", now, at, ids[0])
+	}
+	// The ULID spec example.
+	if got, _ := ULIDTime("01ARZ3NDEKTSV4RRFFQ69G5FAV"); got.UnixMilli() != 1469922850259 {
+		t.Errorf("ULIDTime() of the spec example returned %d", got.UnixMilli())
+	}
+	if Valid("not-an-id") {
+		t.Errorf("Valid() accepted garbage")
+	}
+}